@@ -0,0 +1,79 @@
+// Command networkpolicy generates a least-privilege NetworkPolicy, and optionally a CiliumNetworkPolicy,
+// from a completed NetworkNeighborhood. The policies are printed as YAML and the neighbors they cannot
+// express are flagged on stderr.
+//
+//	kubectl get networkneighborhood -n default deployment-nginx -o yaml | networkpolicy -neighborhood - [-cilium]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"node-agent/pkg/networkpolicy"
+
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+func main() {
+	neighborhood := flag.String("neighborhood", "", "path of the YAML or JSON network neighborhood, - reads it from stdin")
+	cilium := flag.Bool("cilium", false, "also generate a CiliumNetworkPolicy with FQDN rules from the DNS names")
+	allowIncomplete := flag.Bool("allow-incomplete", false, "generate the policies of a network neighborhood that is still learning")
+	strict := flag.Bool("strict", false, "exit with an error when a neighbor cannot be expressed")
+	flag.Parse()
+
+	if err := run(*neighborhood, networkpolicy.Options{Cilium: *cilium, AllowIncomplete: *allowIncomplete}, *strict); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(path string, opts networkpolicy.Options, strict bool) error {
+	if path == "" {
+		return fmt.Errorf("missing -neighborhood")
+	}
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	var nn v1beta1.NetworkNeighborhood
+	if err := yaml.Unmarshal(data, &nn); err != nil {
+		return fmt.Errorf("parsing network neighborhood: %w", err)
+	}
+
+	policies, err := networkpolicy.Generate(&nn, opts)
+	if err != nil {
+		return err
+	}
+	for _, warning := range policies.Warnings {
+		fmt.Fprintln(os.Stderr, "warning:", warning)
+	}
+
+	objects := []interface{}{policies.NetworkPolicy}
+	if policies.CiliumNetworkPolicy != nil {
+		objects = append(objects, policies.CiliumNetworkPolicy)
+	}
+	for i, object := range objects {
+		out, err := yaml.Marshal(object)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Println("---")
+		}
+		fmt.Print(string(out))
+	}
+
+	if strict && len(policies.Warnings) > 0 {
+		return fmt.Errorf("%d neighbors cannot be expressed", len(policies.Warnings))
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+// Command replay evaluates the rules on a recording of the events of the tracers, without a cluster,
+// and prints the alerts as JSON lines in a deterministic order. The custom rules are RuntimeRule YAML files, and the
+// application profiles learned from the recording by the application profile manager can be written as JSON.
+//
+//	replay -recording events.jsonl.gz [-rules R0001,R1001] [-custom-rules rules.yaml] [-profile profile.json]
+//		[-network-neighborhood nn.json] [-learned-profiles profiles.json]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"node-agent/pkg/config"
+	"node-agent/pkg/eventrecorder"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"node-agent/pkg/utils"
+
+	typesv1 "node-agent/pkg/rulebindingmanager/types/v1"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// learnerConfig learns the profiles with the defaults of the node agent, the profiles are saved once the replay ends
+var learnerConfig = config.Config{
+	InitialDelay:              24 * time.Hour,
+	UpdateDataPeriod:          24 * time.Hour,
+	ProfileRelearnGracePeriod: 30 * time.Minute,
+	OpenPathCollapseThreshold: 50,
+}
+
+// alert is the printed form of an alert
+type alert struct {
+	BaseRuntimeAlert       apitypes.BaseRuntimeAlert       `json:"baseRuntimeAlert"`
+	RuleAlert              apitypes.RuleAlert              `json:"ruleAlert"`
+	RuntimeProcessDetails  apitypes.ProcessTree            `json:"runtimeProcessDetails"`
+	RuntimeAlertK8sDetails apitypes.RuntimeAlertK8sDetails `json:"runtimeAlertK8sDetails"`
+}
+
+func main() {
+	recording := flag.String("recording", "", "path of the recording to replay")
+	ruleIDs := flag.String("rules", "", "comma separated IDs or names of the evaluated rules, all the rules are evaluated when empty")
+	customRules := flag.String("custom-rules", "", "comma separated paths of YAML files of RuntimeRules, evaluated with the other rules")
+	profile := flag.String("profile", "", "path of the JSON application profile of the replayed containers")
+	networkNeighborhood := flag.String("network-neighborhood", "", "path of the JSON network neighborhood of the replayed containers")
+	learnedProfiles := flag.String("learned-profiles", "", "path of the JSON application profiles learned from the recording")
+	flag.Parse()
+
+	if err := run(*recording, *ruleIDs, *customRules, *profile, *networkNeighborhood, *learnedProfiles); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(recording, ruleIDs, customRulePaths, profilePath, networkNeighborhoodPath, learnedProfilesPath string) error {
+	if recording == "" {
+		return fmt.Errorf("missing -recording")
+	}
+	customRules, err := readCustomRules(customRulePaths)
+	if err != nil {
+		return err
+	}
+	rules, err := createRules(ruleIDs, customRules)
+	if err != nil {
+		return err
+	}
+
+	objectCache := &ruleenginev1.RuleObjectCacheMock{}
+	if profilePath != "" {
+		var profile v1beta1.ApplicationProfile
+		if err := readJSON(profilePath, &profile); err != nil {
+			return err
+		}
+		objectCache.SetApplicationProfile(&profile)
+	}
+	if networkNeighborhoodPath != "" {
+		var nn v1beta1.NetworkNeighborhood
+		if err := readJSON(networkNeighborhoodPath, &nn); err != nil {
+			return err
+		}
+		objectCache.SetNetworkNeighborhood(&nn)
+	}
+
+	collector := &eventrecorder.AlertCollector{}
+	ruleManager, err := eventrecorder.NewReplayRuleManager(context.Background(), rules, objectCache, collector)
+	if err != nil {
+		return fmt.Errorf("creating rule manager: %w", err)
+	}
+	learner, err := eventrecorder.NewProfileLearner(context.Background(), learnerConfig)
+	if err != nil {
+		return fmt.Errorf("creating profile learner: %w", err)
+	}
+	file, err := os.Open(recording)
+	if err != nil {
+		return fmt.Errorf("opening recording: %w", err)
+	}
+	defer file.Close()
+	if err := eventrecorder.ReadRecording(file, func(_ utils.EventType, event interface{}) error {
+		learner.Observe(event)
+		eventrecorder.Replay(event, ruleManager, learner.Manager())
+		return nil
+	}); err != nil {
+		return err
+	}
+	profiles := learner.Profiles()
+	if learnedProfilesPath != "" {
+		data, err := json.MarshalIndent(profiles, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(learnedProfilesPath, data, 0644); err != nil {
+			return fmt.Errorf("writing learned profiles: %w", err)
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, ruleAlert := range collector.RuleAlerts {
+		if err := encoder.Encode(alert{
+			BaseRuntimeAlert:       ruleAlert.GetBaseRuntimeAlert(),
+			RuleAlert:              ruleAlert.GetRuleAlert(),
+			RuntimeProcessDetails:  ruleAlert.GetRuntimeProcessDetails(),
+			RuntimeAlertK8sDetails: ruleAlert.GetRuntimeAlertK8sDetails(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createRules creates the rules by ID or name, among the built-in and the custom rules, or all the rules
+func createRules(ruleIDs string, customRules []ruleenginev1.RuleDescriptor) ([]ruleengine.RuleEvaluator, error) {
+	ruleCreator := ruleenginev1.NewRuleCreator()
+	var rules []ruleengine.RuleEvaluator
+	if ruleIDs == "" {
+		for _, descriptor := range append(ruleCreator.GetAllRuleDescriptors(), customRules...) {
+			rules = append(rules, descriptor.CreateRule())
+		}
+		return rules, nil
+	}
+	for _, id := range strings.Split(ruleIDs, ",") {
+		id = strings.TrimSpace(id)
+		rule := ruleCreator.CreateRuleByID(id)
+		if rule == nil {
+			rule = ruleCreator.CreateRuleByName(id)
+		}
+		for i := 0; rule == nil && i < len(customRules); i++ {
+			if customRules[i].ID == id || customRules[i].Name == id {
+				rule = customRules[i].CreateRule()
+			}
+		}
+		if rule == nil {
+			return nil, fmt.Errorf("unknown rule %q", id)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// readCustomRules compiles the RuntimeRules of comma separated YAML files
+func readCustomRules(paths string) ([]ruleenginev1.RuleDescriptor, error) {
+	if paths == "" {
+		return nil, nil
+	}
+	var descriptors []ruleenginev1.RuleDescriptor
+	for _, path := range strings.Split(paths, ",") {
+		fileDescriptors, err := readCustomRulesFile(strings.TrimSpace(path))
+		if err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, fileDescriptors...)
+	}
+	return descriptors, nil
+}
+
+// readCustomRulesFile compiles the RuntimeRules of a YAML file, the rules are separated by ---
+func readCustomRulesFile(path string) ([]ruleenginev1.RuleDescriptor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var descriptors []ruleenginev1.RuleDescriptor
+	decoder := k8syaml.NewYAMLOrJSONDecoder(file, 4096)
+	for {
+		var rule typesv1.RuntimeRule
+		if err := decoder.Decode(&rule); err != nil {
+			if errors.Is(err, io.EOF) {
+				return descriptors, nil
+			}
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if rule.Kind == "" && rule.Spec.ID == "" {
+			// empty document
+			continue
+		}
+		descriptor, err := ruleenginev1.CreateCELRuleDescriptor(ruleenginev1.CELRuleSpecFromRuntimeRule(rule.Spec))
+		if err != nil {
+			return nil, fmt.Errorf("compiling runtime rule %s of %s: %w", rule.Name, path, err)
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
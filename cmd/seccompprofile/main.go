@@ -0,0 +1,114 @@
+// Command seccompprofile generates the seccomp profiles of the containers of a completed ApplicationProfile,
+// allowing the syscalls they were seen calling. The profiles are printed as JSON, written to a directory, or
+// printed as SeccompProfile custom resources of the security-profiles-operator.
+//
+//	kubectl get applicationprofile -n default deployment-nginx -o yaml | seccompprofile -profile - [-container nginx] [-output dir] [-crd]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"node-agent/pkg/seccompprofile"
+
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+func main() {
+	profile := flag.String("profile", "", "path of the YAML or JSON application profile, - reads it from stdin")
+	container := flag.String("container", "", "name of the container whose profile is generated, all the containers when empty")
+	archs := flag.String("arch", "", "comma separated Go architectures of the nodes, a profile is generated per architecture, the architectures the syscalls were learned on when empty")
+	output := flag.String("output", "", "directory the profiles are written to, as <application profile>-<container>[-<arch>].json")
+	crd := flag.Bool("crd", false, "print the profiles as SeccompProfile custom resources of the security-profiles-operator")
+	allowIncomplete := flag.Bool("allow-incomplete", false, "generate the profiles of an application profile that is still learning")
+	flag.Parse()
+
+	opts := seccompprofile.Options{AllowIncomplete: *allowIncomplete}
+	if *archs != "" {
+		opts.Architectures = strings.Split(*archs, ",")
+	}
+	if err := run(*profile, *container, *output, *crd, opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(path, containerName, output string, crd bool, opts seccompprofile.Options) error {
+	if path == "" {
+		return fmt.Errorf("missing -profile")
+	}
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	var ap v1beta1.ApplicationProfile
+	if err := yaml.Unmarshal(data, &ap); err != nil {
+		return fmt.Errorf("parsing application profile: %w", err)
+	}
+
+	profiles, err := seccompprofile.Generate(&ap, opts)
+	if err != nil {
+		return err
+	}
+	for _, warning := range profiles.Warnings {
+		fmt.Fprintln(os.Stderr, "warning:", warning)
+	}
+	containers := profiles.Containers
+	if containerName != "" {
+		containers = nil
+		for _, container := range profiles.Containers {
+			if container.Name == containerName {
+				containers = append(containers, container)
+			}
+		}
+	}
+	if len(containers) == 0 {
+		return fmt.Errorf("no container profile to generate")
+	}
+
+	switch {
+	case output != "":
+		for _, container := range containers {
+			out, err := json.MarshalIndent(container.Seccomp, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(output, container.ProfileName+".json"), append(out, '\n'), 0644); err != nil {
+				return err
+			}
+		}
+	case crd:
+		for i, container := range containers {
+			out, err := yaml.Marshal(seccompprofile.SeccompProfileCR(&ap, container))
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				fmt.Println("---")
+			}
+			fmt.Print(string(out))
+		}
+	default:
+		if len(containers) > 1 {
+			return fmt.Errorf("%d profiles are generated, select one with -container and -arch or write them with -output", len(containers))
+		}
+		out, err := json.MarshalIndent(containers[0].Seccomp, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	}
+	return nil
+}
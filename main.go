@@ -28,6 +28,8 @@ import (
 	objectcachev1 "node-agent/pkg/objectcache/v1"
 	"node-agent/pkg/relevancymanager"
 	relevancymanagerv1 "node-agent/pkg/relevancymanager/v1"
+	"node-agent/pkg/responsemanager"
+	responsemanagerv1 "node-agent/pkg/responsemanager/v1"
 	rulebinding "node-agent/pkg/rulebindingmanager"
 	rulebindingcachev1 "node-agent/pkg/rulebindingmanager/cache"
 	"node-agent/pkg/rulemanager"
@@ -129,7 +131,7 @@ func main() {
 	// Create the application profile manager
 	var applicationProfileManager applicationprofilemanager.ApplicationProfileManagerClient
 	if cfg.EnableApplicationProfile {
-		applicationProfileManager, err = applicationprofilemanagerv1.CreateApplicationProfileManager(ctx, cfg, clusterData.ClusterName, k8sClient, storageClient, preRunningContainersIDs, k8sObjectCache)
+		applicationProfileManager, err = applicationprofilemanagerv1.CreateApplicationProfileManager(ctx, cfg, clusterData.ClusterName, nodeName, k8sClient, storageClient, preRunningContainersIDs, k8sObjectCache)
 		if err != nil {
 			logger.L().Ctx(ctx).Fatal("error creating the application profile manager", helpers.Error(err))
 		}
@@ -154,6 +156,23 @@ func main() {
 		relevancyManager = relevancymanager.CreateRelevancyManagerMock()
 	}
 
+	// create exporter, shared by the rule and malware managers
+	var exporter *exporters.ExporterBus
+	if cfg.EnableRuntimeDetection || cfg.EnableMalwareDetection {
+		exporter = exporters.InitExporters(cfg.Exporters, clusterData.ClusterName, nodeName, prometheusExporter)
+	}
+
+	// create response manager, shared by the rule and malware managers
+	var responseManager responsemanager.ResponseManagerClient
+	if cfg.Response.Enabled && (cfg.EnableRuntimeDetection || cfg.EnableMalwareDetection) {
+		responseManager, err = responsemanagerv1.CreateResponseManager(ctx, cfg.Response, exporter)
+		if err != nil {
+			logger.L().Ctx(ctx).Fatal("error creating ResponseManager", helpers.Error(err))
+		}
+	} else {
+		responseManager = responsemanager.CreateResponseManagerMock()
+	}
+
 	var ruleManager rulemanager.RuleManagerClient
 	var objCache objectcache.ObjectCache
 	var ruleBindingNotify chan rulebinding.RuleBindingNotify
@@ -167,7 +186,7 @@ func main() {
 		ruleBindingNotify = make(chan rulebinding.RuleBindingNotify, 100)
 		ruleBindingCache.AddNotifier(&ruleBindingNotify)
 
-		apc := applicationprofilecache.NewApplicationProfileCache(nodeName, k8sClient)
+		apc := applicationprofilecache.NewApplicationProfileCache(nodeName, k8sClient, cfg.ProfileRelearnGracePeriod)
 		dWatcher.AddAdaptor(apc)
 
 		nnc := networkneighborhoodcache.NewNetworkNeighborhoodCache(nodeName, k8sClient)
@@ -176,11 +195,17 @@ func main() {
 		// create object cache
 		objCache = objectcachev1.NewObjectCache(k8sObjectCache, apc, nnc)
 
-		// create exporter
-		exporter := exporters.InitExporters(cfg.Exporters, clusterData.ClusterName, nodeName)
+		// report the drift of the profiles of the node through the exporters
+		if cfg.Exporters.DriftReport != nil {
+			driftReporter, err := exporters.CreateDriftReporter(*cfg.Exporters.DriftReport, exporter, apc, nnc, clusterData.ClusterName, nodeName)
+			if err != nil {
+				logger.L().Ctx(ctx).Fatal("error creating the drift reporter", helpers.Error(err))
+			}
+			driftReporter.Start(ctx)
+		}
 
 		// create runtimeDetection managers
-		ruleManager, err = rulemanagerv1.CreateRuleManager(ctx, cfg, k8sClient, ruleBindingCache, objCache, exporter, prometheusExporter, preRunningContainersIDs, nodeName, clusterData.ClusterName)
+		ruleManager, err = rulemanagerv1.CreateRuleManager(ctx, cfg, k8sClient, ruleBindingCache, objCache, exporter, prometheusExporter, preRunningContainersIDs, nodeName, clusterData.ClusterName, responseManager)
 		if err != nil {
 			logger.L().Ctx(ctx).Fatal("error creating RuleManager", helpers.Error(err))
 		}
@@ -193,9 +218,7 @@ func main() {
 
 	var malwareManager malwaremanager.MalwareManagerClient
 	if cfg.EnableMalwareDetection {
-		// create exporter
-		exporter := exporters.InitExporters(cfg.Exporters, clusterData.ClusterName, nodeName)
-		malwareManager, err = malwaremanagerv1.CreateMalwareManager(cfg, k8sClient, nodeName, clusterData.ClusterName, exporter, prometheusExporter)
+		malwareManager, err = malwaremanagerv1.CreateMalwareManager(cfg, k8sClient, nodeName, clusterData.ClusterName, exporter, prometheusExporter, responseManager)
 		if err != nil {
 			logger.L().Ctx(ctx).Fatal("error creating MalwareManager", helpers.Error(err))
 		}
@@ -211,7 +234,7 @@ func main() {
 		dnsManager := dnsmanager.CreateDNSManager()
 		dnsManagerClient = dnsManager
 		networkManagerv1Client = networkmanagerv1.CreateNetworkManager(ctx, cfg, k8sClient, storageClient, clusterData.ClusterName, dnsManager, preRunningContainersIDs, k8sObjectCache)
-		networkManagerClient = networkmanagerv2.CreateNetworkManager(ctx, cfg, clusterData.ClusterName, k8sClient, storageClient, dnsManager, preRunningContainersIDs, k8sObjectCache)
+		networkManagerClient = networkmanagerv2.CreateNetworkManager(ctx, cfg, clusterData.ClusterName, nodeName, k8sClient, storageClient, dnsManager, preRunningContainersIDs, k8sObjectCache)
 	} else {
 		networkManagerClient = networkmanager.CreateNetworkManagerMock()
 		dnsManagerClient = dnsmanager.CreateDNSManagerMock()
@@ -250,6 +273,11 @@ func main() {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 	<-shutdown
 
+	malwareManager.Stop()
+	if exporter != nil {
+		exporter.Close()
+	}
+
 	// Exit with success
 	os.Exit(utils.ExitCodeSuccess)
 }
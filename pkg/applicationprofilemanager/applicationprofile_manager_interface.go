@@ -6,6 +6,9 @@ type ApplicationProfileManagerClient interface {
 	ContainerCallback(notif containercollection.PubSubEvent)
 	RegisterPeekFunc(peek func(mntns uint64) ([]string, error))
 	ReportCapability(k8sContainerID, capability string)
+	ReportCredentialTransition(k8sContainerID, transition string)
+	ReportBPFUsage(k8sContainerID, usage string)
+	ReportFileModification(k8sContainerID, modification string)
 	ReportFileExec(k8sContainerID, path string, args []string)
 	ReportFileOpen(k8sContainerID, path string, flags []string)
 	ReportDroppedEvent(k8sContainerID string)
@@ -23,6 +23,18 @@ func (a ApplicationProfileManagerMock) ReportCapability(_, _ string) {
 	// noop
 }
 
+func (a ApplicationProfileManagerMock) ReportCredentialTransition(_, _ string) {
+	// noop
+}
+
+func (a ApplicationProfileManagerMock) ReportBPFUsage(_, _ string) {
+	// noop
+}
+
+func (a ApplicationProfileManagerMock) ReportFileModification(_, _ string) {
+	// noop
+}
+
 func (a ApplicationProfileManagerMock) ReportFileExec(_, _ string, _ []string) {
 	// noop
 }
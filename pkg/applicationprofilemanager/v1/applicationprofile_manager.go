@@ -11,6 +11,8 @@ import (
 	"node-agent/pkg/objectcache"
 	"node-agent/pkg/storage"
 	"node-agent/pkg/utils"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -35,22 +37,36 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// maxFileModifications is the maximum number of file modifications recorded in the profile of a container
+const maxFileModifications = 500
+
 type ApplicationProfileManager struct {
 	cfg                      config.Config
 	clusterName              string
+	nodeName                 string // recorded on the saved profiles, the drift is reported from the node that last updated them
 	ctx                      context.Context
 	containerMutexes         storageUtils.MapMutex[string]                                   // key is k8sContainerID
 	trackedContainers        mapset.Set[string]                                              // key is k8sContainerID
 	removedContainers        mapset.Set[string]                                              // key is k8sContainerID
 	savedCapabilities        maps.SafeMap[string, mapset.Set[string]]                        // key is k8sContainerID
+	savedCredentials         maps.SafeMap[string, mapset.Set[string]]                        // key is k8sContainerID
+	savedBPF                 maps.SafeMap[string, mapset.Set[string]]                        // key is k8sContainerID
+	savedFileModifications   maps.SafeMap[string, mapset.Set[string]]                        // key is k8sContainerID
 	savedExecs               maps.SafeMap[string, *maps.SafeMap[string, []string]]           // key is k8sContainerID
 	droppedEvents            maps.SafeMap[string, bool]                                      // key is k8sContainerID
 	savedOpens               maps.SafeMap[string, *maps.SafeMap[string, mapset.Set[string]]] // key is k8sContainerID
 	savedSyscalls            maps.SafeMap[string, mapset.Set[string]]                        // key is k8sContainerID
 	toSaveCapabilities       maps.SafeMap[string, mapset.Set[string]]                        // key is k8sContainerID
+	toSaveCredentials        maps.SafeMap[string, mapset.Set[string]]                        // key is k8sContainerID
+	toSaveBPF                maps.SafeMap[string, mapset.Set[string]]                        // key is k8sContainerID
+	toSaveFileModifications  maps.SafeMap[string, mapset.Set[string]]                        // key is k8sContainerID
 	toSaveExecs              maps.SafeMap[string, *maps.SafeMap[string, []string]]           // key is k8sContainerID
 	toSaveOpens              maps.SafeMap[string, *maps.SafeMap[string, mapset.Set[string]]] // key is k8sContainerID
+	pathNormalizers          maps.SafeMap[string, *utils.PathNormalizer]                     // key is k8sContainerID
+	learningContainers       mapset.Set[string]                                              // key is k8sContainerID
+	relearnStarts            maps.SafeMap[string, time.Time]                                 // key is k8sContainerID
 	watchedContainerChannels maps.SafeMap[string, chan error]                                // key is ContainerID
+	monitors                 sync.WaitGroup                                                  // the running startApplicationProfiling
 	k8sClient                k8sclient.K8sClientInterface
 	k8sObjectCache           objectcache.K8sObjectCache
 	storageClient            storage.StorageClient
@@ -60,10 +76,11 @@ type ApplicationProfileManager struct {
 
 var _ applicationprofilemanager.ApplicationProfileManagerClient = (*ApplicationProfileManager)(nil)
 
-func CreateApplicationProfileManager(ctx context.Context, cfg config.Config, clusterName string, k8sClient k8sclient.K8sClientInterface, storageClient storage.StorageClient, preRunningContainerIDs mapset.Set[string], k8sObjectCache objectcache.K8sObjectCache) (*ApplicationProfileManager, error) {
+func CreateApplicationProfileManager(ctx context.Context, cfg config.Config, clusterName, nodeName string, k8sClient k8sclient.K8sClientInterface, storageClient storage.StorageClient, preRunningContainerIDs mapset.Set[string], k8sObjectCache objectcache.K8sObjectCache) (*ApplicationProfileManager, error) {
 	return &ApplicationProfileManager{
 		cfg:                    cfg,
 		clusterName:            clusterName,
+		nodeName:               nodeName,
 		ctx:                    ctx,
 		k8sClient:              k8sClient,
 		k8sObjectCache:         k8sObjectCache,
@@ -71,6 +88,7 @@ func CreateApplicationProfileManager(ctx context.Context, cfg config.Config, clu
 		containerMutexes:       storageUtils.NewMapMutex[string](),
 		trackedContainers:      mapset.NewSet[string](),
 		removedContainers:      mapset.NewSet[string](),
+		learningContainers:     mapset.NewSet[string](),
 		preRunningContainerIDs: preRunningContainerIDs,
 	}, nil
 }
@@ -87,6 +105,8 @@ func (am *ApplicationProfileManager) ensureInstanceID(container *containercollec
 
 	// get pod template hash
 	watchedContainer.TemplateHash, _ = pod.GetLabel("pod-template-hash")
+	// get the revision of the workload, the profile is relearned when it changes
+	watchedContainer.Revision = utils.GetRevision(pod.GetLabels())
 
 	// find parentWlid
 	kind, name, err := am.k8sClient.CalculateWorkloadParentRecursive(pod)
@@ -104,21 +124,35 @@ func (am *ApplicationProfileManager) ensureInstanceID(container *containercollec
 		return fmt.Errorf("failed to validate WLID: %w", err)
 	}
 	watchedContainer.ParentResourceVersion = w.GetResourceVersion()
+	// each new relearn annotation of the workload relearns the profile
+	watchedContainer.RelearnRequest = w.GetAnnotations()[utils.RelearnMetadataKey]
 	// find instanceID
 	instanceIDs, err := instanceidhandler.GenerateInstanceID(pod)
 	if err != nil {
 		return fmt.Errorf("failed to generate instanceID: %w", err)
 	}
-	watchedContainer.InstanceID = instanceIDs[0]
+	instanceID := instanceIDs[0]
 	for i := range instanceIDs {
 		if instanceIDs[i].GetContainerName() == container.K8s.ContainerName {
-			watchedContainer.InstanceID = instanceIDs[i]
+			instanceID = instanceIDs[i]
 		}
 	}
 	// fill container type, index and names
 	if watchedContainer.ContainerType == utils.Unknown {
 		watchedContainer.SetContainerInfo(pod, container.K8s.ContainerName)
 	}
+	// the image ID comes from the pod status, like the image IDs the application profile cache compares to the profile
+	podStatus, err := pod.GetPodStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get pod status: %w", err)
+	}
+	imageID, ok := utils.GetContainerImageID(podStatus, watchedContainer.ContainerType, container.K8s.ContainerName)
+	if !ok {
+		return fmt.Errorf("container %s has no status yet", container.K8s.ContainerName)
+	}
+	watchedContainer.ImageID = imageID
+	// the instanceID is set last, once all the container info is found
+	watchedContainer.InstanceID = instanceID
 	return nil
 }
 
@@ -131,13 +165,22 @@ func (am *ApplicationProfileManager) deleteResources(watchedContainer *utils.Wat
 	watchedContainer.UpdateDataTicker.Stop()
 	am.trackedContainers.Remove(watchedContainer.K8sContainerID)
 	am.savedCapabilities.Delete(watchedContainer.K8sContainerID)
+	am.savedCredentials.Delete(watchedContainer.K8sContainerID)
+	am.savedBPF.Delete(watchedContainer.K8sContainerID)
+	am.savedFileModifications.Delete(watchedContainer.K8sContainerID)
 	am.savedExecs.Delete(watchedContainer.K8sContainerID)
 	am.droppedEvents.Delete(watchedContainer.K8sContainerID)
 	am.savedOpens.Delete(watchedContainer.K8sContainerID)
 	am.savedSyscalls.Delete(watchedContainer.K8sContainerID)
 	am.toSaveCapabilities.Delete(watchedContainer.K8sContainerID)
+	am.toSaveCredentials.Delete(watchedContainer.K8sContainerID)
+	am.toSaveBPF.Delete(watchedContainer.K8sContainerID)
+	am.toSaveFileModifications.Delete(watchedContainer.K8sContainerID)
 	am.toSaveExecs.Delete(watchedContainer.K8sContainerID)
 	am.toSaveOpens.Delete(watchedContainer.K8sContainerID)
+	am.pathNormalizers.Delete(watchedContainer.K8sContainerID)
+	am.learningContainers.Remove(watchedContainer.K8sContainerID)
+	am.relearnStarts.Delete(watchedContainer.K8sContainerID)
 	am.watchedContainerChannels.Delete(watchedContainer.ContainerID)
 }
 func (am *ApplicationProfileManager) ContainerReachedMaxTime(containerID string) {
@@ -160,6 +203,9 @@ func (am *ApplicationProfileManager) monitorContainer(ctx context.Context, conta
 		watchedContainer.SetCompletionStatus(utils.WatchedContainerCompletionStatusFull)
 	}
 	watchedContainer.SetStatus(utils.WatchedContainerStatusInitializing)
+	if err := am.checkProfileMode(ctx, watchedContainer, container.K8s.Namespace); err != nil {
+		return err
+	}
 	am.saveProfile(ctx, watchedContainer, container.K8s.Namespace)
 
 	for {
@@ -197,6 +243,103 @@ func (am *ApplicationProfileManager) monitorContainer(ctx context.Context, conta
 	}
 }
 
+// checkProfileMode starts relearning the existing profile of a container of a new revision or image of the workload, or
+// on a new relearn request, and records the revision and image the container learns the profile on. The containers of
+// a locked profile are not monitored.
+func (am *ApplicationProfileManager) checkProfileMode(ctx context.Context, watchedContainer *utils.WatchedContainerData, namespace string) error {
+	if watchedContainer.InstanceID == nil {
+		return nil
+	}
+	slug, err := names.InstanceIDToSlug(watchedContainer.InstanceID.GetName(), watchedContainer.InstanceID.GetKind(), "", watchedContainer.InstanceID.GetHashed())
+	if err != nil {
+		return nil
+	}
+	existingObject, err := am.storageClient.GetApplicationProfile(namespace, slug)
+	if err != nil {
+		// new profile, it is created in learning mode by saveProfile
+		am.learningContainers.Add(watchedContainer.K8sContainerID)
+		return nil
+	}
+
+	now := time.Now()
+	annotations := existingObject.Annotations
+	mode := utils.GetProfileMode(existingObject, now, am.cfg.ProfileRelearnGracePeriod)
+	imageID := utils.ImageDigest(watchedContainer.ImageID)
+	relearnRequested := watchedContainer.RelearnRequest != "" && watchedContainer.RelearnRequest != annotations[utils.ProfileRelearnRequestMetadataKey]
+	drift := utils.ProfileDrift(existingObject, watchedContainer.Revision, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex, imageID)
+
+	var operations []utils.PatchOperation
+	if annotations == nil {
+		operations = append(operations, utils.PatchOperation{
+			Op:    "add",
+			Path:  "/metadata/annotations",
+			Value: map[string]string{},
+		})
+	}
+	switch {
+	case relearnRequested || (mode == utils.ProfileModeReady && drift != ""):
+		reason := drift
+		if relearnRequested {
+			reason = "relearn requested"
+		}
+		logger.L().Info("ApplicationProfileManager - relearning application profile",
+			helpers.String("reason", reason),
+			helpers.String("slug", slug),
+			helpers.String("k8s workload", watchedContainer.K8sContainerID))
+		operations = append(operations, utils.CreateRelearnPatchOperations(existingObject, now, watchedContainer.Revision, watchedContainer.RelearnRequest, watchedContainer.ContainerType, watchedContainer.ContainerIndex, imageID)...)
+		am.relearnStarts.Set(watchedContainer.K8sContainerID, now)
+	case mode == utils.ProfileModeLocked:
+		return fmt.Errorf("application profile %s is locked", slug)
+	default:
+		switch {
+		case utils.ProfileMode(annotations[utils.ProfileModeMetadataKey]) == utils.ProfileModeRelearning && mode == utils.ProfileModeReady:
+			// the grace period ended while no container of the profile was monitored
+			operations = append(operations, utils.CreateProfileModePatchOperations(utils.ProfileModeReady)...)
+		case mode == utils.ProfileModeRelearning:
+			if start, err := time.Parse(time.RFC3339, annotations[utils.ProfileRelearnStartMetadataKey]); err == nil {
+				am.relearnStarts.Set(watchedContainer.K8sContainerID, start)
+			}
+		case annotations[utils.ProfileModeMetadataKey] == "":
+			// the profile was created before the modes
+			operations = append(operations, utils.CreateProfileModePatchOperations(mode)...)
+		}
+		if mode == utils.ProfileModeLearning {
+			am.learningContainers.Add(watchedContainer.K8sContainerID)
+		}
+		operations = append(operations, utils.CreateProfileRevisionPatchOperations(annotations, watchedContainer.Revision, "", watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex, imageID)...)
+	}
+	if len(operations) == 0 || (annotations == nil && len(operations) == 1) {
+		return nil
+	}
+	patch, err := json.Marshal(operations)
+	if err != nil {
+		return nil
+	}
+	if err := am.storageClient.PatchApplicationProfile(slug, namespace, patch, watchedContainer.SyncChannel); err != nil {
+		logger.L().Ctx(ctx).Warning("ApplicationProfileManager - failed to patch application profile mode", helpers.Error(err),
+			helpers.String("slug", slug),
+			helpers.Int("container index", watchedContainer.ContainerIndex),
+			helpers.String("container ID", watchedContainer.ContainerID),
+			helpers.String("k8s workload", watchedContainer.K8sContainerID))
+	}
+	return nil
+}
+
+// profileModePatchOperations sets the mode of the profile to ready once the container learned it, or once the grace
+// period of its relearning is over
+func (am *ApplicationProfileManager) profileModePatchOperations(watchedContainer *utils.WatchedContainerData) []utils.PatchOperation {
+	if start, ok := am.relearnStarts.Load(watchedContainer.K8sContainerID); ok {
+		if time.Since(start) >= am.cfg.ProfileRelearnGracePeriod {
+			return utils.CreateProfileModePatchOperations(utils.ProfileModeReady)
+		}
+		return nil
+	}
+	if am.learningContainers.Contains(watchedContainer.K8sContainerID) && watchedContainer.GetStatus() == utils.WatchedContainerStatusCompleted {
+		return utils.CreateProfileModePatchOperations(utils.ProfileModeReady)
+	}
+	return nil
+}
+
 func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedContainer *utils.WatchedContainerData, namespace string) {
 	ctx, span := otel.Tracer("").Start(ctx, "ApplicationProfileManager.saveProfile")
 	defer span.End()
@@ -255,6 +398,9 @@ func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedCon
 
 	// get capabilities from IG
 	var capabilities []string
+	var credentials []string
+	var bpf []string
+	var fileModifications []string
 	execs := make(map[string][]string)
 	opens := make(map[string]mapset.Set[string])
 	if toSaveCapabilities := am.toSaveCapabilities.Get(watchedContainer.K8sContainerID); toSaveCapabilities.Cardinality() > 0 {
@@ -269,6 +415,64 @@ func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedCon
 		}
 	}
 
+	// get credential transitions from the creds tracer
+	if toSaveCredentials := am.toSaveCredentials.Get(watchedContainer.K8sContainerID); toSaveCredentials.Cardinality() > 0 {
+		// remove credential transitions to save in a thread safe way using Pop
+		for {
+			transition, continuePop := toSaveCredentials.Pop()
+			if continuePop {
+				credentials = append(credentials, transition)
+			} else {
+				break
+			}
+		}
+	}
+
+	// get BPF usages from the bpf tracer
+	if toSaveBPF := am.toSaveBPF.Get(watchedContainer.K8sContainerID); toSaveBPF.Cardinality() > 0 {
+		// remove BPF usages to save in a thread safe way using Pop
+		for {
+			usage, continuePop := toSaveBPF.Pop()
+			if continuePop {
+				bpf = append(bpf, usage)
+			} else {
+				break
+			}
+		}
+	}
+
+	// get file modifications from the filemod tracer
+	if toSaveFileModifications := am.toSaveFileModifications.Get(watchedContainer.K8sContainerID); toSaveFileModifications.Cardinality() > 0 {
+		// remove file modifications to save in a thread safe way using Pop
+		for {
+			modification, continuePop := toSaveFileModifications.Pop()
+			if continuePop {
+				fileModifications = append(fileModifications, modification)
+			} else {
+				break
+			}
+		}
+	}
+
+	// the activities are annotations, those over the bytes left to the container are dropped
+	containerType := watchedContainer.ContainerType.String()
+	left := utils.MaxActivitiesAnnotationsBytes -
+		utils.ActivitiesAnnotationsBytes(utils.CredentialTransitionActivity, am.savedCredentials.Get(watchedContainer.K8sContainerID).ToSlice(), containerType, watchedContainer.ContainerIndex) -
+		utils.ActivitiesAnnotationsBytes(utils.BPFActivity, am.savedBPF.Get(watchedContainer.K8sContainerID).ToSlice(), containerType, watchedContainer.ContainerIndex) -
+		utils.ActivitiesAnnotationsBytes(utils.FileModificationActivity, am.savedFileModifications.Get(watchedContainer.K8sContainerID).ToSlice(), containerType, watchedContainer.ContainerIndex)
+	activities := len(credentials) + len(bpf) + len(fileModifications)
+	credentials, left = utils.LimitActivities(utils.CredentialTransitionActivity, credentials, left, containerType, watchedContainer.ContainerIndex)
+	bpf, left = utils.LimitActivities(utils.BPFActivity, bpf, left, containerType, watchedContainer.ContainerIndex)
+	fileModifications, _ = utils.LimitActivities(utils.FileModificationActivity, fileModifications, left, containerType, watchedContainer.ContainerIndex)
+	if dropped := activities - len(credentials) - len(bpf) - len(fileModifications); dropped > 0 {
+		logger.L().Debug("ApplicationProfileManager - dropped activities over the annotations size limit",
+			helpers.Int("dropped", dropped),
+			helpers.String("slug", slug),
+			helpers.Int("container index", watchedContainer.ContainerIndex),
+			helpers.String("container ID", watchedContainer.ContainerID),
+			helpers.String("k8s workload", watchedContainer.K8sContainerID))
+	}
+
 	// get pointer to execs map from IG
 	toSaveExecs := am.toSaveExecs.Get(watchedContainer.K8sContainerID)
 	// point IG to a new exec map
@@ -283,8 +487,10 @@ func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedCon
 	toSaveOpens := am.toSaveOpens.Get(watchedContainer.K8sContainerID)
 	// point IG to a new opens map
 	am.toSaveOpens.Set(watchedContainer.K8sContainerID, new(maps.SafeMap[string, mapset.Set[string]]))
-	// prepare opens map
+	// prepare opens map, the paths reported before their directory was collapsed are merged into its wildcard
+	pathNormalizer := am.pathNormalizers.Get(watchedContainer.K8sContainerID)
 	toSaveOpens.Range(func(path string, open mapset.Set[string]) bool {
+		path = pathNormalizer.Collapse(path)
 		if _, exist := opens[path]; !exist {
 			opens[path] = mapset.NewSet[string]()
 		}
@@ -300,10 +506,19 @@ func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedCon
 	// 3a. the object is missing its container slice - ADD one with the container profile at the right index
 	// 3b. the object is missing the container profile - ADD the container profile at the right index
 	// 3c. default - patch the container ourselves and REPLACE it at the right index
-	if len(capabilities) > 0 || len(execs) > 0 || len(opens) > 0 || len(toSaveSyscalls) > 0 || watchedContainer.StatusUpdated() {
+	modeOperations := am.profileModePatchOperations(watchedContainer)
+	if len(capabilities) > 0 || len(credentials) > 0 || len(bpf) > 0 || len(fileModifications) > 0 || len(execs) > 0 || len(opens) > 0 || len(toSaveSyscalls) > 0 || len(modeOperations) > 0 || watchedContainer.StatusUpdated() {
 		// 0. calculate patch
 		operations := utils.CreateCapabilitiesPatchOperations(capabilities, observedSyscalls, execs, opens, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)
+		// credential transitions, BPF usages and file modifications are annotations, the container profile has no
+		// field for them
+		operations = append(operations, utils.CreateActivitiesPatchOperations(utils.CredentialTransitionActivity, credentials, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)...)
+		operations = append(operations, utils.CreateActivitiesPatchOperations(utils.BPFActivity, bpf, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)...)
+		operations = append(operations, utils.CreateActivitiesPatchOperations(utils.FileModificationActivity, fileModifications, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)...)
+		operations = append(operations, modeOperations...)
 		operations = utils.AppendStatusAnnotationPatchOperations(operations, watchedContainer)
+		operations = utils.AppendLastUpdatedNodePatchOperations(operations, am.nodeName)
+		operations = utils.AppendLearnedArchitecturePatchOperations(operations, runtime.GOARCH)
 
 		patch, err := json.Marshal(operations)
 		if err != nil {
@@ -330,6 +545,23 @@ func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedCon
 						Labels: utils.GetLabels(watchedContainer, true),
 					},
 				}
+				// the new profile is learned on the revision and image of the container
+				newObject.Annotations[utils.ProfileModeMetadataKey] = string(utils.ProfileModeLearning)
+				if len(modeOperations) > 0 {
+					newObject.Annotations[utils.ProfileModeMetadataKey] = string(utils.ProfileModeReady)
+				}
+				utils.SetLastUpdatedNodeAnnotation(newObject.Annotations, am.nodeName)
+				utils.SetLearnedArchitectureAnnotation(newObject.Annotations, runtime.GOARCH)
+				utils.SetProfileRevisionAnnotations(newObject.Annotations, watchedContainer.Revision, watchedContainer.RelearnRequest, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex, utils.ImageDigest(watchedContainer.ImageID))
+				for _, transition := range credentials {
+					newObject.Annotations[utils.ActivityMetadataKey(utils.CredentialTransitionActivity, transition, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)] = transition
+				}
+				for _, usage := range bpf {
+					newObject.Annotations[utils.ActivityMetadataKey(utils.BPFActivity, usage, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)] = usage
+				}
+				for _, modification := range fileModifications {
+					newObject.Annotations[utils.ActivityMetadataKey(utils.FileModificationActivity, modification, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)] = modification
+				}
 				addContainers := func(containers []v1beta1.ApplicationProfileContainer, containerNames []string) []v1beta1.ApplicationProfileContainer {
 					for _, name := range containerNames {
 						containers = append(containers, v1beta1.ApplicationProfileContainer{
@@ -439,7 +671,23 @@ func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedCon
 						})
 					}
 
+					// add the credential transitions, BPF usages and file modifications, creating the annotations if
+					// needed
+					if existingObject.Annotations == nil {
+						replaceOperations = append(replaceOperations, utils.PatchOperation{
+							Op:    "add",
+							Path:  "/metadata/annotations",
+							Value: map[string]string{},
+						})
+					}
+					replaceOperations = append(replaceOperations, utils.CreateActivitiesPatchOperations(utils.CredentialTransitionActivity, credentials, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)...)
+					replaceOperations = append(replaceOperations, utils.CreateActivitiesPatchOperations(utils.BPFActivity, bpf, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)...)
+					replaceOperations = append(replaceOperations, utils.CreateActivitiesPatchOperations(utils.FileModificationActivity, fileModifications, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)...)
+
+					replaceOperations = append(replaceOperations, modeOperations...)
 					replaceOperations = utils.AppendStatusAnnotationPatchOperations(replaceOperations, watchedContainer)
+					replaceOperations = utils.AppendLastUpdatedNodePatchOperations(replaceOperations, am.nodeName)
+					replaceOperations = utils.AppendLearnedArchitecturePatchOperations(replaceOperations, runtime.GOARCH)
 
 					patch, err := json.Marshal(replaceOperations)
 					if err != nil {
@@ -465,6 +713,12 @@ func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedCon
 		if gotErr != nil {
 			// restore capabilities set
 			am.toSaveCapabilities.Get(watchedContainer.K8sContainerID).Append(capabilities...)
+			// restore credential transitions set
+			am.toSaveCredentials.Get(watchedContainer.K8sContainerID).Append(credentials...)
+			// restore BPF usages set
+			am.toSaveBPF.Get(watchedContainer.K8sContainerID).Append(bpf...)
+			// restore file modifications set
+			am.toSaveFileModifications.Get(watchedContainer.K8sContainerID).Append(fileModifications...)
 			// restore execs map entries
 			toSaveExecs.Range(func(uniqueExecIdentifier string, v []string) bool {
 				if !am.toSaveExecs.Get(watchedContainer.K8sContainerID).Has(uniqueExecIdentifier) {
@@ -477,11 +731,22 @@ func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedCon
 		} else {
 			// for status updates to be tracked, we reset the update flag
 			watchedContainer.ResetStatusUpdatedFlag()
+			// the profile is ready
+			if len(modeOperations) > 0 {
+				am.learningContainers.Remove(watchedContainer.K8sContainerID)
+				am.relearnStarts.Delete(watchedContainer.K8sContainerID)
+			}
 
 			// record saved syscalls
 			am.savedSyscalls.Get(watchedContainer.K8sContainerID).Append(toSaveSyscalls...)
 			// record saved capabilities
 			am.savedCapabilities.Get(watchedContainer.K8sContainerID).Append(capabilities...)
+			// record saved credential transitions
+			am.savedCredentials.Get(watchedContainer.K8sContainerID).Append(credentials...)
+			// record saved BPF usages
+			am.savedBPF.Get(watchedContainer.K8sContainerID).Append(bpf...)
+			// record saved file modifications
+			am.savedFileModifications.Get(watchedContainer.K8sContainerID).Append(fileModifications...)
 			// record saved execs
 			toSaveExecs.Range(func(uniqueExecIdentifier string, v []string) bool {
 				if !am.savedExecs.Get(watchedContainer.K8sContainerID).Has(uniqueExecIdentifier) {
@@ -489,10 +754,15 @@ func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedCon
 				}
 				return true
 			})
-			// record saved opens
-			toSaveOpens.Range(utils.SetInMap(am.savedOpens.Get(watchedContainer.K8sContainerID)))
+			// record saved opens, with their collapsed paths
+			for path, open := range opens {
+				utils.SetInMap(am.savedOpens.Get(watchedContainer.K8sContainerID))(path, open)
+			}
 			logger.L().Debug("ApplicationProfileManager - saved application profile",
 				helpers.Int("capabilities", len(capabilities)),
+				helpers.Int("credentials", len(credentials)),
+				helpers.Int("bpf", len(bpf)),
+				helpers.Int("file modifications", len(fileModifications)),
 				helpers.Int("execs", toSaveExecs.Len()),
 				helpers.Int("opens", toSaveOpens.Len()),
 				helpers.String("slug", slug),
@@ -503,13 +773,11 @@ func (am *ApplicationProfileManager) saveProfile(ctx context.Context, watchedCon
 	}
 }
 
-func (am *ApplicationProfileManager) startApplicationProfiling(ctx context.Context, container *containercollection.Container, k8sContainerID string) {
+func (am *ApplicationProfileManager) startApplicationProfiling(ctx context.Context, container *containercollection.Container, k8sContainerID string, syncChannel chan error) {
+	defer am.monitors.Done()
 	ctx, span := otel.Tracer("").Start(ctx, "ApplicationProfileManager.startApplicationProfiling")
 	defer span.End()
 
-	syncChannel := make(chan error, 10)
-	am.watchedContainerChannels.Set(container.Runtime.ContainerID, syncChannel)
-
 	watchedContainer := &utils.WatchedContainerData{
 		ContainerID:      container.Runtime.ContainerID,
 		UpdateDataTicker: time.NewTicker(utils.AddRandomDuration(5, 10, am.cfg.InitialDelay)), // get out of sync with the relevancy manager
@@ -561,16 +829,27 @@ func (am *ApplicationProfileManager) ContainerCallback(notif containercollection
 			return
 		}
 		am.savedCapabilities.Set(k8sContainerID, mapset.NewSet[string]())
+		am.savedCredentials.Set(k8sContainerID, mapset.NewSet[string]())
+		am.savedBPF.Set(k8sContainerID, mapset.NewSet[string]())
+		am.savedFileModifications.Set(k8sContainerID, mapset.NewSet[string]())
 		am.droppedEvents.Set(k8sContainerID, false)
 		am.savedExecs.Set(k8sContainerID, new(maps.SafeMap[string, []string]))
 		am.savedOpens.Set(k8sContainerID, new(maps.SafeMap[string, mapset.Set[string]]))
 		am.savedSyscalls.Set(k8sContainerID, mapset.NewSet[string]())
 		am.toSaveCapabilities.Set(k8sContainerID, mapset.NewSet[string]())
+		am.toSaveCredentials.Set(k8sContainerID, mapset.NewSet[string]())
+		am.toSaveBPF.Set(k8sContainerID, mapset.NewSet[string]())
+		am.toSaveFileModifications.Set(k8sContainerID, mapset.NewSet[string]())
 		am.toSaveExecs.Set(k8sContainerID, new(maps.SafeMap[string, []string]))
 		am.toSaveOpens.Set(k8sContainerID, new(maps.SafeMap[string, mapset.Set[string]]))
+		am.pathNormalizers.Set(k8sContainerID, utils.NewPathNormalizer(am.cfg.OpenPathCollapseThreshold))
 		am.removedContainers.Remove(k8sContainerID) // make sure container is not in the removed list
 		am.trackedContainers.Add(k8sContainerID)
-		go am.startApplicationProfiling(ctx, notif.Container, k8sContainerID)
+		// the channel is set before the monitoring starts, so a container removed right after being added is stopped
+		syncChannel := make(chan error, 10)
+		am.watchedContainerChannels.Set(notif.Container.Runtime.ContainerID, syncChannel)
+		am.monitors.Add(1)
+		go am.startApplicationProfiling(ctx, notif.Container, k8sContainerID, syncChannel)
 
 	case containercollection.EventTypeRemoveContainer:
 		channel := am.watchedContainerChannels.Get(notif.Container.Runtime.ContainerID)
@@ -580,6 +859,12 @@ func (am *ApplicationProfileManager) ContainerCallback(notif containercollection
 	}
 }
 
+// Wait waits for the monitoring of the containers to stop, once they are removed or reached their maximum sniffing time
+// and their profiles are saved
+func (am *ApplicationProfileManager) Wait() {
+	am.monitors.Wait()
+}
+
 func (am *ApplicationProfileManager) RegisterPeekFunc(peek func(mntns uint64) ([]string, error)) {
 	am.syscallPeekFunc = peek
 }
@@ -594,6 +879,43 @@ func (am *ApplicationProfileManager) ReportCapability(k8sContainerID, capability
 	am.toSaveCapabilities.Get(k8sContainerID).Add(capability)
 }
 
+func (am *ApplicationProfileManager) ReportCredentialTransition(k8sContainerID, transition string) {
+	if err := am.waitForContainer(k8sContainerID); err != nil {
+		return
+	}
+	if am.savedCredentials.Get(k8sContainerID).Contains(transition) {
+		return
+	}
+	am.toSaveCredentials.Get(k8sContainerID).Add(transition)
+}
+
+func (am *ApplicationProfileManager) ReportBPFUsage(k8sContainerID, usage string) {
+	if err := am.waitForContainer(k8sContainerID); err != nil {
+		return
+	}
+	if am.savedBPF.Get(k8sContainerID).Contains(usage) {
+		return
+	}
+	am.toSaveBPF.Get(k8sContainerID).Add(usage)
+}
+
+func (am *ApplicationProfileManager) ReportFileModification(k8sContainerID, modification string) {
+	if err := am.waitForContainer(k8sContainerID); err != nil {
+		return
+	}
+	saved := am.savedFileModifications.Get(k8sContainerID)
+	if saved.Contains(modification) {
+		return
+	}
+	// the annotations of the profile are limited in size, the modifications of files with generated names would
+	// fill them
+	toSave := am.toSaveFileModifications.Get(k8sContainerID)
+	if saved.Cardinality()+toSave.Cardinality() >= maxFileModifications {
+		return
+	}
+	toSave.Add(modification)
+}
+
 func (am *ApplicationProfileManager) ReportFileExec(k8sContainerID, path string, args []string) {
 	// skip empty path
 	if path == "" {
@@ -619,6 +941,8 @@ func (am *ApplicationProfileManager) ReportFileOpen(k8sContainerID, path string,
 	if err := am.waitForContainer(k8sContainerID); err != nil {
 		return
 	}
+	// collapse the high cardinality segments of the path
+	path = am.pathNormalizers.Get(k8sContainerID).Normalize(path)
 	// check if we already have this open
 	savedOpens := am.savedOpens.Get(k8sContainerID)
 	if savedOpens.Has(path) && savedOpens.Get(path).Contains(flags...) {
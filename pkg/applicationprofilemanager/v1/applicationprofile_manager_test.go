@@ -6,6 +6,8 @@ import (
 	"node-agent/pkg/k8sclient"
 	"node-agent/pkg/objectcache"
 	"node-agent/pkg/storage"
+	"node-agent/pkg/utils"
+	"runtime"
 	"sort"
 	"testing"
 	"time"
@@ -13,8 +15,10 @@ import (
 	mapset "github.com/deckarep/golang-set/v2"
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/kubescape/k8s-interface/instanceidhandler/v1"
 	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestApplicationProfileManager(t *testing.T) {
@@ -27,7 +31,7 @@ func TestApplicationProfileManager(t *testing.T) {
 	k8sClient := &k8sclient.K8sClientMock{}
 	storageClient := &storage.StorageHttpClientMock{}
 	k8sObjectCacheMock := &objectcache.K8sObjectCacheMock{}
-	am, err := CreateApplicationProfileManager(ctx, cfg, "cluster", k8sClient, storageClient, mapset.NewSet[string](), k8sObjectCacheMock)
+	am, err := CreateApplicationProfileManager(ctx, cfg, "cluster", "node", k8sClient, storageClient, mapset.NewSet[string](), k8sObjectCacheMock)
 	assert.NoError(t, err)
 	// prepare container
 	container := &containercollection.Container{
@@ -50,6 +54,12 @@ func TestApplicationProfileManager(t *testing.T) {
 	})
 	// report capability
 	go am.ReportCapability("ns/pod/cont", "NET_BIND_SERVICE")
+	// report credential transition
+	go am.ReportCredentialTransition("ns/pod/cont", "uid:0->1000")
+	// report BPF usage
+	go am.ReportBPFUsage("ns/pod/cont", "bpf:MAP_CREATE:Hash")
+	// report file modification
+	go am.ReportFileModification("ns/pod/cont", "rename:/etc/nginx/nginx.conf")
 	// report file exec
 	go am.ReportFileExec("ns/pod/cont", "", []string{"ls"}) // will not be reported
 	go am.ReportFileExec("ns/pod/cont", "/bin/bash", []string{"-c", "ls"})
@@ -68,6 +78,9 @@ func TestApplicationProfileManager(t *testing.T) {
 	time.Sleep(15 * time.Second) // need to sleep longer because of AddRandomDuration in startApplicationProfiling
 	// report another file open
 	go am.ReportFileOpen("ns/pod/cont", "/etc/hosts", []string{"O_RDONLY"})
+	// report another credential transition
+	go am.ReportCredentialTransition("ns/pod/cont", "caps:+NET_RAW")
+	go am.ReportCredentialTransition("ns/pod/cont", "uid:0->1000") // duplicate - will not be reported
 	// report another file open
 	go am.ReportFileExec("ns/pod/cont", "/bin/bash", []string{"-c", "ls"}) // duplicate - will not be reported
 	// sleep more
@@ -84,6 +97,8 @@ func TestApplicationProfileManager(t *testing.T) {
 	// check the first profile
 	sort.Strings(storageClient.ApplicationProfiles[0].Spec.Containers[0].Capabilities)
 	assert.Equal(t, []string{"dup", "listen"}, storageClient.ApplicationProfiles[0].Spec.Containers[1].Syscalls)
+	assert.Equal(t, []string{runtime.GOARCH}, utils.GetLearnedArchitectures(storageClient.ApplicationProfiles[0].Annotations))
+	assert.Equal(t, "node", storageClient.ApplicationProfiles[0].Annotations[utils.LastUpdatedNodeMetadataKey])
 	assert.Equal(t, []string{"NET_BIND_SERVICE"}, storageClient.ApplicationProfiles[0].Spec.Containers[1].Capabilities)
 
 	reportedExecs := storageClient.ApplicationProfiles[0].Spec.Containers[1].Execs
@@ -98,6 +113,9 @@ func TestApplicationProfileManager(t *testing.T) {
 		assert.Contains(t, reportedExecs, expectedExec)
 	}
 	assert.Equal(t, []v1beta1.OpenCalls{{Path: "/etc/passwd", Flags: []string{"O_RDONLY"}}}, storageClient.ApplicationProfiles[0].Spec.Containers[1].Opens)
+	assert.Equal(t, []string{"uid:0->1000"}, utils.GetActivities(storageClient.ApplicationProfiles[0], storageClient.ApplicationProfiles[0].Spec.Containers[1].Name, utils.CredentialTransitionActivity))
+	assert.Equal(t, []string{"bpf:MAP_CREATE:Hash"}, utils.GetActivities(storageClient.ApplicationProfiles[0], storageClient.ApplicationProfiles[0].Spec.Containers[1].Name, utils.BPFActivity))
+	assert.Equal(t, []string{"rename:/etc/nginx/nginx.conf"}, utils.GetActivities(storageClient.ApplicationProfiles[0], storageClient.ApplicationProfiles[0].Spec.Containers[1].Name, utils.FileModificationActivity))
 	// check the second profile - this is a patch for execs and opens
 	sort.Strings(storageClient.ApplicationProfiles[1].Spec.Containers[0].Capabilities)
 	assert.Equal(t, []string{"NET_BIND_SERVICE"}, storageClient.ApplicationProfiles[1].Spec.Containers[1].Capabilities)
@@ -106,4 +124,100 @@ func TestApplicationProfileManager(t *testing.T) {
 		{Path: "/etc/passwd", Flags: []string{"O_RDONLY"}},
 		{Path: "/etc/hosts", Flags: []string{"O_RDONLY"}},
 	}, storageClient.ApplicationProfiles[1].Spec.Containers[1].Opens)
+	assert.Equal(t, []string{"caps:+NET_RAW", "uid:0->1000"}, utils.GetActivities(storageClient.ApplicationProfiles[1], storageClient.ApplicationProfiles[1].Spec.Containers[1].Name, utils.CredentialTransitionActivity))
+}
+
+func TestCheckProfileMode(t *testing.T) {
+	instanceID, _ := instanceidhandler.GenerateInstanceIDFromString("apiVersion-v1/namespace-ns/kind-deployment/name-nginx/containerName-nginx")
+	tests := []struct {
+		name           string
+		mode           utils.ProfileMode
+		revision       string
+		relearnRequest string
+		wantErr        bool
+		wantMode       utils.ProfileMode
+		wantLearning   bool
+	}{
+		{name: "ready on the same revision", mode: utils.ProfileModeReady, revision: "77b4fdf86c", wantMode: utils.ProfileModeReady},
+		{name: "ready on a new revision", mode: utils.ProfileModeReady, revision: "5f8c6d9b7", wantMode: utils.ProfileModeRelearning},
+		{name: "locked on a new revision", mode: utils.ProfileModeLocked, revision: "5f8c6d9b7", wantErr: true, wantMode: utils.ProfileModeLocked},
+		{name: "locked with a relearn request", mode: utils.ProfileModeLocked, revision: "77b4fdf86c", relearnRequest: "1", wantMode: utils.ProfileModeRelearning},
+		{name: "learning", mode: utils.ProfileModeLearning, revision: "5f8c6d9b7", wantMode: utils.ProfileModeLearning, wantLearning: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageClient := &storage.StorageHttpClientMock{
+				ApplicationProfiles: []*v1beta1.ApplicationProfile{{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "deployment-nginx",
+						Annotations: map[string]string{
+							utils.ProfileModeMetadataKey:     string(tt.mode),
+							utils.ProfileRevisionMetadataKey: "77b4fdf86c",
+						},
+					},
+					Spec: v1beta1.ApplicationProfileSpec{
+						Containers: []v1beta1.ApplicationProfileContainer{{
+							Name:     "nginx",
+							Execs:    []v1beta1.ExecCalls{{Path: "/usr/sbin/nginx"}},
+							Opens:    []v1beta1.OpenCalls{{Path: "/etc/nginx/nginx.conf", Flags: []string{"O_RDONLY"}}},
+							Syscalls: []string{"read"},
+						}},
+					},
+				}},
+			}
+			cfg := config.Config{ProfileRelearnGracePeriod: 30 * time.Minute}
+			am, err := CreateApplicationProfileManager(context.TODO(), cfg, "cluster", "node", &k8sclient.K8sClientMock{}, storageClient, mapset.NewSet[string](), &objectcache.K8sObjectCacheMock{})
+			assert.NoError(t, err)
+			watchedContainer := &utils.WatchedContainerData{
+				InstanceID:     instanceID,
+				K8sContainerID: "ns/pod/nginx",
+				ContainerType:  utils.Container,
+				Revision:       tt.revision,
+				RelearnRequest: tt.relearnRequest,
+			}
+
+			err = am.checkProfileMode(context.TODO(), watchedContainer, "ns")
+			assert.Equal(t, tt.wantErr, err != nil)
+			profile := storageClient.ApplicationProfiles[len(storageClient.ApplicationProfiles)-1]
+			assert.Equal(t, string(tt.wantMode), profile.Annotations[utils.ProfileModeMetadataKey])
+			assert.Equal(t, tt.wantMode == utils.ProfileModeRelearning, am.relearnStarts.Has("ns/pod/nginx"))
+			assert.Equal(t, tt.wantLearning, am.learningContainers.Contains("ns/pod/nginx"))
+			if tt.wantMode == utils.ProfileModeRelearning {
+				assert.Equal(t, tt.revision, profile.Annotations[utils.ProfileRevisionMetadataKey])
+				assert.Equal(t, tt.relearnRequest, profile.Annotations[utils.ProfileRelearnRequestMetadataKey])
+				// the container relearns its activities from scratch
+				assert.Empty(t, profile.Spec.Containers[0].Execs)
+				assert.Empty(t, profile.Spec.Containers[0].Opens)
+				assert.Empty(t, profile.Spec.Containers[0].Syscalls)
+			} else {
+				assert.Len(t, profile.Spec.Containers[0].Execs, 1)
+				assert.Len(t, profile.Spec.Containers[0].Opens, 1)
+				assert.Equal(t, []string{"read"}, profile.Spec.Containers[0].Syscalls)
+			}
+		})
+	}
+}
+
+func TestEnsureInstanceIDImageID(t *testing.T) {
+	am, err := CreateApplicationProfileManager(context.TODO(), config.Config{}, "cluster", "node", &k8sclient.K8sClientMock{}, &storage.StorageHttpClientMock{}, mapset.NewSet[string](), &objectcache.K8sObjectCacheMock{})
+	assert.NoError(t, err)
+	container := &containercollection.Container{
+		K8s: containercollection.K8sMetadata{
+			BasicK8sMetadata: types.BasicK8sMetadata{Namespace: "ns", PodName: "pod", ContainerName: "cont"},
+		},
+		Runtime: containercollection.RuntimeMetadata{
+			BasicRuntimeMetadata: types.BasicRuntimeMetadata{ContainerImageDigest: "sha256:runtime"},
+		},
+	}
+	// the image ID is the one of the pod status, not the one of the container runtime
+	watchedContainer := &utils.WatchedContainerData{K8sContainerID: "ns/pod/cont"}
+	assert.NoError(t, am.ensureInstanceID(container, watchedContainer))
+	assert.Equal(t, storage.NginxImageID, watchedContainer.ImageID)
+	assert.NotNil(t, watchedContainer.InstanceID)
+
+	// the container info is retried until the pod has a status for the container
+	container.K8s.ContainerName = "log"
+	watchedContainer = &utils.WatchedContainerData{K8sContainerID: "ns/pod/log"}
+	assert.Error(t, am.ensureInstanceID(container, watchedContainer))
+	assert.Nil(t, watchedContainer.InstanceID)
 }
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"node-agent/pkg/exporters"
+	responsemanagerv1 "node-agent/pkg/responsemanager/v1"
+	"node-agent/pkg/utils"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -23,6 +27,94 @@ type Config struct {
 	EnableRuntimeDetection   bool                      `mapstructure:"runtimeDetectionEnabled"`
 	EnableNetworkTracing     bool                      `mapstructure:"networkServiceEnabled"`
 	EnableRelevancy          bool                      `mapstructure:"relevantCVEServiceEnabled"`
+	// AlertAggregationWindow is the window in which repeated alerts are aggregated, aggregation is disabled when zero
+	AlertAggregationWindow time.Duration `mapstructure:"alertAggregationWindow"`
+	// MalwareScanCacheSize is the number of files whose malware scan verdict is cached
+	MalwareScanCacheSize int `mapstructure:"malwareScanCacheSize"`
+	// MalwareScanCacheTTL is how long a verdict is trusted before the file is scanned, and alerted on, again
+	MalwareScanCacheTTL time.Duration `mapstructure:"malwareScanCacheTTL"`
+	// Response configures the actions taken on alerts, they are disabled by default
+	Response responsemanagerv1.ResponseConfig `mapstructure:"response"`
+	// WorkerPools sizes the worker pools of the tracers, keyed by event type name (e.g. "open")
+	WorkerPools map[string]WorkerPoolConfig `mapstructure:"workerPools"`
+	// OpenFilter filters the open events before they are queued, nothing is filtered by default
+	OpenFilter OpenFilterConfig `mapstructure:"openFilter"`
+	// RecordingPath is the file the events of the tracers are recorded to for replays, recording is disabled when empty
+	RecordingPath string `mapstructure:"recordingPath"`
+	// ProfileRelearnGracePeriod is how long the anomaly rules ignore a profile relearning a new revision or image of its workload
+	ProfileRelearnGracePeriod time.Duration `mapstructure:"profileRelearnGracePeriod"`
+	// OpenPathCollapseThreshold is the number of distinct dynamic segments (PIDs, UUIDs, hashes, timestamps) of a
+	// directory above which the profiles record them as wildcards, collapsing is disabled when zero
+	OpenPathCollapseThreshold int `mapstructure:"openPathCollapseThreshold"`
+}
+
+// OpenFilterConfig filters the open events as soon as the tracer reports them, the filtered events are neither recorded in the profiles nor evaluated by the rules.
+// The opens for writing and the opens of the files the rules watch, like /etc/shadow or the service account tokens, are never filtered
+type OpenFilterConfig struct {
+	// IgnorePrefixes are the path prefixes of the ignored files, like "/proc/"
+	IgnorePrefixes []string `mapstructure:"ignorePrefixes"`
+	// AlwaysPassPrefixes are the path prefixes of the files whose opens are never filtered, in addition to the files the rules watch
+	AlwaysPassPrefixes []string `mapstructure:"alwaysPassPrefixes"`
+	// SampleRate keeps one out of SampleRate open events of each container, all the events are kept when it is not above 1
+	SampleRate int `mapstructure:"sampleRate"`
+	// ContainerSampleRates overrides SampleRate for the containers named "<namespace>/<container>"
+	ContainerSampleRates map[string]int `mapstructure:"containerSampleRates"`
+	// DedupWindow drops the repeated opens of a file with the same flags by a container during the window, deduplication is disabled when zero
+	DedupWindow time.Duration `mapstructure:"dedupWindow"`
+}
+
+func (c OpenFilterConfig) Validate() error {
+	for _, prefix := range c.IgnorePrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("ignored path prefix %q is not absolute", prefix)
+		}
+	}
+	for _, prefix := range c.AlwaysPassPrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("always passing path prefix %q is not absolute", prefix)
+		}
+	}
+	if c.SampleRate < 0 {
+		return fmt.Errorf("negative sample rate %d", c.SampleRate)
+	}
+	for container, rate := range c.ContainerSampleRates {
+		if strings.Count(container, "/") != 1 {
+			return fmt.Errorf("container %q is not named <namespace>/<container>", container)
+		}
+		if rate < 0 {
+			return fmt.Errorf("negative sample rate %d for container %s", rate, container)
+		}
+	}
+	if c.DedupWindow < 0 {
+		return fmt.Errorf("negative dedup window %s", c.DedupWindow)
+	}
+	return nil
+}
+
+// WorkerPoolConfig sizes the worker pool of a tracer and the queue of its events, zero values keep the defaults
+type WorkerPoolConfig struct {
+	// Size is the number of workers the pool starts with and shrinks back to
+	Size int `mapstructure:"size"`
+	// MaxSize is the number of workers the pool grows to while its queue is backed up
+	MaxSize int `mapstructure:"maxSize"`
+	// QueueSize is the number of events waiting for a worker, the events are dropped when the queue is full
+	QueueSize int `mapstructure:"queueSize"`
+}
+
+// validateWorkerPools checks the event types and sizes of the worker pools
+func validateWorkerPools(workerPools map[string]WorkerPoolConfig) error {
+	for name, pool := range workerPools {
+		if _, err := utils.EventTypeFromString(name); err != nil {
+			return err
+		}
+		if pool.Size < 0 || pool.MaxSize < 0 || pool.QueueSize < 0 {
+			return fmt.Errorf("negative size for the %s worker pool", name)
+		}
+		if pool.Size > 0 && pool.MaxSize > 0 && pool.MaxSize < pool.Size {
+			return fmt.Errorf("maxSize %d of the %s worker pool is lower than its size %d", pool.MaxSize, name, pool.Size)
+		}
+	}
+	return nil
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -33,6 +125,8 @@ func LoadConfig(path string) (Config, error) {
 
 	viper.SetDefault("fullPathTracingEnabled", true)
 	viper.SetDefault("initialDelay", 2*time.Minute)
+	viper.SetDefault("profileRelearnGracePeriod", 30*time.Minute)
+	viper.SetDefault("openPathCollapseThreshold", 50)
 
 	viper.AutomaticEnv()
 
@@ -42,6 +136,23 @@ func LoadConfig(path string) (Config, error) {
 	}
 
 	var config Config
-	err = viper.Unmarshal(&config)
-	return config, err
+	if err := viper.Unmarshal(&config); err != nil {
+		return Config{}, err
+	}
+	if err := config.Exporters.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid exporters config: %w", err)
+	}
+	if err := config.Response.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid response config: %w", err)
+	}
+	if err := validateWorkerPools(config.WorkerPools); err != nil {
+		return Config{}, fmt.Errorf("invalid worker pools config: %w", err)
+	}
+	if err := config.OpenFilter.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid open filter config: %w", err)
+	}
+	if config.OpenPathCollapseThreshold < 0 {
+		return Config{}, fmt.Errorf("negative open path collapse threshold %d", config.OpenPathCollapseThreshold)
+	}
+	return config, nil
 }
@@ -43,6 +43,11 @@ func TestLoadConfig(t *testing.T) {
 						URL: "http://synchronizer.kubescape.svc.cluster.local:8089/apis/v1/kubescape.io/v1/runtimealerts",
 					},
 				},
+				WorkerPools: map[string]WorkerPoolConfig{
+					"open": {Size: 8, MaxSize: 32, QueueSize: 100000},
+				},
+				ProfileRelearnGracePeriod: 30 * time.Minute,
+				OpenPathCollapseThreshold: 50,
 			},
 			wantErr: false,
 		},
@@ -58,3 +63,93 @@ func TestLoadConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateWorkerPools(t *testing.T) {
+	tests := []struct {
+		name        string
+		workerPools map[string]WorkerPoolConfig
+		wantErr     bool
+	}{
+		{
+			name: "no worker pools",
+		},
+		{
+			name:        "partial config",
+			workerPools: map[string]WorkerPoolConfig{"open": {QueueSize: 200000}, "dns": {MaxSize: 20}},
+		},
+		{
+			name:        "unknown event type",
+			workerPools: map[string]WorkerPoolConfig{"opens": {Size: 1}},
+			wantErr:     true,
+		},
+		{
+			name:        "negative size",
+			workerPools: map[string]WorkerPoolConfig{"exec": {QueueSize: -1}},
+			wantErr:     true,
+		},
+		{
+			name:        "max size lower than size",
+			workerPools: map[string]WorkerPoolConfig{"exec": {Size: 4, MaxSize: 2}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWorkerPools(tt.workerPools)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestOpenFilterConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     OpenFilterConfig
+		wantErr bool
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name: "valid",
+			cfg: OpenFilterConfig{
+				IgnorePrefixes:       []string{"/proc/", "/sys/"},
+				AlwaysPassPrefixes:   []string{"/proc/self/mem"},
+				SampleRate:           10,
+				ContainerSampleRates: map[string]int{"default/nginx": 1},
+				DedupWindow:          time.Second,
+			},
+		},
+		{
+			name:    "relative prefix",
+			cfg:     OpenFilterConfig{IgnorePrefixes: []string{"proc/"}},
+			wantErr: true,
+		},
+		{
+			name:    "relative always passing prefix",
+			cfg:     OpenFilterConfig{AlwaysPassPrefixes: []string{"data/"}},
+			wantErr: true,
+		},
+		{
+			name:    "negative sample rate",
+			cfg:     OpenFilterConfig{SampleRate: -1},
+			wantErr: true,
+		},
+		{
+			name:    "container without namespace",
+			cfg:     OpenFilterConfig{ContainerSampleRates: map[string]int{"nginx": 2}},
+			wantErr: true,
+		},
+		{
+			name:    "negative dedup window",
+			cfg:     OpenFilterConfig{DedupWindow: -time.Second},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
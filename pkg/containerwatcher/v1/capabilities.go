@@ -2,6 +2,8 @@ package containerwatcher
 
 import (
 	"fmt"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/utils"
 
 	tracercapabilities "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/tracer"
 	tracercapabilitiestype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
@@ -14,9 +16,10 @@ func (ch *IGContainerWatcher) capabilitiesEventCallback(event *tracercapabilitie
 	if event.Type != types.NORMAL {
 		// dropped event
 		logger.L().Ctx(ch.ctx).Warning("capabilities tracer got drop events - we may miss some realtime data", helpers.Interface("event", event), helpers.String("error", event.Message))
+		ch.metrics.ReportDroppedEvent(utils.CapabilitiesEventType, metricsmanager.DroppedByTracer)
 		return
 	}
-	_ = ch.capabilitiesWorkerPool.Invoke(*event)
+	enqueueEvent(ch.capabilitiesWorkerChan, event, utils.CapabilitiesEventType, ch.metrics)
 }
 
 func (ch *IGContainerWatcher) startCapabilitiesTracing() error {
@@ -30,6 +33,12 @@ func (ch *IGContainerWatcher) startCapabilitiesTracing() error {
 		return fmt.Errorf("getting capabilitiesMountnsmap: %w", err)
 	}
 
+	go func() {
+		for event := range ch.capabilitiesWorkerChan {
+			_ = ch.capabilitiesWorkerPool.Invoke(*event)
+		}
+	}()
+
 	tracerCapabilities, err := tracercapabilities.NewTracer(&tracercapabilities.Config{MountnsMap: capabilitiesMountnsmap}, ch.containerCollection, ch.capabilitiesEventCallback)
 	if err != nil {
 		return fmt.Errorf("creating tracer: %w", err)
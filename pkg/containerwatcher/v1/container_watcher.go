@@ -7,6 +7,7 @@ import (
 	"node-agent/pkg/config"
 	"node-agent/pkg/containerwatcher"
 	"node-agent/pkg/dnsmanager"
+	"node-agent/pkg/eventrecorder"
 	"node-agent/pkg/malwaremanager"
 	"node-agent/pkg/metricsmanager"
 	"node-agent/pkg/networkmanager"
@@ -45,18 +46,17 @@ import (
 )
 
 const (
-	capabilitiesTraceName      = "trace_capabilities"
-	execTraceName              = "trace_exec"
-	networkTraceName           = "trace_network"
-	dnsTraceName               = "trace_dns"
-	openTraceName              = "trace_open"
-	randomxTraceName           = "trace_randomx"
-	capabilitiesWorkerPoolSize = 1
-	execWorkerPoolSize         = 2
-	openWorkerPoolSize         = 8
-	networkWorkerPoolSize      = 1
-	dnsWorkerPoolSize          = 5
-	randomxWorkerPoolSize      = 1
+	capabilitiesTraceName = "trace_capabilities"
+	execTraceName         = "trace_exec"
+	networkTraceName      = "trace_network"
+	dnsTraceName          = "trace_dns"
+	openTraceName         = "trace_open"
+	randomxTraceName      = "trace_randomx"
+	ptraceTraceName       = "trace_ptrace"
+	escapeTraceName       = "trace_escape"
+	credsTraceName        = "trace_creds"
+	bpfTraceName          = "trace_bpf"
+	filemodTraceName      = "trace_filemod"
 )
 
 type IGContainerWatcher struct {
@@ -90,8 +90,10 @@ type IGContainerWatcher struct {
 	networkTracer      *tracernetwork.Tracer
 	dnsTracer          *tracerdns.Tracer
 	randomxTracer      *tracerandomx.Tracer
-	kubeIPInstance     operators.OperatorInstance
-	kubeNameInstance   operators.OperatorInstance
+	// eventSources are the gadgets of utils.EventSources with their tracers and worker pools
+	eventSources     []*gadgetSource
+	kubeIPInstance   operators.OperatorInstance
+	kubeNameInstance operators.OperatorInstance
 
 	// Worker pools
 	capabilitiesWorkerPool *ants.PoolWithFunc
@@ -108,6 +110,16 @@ type IGContainerWatcher struct {
 	dnsWorkerChan          chan *tracerdnstype.Event
 	randomxWorkerChan      chan *tracerandomxtype.Event
 
+	// openFilter drops the ignored open events before they are queued
+	openFilter *openFilter
+
+	// recorder records the events for replays, it is nil when recording is disabled
+	recorder *eventrecorder.Recorder
+
+	// workerPools are resized to the depth of their queues while the watcher runs
+	workerPools             []*workerPool
+	stopResizingWorkerPools context.CancelFunc
+
 	preRunningContainersIDs mapset.Set[string]
 
 	timeBasedContainers mapset.Set[string] // list of containers to track based on ticker
@@ -128,13 +140,29 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 	if err != nil {
 		return nil, fmt.Errorf("creating tracer collection: %w", err)
 	}
+	// Record the events for replays
+	var recorder *eventrecorder.Recorder
+	if cfg.RecordingPath != "" {
+		recorder, err = eventrecorder.NewRecorder(cfg.RecordingPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Size the worker pools and their queues
+	capabilitiesPoolConfig := workerPoolConfig(cfg, utils.CapabilitiesEventType)
+	execPoolConfig := workerPoolConfig(cfg, utils.ExecveEventType)
+	openPoolConfig := workerPoolConfig(cfg, utils.OpenEventType)
+	networkPoolConfig := workerPoolConfig(cfg, utils.NetworkEventType)
+	dnsPoolConfig := workerPoolConfig(cfg, utils.DnsEventType)
+	randomxPoolConfig := workerPoolConfig(cfg, utils.RandomXEventType)
 	// Create a capabilities worker pool
-	capabilitiesWorkerPool, err := ants.NewPoolWithFunc(capabilitiesWorkerPoolSize, func(i interface{}) {
+	capabilitiesWorkerPool, err := ants.NewPoolWithFunc(capabilitiesPoolConfig.Size, func(i interface{}) {
 		event := i.(tracercapabilitiestype.Event)
 		// ignore events with empty container name
 		if event.K8s.ContainerName == "" {
 			return
 		}
+		recorder.Record(utils.CapabilitiesEventType, &event)
 		metrics.ReportEvent(utils.CapabilitiesEventType)
 		k8sContainerID := utils.CreateK8sContainerID(event.K8s.Namespace, event.K8s.PodName, event.K8s.ContainerName)
 		applicationProfileManager.ReportCapability(k8sContainerID, event.CapName)
@@ -144,7 +172,7 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 		return nil, fmt.Errorf("creating capabilities worker pool: %w", err)
 	}
 	// Create an exec worker pool
-	execWorkerPool, err := ants.NewPoolWithFunc(execWorkerPoolSize, func(i interface{}) {
+	execWorkerPool, err := ants.NewPoolWithFunc(execPoolConfig.Size, func(i interface{}) {
 		event := i.(tracerexectype.Event)
 		// ignore events with empty container name
 		if event.K8s.ContainerName == "" {
@@ -163,6 +191,7 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 		if len(event.Args) > 0 {
 			path = event.Args[0]
 		}
+		recorder.Record(utils.ExecveEventType, &event)
 		metrics.ReportEvent(utils.ExecveEventType)
 		applicationProfileManager.ReportFileExec(k8sContainerID, path, event.Args)
 		relevancyManager.ReportFileExec(event.Runtime.ContainerID, k8sContainerID, path)
@@ -173,7 +202,7 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 		return nil, fmt.Errorf("creating exec worker pool: %w", err)
 	}
 	// Create an open worker pool
-	openWorkerPool, err := ants.NewPoolWithFunc(openWorkerPoolSize, func(i interface{}) {
+	openWorkerPool, err := ants.NewPoolWithFunc(openPoolConfig.Size, func(i interface{}) {
 		event := i.(traceropentype.Event)
 		// ignore events with empty container name
 		if event.K8s.ContainerName == "" {
@@ -192,6 +221,7 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 			path = event.FullPath
 		}
 
+		recorder.Record(utils.OpenEventType, &event)
 		metrics.ReportEvent(utils.OpenEventType)
 		applicationProfileManager.ReportFileOpen(k8sContainerID, path, event.Flags)
 		relevancyManager.ReportFileOpen(event.Runtime.ContainerID, k8sContainerID, path)
@@ -202,7 +232,7 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 		return nil, fmt.Errorf("creating open worker pool: %w", err)
 	}
 	// Create a network worker pool
-	networkWorkerPool, err := ants.NewPoolWithFunc(networkWorkerPoolSize, func(i interface{}) {
+	networkWorkerPool, err := ants.NewPoolWithFunc(networkPoolConfig.Size, func(i interface{}) {
 		event := i.(tracernetworktype.Event)
 		// ignore events with empty container name
 		if event.K8s.ContainerName == "" {
@@ -216,6 +246,7 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 			networkManagerClient.ReportDroppedEvent(k8sContainerID)
 			return
 		}
+		recorder.Record(utils.NetworkEventType, &event)
 		metrics.ReportEvent(utils.NetworkEventType)
 		networkManagerv1Client.ReportNetworkEvent(event.Runtime.ContainerID, event)
 		networkManagerClient.ReportNetworkEvent(k8sContainerID, event)
@@ -225,7 +256,7 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 		return nil, fmt.Errorf("creating network worker pool: %w", err)
 	}
 	// Create a dns worker pool
-	dnsWorkerPool, err := ants.NewPoolWithFunc(dnsWorkerPoolSize, func(i interface{}) {
+	dnsWorkerPool, err := ants.NewPoolWithFunc(dnsPoolConfig.Size, func(i interface{}) {
 		event := i.(tracerdnstype.Event)
 
 		// ignore DNS events that are not responses
@@ -238,6 +269,7 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 			return
 		}
 
+		recorder.Record(utils.DnsEventType, &event)
 		metrics.ReportEvent(utils.DnsEventType)
 		dnsManagerClient.ReportDNSEvent(event)
 		ruleManager.ReportDNSEvent(event)
@@ -246,19 +278,19 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 		return nil, fmt.Errorf("creating dns worker pool: %w", err)
 	}
 	// Create a randomx worker pool
-	randomxWorkerPool, err := ants.NewPoolWithFunc(randomxWorkerPoolSize, func(i interface{}) {
+	randomxWorkerPool, err := ants.NewPoolWithFunc(randomxPoolConfig.Size, func(i interface{}) {
 		event := i.(tracerandomxtype.Event)
 		if event.K8s.ContainerName == "" {
 			return
 		}
+		recorder.Record(utils.RandomXEventType, &event)
 		metrics.ReportEvent(utils.RandomXEventType)
 		ruleManager.ReportRandomxEvent(event.Runtime.ContainerID, event)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("creating randomx worker pool: %w", err)
 	}
-
-	return &IGContainerWatcher{
+	ch := &IGContainerWatcher{
 		// Configuration
 		cfg:               cfg,
 		containerSelector: containercollection.ContainerSelector{}, // Empty selector to get all containers
@@ -288,20 +320,40 @@ func CreateIGContainerWatcher(cfg config.Config, applicationProfileManager appli
 		metrics:                 metrics,
 		preRunningContainersIDs: preRunningContainers,
 
+		openFilter: newOpenFilter(cfg.OpenFilter),
+		recorder:   recorder,
+
 		// Channels
-		capabilitiesWorkerChan: make(chan *tracercapabilitiestype.Event, 1000),
-		execWorkerChan:         make(chan *tracerexectype.Event, 1000),
-		openWorkerChan:         make(chan *traceropentype.Event, 100000),
-		networkWorkerChan:      make(chan *tracernetworktype.Event, 50000),
-		dnsWorkerChan:          make(chan *tracerdnstype.Event, 10000),
-		randomxWorkerChan:      make(chan *tracerandomxtype.Event, 500),
+		capabilitiesWorkerChan: make(chan *tracercapabilitiestype.Event, capabilitiesPoolConfig.QueueSize),
+		execWorkerChan:         make(chan *tracerexectype.Event, execPoolConfig.QueueSize),
+		openWorkerChan:         make(chan *traceropentype.Event, openPoolConfig.QueueSize),
+		networkWorkerChan:      make(chan *tracernetworktype.Event, networkPoolConfig.QueueSize),
+		dnsWorkerChan:          make(chan *tracerdnstype.Event, dnsPoolConfig.QueueSize),
+		randomxWorkerChan:      make(chan *tracerandomxtype.Event, randomxPoolConfig.QueueSize),
 
 		// cache
 		ruleBindingPodNotify: ruleBindingPodNotify,
 
 		timeBasedContainers: mapset.NewSet[string](),
 		ruleManagedPods:     mapset.NewSet[string](),
-	}, nil
+	}
+	ch.workerPools = []*workerPool{
+		{eventType: utils.CapabilitiesEventType, pool: capabilitiesWorkerPool, config: capabilitiesPoolConfig, queueDepth: func() int { return len(ch.capabilitiesWorkerChan) }},
+		{eventType: utils.ExecveEventType, pool: execWorkerPool, config: execPoolConfig, queueDepth: func() int { return len(ch.execWorkerChan) }},
+		{eventType: utils.OpenEventType, pool: openWorkerPool, config: openPoolConfig, queueDepth: func() int { return len(ch.openWorkerChan) }},
+		{eventType: utils.NetworkEventType, pool: networkWorkerPool, config: networkPoolConfig, queueDepth: func() int { return len(ch.networkWorkerChan) }},
+		{eventType: utils.DnsEventType, pool: dnsWorkerPool, config: dnsPoolConfig, queueDepth: func() int { return len(ch.dnsWorkerChan) }},
+		{eventType: utils.RandomXEventType, pool: randomxWorkerPool, config: randomxPoolConfig, queueDepth: func() int { return len(ch.randomxWorkerChan) }},
+	}
+	ch.eventSources = ch.gadgetSources()
+	for _, source := range ch.eventSources {
+		pool, err := ch.createGadgetWorkerPool(source)
+		if err != nil {
+			return nil, err
+		}
+		ch.workerPools = append(ch.workerPools, pool)
+	}
+	return ch, nil
 }
 
 func (ch *IGContainerWatcher) Start(ctx context.Context) error {
@@ -315,6 +367,10 @@ func (ch *IGContainerWatcher) Start(ctx context.Context) error {
 			ch.stopContainerCollection()
 			return fmt.Errorf("starting app behavior tracing: %w", err)
 		}
+		resizeCtx, cancel := context.WithCancel(ctx)
+		ch.stopResizingWorkerPools = cancel
+		go ch.resizeWorkerPools(resizeCtx)
+
 		logger.L().Info("main container handler started")
 		ch.running = true
 	}
@@ -324,11 +380,15 @@ func (ch *IGContainerWatcher) Start(ctx context.Context) error {
 
 func (ch *IGContainerWatcher) Stop() {
 	if ch.running {
+		ch.stopResizingWorkerPools()
 		ch.stopContainerCollection()
 		err := ch.stopTracers()
 		if err != nil {
 			logger.L().Ctx(ch.ctx).Warning("error stopping app behavior tracing", helpers.Error(err))
 		}
+		if err := ch.recorder.Close(); err != nil {
+			logger.L().Ctx(ch.ctx).Warning("error closing the events recording", helpers.Error(err))
+		}
 		ch.running = false
 	}
 }
@@ -226,6 +226,13 @@ func (ch *IGContainerWatcher) startTracers() error {
 		} else {
 			logger.L().Warning("randomx tracing is not supported on this architecture", helpers.String("architecture", runtime.GOARCH))
 		}
+
+		for _, source := range ch.eventSources {
+			if err := ch.startGadgetTracing(source); err != nil {
+				// not failing on the gadgets tracing errors, the kernel may not have the syscall tracepoints
+				logger.L().Error(fmt.Sprintf("error starting %s tracing", source.eventType), helpers.Error(err))
+			}
+		}
 	}
 
 	return nil
@@ -279,6 +286,16 @@ func (ch *IGContainerWatcher) stopTracers() error {
 				errs = errors.Join(errs, err)
 			}
 		}
+		// Stop the gadgets tracers
+		for _, source := range ch.eventSources {
+			if source.tracer == nil {
+				continue
+			}
+			if err := ch.stopGadgetTracing(source); err != nil {
+				logger.L().Error(fmt.Sprintf("error stopping %s tracing", source.eventType), helpers.Error(err))
+				errs = errors.Join(errs, err)
+			}
+		}
 	}
 
 	return errs
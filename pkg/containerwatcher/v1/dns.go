@@ -2,6 +2,8 @@ package containerwatcher
 
 import (
 	"fmt"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/utils"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection/networktracer"
 	tracerdns "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/tracer"
@@ -14,13 +16,14 @@ import (
 func (ch *IGContainerWatcher) dnsEventCallback(event *tracerdnstype.Event) {
 	if event.Type != types.NORMAL && event.Type != types.DEBUG {
 		logger.L().Ctx(ch.ctx).Warning("dns tracer got drop events - we may miss some realtime data", helpers.Interface("event", event), helpers.String("error", event.Message))
+		ch.metrics.ReportDroppedEvent(utils.DnsEventType, metricsmanager.DroppedByTracer)
 		return
 	}
 
 	ch.containerCollection.EnrichByMntNs(&event.CommonData, event.MountNsID)
 	ch.containerCollection.EnrichByNetNs(&event.CommonData, event.NetNsID)
 
-	ch.dnsWorkerChan <- event
+	enqueueEvent(ch.dnsWorkerChan, event, utils.DnsEventType, ch.metrics)
 }
 
 func (ch *IGContainerWatcher) startDNSTracing() error {
@@ -2,6 +2,8 @@ package containerwatcher
 
 import (
 	"fmt"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/utils"
 
 	tracerexec "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/tracer"
 	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
@@ -14,9 +16,10 @@ func (ch *IGContainerWatcher) execEventCallback(event *tracerexectype.Event) {
 	if event.Type != types.NORMAL {
 		// dropped event
 		logger.L().Ctx(ch.ctx).Warning("exec tracer got drop events - we may miss some realtime data", helpers.Interface("event", event), helpers.String("error", event.Message))
+		ch.metrics.ReportDroppedEvent(utils.ExecveEventType, metricsmanager.DroppedByTracer)
 	}
 	if event.Retval > -1 && event.Comm != "" {
-		ch.execWorkerChan <- event
+		enqueueEvent(ch.execWorkerChan, event, utils.ExecveEventType, ch.metrics)
 	}
 }
 
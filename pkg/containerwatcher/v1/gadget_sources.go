@@ -0,0 +1,193 @@
+package containerwatcher
+
+import (
+	"fmt"
+
+	tracerbpf "node-agent/pkg/ebpf/gadgets/bpf/tracer"
+	tracerbpftype "node-agent/pkg/ebpf/gadgets/bpf/types"
+	tracercreds "node-agent/pkg/ebpf/gadgets/creds/tracer"
+	tracercredstype "node-agent/pkg/ebpf/gadgets/creds/types"
+	tracerescape "node-agent/pkg/ebpf/gadgets/escape/tracer"
+	tracerescapetype "node-agent/pkg/ebpf/gadgets/escape/types"
+	tracerfilemod "node-agent/pkg/ebpf/gadgets/filemod/tracer"
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+	tracerptrace "node-agent/pkg/ebpf/gadgets/ptrace/tracer"
+	tracerptracetype "node-agent/pkg/ebpf/gadgets/ptrace/types"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/utils"
+
+	"github.com/cilium/ebpf"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/panjf2000/ants/v2"
+)
+
+// gadgetTracer is a started tracer of a gadget
+type gadgetTracer interface {
+	Stop()
+}
+
+// gadgetEventCallback receives the base of an event of a gadget with the pointer to the event
+type gadgetEventCallback func(base *types.Event, event interface{})
+
+// gadgetSource is a gadget of the agent tracing syscalls, see utils.EventSources. The gadgets only differ by their
+// tracer and by the managers other than the rule manager their events are reported to.
+type gadgetSource struct {
+	eventType utils.EventType
+	traceName string
+	// newTracer starts the tracer of the gadget, filtered by the mount namespaces of mountnsMap
+	newTracer func(mountnsMap *ebpf.Map, enricher gadgets.DataEnricherByMntNs, callback gadgetEventCallback) (gadgetTracer, error)
+	// report reports an event to the managers other than the rule manager, it is nil when there are none
+	report func(k8sContainerID string, event interface{})
+
+	tracer     gadgetTracer
+	workerPool *ants.PoolWithFunc
+	workerChan chan interface{}
+}
+
+// gadgetSources returns the gadgets tracing syscalls, their events are reported to the rule manager and to the
+// managers of their report function
+func (ch *IGContainerWatcher) gadgetSources() []*gadgetSource {
+	escape := &gadgetSource{
+		eventType: utils.EscapeEventType,
+		traceName: escapeTraceName,
+		newTracer: func(mountnsMap *ebpf.Map, enricher gadgets.DataEnricherByMntNs, callback gadgetEventCallback) (gadgetTracer, error) {
+			return tracerescape.NewTracer(&tracerescape.Config{MountnsMap: mountnsMap}, enricher, func(event *tracerescapetype.Event) {
+				callback(&event.Event, event)
+			})
+		},
+	}
+	return []*gadgetSource{
+		{
+			eventType: utils.PtraceEventType,
+			traceName: ptraceTraceName,
+			newTracer: func(mountnsMap *ebpf.Map, enricher gadgets.DataEnricherByMntNs, callback gadgetEventCallback) (gadgetTracer, error) {
+				// the writes to the kernel usermode helper files are traced by the open probes of the ptrace gadget
+				escapeCallback := ch.gadgetEventCallback(escape)
+				return tracerptrace.NewTracer(&tracerptrace.Config{
+					MountnsMap: mountnsMap,
+					EscapeCallback: func(event *tracerescapetype.Event) {
+						escapeCallback(&event.Event, event)
+					},
+				}, enricher, func(event *tracerptracetype.Event) {
+					callback(&event.Event, event)
+				})
+			},
+		},
+		escape,
+		{
+			eventType: utils.CredsEventType,
+			traceName: credsTraceName,
+			newTracer: func(mountnsMap *ebpf.Map, enricher gadgets.DataEnricherByMntNs, callback gadgetEventCallback) (gadgetTracer, error) {
+				return tracercreds.NewTracer(&tracercreds.Config{MountnsMap: mountnsMap}, enricher, func(event *tracercredstype.Event) {
+					callback(&event.Event, event)
+				})
+			},
+			report: func(k8sContainerID string, event interface{}) {
+				ch.applicationProfileManager.ReportCredentialTransition(k8sContainerID, event.(*tracercredstype.Event).Transition)
+			},
+		},
+		{
+			eventType: utils.BPFEventType,
+			traceName: bpfTraceName,
+			newTracer: func(mountnsMap *ebpf.Map, enricher gadgets.DataEnricherByMntNs, callback gadgetEventCallback) (gadgetTracer, error) {
+				return tracerbpf.NewTracer(&tracerbpf.Config{MountnsMap: mountnsMap}, enricher, func(event *tracerbpftype.Event) {
+					callback(&event.Event, event)
+				})
+			},
+			report: func(k8sContainerID string, event interface{}) {
+				ch.applicationProfileManager.ReportBPFUsage(k8sContainerID, event.(*tracerbpftype.Event).Usage)
+			},
+		},
+		{
+			eventType: utils.FileModEventType,
+			traceName: filemodTraceName,
+			newTracer: func(mountnsMap *ebpf.Map, enricher gadgets.DataEnricherByMntNs, callback gadgetEventCallback) (gadgetTracer, error) {
+				return tracerfilemod.NewTracer(&tracerfilemod.Config{MountnsMap: mountnsMap}, enricher, func(event *tracerfilemodtype.Event) {
+					callback(&event.Event, event)
+				})
+			},
+			report: func(k8sContainerID string, event interface{}) {
+				filemodEvent := event.(*tracerfilemodtype.Event)
+				for _, path := range filemodEvent.ModifiedPaths() {
+					ch.applicationProfileManager.ReportFileModification(k8sContainerID, filemodEvent.Operation+":"+path)
+				}
+			},
+		},
+	}
+}
+
+// createGadgetWorkerPool creates the worker pool of a gadget with its queue
+func (ch *IGContainerWatcher) createGadgetWorkerPool(source *gadgetSource) (*workerPool, error) {
+	poolConfig := workerPoolConfig(ch.cfg, source.eventType)
+	pool, err := ants.NewPoolWithFunc(poolConfig.Size, func(i interface{}) {
+		details, ok := utils.DescribeSourceEvent(source.eventType, i)
+		if !ok || details.Event.K8s.ContainerName == "" {
+			return
+		}
+		k8sContainerID := utils.CreateK8sContainerID(details.Event.K8s.Namespace, details.Event.K8s.PodName, details.Event.K8s.ContainerName)
+		ch.recorder.Record(source.eventType, i)
+		ch.metrics.ReportEvent(source.eventType)
+		if source.report != nil {
+			source.report(k8sContainerID, i)
+		}
+		ch.ruleManager.ReportGadgetEvent(source.eventType, k8sContainerID, i)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating %s worker pool: %w", source.eventType, err)
+	}
+	source.workerPool = pool
+	source.workerChan = make(chan interface{}, poolConfig.QueueSize)
+	return &workerPool{eventType: source.eventType, pool: pool, config: poolConfig, queueDepth: func() int { return len(source.workerChan) }}, nil
+}
+
+func (ch *IGContainerWatcher) gadgetEventCallback(source *gadgetSource) gadgetEventCallback {
+	return func(base *types.Event, event interface{}) {
+		if base.Type != types.NORMAL {
+			// dropped event
+			logger.L().Ctx(ch.ctx).Warning(fmt.Sprintf("%s tracer got drop events - we may miss some realtime data", source.eventType), helpers.Interface("event", event), helpers.String("error", base.Message))
+			ch.metrics.ReportDroppedEvent(source.eventType, metricsmanager.DroppedByTracer)
+			return
+		}
+
+		enqueueEvent(source.workerChan, event, source.eventType, ch.metrics)
+	}
+}
+
+func (ch *IGContainerWatcher) startGadgetTracing(source *gadgetSource) error {
+	if err := ch.tracerCollection.AddTracer(source.traceName, ch.containerSelector); err != nil {
+		return fmt.Errorf("adding tracer: %w", err)
+	}
+
+	// Get mount namespace map to filter by containers
+	mountnsmap, err := ch.tracerCollection.TracerMountNsMap(source.traceName)
+	if err != nil {
+		return fmt.Errorf("getting %s mountnsmap: %w", source.eventType, err)
+	}
+
+	tracer, err := source.newTracer(mountnsmap, ch.containerCollection, ch.gadgetEventCallback(source))
+	if err != nil {
+		_ = ch.tracerCollection.RemoveTracer(source.traceName)
+		return fmt.Errorf("creating tracer: %w", err)
+	}
+	source.tracer = tracer
+
+	go func() {
+		for event := range source.workerChan {
+			_ = source.workerPool.Invoke(event)
+		}
+	}()
+
+	return nil
+}
+
+func (ch *IGContainerWatcher) stopGadgetTracing(source *gadgetSource) error {
+	if err := ch.tracerCollection.RemoveTracer(source.traceName); err != nil {
+		return fmt.Errorf("removing tracer: %w", err)
+	}
+	source.tracer.Stop()
+	source.tracer = nil
+	return nil
+}
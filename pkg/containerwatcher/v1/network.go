@@ -2,6 +2,8 @@ package containerwatcher
 
 import (
 	"fmt"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/utils"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection/networktracer"
 	tracernetwork "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/tracer"
@@ -22,6 +24,7 @@ func (ch *IGContainerWatcher) networkEventCallback(event *tracernetworktypes.Eve
 	if event.Type != types.NORMAL {
 		// dropped event
 		logger.L().Ctx(ch.ctx).Warning("network tracer got drop events - we may miss some realtime data", helpers.Interface("event", event), helpers.String("error", event.Message))
+		ch.metrics.ReportDroppedEvent(utils.NetworkEventType, metricsmanager.DroppedByTracer)
 	} else {
 
 		ch.containerCollection.EnrichByMntNs(&event.CommonData, event.MountNsID)
@@ -35,7 +38,7 @@ func (ch *IGContainerWatcher) networkEventCallback(event *tracernetworktypes.Eve
 		}
 	}
 
-	ch.networkWorkerChan <- event
+	enqueueEvent(ch.networkWorkerChan, event, utils.NetworkEventType, ch.metrics)
 }
 
 func (ch *IGContainerWatcher) startNetworkTracing() error {
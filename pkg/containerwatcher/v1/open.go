@@ -2,6 +2,8 @@ package containerwatcher
 
 import (
 	"fmt"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/utils"
 
 	traceropen "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/tracer"
 	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
@@ -14,9 +16,14 @@ func (ch *IGContainerWatcher) openEventCallback(event *traceropentype.Event) {
 	if event.Type != types.NORMAL {
 		// dropped event
 		logger.L().Ctx(ch.ctx).Warning("open tracer got drop events - we may miss some realtime data", helpers.Interface("event", event), helpers.String("error", event.Message))
+		ch.metrics.ReportDroppedEvent(utils.OpenEventType, metricsmanager.DroppedByTracer)
 	}
 	if event.Ret > -1 && event.FullPath != "" {
-		ch.openWorkerChan <- event
+		if reason := ch.openFilter.filter(event); reason != "" {
+			ch.metrics.ReportFilteredEvent(utils.OpenEventType, reason)
+			return
+		}
+		enqueueEvent(ch.openWorkerChan, event, utils.OpenEventType, ch.metrics)
 	}
 }
 
@@ -0,0 +1,144 @@
+package containerwatcher
+
+import (
+	"node-agent/pkg/config"
+	"node-agent/pkg/metricsmanager"
+	"strings"
+	"syscall"
+	"time"
+
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	"k8s.io/utils/lru"
+)
+
+const (
+	// openDedupCacheSize bounds the number of distinct opens remembered for deduplication
+	openDedupCacheSize = 16384
+	// openSampleCacheSize bounds the number of containers whose opens are counted for sampling
+	openSampleCacheSize = 4096
+)
+
+// alwaysPassPrefixes are the path prefixes of the files the rules watch, their opens are never filtered: the service
+// account tokens, the users, privileges, scheduled jobs and preloaded libraries and the SSH configuration
+var alwaysPassPrefixes = []string{
+	"/run/secrets/kubernetes.io/serviceaccount",
+	"/var/run/secrets/kubernetes.io/serviceaccount",
+	"/etc/passwd",
+	"/etc/shadow",
+	"/etc/group",
+	"/etc/gshadow",
+	"/etc/sudoers",
+	"/etc/crontab",
+	"/etc/cron.",
+	"/etc/ld.so.preload",
+	"/var/spool/cron/",
+	"/etc/ssh/",
+}
+
+// alwaysPassNames are the names of the SSH files of the users, their opens are never filtered wherever the home is
+var alwaysPassNames = []string{
+	"/.ssh/",
+	"/authorized_keys",
+	"/known_hosts",
+}
+
+// openDedupKey identifies the identical opens of a container
+type openDedupKey struct {
+	mountNsID uint64
+	path      string
+	flags     int32
+}
+
+// openFilter drops the open events the configuration ignores before they are queued. The opens for writing and the
+// opens of the files the rules watch always pass, the rules must see every write and every access to a sensitive file.
+type openFilter struct {
+	cfg config.OpenFilterConfig
+	// seen is the timestamp of the first open of each openDedupKey during its window
+	seen *lru.Cache
+	// counts is the number of opens of each container mount namespace, to sample them
+	counts *lru.Cache
+}
+
+func newOpenFilter(cfg config.OpenFilterConfig) *openFilter {
+	f := &openFilter{cfg: cfg}
+	if cfg.DedupWindow > 0 {
+		f.seen = lru.New(openDedupCacheSize)
+	}
+	if cfg.SampleRate > 1 || len(cfg.ContainerSampleRates) > 0 {
+		f.counts = lru.New(openSampleCacheSize)
+	}
+	return f
+}
+
+// filter returns the reason the event is filtered, or an empty string when the event must be processed
+func (f *openFilter) filter(event *traceropentype.Event) string {
+	if f.alwaysPass(event) {
+		return ""
+	}
+	for _, prefix := range f.cfg.IgnorePrefixes {
+		if strings.HasPrefix(event.FullPath, prefix) {
+			return metricsmanager.FilteredByPath
+		}
+	}
+	if f.seen != nil && f.duplicate(event) {
+		return metricsmanager.FilteredAsDuplicate
+	}
+	if f.counts != nil && !f.sampled(event) {
+		return metricsmanager.FilteredBySampling
+	}
+	return ""
+}
+
+// alwaysPass returns true when the open is for writing or opens a file the rules watch
+func (f *openFilter) alwaysPass(event *traceropentype.Event) bool {
+	if event.FlagsRaw&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_TRUNC) != 0 {
+		return true
+	}
+	for _, prefix := range alwaysPassPrefixes {
+		if strings.HasPrefix(event.FullPath, prefix) {
+			return true
+		}
+	}
+	for _, prefix := range f.cfg.AlwaysPassPrefixes {
+		if strings.HasPrefix(event.FullPath, prefix) {
+			return true
+		}
+	}
+	for _, name := range alwaysPassNames {
+		if strings.Contains(event.FullPath, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicate returns true when the container opened the same file with the same flags during the window
+func (f *openFilter) duplicate(event *traceropentype.Event) bool {
+	timestamp := int64(event.Timestamp)
+	if timestamp == 0 {
+		timestamp = time.Now().UnixNano()
+	}
+	key := openDedupKey{mountNsID: event.MountNsID, path: event.FullPath, flags: event.FlagsRaw}
+	if first, ok := f.seen.Get(key); ok && timestamp-first.(int64) < int64(f.cfg.DedupWindow) {
+		return true
+	}
+	f.seen.Add(key, timestamp)
+	return false
+}
+
+// sampled returns true for one out of sample rate events of the container, starting with its first event
+func (f *openFilter) sampled(event *traceropentype.Event) bool {
+	rate := f.cfg.SampleRate
+	if containerRate, ok := f.cfg.ContainerSampleRates[event.K8s.Namespace+"/"+event.K8s.ContainerName]; ok {
+		rate = containerRate
+	}
+	if rate <= 1 {
+		return true
+	}
+	count := 0
+	if value, ok := f.counts.Get(event.MountNsID); ok {
+		count = value.(int)
+	}
+	f.counts.Add(event.MountNsID, count+1)
+	return count%rate == 0
+}
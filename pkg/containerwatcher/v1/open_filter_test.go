@@ -0,0 +1,105 @@
+package containerwatcher
+
+import (
+	"node-agent/pkg/config"
+	metricsmanager "node-agent/pkg/metricsmanager"
+	"syscall"
+	"testing"
+	"time"
+
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func openEvent(mountNsID uint64, namespace, container, path string, flags int32, timestamp time.Duration) *traceropentype.Event {
+	return &traceropentype.Event{
+		Event: types.Event{
+			CommonData: types.CommonData{
+				K8s: types.K8sMetadata{
+					BasicK8sMetadata: types.BasicK8sMetadata{
+						Namespace:     namespace,
+						ContainerName: container,
+					},
+				},
+			},
+			Timestamp: types.Time(timestamp),
+			Type:      types.NORMAL,
+		},
+		WithMountNsID: types.WithMountNsID{MountNsID: mountNsID},
+		FullPath:      path,
+		FlagsRaw:      flags,
+	}
+}
+
+func TestOpenFilterPrefixes(t *testing.T) {
+	f := newOpenFilter(config.OpenFilterConfig{IgnorePrefixes: []string{"/proc/", "/sys/"}})
+	assert.Equal(t, metricsmanager.FilteredByPath, f.filter(openEvent(1, "ns", "app", "/proc/self/status", 0, 0)))
+	assert.Equal(t, metricsmanager.FilteredByPath, f.filter(openEvent(1, "ns", "app", "/sys/fs/cgroup/memory.max", 0, 0)))
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/etc/passwd", 0, 0)))
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/process", 0, 0)))
+}
+
+func TestOpenFilterDedup(t *testing.T) {
+	f := newOpenFilter(config.OpenFilterConfig{DedupWindow: time.Second})
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/etc/hosts", 0, time.Second)))
+	assert.Equal(t, metricsmanager.FilteredAsDuplicate, f.filter(openEvent(1, "ns", "app", "/etc/hosts", 0, 1500*time.Millisecond)))
+	// other flags, file or container
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/etc/hosts", 1, 1500*time.Millisecond)))
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/etc/resolv.conf", 0, 1500*time.Millisecond)))
+	assert.Empty(t, f.filter(openEvent(2, "ns", "app", "/etc/hosts", 0, 1500*time.Millisecond)))
+	// the window expired
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/etc/hosts", 0, 2*time.Second)))
+	assert.Equal(t, metricsmanager.FilteredAsDuplicate, f.filter(openEvent(1, "ns", "app", "/etc/hosts", 0, 2500*time.Millisecond)))
+}
+
+func TestOpenFilterSampling(t *testing.T) {
+	f := newOpenFilter(config.OpenFilterConfig{
+		SampleRate:           3,
+		ContainerSampleRates: map[string]int{"ns/db": 1, "ns/web": 2},
+	})
+	processed := func(mountNsID uint64, container string, events int) int {
+		count := 0
+		for i := 0; i < events; i++ {
+			if f.filter(openEvent(mountNsID, "ns", container, "/data", 0, 0)) == "" {
+				count++
+			}
+		}
+		return count
+	}
+	assert.Equal(t, 4, processed(1, "app", 10))
+	assert.Equal(t, 10, processed(2, "db", 10))
+	assert.Equal(t, 5, processed(3, "web", 10))
+	assert.Equal(t, metricsmanager.FilteredBySampling, f.filter(openEvent(1, "ns", "app", "/data", 0, 0)))
+}
+
+func TestOpenFilterAlwaysPass(t *testing.T) {
+	f := newOpenFilter(config.OpenFilterConfig{
+		IgnorePrefixes:     []string{"/etc/", "/home/", "/tmp/", "/data/"},
+		AlwaysPassPrefixes: []string{"/data/keys/"},
+		DedupWindow:        time.Second,
+	})
+	// the files the rules watch
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/etc/shadow", 0, 0)))
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/etc/shadow", 0, 0)))
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/etc/cron.d/job", 0, 0)))
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/home/user/.ssh/id_rsa", 0, 0)))
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/data/keys/key.pem", 0, 0)))
+	// the opens for writing
+	assert.Empty(t, f.filter(openEvent(1, "ns", "app", "/tmp/payload", syscall.O_WRONLY|syscall.O_CREAT, 0)))
+	assert.Equal(t, metricsmanager.FilteredByPath, f.filter(openEvent(1, "ns", "app", "/tmp/payload", syscall.O_RDONLY, 0)))
+	assert.Equal(t, metricsmanager.FilteredByPath, f.filter(openEvent(1, "ns", "app", "/etc/hosts", 0, 0)))
+}
+
+func TestOpenEventCallbackFilter(t *testing.T) {
+	metrics := metricsmanager.NewMetricsMock()
+	ch := &IGContainerWatcher{
+		metrics:        metrics,
+		openFilter:     newOpenFilter(config.OpenFilterConfig{IgnorePrefixes: []string{"/proc/"}}),
+		openWorkerChan: make(chan *traceropentype.Event, 10),
+	}
+	ch.openEventCallback(openEvent(1, "ns", "app", "/proc/1/cmdline", 0, 0))
+	ch.openEventCallback(openEvent(1, "ns", "app", "/etc/hosts", 0, 0))
+	assert.Len(t, ch.openWorkerChan, 1)
+	assert.Equal(t, 1, metrics.FilteredEventCounter.Get("open/"+metricsmanager.FilteredByPath))
+}
@@ -5,6 +5,8 @@ import (
 
 	tracerandomx "node-agent/pkg/ebpf/gadgets/randomx/tracer"
 	tracerrandomxtype "node-agent/pkg/ebpf/gadgets/randomx/types"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/utils"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 	"github.com/kubescape/go-logger"
@@ -15,10 +17,11 @@ func (ch *IGContainerWatcher) randomxEventCallback(event *tracerrandomxtype.Even
 	if event.Type != types.NORMAL {
 		// dropped event
 		logger.L().Ctx(ch.ctx).Warning("randomx tracer got drop events - we may miss some realtime data", helpers.Interface("event", event), helpers.String("error", event.Message))
+		ch.metrics.ReportDroppedEvent(utils.RandomXEventType, metricsmanager.DroppedByTracer)
 		return
 	}
 
-	ch.randomxWorkerChan <- event
+	enqueueEvent(ch.randomxWorkerChan, event, utils.RandomXEventType, ch.metrics)
 }
 
 func (ch *IGContainerWatcher) startRandomxTracing() error {
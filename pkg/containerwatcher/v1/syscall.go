@@ -2,8 +2,15 @@ package containerwatcher
 
 import (
 	"fmt"
+	"node-agent/pkg/utils"
+	"sync"
+	"time"
 
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+
+	mapset "github.com/deckarep/golang-set/v2"
 	tracerseccomp "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
 func (ch *IGContainerWatcher) startSystemcallTracing() error {
@@ -15,10 +22,59 @@ func (ch *IGContainerWatcher) startSystemcallTracing() error {
 	ch.syscallTracer = syscallTracer
 	// Register peek func for application profile manager
 	ch.applicationProfileManager.RegisterPeekFunc(ch.syscallTracer.Peek)
-	ch.ruleManager.RegisterPeekFunc(ch.syscallTracer.Peek)
+	if ch.recorder != nil {
+		ch.ruleManager.RegisterPeekFunc(ch.recordingPeekFunc(ch.syscallTracer.Peek))
+	} else {
+		ch.ruleManager.RegisterPeekFunc(ch.syscallTracer.Peek)
+	}
 	return nil
 }
 
+// recordingPeekFunc records the syscalls of a container the first time they are peeked,
+// the tracer only reports the set of syscalls a container used so far, not each call
+func (ch *IGContainerWatcher) recordingPeekFunc(peek func(mntns uint64) ([]string, error)) func(mntns uint64) ([]string, error) {
+	var mutex sync.Mutex
+	recorded := make(map[uint64]mapset.Set[string])
+	return func(mntns uint64) ([]string, error) {
+		syscalls, err := peek(mntns)
+		if err != nil {
+			return syscalls, err
+		}
+		container := ch.containerCollection.LookupContainerByMntns(mntns)
+		if container == nil {
+			return syscalls, nil
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if _, ok := recorded[mntns]; !ok {
+			recorded[mntns] = mapset.NewThreadUnsafeSet[string]()
+		}
+		for _, syscall := range syscalls {
+			if !recorded[mntns].Add(syscall) {
+				continue
+			}
+			ch.recorder.Record(utils.SyscallEventType, &ruleenginetypes.SyscallEvent{
+				Event: types.Event{
+					Timestamp: types.Time(time.Now().UnixNano()),
+					Type:      types.NORMAL,
+					CommonData: types.CommonData{
+						Runtime: container.Runtime.BasicRuntimeMetadata,
+						K8s: types.K8sMetadata{
+							BasicK8sMetadata: container.K8s.BasicK8sMetadata,
+							HostNetwork:      container.HostNetwork,
+						},
+					},
+				},
+				WithMountNsID: types.WithMountNsID{MountNsID: mntns},
+				Pid:           container.Pid,
+				SyscallName:   syscall,
+			})
+		}
+		return syscalls, nil
+	}
+}
+
 func (ch *IGContainerWatcher) stopSystemcallTracing() error {
 	// Stop seccomp tracer
 	ch.syscallTracer.Close()
@@ -0,0 +1,58 @@
+package containerwatcher
+
+import (
+	"bytes"
+	"node-agent/pkg/eventrecorder"
+	"node-agent/pkg/utils"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingPeekFunc(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl.gz")
+	recorder, err := eventrecorder.NewRecorder(path)
+	require.NoError(t, err)
+	containerCollection := &containercollection.ContainerCollection{}
+	require.NoError(t, containerCollection.Initialize())
+	containerCollection.AddContainer(&containercollection.Container{
+		Runtime: containercollection.RuntimeMetadata{BasicRuntimeMetadata: types.BasicRuntimeMetadata{ContainerID: "1234"}},
+		K8s:     containercollection.K8sMetadata{BasicK8sMetadata: types.BasicK8sMetadata{Namespace: "default", PodName: "nginx-1", ContainerName: "nginx"}},
+		Mntns:   42,
+		Pid:     10,
+	})
+	ch := &IGContainerWatcher{containerCollection: containerCollection, recorder: recorder}
+
+	syscalls := map[uint64][]string{42: {"read"}, 43: {"write"}}
+	peek := ch.recordingPeekFunc(func(mntns uint64) ([]string, error) {
+		return syscalls[mntns], nil
+	})
+	got, err := peek(42)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read"}, got)
+	syscalls[42] = []string{"read", "ptrace"}
+	_, _ = peek(42)
+	// unknown container
+	_, _ = peek(43)
+	require.NoError(t, recorder.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var recorded []string
+	require.NoError(t, eventrecorder.ReadRecording(bytes.NewReader(data), func(eventType utils.EventType, event interface{}) error {
+		syscallEvent := event.(*ruleenginetypes.SyscallEvent)
+		assert.Equal(t, utils.SyscallEventType, eventType)
+		assert.Equal(t, "nginx-1", syscallEvent.K8s.PodName)
+		assert.Equal(t, uint64(42), syscallEvent.MountNsID)
+		recorded = append(recorded, syscallEvent.SyscallName)
+		return nil
+	}))
+	assert.Equal(t, []string{"read", "ptrace"}, recorded)
+}
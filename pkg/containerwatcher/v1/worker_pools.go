@@ -0,0 +1,106 @@
+package containerwatcher
+
+import (
+	"context"
+	"node-agent/pkg/config"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/utils"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+const (
+	// workerPoolResizeInterval is how often the worker pools are resized to the depth of their queues
+	workerPoolResizeInterval = 10 * time.Second
+	// workerPoolGrowRatio is the part of the queue of a pool that must be used for the pool to grow
+	workerPoolGrowRatio = 10
+)
+
+// defaultWorkerPools are the sizes of the worker pools of the tracers when they are not configured
+var defaultWorkerPools = map[utils.EventType]config.WorkerPoolConfig{
+	utils.CapabilitiesEventType: {Size: 1, MaxSize: 4, QueueSize: 1000},
+	utils.ExecveEventType:       {Size: 2, MaxSize: 8, QueueSize: 1000},
+	utils.OpenEventType:         {Size: 8, MaxSize: 32, QueueSize: 100000},
+	utils.NetworkEventType:      {Size: 1, MaxSize: 4, QueueSize: 50000},
+	utils.DnsEventType:          {Size: 5, MaxSize: 20, QueueSize: 10000},
+	utils.RandomXEventType:      {Size: 1, MaxSize: 4, QueueSize: 500},
+	utils.PtraceEventType:       {Size: 1, MaxSize: 4, QueueSize: 500},
+	utils.EscapeEventType:       {Size: 1, MaxSize: 4, QueueSize: 500},
+	utils.CredsEventType:        {Size: 1, MaxSize: 4, QueueSize: 500},
+	utils.BPFEventType:          {Size: 1, MaxSize: 4, QueueSize: 500},
+	utils.FileModEventType:      {Size: 1, MaxSize: 4, QueueSize: 500},
+}
+
+// workerPoolConfig returns the sizes of the worker pool of an event type, the configured sizes override the defaults
+func workerPoolConfig(cfg config.Config, eventType utils.EventType) config.WorkerPoolConfig {
+	poolConfig := defaultWorkerPools[eventType]
+	configured := cfg.WorkerPools[eventType.String()]
+	if configured.Size > 0 {
+		poolConfig.Size = configured.Size
+	}
+	if configured.MaxSize > 0 {
+		poolConfig.MaxSize = configured.MaxSize
+	}
+	if configured.QueueSize > 0 {
+		poolConfig.QueueSize = configured.QueueSize
+	}
+	poolConfig.MaxSize = max(poolConfig.MaxSize, poolConfig.Size)
+	return poolConfig
+}
+
+// workerPool is the worker pool of a tracer with the queue of its events
+type workerPool struct {
+	eventType utils.EventType
+	pool      *ants.PoolWithFunc
+	config    config.WorkerPoolConfig
+	// queueDepth returns the number of queued events
+	queueDepth func() int
+}
+
+// targetSize returns the number of workers for the depth of the queue:
+// the pool doubles while its queue is backed up and halves back to its size once the queue is drained
+func (w *workerPool) targetSize(workers, queueDepth int) int {
+	switch {
+	case queueDepth*workerPoolGrowRatio > w.config.QueueSize && workers < w.config.MaxSize:
+		return min(workers*2, w.config.MaxSize)
+	case queueDepth == 0 && workers > w.config.Size:
+		return max(workers/2, w.config.Size)
+	}
+	return workers
+}
+
+// resize tunes the pool to the depth of its queue and reports both
+func (w *workerPool) resize(metrics metricsmanager.MetricsManager) {
+	queueDepth := w.queueDepth()
+	workers := w.targetSize(w.pool.Cap(), queueDepth)
+	if workers != w.pool.Cap() {
+		w.pool.Tune(workers)
+	}
+	metrics.ReportWorkerPool(w.eventType, workers, queueDepth)
+}
+
+// resizeWorkerPools periodically resizes the worker pools until the context is done
+func (ch *IGContainerWatcher) resizeWorkerPools(ctx context.Context) {
+	ticker := time.NewTicker(workerPoolResizeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, pool := range ch.workerPools {
+				pool.resize(ch.metrics)
+			}
+		}
+	}
+}
+
+// enqueueEvent queues an event for its worker pool without blocking the tracer, the event is dropped when the queue is full
+func enqueueEvent[T any](queue chan T, event T, eventType utils.EventType, metrics metricsmanager.MetricsManager) {
+	select {
+	case queue <- event:
+	default:
+		metrics.ReportDroppedEvent(eventType, metricsmanager.DroppedByQueue)
+	}
+}
@@ -0,0 +1,87 @@
+package containerwatcher
+
+import (
+	"node-agent/pkg/config"
+	metricsmanager "node-agent/pkg/metricsmanager"
+	"node-agent/pkg/utils"
+	"testing"
+
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	"github.com/panjf2000/ants/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPoolConfig(t *testing.T) {
+	cfg := config.Config{WorkerPools: map[string]config.WorkerPoolConfig{
+		"open": {Size: 16, QueueSize: 200000},
+		"exec": {MaxSize: 3},
+	}}
+	assert.Equal(t, config.WorkerPoolConfig{Size: 16, MaxSize: 32, QueueSize: 200000}, workerPoolConfig(cfg, utils.OpenEventType))
+	assert.Equal(t, config.WorkerPoolConfig{Size: 2, MaxSize: 3, QueueSize: 1000}, workerPoolConfig(cfg, utils.ExecveEventType))
+	assert.Equal(t, defaultWorkerPools[utils.DnsEventType], workerPoolConfig(cfg, utils.DnsEventType))
+
+	// the pool never shrinks below its size
+	cfg.WorkerPools["dns"] = config.WorkerPoolConfig{Size: 40}
+	assert.Equal(t, config.WorkerPoolConfig{Size: 40, MaxSize: 40, QueueSize: 10000}, workerPoolConfig(cfg, utils.DnsEventType))
+}
+
+func TestWorkerPoolTargetSize(t *testing.T) {
+	w := &workerPool{config: config.WorkerPoolConfig{Size: 2, MaxSize: 10, QueueSize: 1000}}
+	tests := []struct {
+		name       string
+		workers    int
+		queueDepth int
+		want       int
+	}{
+		{name: "idle", workers: 2, queueDepth: 0, want: 2},
+		{name: "short queue", workers: 2, queueDepth: 100, want: 2},
+		{name: "backed up queue", workers: 2, queueDepth: 500, want: 4},
+		{name: "grows up to the max size", workers: 8, queueDepth: 1000, want: 10},
+		{name: "at the max size", workers: 10, queueDepth: 1000, want: 10},
+		{name: "draining queue", workers: 8, queueDepth: 50, want: 8},
+		{name: "drained queue", workers: 8, queueDepth: 0, want: 4},
+		{name: "shrinks down to the size", workers: 3, queueDepth: 0, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, w.targetSize(tt.workers, tt.queueDepth))
+		})
+	}
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	pool, err := ants.NewPoolWithFunc(1, func(interface{}) {})
+	require.NoError(t, err)
+	defer pool.Release()
+	queueDepth := 0
+	w := &workerPool{
+		eventType:  utils.OpenEventType,
+		pool:       pool,
+		config:     config.WorkerPoolConfig{Size: 1, MaxSize: 4, QueueSize: 10},
+		queueDepth: func() int { return queueDepth },
+	}
+	metrics := metricsmanager.NewMetricsMock()
+
+	queueDepth = 5
+	w.resize(metrics)
+	w.resize(metrics)
+	assert.Equal(t, 4, pool.Cap())
+	assert.Equal(t, 4, metrics.WorkerPoolSize.Get(utils.OpenEventType))
+	assert.Equal(t, 5, metrics.WorkerPoolQueueDepth.Get(utils.OpenEventType))
+
+	queueDepth = 0
+	w.resize(metrics)
+	assert.Equal(t, 2, pool.Cap())
+	assert.Equal(t, 0, metrics.WorkerPoolQueueDepth.Get(utils.OpenEventType))
+}
+
+func TestEnqueueEvent(t *testing.T) {
+	metrics := metricsmanager.NewMetricsMock()
+	queue := make(chan *traceropentype.Event, 2)
+	for i := 0; i < 3; i++ {
+		enqueueEvent(queue, &traceropentype.Event{}, utils.OpenEventType, metrics)
+	}
+	assert.Len(t, queue, 2)
+	assert.Equal(t, 1, metrics.DroppedEventCounter.Get("open/"+metricsmanager.DroppedByQueue))
+}
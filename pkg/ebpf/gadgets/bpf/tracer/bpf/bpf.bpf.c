@@ -0,0 +1,172 @@
+#include "../../../../include/amd64/vmlinux.h"
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+
+#include "bpf.h"
+#include "../../../../include/mntns_filter.h"
+#include "../../../../include/macros.h"
+
+// Events map.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} events SEC(".maps");
+
+// The events are too large for the stack.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct event);
+} heap SEC(".maps");
+
+// The arguments are too large for the stack.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct args);
+} args_heap SEC(".maps");
+
+// The arguments of the calls in progress, by thread.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 10240);
+	__type(key, u64);
+	__type(value, struct args);
+} bpf_args SEC(".maps");
+
+// we need this to make sure the compiler doesn't remove our struct.
+const struct event *unusedevent __attribute__((unused));
+
+// new_args returns the cleared arguments of a call of the current task, or NULL when the task is not traced
+static __always_inline struct args *new_args(u32 syscall)
+{
+	u64 mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id)) {
+		return NULL;
+	}
+
+	u32 zero = 0;
+	struct args *args = bpf_map_lookup_elem(&args_heap, &zero);
+	if (!args) {
+		return NULL;
+	}
+	__builtin_memset(args, 0, sizeof(*args));
+	args->syscall = syscall;
+	return args;
+}
+
+static __always_inline void keep_args(struct args *args)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	bpf_map_update_elem(&bpf_args, &pid_tgid, args, BPF_ANY);
+}
+
+// exit_call reports the calls which succeeded, the failed calls did not load, create or attach anything
+static __always_inline int exit_call(struct trace_event_raw_sys_exit *ctx)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct args *args = bpf_map_lookup_elem(&bpf_args, &pid_tgid);
+	if (!args) {
+		return 0;
+	}
+
+	u32 zero = 0;
+	struct event *event = NULL;
+	if (ctx->ret >= 0) {
+		event = bpf_map_lookup_elem(&heap, &zero);
+	}
+	if (event) {
+		struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+		u64 uid_gid = bpf_get_current_uid_gid();
+
+		event->timestamp = bpf_ktime_get_boot_ns();
+		event->mntns_id = gadget_get_mntns_id();
+		event->pid = pid_tgid >> 32;
+		event->ppid = BPF_CORE_READ(task, real_parent, tgid);
+		event->uid = (u32)uid_gid;
+		event->gid = (u32)(uid_gid >> 32);
+		event->syscall = args->syscall;
+		event->cmd = args->cmd;
+		__builtin_memcpy(event->attr, args->attr, sizeof(event->attr));
+		__builtin_memcpy(event->name, args->name, sizeof(event->name));
+		bpf_get_current_comm(&event->comm, sizeof(event->comm));
+	}
+	bpf_map_delete_elem(&bpf_args, &pid_tgid);
+
+	if (event) {
+		bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+	}
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_bpf")
+int tracepoint__sys_enter_bpf(struct trace_event_raw_sys_enter *ctx)
+{
+	u32 cmd = ctx->args[0];
+	switch (cmd) {
+	case BPF_CMD_MAP_CREATE:
+	case BPF_CMD_PROG_LOAD:
+	case BPF_CMD_PROG_ATTACH:
+	case BPF_CMD_RAW_TRACEPOINT_OPEN:
+	case BPF_CMD_LINK_CREATE:
+		break;
+	default:
+		return 0;
+	}
+
+	struct args *args = new_args(SYSCALL_BPF);
+	if (!args) {
+		return 0;
+	}
+	args->cmd = cmd;
+
+	// the attributes may be shorter than the fields read, the missing fields are zero
+	u64 size = ctx->args[2];
+	if (size > ATTR_SIZE) {
+		size = ATTR_SIZE;
+	}
+	bpf_probe_read_user(args->attr, size, (const void *)ctx->args[1]);
+	if (cmd == BPF_CMD_RAW_TRACEPOINT_OPEN) {
+		// the name of a raw tracepoint is pointed by the first field of the attributes
+		const char *name = (const char *)*(u64 *)args->attr;
+		bpf_probe_read_user_str(args->name, sizeof(args->name), name);
+	}
+	keep_args(args);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_exit_bpf")
+int tracepoint__sys_exit_bpf(struct trace_event_raw_sys_exit *ctx)
+{
+	return exit_call(ctx);
+}
+
+SEC("tracepoint/syscalls/sys_enter_perf_event_open")
+int tracepoint__sys_enter_perf_event_open(struct trace_event_raw_sys_enter *ctx)
+{
+	struct args *args = new_args(SYSCALL_PERF_EVENT_OPEN);
+	if (!args) {
+		return 0;
+	}
+
+	bpf_probe_read_user(args->attr, sizeof(args->attr), (const void *)ctx->args[0]);
+	if (*(u32 *)args->attr >= PERF_TYPE_DYNAMIC_PMU_START) {
+		// config1 points to the function of a kprobe or the file of an uprobe, it is null for a kprobe on an address
+		const char *name = (const char *)*(u64 *)&args->attr[PERF_ATTR_CONFIG1];
+		bpf_probe_read_user_str(args->name, sizeof(args->name), name);
+	}
+	keep_args(args);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_exit_perf_event_open")
+int tracepoint__sys_exit_perf_event_open(struct trace_event_raw_sys_exit *ctx)
+{
+	return exit_call(ctx);
+}
+
+char _license[] SEC("license") = "GPL";
@@ -0,0 +1,53 @@
+#pragma once
+
+#include "../../../../include/types.h"
+
+#ifndef TASK_COMM_LEN
+#define TASK_COMM_LEN 16
+#endif
+#define NAME_MAX_LEN 256
+// ATTR_SIZE is the size of the attributes of bpf and perf_event_open read, it ends with the expected attach type of
+// PROG_LOAD and the config1 of perf_event_open
+#define ATTR_SIZE 72
+
+// The commands of bpf traced.
+#define BPF_CMD_MAP_CREATE 0
+#define BPF_CMD_PROG_LOAD 5
+#define BPF_CMD_PROG_ATTACH 8
+#define BPF_CMD_RAW_TRACEPOINT_OPEN 17
+#define BPF_CMD_LINK_CREATE 28
+
+// PERF_TYPE_DYNAMIC_PMU_START is the first type of the dynamic PMUs, like kprobe and uprobe, whose config1 points to
+// the function or the file probed.
+#define PERF_TYPE_DYNAMIC_PMU_START 6
+#define PERF_ATTR_CONFIG1 56
+
+// The traced syscalls, the tracer names them.
+enum syscall {
+	SYSCALL_BPF,
+	SYSCALL_PERF_EVENT_OPEN,
+};
+
+// args are the arguments of a call, kept from its entry to its exit
+struct args {
+	__u32 syscall;
+	__u32 cmd;
+	__u8 attr[ATTR_SIZE];
+	__u8 name[NAME_MAX_LEN];
+};
+
+struct event {
+	gadget_timestamp timestamp;
+	gadget_mntns_id mntns_id;
+	__u32 pid;
+	__u32 ppid;
+	__u32 uid;
+	__u32 gid;
+	__u32 syscall;
+	// cmd is the command of bpf
+	__u32 cmd;
+	__u8 attr[ATTR_SIZE];
+	__u8 comm[TASK_COMM_LEN];
+	// name is the raw tracepoint of RAW_TRACEPOINT_OPEN or the function or the file of a dynamic PMU
+	__u8 name[NAME_MAX_LEN];
+};
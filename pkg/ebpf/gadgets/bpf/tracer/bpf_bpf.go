@@ -0,0 +1,153 @@
+// Code generated by bpf2go; DO NOT EDIT.
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type bpfEvent struct {
+	Timestamp uint64
+	MntnsId   uint64
+	Pid       uint32
+	Ppid      uint32
+	Uid       uint32
+	Gid       uint32
+	Syscall   uint32
+	Cmd       uint32
+	Attr      [72]uint8
+	Comm      [16]uint8
+	Name      [256]uint8
+}
+
+// loadBpf returns the embedded CollectionSpec for bpf.
+func loadBpf() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_BpfBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load bpf: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadBpfObjects loads bpf and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*bpfObjects
+//	*bpfPrograms
+//	*bpfMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadBpfObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadBpf()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// bpfSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type bpfSpecs struct {
+	bpfProgramSpecs
+	bpfMapSpecs
+}
+
+// bpfSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type bpfProgramSpecs struct {
+	TracepointSysEnterBpf           *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_bpf"`
+	TracepointSysEnterPerfEventOpen *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_perf_event_open"`
+	TracepointSysExitBpf            *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_bpf"`
+	TracepointSysExitPerfEventOpen  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_perf_event_open"`
+}
+
+// bpfMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type bpfMapSpecs struct {
+	ArgsHeap             *ebpf.MapSpec `ebpf:"args_heap"`
+	BpfArgs              *ebpf.MapSpec `ebpf:"bpf_args"`
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.MapSpec `ebpf:"heap"`
+}
+
+// bpfObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadBpfObjects or ebpf.CollectionSpec.LoadAndAssign.
+type bpfObjects struct {
+	bpfPrograms
+	bpfMaps
+}
+
+func (o *bpfObjects) Close() error {
+	return _BpfClose(
+		&o.bpfPrograms,
+		&o.bpfMaps,
+	)
+}
+
+// bpfMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadBpfObjects or ebpf.CollectionSpec.LoadAndAssign.
+type bpfMaps struct {
+	ArgsHeap             *ebpf.Map `ebpf:"args_heap"`
+	BpfArgs              *ebpf.Map `ebpf:"bpf_args"`
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.Map `ebpf:"heap"`
+}
+
+func (m *bpfMaps) Close() error {
+	return _BpfClose(
+		m.ArgsHeap,
+		m.BpfArgs,
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Heap,
+	)
+}
+
+// bpfPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadBpfObjects or ebpf.CollectionSpec.LoadAndAssign.
+type bpfPrograms struct {
+	TracepointSysEnterBpf           *ebpf.Program `ebpf:"tracepoint__sys_enter_bpf"`
+	TracepointSysEnterPerfEventOpen *ebpf.Program `ebpf:"tracepoint__sys_enter_perf_event_open"`
+	TracepointSysExitBpf            *ebpf.Program `ebpf:"tracepoint__sys_exit_bpf"`
+	TracepointSysExitPerfEventOpen  *ebpf.Program `ebpf:"tracepoint__sys_exit_perf_event_open"`
+}
+
+func (p *bpfPrograms) Close() error {
+	return _BpfClose(
+		p.TracepointSysEnterBpf,
+		p.TracepointSysEnterPerfEventOpen,
+		p.TracepointSysExitBpf,
+		p.TracepointSysExitPerfEventOpen,
+	)
+}
+
+func _BpfClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed bpf_bpf.o
+var _BpfBytes []byte
@@ -0,0 +1,330 @@
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+
+	"node-agent/pkg/ebpf/gadgets/bpf/types"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -no-global-types -target bpf -cc clang -cflags "-g -O2 -Wall" -type event bpf bpf/bpf.bpf.c -- -I./bpf/
+
+// Syscalls are the names of the traced syscalls, by their index in the eBPF program
+var Syscalls = []string{"bpf", "perf_event_open"}
+
+const (
+	bpfSyscall = iota
+	perfEventOpenSyscall
+)
+
+// the commands of bpf traced
+const (
+	bpfMapCreate         = 0
+	bpfProgLoad          = 5
+	bpfProgAttach        = 8
+	bpfRawTracepointOpen = 17
+	bpfLinkCreate        = 28
+)
+
+var commandNames = map[uint32]string{
+	bpfMapCreate:         "MAP_CREATE",
+	bpfProgLoad:          "PROG_LOAD",
+	bpfProgAttach:        "PROG_ATTACH",
+	bpfRawTracepointOpen: "RAW_TRACEPOINT_OPEN",
+	bpfLinkCreate:        "LINK_CREATE",
+}
+
+// the offsets of the fields of the attributes of bpf and perf_event_open, the recorded attributes end with the
+// expected attach type of PROG_LOAD and the config1 of perf_event_open
+const (
+	attrType                = 0
+	attrProgName            = 48
+	attrExpectedAttachType  = 68
+	attrMapName             = 28
+	attrAttachType          = 8
+	perfAttrConfig          = 8
+	bpfObjectNameLen        = 16
+	perfTypeTracepoint      = 2
+	perfTypeDynamicPMUStart = 6
+)
+
+// perfTypeNames are the types of the events of perf_event_open, kprobe and uprobe are dynamic PMUs whose types are
+// read from sysfs
+var perfTypeNames = map[uint32]string{
+	0:                  "hardware",
+	1:                  "software",
+	perfTypeTracepoint: "tracepoint",
+	3:                  "hw_cache",
+	4:                  "raw",
+	5:                  "breakpoint",
+}
+
+// dynamicPMUs are the dynamic PMUs whose attach point is the string pointed by config1
+var dynamicPMUs = []string{"kprobe", "uprobe"}
+
+type Config struct {
+	MountnsMap *ebpf.Map
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+	// sysDir is the sys filesystem of the host, the types of the dynamic PMUs are read from it
+	sysDir    string
+	perfTypes map[uint32]string
+
+	objs   bpfObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+		sysDir:        "/sys",
+	}
+
+	if err := t.install(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Stop stops the tracer
+// TODO: Remove after refactoring
+func (t *Tracer) Stop() {
+	t.close()
+}
+
+func (t *Tracer) close() {
+	for i := range t.links {
+		t.links[i] = gadgets.CloseLink(t.links[i])
+	}
+	t.links = nil
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	t.perfTypes = loadPerfTypes(t.sysDir)
+
+	spec, err := loadBpf()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	for _, tracepoint := range []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"sys_enter_bpf", t.objs.TracepointSysEnterBpf},
+		{"sys_exit_bpf", t.objs.TracepointSysExitBpf},
+		{"sys_enter_perf_event_open", t.objs.TracepointSysEnterPerfEventOpen},
+		{"sys_exit_perf_event_open", t.objs.TracepointSysExitPerfEventOpen},
+	} {
+		l, err := link.Tracepoint("syscalls", tracepoint.name, tracepoint.prog, nil)
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", tracepoint.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.bpfMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+// loadPerfTypes returns the names of the types of perf_event_open, with the types of the dynamic PMUs of the kernel
+func loadPerfTypes(sysDir string) map[uint32]string {
+	perfTypes := make(map[uint32]string, len(perfTypeNames)+len(dynamicPMUs))
+	for perfType, name := range perfTypeNames {
+		perfTypes[perfType] = name
+	}
+	for _, pmu := range dynamicPMUs {
+		content, err := os.ReadFile(filepath.Join(sysDir, "bus", "event_source", "devices", pmu, "type"))
+		if err != nil {
+			continue
+		}
+		perfType, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 32)
+		if err != nil {
+			continue
+		}
+		perfTypes[uint32(perfType)] = pmu
+	}
+	return perfTypes
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*bpfEvent)(unsafe.Pointer(&record.RawSample[0]))
+		event := t.parseEvent(bpfEvent)
+		if event == nil {
+			continue
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(event)
+	}
+}
+
+// parseEvent returns the event of a traced call, or nil when the call is not relevant. The calls are traced at their
+// exit and only the successful ones are reported, the attributes too short for the recorded fields are zero.
+func (t *Tracer) parseEvent(bpfEvent *bpfEvent) *types.Event {
+	if int(bpfEvent.Syscall) >= len(Syscalls) {
+		return nil
+	}
+	attr := bpfEvent.Attr[:]
+	order := binary.NativeEndian
+
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+		Pid:           bpfEvent.Pid,
+		PPid:          bpfEvent.Ppid,
+		Uid:           bpfEvent.Uid,
+		Gid:           bpfEvent.Gid,
+		Comm:          gadgets.FromCString(bpfEvent.Comm[:]),
+		Syscall:       Syscalls[bpfEvent.Syscall],
+	}
+
+	switch bpfEvent.Syscall {
+	case bpfSyscall:
+		command, ok := commandNames[bpfEvent.Cmd]
+		if !ok {
+			return nil
+		}
+		event.Command = command
+		switch bpfEvent.Cmd {
+		case bpfProgLoad:
+			event.ProgramType = ebpf.ProgramType(order.Uint32(attr[attrType:])).String()
+			event.ProgramName = gadgets.FromCString(attr[attrProgName : attrProgName+bpfObjectNameLen])
+			event.AttachType = ebpf.AttachType(order.Uint32(attr[attrExpectedAttachType:])).String()
+			event.Usage = fmt.Sprintf("bpf:%s:%s", command, event.ProgramType)
+		case bpfMapCreate:
+			event.MapType = ebpf.MapType(order.Uint32(attr[attrType:])).String()
+			event.MapName = gadgets.FromCString(attr[attrMapName : attrMapName+bpfObjectNameLen])
+			event.Usage = fmt.Sprintf("bpf:%s:%s", command, event.MapType)
+		case bpfProgAttach, bpfLinkCreate:
+			event.AttachType = ebpf.AttachType(order.Uint32(attr[attrAttachType:])).String()
+			event.Usage = fmt.Sprintf("bpf:%s:%s", command, event.AttachType)
+		case bpfRawTracepointOpen:
+			event.AttachPoint = gadgets.FromCString(bpfEvent.Name[:])
+			event.Usage = fmt.Sprintf("bpf:%s:%s", command, event.AttachPoint)
+		}
+	case perfEventOpenSyscall:
+		perfType := order.Uint32(attr[attrType:])
+		name, ok := t.perfTypes[perfType]
+		if !ok {
+			name = fmt.Sprintf("pmu%d", perfType)
+		}
+		event.PerfType = name
+		event.Usage = "perf_event_open:" + name
+		switch {
+		case perfType == perfTypeTracepoint:
+			// the ids of the tracepoints depend on the kernel, they are not part of the usage
+			event.AttachPoint = strconv.FormatUint(order.Uint64(attr[perfAttrConfig:]), 10)
+		case perfType >= perfTypeDynamicPMUStart && (name == "kprobe" || name == "uprobe"):
+			// config1 points to the function or the file, it is null for a kprobe on an address
+			event.AttachPoint = gadgets.FromCString(bpfEvent.Name[:])
+			if event.AttachPoint != "" {
+				event.Usage += ":" + event.AttachPoint
+			}
+		}
+	}
+
+	return event
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+		sysDir: "/sys",
+	}
+	return tracer, nil
+}
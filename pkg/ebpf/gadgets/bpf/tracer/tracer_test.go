@@ -0,0 +1,181 @@
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"node-agent/pkg/ebpf/gadgets/bpf/types"
+
+	"github.com/cilium/ebpf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bpfAttr returns the attributes of a call, with a 32 bits field at each offset
+func bpfAttr(fields map[int]uint32, name string, nameOffset int) [72]uint8 {
+	var attr [72]uint8
+	for offset, value := range fields {
+		binary.NativeEndian.PutUint32(attr[offset:], value)
+	}
+	copy(attr[nameOffset:], name)
+	return attr
+}
+
+func bpfName(name string) [256]uint8 {
+	var bpfName [256]uint8
+	copy(bpfName[:], name)
+	return bpfName
+}
+
+func TestLoadPerfTypes(t *testing.T) {
+	sysDir := t.TempDir()
+	kprobeDir := filepath.Join(sysDir, "bus", "event_source", "devices", "kprobe")
+	require.NoError(t, os.MkdirAll(kprobeDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(kprobeDir, "type"), []byte("6\n"), 0644))
+
+	perfTypes := loadPerfTypes(sysDir)
+	assert.Equal(t, "kprobe", perfTypes[6])
+	assert.Equal(t, "tracepoint", perfTypes[perfTypeTracepoint])
+	assert.NotContains(t, perfTypes, uint32(7))
+}
+
+func TestParseEvent(t *testing.T) {
+	tracer := &Tracer{perfTypes: loadPerfTypes(t.TempDir())}
+	tracer.perfTypes[6] = "kprobe"
+	tracer.perfTypes[7] = "uprobe"
+
+	tests := []struct {
+		name     string
+		bpfEvent bpfEvent
+		want     *types.Event
+	}{
+		{
+			name: "program load",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: bpfSyscall, Cmd: bpfProgLoad,
+				Attr: bpfAttr(map[int]uint32{attrType: uint32(ebpf.Kprobe)}, "rootkit", attrProgName)},
+			want: &types.Event{Pid: 10, Syscall: "bpf", Command: "PROG_LOAD", ProgramType: "Kprobe", ProgramName: "rootkit",
+				AttachType: "None", Usage: "bpf:PROG_LOAD:Kprobe"},
+		},
+		{
+			name: "map create",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: bpfSyscall, Cmd: bpfMapCreate,
+				Attr: bpfAttr(map[int]uint32{attrType: uint32(ebpf.Hash)}, "pids", attrMapName)},
+			want: &types.Event{Pid: 10, Syscall: "bpf", Command: "MAP_CREATE", MapType: "Hash", MapName: "pids",
+				Usage: "bpf:MAP_CREATE:Hash"},
+		},
+		{
+			name: "link create",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: bpfSyscall, Cmd: bpfLinkCreate,
+				Attr: bpfAttr(map[int]uint32{attrAttachType: uint32(ebpf.AttachTraceFEntry)}, "", 0)},
+			want: &types.Event{Pid: 10, Syscall: "bpf", Command: "LINK_CREATE", AttachType: "TraceFEntry",
+				Usage: "bpf:LINK_CREATE:TraceFEntry"},
+		},
+		{
+			name: "raw tracepoint open",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: bpfSyscall, Cmd: bpfRawTracepointOpen,
+				Attr: bpfAttr(nil, "", 0), Name: bpfName("sys_enter")},
+			want: &types.Event{Pid: 10, Syscall: "bpf", Command: "RAW_TRACEPOINT_OPEN", AttachPoint: "sys_enter",
+				Usage: "bpf:RAW_TRACEPOINT_OPEN:sys_enter"},
+		},
+		{
+			name:     "untraced command",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: bpfSyscall, Cmd: 1},
+		},
+		{
+			name:     "unknown syscall",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: 2},
+		},
+		{
+			name: "kprobe",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: perfEventOpenSyscall,
+				Attr: bpfAttr(map[int]uint32{attrType: 6}, "", 0), Name: bpfName("do_sys_open")},
+			want: &types.Event{Pid: 10, Syscall: "perf_event_open", PerfType: "kprobe", AttachPoint: "do_sys_open",
+				Usage: "perf_event_open:kprobe:do_sys_open"},
+		},
+		{
+			name: "kprobe on an address",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: perfEventOpenSyscall,
+				Attr: bpfAttr(map[int]uint32{attrType: 6}, "", 0)},
+			want: &types.Event{Pid: 10, Syscall: "perf_event_open", PerfType: "kprobe", Usage: "perf_event_open:kprobe"},
+		},
+		{
+			name: "uprobe",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: perfEventOpenSyscall,
+				Attr: bpfAttr(map[int]uint32{attrType: 7}, "", 0), Name: bpfName("/usr/lib/libssl.so.3")},
+			want: &types.Event{Pid: 10, Syscall: "perf_event_open", PerfType: "uprobe", AttachPoint: "/usr/lib/libssl.so.3",
+				Usage: "perf_event_open:uprobe:/usr/lib/libssl.so.3"},
+		},
+		{
+			name: "tracepoint",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: perfEventOpenSyscall,
+				Attr: bpfAttr(map[int]uint32{attrType: perfTypeTracepoint, perfAttrConfig: 321}, "", 0)},
+			want: &types.Event{Pid: 10, Syscall: "perf_event_open", PerfType: "tracepoint", AttachPoint: "321",
+				Usage: "perf_event_open:tracepoint"},
+		},
+		{
+			name: "software event ignores config1",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: perfEventOpenSyscall,
+				Attr: bpfAttr(map[int]uint32{attrType: 1}, "", 0), Name: bpfName("garbage")},
+			want: &types.Event{Pid: 10, Syscall: "perf_event_open", PerfType: "software", Usage: "perf_event_open:software"},
+		},
+		{
+			name: "unknown pmu",
+			bpfEvent: bpfEvent{Pid: 10, Syscall: perfEventOpenSyscall,
+				Attr: bpfAttr(map[int]uint32{attrType: 12}, "", 0)},
+			want: &types.Event{Pid: 10, Syscall: "perf_event_open", PerfType: "pmu12", Usage: "perf_event_open:pmu12"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tracer.parseEvent(&tt.bpfEvent)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			got.Event = tt.want.Event
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestTracer creates a map after a failed creation which is not reported, it is skipped without the privileges to load the tracer
+func TestTracer(t *testing.T) {
+	events := make(chan *types.Event, 100)
+	tracer, err := NewTracer(&Config{}, nil, func(event *types.Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Skipf("loading the tracer: %v", err)
+	}
+	defer tracer.Stop()
+
+	_, err = ebpf.NewMap(&ebpf.MapSpec{Name: "tracer_failed", Type: ebpf.Hash, KeySize: 4, ValueSize: 4, MaxEntries: 0})
+	require.Error(t, err)
+	m, err := ebpf.NewMap(&ebpf.MapSpec{Name: "tracer_test", Type: ebpf.Hash, KeySize: 4, ValueSize: 4, MaxEntries: 1})
+	require.NoError(t, err)
+	defer m.Close()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Pid != uint32(os.Getpid()) {
+				continue
+			}
+			require.NotEqual(t, "tracer_failed", event.MapName)
+			if event.MapName != "tracer_test" {
+				continue
+			}
+			assert.Equal(t, "bpf:MAP_CREATE:Hash", event.Usage)
+			return
+		case <-timeout:
+			t.Fatal("timed out waiting for the event")
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	PPid uint32 `json:"ppid,omitempty" column:"ppid,template:pid"`
+	Uid  uint32 `json:"uid,omitempty" column:"uid,template:uid"`
+	Gid  uint32 `json:"gid,omitempty" column:"gid,template:gid"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+	// Syscall is bpf or perf_event_open
+	Syscall string `json:"syscall,omitempty" column:"syscall,width:16"`
+	// Command is the command of bpf, like PROG_LOAD or MAP_CREATE
+	Command string `json:"command,omitempty" column:"command,width:20"`
+	// ProgramType and ProgramName are the type and the name of the program loaded by PROG_LOAD
+	ProgramType string `json:"programtype,omitempty" column:"programtype,width:16"`
+	ProgramName string `json:"programname,omitempty" column:"programname,width:16"`
+	// MapType and MapName are the type and the name of the map created by MAP_CREATE
+	MapType string `json:"maptype,omitempty" column:"maptype,width:16"`
+	MapName string `json:"mapname,omitempty" column:"mapname,width:16,hide"`
+	// AttachType is the expected attach type of a loaded program or the attach type of PROG_ATTACH and LINK_CREATE
+	AttachType string `json:"attachtype,omitempty" column:"attachtype,width:16"`
+	// PerfType is the type of the event opened by perf_event_open, like kprobe, uprobe or tracepoint
+	PerfType string `json:"perftype,omitempty" column:"perftype,width:10"`
+	// AttachPoint is the function of a kprobe, the file of an uprobe, the id of a tracepoint or the name of a raw
+	// tracepoint
+	AttachPoint string `json:"attachpoint,omitempty" column:"attachpoint,width:32"`
+	// Usage identifies the usage in the activities of the application profile, like bpf:PROG_LOAD:Kprobe or
+	// perf_event_open:kprobe:do_sys_open
+	Usage string `json:"usage,omitempty" column:"usage,width:32"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	bpfColumns := columns.MustCreateColumns[Event]()
+
+	return bpfColumns
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
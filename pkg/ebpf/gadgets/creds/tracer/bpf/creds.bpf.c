@@ -0,0 +1,220 @@
+#include "../../../../include/amd64/vmlinux.h"
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+
+#include "creds.h"
+#include "../../../../include/mntns_filter.h"
+#include "../../../../include/filesystem.h"
+#include "../../../../include/macros.h"
+
+// Events map.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} events SEC(".maps");
+
+// The events are too large for the stack.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct event);
+} heap SEC(".maps");
+
+// The arguments of the calls in progress, by thread.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 10240);
+	__type(key, u64);
+	__type(value, struct args);
+} creds_args SEC(".maps");
+
+// we need this to make sure the compiler doesn't remove our struct.
+const struct event *unusedevent __attribute__((unused));
+
+static __always_inline u64 current_mntns_id(struct task_struct *task)
+{
+	return BPF_CORE_READ(task, nsproxy, mnt_ns, ns.inum);
+}
+
+// new_event returns the event of the current task, the caller filters its mount namespace
+static __always_inline struct event *new_event(struct task_struct *task, u64 mntns_id, u32 syscall)
+{
+	u32 zero = 0;
+	struct event *event = bpf_map_lookup_elem(&heap, &zero);
+	if (!event) {
+		return NULL;
+	}
+
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	u64 uid_gid = bpf_get_current_uid_gid();
+
+	__builtin_memset(event, 0, sizeof(*event));
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->mntns_id = mntns_id;
+	event->pid = pid_tgid >> 32;
+	event->ppid = BPF_CORE_READ(task, real_parent, tgid);
+	event->uid = (u32)uid_gid;
+	event->gid = (u32)(uid_gid >> 32);
+	event->syscall = syscall;
+	bpf_get_current_comm(&event->comm, sizeof(event->comm));
+	return event;
+}
+
+static __always_inline void submit_event(void *ctx, struct event *event)
+{
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+}
+
+// enter_call keeps the nargs arguments of a call and the credentials of the caller until its exit, the credentials
+// are changed when the call exits. The tracepoints only give access to the arguments of their syscall.
+static __always_inline struct args *enter_call(struct trace_event_raw_sys_enter *ctx, u32 syscall, int nargs)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	if (gadget_should_discard_mntns_id(current_mntns_id(task))) {
+		return NULL;
+	}
+
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	u64 uid_gid = bpf_get_current_uid_gid();
+	struct args args = {
+		.syscall = syscall,
+		.uid = (u32)uid_gid,
+		.gid = (u32)(uid_gid >> 32),
+		.args = {ctx->args[0]},
+	};
+	if (nargs > 1) {
+		args.args[1] = ctx->args[1];
+	}
+	if (nargs > 2) {
+		args.args[2] = ctx->args[2];
+	}
+	// the capabilities are two 32 bits words before Linux 6.3 and a 64 bits one since
+	const struct cred *cred = BPF_CORE_READ(task, cred);
+	bpf_core_read(&args.cap_effective, sizeof(args.cap_effective), &cred->cap_effective);
+
+	if (bpf_map_update_elem(&creds_args, &pid_tgid, &args, BPF_ANY)) {
+		return NULL;
+	}
+	return bpf_map_lookup_elem(&creds_args, &pid_tgid);
+}
+
+// exit_call reports the calls which succeeded, the failed calls did not change the credentials
+static __always_inline int exit_call(struct trace_event_raw_sys_exit *ctx)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct args *args = bpf_map_lookup_elem(&creds_args, &pid_tgid);
+	if (!args) {
+		return 0;
+	}
+
+	struct event *event = NULL;
+	if (ctx->ret == 0) {
+		struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+		event = new_event(task, current_mntns_id(task), args->syscall);
+	}
+	if (event) {
+		event->uid = args->uid;
+		event->gid = args->gid;
+		event->cap_effective = args->cap_effective;
+		__builtin_memcpy(event->args, args->args, sizeof(event->args));
+		__builtin_memcpy(event->capset_header, args->capset_header, sizeof(event->capset_header));
+		__builtin_memcpy(event->capset_data, args->capset_data, sizeof(event->capset_data));
+	}
+	bpf_map_delete_elem(&creds_args, &pid_tgid);
+
+	if (event) {
+		submit_event(ctx, event);
+	}
+	return 0;
+}
+
+#define TRACE_SET_ID(name, syscall, nargs)                                                 \
+	SEC("tracepoint/syscalls/sys_enter_" #name)                                        \
+	int tracepoint__sys_enter_##name(struct trace_event_raw_sys_enter *ctx)            \
+	{                                                                                  \
+		enter_call(ctx, syscall, nargs);                                           \
+		return 0;                                                                  \
+	}                                                                                  \
+                                                                                           \
+	SEC("tracepoint/syscalls/sys_exit_" #name)                                         \
+	int tracepoint__sys_exit_##name(struct trace_event_raw_sys_exit *ctx)              \
+	{                                                                                  \
+		return exit_call(ctx);                                                     \
+	}
+
+TRACE_SET_ID(setuid, SYSCALL_SETUID, 1)
+TRACE_SET_ID(setreuid, SYSCALL_SETREUID, 2)
+TRACE_SET_ID(setresuid, SYSCALL_SETRESUID, 3)
+TRACE_SET_ID(setgid, SYSCALL_SETGID, 1)
+TRACE_SET_ID(setregid, SYSCALL_SETREGID, 2)
+TRACE_SET_ID(setresgid, SYSCALL_SETRESGID, 3)
+
+SEC("tracepoint/syscalls/sys_enter_capset")
+int tracepoint__sys_enter_capset(struct trace_event_raw_sys_enter *ctx)
+{
+	struct args *args = enter_call(ctx, SYSCALL_CAPSET, 2);
+	if (!args) {
+		return 0;
+	}
+
+	bpf_probe_read_user(&args->capset_header, sizeof(args->capset_header), (void *)ctx->args[0]);
+	bpf_probe_read_user(&args->capset_data, sizeof(args->capset_data), (void *)ctx->args[1]);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_exit_capset")
+int tracepoint__sys_exit_capset(struct trace_event_raw_sys_exit *ctx)
+{
+	return exit_call(ctx);
+}
+
+// The executions are traced once the new program runs with its credentials, those of the executions of the SUID and
+// SGID files changing the effective ids are reported with the attributes of the executed file.
+SEC("tracepoint/sched/sched_process_exec")
+int tracepoint__sched_process_exec(struct trace_event_raw_sched_process_exec *ctx)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	u64 mntns_id = current_mntns_id(task);
+	if (gadget_should_discard_mntns_id(mntns_id)) {
+		return 0;
+	}
+
+	const struct cred *cred = BPF_CORE_READ(task, cred);
+	u32 uid = BPF_CORE_READ(cred, uid.val);
+	u32 gid = BPF_CORE_READ(cred, gid.val);
+	u32 euid = BPF_CORE_READ(cred, euid.val);
+	u32 egid = BPF_CORE_READ(cred, egid.val);
+	if (euid == uid && egid == gid) {
+		return 0;
+	}
+	struct file *exe_file = BPF_CORE_READ(task, mm, exe_file);
+	struct inode *inode = BPF_CORE_READ(exe_file, f_inode);
+	u32 mode = BPF_CORE_READ(inode, i_mode);
+	if (!(mode & (S_ISUID | S_ISGID))) {
+		return 0;
+	}
+
+	struct event *event = new_event(task, mntns_id, SYSCALL_EXECVE);
+	if (!event) {
+		return 0;
+	}
+	event->uid = uid;
+	event->gid = gid;
+	event->euid = euid;
+	event->egid = egid;
+	event->mode = mode;
+	event->file_uid = BPF_CORE_READ(inode, i_uid.val);
+	event->file_gid = BPF_CORE_READ(inode, i_gid.val);
+	// the path of the executed file in the mount namespace of the process, the links are resolved
+	char *path = get_path_str(&exe_file->f_path);
+	if (path) {
+		bpf_probe_read_kernel_str(&event->path, sizeof(event->path), path);
+	}
+	submit_event(ctx, event);
+	return 0;
+}
+
+char _license[] SEC("license") = "GPL";
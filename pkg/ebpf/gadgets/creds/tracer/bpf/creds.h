@@ -0,0 +1,62 @@
+#pragma once
+
+#include "../../../../include/types.h"
+
+#ifndef TASK_COMM_LEN
+#define TASK_COMM_LEN 16
+#endif
+#define PATH_MAX_LEN 256
+// CAPSET_HEADER_SIZE is the size of the version and the pid of capset, CAPSET_DATA_SIZE the size of the two
+// capabilities structs of its version 2 and 3
+#define CAPSET_HEADER_SIZE 8
+#define CAPSET_DATA_SIZE 24
+
+#define S_ISUID 0004000
+#define S_ISGID 0002000
+
+// The traced syscalls, the tracer names them. The executions are traced by the sched_process_exec tracepoint.
+enum syscall {
+	SYSCALL_SETUID,
+	SYSCALL_SETREUID,
+	SYSCALL_SETRESUID,
+	SYSCALL_SETGID,
+	SYSCALL_SETREGID,
+	SYSCALL_SETRESGID,
+	SYSCALL_CAPSET,
+	SYSCALL_EXECVE,
+};
+
+// args are the arguments of a call with the credentials of the caller, kept from its entry to its exit
+struct args {
+	__u32 syscall;
+	__u32 uid;
+	__u32 gid;
+	__u64 cap_effective;
+	__u64 args[3];
+	__u8 capset_header[CAPSET_HEADER_SIZE];
+	__u8 capset_data[CAPSET_DATA_SIZE];
+};
+
+struct event {
+	gadget_timestamp timestamp;
+	gadget_mntns_id mntns_id;
+	__u32 pid;
+	__u32 ppid;
+	// uid and gid are the real ids of the caller before the call
+	__u32 uid;
+	__u32 gid;
+	__u32 syscall;
+	__u64 cap_effective;
+	__u64 args[3];
+	__u8 capset_header[CAPSET_HEADER_SIZE];
+	__u8 capset_data[CAPSET_DATA_SIZE];
+	// euid and egid are the effective ids of the process after an execution, mode, file_uid and file_gid the
+	// attributes of the executed file
+	__u32 euid;
+	__u32 egid;
+	__u32 mode;
+	__u32 file_uid;
+	__u32 file_gid;
+	__u8 comm[TASK_COMM_LEN];
+	__u8 path[PATH_MAX_LEN];
+};
@@ -0,0 +1,195 @@
+// Code generated by bpf2go; DO NOT EDIT.
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type credsEvent struct {
+	Timestamp    uint64
+	MntnsId      uint64
+	Pid          uint32
+	Ppid         uint32
+	Uid          uint32
+	Gid          uint32
+	Syscall      uint32
+	_            [4]byte
+	CapEffective uint64
+	Args         [3]uint64
+	CapsetHeader [8]uint8
+	CapsetData   [24]uint8
+	Euid         uint32
+	Egid         uint32
+	Mode         uint32
+	FileUid      uint32
+	FileGid      uint32
+	Comm         [16]uint8
+	Path         [256]uint8
+	_            [4]byte
+}
+
+// loadCreds returns the embedded CollectionSpec for creds.
+func loadCreds() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_CredsBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load creds: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadCredsObjects loads creds and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*credsObjects
+//	*credsPrograms
+//	*credsMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadCredsObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadCreds()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// credsSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type credsSpecs struct {
+	credsProgramSpecs
+	credsMapSpecs
+}
+
+// credsSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type credsProgramSpecs struct {
+	TracepointSchedProcessExec  *ebpf.ProgramSpec `ebpf:"tracepoint__sched_process_exec"`
+	TracepointSysEnterCapset    *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_capset"`
+	TracepointSysEnterSetgid    *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_setgid"`
+	TracepointSysEnterSetregid  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_setregid"`
+	TracepointSysEnterSetresgid *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_setresgid"`
+	TracepointSysEnterSetresuid *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_setresuid"`
+	TracepointSysEnterSetreuid  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_setreuid"`
+	TracepointSysEnterSetuid    *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_setuid"`
+	TracepointSysExitCapset     *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_capset"`
+	TracepointSysExitSetgid     *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_setgid"`
+	TracepointSysExitSetregid   *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_setregid"`
+	TracepointSysExitSetresgid  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_setresgid"`
+	TracepointSysExitSetresuid  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_setresuid"`
+	TracepointSysExitSetreuid   *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_setreuid"`
+	TracepointSysExitSetuid     *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_setuid"`
+}
+
+// credsMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type credsMapSpecs struct {
+	Bufs                 *ebpf.MapSpec `ebpf:"bufs"`
+	CredsArgs            *ebpf.MapSpec `ebpf:"creds_args"`
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.MapSpec `ebpf:"heap"`
+}
+
+// credsObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadCredsObjects or ebpf.CollectionSpec.LoadAndAssign.
+type credsObjects struct {
+	credsPrograms
+	credsMaps
+}
+
+func (o *credsObjects) Close() error {
+	return _CredsClose(
+		&o.credsPrograms,
+		&o.credsMaps,
+	)
+}
+
+// credsMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadCredsObjects or ebpf.CollectionSpec.LoadAndAssign.
+type credsMaps struct {
+	Bufs                 *ebpf.Map `ebpf:"bufs"`
+	CredsArgs            *ebpf.Map `ebpf:"creds_args"`
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.Map `ebpf:"heap"`
+}
+
+func (m *credsMaps) Close() error {
+	return _CredsClose(
+		m.Bufs,
+		m.CredsArgs,
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Heap,
+	)
+}
+
+// credsPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadCredsObjects or ebpf.CollectionSpec.LoadAndAssign.
+type credsPrograms struct {
+	TracepointSchedProcessExec  *ebpf.Program `ebpf:"tracepoint__sched_process_exec"`
+	TracepointSysEnterCapset    *ebpf.Program `ebpf:"tracepoint__sys_enter_capset"`
+	TracepointSysEnterSetgid    *ebpf.Program `ebpf:"tracepoint__sys_enter_setgid"`
+	TracepointSysEnterSetregid  *ebpf.Program `ebpf:"tracepoint__sys_enter_setregid"`
+	TracepointSysEnterSetresgid *ebpf.Program `ebpf:"tracepoint__sys_enter_setresgid"`
+	TracepointSysEnterSetresuid *ebpf.Program `ebpf:"tracepoint__sys_enter_setresuid"`
+	TracepointSysEnterSetreuid  *ebpf.Program `ebpf:"tracepoint__sys_enter_setreuid"`
+	TracepointSysEnterSetuid    *ebpf.Program `ebpf:"tracepoint__sys_enter_setuid"`
+	TracepointSysExitCapset     *ebpf.Program `ebpf:"tracepoint__sys_exit_capset"`
+	TracepointSysExitSetgid     *ebpf.Program `ebpf:"tracepoint__sys_exit_setgid"`
+	TracepointSysExitSetregid   *ebpf.Program `ebpf:"tracepoint__sys_exit_setregid"`
+	TracepointSysExitSetresgid  *ebpf.Program `ebpf:"tracepoint__sys_exit_setresgid"`
+	TracepointSysExitSetresuid  *ebpf.Program `ebpf:"tracepoint__sys_exit_setresuid"`
+	TracepointSysExitSetreuid   *ebpf.Program `ebpf:"tracepoint__sys_exit_setreuid"`
+	TracepointSysExitSetuid     *ebpf.Program `ebpf:"tracepoint__sys_exit_setuid"`
+}
+
+func (p *credsPrograms) Close() error {
+	return _CredsClose(
+		p.TracepointSchedProcessExec,
+		p.TracepointSysEnterCapset,
+		p.TracepointSysEnterSetgid,
+		p.TracepointSysEnterSetregid,
+		p.TracepointSysEnterSetresgid,
+		p.TracepointSysEnterSetresuid,
+		p.TracepointSysEnterSetreuid,
+		p.TracepointSysEnterSetuid,
+		p.TracepointSysExitCapset,
+		p.TracepointSysExitSetgid,
+		p.TracepointSysExitSetregid,
+		p.TracepointSysExitSetresgid,
+		p.TracepointSysExitSetresuid,
+		p.TracepointSysExitSetreuid,
+		p.TracepointSysExitSetuid,
+	)
+}
+
+func _CredsClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed creds_bpf.o
+var _CredsBytes []byte
@@ -0,0 +1,371 @@
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+	"golang.org/x/sys/unix"
+
+	"node-agent/pkg/ebpf/gadgets/creds/types"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -no-global-types -target bpf -cc clang -cflags "-g -O2 -Wall" -type event creds bpf/creds.bpf.c -- -I./bpf/
+
+const (
+	setuidSyscall = iota
+	setreuidSyscall
+	setresuidSyscall
+	setgidSyscall
+	setregidSyscall
+	setresgidSyscall
+	capsetSyscall
+	execveSyscall
+)
+
+// Syscalls are the names of the traced syscalls, by their index in the eBPF program. The executions are traced once
+// the new program runs, by the sched_process_exec tracepoint.
+var Syscalls = []string{"setuid", "setreuid", "setresuid", "setgid", "setregid", "setresgid", "capset", "execve"}
+
+const (
+	// unchangedId is the -1 argument of the set*id calls keeping an id
+	unchangedId = 0xffffffff
+	// capsetVersion1 only sets the 32 lower capabilities with a single struct
+	capsetVersion1 = 0x19980330
+)
+
+// newIdArgs are the arguments of the set*id calls in the order they are looked up for the new id: the effective id
+// first since it is the one used for the permission checks
+var newIdArgs = map[uint32][]int{
+	setuidSyscall:    {0},
+	setreuidSyscall:  {1, 0},
+	setresuidSyscall: {1, 0, 2},
+	setgidSyscall:    {0},
+	setregidSyscall:  {1, 0},
+	setresgidSyscall: {1, 0, 2},
+}
+
+// capabilitiesNames are the names of the capabilities, as reported by the capabilities gadget in the application
+// profile
+var capabilitiesNames = map[int]string{
+	0:  "CHOWN",
+	1:  "DAC_OVERRIDE",
+	2:  "DAC_READ_SEARCH",
+	3:  "FOWNER",
+	4:  "FSETID",
+	5:  "KILL",
+	6:  "SETGID",
+	7:  "SETUID",
+	8:  "SETPCAP",
+	9:  "LINUX_IMMUTABLE",
+	10: "NET_BIND_SERVICE",
+	11: "NET_BROADCAST",
+	12: "NET_ADMIN",
+	13: "NET_RAW",
+	14: "IPC_LOCK",
+	15: "IPC_OWNER",
+	16: "SYS_MODULE",
+	17: "SYS_RAWIO",
+	18: "SYS_CHROOT",
+	19: "SYS_PTRACE",
+	20: "SYS_PACCT",
+	21: "SYS_ADMIN",
+	22: "SYS_BOOT",
+	23: "SYS_NICE",
+	24: "SYS_RESOURCE",
+	25: "SYS_TIME",
+	26: "SYS_TTY_CONFIG",
+	27: "MKNOD",
+	28: "LEASE",
+	29: "AUDIT_WRITE",
+	30: "AUDIT_CONTROL",
+	31: "SETFCAP",
+	32: "MAC_OVERRIDE",
+	33: "MAC_ADMIN",
+	34: "SYSLOG",
+	35: "WAKE_ALARM",
+	36: "BLOCK_SUSPEND",
+	37: "AUDIT_READ",
+	38: "PERFMON",
+	39: "BPF",
+	40: "CHECKPOINT_RESTORE",
+}
+
+type Config struct {
+	MountnsMap *ebpf.Map
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+
+	objs   credsObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+	}
+
+	if err := t.install(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Stop stops the tracer
+// TODO: Remove after refactoring
+func (t *Tracer) Stop() {
+	t.close()
+}
+
+func (t *Tracer) close() {
+	for i := range t.links {
+		t.links[i] = gadgets.CloseLink(t.links[i])
+	}
+	t.links = nil
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadCreds()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	for _, tracepoint := range []struct {
+		group string
+		name  string
+		prog  *ebpf.Program
+	}{
+		{"syscalls", "sys_enter_setuid", t.objs.TracepointSysEnterSetuid},
+		{"syscalls", "sys_exit_setuid", t.objs.TracepointSysExitSetuid},
+		{"syscalls", "sys_enter_setreuid", t.objs.TracepointSysEnterSetreuid},
+		{"syscalls", "sys_exit_setreuid", t.objs.TracepointSysExitSetreuid},
+		{"syscalls", "sys_enter_setresuid", t.objs.TracepointSysEnterSetresuid},
+		{"syscalls", "sys_exit_setresuid", t.objs.TracepointSysExitSetresuid},
+		{"syscalls", "sys_enter_setgid", t.objs.TracepointSysEnterSetgid},
+		{"syscalls", "sys_exit_setgid", t.objs.TracepointSysExitSetgid},
+		{"syscalls", "sys_enter_setregid", t.objs.TracepointSysEnterSetregid},
+		{"syscalls", "sys_exit_setregid", t.objs.TracepointSysExitSetregid},
+		{"syscalls", "sys_enter_setresgid", t.objs.TracepointSysEnterSetresgid},
+		{"syscalls", "sys_exit_setresgid", t.objs.TracepointSysExitSetresgid},
+		{"syscalls", "sys_enter_capset", t.objs.TracepointSysEnterCapset},
+		{"syscalls", "sys_exit_capset", t.objs.TracepointSysExitCapset},
+		{"sched", "sched_process_exec", t.objs.TracepointSchedProcessExec},
+	} {
+		l, err := link.Tracepoint(tracepoint.group, tracepoint.name, tracepoint.prog, nil)
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", tracepoint.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.credsMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*credsEvent)(unsafe.Pointer(&record.RawSample[0]))
+		event := parseEvent(bpfEvent)
+		if event == nil {
+			continue
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(event)
+	}
+}
+
+// parseEvent returns the event of a traced call, or nil when the call does not change the credentials. The calls are
+// traced at their exit and only the successful ones are reported.
+func parseEvent(bpfEvent *credsEvent) *types.Event {
+	if int(bpfEvent.Syscall) >= len(Syscalls) {
+		return nil
+	}
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+		Pid:           bpfEvent.Pid,
+		PPid:          bpfEvent.Ppid,
+		Uid:           bpfEvent.Uid,
+		Gid:           bpfEvent.Gid,
+		Comm:          gadgets.FromCString(bpfEvent.Comm[:]),
+		Syscall:       Syscalls[bpfEvent.Syscall],
+	}
+
+	switch bpfEvent.Syscall {
+	case setuidSyscall, setreuidSyscall, setresuidSyscall:
+		id, ok := newId(bpfEvent, newIdArgs[bpfEvent.Syscall])
+		if !ok || id == bpfEvent.Uid {
+			return nil
+		}
+		event.OldId, event.NewId = bpfEvent.Uid, id
+		event.Transition = fmt.Sprintf("uid:%d->%d", bpfEvent.Uid, id)
+	case setgidSyscall, setregidSyscall, setresgidSyscall:
+		id, ok := newId(bpfEvent, newIdArgs[bpfEvent.Syscall])
+		if !ok || id == bpfEvent.Gid {
+			return nil
+		}
+		event.OldId, event.NewId = bpfEvent.Gid, id
+		event.Transition = fmt.Sprintf("gid:%d->%d", bpfEvent.Gid, id)
+	case capsetSyscall:
+		added := addedCapabilities(bpfEvent)
+		if added == "" {
+			return nil
+		}
+		event.Capabilities = added
+		event.Transition = "caps:+" + added
+	case execveSyscall:
+		// the effective ids are those the execution gave to the process, they only come from the file when they are
+		// its owners
+		path := gadgets.FromCString(bpfEvent.Path[:])
+		switch {
+		case bpfEvent.Mode&unix.S_ISUID != 0 && bpfEvent.Euid == bpfEvent.FileUid && bpfEvent.Euid != bpfEvent.Uid:
+			event.OldId, event.NewId = bpfEvent.Uid, bpfEvent.Euid
+			event.Transition = "suid:" + path
+		case bpfEvent.Mode&(unix.S_ISGID|unix.S_IXGRP) == unix.S_ISGID|unix.S_IXGRP && bpfEvent.Egid == bpfEvent.FileGid && bpfEvent.Egid != bpfEvent.Gid:
+			// the set-group-ID bit without the group execution bit marks the files with mandatory locking
+			event.OldId, event.NewId = bpfEvent.Gid, bpfEvent.Egid
+			event.Transition = "sgid:" + path
+		default:
+			return nil
+		}
+		event.Path = path
+	}
+
+	return event
+}
+
+// newId returns the first id set by a set*id call, or false when the call keeps all the ids
+func newId(bpfEvent *credsEvent, args []int) (uint32, bool) {
+	for _, arg := range args {
+		if id := uint32(bpfEvent.Args[arg]); id != unchangedId {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// addedCapabilities returns the names of the capabilities capset adds to the effective set of the task, joined with
+// commas. Dropping capabilities is not a privilege change.
+func addedCapabilities(bpfEvent *credsEvent) string {
+	order := binary.NativeEndian
+	header, data := bpfEvent.CapsetHeader[:], bpfEvent.CapsetData[:]
+	// the header is the version and the pid, the data is the effective, permitted and inheritable sets of the lower
+	// and upper capabilities
+	effective := uint64(order.Uint32(data[0:]))
+	if order.Uint32(header[0:]) != capsetVersion1 {
+		effective |= uint64(order.Uint32(data[12:])) << 32
+	}
+	added := effective &^ bpfEvent.CapEffective
+
+	var names []string
+	for i := 0; i < 64; i++ {
+		if added&(1<<i) != 0 {
+			names = append(names, capabilityName(i))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func capabilityName(capability int) string {
+	if name, ok := capabilitiesNames[capability]; ok {
+		return name
+	}
+	return fmt.Sprintf("CAP_%d", capability)
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
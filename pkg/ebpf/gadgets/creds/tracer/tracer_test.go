@@ -0,0 +1,221 @@
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"node-agent/pkg/ebpf/gadgets/creds/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capsetEvent returns the event of a capset call setting the effective capabilities
+func capsetEvent(version uint32, effective, capEffective uint64) credsEvent {
+	bpfEvent := credsEvent{Pid: 10, Syscall: capsetSyscall, CapEffective: capEffective}
+	binary.NativeEndian.PutUint32(bpfEvent.CapsetHeader[:], version)
+	binary.NativeEndian.PutUint32(bpfEvent.CapsetData[0:], uint32(effective))
+	binary.NativeEndian.PutUint32(bpfEvent.CapsetData[12:], uint32(effective>>32))
+	return bpfEvent
+}
+
+func bpfPath(path string) [256]uint8 {
+	var bpfPath [256]uint8
+	copy(bpfPath[:], path)
+	return bpfPath
+}
+
+func TestParseEvent(t *testing.T) {
+	const (
+		netAdmin = uint64(1) << 12
+		netRaw   = uint64(1) << 13
+		bpf      = uint64(1) << 39
+	)
+
+	tests := []struct {
+		name     string
+		bpfEvent credsEvent
+		want     *types.Event
+	}{
+		{
+			name:     "setuid",
+			bpfEvent: credsEvent{Pid: 10, Syscall: setuidSyscall, Args: [3]uint64{1000}},
+			want:     &types.Event{Pid: 10, Syscall: "setuid", NewId: 1000, Transition: "uid:0->1000"},
+		},
+		{
+			name:     "setuid to the same user",
+			bpfEvent: credsEvent{Pid: 10, Uid: 1000, Syscall: setuidSyscall, Args: [3]uint64{1000}},
+		},
+		{
+			name:     "setresuid effective user",
+			bpfEvent: credsEvent{Pid: 10, Uid: 1000, Syscall: setresuidSyscall, Args: [3]uint64{unchangedId, 0, unchangedId}},
+			want:     &types.Event{Pid: 10, Uid: 1000, Syscall: "setresuid", OldId: 1000, Transition: "uid:1000->0"},
+		},
+		{
+			name:     "setresuid with 64 bits unchanged ids",
+			bpfEvent: credsEvent{Pid: 10, Syscall: setresuidSyscall, Args: [3]uint64{^uint64(0), ^uint64(0), 1000}},
+			want:     &types.Event{Pid: 10, Syscall: "setresuid", NewId: 1000, Transition: "uid:0->1000"},
+		},
+		{
+			name:     "setresuid keeping all the ids",
+			bpfEvent: credsEvent{Pid: 10, Syscall: setresuidSyscall, Args: [3]uint64{unchangedId, unchangedId, unchangedId}},
+		},
+		{
+			name:     "setregid real group",
+			bpfEvent: credsEvent{Pid: 10, Syscall: setregidSyscall, Args: [3]uint64{101, unchangedId}},
+			want:     &types.Event{Pid: 10, Syscall: "setregid", NewId: 101, Transition: "gid:0->101"},
+		},
+		{
+			name:     "capset adding capabilities",
+			bpfEvent: capsetEvent(0x20080522, netAdmin|netRaw|bpf, netAdmin),
+			want:     &types.Event{Pid: 10, Syscall: "capset", Capabilities: "NET_RAW,BPF", Transition: "caps:+NET_RAW,BPF"},
+		},
+		{
+			name:     "capset version 1 only sets the lower capabilities",
+			bpfEvent: capsetEvent(capsetVersion1, netRaw|bpf, 0),
+			want:     &types.Event{Pid: 10, Syscall: "capset", Capabilities: "NET_RAW", Transition: "caps:+NET_RAW"},
+		},
+		{
+			name:     "capset dropping capabilities",
+			bpfEvent: capsetEvent(0x20080522, netRaw, netAdmin|netRaw),
+		},
+		{
+			name:     "execve suid file",
+			bpfEvent: credsEvent{Pid: 10, Uid: 1000, Syscall: execveSyscall, Euid: 0, Egid: 0, Mode: 0104755, Path: bpfPath("/usr/bin/passwd")},
+			want:     &types.Event{Pid: 10, Uid: 1000, Syscall: "execve", OldId: 1000, NewId: 0, Path: "/usr/bin/passwd", Transition: "suid:/usr/bin/passwd"},
+		},
+		{
+			name:     "execve suid file keeping the effective user",
+			bpfEvent: credsEvent{Pid: 10, Uid: 1000, Gid: 1000, Syscall: execveSyscall, Euid: 1000, Egid: 0, Mode: 0104755, Path: bpfPath("/usr/bin/passwd")},
+		},
+		{
+			name:     "execve sgid file",
+			bpfEvent: credsEvent{Pid: 10, Uid: 1000, Gid: 1000, Syscall: execveSyscall, Euid: 1000, Egid: 5, Mode: 0102755, FileGid: 5, Path: bpfPath("/usr/bin/wall")},
+			want:     &types.Event{Pid: 10, Uid: 1000, Gid: 1000, Syscall: "execve", OldId: 1000, NewId: 5, Path: "/usr/bin/wall", Transition: "sgid:/usr/bin/wall"},
+		},
+		{
+			name:     "execve mandatory locking file",
+			bpfEvent: credsEvent{Pid: 10, Uid: 1000, Gid: 1000, Syscall: execveSyscall, Euid: 1000, Egid: 5, Mode: 0102745, FileGid: 5, Path: bpfPath("/usr/bin/locked")},
+		},
+		{
+			name:     "execve with effective ids not from the file",
+			bpfEvent: credsEvent{Pid: 10, Uid: 1000, Syscall: execveSyscall, Euid: 0, Mode: 0104755, FileUid: 2, Path: bpfPath("/usr/bin/passwd")},
+		},
+		{
+			name:     "unknown syscall",
+			bpfEvent: credsEvent{Pid: 10, Syscall: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseEvent(&tt.bpfEvent)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			got.Event = tt.want.Event
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestTracer runs a process as another user, it is skipped without the privileges to do so
+func TestTracer(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing the user of a process needs root")
+	}
+	events := make(chan *types.Event, 100)
+	tracer, err := NewTracer(&Config{}, nil, func(event *types.Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Skipf("loading the tracer: %v", err)
+	}
+	defer tracer.Stop()
+
+	cmd := exec.Command("true")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{Uid: 65534, Gid: 65534}}
+	require.NoError(t, cmd.Run())
+
+	var setuid, setgid *types.Event
+	timeout := time.After(5 * time.Second)
+	for setuid == nil || setgid == nil {
+		select {
+		case event := <-events:
+			if event.Pid != uint32(cmd.Process.Pid) {
+				continue
+			}
+			switch event.Syscall {
+			case "setuid":
+				setuid = event
+			case "setgid":
+				setgid = event
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the events")
+		}
+	}
+
+	assert.Equal(t, "uid:0->65534", setuid.Transition)
+	assert.Equal(t, "gid:0->65534", setgid.Transition)
+	assert.Equal(t, uint32(os.Getpid()), setuid.PPid)
+}
+
+// TestTracerSuid executes a SUID file of another user, it is skipped without the privileges to create it
+func TestTracerSuid(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("creating a SUID file of another user needs root")
+	}
+	events := make(chan *types.Event, 100)
+	tracer, err := NewTracer(&Config{}, nil, func(event *types.Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Skipf("loading the tracer: %v", err)
+	}
+	defer tracer.Stop()
+
+	dir := t.TempDir()
+	var stat unix.Statfs_t
+	require.NoError(t, unix.Statfs(dir, &stat))
+	if stat.Flags&(unix.ST_NOSUID|unix.ST_NOEXEC) != 0 {
+		t.Skip("the temporary directory does not allow SUID executions")
+	}
+	truePath, err := exec.LookPath("true")
+	require.NoError(t, err)
+	content, err := os.ReadFile(truePath)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "true")
+	require.NoError(t, os.WriteFile(path, content, 0755))
+	require.NoError(t, os.Chown(path, 65534, 65534))
+	require.NoError(t, os.Chmod(path, 0755|os.ModeSetuid))
+
+	cmd := exec.Command(path)
+	require.NoError(t, cmd.Run())
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Pid != uint32(cmd.Process.Pid) || event.Syscall != "execve" {
+				continue
+			}
+			assert.Equal(t, "suid:"+path, event.Transition)
+			assert.Equal(t, uint32(0), event.OldId)
+			assert.Equal(t, uint32(65534), event.NewId)
+			return
+		case <-timeout:
+			t.Fatal("timed out waiting for the event")
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	PPid uint32 `json:"ppid,omitempty" column:"ppid,template:pid"`
+	Uid  uint32 `json:"uid,omitempty" column:"uid,template:uid"`
+	Gid  uint32 `json:"gid,omitempty" column:"gid,template:gid"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+	// Syscall is one of the set*uid and set*gid calls, capset or execve for the executions of SUID and SGID files
+	Syscall string `json:"syscall,omitempty" column:"syscall,width:10"`
+	// OldId and NewId are the user or group ids before and after the change, NewId is the owner of the file for the
+	// executions of SUID and SGID files
+	OldId uint32 `json:"oldid" column:"oldid,template:uid"`
+	NewId uint32 `json:"newid" column:"newid,template:uid"`
+	// Capabilities are the capabilities added to the effective set by capset, like NET_ADMIN,SYS_ADMIN
+	Capabilities string `json:"capabilities,omitempty" column:"capabilities,width:32"`
+	// Path is the executed SUID or SGID file
+	Path string `json:"path,omitempty" column:"path,width:32"`
+	// Transition identifies the change of credentials in the application profile, like uid:0->1000,
+	// caps:+NET_ADMIN or suid:/usr/bin/passwd
+	Transition string `json:"transition,omitempty" column:"transition,width:32"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	credsColumns := columns.MustCreateColumns[Event]()
+
+	return credsColumns
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
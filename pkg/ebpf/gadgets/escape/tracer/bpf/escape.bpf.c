@@ -0,0 +1,291 @@
+#include "../../../../include/amd64/vmlinux.h"
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+
+#include "escape.h"
+#include "../../../../include/mntns_filter.h"
+#include "../../../../include/macros.h"
+
+// Events map.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} events SEC(".maps");
+
+// The events are too large for the stack.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct event);
+} heap SEC(".maps");
+
+// The namespaces joined by the setns calls in progress, by thread.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 10240);
+	__type(key, u64);
+	__type(value, struct setns_args);
+} setns_args SEC(".maps");
+
+// we need this to make sure the compiler doesn't remove our struct.
+const struct event *unusedevent __attribute__((unused));
+
+// Linux 6.18 moved the type of the namespaces from their operations to their common part.
+struct ns_common___new {
+	u32 ns_type;
+} __attribute__((preserve_access_index));
+
+struct proc_ns_operations___old {
+	int type;
+} __attribute__((preserve_access_index));
+
+static __always_inline u64 task_mntns_id(struct task_struct *task)
+{
+	return BPF_CORE_READ(task, nsproxy, mnt_ns, ns.inum);
+}
+
+// new_event returns the event of the current task in the mount namespace mntns_id, the caller filters the namespace
+static __always_inline struct event *new_event(u32 syscall, u64 mntns_id)
+{
+	u32 zero = 0;
+	struct event *event = bpf_map_lookup_elem(&heap, &zero);
+	if (!event) {
+		return NULL;
+	}
+
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	u64 uid_gid = bpf_get_current_uid_gid();
+
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->mntns_id = mntns_id;
+	event->pid = pid_tgid >> 32;
+	event->ppid = BPF_CORE_READ(task, real_parent, tgid);
+	event->uid = (u32)uid_gid;
+	event->gid = (u32)(uid_gid >> 32);
+	event->syscall = syscall;
+	event->flags = 0;
+	__builtin_memset(event->namespaces, 0, sizeof(event->namespaces));
+	event->source[0] = '\0';
+	event->target[0] = '\0';
+	event->fstype[0] = '\0';
+	bpf_get_current_comm(&event->comm, sizeof(event->comm));
+	return event;
+}
+
+// current_event returns the event of the current task, or NULL when the task is not traced
+static __always_inline struct event *current_event(u32 syscall)
+{
+	u64 mntns_id = task_mntns_id((struct task_struct *)bpf_get_current_task());
+	if (gadget_should_discard_mntns_id(mntns_id)) {
+		return NULL;
+	}
+	return new_event(syscall, mntns_id);
+}
+
+static __always_inline void submit_event(void *ctx, struct event *event)
+{
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+}
+
+SEC("tracepoint/syscalls/sys_enter_mount")
+int tracepoint__sys_enter_mount(struct trace_event_raw_sys_enter *ctx)
+{
+	struct event *event = current_event(SYSCALL_MOUNT);
+	if (!event) {
+		return 0;
+	}
+
+	// the source and the file system type of the remounts and of the bind mounts may be NULL
+	if (bpf_probe_read_user_str(&event->source, sizeof(event->source), (const char *)ctx->args[0]) < 0) {
+		event->source[0] = '\0';
+	}
+	if (bpf_probe_read_user_str(&event->target, sizeof(event->target), (const char *)ctx->args[1]) < 0) {
+		event->target[0] = '\0';
+	}
+	if (bpf_probe_read_user_str(&event->fstype, sizeof(event->fstype), (const char *)ctx->args[2]) < 0) {
+		event->fstype[0] = '\0';
+	}
+	event->flags = ctx->args[3];
+	submit_event(ctx, event);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_pivot_root")
+int tracepoint__sys_enter_pivot_root(struct trace_event_raw_sys_enter *ctx)
+{
+	struct event *event = current_event(SYSCALL_PIVOT_ROOT);
+	if (!event) {
+		return 0;
+	}
+
+	if (bpf_probe_read_user_str(&event->source, sizeof(event->source), (const char *)ctx->args[0]) < 0) {
+		event->source[0] = '\0';
+	}
+	if (bpf_probe_read_user_str(&event->target, sizeof(event->target), (const char *)ctx->args[1]) < 0) {
+		event->target[0] = '\0';
+	}
+	submit_event(ctx, event);
+	return 0;
+}
+
+static __always_inline void set_namespace(struct setns_args *args, u32 type, u32 inum)
+{
+	switch (type) {
+	case CLONE_NEWNS:
+		args->namespaces[NAMESPACE_MNT] = inum;
+		break;
+	case CLONE_NEWNET:
+		args->namespaces[NAMESPACE_NET] = inum;
+		break;
+	case CLONE_NEWUTS:
+		args->namespaces[NAMESPACE_UTS] = inum;
+		break;
+	case CLONE_NEWIPC:
+		args->namespaces[NAMESPACE_IPC] = inum;
+		break;
+	case CLONE_NEWPID:
+		args->namespaces[NAMESPACE_PID] = inum;
+		break;
+	case CLONE_NEWCGROUP:
+		args->namespaces[NAMESPACE_CGROUP] = inum;
+		break;
+	case CLONE_NEWUSER:
+		args->namespaces[NAMESPACE_USER] = inum;
+		break;
+	case CLONE_NEWTIME:
+		args->namespaces[NAMESPACE_TIME] = inum;
+		break;
+	}
+}
+
+// ns_type returns the CLONE_NEW* type of a namespace
+static __always_inline u32 ns_type(struct ns_common *ns)
+{
+	if (bpf_core_field_exists(((struct ns_common___new *)ns)->ns_type)) {
+		return BPF_CORE_READ((struct ns_common___new *)ns, ns_type);
+	}
+	const struct proc_ns_operations___old *ops = (const void *)BPF_CORE_READ(ns, ops);
+	return BPF_CORE_READ(ops, type);
+}
+
+// task_namespaces sets the namespaces of a task selected by the CLONE_NEW* flags, those a pidfd joins
+static __always_inline void task_namespaces(struct setns_args *args, struct task_struct *task, u64 flags)
+{
+	struct nsproxy *nsproxy = BPF_CORE_READ(task, nsproxy);
+	if (!nsproxy) {
+		return;
+	}
+
+	if (flags & CLONE_NEWNS) {
+		args->namespaces[NAMESPACE_MNT] = BPF_CORE_READ(nsproxy, mnt_ns, ns.inum);
+	}
+	if (flags & CLONE_NEWNET) {
+		args->namespaces[NAMESPACE_NET] = BPF_CORE_READ(nsproxy, net_ns, ns.inum);
+	}
+	if (flags & CLONE_NEWUTS) {
+		args->namespaces[NAMESPACE_UTS] = BPF_CORE_READ(nsproxy, uts_ns, ns.inum);
+	}
+	if (flags & CLONE_NEWIPC) {
+		args->namespaces[NAMESPACE_IPC] = BPF_CORE_READ(nsproxy, ipc_ns, ns.inum);
+	}
+	if (flags & CLONE_NEWPID) {
+		// the PID namespace of the process, not the one of its children
+		struct pid *pid = BPF_CORE_READ(task, thread_pid);
+		unsigned int level = BPF_CORE_READ(pid, level);
+		struct upid upid;
+
+		bpf_core_read(&upid, sizeof(upid), &pid->numbers[level]);
+		args->namespaces[NAMESPACE_PID] = BPF_CORE_READ(upid.ns, ns.inum);
+	}
+	if (flags & CLONE_NEWCGROUP) {
+		args->namespaces[NAMESPACE_CGROUP] = BPF_CORE_READ(nsproxy, cgroup_ns, ns.inum);
+	}
+	if (flags & CLONE_NEWUSER) {
+		args->namespaces[NAMESPACE_USER] = BPF_CORE_READ(task, real_cred, user_ns, ns.inum);
+	}
+	if ((flags & CLONE_NEWTIME) && bpf_core_field_exists(nsproxy->time_ns)) {
+		args->namespaces[NAMESPACE_TIME] = BPF_CORE_READ(nsproxy, time_ns, ns.inum);
+	}
+}
+
+// The namespaces are read when setns is called, since the descriptor may be closed when the event is handled. The
+// descriptor is either a namespace file or a pidfd joining the namespaces of its process.
+SEC("tracepoint/syscalls/sys_enter_setns")
+int tracepoint__sys_enter_setns(struct trace_event_raw_sys_enter *ctx)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	u64 mntns_id = task_mntns_id(task);
+	if (gadget_should_discard_mntns_id(mntns_id)) {
+		return 0;
+	}
+
+	u32 fd = ctx->args[0];
+	u64 flags = ctx->args[1];
+	struct fdtable *fdt = BPF_CORE_READ(task, files, fdt);
+	if (fd >= BPF_CORE_READ(fdt, max_fds)) {
+		return 0;
+	}
+	struct file **fds = BPF_CORE_READ(fdt, fd);
+	struct file *file = NULL;
+	bpf_core_read(&file, sizeof(file), &fds[fd]);
+	if (!file) {
+		return 0;
+	}
+
+	struct setns_args args = {.mntns_id = mntns_id};
+	struct inode *inode = BPF_CORE_READ(file, f_inode);
+	unsigned long magic = BPF_CORE_READ(inode, i_sb, s_magic);
+	if (magic == NSFS_MAGIC) {
+		struct ns_common *ns = BPF_CORE_READ(inode, i_private);
+		set_namespace(&args, ns_type(ns), BPF_CORE_READ(ns, inum));
+	} else {
+		// a pidfd, the other files fail the call which is not reported. The pidfds of pidfs keep their process in
+		// their inode, the anonymous ones in their file.
+		struct pid *pid = magic == PID_FS_MAGIC ? BPF_CORE_READ(inode, i_private) : BPF_CORE_READ(file, private_data);
+		struct hlist_node *node = BPF_CORE_READ(pid, tasks[PIDTYPE_TGID].first);
+		if (!node) {
+			return 0;
+		}
+		struct task_struct *target = NULL;
+		u32 offset = __builtin_preserve_field_info(target->pid_links, BPF_FIELD_BYTE_OFFSET) +
+			     PIDTYPE_TGID * sizeof(struct hlist_node);
+		target = (void *)node - offset;
+		task_namespaces(&args, target, flags);
+	}
+
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	bpf_map_update_elem(&setns_args, &pid_tgid, &args, BPF_ANY);
+	return 0;
+}
+
+// The setns calls are traced at their exit, the failed calls did not join any namespace. The caller may have left
+// the mount namespace of its container, its event keeps the one it called setns from.
+SEC("tracepoint/syscalls/sys_exit_setns")
+int tracepoint__sys_exit_setns(struct trace_event_raw_sys_exit *ctx)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct setns_args *args = bpf_map_lookup_elem(&setns_args, &pid_tgid);
+	if (!args) {
+		return 0;
+	}
+
+	struct event *event = NULL;
+	if (ctx->ret == 0) {
+		event = new_event(SYSCALL_SETNS, args->mntns_id);
+	}
+	if (event) {
+		__builtin_memcpy(event->namespaces, args->namespaces, sizeof(event->namespaces));
+	}
+	bpf_map_delete_elem(&setns_args, &pid_tgid);
+
+	if (event) {
+		submit_event(ctx, event);
+	}
+	return 0;
+}
+
+char _license[] SEC("license") = "GPL";
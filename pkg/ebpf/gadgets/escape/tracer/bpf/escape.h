@@ -0,0 +1,67 @@
+#pragma once
+
+#include "../../../../include/types.h"
+
+#ifndef TASK_COMM_LEN
+#define TASK_COMM_LEN 16
+#endif
+#define PATH_MAX_LEN 256
+#define FSTYPE_MAX_LEN 32
+
+// The namespace types of setns, see include/uapi/linux/sched.h
+#define CLONE_NEWTIME 0x00000080
+#define CLONE_NEWNS 0x00020000
+#define CLONE_NEWCGROUP 0x02000000
+#define CLONE_NEWUTS 0x04000000
+#define CLONE_NEWIPC 0x08000000
+#define CLONE_NEWUSER 0x10000000
+#define CLONE_NEWPID 0x20000000
+#define CLONE_NEWNET 0x40000000
+
+// The magic numbers of the namespace files and of the pidfds, see include/uapi/linux/magic.h
+#define NSFS_MAGIC 0x6e736673
+#define PID_FS_MAGIC 0x50494446
+
+// The traced syscalls, the tracer names them
+enum syscall {
+	SYSCALL_MOUNT,
+	SYSCALL_PIVOT_ROOT,
+	SYSCALL_SETNS,
+};
+
+// The namespaces joined by setns, the tracer names them
+enum namespace {
+	NAMESPACE_MNT,
+	NAMESPACE_NET,
+	NAMESPACE_UTS,
+	NAMESPACE_IPC,
+	NAMESPACE_PID,
+	NAMESPACE_CGROUP,
+	NAMESPACE_USER,
+	NAMESPACE_TIME,
+	NAMESPACE_COUNT,
+};
+
+// setns_args are the inodes of the namespaces joined by a setns call, by their type, with the mount namespace of the
+// caller, kept from its entry to its exit
+struct setns_args {
+	gadget_mntns_id mntns_id;
+	__u32 namespaces[NAMESPACE_COUNT];
+};
+
+struct event {
+	gadget_timestamp timestamp;
+	gadget_mntns_id mntns_id;
+	__u32 pid;
+	__u32 ppid;
+	__u32 uid;
+	__u32 gid;
+	__u32 syscall;
+	// namespaces are the inodes of the namespaces joined by setns, zero for the others
+	__u32 namespaces[NAMESPACE_COUNT];
+	__u64 flags;
+	__u8 comm[TASK_COMM_LEN];
+	__u8 source[PATH_MAX_LEN];
+	__u8 target[PATH_MAX_LEN];
+	__u8 fstype[FSTYPE_MAX_LEN];
+};
@@ -0,0 +1,153 @@
+// Code generated by bpf2go; DO NOT EDIT.
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type escapeEvent struct {
+	Timestamp  uint64
+	MntnsId    uint64
+	Pid        uint32
+	Ppid       uint32
+	Uid        uint32
+	Gid        uint32
+	Syscall    uint32
+	Namespaces [8]uint32
+	_          [4]byte
+	Flags      uint64
+	Comm       [16]uint8
+	Source     [256]uint8
+	Target     [256]uint8
+	Fstype     [32]uint8
+}
+
+// loadEscape returns the embedded CollectionSpec for escape.
+func loadEscape() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_EscapeBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load escape: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadEscapeObjects loads escape and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*escapeObjects
+//	*escapePrograms
+//	*escapeMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadEscapeObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadEscape()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// escapeSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type escapeSpecs struct {
+	escapeProgramSpecs
+	escapeMapSpecs
+}
+
+// escapeSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type escapeProgramSpecs struct {
+	TracepointSysEnterMount     *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_mount"`
+	TracepointSysEnterPivotRoot *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_pivot_root"`
+	TracepointSysEnterSetns     *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_setns"`
+	TracepointSysExitSetns      *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_setns"`
+}
+
+// escapeMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type escapeMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.MapSpec `ebpf:"heap"`
+	SetnsArgs            *ebpf.MapSpec `ebpf:"setns_args"`
+}
+
+// escapeObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadEscapeObjects or ebpf.CollectionSpec.LoadAndAssign.
+type escapeObjects struct {
+	escapePrograms
+	escapeMaps
+}
+
+func (o *escapeObjects) Close() error {
+	return _EscapeClose(
+		&o.escapePrograms,
+		&o.escapeMaps,
+	)
+}
+
+// escapeMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadEscapeObjects or ebpf.CollectionSpec.LoadAndAssign.
+type escapeMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.Map `ebpf:"heap"`
+	SetnsArgs            *ebpf.Map `ebpf:"setns_args"`
+}
+
+func (m *escapeMaps) Close() error {
+	return _EscapeClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Heap,
+		m.SetnsArgs,
+	)
+}
+
+// escapePrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadEscapeObjects or ebpf.CollectionSpec.LoadAndAssign.
+type escapePrograms struct {
+	TracepointSysEnterMount     *ebpf.Program `ebpf:"tracepoint__sys_enter_mount"`
+	TracepointSysEnterPivotRoot *ebpf.Program `ebpf:"tracepoint__sys_enter_pivot_root"`
+	TracepointSysEnterSetns     *ebpf.Program `ebpf:"tracepoint__sys_enter_setns"`
+	TracepointSysExitSetns      *ebpf.Program `ebpf:"tracepoint__sys_exit_setns"`
+}
+
+func (p *escapePrograms) Close() error {
+	return _EscapeClose(
+		p.TracepointSysEnterMount,
+		p.TracepointSysEnterPivotRoot,
+		p.TracepointSysEnterSetns,
+		p.TracepointSysExitSetns,
+	)
+}
+
+func _EscapeClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed escape_bpf.o
+var _EscapeBytes []byte
@@ -0,0 +1,232 @@
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+
+	"node-agent/pkg/ebpf/gadgets/escape/types"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -no-global-types -target bpf -cc clang -cflags "-g -O2 -Wall" -type event escape bpf/escape.bpf.c -- -I./bpf/
+
+// Syscalls are the names of the traced syscalls, by their index in the eBPF program. The writes to the kernel usermode
+// helper files are traced by the open probes of the ptrace gadget.
+var Syscalls = []string{"mount", "pivot_root", "setns"}
+
+// Namespaces are the names of the namespaces joined by setns, by their index in the eBPF program, as in /proc/<pid>/ns
+var Namespaces = []string{"mnt", "net", "uts", "ipc", "pid", "cgroup", "user", "time"}
+
+type Config struct {
+	MountnsMap *ebpf.Map
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+	// procDir is the proc filesystem of the host, the namespaces of the host are those of its init process
+	procDir string
+
+	objs   escapeObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+		procDir:       "/proc",
+	}
+
+	if err := t.install(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Stop stops the tracer
+// TODO: Remove after refactoring
+func (t *Tracer) Stop() {
+	t.close()
+}
+
+func (t *Tracer) close() {
+	for i := range t.links {
+		t.links[i] = gadgets.CloseLink(t.links[i])
+	}
+	t.links = nil
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadEscape()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	for _, tracepoint := range []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"sys_enter_mount", t.objs.TracepointSysEnterMount},
+		{"sys_enter_pivot_root", t.objs.TracepointSysEnterPivotRoot},
+		{"sys_enter_setns", t.objs.TracepointSysEnterSetns},
+		{"sys_exit_setns", t.objs.TracepointSysExitSetns},
+	} {
+		l, err := link.Tracepoint("syscalls", tracepoint.name, tracepoint.prog, nil)
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", tracepoint.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.escapeMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*escapeEvent)(unsafe.Pointer(&record.RawSample[0]))
+		event := t.parseEvent(bpfEvent)
+		if event == nil {
+			continue
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(event)
+	}
+}
+
+// parseEvent returns the event of a traced call, or nil when the syscall is unknown
+func (t *Tracer) parseEvent(bpfEvent *escapeEvent) *types.Event {
+	if int(bpfEvent.Syscall) >= len(Syscalls) {
+		return nil
+	}
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+		Pid:           bpfEvent.Pid,
+		PPid:          bpfEvent.Ppid,
+		Uid:           bpfEvent.Uid,
+		Gid:           bpfEvent.Gid,
+		Comm:          gadgets.FromCString(bpfEvent.Comm[:]),
+		Syscall:       Syscalls[bpfEvent.Syscall],
+		Source:        gadgets.FromCString(bpfEvent.Source[:]),
+		Target:        gadgets.FromCString(bpfEvent.Target[:]),
+		FsType:        gadgets.FromCString(bpfEvent.Fstype[:]),
+		Flags:         bpfEvent.Flags,
+	}
+	event.Namespace, event.HostNamespace = t.namespaces(bpfEvent.Namespaces)
+	return event
+}
+
+// namespaces returns the namespaces joined by setns, like mnt:[4026531840], and whether one of them is a namespace
+// of the host
+func (t *Tracer) namespaces(inodes [8]uint32) (string, bool) {
+	var joined []string
+	host := false
+	for i, inode := range inodes {
+		if inode == 0 || i >= len(Namespaces) {
+			continue
+		}
+		link := fmt.Sprintf("%s:[%d]", Namespaces[i], inode)
+		joined = append(joined, link)
+		if hostLink, err := os.Readlink(filepath.Join(t.procDir, "1", "ns", Namespaces[i])); err == nil && hostLink == link {
+			host = true
+		}
+	}
+	return strings.Join(joined, ","), host
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config:  &Config{},
+		procDir: "/proc",
+	}
+	return tracer, nil
+}
@@ -0,0 +1,150 @@
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"node-agent/pkg/ebpf/gadgets/escape/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func bpfString(s string) (b [256]uint8) {
+	copy(b[:], s)
+	return b
+}
+
+func TestParseEvent(t *testing.T) {
+	procDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procDir, "1", "ns"), 0755))
+	require.NoError(t, os.Symlink("mnt:[1]", filepath.Join(procDir, "1", "ns", "mnt")))
+	require.NoError(t, os.Symlink("net:[3]", filepath.Join(procDir, "1", "ns", "net")))
+	tracer := &Tracer{procDir: procDir}
+	var fstype [32]uint8
+	copy(fstype[:], "ext4")
+
+	tests := []struct {
+		name  string
+		event escapeEvent
+		want  *types.Event
+	}{
+		{
+			name:  "mount",
+			event: escapeEvent{Pid: 10, Syscall: 0, Source: bpfString("/dev/sda1"), Target: bpfString("/mnt"), Fstype: fstype, Flags: syscall.MS_BIND},
+			want:  &types.Event{Pid: 10, Syscall: "mount", Source: "/dev/sda1", Target: "/mnt", FsType: "ext4", Flags: syscall.MS_BIND},
+		},
+		{
+			name:  "pivot_root",
+			event: escapeEvent{Pid: 10, Syscall: 1, Source: bpfString("/new"), Target: bpfString("/new/old")},
+			want:  &types.Event{Pid: 10, Syscall: "pivot_root", Source: "/new", Target: "/new/old"},
+		},
+		{
+			name:  "setns host namespace",
+			event: escapeEvent{Pid: 10, Syscall: 2, Namespaces: [8]uint32{1}},
+			want:  &types.Event{Pid: 10, Syscall: "setns", Namespace: "mnt:[1]", HostNamespace: true},
+		},
+		{
+			name:  "setns container namespace",
+			event: escapeEvent{Pid: 10, Syscall: 2, Namespaces: [8]uint32{2}},
+			want:  &types.Event{Pid: 10, Syscall: "setns", Namespace: "mnt:[2]"},
+		},
+		{
+			name:  "setns pidfd",
+			event: escapeEvent{Pid: 10, Syscall: 2, Namespaces: [8]uint32{2, 3, 4}},
+			want:  &types.Event{Pid: 10, Syscall: "setns", Namespace: "mnt:[2],net:[3],uts:[4]", HostNamespace: true},
+		},
+		{
+			name:  "unknown syscall",
+			event: escapeEvent{Pid: 10, Syscall: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tracer.parseEvent(&tt.event)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			got.Event = tt.want.Event
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestTracer mounts a tmpfs and joins its own namespace, it is skipped without the privileges to do so
+func TestTracer(t *testing.T) {
+	events := make(chan *types.Event, 100)
+	tracer, err := NewTracer(&Config{}, nil, func(event *types.Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Skipf("loading the tracer: %v", err)
+	}
+	defer tracer.Stop()
+
+	dir := t.TempDir()
+	if err := unix.Mount("escape-test", dir, "tmpfs", 0, ""); err != nil {
+		t.Skipf("mounting: %v", err)
+	}
+	require.NoError(t, unix.Unmount(dir, 0))
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	ns, err := os.Open("/proc/thread-self/ns/uts")
+	require.NoError(t, err)
+	defer ns.Close()
+	require.NoError(t, unix.Setns(int(ns.Fd()), unix.CLONE_NEWUTS))
+	pidfd, err := unix.PidfdOpen(os.Getpid(), 0)
+	require.NoError(t, err)
+	defer unix.Close(pidfd)
+	require.NoError(t, unix.Setns(pidfd, unix.CLONE_NEWUTS|unix.CLONE_NEWIPC))
+	// the failed calls are not reported
+	require.Error(t, unix.Setns(int(ns.Fd()), unix.CLONE_NEWNET))
+
+	utsLink, err := os.Readlink("/proc/thread-self/ns/uts")
+	require.NoError(t, err)
+	ipcLink, err := os.Readlink("/proc/thread-self/ns/ipc")
+	require.NoError(t, err)
+
+	var mount *types.Event
+	var setns []*types.Event
+	timeout := time.After(5 * time.Second)
+	for mount == nil || len(setns) < 2 {
+		select {
+		case event := <-events:
+			if event.Pid != uint32(os.Getpid()) {
+				continue
+			}
+			switch event.Syscall {
+			case "mount":
+				mount = event
+			case "setns":
+				setns = append(setns, event)
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the events")
+		}
+	}
+
+	assert.Equal(t, "escape-test", mount.Source)
+	assert.Equal(t, dir, mount.Target)
+	assert.Equal(t, "tmpfs", mount.FsType)
+	assert.Equal(t, utsLink, setns[0].Namespace)
+	assert.Equal(t, utsLink+","+ipcLink, setns[1].Namespace)
+	select {
+	case event := <-events:
+		if event.Pid == uint32(os.Getpid()) && event.Syscall == "setns" {
+			t.Fatalf("unexpected event of a failed setns: %+v", event)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,65 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// HelperFiles are the files configuring the programs the kernel runs on the host, a write to them from a container
+// is an escape. They match the end of the written paths.
+var HelperFiles = []string{
+	"release_agent",
+	"core_pattern",
+	"uevent_helper",
+	"sys/kernel/modprobe",
+}
+
+// IsHelperFile tells whether a path is one of the HelperFiles
+func IsHelperFile(path string) bool {
+	for _, helper := range HelperFiles {
+		if path == helper || strings.HasSuffix(path, "/"+helper) {
+			return true
+		}
+	}
+	return false
+}
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	PPid uint32 `json:"ppid,omitempty" column:"ppid,template:pid"`
+	Uid  uint32 `json:"uid,omitempty" column:"uid,template:uid"`
+	Gid  uint32 `json:"gid,omitempty" column:"gid,template:gid"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+	// Syscall is mount, pivot_root, setns, or open, openat and openat2 for the writes to the kernel usermode helper
+	// files
+	Syscall string `json:"syscall,omitempty" column:"syscall,width:10"`
+	// Source, Target, FsType and Flags are the arguments of mount, Source and Target are the new and old roots of
+	// pivot_root
+	Source string `json:"source,omitempty" column:"source,width:32"`
+	Target string `json:"target,omitempty" column:"target,width:32"`
+	FsType string `json:"fstype,omitempty" column:"fstype,width:10"`
+	Flags  uint64 `json:"flags,omitempty" column:"flags,hide"`
+	// Namespace lists the namespaces joined by setns separated by commas, like mnt:[4026531840], a pidfd joins several
+	// namespaces of its process. HostNamespace tells whether one of them is a namespace of the host.
+	Namespace     string `json:"namespace,omitempty" column:"namespace,width:20"`
+	HostNamespace bool   `json:"hostnamespace,omitempty" column:"hostnamespace,width:13,fixed"`
+	// Path is the written kernel usermode helper file, like release_agent or core_pattern
+	Path string `json:"path,omitempty" column:"path,width:32"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	escapeColumns := columns.MustCreateColumns[Event]()
+
+	return escapeColumns
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
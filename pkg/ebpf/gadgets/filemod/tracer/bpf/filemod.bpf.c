@@ -0,0 +1,220 @@
+#include "../../../../include/amd64/vmlinux.h"
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+
+#include "filemod.h"
+#include "../../../../include/mntns_filter.h"
+#include "../../../../include/filesystem.h"
+#include "../../../../include/macros.h"
+
+// Events map.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} events SEC(".maps");
+
+// The events are too large for the stack.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct event);
+} heap SEC(".maps");
+
+// The arguments of the calls in progress, by thread.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 10240);
+	__type(key, u64);
+	__type(value, struct args);
+} filemod_args SEC(".maps");
+
+// we need this to make sure the compiler doesn't remove our struct.
+const struct event *unusedevent __attribute__((unused));
+
+// call are the indexes of the arguments of a call, NO_ARG for those it does not have. The directories are the file
+// descriptors the relative paths are resolved in, the working directory when they are CWD or AT_FDCWD. The
+// directory of a path without directory is not read.
+struct call {
+	int path;
+	int path_dir;
+	int source;
+	int source_dir;
+	int mode;
+	int length;
+};
+
+static __always_inline u64 current_mntns_id(struct task_struct *task)
+{
+	return BPF_CORE_READ(task, nsproxy, mnt_ns, ns.inum);
+}
+
+// enter_call keeps the nargs arguments of a call until its exit. The tracepoints only give access to the arguments
+// of their syscall.
+static __always_inline int enter_call(struct trace_event_raw_sys_enter *ctx, u32 syscall, int nargs)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	if (gadget_should_discard_mntns_id(current_mntns_id(task))) {
+		return 0;
+	}
+
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct args args = {
+		.syscall = syscall,
+		.args = {ctx->args[0]},
+	};
+	if (nargs > 1) {
+		args.args[1] = ctx->args[1];
+	}
+	if (nargs > 2) {
+		args.args[2] = ctx->args[2];
+	}
+	if (nargs > 3) {
+		args.args[3] = ctx->args[3];
+	}
+	if (nargs > 4) {
+		args.args[4] = ctx->args[4];
+	}
+	bpf_map_update_elem(&filemod_args, &pid_tgid, &args, BPF_ANY);
+	return 0;
+}
+
+// read_dir reads the path of the directory of a relative path, or of the file of a file descriptor when there is no
+// path. The files without path in the filesystem, like the sockets and the pipes, name themselves and are skipped.
+static __always_inline void read_dir(struct task_struct *task, struct args *args, int dir, __u8 *buf)
+{
+	struct path dir_path;
+	if (dir == NO_ARG) {
+		return;
+	}
+	if (dir == CWD || (int)args->args[dir] == AT_FDCWD) {
+		dir_path = BPF_CORE_READ(task, fs, pwd);
+	} else {
+		struct file *file = get_struct_file_for_fd((int)args->args[dir]);
+		if (!file || BPF_CORE_READ(file, f_path.dentry, d_op, d_dname)) {
+			return;
+		}
+		dir_path = BPF_CORE_READ(file, f_path);
+	}
+
+	char *path = get_path_str(&dir_path);
+	if (path) {
+		bpf_probe_read_kernel_str(buf, PATH_MAX_LEN, path);
+	}
+}
+
+// read_path reads a path argument and the directory it is relative to
+static __always_inline void read_path(struct task_struct *task, struct args *args, int arg, int dir, __u8 *buf,
+				      __u8 *dir_buf)
+{
+	if (arg != NO_ARG) {
+		bpf_probe_read_user_str(buf, PATH_MAX_LEN, (void *)args->args[arg]);
+		if (buf[0] == '/') {
+			return;
+		}
+	}
+	read_dir(task, args, dir, dir_buf);
+}
+
+// exit_call reports the calls which succeeded, the failed calls did not modify the files. The paths are read at the
+// exit, they are the ones the kernel resolved.
+static __always_inline int exit_call(struct trace_event_raw_sys_exit *ctx, struct call call)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct args *args = bpf_map_lookup_elem(&filemod_args, &pid_tgid);
+	if (!args) {
+		return 0;
+	}
+	if (ctx->ret != 0) {
+		goto cleanup;
+	}
+
+	u32 zero = 0;
+	struct event *event = bpf_map_lookup_elem(&heap, &zero);
+	if (!event) {
+		goto cleanup;
+	}
+
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	u64 uid_gid = bpf_get_current_uid_gid();
+
+	// the event is too large to be cleared, the strings are only read up to their end
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->mntns_id = current_mntns_id(task);
+	event->pid = pid_tgid >> 32;
+	event->ppid = BPF_CORE_READ(task, real_parent, tgid);
+	event->uid = (u32)uid_gid;
+	event->gid = (u32)(uid_gid >> 32);
+	event->syscall = args->syscall;
+	event->mode = 0;
+	event->length = 0;
+	bpf_get_current_comm(&event->comm, sizeof(event->comm));
+	event->path[0] = 0;
+	event->path_dir[0] = 0;
+	event->source[0] = 0;
+	event->source_dir[0] = 0;
+
+	read_path(task, args, call.path, call.path_dir, event->path, event->path_dir);
+	read_path(task, args, call.source, call.source_dir, event->source, event->source_dir);
+	if (call.mode != NO_ARG) {
+		event->mode = args->args[call.mode];
+	}
+	if (call.length != NO_ARG) {
+		event->length = args->args[call.length];
+	}
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+
+cleanup:
+	bpf_map_delete_elem(&filemod_args, &pid_tgid);
+	return 0;
+}
+
+#define TRACE_FILEMOD(name, syscall, nargs, ...)                                           \
+	SEC("tracepoint/syscalls/sys_enter_" #name)                                        \
+	int tracepoint__sys_enter_##name(struct trace_event_raw_sys_enter *ctx)            \
+	{                                                                                  \
+		return enter_call(ctx, syscall, nargs);                                    \
+	}                                                                                  \
+                                                                                           \
+	SEC("tracepoint/syscalls/sys_exit_" #name)                                         \
+	int tracepoint__sys_exit_##name(struct trace_event_raw_sys_exit *ctx)              \
+	{                                                                                  \
+		return exit_call(ctx, (struct call)__VA_ARGS__);                           \
+	}
+
+TRACE_FILEMOD(rename, SYSCALL_RENAME, 2,
+	      { .path = 1, .path_dir = CWD, .source = 0, .source_dir = CWD, .mode = NO_ARG, .length = NO_ARG })
+TRACE_FILEMOD(renameat, SYSCALL_RENAMEAT, 4,
+	      { .path = 3, .path_dir = 2, .source = 1, .source_dir = 0, .mode = NO_ARG, .length = NO_ARG })
+TRACE_FILEMOD(renameat2, SYSCALL_RENAMEAT2, 4,
+	      { .path = 3, .path_dir = 2, .source = 1, .source_dir = 0, .mode = NO_ARG, .length = NO_ARG })
+TRACE_FILEMOD(unlink, SYSCALL_UNLINK, 1,
+	      { .path = 0, .path_dir = CWD, .source = NO_ARG, .source_dir = NO_ARG, .mode = NO_ARG, .length = NO_ARG })
+TRACE_FILEMOD(unlinkat, SYSCALL_UNLINKAT, 2,
+	      { .path = 1, .path_dir = 0, .source = NO_ARG, .source_dir = NO_ARG, .mode = NO_ARG, .length = NO_ARG })
+TRACE_FILEMOD(chmod, SYSCALL_CHMOD, 2,
+	      { .path = 0, .path_dir = CWD, .source = NO_ARG, .source_dir = NO_ARG, .mode = 1, .length = NO_ARG })
+TRACE_FILEMOD(fchmod, SYSCALL_FCHMOD, 2,
+	      { .path = NO_ARG, .path_dir = 0, .source = NO_ARG, .source_dir = NO_ARG, .mode = 1, .length = NO_ARG })
+TRACE_FILEMOD(fchmodat, SYSCALL_FCHMODAT, 3,
+	      { .path = 1, .path_dir = 0, .source = NO_ARG, .source_dir = NO_ARG, .mode = 2, .length = NO_ARG })
+TRACE_FILEMOD(fchmodat2, SYSCALL_FCHMODAT2, 3,
+	      { .path = 1, .path_dir = 0, .source = NO_ARG, .source_dir = NO_ARG, .mode = 2, .length = NO_ARG })
+TRACE_FILEMOD(link, SYSCALL_LINK, 2,
+	      { .path = 1, .path_dir = CWD, .source = 0, .source_dir = CWD, .mode = NO_ARG, .length = NO_ARG })
+TRACE_FILEMOD(linkat, SYSCALL_LINKAT, 4,
+	      { .path = 3, .path_dir = 2, .source = 1, .source_dir = 0, .mode = NO_ARG, .length = NO_ARG })
+// the target of a symbolic link is kept as is, it is resolved when the link is followed
+TRACE_FILEMOD(symlink, SYSCALL_SYMLINK, 2,
+	      { .path = 1, .path_dir = CWD, .source = 0, .source_dir = NO_ARG, .mode = NO_ARG, .length = NO_ARG })
+TRACE_FILEMOD(symlinkat, SYSCALL_SYMLINKAT, 3,
+	      { .path = 2, .path_dir = 1, .source = 0, .source_dir = NO_ARG, .mode = NO_ARG, .length = NO_ARG })
+TRACE_FILEMOD(truncate, SYSCALL_TRUNCATE, 2,
+	      { .path = 0, .path_dir = CWD, .source = NO_ARG, .source_dir = NO_ARG, .mode = NO_ARG, .length = 1 })
+TRACE_FILEMOD(ftruncate, SYSCALL_FTRUNCATE, 2,
+	      { .path = NO_ARG, .path_dir = 0, .source = NO_ARG, .source_dir = NO_ARG, .mode = NO_ARG, .length = 1 })
+
+char _license[] SEC("license") = "GPL";
@@ -0,0 +1,60 @@
+#pragma once
+
+#include "../../../../include/types.h"
+
+#ifndef TASK_COMM_LEN
+#define TASK_COMM_LEN 16
+#endif
+#define PATH_MAX_LEN 512
+#define MAX_ARGS 5
+
+// NO_ARG marks the arguments a call does not have and CWD the paths relative to the working directory
+#define NO_ARG -1
+#define CWD -2
+#define AT_FDCWD -100
+
+// The traced syscalls, the tracer names them. rename, unlink, chmod, link and symlink are the legacy syscalls of
+// x86_64, the other architectures only have the *at syscalls.
+enum syscall {
+	SYSCALL_RENAME,
+	SYSCALL_RENAMEAT,
+	SYSCALL_RENAMEAT2,
+	SYSCALL_UNLINK,
+	SYSCALL_UNLINKAT,
+	SYSCALL_CHMOD,
+	SYSCALL_FCHMOD,
+	SYSCALL_FCHMODAT,
+	SYSCALL_FCHMODAT2,
+	SYSCALL_LINK,
+	SYSCALL_LINKAT,
+	SYSCALL_SYMLINK,
+	SYSCALL_SYMLINKAT,
+	SYSCALL_TRUNCATE,
+	SYSCALL_FTRUNCATE,
+};
+
+// args are the arguments of a call, kept from its entry to its exit
+struct args {
+	__u32 syscall;
+	__u64 args[MAX_ARGS];
+};
+
+struct event {
+	gadget_timestamp timestamp;
+	gadget_mntns_id mntns_id;
+	__u32 pid;
+	__u32 ppid;
+	__u32 uid;
+	__u32 gid;
+	__u32 syscall;
+	__u32 mode;
+	__u64 length;
+	__u8 comm[TASK_COMM_LEN];
+	// path is the modified file and source the old path of a rename, the linked file of link or the target of
+	// symlink. Their directories are the paths of the directories the relative paths are resolved in, path_dir is
+	// the modified file itself for the calls on a file descriptor.
+	__u8 path[PATH_MAX_LEN];
+	__u8 path_dir[PATH_MAX_LEN];
+	__u8 source[PATH_MAX_LEN];
+	__u8 source_dir[PATH_MAX_LEN];
+};
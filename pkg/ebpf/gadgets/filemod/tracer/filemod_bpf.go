@@ -0,0 +1,234 @@
+// Code generated by bpf2go; DO NOT EDIT.
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type filemodEvent struct {
+	Timestamp uint64
+	MntnsId   uint64
+	Pid       uint32
+	Ppid      uint32
+	Uid       uint32
+	Gid       uint32
+	Syscall   uint32
+	Mode      uint32
+	Length    uint64
+	Comm      [16]uint8
+	Path      [512]uint8
+	PathDir   [512]uint8
+	Source    [512]uint8
+	SourceDir [512]uint8
+}
+
+// loadFilemod returns the embedded CollectionSpec for filemod.
+func loadFilemod() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_FilemodBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load filemod: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadFilemodObjects loads filemod and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*filemodObjects
+//	*filemodPrograms
+//	*filemodMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadFilemodObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadFilemod()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// filemodSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type filemodSpecs struct {
+	filemodProgramSpecs
+	filemodMapSpecs
+}
+
+// filemodSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type filemodProgramSpecs struct {
+	TracepointSysEnterChmod     *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_chmod"`
+	TracepointSysEnterFchmod    *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_fchmod"`
+	TracepointSysEnterFchmodat  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_fchmodat"`
+	TracepointSysEnterFchmodat2 *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_fchmodat2"`
+	TracepointSysEnterFtruncate *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_ftruncate"`
+	TracepointSysEnterLink      *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_link"`
+	TracepointSysEnterLinkat    *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_linkat"`
+	TracepointSysEnterRename    *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_rename"`
+	TracepointSysEnterRenameat  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_renameat"`
+	TracepointSysEnterRenameat2 *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_renameat2"`
+	TracepointSysEnterSymlink   *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_symlink"`
+	TracepointSysEnterSymlinkat *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_symlinkat"`
+	TracepointSysEnterTruncate  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_truncate"`
+	TracepointSysEnterUnlink    *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_unlink"`
+	TracepointSysEnterUnlinkat  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_unlinkat"`
+	TracepointSysExitChmod      *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_chmod"`
+	TracepointSysExitFchmod     *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_fchmod"`
+	TracepointSysExitFchmodat   *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_fchmodat"`
+	TracepointSysExitFchmodat2  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_fchmodat2"`
+	TracepointSysExitFtruncate  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_ftruncate"`
+	TracepointSysExitLink       *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_link"`
+	TracepointSysExitLinkat     *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_linkat"`
+	TracepointSysExitRename     *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_rename"`
+	TracepointSysExitRenameat   *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_renameat"`
+	TracepointSysExitRenameat2  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_renameat2"`
+	TracepointSysExitSymlink    *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_symlink"`
+	TracepointSysExitSymlinkat  *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_symlinkat"`
+	TracepointSysExitTruncate   *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_truncate"`
+	TracepointSysExitUnlink     *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_unlink"`
+	TracepointSysExitUnlinkat   *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_unlinkat"`
+}
+
+// filemodMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type filemodMapSpecs struct {
+	Bufs                 *ebpf.MapSpec `ebpf:"bufs"`
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	FilemodArgs          *ebpf.MapSpec `ebpf:"filemod_args"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.MapSpec `ebpf:"heap"`
+}
+
+// filemodObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadFilemodObjects or ebpf.CollectionSpec.LoadAndAssign.
+type filemodObjects struct {
+	filemodPrograms
+	filemodMaps
+}
+
+func (o *filemodObjects) Close() error {
+	return _FilemodClose(
+		&o.filemodPrograms,
+		&o.filemodMaps,
+	)
+}
+
+// filemodMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadFilemodObjects or ebpf.CollectionSpec.LoadAndAssign.
+type filemodMaps struct {
+	Bufs                 *ebpf.Map `ebpf:"bufs"`
+	Events               *ebpf.Map `ebpf:"events"`
+	FilemodArgs          *ebpf.Map `ebpf:"filemod_args"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.Map `ebpf:"heap"`
+}
+
+func (m *filemodMaps) Close() error {
+	return _FilemodClose(
+		m.Bufs,
+		m.Events,
+		m.FilemodArgs,
+		m.GadgetMntnsFilterMap,
+		m.Heap,
+	)
+}
+
+// filemodPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadFilemodObjects or ebpf.CollectionSpec.LoadAndAssign.
+type filemodPrograms struct {
+	TracepointSysEnterChmod     *ebpf.Program `ebpf:"tracepoint__sys_enter_chmod"`
+	TracepointSysEnterFchmod    *ebpf.Program `ebpf:"tracepoint__sys_enter_fchmod"`
+	TracepointSysEnterFchmodat  *ebpf.Program `ebpf:"tracepoint__sys_enter_fchmodat"`
+	TracepointSysEnterFchmodat2 *ebpf.Program `ebpf:"tracepoint__sys_enter_fchmodat2"`
+	TracepointSysEnterFtruncate *ebpf.Program `ebpf:"tracepoint__sys_enter_ftruncate"`
+	TracepointSysEnterLink      *ebpf.Program `ebpf:"tracepoint__sys_enter_link"`
+	TracepointSysEnterLinkat    *ebpf.Program `ebpf:"tracepoint__sys_enter_linkat"`
+	TracepointSysEnterRename    *ebpf.Program `ebpf:"tracepoint__sys_enter_rename"`
+	TracepointSysEnterRenameat  *ebpf.Program `ebpf:"tracepoint__sys_enter_renameat"`
+	TracepointSysEnterRenameat2 *ebpf.Program `ebpf:"tracepoint__sys_enter_renameat2"`
+	TracepointSysEnterSymlink   *ebpf.Program `ebpf:"tracepoint__sys_enter_symlink"`
+	TracepointSysEnterSymlinkat *ebpf.Program `ebpf:"tracepoint__sys_enter_symlinkat"`
+	TracepointSysEnterTruncate  *ebpf.Program `ebpf:"tracepoint__sys_enter_truncate"`
+	TracepointSysEnterUnlink    *ebpf.Program `ebpf:"tracepoint__sys_enter_unlink"`
+	TracepointSysEnterUnlinkat  *ebpf.Program `ebpf:"tracepoint__sys_enter_unlinkat"`
+	TracepointSysExitChmod      *ebpf.Program `ebpf:"tracepoint__sys_exit_chmod"`
+	TracepointSysExitFchmod     *ebpf.Program `ebpf:"tracepoint__sys_exit_fchmod"`
+	TracepointSysExitFchmodat   *ebpf.Program `ebpf:"tracepoint__sys_exit_fchmodat"`
+	TracepointSysExitFchmodat2  *ebpf.Program `ebpf:"tracepoint__sys_exit_fchmodat2"`
+	TracepointSysExitFtruncate  *ebpf.Program `ebpf:"tracepoint__sys_exit_ftruncate"`
+	TracepointSysExitLink       *ebpf.Program `ebpf:"tracepoint__sys_exit_link"`
+	TracepointSysExitLinkat     *ebpf.Program `ebpf:"tracepoint__sys_exit_linkat"`
+	TracepointSysExitRename     *ebpf.Program `ebpf:"tracepoint__sys_exit_rename"`
+	TracepointSysExitRenameat   *ebpf.Program `ebpf:"tracepoint__sys_exit_renameat"`
+	TracepointSysExitRenameat2  *ebpf.Program `ebpf:"tracepoint__sys_exit_renameat2"`
+	TracepointSysExitSymlink    *ebpf.Program `ebpf:"tracepoint__sys_exit_symlink"`
+	TracepointSysExitSymlinkat  *ebpf.Program `ebpf:"tracepoint__sys_exit_symlinkat"`
+	TracepointSysExitTruncate   *ebpf.Program `ebpf:"tracepoint__sys_exit_truncate"`
+	TracepointSysExitUnlink     *ebpf.Program `ebpf:"tracepoint__sys_exit_unlink"`
+	TracepointSysExitUnlinkat   *ebpf.Program `ebpf:"tracepoint__sys_exit_unlinkat"`
+}
+
+func (p *filemodPrograms) Close() error {
+	return _FilemodClose(
+		p.TracepointSysEnterChmod,
+		p.TracepointSysEnterFchmod,
+		p.TracepointSysEnterFchmodat,
+		p.TracepointSysEnterFchmodat2,
+		p.TracepointSysEnterFtruncate,
+		p.TracepointSysEnterLink,
+		p.TracepointSysEnterLinkat,
+		p.TracepointSysEnterRename,
+		p.TracepointSysEnterRenameat,
+		p.TracepointSysEnterRenameat2,
+		p.TracepointSysEnterSymlink,
+		p.TracepointSysEnterSymlinkat,
+		p.TracepointSysEnterTruncate,
+		p.TracepointSysEnterUnlink,
+		p.TracepointSysEnterUnlinkat,
+		p.TracepointSysExitChmod,
+		p.TracepointSysExitFchmod,
+		p.TracepointSysExitFchmodat,
+		p.TracepointSysExitFchmodat2,
+		p.TracepointSysExitFtruncate,
+		p.TracepointSysExitLink,
+		p.TracepointSysExitLinkat,
+		p.TracepointSysExitRename,
+		p.TracepointSysExitRenameat,
+		p.TracepointSysExitRenameat2,
+		p.TracepointSysExitSymlink,
+		p.TracepointSysExitSymlinkat,
+		p.TracepointSysExitTruncate,
+		p.TracepointSysExitUnlink,
+		p.TracepointSysExitUnlinkat,
+	)
+}
+
+func _FilemodClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed filemod_bpf.o
+var _FilemodBytes []byte
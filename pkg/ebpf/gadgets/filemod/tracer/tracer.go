@@ -0,0 +1,295 @@
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+
+	"node-agent/pkg/ebpf/gadgets/filemod/types"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -no-global-types -target bpf -cc clang -cflags "-g -O2 -Wall" -type event filemod bpf/filemod.bpf.c -- -I./bpf/
+
+const (
+	renameSyscall = iota
+	renameatSyscall
+	renameat2Syscall
+	unlinkSyscall
+	unlinkatSyscall
+	chmodSyscall
+	fchmodSyscall
+	fchmodatSyscall
+	fchmodat2Syscall
+	linkSyscall
+	linkatSyscall
+	symlinkSyscall
+	symlinkatSyscall
+	truncateSyscall
+	ftruncateSyscall
+)
+
+// Syscalls are the names of the traced syscalls, by their index in the eBPF program
+var Syscalls = []string{"rename", "renameat", "renameat2", "unlink", "unlinkat", "chmod", "fchmod", "fchmodat",
+	"fchmodat2", "link", "linkat", "symlink", "symlinkat", "truncate", "ftruncate"}
+
+// syscallsOperations are the modifications of the traced syscalls, by their index in the eBPF program
+var syscallsOperations = []string{
+	renameSyscall:    types.RenameOperation,
+	renameatSyscall:  types.RenameOperation,
+	renameat2Syscall: types.RenameOperation,
+	unlinkSyscall:    types.UnlinkOperation,
+	unlinkatSyscall:  types.UnlinkOperation,
+	chmodSyscall:     types.ChmodOperation,
+	fchmodSyscall:    types.ChmodOperation,
+	fchmodatSyscall:  types.ChmodOperation,
+	fchmodat2Syscall: types.ChmodOperation,
+	linkSyscall:      types.LinkOperation,
+	linkatSyscall:    types.LinkOperation,
+	symlinkSyscall:   types.SymlinkOperation,
+	symlinkatSyscall: types.SymlinkOperation,
+	truncateSyscall:  types.TruncateOperation,
+	ftruncateSyscall: types.TruncateOperation,
+}
+
+type Config struct {
+	MountnsMap *ebpf.Map
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+
+	objs   filemodObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+	}
+
+	if err := t.install(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Stop stops the tracer
+// TODO: Remove after refactoring
+func (t *Tracer) Stop() {
+	t.close()
+}
+
+func (t *Tracer) close() {
+	for i := range t.links {
+		t.links[i] = gadgets.CloseLink(t.links[i])
+	}
+	t.links = nil
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadFilemod()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	// rename, unlink, chmod, link and symlink are the legacy syscalls of x86_64, the other architectures only have the
+	// *at syscalls, and fchmodat2 was added in Linux 6.6. Their tracepoints are optional.
+	for _, tracepoint := range []struct {
+		name     string
+		prog     *ebpf.Program
+		optional bool
+	}{
+		{"sys_enter_rename", t.objs.TracepointSysEnterRename, true},
+		{"sys_exit_rename", t.objs.TracepointSysExitRename, true},
+		{"sys_enter_renameat", t.objs.TracepointSysEnterRenameat, true},
+		{"sys_exit_renameat", t.objs.TracepointSysExitRenameat, true},
+		{"sys_enter_renameat2", t.objs.TracepointSysEnterRenameat2, false},
+		{"sys_exit_renameat2", t.objs.TracepointSysExitRenameat2, false},
+		{"sys_enter_unlink", t.objs.TracepointSysEnterUnlink, true},
+		{"sys_exit_unlink", t.objs.TracepointSysExitUnlink, true},
+		{"sys_enter_unlinkat", t.objs.TracepointSysEnterUnlinkat, false},
+		{"sys_exit_unlinkat", t.objs.TracepointSysExitUnlinkat, false},
+		{"sys_enter_chmod", t.objs.TracepointSysEnterChmod, true},
+		{"sys_exit_chmod", t.objs.TracepointSysExitChmod, true},
+		{"sys_enter_fchmod", t.objs.TracepointSysEnterFchmod, false},
+		{"sys_exit_fchmod", t.objs.TracepointSysExitFchmod, false},
+		{"sys_enter_fchmodat", t.objs.TracepointSysEnterFchmodat, false},
+		{"sys_exit_fchmodat", t.objs.TracepointSysExitFchmodat, false},
+		{"sys_enter_fchmodat2", t.objs.TracepointSysEnterFchmodat2, true},
+		{"sys_exit_fchmodat2", t.objs.TracepointSysExitFchmodat2, true},
+		{"sys_enter_link", t.objs.TracepointSysEnterLink, true},
+		{"sys_exit_link", t.objs.TracepointSysExitLink, true},
+		{"sys_enter_linkat", t.objs.TracepointSysEnterLinkat, false},
+		{"sys_exit_linkat", t.objs.TracepointSysExitLinkat, false},
+		{"sys_enter_symlink", t.objs.TracepointSysEnterSymlink, true},
+		{"sys_exit_symlink", t.objs.TracepointSysExitSymlink, true},
+		{"sys_enter_symlinkat", t.objs.TracepointSysEnterSymlinkat, false},
+		{"sys_exit_symlinkat", t.objs.TracepointSysExitSymlinkat, false},
+		{"sys_enter_truncate", t.objs.TracepointSysEnterTruncate, false},
+		{"sys_exit_truncate", t.objs.TracepointSysExitTruncate, false},
+		{"sys_enter_ftruncate", t.objs.TracepointSysEnterFtruncate, false},
+		{"sys_exit_ftruncate", t.objs.TracepointSysExitFtruncate, false},
+	} {
+		l, err := link.Tracepoint("syscalls", tracepoint.name, tracepoint.prog, nil)
+		if err != nil {
+			if tracepoint.optional && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("attaching tracepoint %s: %w", tracepoint.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.filemodMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*filemodEvent)(unsafe.Pointer(&record.RawSample[0]))
+		event := parseEvent(bpfEvent)
+		if event == nil {
+			continue
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(event)
+	}
+}
+
+// parseEvent returns the event of a traced call, or nil when the modified file is not known. The calls are traced at
+// their exit and only the successful ones are reported.
+func parseEvent(bpfEvent *filemodEvent) *types.Event {
+	if int(bpfEvent.Syscall) >= len(Syscalls) {
+		return nil
+	}
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+		Pid:           bpfEvent.Pid,
+		PPid:          bpfEvent.Ppid,
+		Uid:           bpfEvent.Uid,
+		Gid:           bpfEvent.Gid,
+		Comm:          gadgets.FromCString(bpfEvent.Comm[:]),
+		Syscall:       Syscalls[bpfEvent.Syscall],
+		Operation:     syscallsOperations[bpfEvent.Syscall],
+		Mode:          bpfEvent.Mode & 07777,
+		Length:        bpfEvent.Length,
+	}
+
+	event.Path = resolvePath(gadgets.FromCString(bpfEvent.PathDir[:]), gadgets.FromCString(bpfEvent.Path[:]))
+	if event.Path == "" {
+		return nil
+	}
+	// the target of a symbolic link is kept as is, it has no directory
+	event.Source = resolvePath(gadgets.FromCString(bpfEvent.SourceDir[:]), gadgets.FromCString(bpfEvent.Source[:]))
+
+	return event
+}
+
+// resolvePath returns the absolute path of a path relative to a directory, the directory itself for an empty path.
+// The relative path is kept when the directory is not known, like the directory of a file descriptor closed during
+// the call.
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	if !filepath.IsAbs(dir) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
@@ -0,0 +1,168 @@
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"node-agent/pkg/ebpf/gadgets/filemod/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func bpfPath(path string) [512]uint8 {
+	var bpfPath [512]uint8
+	copy(bpfPath[:], path)
+	return bpfPath
+}
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		bpfEvent filemodEvent
+		want     *types.Event
+	}{
+		{
+			name:     "rename",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: renameSyscall, Path: bpfPath("/etc/passwd"), Source: bpfPath("/etc/passwd+")},
+			want:     &types.Event{Pid: 10, Syscall: "rename", Operation: "rename", Path: "/etc/passwd", Source: "/etc/passwd+"},
+		},
+		{
+			name:     "renameat2 relative to directories",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: renameat2Syscall, Path: bpfPath("shadow"), PathDir: bpfPath("/etc"), Source: bpfPath("shadow.new"), SourceDir: bpfPath("/app")},
+			want:     &types.Event{Pid: 10, Syscall: "renameat2", Operation: "rename", Path: "/etc/shadow", Source: "/app/shadow.new"},
+		},
+		{
+			name:     "unlinkat",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: unlinkatSyscall, Path: bpfPath("./bin/../run.sh"), PathDir: bpfPath("/app")},
+			want:     &types.Event{Pid: 10, Syscall: "unlinkat", Operation: "unlink", Path: "/app/run.sh"},
+		},
+		{
+			name:     "unlinkat in an unknown directory keeps the relative path",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: unlinkatSyscall, Path: bpfPath("run.sh")},
+			want:     &types.Event{Pid: 10, Syscall: "unlinkat", Operation: "unlink", Path: "run.sh"},
+		},
+		{
+			name:     "fchmodat",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: fchmodatSyscall, Mode: 0104755, Path: bpfPath("/tmp/x")},
+			want:     &types.Event{Pid: 10, Syscall: "fchmodat", Operation: "chmod", Path: "/tmp/x", Mode: 04755},
+		},
+		{
+			name:     "fchmod",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: fchmodSyscall, Mode: 0600, PathDir: bpfPath("/var/log/app.log")},
+			want:     &types.Event{Pid: 10, Syscall: "fchmod", Operation: "chmod", Path: "/var/log/app.log", Mode: 0600},
+		},
+		{
+			name:     "fchmod of a file without path",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: fchmodSyscall, Mode: 0600, PathDir: bpfPath("memfd:payload")},
+		},
+		{
+			name:     "linkat",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: linkatSyscall, Path: bpfPath("ld.so.preload"), PathDir: bpfPath("/etc"), Source: bpfPath("/tmp/payload")},
+			want:     &types.Event{Pid: 10, Syscall: "linkat", Operation: "link", Path: "/etc/ld.so.preload", Source: "/tmp/payload"},
+		},
+		{
+			name:     "symlinkat keeps the target",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: symlinkatSyscall, Path: bpfPath("history"), PathDir: bpfPath("/app"), Source: bpfPath("../../dev/null")},
+			want:     &types.Event{Pid: 10, Syscall: "symlinkat", Operation: "symlink", Path: "/app/history", Source: "../../dev/null"},
+		},
+		{
+			name:     "ftruncate",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: ftruncateSyscall, PathDir: bpfPath("/var/log/app.log")},
+			want:     &types.Event{Pid: 10, Syscall: "ftruncate", Operation: "truncate", Path: "/var/log/app.log"},
+		},
+		{
+			name:     "truncate",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: truncateSyscall, Length: 100, Path: bpfPath("/var/log/syslog")},
+			want:     &types.Event{Pid: 10, Syscall: "truncate", Operation: "truncate", Path: "/var/log/syslog", Length: 100},
+		},
+		{
+			name:     "missing path",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: unlinkSyscall},
+		},
+		{
+			name:     "unknown syscall",
+			bpfEvent: filemodEvent{Pid: 10, Syscall: 100, Path: bpfPath("/tmp/x")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseEvent(&tt.bpfEvent)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			got.Event = tt.want.Event
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestModifiedPaths(t *testing.T) {
+	assert.Equal(t, []string{"/a", "/b"}, (&types.Event{Operation: types.RenameOperation, Source: "/a", Path: "/b"}).ModifiedPaths())
+	assert.Equal(t, []string{"/b"}, (&types.Event{Operation: types.LinkOperation, Source: "/a", Path: "/b"}).ModifiedPaths())
+	assert.Empty(t, (&types.Event{Operation: types.UnlinkOperation}).ModifiedPaths())
+}
+
+// TestTracer renames in a directory, chmods and truncates a file, it is skipped without the privileges to load the
+// tracer
+func TestTracer(t *testing.T) {
+	events := make(chan *types.Event, 100)
+	tracer, err := NewTracer(&Config{}, nil, func(event *types.Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Skipf("loading the tracer: %v", err)
+	}
+	defer tracer.Stop()
+
+	dir := t.TempDir()
+	// the failed calls are not reported
+	require.Error(t, os.Remove(filepath.Join(dir, "missing")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "old"), []byte("content"), 0644))
+	dirFile, err := os.Open(dir)
+	require.NoError(t, err)
+	defer dirFile.Close()
+	require.NoError(t, unix.Renameat2(int(dirFile.Fd()), "old", int(dirFile.Fd()), "new", 0))
+	file, err := os.OpenFile(filepath.Join(dir, "new"), os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer file.Close()
+	require.NoError(t, file.Chmod(0600))
+	require.NoError(t, os.Truncate(filepath.Join(dir, "new"), 0))
+
+	var rename, chmod, truncate *types.Event
+	timeout := time.After(5 * time.Second)
+	for rename == nil || chmod == nil || truncate == nil {
+		select {
+		case event := <-events:
+			if event.Pid != uint32(os.Getpid()) {
+				continue
+			}
+			require.NotEqual(t, filepath.Join(dir, "missing"), event.Path)
+			if event.Path != filepath.Join(dir, "new") {
+				continue
+			}
+			switch event.Operation {
+			case types.RenameOperation:
+				rename = event
+			case types.ChmodOperation:
+				chmod = event
+			case types.TruncateOperation:
+				truncate = event
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the events")
+		}
+	}
+
+	assert.Equal(t, filepath.Join(dir, "old"), rename.Source)
+	assert.Equal(t, "fchmod", chmod.Syscall)
+	assert.Equal(t, uint32(0600), chmod.Mode)
+	assert.Equal(t, "truncate", truncate.Syscall)
+}
@@ -0,0 +1,61 @@
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// the operations of the file modifications
+const (
+	RenameOperation   = "rename"
+	UnlinkOperation   = "unlink"
+	ChmodOperation    = "chmod"
+	LinkOperation     = "link"
+	SymlinkOperation  = "symlink"
+	TruncateOperation = "truncate"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	PPid uint32 `json:"ppid,omitempty" column:"ppid,template:pid"`
+	Uid  uint32 `json:"uid,omitempty" column:"uid,template:uid"`
+	Gid  uint32 `json:"gid,omitempty" column:"gid,template:gid"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+	// Syscall is the traced syscall, Operation the modification it does, like rename for renameat2
+	Syscall   string `json:"syscall,omitempty" column:"syscall,width:16"`
+	Operation string `json:"operation,omitempty" column:"operation,width:10"`
+	// Path is the modified file: the new path of a rename, the new link of link and symlink, and the file of the
+	// other operations
+	Path string `json:"path,omitempty" column:"path,width:40"`
+	// Source is the old path of a rename, the linked file of link and the target of symlink, which is not resolved
+	Source string `json:"source,omitempty" column:"source,width:40"`
+	// Mode is the mode set by chmod and Length the length set by truncate
+	Mode   uint32 `json:"mode,omitempty" column:"mode,hide"`
+	Length uint64 `json:"length,omitempty" column:"length,hide"`
+}
+
+// ModifiedPaths returns the paths of the files whose content, name or attributes are modified
+func (e *Event) ModifiedPaths() []string {
+	if e.Operation == RenameOperation && e.Source != "" {
+		return []string{e.Source, e.Path}
+	}
+	if e.Path == "" {
+		return nil
+	}
+	return []string{e.Path}
+}
+
+func GetColumns() *columns.Columns[Event] {
+	filemodColumns := columns.MustCreateColumns[Event]()
+
+	return filemodColumns
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
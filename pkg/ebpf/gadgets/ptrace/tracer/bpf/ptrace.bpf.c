@@ -0,0 +1,258 @@
+#include "../../../../include/amd64/vmlinux.h"
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+
+#include "ptrace.h"
+#include "../../../../include/mntns_filter.h"
+#include "../../../../include/macros.h"
+
+// Events map.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} events SEC(".maps");
+
+// The events are too large for the stack.
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct event);
+} heap SEC(".maps");
+
+// The arguments of the ptrace calls in progress, by thread.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 10240);
+	__type(key, u64);
+	__type(value, struct args);
+} ptrace_args SEC(".maps");
+
+// we need this to make sure the compiler doesn't remove our struct.
+const struct event *unusedevent __attribute__((unused));
+
+static __always_inline bool is_traced_request(u64 request)
+{
+	switch (request) {
+	case PTRACE_POKETEXT:
+	case PTRACE_POKEDATA:
+	case PTRACE_POKEUSER:
+	case PTRACE_SETREGS:
+	case PTRACE_SETFPREGS:
+	case PTRACE_ATTACH:
+	case PTRACE_SETREGSET:
+	case PTRACE_SEIZE:
+		return true;
+	}
+	return false;
+}
+
+// pid_nr_ns returns the number of a pid in the PID namespace of its task
+static __always_inline u32 pid_nr_ns(struct pid *pid)
+{
+	unsigned int level = BPF_CORE_READ(pid, level);
+	struct upid upid;
+
+	bpf_core_read(&upid, sizeof(upid), &pid->numbers[level]);
+	return upid.nr;
+}
+
+// current_event returns the event of the current task with its process, or NULL when the task is not traced
+static __always_inline struct event *current_event(u32 syscall)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	u64 mntns_id = BPF_CORE_READ(task, nsproxy, mnt_ns, ns.inum);
+	if (gadget_should_discard_mntns_id(mntns_id)) {
+		return NULL;
+	}
+
+	u32 zero = 0;
+	struct event *event = bpf_map_lookup_elem(&heap, &zero);
+	if (!event) {
+		return NULL;
+	}
+
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	u64 uid_gid = bpf_get_current_uid_gid();
+
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->mntns_id = mntns_id;
+	event->pid = pid_tgid >> 32;
+	event->ppid = BPF_CORE_READ(task, real_parent, tgid);
+	event->uid = (u32)uid_gid;
+	event->gid = (u32)(uid_gid >> 32);
+	event->ns_pid = pid_nr_ns(BPF_CORE_READ(task, group_leader, thread_pid));
+	event->ns_tid = pid_nr_ns(BPF_CORE_READ(task, thread_pid));
+	event->target_pid = 0;
+	event->syscall = syscall;
+	event->request = 0;
+	event->addr = 0;
+	event->path[0] = '\0';
+	bpf_get_current_comm(&event->comm, sizeof(event->comm));
+	return event;
+}
+
+static __always_inline void submit_event(void *ctx, struct event *event)
+{
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+}
+
+SEC("tracepoint/syscalls/sys_enter_ptrace")
+int tracepoint__sys_enter_ptrace(struct trace_event_raw_sys_enter *ctx)
+{
+	struct args args = {
+		.request = ctx->args[0],
+		.target_pid = ctx->args[1],
+		.addr = ctx->args[2],
+	};
+	if (!is_traced_request(args.request)) {
+		return 0;
+	}
+
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	bpf_map_update_elem(&ptrace_args, &pid_tgid, &args, BPF_ANY);
+	return 0;
+}
+
+// The ptrace calls are traced at their exit, the failed calls did not inject anything, like the attaches to a
+// thread of the same process, which the kernel refuses.
+SEC("tracepoint/syscalls/sys_exit_ptrace")
+int tracepoint__sys_exit_ptrace(struct trace_event_raw_sys_exit *ctx)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct args *args = bpf_map_lookup_elem(&ptrace_args, &pid_tgid);
+	if (!args) {
+		return 0;
+	}
+
+	struct event *event = NULL;
+	if (ctx->ret == 0) {
+		event = current_event(SYSCALL_PTRACE);
+	}
+	if (event) {
+		event->request = args->request;
+		event->target_pid = args->target_pid;
+		event->addr = args->addr;
+	}
+	bpf_map_delete_elem(&ptrace_args, &pid_tgid);
+
+	if (event) {
+		submit_event(ctx, event);
+	}
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_process_vm_writev")
+int tracepoint__sys_enter_process_vm_writev(struct trace_event_raw_sys_enter *ctx)
+{
+	struct event *event = current_event(SYSCALL_PROCESS_VM_WRITEV);
+	if (!event) {
+		return 0;
+	}
+
+	event->target_pid = ctx->args[0];
+	submit_event(ctx, event);
+	return 0;
+}
+
+// has_suffix tells whether a path of len bytes ends with a suffix of suffix_len bytes
+static __always_inline bool has_suffix(const u8 *path, long len, const char *suffix, u32 suffix_len)
+{
+	if (len - 1 < suffix_len) {
+		return false;
+	}
+	u32 start = len - 1 - suffix_len;
+	for (u32 i = 0; i < suffix_len; i++) {
+		if (path[(start + i) & (PATH_MAX_LEN - 1)] != suffix[i]) {
+			return false;
+		}
+	}
+	return true;
+}
+
+// is_proc_mem tells whether a path of len bytes, with its terminating null byte, is /proc/<pid>/mem. /proc/self/mem
+// and /proc/thread-self/mem are the memory of the process itself.
+static __always_inline bool is_proc_mem(const u8 *path, long len)
+{
+	// the shortest path is /proc/1/mem
+	if (len < 12) {
+		return false;
+	}
+	if (path[0] != '/' || path[1] != 'p' || path[2] != 'r' || path[3] != 'o' || path[4] != 'c' || path[5] != '/' ||
+	    path[6] < '0' || path[6] > '9') {
+		return false;
+	}
+	return has_suffix(path, len, "/mem", 4);
+}
+
+// is_helper_file_path tells whether a path ends with a helper file name, as a relative path or after a slash
+static __always_inline bool is_helper_file_path(const u8 *path, long len, const char *helper, u32 helper_len)
+{
+	if (!has_suffix(path, len, helper, helper_len)) {
+		return false;
+	}
+	u32 start = len - 1 - helper_len;
+	return start == 0 || path[(start - 1) & (PATH_MAX_LEN - 1)] == '/';
+}
+
+// is_helper_file tells whether a path is a file configuring the programs the kernel runs on the host, a write to
+// them from a container is an escape which the escape gadget reports
+static __always_inline bool is_helper_file(const u8 *path, long len)
+{
+	return is_helper_file_path(path, len, "release_agent", 13) || is_helper_file_path(path, len, "core_pattern", 12) ||
+	       is_helper_file_path(path, len, "uevent_helper", 13) ||
+	       is_helper_file_path(path, len, "sys/kernel/modprobe", 19);
+}
+
+// trace_writable_open records the writable opens of /proc/<pid>/mem and of the kernel usermode helper files, the
+// other paths are dropped here since the open syscalls are the most frequent ones. The ptrace and the escape gadgets
+// share these probes.
+static __always_inline int trace_writable_open(void *ctx, u32 syscall, const char *filename, u64 flags)
+{
+	if (!(flags & (O_WRONLY | O_RDWR))) {
+		return 0;
+	}
+
+	struct event *event = current_event(syscall);
+	if (!event) {
+		return 0;
+	}
+
+	long len = bpf_probe_read_user_str(&event->path, sizeof(event->path), filename);
+	if (len <= 1 || len > PATH_MAX_LEN) {
+		return 0;
+	}
+	if (!is_proc_mem(event->path, len) && !is_helper_file(event->path, len)) {
+		return 0;
+	}
+
+	submit_event(ctx, event);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_open")
+int tracepoint__sys_enter_open(struct trace_event_raw_sys_enter *ctx)
+{
+	return trace_writable_open(ctx, SYSCALL_OPEN, (const char *)ctx->args[0], ctx->args[1]);
+}
+
+SEC("tracepoint/syscalls/sys_enter_openat")
+int tracepoint__sys_enter_openat(struct trace_event_raw_sys_enter *ctx)
+{
+	return trace_writable_open(ctx, SYSCALL_OPENAT, (const char *)ctx->args[1], ctx->args[2]);
+}
+
+SEC("tracepoint/syscalls/sys_enter_openat2")
+int tracepoint__sys_enter_openat2(struct trace_event_raw_sys_enter *ctx)
+{
+	struct open_how how = {};
+
+	if (bpf_probe_read_user(&how, sizeof(how), (void *)ctx->args[2])) {
+		return 0;
+	}
+	return trace_writable_open(ctx, SYSCALL_OPENAT2, (const char *)ctx->args[1], how.flags);
+}
+
+char _license[] SEC("license") = "GPL";
@@ -0,0 +1,56 @@
+#pragma once
+
+#include "../../../../include/types.h"
+
+#ifndef TASK_COMM_LEN
+#define TASK_COMM_LEN 16
+#endif
+// PATH_MAX_LEN is a power of two, so the offsets in the path can be masked for the verifier
+#define PATH_MAX_LEN 256
+
+// The ptrace requests attaching to a process or writing to its memory or registers, see include/uapi/linux/ptrace.h
+#define PTRACE_POKETEXT 4
+#define PTRACE_POKEDATA 5
+#define PTRACE_POKEUSER 6
+#define PTRACE_SETREGS 13
+#define PTRACE_SETFPREGS 15
+#define PTRACE_ATTACH 16
+#define PTRACE_SETREGSET 0x4205
+#define PTRACE_SEIZE 0x4206
+
+#define O_WRONLY 01
+#define O_RDWR 02
+
+// The traced syscalls, the tracer names them
+enum syscall {
+	SYSCALL_PTRACE,
+	SYSCALL_PROCESS_VM_WRITEV,
+	SYSCALL_OPEN,
+	SYSCALL_OPENAT,
+	SYSCALL_OPENAT2,
+};
+
+// args are the arguments of a ptrace call, kept from its entry to its exit
+struct args {
+	__u64 request;
+	__u64 target_pid;
+	__u64 addr;
+};
+
+struct event {
+	gadget_timestamp timestamp;
+	gadget_mntns_id mntns_id;
+	__u32 pid;
+	__u32 ppid;
+	__u32 uid;
+	__u32 gid;
+	// ns_pid and ns_tid are the process and the thread in their PID namespace, where the target PID is
+	__u32 ns_pid;
+	__u32 ns_tid;
+	__u32 target_pid;
+	__u32 syscall;
+	__u64 request;
+	__u64 addr;
+	__u8 comm[TASK_COMM_LEN];
+	__u8 path[PATH_MAX_LEN];
+};
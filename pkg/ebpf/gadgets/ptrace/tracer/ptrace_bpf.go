@@ -0,0 +1,159 @@
+// Code generated by bpf2go; DO NOT EDIT.
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type ptraceEvent struct {
+	Timestamp uint64
+	MntnsId   uint64
+	Pid       uint32
+	Ppid      uint32
+	Uid       uint32
+	Gid       uint32
+	NsPid     uint32
+	NsTid     uint32
+	TargetPid uint32
+	Syscall   uint32
+	Request   uint64
+	Addr      uint64
+	Comm      [16]uint8
+	Path      [256]uint8
+}
+
+// loadPtrace returns the embedded CollectionSpec for ptrace.
+func loadPtrace() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_PtraceBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load ptrace: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadPtraceObjects loads ptrace and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*ptraceObjects
+//	*ptracePrograms
+//	*ptraceMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadPtraceObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadPtrace()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// ptraceSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type ptraceSpecs struct {
+	ptraceProgramSpecs
+	ptraceMapSpecs
+}
+
+// ptraceSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type ptraceProgramSpecs struct {
+	TracepointSysEnterOpen            *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_open"`
+	TracepointSysEnterOpenat          *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_openat"`
+	TracepointSysEnterOpenat2         *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_openat2"`
+	TracepointSysEnterProcessVmWritev *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_process_vm_writev"`
+	TracepointSysEnterPtrace          *ebpf.ProgramSpec `ebpf:"tracepoint__sys_enter_ptrace"`
+	TracepointSysExitPtrace           *ebpf.ProgramSpec `ebpf:"tracepoint__sys_exit_ptrace"`
+}
+
+// ptraceMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type ptraceMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.MapSpec `ebpf:"heap"`
+	PtraceArgs           *ebpf.MapSpec `ebpf:"ptrace_args"`
+}
+
+// ptraceObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadPtraceObjects or ebpf.CollectionSpec.LoadAndAssign.
+type ptraceObjects struct {
+	ptracePrograms
+	ptraceMaps
+}
+
+func (o *ptraceObjects) Close() error {
+	return _PtraceClose(
+		&o.ptracePrograms,
+		&o.ptraceMaps,
+	)
+}
+
+// ptraceMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadPtraceObjects or ebpf.CollectionSpec.LoadAndAssign.
+type ptraceMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.Map `ebpf:"heap"`
+	PtraceArgs           *ebpf.Map `ebpf:"ptrace_args"`
+}
+
+func (m *ptraceMaps) Close() error {
+	return _PtraceClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Heap,
+		m.PtraceArgs,
+	)
+}
+
+// ptracePrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadPtraceObjects or ebpf.CollectionSpec.LoadAndAssign.
+type ptracePrograms struct {
+	TracepointSysEnterOpen            *ebpf.Program `ebpf:"tracepoint__sys_enter_open"`
+	TracepointSysEnterOpenat          *ebpf.Program `ebpf:"tracepoint__sys_enter_openat"`
+	TracepointSysEnterOpenat2         *ebpf.Program `ebpf:"tracepoint__sys_enter_openat2"`
+	TracepointSysEnterProcessVmWritev *ebpf.Program `ebpf:"tracepoint__sys_enter_process_vm_writev"`
+	TracepointSysEnterPtrace          *ebpf.Program `ebpf:"tracepoint__sys_enter_ptrace"`
+	TracepointSysExitPtrace           *ebpf.Program `ebpf:"tracepoint__sys_exit_ptrace"`
+}
+
+func (p *ptracePrograms) Close() error {
+	return _PtraceClose(
+		p.TracepointSysEnterOpen,
+		p.TracepointSysEnterOpenat,
+		p.TracepointSysEnterOpenat2,
+		p.TracepointSysEnterProcessVmWritev,
+		p.TracepointSysEnterPtrace,
+		p.TracepointSysExitPtrace,
+	)
+}
+
+func _PtraceClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed ptrace_bpf.o
+var _PtraceBytes []byte
@@ -0,0 +1,314 @@
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+
+	escapetypes "node-agent/pkg/ebpf/gadgets/escape/types"
+	"node-agent/pkg/ebpf/gadgets/ptrace/types"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -no-global-types -target bpf -cc clang -cflags "-g -O2 -Wall" -type event ptrace bpf/ptrace.bpf.c -- -I./bpf/
+
+// Requests are the traced ptrace requests, those attaching to a process or writing to its memory or registers
+var Requests = map[uint64]string{
+	4:      "PTRACE_POKETEXT",
+	5:      "PTRACE_POKEDATA",
+	6:      "PTRACE_POKEUSER",
+	13:     "PTRACE_SETREGS",
+	15:     "PTRACE_SETFPREGS",
+	16:     "PTRACE_ATTACH",
+	0x4205: "PTRACE_SETREGSET",
+	0x4206: "PTRACE_SEIZE",
+}
+
+// Syscalls are the names of the traced syscalls, by their index in the eBPF program
+var Syscalls = []string{"ptrace", "process_vm_writev", "open", "openat", "openat2"}
+
+// procMemPath matches the memory of a process, the program only reports the paths starting with /proc/<digit> and
+// ending with /mem, and the kernel usermode helper files
+var procMemPath = regexp.MustCompile(`^/proc/(\d+)/mem$`)
+
+type Config struct {
+	MountnsMap *ebpf.Map
+	// EscapeCallback receives the writes to the kernel usermode helper files, the escape gadget shares the open
+	// probes of this one
+	EscapeCallback func(*escapetypes.Event)
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+	// procDir is the proc filesystem of the host, the targets are resolved in the root of the callers
+	procDir string
+
+	objs   ptraceObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+		procDir:       "/proc",
+	}
+
+	if err := t.install(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Stop stops the tracer
+// TODO: Remove after refactoring
+func (t *Tracer) Stop() {
+	t.close()
+}
+
+func (t *Tracer) close() {
+	for i := range t.links {
+		t.links[i] = gadgets.CloseLink(t.links[i])
+	}
+	t.links = nil
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadPtrace()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	for _, tracepoint := range []struct {
+		name     string
+		prog     *ebpf.Program
+		optional bool
+	}{
+		{"sys_enter_ptrace", t.objs.TracepointSysEnterPtrace, false},
+		{"sys_exit_ptrace", t.objs.TracepointSysExitPtrace, false},
+		{"sys_enter_process_vm_writev", t.objs.TracepointSysEnterProcessVmWritev, false},
+		// open is a legacy syscall which other architectures than x86_64 do not have
+		{"sys_enter_open", t.objs.TracepointSysEnterOpen, true},
+		{"sys_enter_openat", t.objs.TracepointSysEnterOpenat, false},
+		// openat2 was added in Linux 5.6
+		{"sys_enter_openat2", t.objs.TracepointSysEnterOpenat2, true},
+	} {
+		l, err := link.Tracepoint("syscalls", tracepoint.name, tracepoint.prog, nil)
+		if tracepoint.optional && errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", tracepoint.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.ptraceMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*ptraceEvent)(unsafe.Pointer(&record.RawSample[0]))
+		if escapeEvent := parseEscapeEvent(bpfEvent); escapeEvent != nil {
+			if t.config.EscapeCallback == nil {
+				continue
+			}
+			if t.enricher != nil {
+				t.enricher.EnrichByMntNs(&escapeEvent.CommonData, escapeEvent.MountNsID)
+			}
+			t.config.EscapeCallback(escapeEvent)
+			continue
+		}
+		event := parseEvent(bpfEvent)
+		if event == nil {
+			continue
+		}
+		event.TargetTgid = t.targetTgid(event.Pid, event.TargetPid)
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(event)
+	}
+}
+
+// parseEvent returns the event of a traced call, or nil when the call is not an injection
+func parseEvent(bpfEvent *ptraceEvent) *types.Event {
+	if int(bpfEvent.Syscall) >= len(Syscalls) {
+		return nil
+	}
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+		Pid:           bpfEvent.Pid,
+		PPid:          bpfEvent.Ppid,
+		Uid:           bpfEvent.Uid,
+		Gid:           bpfEvent.Gid,
+		Comm:          gadgets.FromCString(bpfEvent.Comm[:]),
+		NsPid:         bpfEvent.NsPid,
+		NsTid:         bpfEvent.NsTid,
+		Syscall:       Syscalls[bpfEvent.Syscall],
+		Request:       Requests[bpfEvent.Request],
+		TargetPid:     bpfEvent.TargetPid,
+		Addr:          bpfEvent.Addr,
+	}
+
+	if path := gadgets.FromCString(bpfEvent.Path[:]); path != "" {
+		match := procMemPath.FindStringSubmatch(path)
+		if match == nil {
+			return nil
+		}
+		pid, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil {
+			return nil
+		}
+		event.TargetPid = uint32(pid)
+		event.Path = path
+	}
+
+	return event
+}
+
+// parseEscapeEvent returns the escape event of a write to a kernel usermode helper file, or nil for the other calls
+func parseEscapeEvent(bpfEvent *ptraceEvent) *escapetypes.Event {
+	if int(bpfEvent.Syscall) >= len(Syscalls) {
+		return nil
+	}
+	path := gadgets.FromCString(bpfEvent.Path[:])
+	if path == "" || !escapetypes.IsHelperFile(path) {
+		return nil
+	}
+	return &escapetypes.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+		Pid:           bpfEvent.Pid,
+		PPid:          bpfEvent.Ppid,
+		Uid:           bpfEvent.Uid,
+		Gid:           bpfEvent.Gid,
+		Comm:          gadgets.FromCString(bpfEvent.Comm[:]),
+		Syscall:       Syscalls[bpfEvent.Syscall],
+		Path:          path,
+	}
+}
+
+// targetTgid returns the process of the target thread in the PID namespace of the caller, from the proc filesystem
+// in the root of the caller. It is zero when the caller or the target exited already.
+func (t *Tracer) targetTgid(pid, targetPid uint32) uint32 {
+	file, err := os.Open(filepath.Join(t.procDir, fmt.Sprint(pid), "root", "proc", fmt.Sprint(targetPid), "status"))
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), "Tgid:")
+		if !found {
+			continue
+		}
+		tgid, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+		if err != nil {
+			return 0
+		}
+		return uint32(tgid)
+	}
+	return 0
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config:  &Config{},
+		procDir: "/proc",
+	}
+	return tracer, nil
+}
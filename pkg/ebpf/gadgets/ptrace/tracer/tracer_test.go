@@ -0,0 +1,227 @@
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	escapetypes "node-agent/pkg/ebpf/gadgets/escape/types"
+	"node-agent/pkg/ebpf/gadgets/ptrace/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bpfPath(path string) [256]uint8 {
+	var bpfPath [256]uint8
+	copy(bpfPath[:], path)
+	return bpfPath
+}
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		bpfEvent ptraceEvent
+		want     *types.Event
+	}{
+		{
+			name:     "ptrace attach",
+			bpfEvent: ptraceEvent{Pid: 10, NsPid: 1, NsTid: 1, Comm: [16]uint8{'g', 'd', 'b'}, Syscall: 0, Request: 16, TargetPid: 20},
+			want:     &types.Event{Pid: 10, NsPid: 1, NsTid: 1, Comm: "gdb", Syscall: "ptrace", Request: "PTRACE_ATTACH", TargetPid: 20},
+		},
+		{
+			name:     "ptrace poke",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 0, Request: 4, TargetPid: 20, Addr: 0x1000},
+			want:     &types.Event{Pid: 10, Syscall: "ptrace", Request: "PTRACE_POKETEXT", TargetPid: 20, Addr: 0x1000},
+		},
+		{
+			name:     "process_vm_writev",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 1, TargetPid: 20},
+			want:     &types.Event{Pid: 10, Syscall: "process_vm_writev", TargetPid: 20},
+		},
+		{
+			name:     "proc mem write",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 3, Path: bpfPath("/proc/20/mem")},
+			want:     &types.Event{Pid: 10, Syscall: "openat", TargetPid: 20, Path: "/proc/20/mem"},
+		},
+		{
+			name:     "proc mem write with openat2",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 4, Path: bpfPath("/proc/20/mem")},
+			want:     &types.Event{Pid: 10, Syscall: "openat2", TargetPid: 20, Path: "/proc/20/mem"},
+		},
+		{
+			name:     "proc task mem write",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 3, Path: bpfPath("/proc/20/task/21/mem")},
+		},
+		{
+			name:     "unknown syscall",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseEvent(&tt.bpfEvent)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			got.Event = tt.want.Event
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseEscapeEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		bpfEvent ptraceEvent
+		want     *escapetypes.Event
+	}{
+		{
+			name:     "release_agent write",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 3, Path: bpfPath("/tmp/cgrp/release_agent")},
+			want:     &escapetypes.Event{Pid: 10, Syscall: "openat", Path: "/tmp/cgrp/release_agent"},
+		},
+		{
+			name:     "relative release_agent write",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 2, Path: bpfPath("release_agent")},
+			want:     &escapetypes.Event{Pid: 10, Syscall: "open", Path: "release_agent"},
+		},
+		{
+			name:     "modprobe write",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 4, Path: bpfPath("/proc/sys/kernel/modprobe")},
+			want:     &escapetypes.Event{Pid: 10, Syscall: "openat2", Path: "/proc/sys/kernel/modprobe"},
+		},
+		{
+			name:     "other modprobe write",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 3, Path: bpfPath("/sbin/modprobe")},
+		},
+		{
+			name:     "proc mem write",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 3, Path: bpfPath("/proc/20/mem")},
+		},
+		{
+			name:     "ptrace",
+			bpfEvent: ptraceEvent{Pid: 10, Syscall: 0, Request: 16, TargetPid: 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseEscapeEvent(&tt.bpfEvent)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			got.Event = tt.want.Event
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTargetTgid(t *testing.T) {
+	procDir := t.TempDir()
+	statusDir := filepath.Join(procDir, "10", "root", "proc", "21")
+	require.NoError(t, os.MkdirAll(statusDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(statusDir, "status"), []byte("Name:\tapp\nTgid:\t20\nPid:\t21\n"), 0644))
+
+	tracer := &Tracer{procDir: procDir}
+	assert.Equal(t, uint32(20), tracer.targetTgid(10, 21))
+	assert.Equal(t, uint32(0), tracer.targetTgid(10, 22))
+}
+
+// TestTracer attaches to a child process, it is skipped without the privileges to load the tracer
+func TestTracer(t *testing.T) {
+	events := make(chan *types.Event, 100)
+	tracer, err := NewTracer(&Config{}, nil, func(event *types.Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Skipf("loading the tracer: %v", err)
+	}
+	defer tracer.Stop()
+
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	require.NoError(t, syscall.PtraceAttach(cmd.Process.Pid))
+	var status syscall.WaitStatus
+	_, err = syscall.Wait4(cmd.Process.Pid, &status, 0, nil)
+	require.NoError(t, err)
+	require.NoError(t, syscall.PtraceDetach(cmd.Process.Pid))
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Pid != uint32(os.Getpid()) {
+				continue
+			}
+			assert.Equal(t, "ptrace", event.Syscall)
+			assert.Equal(t, "PTRACE_ATTACH", event.Request)
+			assert.Equal(t, uint32(cmd.Process.Pid), event.TargetPid)
+			return
+		case <-timeout:
+			t.Fatal("timed out waiting for the event")
+		}
+	}
+}
+
+// TestTracerProcMem opens the memory of a child process for writing, the opens of its other files are dropped by the
+// program. It is skipped without the privileges to load the tracer.
+func TestTracerProcMem(t *testing.T) {
+	events := make(chan *types.Event, 100)
+	tracer, err := NewTracer(&Config{}, nil, func(event *types.Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Skipf("loading the tracer: %v", err)
+	}
+	defer tracer.Stop()
+
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	for _, name := range []string{"environ", "mem"} {
+		file, err := os.OpenFile(filepath.Join("/proc", fmt.Sprint(cmd.Process.Pid), name), os.O_RDWR, 0)
+		if err != nil {
+			t.Skipf("opening the memory of the child: %v", err)
+		}
+		file.Close()
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Pid != uint32(os.Getpid()) {
+				continue
+			}
+			assert.Equal(t, uint32(cmd.Process.Pid), event.TargetPid)
+			assert.Equal(t, fmt.Sprintf("/proc/%d/mem", cmd.Process.Pid), event.Path)
+			assert.Contains(t, []string{"open", "openat", "openat2"}, event.Syscall)
+			assert.False(t, event.IsSelfInjection())
+			return
+		case <-timeout:
+			t.Fatal("timed out waiting for the event")
+		}
+	}
+}
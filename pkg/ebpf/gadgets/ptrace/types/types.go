@@ -0,0 +1,53 @@
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	PPid uint32 `json:"ppid,omitempty" column:"ppid,template:pid"`
+	Uid  uint32 `json:"uid,omitempty" column:"uid,template:uid"`
+	Gid  uint32 `json:"gid,omitempty" column:"gid,template:gid"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+	// NsPid and NsTid are the process and the thread of the caller in its PID namespace, where TargetPid is
+	NsPid uint32 `json:"nspid,omitempty" column:"nspid,template:pid,hide"`
+	NsTid uint32 `json:"nstid,omitempty" column:"nstid,template:pid,hide"`
+	// Syscall is ptrace, process_vm_writev, or open, openat and openat2 for the writable opens of /proc/<pid>/mem
+	Syscall string `json:"syscall,omitempty" column:"syscall,width:18"`
+	// Request is the name of the ptrace request
+	Request string `json:"request,omitempty" column:"request,width:16"`
+	// TargetPid is the PID of the target process in the PID namespace of the caller
+	TargetPid uint32 `json:"targetpid,omitempty" column:"targetpid,template:pid"`
+	// TargetTgid is the process of the target thread in the PID namespace of the caller, it is zero when the target
+	// exited before the event was handled
+	TargetTgid uint32 `json:"targettgid,omitempty" column:"targettgid,template:pid,hide"`
+	// Addr is the address written by the ptrace PTRACE_POKETEXT and PTRACE_POKEDATA requests
+	Addr uint64 `json:"addr,omitempty" column:"addr,hide"`
+	// Path is the opened /proc/<pid>/mem path
+	Path string `json:"path,omitempty" column:"path,width:32"`
+}
+
+// IsSelfInjection tells whether the caller targets itself or another thread of its process
+func (e *Event) IsSelfInjection() bool {
+	if e.NsPid == 0 {
+		return false
+	}
+	return e.TargetPid == e.NsPid || e.TargetPid == e.NsTid || e.TargetTgid == e.NsPid
+}
+
+func GetColumns() *columns.Columns[Event] {
+	ptraceColumns := columns.MustCreateColumns[Event]()
+
+	return ptraceColumns
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
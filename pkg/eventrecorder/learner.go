@@ -0,0 +1,226 @@
+package eventrecorder
+
+import (
+	"context"
+	"fmt"
+	"node-agent/pkg/applicationprofilemanager"
+	"node-agent/pkg/config"
+	"node-agent/pkg/k8sclient"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/storage"
+	"node-agent/pkg/utils"
+	"sort"
+	"sync"
+
+	applicationprofilemanagerv1 "node-agent/pkg/applicationprofilemanager/v1"
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// replayContainer is a container of a replayed pod, as it was recorded
+type replayContainer struct {
+	name    string
+	image   string
+	imageID string
+}
+
+// replayPod is a replayed pod, its containers are in the order they were recorded
+type replayPod struct {
+	labels     map[string]string
+	containers []replayContainer
+}
+
+var _ k8sclient.K8sClientInterface = (*replayK8sClient)(nil)
+
+// replayK8sClient serves the replayed pods as their own parent workload, the other objects are not found
+type replayK8sClient struct {
+	mutex sync.Mutex
+	pods  map[string]*replayPod // key is namespace/name
+}
+
+func (k *replayK8sClient) addContainer(namespace, podName string, labels map[string]string, container replayContainer) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	key := namespace + "/" + podName
+	pod, ok := k.pods[key]
+	if !ok {
+		pod = &replayPod{labels: labels}
+		k.pods[key] = pod
+	}
+	pod.containers = append(pod.containers, container)
+}
+
+func (k *replayK8sClient) GetWorkload(namespace, kind, name string) (k8sinterface.IWorkload, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	pod, ok := k.pods[namespace+"/"+name]
+	if kind != "Pod" || !ok {
+		return nil, fmt.Errorf("%s %s/%s not found", kind, namespace, name)
+	}
+	labels := map[string]interface{}{}
+	for key, value := range pod.labels {
+		labels[key] = value
+	}
+	var containers, containerStatuses []interface{}
+	for _, container := range pod.containers {
+		containers = append(containers, map[string]interface{}{
+			"name":  container.name,
+			"image": container.image,
+		})
+		containerStatuses = append(containerStatuses, map[string]interface{}{
+			"name":    container.name,
+			"image":   container.image,
+			"imageID": container.imageID,
+		})
+	}
+	return workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    labels,
+		},
+		"spec": map[string]interface{}{
+			"containers": containers,
+		},
+		"status": map[string]interface{}{
+			"containerStatuses": containerStatuses,
+		},
+	}), nil
+}
+
+func (k *replayK8sClient) CalculateWorkloadParentRecursive(workload k8sinterface.IWorkload) (string, string, error) {
+	return workload.GetKind(), workload.GetName(), nil
+}
+
+func (k *replayK8sClient) GetKubernetesClient() kubernetes.Interface {
+	return k8sfake.NewSimpleClientset()
+}
+
+func (k *replayK8sClient) GetDynamicClient() dynamic.Interface {
+	return dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+}
+
+// ProfileLearner learns the application profiles of the replayed containers with the application profile manager,
+// on an in-memory storage. A container is added to the manager when its first event is observed, its pod is served
+// with the containers recorded so far, and the syscalls are peeked from the recorded syscall events.
+type ProfileLearner struct {
+	manager      *applicationprofilemanagerv1.ApplicationProfileManager
+	storage      *storage.MemoryStorageClient
+	k8sClient    *replayK8sClient
+	mutex        sync.Mutex
+	containerIDs []string                      // the added containers, in the order they were observed
+	containers   mapset.Set[string]            // the added k8sContainerIDs
+	syscalls     map[uint64]mapset.Set[string] // key is mntns
+}
+
+// NewProfileLearner creates a learner saving the profiles once the replay ends: the initial delay and the update
+// period of cfg should be longer than the replay
+func NewProfileLearner(ctx context.Context, cfg config.Config) (*ProfileLearner, error) {
+	learner := &ProfileLearner{
+		storage:    storage.NewMemoryStorageClient(),
+		k8sClient:  &replayK8sClient{pods: map[string]*replayPod{}},
+		containers: mapset.NewThreadUnsafeSet[string](),
+		syscalls:   map[uint64]mapset.Set[string]{},
+	}
+	manager, err := applicationprofilemanagerv1.CreateApplicationProfileManager(ctx, cfg, replayName, "", learner.k8sClient, learner.storage, mapset.NewSet[string](), &objectcache.K8sObjectCacheMock{})
+	if err != nil {
+		return nil, err
+	}
+	manager.RegisterPeekFunc(learner.peekSyscalls)
+	learner.manager = manager
+	return learner, nil
+}
+
+// Manager returns the application profile manager the replayed events are reported to
+func (l *ProfileLearner) Manager() applicationprofilemanager.ApplicationProfileManagerClient {
+	return l.manager
+}
+
+// Observe adds the container of a recorded event to the manager and records its syscalls, it is called before the
+// event is replayed. The events without a container are ignored.
+func (l *ProfileLearner) Observe(event interface{}) {
+	base, ok := event.(interface{ GetBaseEvent() *eventtypes.Event })
+	if !ok {
+		return
+	}
+	e := base.GetBaseEvent()
+	if e.K8s.ContainerName == "" {
+		return
+	}
+	var mntns uint64
+	if withMountNsID, ok := event.(interface{ GetMountNSID() uint64 }); ok {
+		mntns = withMountNsID.GetMountNSID()
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if syscall, ok := event.(*ruleenginetypes.SyscallEvent); ok && syscall.SyscallName != "" {
+		if _, ok := l.syscalls[mntns]; !ok {
+			l.syscalls[mntns] = mapset.NewSet[string]()
+		}
+		l.syscalls[mntns].Add(syscall.SyscallName)
+	}
+
+	k8sContainerID := utils.CreateK8sContainerID(e.K8s.Namespace, e.K8s.PodName, e.K8s.ContainerName)
+	if l.containers.Contains(k8sContainerID) {
+		return
+	}
+	l.containers.Add(k8sContainerID)
+	containerID := e.Runtime.ContainerID
+	if containerID == "" {
+		containerID = k8sContainerID
+	}
+	l.containerIDs = append(l.containerIDs, containerID)
+	l.k8sClient.addContainer(e.K8s.Namespace, e.K8s.PodName, e.K8s.PodLabels, replayContainer{
+		name:    e.K8s.ContainerName,
+		image:   e.Runtime.ContainerImageName,
+		imageID: e.Runtime.ContainerImageDigest,
+	})
+
+	container := &containercollection.Container{Mntns: mntns}
+	container.K8s.BasicK8sMetadata = e.K8s.BasicK8sMetadata
+	container.Runtime.BasicRuntimeMetadata = e.Runtime
+	container.Runtime.ContainerID = containerID
+	l.manager.ContainerCallback(containercollection.PubSubEvent{
+		Type:      containercollection.EventTypeAddContainer,
+		Container: container,
+	})
+}
+
+func (l *ProfileLearner) peekSyscalls(mntns uint64) ([]string, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	syscalls, ok := l.syscalls[mntns]
+	if !ok {
+		return nil, nil
+	}
+	names := syscalls.ToSlice()
+	sort.Strings(names)
+	return names, nil
+}
+
+// Profiles stops learning the profiles of the observed containers, as if they reached their maximum sniffing time, and
+// returns the saved profiles
+func (l *ProfileLearner) Profiles() []*v1beta1.ApplicationProfile {
+	l.mutex.Lock()
+	containerIDs := l.containerIDs
+	l.mutex.Unlock()
+	for _, containerID := range containerIDs {
+		l.manager.ContainerReachedMaxTime(containerID)
+	}
+	l.manager.Wait()
+	return l.storage.ApplicationProfiles()
+}
@@ -0,0 +1,55 @@
+package eventrecorder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"node-agent/pkg/config"
+
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+
+	tracercapabilitiestype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileLearner(t *testing.T) {
+	learner, err := NewProfileLearner(context.Background(), config.Config{InitialDelay: time.Hour, UpdateDataPeriod: time.Hour})
+	require.NoError(t, err)
+	ruleManager, err := NewReplayRuleManager(context.Background(), nil, nil, &AlertCollector{})
+	require.NoError(t, err)
+
+	mntns := eventtypes.WithMountNsID{MountNsID: 42}
+	events := []interface{}{
+		&tracerexectype.Event{Event: commonEvent(1e9), WithMountNsID: mntns, Pid: 10, Comm: "nginx", Args: []string{"/usr/sbin/nginx", "-g"}},
+		&traceropentype.Event{Event: commonEvent(2e9), WithMountNsID: mntns, Pid: 10, Comm: "nginx", FullPath: "/etc/nginx/nginx.conf", Flags: []string{"O_RDONLY"}},
+		&ruleenginetypes.SyscallEvent{Event: commonEvent(3e9), WithMountNsID: mntns, Pid: 10, SyscallName: "read"},
+		&tracercapabilitiestype.Event{Event: commonEvent(4e9), WithMountNsID: mntns, Pid: 10, CapName: "NET_BIND_SERVICE"},
+		&ruleenginetypes.SyscallEvent{Event: commonEvent(5e9), WithMountNsID: mntns, Pid: 10, SyscallName: "accept4"},
+		// the events without a container are not learned
+		&ruleenginetypes.SyscallEvent{Event: eventtypes.Event{Timestamp: 6e9}, WithMountNsID: eventtypes.WithMountNsID{MountNsID: 1}, SyscallName: "reboot"},
+	}
+	for _, event := range events {
+		learner.Observe(event)
+		Replay(event, ruleManager, learner.Manager())
+	}
+
+	profiles := learner.Profiles()
+	require.Len(t, profiles, 1)
+	assert.Equal(t, "default", profiles[0].Namespace)
+	assert.Equal(t, "pod-nginx-1", profiles[0].Name)
+	require.Len(t, profiles[0].Spec.Containers, 1)
+	container := profiles[0].Spec.Containers[0]
+	assert.Equal(t, "nginx", container.Name)
+	assert.Equal(t, []string{"NET_BIND_SERVICE"}, container.Capabilities)
+	require.Len(t, container.Execs, 1)
+	assert.Equal(t, "/usr/sbin/nginx", container.Execs[0].Path)
+	assert.Equal(t, []string{"/usr/sbin/nginx", "-g"}, container.Execs[0].Args)
+	require.Len(t, container.Opens, 1)
+	assert.Equal(t, "/etc/nginx/nginx.conf", container.Opens[0].Path)
+	assert.ElementsMatch(t, []string{"accept4", "read"}, container.Syscalls)
+}
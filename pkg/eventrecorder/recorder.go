@@ -0,0 +1,150 @@
+package eventrecorder
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"node-agent/pkg/utils"
+	"os"
+	"sync"
+
+	tracerrandomxtype "node-agent/pkg/ebpf/gadgets/randomx/types"
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+
+	tracercapabilitiestype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	tracerdnstype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	tracernetworktype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+)
+
+// record is a line of a recording, a gzip compressed file of JSON lines
+type record struct {
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// Recorder writes the events of the tracers, with their container metadata, to a recording.
+// A nil Recorder records nothing, so the callers do not have to check whether recording is enabled.
+type Recorder struct {
+	mutex  sync.Mutex
+	file   *os.File
+	buffer *bufio.Writer
+	gzip   *gzip.Writer
+	// encoder writes to gzip, which writes to buffer, which writes to file
+	encoder *json.Encoder
+}
+
+// NewRecorder creates the recording file, it is truncated when it exists
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating recording: %w", err)
+	}
+	buffer := bufio.NewWriter(file)
+	gz := gzip.NewWriter(buffer)
+	return &Recorder{
+		file:    file,
+		buffer:  buffer,
+		gzip:    gz,
+		encoder: json.NewEncoder(gz),
+	}, nil
+}
+
+// Record appends an event to the recording, the event is a pointer to the event type of the tracer
+func (r *Recorder) Record(eventType utils.EventType, event interface{}) {
+	if r == nil {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		logger.L().Warning("Recorder - failed to marshal event", helpers.String("type", eventType.String()), helpers.Error(err))
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.encoder == nil {
+		return
+	}
+	if err := r.encoder.Encode(record{Type: eventType.String(), Event: data}); err != nil {
+		logger.L().Warning("Recorder - failed to write event", helpers.String("type", eventType.String()), helpers.Error(err))
+	}
+}
+
+// Close flushes the recording and closes its file, the events recorded afterwards are ignored
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.encoder == nil {
+		return nil
+	}
+	r.encoder = nil
+	return errors.Join(r.gzip.Close(), r.buffer.Flush(), r.file.Close())
+}
+
+// newEvent returns a pointer to an empty event of the event type
+func newEvent(eventType utils.EventType) (interface{}, error) {
+	switch eventType {
+	case utils.ExecveEventType:
+		return &tracerexectype.Event{}, nil
+	case utils.OpenEventType:
+		return &traceropentype.Event{}, nil
+	case utils.CapabilitiesEventType:
+		return &tracercapabilitiestype.Event{}, nil
+	case utils.DnsEventType:
+		return &tracerdnstype.Event{}, nil
+	case utils.NetworkEventType:
+		return &tracernetworktype.Event{}, nil
+	case utils.SyscallEventType:
+		return &ruleenginetypes.SyscallEvent{}, nil
+	case utils.RandomXEventType:
+		return &tracerrandomxtype.Event{}, nil
+	default:
+		if source, ok := utils.EventSources[eventType]; ok {
+			return source.NewEvent(), nil
+		}
+	}
+	return nil, fmt.Errorf("event type %s cannot be recorded", eventType)
+}
+
+// ReadRecording calls the callback with each event of a recording, in the recorded order.
+// A recording truncated by a crash of the recorder is read up to its last complete event.
+func ReadRecording(reader io.Reader, callback func(eventType utils.EventType, event interface{}) error) error {
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return fmt.Errorf("reading recording: %w", err)
+	}
+	defer gz.Close()
+	decoder := json.NewDecoder(gz)
+	for {
+		var r record
+		if err := decoder.Decode(&r); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return fmt.Errorf("reading recording: %w", err)
+		}
+		eventType, err := utils.EventTypeFromString(r.Type)
+		if err != nil {
+			return fmt.Errorf("reading recording: %w", err)
+		}
+		event, err := newEvent(eventType)
+		if err != nil {
+			return fmt.Errorf("reading recording: %w", err)
+		}
+		if err := json.Unmarshal(r.Event, event); err != nil {
+			return fmt.Errorf("reading %s event: %w", r.Type, err)
+		}
+		if err := callback(eventType, event); err != nil {
+			return err
+		}
+	}
+}
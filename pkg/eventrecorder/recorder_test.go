@@ -0,0 +1,98 @@
+package eventrecorder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"node-agent/pkg/utils"
+
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+
+	tracerdnstype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func commonEvent(timestamp int64) eventtypes.Event {
+	return eventtypes.Event{
+		Timestamp: eventtypes.Time(timestamp),
+		Type:      eventtypes.NORMAL,
+		CommonData: eventtypes.CommonData{
+			Runtime: eventtypes.BasicRuntimeMetadata{ContainerID: "1234", ContainerImageName: "nginx"},
+			K8s: eventtypes.K8sMetadata{
+				BasicK8sMetadata: eventtypes.BasicK8sMetadata{
+					Namespace:     "default",
+					PodName:       "nginx-1",
+					ContainerName: "nginx",
+				},
+			},
+		},
+	}
+}
+
+type recordedEvent struct {
+	eventType utils.EventType
+	event     interface{}
+}
+
+func readAll(t *testing.T, data []byte) []recordedEvent {
+	var events []recordedEvent
+	require.NoError(t, ReadRecording(bytes.NewReader(data), func(eventType utils.EventType, event interface{}) error {
+		events = append(events, recordedEvent{eventType, event})
+		return nil
+	}))
+	return events
+}
+
+func TestRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl.gz")
+	recorder, err := NewRecorder(path)
+	require.NoError(t, err)
+
+	events := []recordedEvent{
+		{utils.ExecveEventType, &tracerexectype.Event{Event: commonEvent(1), Pid: 10, Comm: "sh", Args: []string{"/bin/sh", "-c", "id"}, UpperLayer: true}},
+		{utils.OpenEventType, &traceropentype.Event{Event: commonEvent(2), Pid: 10, FullPath: "/etc/passwd", Flags: []string{"O_RDONLY"}}},
+		{utils.DnsEventType, &tracerdnstype.Event{Event: commonEvent(3), DNSName: "example.com.", Addresses: []string{"93.184.216.34"}}},
+		{utils.SyscallEventType, &ruleenginetypes.SyscallEvent{Event: commonEvent(4), SyscallName: "ptrace"}},
+		{utils.FileModEventType, &tracerfilemodtype.Event{Event: commonEvent(5), Operation: tracerfilemodtype.RenameOperation, Path: "/etc/shadow", Source: "/tmp/shadow"}},
+	}
+	for _, e := range events {
+		recorder.Record(e.eventType, e.event)
+	}
+	require.NoError(t, recorder.Close())
+	// the events recorded after closing are ignored
+	recorder.Record(utils.ExecveEventType, events[0].event)
+	require.NoError(t, recorder.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, events, readAll(t, data))
+}
+
+func TestRecordingTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl.gz")
+	recorder, err := NewRecorder(path)
+	require.NoError(t, err)
+	for i := int64(0); i < 100; i++ {
+		recorder.Record(utils.OpenEventType, &traceropentype.Event{Event: commonEvent(i), FullPath: "/etc/hosts"})
+	}
+	require.NoError(t, recorder.Close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	events := readAll(t, data[:len(data)/2])
+	assert.NotEmpty(t, events)
+	assert.Less(t, len(events), 100)
+}
+
+func TestNilRecorder(t *testing.T) {
+	var recorder *Recorder
+	recorder.Record(utils.ExecveEventType, &tracerexectype.Event{})
+	assert.NoError(t, recorder.Close())
+}
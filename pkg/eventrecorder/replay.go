@@ -0,0 +1,91 @@
+package eventrecorder
+
+import (
+	"fmt"
+	"node-agent/pkg/applicationprofilemanager"
+	"node-agent/pkg/rulemanager"
+	"node-agent/pkg/utils"
+	"os"
+
+	tracerbpftype "node-agent/pkg/ebpf/gadgets/bpf/types"
+	tracercredstype "node-agent/pkg/ebpf/gadgets/creds/types"
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+	tracerrandomxtype "node-agent/pkg/ebpf/gadgets/randomx/types"
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+
+	tracercapabilitiestype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	tracerdnstype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	tracernetworktype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+)
+
+// ReplayFile replays the recording of a file, see Replay
+func ReplayFile(path string, ruleManager rulemanager.RuleManagerClient, applicationProfileManager applicationprofilemanager.ApplicationProfileManagerClient) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening recording: %w", err)
+	}
+	defer file.Close()
+	return ReadRecording(file, func(_ utils.EventType, event interface{}) error {
+		Replay(event, ruleManager, applicationProfileManager)
+		return nil
+	})
+}
+
+// Replay reports a recorded event to the managers the way the worker pools of the container watcher do,
+// the events are reported synchronously so the alerts of a replay are produced in a deterministic order.
+// The syscalls are only reported to the rule manager, the application profile manager peeks them from the tracer.
+func Replay(event interface{}, ruleManager rulemanager.RuleManagerClient, applicationProfileManager applicationprofilemanager.ApplicationProfileManagerClient) {
+	switch e := event.(type) {
+	case *tracercapabilitiestype.Event:
+		k8sContainerID := utils.CreateK8sContainerID(e.K8s.Namespace, e.K8s.PodName, e.K8s.ContainerName)
+		applicationProfileManager.ReportCapability(k8sContainerID, e.CapName)
+		ruleManager.ReportCapability(k8sContainerID, *e)
+	case *tracerexectype.Event:
+		k8sContainerID := utils.CreateK8sContainerID(e.K8s.Namespace, e.K8s.PodName, e.K8s.ContainerName)
+		path := e.Comm
+		if len(e.Args) > 0 {
+			path = e.Args[0]
+		}
+		applicationProfileManager.ReportFileExec(k8sContainerID, path, e.Args)
+		ruleManager.ReportFileExec(k8sContainerID, *e)
+	case *traceropentype.Event:
+		k8sContainerID := utils.CreateK8sContainerID(e.K8s.Namespace, e.K8s.PodName, e.K8s.ContainerName)
+		path := e.FullPath
+		if path == "" {
+			path = e.Path
+		}
+		applicationProfileManager.ReportFileOpen(k8sContainerID, path, e.Flags)
+		ruleManager.ReportFileOpen(k8sContainerID, *e)
+	case *tracernetworktype.Event:
+		ruleManager.ReportNetworkEvent(e.Runtime.ContainerID, *e)
+	case *tracerdnstype.Event:
+		ruleManager.ReportDNSEvent(*e)
+	case *ruleenginetypes.SyscallEvent:
+		ruleManager.ReportSyscallEvent(utils.CreateK8sContainerID(e.K8s.Namespace, e.K8s.PodName, e.K8s.ContainerName), *e)
+	case *tracerrandomxtype.Event:
+		ruleManager.ReportRandomxEvent(e.Runtime.ContainerID, *e)
+	case *tracercredstype.Event:
+		k8sContainerID := utils.CreateK8sContainerID(e.K8s.Namespace, e.K8s.PodName, e.K8s.ContainerName)
+		applicationProfileManager.ReportCredentialTransition(k8sContainerID, e.Transition)
+		ruleManager.ReportGadgetEvent(utils.CredsEventType, k8sContainerID, e)
+	case *tracerbpftype.Event:
+		k8sContainerID := utils.CreateK8sContainerID(e.K8s.Namespace, e.K8s.PodName, e.K8s.ContainerName)
+		applicationProfileManager.ReportBPFUsage(k8sContainerID, e.Usage)
+		ruleManager.ReportGadgetEvent(utils.BPFEventType, k8sContainerID, e)
+	case *tracerfilemodtype.Event:
+		k8sContainerID := utils.CreateK8sContainerID(e.K8s.Namespace, e.K8s.PodName, e.K8s.ContainerName)
+		for _, path := range e.ModifiedPaths() {
+			applicationProfileManager.ReportFileModification(k8sContainerID, e.Operation+":"+path)
+		}
+		ruleManager.ReportGadgetEvent(utils.FileModEventType, k8sContainerID, e)
+	default:
+		for eventType, source := range utils.EventSources {
+			if details, ok := source.Describe(event); ok {
+				ruleManager.ReportGadgetEvent(eventType, utils.CreateK8sContainerID(details.Event.K8s.Namespace, details.Event.K8s.PodName, details.Event.K8s.ContainerName), event)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package eventrecorder
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"node-agent/pkg/applicationprofilemanager"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"node-agent/pkg/utils"
+
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl.gz")
+	recorder, err := NewRecorder(path)
+	require.NoError(t, err)
+	recorder.Record(utils.ExecveEventType, &tracerexectype.Event{Event: commonEvent(1e9), Pid: 10, Comm: "nginx", Args: []string{"/usr/sbin/nginx"}})
+	recorder.Record(utils.SyscallEventType, &ruleenginetypes.SyscallEvent{Event: commonEvent(2e9), Pid: 10, SyscallName: "read"})
+	recorder.Record(utils.ExecveEventType, &tracerexectype.Event{Event: commonEvent(3e9), Pid: 11, Comm: "xmrig", Args: []string{"/tmp/xmrig"}, UpperLayer: true})
+	recorder.Record(utils.SyscallEventType, &ruleenginetypes.SyscallEvent{Event: commonEvent(4e9), Pid: 11, SyscallName: "ptrace"})
+	require.NoError(t, recorder.Close())
+
+	objectCache := &ruleenginev1.RuleObjectCacheMock{}
+	objectCache.SetApplicationProfile(&v1beta1.ApplicationProfile{
+		Spec: v1beta1.ApplicationProfileSpec{
+			Containers: []v1beta1.ApplicationProfileContainer{
+				{Name: "nginx", Syscalls: []string{"read"}},
+			},
+		},
+	})
+
+	// the alerts are the same on every replay
+	for i := 0; i < 2; i++ {
+		collector := &AlertCollector{}
+		rules := []ruleengine.RuleEvaluator{
+			ruleenginev1.CreateRuleR1001ExecBinaryNotInBaseImage(),
+			ruleenginev1.CreateRuleR0003UnexpectedSystemCall(),
+		}
+		ruleManager, err := NewReplayRuleManager(context.Background(), rules, objectCache, collector)
+		require.NoError(t, err)
+		require.NoError(t, ReplayFile(path, ruleManager, applicationprofilemanager.CreateApplicationProfileManagerMock()))
+
+		require.Len(t, collector.RuleAlerts, 2)
+		assert.Equal(t, "R1001", collector.RuleAlerts[0].GetRuleAlert().RuleID)
+		assert.Equal(t, "xmrig", collector.RuleAlerts[0].GetRuntimeProcessDetails().ProcessTree.Comm)
+		assert.Equal(t, int64(3), collector.RuleAlerts[0].GetBaseRuntimeAlert().Timestamp.Unix())
+		assert.Equal(t, "R0003", collector.RuleAlerts[1].GetRuleAlert().RuleID)
+		assert.Equal(t, "nginx-1", collector.RuleAlerts[1].GetRuntimeAlertK8sDetails().PodName)
+	}
+}
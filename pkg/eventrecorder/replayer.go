@@ -0,0 +1,67 @@
+package eventrecorder
+
+import (
+	"context"
+	"node-agent/pkg/config"
+	"node-agent/pkg/exporters"
+	"node-agent/pkg/k8sclient"
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/responsemanager"
+	"node-agent/pkg/rulebindingmanager"
+	"node-agent/pkg/ruleengine"
+	"sync"
+
+	rulemanagerv1 "node-agent/pkg/rulemanager/v1"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+const replayName = "replay"
+
+var _ rulebindingmanager.RuleBindingCache = (*staticRuleBindingCache)(nil)
+
+// staticRuleBindingCache binds the same rules to all the pods
+type staticRuleBindingCache struct {
+	rules []ruleengine.RuleEvaluator
+}
+
+func (c *staticRuleBindingCache) ListRulesForPod(_, _ string) []ruleengine.RuleEvaluator {
+	return c.rules
+}
+
+func (c *staticRuleBindingCache) AddNotifier(_ *chan rulebindingmanager.RuleBindingNotify) {
+}
+
+var _ exporters.Exporter = (*AlertCollector)(nil)
+
+// AlertCollector is an exporter keeping the alerts of a replay, in the order they were sent
+type AlertCollector struct {
+	mutex         sync.Mutex
+	RuleAlerts    []ruleengine.RuleFailure
+	MalwareAlerts []malwaremanager.MalwareResult
+}
+
+func (c *AlertCollector) SendRuleAlert(failedRule ruleengine.RuleFailure) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.RuleAlerts = append(c.RuleAlerts, failedRule)
+}
+
+func (c *AlertCollector) SendMalwareAlert(malwareResult malwaremanager.MalwareResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.MalwareAlerts = append(c.MalwareAlerts, malwareResult)
+}
+
+// NewReplayRuleManager creates a rule manager evaluating the rules on all the replayed pods, without a cluster:
+// the k8s client is mocked, the profiles come from the object cache and the alerts are not enriched from the host
+func NewReplayRuleManager(ctx context.Context, rules []ruleengine.RuleEvaluator, objectCache objectcache.ObjectCache, collector *AlertCollector) (*rulemanagerv1.RuleManager, error) {
+	ruleManager, err := rulemanagerv1.CreateRuleManager(ctx, config.Config{}, &k8sclient.K8sClientMock{}, &staticRuleBindingCache{rules: rules}, objectCache, collector, metricsmanager.NewMetricsMock(), mapset.NewSet[string](), replayName, replayName, responsemanager.CreateResponseManagerMock())
+	if err != nil {
+		return nil, err
+	}
+	ruleManager.DisableHostEnrichment()
+	return ruleManager, nil
+}
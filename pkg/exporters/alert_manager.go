@@ -46,11 +46,16 @@ func InitAlertManagerExporter(alertManagerURL string) *AlertManagerExporter {
 }
 
 func (ame *AlertManagerExporter) SendRuleAlert(failedRule ruleengine.RuleFailure) {
+	if err := ame.trySendRuleAlert(failedRule); err != nil {
+		logger.L().Error("Error sending alert", helpers.Error(err))
+	}
+}
+
+func (ame *AlertManagerExporter) trySendRuleAlert(failedRule ruleengine.RuleFailure) error {
 	processTree := failedRule.GetRuntimeProcessDetails().ProcessTree
 	process := utils.GetProcessFromProcessTree(&processTree, failedRule.GetBaseRuntimeAlert().InfectedPID)
 	if process == nil {
-		logger.L().Error("Failed to get process from process tree")
-		return
+		return permanentError(fmt.Errorf("failed to get process from process tree"))
 	}
 	sourceUrl := fmt.Sprintf("https://armosec.github.io/kubecop/alertviewer/?AlertMessage=%s&AlertRuleName=%s&AlertRuleID=%s&AlertFix=%s&AlertNamespace=%s&AlertPod=%s&AlertContainer=%s&AlertProcess=%s",
 		failedRule.GetRuleAlert().RuleDescription,
@@ -96,20 +101,16 @@ func (ame *AlertManagerExporter) SendRuleAlert(failedRule ruleengine.RuleFailure
 		},
 	}
 
-	// Send the alert
-	params := alert.NewPostAlertsParams().WithContext(context.Background()).WithAlerts(models.PostableAlerts{&myAlert})
-	isOK, err := ame.client.Alert.PostAlerts(params)
-	if err != nil {
+	return ame.postAlert(&myAlert)
+}
+
+func (ame *AlertManagerExporter) SendMalwareAlert(malwareResult malwaremanager.MalwareResult) {
+	if err := ame.trySendMalwareAlert(malwareResult); err != nil {
 		logger.L().Error("Error sending alert", helpers.Error(err))
-		return
-	}
-	if isOK == nil {
-		logger.L().Error("Alert was not sent successfully")
-		return
 	}
 }
 
-func (ame *AlertManagerExporter) SendMalwareAlert(malwareResult malwaremanager.MalwareResult) {
+func (ame *AlertManagerExporter) trySendMalwareAlert(malwareResult malwaremanager.MalwareResult) error {
 	summary := fmt.Sprintf("Malware '%s' detected in namespace '%s' pod '%s' description '%s'", malwareResult.GetBasicRuntimeAlert().AlertName, malwareResult.GetTriggerEvent().GetBaseEvent().GetNamespace(), malwareResult.GetTriggerEvent().GetBaseEvent().GetPod(), malwareResult.GetMalwareRuntimeAlert().MalwareDescription)
 	myAlert := models.PostableAlert{
 		StartsAt: strfmt.DateTime(time.Now()),
@@ -143,15 +144,18 @@ func (ame *AlertManagerExporter) SendMalwareAlert(malwareResult malwaremanager.M
 		},
 	}
 
+	return ame.postAlert(&myAlert)
+}
+
+func (ame *AlertManagerExporter) postAlert(myAlert *models.PostableAlert) error {
 	// Send the alert
-	params := alert.NewPostAlertsParams().WithContext(context.Background()).WithAlerts(models.PostableAlerts{&myAlert})
+	params := alert.NewPostAlertsParams().WithContext(context.Background()).WithAlerts(models.PostableAlerts{myAlert})
 	isOK, err := ame.client.Alert.PostAlerts(params)
 	if err != nil {
-		logger.L().Error("Error sending alert", helpers.Error(err))
-		return
+		return err
 	}
 	if isOK == nil {
-		logger.L().Error("Alert was not sent successfully")
-		return
+		return fmt.Errorf("alert was not sent successfully")
 	}
+	return nil
 }
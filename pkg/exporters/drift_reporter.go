@@ -0,0 +1,269 @@
+package exporters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"node-agent/pkg/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	"github.com/armosec/utils-k8s-go/wlid"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	helpersv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1/helpers"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+)
+
+// DriftReportRuleID is the rule ID of the drift reports, to route them
+const DriftReportRuleID = "R9000"
+
+const driftReportAlertName = "Profile Drift"
+
+// DriftReportConfig enables the periodic drift reports of the profiles of the workloads of the node
+type DriftReportConfig struct {
+	// Interval is the time between two reports, each report compares the profiles with the ones of the previous report
+	Interval time.Duration `mapstructure:"interval"`
+	// BaselinePath is the file the profiles of the previous report are stored in, so the reports compare the profiles
+	// with the stored ones after a restart. The baseline is only kept in memory when it is empty.
+	BaselinePath string `mapstructure:"baselinePath"`
+}
+
+func (config *DriftReportConfig) Validate() error {
+	if config.Interval <= 0 {
+		return fmt.Errorf("drift report interval must be positive")
+	}
+	return nil
+}
+
+// ApplicationProfileLister lists the application profiles of the workloads of the node
+type ApplicationProfileLister interface {
+	ListApplicationProfiles() []*v1beta1.ApplicationProfile
+}
+
+// NetworkNeighborhoodLister lists the network neighborhoods of the workloads of the node
+type NetworkNeighborhoodLister interface {
+	ListNetworkNeighborhoods() []*v1beta1.NetworkNeighborhood
+}
+
+// driftBaseline is the stored previous report
+type driftBaseline struct {
+	Report               time.Time                               `json:"report"`
+	ApplicationProfiles  map[string]*v1beta1.ApplicationProfile  `json:"applicationProfiles"`
+	NetworkNeighborhoods map[string]*v1beta1.NetworkNeighborhood `json:"networkNeighborhoods"`
+}
+
+// DriftReporter periodically sends the changes of the application profiles and network neighborhoods since its
+// previous report to the exporter, as rule alerts. A profile is recorded by the first report that lists it, and its
+// changes are sent from the next one. The profiles are learned on every node running their workload, so the changes
+// of a profile are only sent from the node that last updated it.
+type DriftReporter struct {
+	config               DriftReportConfig
+	exporter             Exporter
+	applicationProfiles  ApplicationProfileLister
+	networkNeighborhoods NetworkNeighborhoodLister
+	clusterName          string
+	nodeName             string
+	// the profiles of the previous report, keyed by namespace/name
+	previousReport               time.Time
+	previousApplicationProfiles  map[string]*v1beta1.ApplicationProfile
+	previousNetworkNeighborhoods map[string]*v1beta1.NetworkNeighborhood
+}
+
+func CreateDriftReporter(config DriftReportConfig, exporter Exporter, applicationProfiles ApplicationProfileLister, networkNeighborhoods NetworkNeighborhoodLister, clusterName, nodeName string) (*DriftReporter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	reporter := &DriftReporter{
+		config:               config,
+		exporter:             exporter,
+		applicationProfiles:  applicationProfiles,
+		networkNeighborhoods: networkNeighborhoods,
+		clusterName:          clusterName,
+		nodeName:             nodeName,
+	}
+	if err := reporter.loadBaseline(); err != nil {
+		logger.L().Warning("DriftReporter - failed to load the baseline, the profiles are recorded again", helpers.Error(err),
+			helpers.String("path", config.BaselinePath))
+	}
+	return reporter, nil
+}
+
+// Start reports the drift on every interval until the context is done, the profiles are cached after the start so
+// the first report only records them, unless a baseline was stored
+func (r *DriftReporter) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				r.report(now)
+			}
+		}
+	}()
+}
+
+func (r *DriftReporter) report(now time.Time) {
+	applicationProfiles := make(map[string]*v1beta1.ApplicationProfile)
+	if r.applicationProfiles != nil {
+		for _, ap := range r.applicationProfiles.ListApplicationProfiles() {
+			applicationProfiles[objectcache.UniqueName(ap.Namespace, ap.Name)] = ap
+		}
+	}
+	networkNeighborhoods := make(map[string]*v1beta1.NetworkNeighborhood)
+	if r.networkNeighborhoods != nil {
+		for _, nn := range r.networkNeighborhoods.ListNetworkNeighborhoods() {
+			networkNeighborhoods[objectcache.UniqueName(nn.Namespace, nn.Name)] = nn
+		}
+	}
+
+	var sent int
+	for _, key := range sortedKeys(applicationProfiles) {
+		current := applicationProfiles[key]
+		previous, ok := r.previousApplicationProfiles[key]
+		if !ok || previous.ResourceVersion == current.ResourceVersion && previous.ResourceVersion != "" {
+			continue
+		}
+		if !r.lastUpdatedHere(current.Annotations) {
+			continue
+		}
+		if diff := objectcache.DiffApplicationProfiles(previous, current); !diff.Empty() {
+			r.exporter.SendRuleAlert(r.driftAlert(now, "ApplicationProfile", current.ObjectMeta.Namespace, current.ObjectMeta.Name, current.Annotations, diff))
+			sent++
+		}
+	}
+	for _, key := range sortedKeys(networkNeighborhoods) {
+		current := networkNeighborhoods[key]
+		previous, ok := r.previousNetworkNeighborhoods[key]
+		if !ok || previous.ResourceVersion == current.ResourceVersion && previous.ResourceVersion != "" {
+			continue
+		}
+		if !r.lastUpdatedHere(current.Annotations) {
+			continue
+		}
+		if diff := objectcache.DiffNetworkNeighborhoods(previous, current); !diff.Empty() {
+			r.exporter.SendRuleAlert(r.driftAlert(now, "NetworkNeighborhood", current.ObjectMeta.Namespace, current.ObjectMeta.Name, current.Annotations, diff))
+			sent++
+		}
+	}
+	logger.L().Debug("DriftReporter - sent drift reports", helpers.Int("reports", sent))
+
+	r.previousReport = now
+	r.previousApplicationProfiles = applicationProfiles
+	r.previousNetworkNeighborhoods = networkNeighborhoods
+	if err := r.storeBaseline(); err != nil {
+		logger.L().Warning("DriftReporter - failed to store the baseline", helpers.Error(err),
+			helpers.String("path", r.config.BaselinePath))
+	}
+}
+
+// lastUpdatedHere tells if a profile was last updated by the node, the profiles without the node that last updated
+// them are reported from every node
+func (r *DriftReporter) lastUpdatedHere(annotations map[string]string) bool {
+	node, ok := annotations[utils.LastUpdatedNodeMetadataKey]
+	return !ok || r.nodeName == "" || node == r.nodeName
+}
+
+// loadBaseline restores the previous report from the baseline file, a missing file is an empty baseline
+func (r *DriftReporter) loadBaseline() error {
+	if r.config.BaselinePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.config.BaselinePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read baseline: %w", err)
+	}
+	var baseline driftBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return fmt.Errorf("unmarshal baseline: %w", err)
+	}
+	r.previousReport = baseline.Report
+	r.previousApplicationProfiles = baseline.ApplicationProfiles
+	r.previousNetworkNeighborhoods = baseline.NetworkNeighborhoods
+	return nil
+}
+
+// storeBaseline writes the previous report to the baseline file, it is replaced at once so a crash never leaves a
+// partial baseline
+func (r *DriftReporter) storeBaseline() error {
+	if r.config.BaselinePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(driftBaseline{
+		Report:               r.previousReport,
+		ApplicationProfiles:  r.previousApplicationProfiles,
+		NetworkNeighborhoods: r.previousNetworkNeighborhoods,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal baseline: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.config.BaselinePath), ".baseline-*")
+	if err != nil {
+		return fmt.Errorf("create baseline: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write baseline: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write baseline: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.config.BaselinePath); err != nil {
+		return fmt.Errorf("rename baseline: %w", err)
+	}
+	return nil
+}
+
+// driftAlert is the rule alert of the drift of a profile, its arguments hold the diff
+func (r *DriftReporter) driftAlert(now time.Time, kind, namespace, name string, annotations map[string]string, diff objectcache.ProfileDiff) ruleengine.RuleFailure {
+	since := r.previousReport.UTC().Format(time.RFC3339)
+	workloadID := annotations[helpersv1.WlidMetadataKey]
+	return &ruleenginev1.GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName: driftReportAlertName,
+			Arguments: map[string]interface{}{
+				"kind":  kind,
+				"name":  name,
+				"since": since,
+				"diff":  diff,
+			},
+			Severity:  ruleengine.RulePriorityLow,
+			Timestamp: now,
+		},
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          DriftReportRuleID,
+			RuleDescription: fmt.Sprintf("%s %s/%s changed since %s: %s", kind, namespace, name, since, diff.Summary()),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			ClusterName:       r.clusterName,
+			NodeName:          r.nodeName,
+			Namespace:         namespace,
+			WorkloadNamespace: namespace,
+			WorkloadKind:      wlid.GetKindFromWlid(workloadID),
+			WorkloadName:      wlid.GetNameFromWlid(workloadID),
+		},
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
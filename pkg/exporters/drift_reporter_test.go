@@ -0,0 +1,143 @@
+package exporters
+
+import (
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ruleAlertsMock records the rule alerts
+type ruleAlertsMock struct {
+	alerts []ruleengine.RuleFailure
+}
+
+func (m *ruleAlertsMock) SendRuleAlert(failedRule ruleengine.RuleFailure) {
+	m.alerts = append(m.alerts, failedRule)
+}
+
+func (m *ruleAlertsMock) SendMalwareAlert(_ malwaremanager.MalwareResult) {}
+
+type profileListerMock struct {
+	applicationProfiles  []*v1beta1.ApplicationProfile
+	networkNeighborhoods []*v1beta1.NetworkNeighborhood
+}
+
+func (m *profileListerMock) ListApplicationProfiles() []*v1beta1.ApplicationProfile {
+	return m.applicationProfiles
+}
+
+func (m *profileListerMock) ListNetworkNeighborhoods() []*v1beta1.NetworkNeighborhood {
+	return m.networkNeighborhoods
+}
+
+func driftApplicationProfile(resourceVersion string, syscalls ...string) *v1beta1.ApplicationProfile {
+	return &v1beta1.ApplicationProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "replicaset-nginx-77b4fdf86c",
+			Namespace:       "default",
+			ResourceVersion: resourceVersion,
+			Annotations: map[string]string{
+				"kubescape.io/wlid": "wlid://cluster-test/namespace-default/deployment-nginx",
+			},
+		},
+		Spec: v1beta1.ApplicationProfileSpec{
+			Containers: []v1beta1.ApplicationProfileContainer{{Name: "nginx", Syscalls: syscalls}},
+		},
+	}
+}
+
+func TestDriftReporter(t *testing.T) {
+	exporter := &ruleAlertsMock{}
+	lister := &profileListerMock{applicationProfiles: []*v1beta1.ApplicationProfile{driftApplicationProfile("1", "read")}}
+	reporter, err := CreateDriftReporter(DriftReportConfig{Interval: time.Hour}, exporter, lister, lister, "cluster-test", "node-test")
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// the first report only records the profiles
+	reporter.report(start)
+	assert.Empty(t, exporter.alerts)
+
+	// unchanged profiles are not reported
+	reporter.report(start.Add(time.Hour))
+	assert.Empty(t, exporter.alerts)
+
+	lister.applicationProfiles = []*v1beta1.ApplicationProfile{driftApplicationProfile("2", "read", "ptrace")}
+	reporter.report(start.Add(2 * time.Hour))
+	require.Len(t, exporter.alerts, 1)
+	alert := exporter.alerts[0]
+	assert.Equal(t, DriftReportRuleID, alert.GetRuleAlert().RuleID)
+	assert.Equal(t, "ApplicationProfile default/replicaset-nginx-77b4fdf86c changed since 2024-01-01T01:00:00Z: nginx: 1 added syscalls", alert.GetRuleAlert().RuleDescription)
+	assert.Equal(t, ruleengine.RulePriorityLow, alert.GetBaseRuntimeAlert().Severity)
+	assert.Equal(t, "Deployment", alert.GetRuntimeAlertK8sDetails().WorkloadKind)
+	assert.Equal(t, "nginx", alert.GetRuntimeAlertK8sDetails().WorkloadName)
+	assert.Equal(t, "node-test", alert.GetRuntimeAlertK8sDetails().NodeName)
+
+	// the drift is reported once
+	reporter.report(start.Add(3 * time.Hour))
+	assert.Len(t, exporter.alerts, 1)
+}
+
+func TestDriftReportConfigValidate(t *testing.T) {
+	assert.NoError(t, (&DriftReportConfig{Interval: time.Minute}).Validate())
+	assert.Error(t, (&DriftReportConfig{}).Validate())
+}
+
+func TestDriftReporterLastUpdatedNode(t *testing.T) {
+	exporter := &ruleAlertsMock{}
+	lister := &profileListerMock{applicationProfiles: []*v1beta1.ApplicationProfile{driftApplicationProfile("1", "read")}}
+	reporter, err := CreateDriftReporter(DriftReportConfig{Interval: time.Hour}, exporter, lister, lister, "cluster-test", "node-test")
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reporter.report(start)
+
+	// the profiles last updated by another node are reported from it
+	other := driftApplicationProfile("2", "read", "ptrace")
+	other.Annotations[utils.LastUpdatedNodeMetadataKey] = "node-other"
+	lister.applicationProfiles = []*v1beta1.ApplicationProfile{other}
+	reporter.report(start.Add(time.Hour))
+	assert.Empty(t, exporter.alerts)
+
+	// and they are compared with the last profiles
+	here := driftApplicationProfile("3", "read", "ptrace", "mount")
+	here.Annotations[utils.LastUpdatedNodeMetadataKey] = "node-test"
+	lister.applicationProfiles = []*v1beta1.ApplicationProfile{here}
+	reporter.report(start.Add(2 * time.Hour))
+	require.Len(t, exporter.alerts, 1)
+	assert.Equal(t, "ApplicationProfile default/replicaset-nginx-77b4fdf86c changed since 2024-01-01T01:00:00Z: nginx: 1 added syscalls", exporter.alerts[0].GetRuleAlert().RuleDescription)
+}
+
+func TestDriftReporterBaseline(t *testing.T) {
+	config := DriftReportConfig{Interval: time.Hour, BaselinePath: filepath.Join(t.TempDir(), "baseline.json")}
+	lister := &profileListerMock{applicationProfiles: []*v1beta1.ApplicationProfile{driftApplicationProfile("1", "read")}}
+	reporter, err := CreateDriftReporter(config, &ruleAlertsMock{}, lister, lister, "cluster-test", "node-test")
+	require.NoError(t, err)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reporter.report(start)
+
+	// a restarted reporter compares the profiles with the stored ones
+	exporter := &ruleAlertsMock{}
+	lister.applicationProfiles = []*v1beta1.ApplicationProfile{driftApplicationProfile("2", "read", "ptrace")}
+	reporter, err = CreateDriftReporter(config, exporter, lister, lister, "cluster-test", "node-test")
+	require.NoError(t, err)
+	reporter.report(start.Add(time.Hour))
+	require.Len(t, exporter.alerts, 1)
+	assert.Equal(t, "ApplicationProfile default/replicaset-nginx-77b4fdf86c changed since 2024-01-01T00:00:00Z: nginx: 1 added syscalls", exporter.alerts[0].GetRuleAlert().RuleDescription)
+
+	// an invalid baseline is recorded again
+	require.NoError(t, os.WriteFile(config.BaselinePath, []byte("{"), 0644))
+	exporter = &ruleAlertsMock{}
+	reporter, err = CreateDriftReporter(config, exporter, lister, lister, "cluster-test", "node-test")
+	require.NoError(t, err)
+	reporter.report(start.Add(2 * time.Hour))
+	assert.Empty(t, exporter.alerts)
+}
@@ -2,6 +2,7 @@ package exporters
 
 import (
 	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/metricsmanager"
 	"node-agent/pkg/ruleengine"
 	"os"
 
@@ -16,6 +17,25 @@ type ExportersConfig struct {
 	CsvRuleExporterPath      string              `mapstructure:"CsvRuleExporterPath"`
 	CsvMalwareExporterPath   string              `mapstructure:"CsvMalwareExporterPath"`
 	AlertManagerExporterUrls []string            `mapstructure:"alertManagerExporterUrls"`
+	OTLPExporterConfig       *OTLPExporterConfig `mapstructure:"otlpExporterConfig"`
+	Spool                    *SpoolConfig        `mapstructure:"spool"`
+	// MinSeverity is the minimum severity of the alerts sent to each exporter kind, e.g. {"alertmanager": "high"}
+	MinSeverity map[string]string `mapstructure:"minSeverity"`
+	// Routes select the alerts sent to each exporter kind, exporters without a route receive all the alerts
+	Routes map[string]ExporterRoute `mapstructure:"routes"`
+	// DriftReport sends the changes of the profiles periodically, it is disabled when nil
+	DriftReport *DriftReportConfig `mapstructure:"driftReport"`
+}
+
+// Validate checks the exporters routes and the drift report
+func (config *ExportersConfig) Validate() error {
+	if _, err := parseRoutes(config.Routes, config.MinSeverity); err != nil {
+		return err
+	}
+	if config.DriftReport != nil {
+		return config.DriftReport.Validate()
+	}
+	return nil
 }
 
 // This file will contain the single point of contact for all exporters,
@@ -23,28 +43,59 @@ type ExportersConfig struct {
 type ExporterBus struct {
 	// Exporters is a list of all exporters.
 	exporters []Exporter
+	// spool persists the alerts of the remote exporters, nil when not configured.
+	spool *Spool
 }
 
 // InitExporters initializes all exporters.
-func InitExporters(exportersConfig ExportersConfig, clusterName string, nodeName string) *ExporterBus {
+func InitExporters(exportersConfig ExportersConfig, clusterName string, nodeName string, metrics metricsmanager.MetricsManager) *ExporterBus {
+	var spool *Spool
+	if exportersConfig.Spool != nil {
+		var err error
+		spool, err = CreateSpool(*exportersConfig.Spool, metrics)
+		if err != nil {
+			logger.L().Error("failed to initialize exporters spool, alerts will not be spooled", helpers.Error(err))
+		}
+	}
+	// remote wraps the exporters that talk to remote endpoints with the spool, when configured
+	remote := func(name string, exporter Exporter) Exporter {
+		if spool == nil {
+			return exporter
+		}
+		return spool.Wrap(name, exporter)
+	}
+
+	routes, err := parseRoutes(exportersConfig.Routes, exportersConfig.MinSeverity)
+	if err != nil {
+		logger.L().Error("invalid exporters routes, all alerts will be exported", helpers.Error(err))
+	}
+	// route drops the alerts that do not match the route of the exporter kind, before they are spooled
+	route := func(kind string, exporter Exporter) Exporter {
+		matcher, ok := routes[kind]
+		if !ok {
+			return exporter
+		}
+		return &RouteExporter{exporter: exporter, matcher: matcher}
+	}
+
 	exporters := []Exporter{}
 	for _, url := range exportersConfig.AlertManagerExporterUrls {
 		alertMan := InitAlertManagerExporter(url)
 		if alertMan != nil {
-			exporters = append(exporters, alertMan)
+			exporters = append(exporters, route("alertmanager", remote("alertmanager-"+url, alertMan)))
 		}
 	}
 	stdoutExp := InitStdoutExporter(exportersConfig.StdoutExporter)
 	if stdoutExp != nil {
-		exporters = append(exporters, stdoutExp)
+		exporters = append(exporters, route("stdout", stdoutExp))
 	}
 	syslogExp := InitSyslogExporter(exportersConfig.SyslogExporter)
 	if syslogExp != nil {
-		exporters = append(exporters, syslogExp)
+		exporters = append(exporters, route("syslog", remote("syslog", syslogExp)))
 	}
 	csvExp := InitCsvExporter(exportersConfig.CsvRuleExporterPath, exportersConfig.CsvMalwareExporterPath)
 	if csvExp != nil {
-		exporters = append(exporters, csvExp)
+		exporters = append(exporters, route("csv", csvExp))
 	}
 	if exportersConfig.HTTPExporterConfig == nil {
 		if httpURL := os.Getenv("HTTP_ENDPOINT_URL"); httpURL != "" {
@@ -57,7 +108,15 @@ func InitExporters(exportersConfig ExportersConfig, clusterName string, nodeName
 		if err != nil {
 			logger.L().Error("failed to initialize http exporter", helpers.Error(err))
 		}
-		exporters = append(exporters, httpExp)
+		exporters = append(exporters, route("http", remote("http", httpExp)))
+	}
+	if exportersConfig.OTLPExporterConfig != nil {
+		otlpExp, err := InitOTLPExporter(*exportersConfig.OTLPExporterConfig, clusterName, nodeName)
+		if err != nil {
+			logger.L().Error("failed to initialize otlp exporter", helpers.Error(err))
+		} else {
+			exporters = append(exporters, route("otlp", remote("otlp", otlpExp)))
+		}
 	}
 
 	if len(exporters) == 0 {
@@ -65,7 +124,17 @@ func InitExporters(exportersConfig ExportersConfig, clusterName string, nodeName
 	}
 	logger.L().Info("exporters initialized")
 
-	return &ExporterBus{exporters: exporters}
+	return &ExporterBus{exporters: exporters, spool: spool}
+}
+
+// Close stops the delivery of the spooled alerts, pending alerts are delivered on the next start.
+func (e *ExporterBus) Close() {
+	if e.spool == nil {
+		return
+	}
+	if err := e.spool.Close(); err != nil {
+		logger.L().Error("failed to close exporters spool", helpers.Error(err))
+	}
 }
 
 func (e *ExporterBus) SendRuleAlert(failedRule ruleengine.RuleFailure) {
@@ -108,7 +108,9 @@ func (exporter *HTTPExporter) sendAlertLimitReached() {
 	}
 
 	logger.L().Error("Alert limit reached", helpers.Int("alerts", exporter.alertCount), helpers.String("since", exporter.alertCountStart.Format(time.RFC3339)))
-	exporter.sendInAlertList(httpAlert, apitypes.ProcessTree{})
+	if err := exporter.sendInAlertList(httpAlert, apitypes.ProcessTree{}); err != nil {
+		logger.L().Error("failed to send alert limit reached alert", helpers.Error(err))
+	}
 }
 
 func (exporter *HTTPExporter) SendRuleAlert(failedRule ruleengine.RuleFailure) {
@@ -117,12 +119,31 @@ func (exporter *HTTPExporter) SendRuleAlert(failedRule ruleengine.RuleFailure) {
 		exporter.sendAlertLimitReached()
 		return
 	}
+	if err := exporter.sendInAlertList(exporter.ruleAlert(failedRule), failedRule.GetRuntimeProcessDetails()); err != nil {
+		logger.L().Error("failed to send rule alert", helpers.Error(err))
+	}
+}
+
+// trySendRuleAlert sends the alert and returns the delivery error, alerts over the limit are not dropped but returned as a retryable error.
+// Only the delivered alerts count towards the limit, so the retries while the endpoint is down do not throttle the backlog.
+func (exporter *HTTPExporter) trySendRuleAlert(failedRule ruleengine.RuleFailure) error {
+	if exporter.isAlertLimitReached() {
+		return errAlertLimitReached
+	}
+	if err := exporter.sendInAlertList(exporter.ruleAlert(failedRule), failedRule.GetRuntimeProcessDetails()); err != nil {
+		return err
+	}
+	exporter.countAlert()
+	return nil
+}
+
+func (exporter *HTTPExporter) ruleAlert(failedRule ruleengine.RuleFailure) apitypes.RuntimeAlert {
 	// populate the RuntimeAlert struct with the data from the failedRule
 	k8sDetails := failedRule.GetRuntimeAlertK8sDetails()
 	k8sDetails.NodeName = exporter.NodeName
 	k8sDetails.ClusterName = exporter.ClusterName
 
-	httpAlert := apitypes.RuntimeAlert{
+	return apitypes.RuntimeAlert{
 		Message:                failedRule.GetRuleAlert().RuleDescription,
 		HostName:               exporter.Host,
 		AlertType:              apitypes.AlertTypeRule,
@@ -130,10 +151,9 @@ func (exporter *HTTPExporter) SendRuleAlert(failedRule ruleengine.RuleFailure) {
 		RuntimeAlertK8sDetails: k8sDetails,
 		RuleAlert:              failedRule.GetRuleAlert(),
 	}
-	exporter.sendInAlertList(httpAlert, failedRule.GetRuntimeProcessDetails())
 }
 
-func (exporter *HTTPExporter) sendInAlertList(httpAlert apitypes.RuntimeAlert, processTree apitypes.ProcessTree) {
+func (exporter *HTTPExporter) sendInAlertList(httpAlert apitypes.RuntimeAlert, processTree apitypes.ProcessTree) error {
 	// create the HTTPAlertsListSpec struct
 	// TODO: accumulate alerts and send them in a batch
 	httpAlertsListSpec := HTTPAlertsListSpec{
@@ -150,16 +170,14 @@ func (exporter *HTTPExporter) sendInAlertList(httpAlert apitypes.RuntimeAlert, p
 	// create the JSON representation of the HTTPAlertsList struct
 	bodyBytes, err := json.Marshal(httpAlertsList)
 	if err != nil {
-		logger.L().Error("failed to marshal HTTPAlertsList", helpers.Error(err))
-		return
+		return permanentError(fmt.Errorf("failed to marshal HTTPAlertsList: %w", err))
 	}
 	bodyReader := bytes.NewReader(bodyBytes)
 
 	// send the HTTP request
 	req, err := http.NewRequest(exporter.config.Method, exporter.config.URL, bodyReader)
 	if err != nil {
-		logger.L().Error("failed to create HTTP request", helpers.Error(err))
-		return
+		return permanentError(fmt.Errorf("failed to create HTTP request: %w", err))
 	}
 	for key, value := range exporter.config.Headers {
 		req.Header.Set(key, value)
@@ -167,20 +185,24 @@ func (exporter *HTTPExporter) sendInAlertList(httpAlert apitypes.RuntimeAlert, p
 
 	resp, err := exporter.httpClient.Do(req)
 	if err != nil {
-		logger.L().Error("failed to send HTTP request", helpers.Error(err))
-		return
+		return fmt.Errorf("failed to send HTTP request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		logger.L().Error("Received non-2xx status code", helpers.Int("status", resp.StatusCode))
-		return
-	}
-
 	// discard the body
 	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
 		logger.L().Error("failed to clear response body", helpers.Error(err))
 	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("received non-2xx status code %d", resp.StatusCode)
+		// client errors will not succeed on retry, except for rate limiting
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return permanentError(err)
+		}
+		return err
+	}
+	return nil
 }
 
 func (exporter *HTTPExporter) SendMalwareAlert(malwareResult malwaremanager.MalwareResult) {
@@ -189,11 +211,30 @@ func (exporter *HTTPExporter) SendMalwareAlert(malwareResult malwaremanager.Malw
 		exporter.sendAlertLimitReached()
 		return
 	}
+	if err := exporter.sendInAlertList(exporter.malwareAlert(malwareResult), malwareResult.GetRuntimeProcessDetails()); err != nil {
+		logger.L().Error("failed to send malware alert", helpers.Error(err))
+	}
+}
+
+// trySendMalwareAlert sends the alert and returns the delivery error, alerts over the limit are not dropped but returned as a retryable error.
+// Only the delivered alerts count towards the limit.
+func (exporter *HTTPExporter) trySendMalwareAlert(malwareResult malwaremanager.MalwareResult) error {
+	if exporter.isAlertLimitReached() {
+		return errAlertLimitReached
+	}
+	if err := exporter.sendInAlertList(exporter.malwareAlert(malwareResult), malwareResult.GetRuntimeProcessDetails()); err != nil {
+		return err
+	}
+	exporter.countAlert()
+	return nil
+}
+
+func (exporter *HTTPExporter) malwareAlert(malwareResult malwaremanager.MalwareResult) apitypes.RuntimeAlert {
 	k8sDetails := malwareResult.GetRuntimeAlertK8sDetails()
 	k8sDetails.NodeName = exporter.NodeName
 	k8sDetails.ClusterName = exporter.ClusterName
 
-	httpAlert := apitypes.RuntimeAlert{
+	return apitypes.RuntimeAlert{
 		Message:                fmt.Sprintf("Malware detected: %s", malwareResult.GetBasicRuntimeAlert().AlertName),
 		HostName:               exporter.Host,
 		AlertType:              apitypes.AlertTypeMalware,
@@ -201,13 +242,37 @@ func (exporter *HTTPExporter) SendMalwareAlert(malwareResult malwaremanager.Malw
 		RuntimeAlertK8sDetails: k8sDetails,
 		MalwareAlert:           malwareResult.GetMalwareRuntimeAlert(),
 	}
-	exporter.sendInAlertList(httpAlert, malwareResult.GetRuntimeProcessDetails())
 }
 
 func (exporter *HTTPExporter) checkAlertLimit() bool {
 	exporter.alertCountLock.Lock()
 	defer exporter.alertCountLock.Unlock()
 
+	exporter.resetAlertCount()
+	exporter.alertCount++
+	return exporter.alertCount > exporter.config.MaxAlertsPerMinute
+}
+
+// isAlertLimitReached tells if the alerts sent in the last minute reached the limit, without counting a new alert
+func (exporter *HTTPExporter) isAlertLimitReached() bool {
+	exporter.alertCountLock.Lock()
+	defer exporter.alertCountLock.Unlock()
+
+	exporter.resetAlertCount()
+	return exporter.alertCount >= exporter.config.MaxAlertsPerMinute
+}
+
+// countAlert counts a delivered alert towards the limit
+func (exporter *HTTPExporter) countAlert() {
+	exporter.alertCountLock.Lock()
+	defer exporter.alertCountLock.Unlock()
+
+	exporter.resetAlertCount()
+	exporter.alertCount++
+}
+
+// resetAlertCount starts a new minute of alerts, the lock must be held
+func (exporter *HTTPExporter) resetAlertCount() {
 	if exporter.alertCountStart.IsZero() {
 		exporter.alertCountStart = time.Now()
 	}
@@ -216,7 +281,4 @@ func (exporter *HTTPExporter) checkAlertLimit() bool {
 		exporter.alertCountStart = time.Now()
 		exporter.alertCount = 0
 	}
-
-	exporter.alertCount++
-	return exporter.alertCount > exporter.config.MaxAlertsPerMinute
 }
@@ -8,6 +8,7 @@ import (
 	mmtypes "node-agent/pkg/malwaremanager/v1/types"
 	"node-agent/pkg/ruleengine"
 	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -145,6 +146,38 @@ func TestSendRuleAlertRateReached(t *testing.T) {
 
 }
 
+func TestTrySendRuleAlertCountsDeliveredAlerts(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+	exporter, err := InitHTTPExporter(HTTPExporterConfig{
+		URL:                server.URL,
+		MaxAlertsPerMinute: 2,
+	}, "", "")
+	assert.NoError(t, err)
+	failedRule := &ruleenginev1.GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName: "testrule",
+		},
+	}
+
+	// the failed retries while the endpoint is down do not count towards the limit
+	for i := 0; i < 5; i++ {
+		err := exporter.trySendRuleAlert(failedRule)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, errAlertLimitReached)
+	}
+
+	// once it is back, the delivered alerts do
+	status.Store(http.StatusOK)
+	assert.NoError(t, exporter.trySendRuleAlert(failedRule))
+	assert.NoError(t, exporter.trySendRuleAlert(failedRule))
+	assert.ErrorIs(t, exporter.trySendRuleAlert(failedRule), errAlertLimitReached)
+}
+
 func TestSendMalwareAlertHTTPExporter(t *testing.T) {
 	bodyChan := make(chan []byte, 1)
 	// Create a mock HTTP server
@@ -0,0 +1,367 @@
+package exporters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/ruleengine"
+	"os"
+	"strings"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http/protobuf"
+
+	otlpScopeName    = "node-agent"
+	otlpHTTPLogsPath = "/v1/logs"
+)
+
+type OTLPExporterConfig struct {
+	// Endpoint is the address of the OTLP receiver, host:port for grpc or a URL for http/protobuf (the /v1/logs path is added when missing)
+	Endpoint string `mapstructure:"endpoint"`
+	// Protocol is the OTLP transport, grpc (default) or http/protobuf
+	Protocol string `mapstructure:"protocol"`
+	// Insecure disables TLS for the grpc transport
+	Insecure bool `mapstructure:"insecure"`
+	// Headers are sent with every export request (grpc metadata or http headers)
+	Headers map[string]string `mapstructure:"headers"`
+	// TimeoutSeconds is the timeout of a single export request
+	TimeoutSeconds int `mapstructure:"timeoutSeconds"`
+}
+
+func (config *OTLPExporterConfig) Validate() error {
+	if config.Endpoint == "" {
+		return fmt.Errorf("endpoint is required")
+	}
+	switch config.Protocol {
+	case "":
+		config.Protocol = OTLPProtocolGRPC
+	case OTLPProtocolGRPC, OTLPProtocolHTTP:
+	default:
+		return fmt.Errorf("protocol must be %s or %s", OTLPProtocolGRPC, OTLPProtocolHTTP)
+	}
+	if config.TimeoutSeconds == 0 {
+		config.TimeoutSeconds = 5
+	}
+	if config.Headers == nil {
+		config.Headers = make(map[string]string)
+	}
+	if config.Protocol == OTLPProtocolHTTP {
+		if !strings.HasPrefix(config.Endpoint, "http://") && !strings.HasPrefix(config.Endpoint, "https://") {
+			config.Endpoint = "http://" + config.Endpoint
+		}
+		if !strings.HasSuffix(config.Endpoint, otlpHTTPLogsPath) {
+			config.Endpoint = strings.TrimSuffix(config.Endpoint, "/") + otlpHTTPLogsPath
+		}
+	}
+	return nil
+}
+
+// OTLPExporter sends the alerts as OTLP log records
+type OTLPExporter struct {
+	config      OTLPExporterConfig
+	Host        string
+	NodeName    string
+	ClusterName string
+	grpcConn    *grpc.ClientConn
+	grpcClient  collogspb.LogsServiceClient
+	httpClient  *http.Client
+}
+
+// InitOTLPExporter initializes an OTLPExporter with the given endpoint and transport
+func InitOTLPExporter(config OTLPExporterConfig, clusterName string, nodeName string) (*OTLPExporter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	hostName, _ := os.Hostname()
+
+	exporter := &OTLPExporter{
+		config:      config,
+		Host:        hostName,
+		NodeName:    nodeName,
+		ClusterName: clusterName,
+	}
+
+	switch config.Protocol {
+	case OTLPProtocolGRPC:
+		creds := credentials.NewClientTLSFromCert(nil, "")
+		if config.Insecure {
+			creds = insecure.NewCredentials()
+		}
+		// the connection is established lazily, so an unavailable receiver does not fail the initialization
+		conn, err := grpc.Dial(config.Endpoint, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create grpc connection: %w", err)
+		}
+		exporter.grpcConn = conn
+		exporter.grpcClient = collogspb.NewLogsServiceClient(conn)
+	case OTLPProtocolHTTP:
+		exporter.httpClient = &http.Client{
+			Timeout: time.Duration(config.TimeoutSeconds) * time.Second,
+		}
+	}
+	return exporter, nil
+}
+
+// Close closes the grpc connection
+func (exporter *OTLPExporter) Close() error {
+	if exporter.grpcConn != nil {
+		return exporter.grpcConn.Close()
+	}
+	return nil
+}
+
+func (exporter *OTLPExporter) SendRuleAlert(failedRule ruleengine.RuleFailure) {
+	if err := exporter.trySendRuleAlert(failedRule); err != nil {
+		logger.L().Error("OTLPExporter - failed to export rule alert", helpers.String("rule", failedRule.GetBaseRuntimeAlert().AlertName), helpers.Error(err))
+	}
+}
+
+func (exporter *OTLPExporter) trySendRuleAlert(failedRule ruleengine.RuleFailure) error {
+	return exporter.export(exporter.ruleAlertToLogs(failedRule))
+}
+
+func (exporter *OTLPExporter) SendMalwareAlert(malwareResult malwaremanager.MalwareResult) {
+	if err := exporter.trySendMalwareAlert(malwareResult); err != nil {
+		logger.L().Error("OTLPExporter - failed to export malware alert", helpers.String("malware", malwareResult.GetBasicRuntimeAlert().AlertName), helpers.Error(err))
+	}
+}
+
+func (exporter *OTLPExporter) trySendMalwareAlert(malwareResult malwaremanager.MalwareResult) error {
+	return exporter.export(exporter.malwareAlertToLogs(malwareResult))
+}
+
+func (exporter *OTLPExporter) export(request *collogspb.ExportLogsServiceRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(exporter.config.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	if exporter.grpcClient != nil {
+		if len(exporter.config.Headers) > 0 {
+			ctx = metadata.NewOutgoingContext(ctx, metadata.New(exporter.config.Headers))
+		}
+		resp, err := exporter.grpcClient.Export(ctx, request)
+		if err != nil {
+			return err
+		}
+		if ps := resp.GetPartialSuccess(); ps != nil && ps.GetRejectedLogRecords() > 0 {
+			return fmt.Errorf("receiver rejected %d log records: %s", ps.GetRejectedLogRecords(), ps.GetErrorMessage())
+		}
+		return nil
+	}
+
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return permanentError(fmt.Errorf("failed to marshal logs request: %w", err))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, exporter.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return permanentError(fmt.Errorf("failed to create HTTP request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for key, value := range exporter.config.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := exporter.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// discard the body
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		logger.L().Debug("OTLPExporter - failed to clear response body", helpers.Error(err))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("received non-2xx status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (exporter *OTLPExporter) ruleAlertToLogs(failedRule ruleengine.RuleFailure) *collogspb.ExportLogsServiceRequest {
+	baseAlert := failedRule.GetBaseRuntimeAlert()
+	ruleAlert := failedRule.GetRuleAlert()
+
+	attributes := []*commonpb.KeyValue{
+		otlpStringAttribute("alert.type", "rule"),
+		otlpStringAttribute("rule.id", ruleAlert.RuleID),
+	}
+	attributes = append(attributes, otlpBaseAlertAttributes(baseAlert)...)
+	attributes = append(attributes, otlpProcessAttributes(failedRule.GetRuntimeProcessDetails())...)
+
+	record := otlpLogRecord(baseAlert, failedRule.GetTriggerEvent(), ruleAlert.RuleDescription, attributes)
+	return exporter.logsRequest(failedRule.GetRuntimeAlertK8sDetails(), failedRule.GetTriggerEvent(), record)
+}
+
+func (exporter *OTLPExporter) malwareAlertToLogs(malwareResult malwaremanager.MalwareResult) *collogspb.ExportLogsServiceRequest {
+	baseAlert := malwareResult.GetBasicRuntimeAlert()
+	malwareAlert := malwareResult.GetMalwareRuntimeAlert()
+
+	attributes := []*commonpb.KeyValue{
+		otlpStringAttribute("alert.type", "malware"),
+		otlpStringAttribute("malware.description", malwareAlert.MalwareDescription),
+	}
+	attributes = append(attributes, otlpBaseAlertAttributes(baseAlert)...)
+	attributes = append(attributes, otlpProcessAttributes(malwareResult.GetRuntimeProcessDetails())...)
+
+	body := fmt.Sprintf("Malware detected: %s", baseAlert.AlertName)
+	record := otlpLogRecord(baseAlert, malwareResult.GetTriggerEvent(), body, attributes)
+	return exporter.logsRequest(malwareResult.GetRuntimeAlertK8sDetails(), malwareResult.GetTriggerEvent(), record)
+}
+
+// logsRequest wraps the log record with the resource describing where the alert happened.
+// The k8s details of the alert are used, falling back to the trigger event metadata.
+func (exporter *OTLPExporter) logsRequest(k8sDetails apitypes.RuntimeAlertK8sDetails, triggerEvent igtypes.Event, record *logspb.LogRecord) *collogspb.ExportLogsServiceRequest {
+	namespace := firstNonEmpty(k8sDetails.Namespace, k8sDetails.PodNamespace, triggerEvent.GetNamespace())
+	attributes := []*commonpb.KeyValue{
+		otlpStringAttribute("service.name", otlpScopeName),
+		otlpStringAttribute("host.name", exporter.Host),
+	}
+	for _, attr := range []struct{ key, value string }{
+		{"k8s.cluster.name", firstNonEmpty(k8sDetails.ClusterName, exporter.ClusterName)},
+		{"k8s.node.name", firstNonEmpty(k8sDetails.NodeName, exporter.NodeName)},
+		{"k8s.namespace.name", namespace},
+		{"k8s.pod.name", firstNonEmpty(k8sDetails.PodName, triggerEvent.GetPod())},
+		{"k8s.container.name", firstNonEmpty(k8sDetails.ContainerName, triggerEvent.GetContainer())},
+		{"container.id", firstNonEmpty(k8sDetails.ContainerID, triggerEvent.Runtime.ContainerID)},
+		{"container.image.name", firstNonEmpty(k8sDetails.Image, triggerEvent.Runtime.ContainerImageName)},
+		{"k8s.workload.name", k8sDetails.WorkloadName},
+		{"k8s.workload.kind", k8sDetails.WorkloadKind},
+	} {
+		if attr.value != "" {
+			attributes = append(attributes, otlpStringAttribute(attr.key, attr.value))
+		}
+	}
+
+	return &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{
+			{
+				Resource: &resourcepb.Resource{Attributes: attributes},
+				ScopeLogs: []*logspb.ScopeLogs{
+					{
+						Scope:      &commonpb.InstrumentationScope{Name: otlpScopeName},
+						LogRecords: []*logspb.LogRecord{record},
+					},
+				},
+			},
+		},
+	}
+}
+
+func otlpLogRecord(baseAlert apitypes.BaseRuntimeAlert, triggerEvent igtypes.Event, body string, attributes []*commonpb.KeyValue) *logspb.LogRecord {
+	now := uint64(time.Now().UnixNano())
+	timestamp := now
+	if !baseAlert.Timestamp.IsZero() {
+		timestamp = uint64(baseAlert.Timestamp.UnixNano())
+	} else if triggerEvent.Timestamp != 0 {
+		timestamp = uint64(triggerEvent.Timestamp)
+	}
+	return &logspb.LogRecord{
+		TimeUnixNano:         timestamp,
+		ObservedTimeUnixNano: now,
+		SeverityNumber:       PriorityToSeverityNumber(baseAlert.Severity),
+		SeverityText:         PriorityToStatus(baseAlert.Severity),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: body}},
+		Attributes:           attributes,
+	}
+}
+
+func otlpBaseAlertAttributes(baseAlert apitypes.BaseRuntimeAlert) []*commonpb.KeyValue {
+	attributes := []*commonpb.KeyValue{
+		otlpStringAttribute("alert.name", baseAlert.AlertName),
+		otlpIntAttribute("alert.severity", int64(baseAlert.Severity)),
+	}
+	for _, attr := range []struct{ key, value string }{
+		{"alert.fix_suggestions", baseAlert.FixSuggestions},
+		{"file.hash.md5", baseAlert.MD5Hash},
+		{"file.hash.sha1", baseAlert.SHA1Hash},
+		{"file.hash.sha256", baseAlert.SHA256Hash},
+		{"file.size", baseAlert.Size},
+	} {
+		if attr.value != "" {
+			attributes = append(attributes, otlpStringAttribute(attr.key, attr.value))
+		}
+	}
+	for key, value := range baseAlert.Arguments {
+		attributes = append(attributes, otlpStringAttribute("alert.arguments."+key, fmt.Sprintf("%v", value)))
+	}
+	return attributes
+}
+
+func otlpProcessAttributes(processTree apitypes.ProcessTree) []*commonpb.KeyValue {
+	process := processTree.ProcessTree
+	if process.PID == 0 && process.Comm == "" {
+		return nil
+	}
+	attributes := []*commonpb.KeyValue{
+		otlpIntAttribute("process.pid", int64(process.PID)),
+		otlpStringAttribute("process.executable.name", process.Comm),
+	}
+	if process.PPID != 0 {
+		attributes = append(attributes, otlpIntAttribute("process.parent_pid", int64(process.PPID)))
+	}
+	if process.Cmdline != "" {
+		attributes = append(attributes, otlpStringAttribute("process.command_line", process.Cmdline))
+	}
+	if process.Hardlink != "" {
+		attributes = append(attributes, otlpStringAttribute("process.executable.path", process.Hardlink))
+	}
+	if process.Uid != nil {
+		attributes = append(attributes, otlpIntAttribute("process.user.id", int64(*process.Uid)))
+	}
+	return attributes
+}
+
+// PriorityToSeverityNumber maps a rule priority to the OTLP log severity number
+func PriorityToSeverityNumber(priority int) logspb.SeverityNumber {
+	switch PriorityToStatus(priority) {
+	case "none":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case "low":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case "medium":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN2
+	case "high":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case "critical":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+	case "system_issue":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR3
+	default:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+	}
+}
+
+func otlpStringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func otlpIntAttribute(key string, value int64) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value}}}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
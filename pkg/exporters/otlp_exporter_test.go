@@ -0,0 +1,248 @@
+package exporters
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	mmtypes "node-agent/pkg/malwaremanager/v1/types"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"testing"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpReceiverMock is an in-process OTLP logs receiver
+type otlpReceiverMock struct {
+	collogspb.UnimplementedLogsServiceServer
+	requests chan *collogspb.ExportLogsServiceRequest
+	metadata chan metadata.MD
+}
+
+func (r *otlpReceiverMock) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.metadata <- md
+	r.requests <- req
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func startOTLPReceiver(t *testing.T) (string, *otlpReceiverMock) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	receiver := &otlpReceiverMock{
+		requests: make(chan *collogspb.ExportLogsServiceRequest, 1),
+		metadata: make(chan metadata.MD, 1),
+	}
+	server := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(server, receiver)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String(), receiver
+}
+
+func otlpAttributes(attributes []*commonpb.KeyValue) map[string]interface{} {
+	m := make(map[string]interface{}, len(attributes))
+	for _, attr := range attributes {
+		switch v := attr.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			m[attr.GetKey()] = v.StringValue
+		case *commonpb.AnyValue_IntValue:
+			m[attr.GetKey()] = v.IntValue
+		}
+	}
+	return m
+}
+
+func TestOTLPExporterConfigValidate(t *testing.T) {
+	config := OTLPExporterConfig{}
+	assert.Error(t, config.Validate())
+
+	config = OTLPExporterConfig{Endpoint: "collector:4317", Protocol: "udp"}
+	assert.Error(t, config.Validate())
+
+	config = OTLPExporterConfig{Endpoint: "collector:4317"}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, OTLPProtocolGRPC, config.Protocol)
+	assert.Equal(t, "collector:4317", config.Endpoint)
+	assert.Equal(t, 5, config.TimeoutSeconds)
+
+	config = OTLPExporterConfig{Endpoint: "collector:4318", Protocol: OTLPProtocolHTTP}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, "http://collector:4318/v1/logs", config.Endpoint)
+}
+
+func TestOTLPExporterGRPCSendRuleAlert(t *testing.T) {
+	endpoint, receiver := startOTLPReceiver(t)
+
+	exporter, err := InitOTLPExporter(OTLPExporterConfig{
+		Endpoint: endpoint,
+		Insecure: true,
+		Headers:  map[string]string{"x-tenant": "test"},
+	}, "testcluster", "testnode")
+	require.NoError(t, err)
+	defer exporter.Close()
+
+	uid := uint32(1000)
+	exporter.SendRuleAlert(&ruleenginev1.GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:      "testrule",
+			Severity:       ruleengine.RulePriorityCritical,
+			FixSuggestions: "fix it",
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{
+				PID:     1234,
+				PPID:    1,
+				Comm:    "curl",
+				Cmdline: "curl example.com",
+				Uid:     &uid,
+			},
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			ContainerID:   "testcontainerid",
+			ContainerName: "testcontainer",
+			Namespace:     "testnamespace",
+			PodName:       "testpodname",
+		},
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          "R0001",
+			RuleDescription: "Unexpected process launched",
+		},
+	})
+
+	var request *collogspb.ExportLogsServiceRequest
+	select {
+	case request = <-receiver.requests:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the export request")
+	}
+	md := <-receiver.metadata
+	assert.Equal(t, []string{"test"}, md.Get("x-tenant"))
+
+	require.Equal(t, 1, len(request.GetResourceLogs()))
+	resource := otlpAttributes(request.GetResourceLogs()[0].GetResource().GetAttributes())
+	assert.Equal(t, "testpodname", resource["k8s.pod.name"])
+	assert.Equal(t, "testnamespace", resource["k8s.namespace.name"])
+	assert.Equal(t, "testcontainerid", resource["container.id"])
+	assert.Equal(t, "testcontainer", resource["k8s.container.name"])
+	assert.Equal(t, "testcluster", resource["k8s.cluster.name"])
+	assert.Equal(t, "testnode", resource["k8s.node.name"])
+
+	require.Equal(t, 1, len(request.GetResourceLogs()[0].GetScopeLogs()))
+	records := request.GetResourceLogs()[0].GetScopeLogs()[0].GetLogRecords()
+	require.Equal(t, 1, len(records))
+	record := records[0]
+	assert.Equal(t, "Unexpected process launched", record.GetBody().GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_FATAL, record.GetSeverityNumber())
+	assert.Equal(t, "critical", record.GetSeverityText())
+	assert.NotZero(t, record.GetTimeUnixNano())
+
+	attributes := otlpAttributes(record.GetAttributes())
+	assert.Equal(t, "rule", attributes["alert.type"])
+	assert.Equal(t, "R0001", attributes["rule.id"])
+	assert.Equal(t, "testrule", attributes["alert.name"])
+	assert.Equal(t, "fix it", attributes["alert.fix_suggestions"])
+	assert.Equal(t, int64(1234), attributes["process.pid"])
+	assert.Equal(t, int64(1), attributes["process.parent_pid"])
+	assert.Equal(t, "curl example.com", attributes["process.command_line"])
+	assert.Equal(t, int64(1000), attributes["process.user.id"])
+}
+
+func TestOTLPExporterHTTPSendMalwareAlert(t *testing.T) {
+	bodyChan := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Failed to read request body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+		bodyChan <- body
+	}))
+	defer server.Close()
+
+	exporter, err := InitOTLPExporter(OTLPExporterConfig{
+		Endpoint: server.URL,
+		Protocol: OTLPProtocolHTTP,
+	}, "", "")
+	require.NoError(t, err)
+
+	exporter.SendMalwareAlert(&mmtypes.GenericMalwareResult{
+		BasicRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:  "testmalware",
+			Severity:   ruleengine.RulePriorityCritical,
+			SHA256Hash: "testsha256",
+			Size:       "2MiB",
+		},
+		TriggerEvent: igtypes.Event{
+			CommonData: igtypes.CommonData{
+				K8s: igtypes.K8sMetadata{
+					BasicK8sMetadata: igtypes.BasicK8sMetadata{
+						Namespace:     "testnamespace",
+						PodName:       "testpodname",
+						ContainerName: "testcontainer",
+					},
+				},
+				Runtime: igtypes.BasicRuntimeMetadata{
+					ContainerID: "testcontainerid",
+				},
+			},
+		},
+		MalwareRuntimeAlert: apitypes.MalwareAlert{
+			MalwareDescription: "testmalwaredescription",
+		},
+	})
+
+	var body []byte
+	select {
+	case body = <-bodyChan:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for request body")
+	}
+	request := &collogspb.ExportLogsServiceRequest{}
+	require.NoError(t, proto.Unmarshal(body, request))
+
+	resource := otlpAttributes(request.GetResourceLogs()[0].GetResource().GetAttributes())
+	assert.Equal(t, "testpodname", resource["k8s.pod.name"])
+	assert.Equal(t, "testnamespace", resource["k8s.namespace.name"])
+	assert.Equal(t, "testcontainerid", resource["container.id"])
+
+	record := request.GetResourceLogs()[0].GetScopeLogs()[0].GetLogRecords()[0]
+	assert.Equal(t, "Malware detected: testmalware", record.GetBody().GetStringValue())
+	attributes := otlpAttributes(record.GetAttributes())
+	assert.Equal(t, "malware", attributes["alert.type"])
+	assert.Equal(t, "testmalwaredescription", attributes["malware.description"])
+	assert.Equal(t, "testsha256", attributes["file.hash.sha256"])
+	assert.Equal(t, "2MiB", attributes["file.size"])
+}
+
+func TestOTLPExporterHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter, err := InitOTLPExporter(OTLPExporterConfig{
+		Endpoint: server.URL,
+		Protocol: OTLPProtocolHTTP,
+	}, "", "")
+	require.NoError(t, err)
+
+	err = exporter.export(exporter.ruleAlertToLogs(&ruleenginev1.GenericRuleFailure{}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package exporters
+
+import (
+	"fmt"
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/ruleengine"
+	"slices"
+)
+
+const (
+	RuleAlertType    = "rule"
+	MalwareAlertType = "malware"
+)
+
+// exporterKinds are the names used to configure the routes of the exporters
+var exporterKinds = []string{"alertmanager", "stdout", "syslog", "csv", "http", "otlp"}
+
+// ExporterRoute selects the alerts sent to an exporter kind, an alert is sent when it matches all the set fields.
+// RuleIDs and Tags only apply to rule alerts, malware alerts are selected with AlertTypes.
+type ExporterRoute struct {
+	// AlertTypes are the types of the alerts, rule and/or malware
+	AlertTypes []string `mapstructure:"alertTypes"`
+	// RuleIDs are the IDs of the rules
+	RuleIDs []string `mapstructure:"ruleIDs"`
+	// Tags are the tags of the rules, a rule matches when it has any of the tags
+	Tags []string `mapstructure:"tags"`
+	// MinSeverity is the minimum severity, a severity name or a numeric priority
+	MinSeverity string `mapstructure:"minSeverity"`
+	// Namespaces are the namespaces of the alerting pods
+	Namespaces []string `mapstructure:"namespaces"`
+}
+
+// alertMatcher is a validated route
+type alertMatcher struct {
+	alertTypes  []string
+	ruleIDs     []string
+	tags        []string
+	minSeverity int
+	namespaces  []string
+}
+
+func newAlertMatcher(route ExporterRoute) (*alertMatcher, error) {
+	for _, alertType := range route.AlertTypes {
+		if alertType != RuleAlertType && alertType != MalwareAlertType {
+			return nil, fmt.Errorf("unknown alert type %q, expected %s or %s", alertType, RuleAlertType, MalwareAlertType)
+		}
+	}
+	matcher := &alertMatcher{
+		alertTypes: route.AlertTypes,
+		ruleIDs:    route.RuleIDs,
+		tags:       route.Tags,
+		namespaces: route.Namespaces,
+	}
+	if route.MinSeverity != "" {
+		minSeverity, err := ruleengine.ParseSeverity(route.MinSeverity)
+		if err != nil {
+			return nil, err
+		}
+		matcher.minSeverity = minSeverity
+	}
+	return matcher, nil
+}
+
+func (m *alertMatcher) matchRuleAlert(failedRule ruleengine.RuleFailure) bool {
+	if len(m.alertTypes) > 0 && !slices.Contains(m.alertTypes, RuleAlertType) {
+		return false
+	}
+	if len(m.ruleIDs) > 0 && !slices.Contains(m.ruleIDs, failedRule.GetRuleAlert().RuleID) {
+		return false
+	}
+	if len(m.tags) > 0 && !slices.ContainsFunc(failedRule.GetRuleTags(), func(tag string) bool { return slices.Contains(m.tags, tag) }) {
+		return false
+	}
+	return m.matchCommon(failedRule.GetBaseRuntimeAlert().Severity, failedRule.GetRuntimeAlertK8sDetails().Namespace, failedRule.GetTriggerEvent().K8s.Namespace)
+}
+
+func (m *alertMatcher) matchMalwareAlert(malwareResult malwaremanager.MalwareResult) bool {
+	if len(m.alertTypes) > 0 && !slices.Contains(m.alertTypes, MalwareAlertType) {
+		return false
+	}
+	return m.matchCommon(malwareResult.GetBasicRuntimeAlert().Severity, malwareResult.GetRuntimeAlertK8sDetails().Namespace, malwareResult.GetTriggerEvent().K8s.Namespace)
+}
+
+func (m *alertMatcher) matchCommon(severity int, namespace string, eventNamespace string) bool {
+	if severity < m.minSeverity {
+		return false
+	}
+	if namespace == "" {
+		namespace = eventNamespace
+	}
+	if len(m.namespaces) > 0 && !slices.Contains(m.namespaces, namespace) {
+		return false
+	}
+	return true
+}
+
+// parseRoutes validates the routes of the exporters, MinSeverity is a shorthand for the route minimum severity
+func parseRoutes(routes map[string]ExporterRoute, minSeverities map[string]string) (map[string]*alertMatcher, error) {
+	merged := make(map[string]ExporterRoute, len(routes)+len(minSeverities))
+	for kind, route := range routes {
+		merged[kind] = route
+	}
+	for kind, minSeverity := range minSeverities {
+		route := merged[kind]
+		if route.MinSeverity == "" {
+			route.MinSeverity = minSeverity
+		}
+		merged[kind] = route
+	}
+
+	matchers := make(map[string]*alertMatcher, len(merged))
+	for kind, route := range merged {
+		if !slices.Contains(exporterKinds, kind) {
+			return nil, fmt.Errorf("unknown exporter %q, expected one of %v", kind, exporterKinds)
+		}
+		matcher, err := newAlertMatcher(route)
+		if err != nil {
+			return nil, fmt.Errorf("exporter %q: %w", kind, err)
+		}
+		matchers[kind] = matcher
+	}
+	return matchers, nil
+}
+
+var _ Exporter = (*RouteExporter)(nil)
+
+// RouteExporter sends to the wrapped exporter only the alerts that match its route
+type RouteExporter struct {
+	exporter Exporter
+	matcher  *alertMatcher
+}
+
+func (e *RouteExporter) SendRuleAlert(failedRule ruleengine.RuleFailure) {
+	if !e.matcher.matchRuleAlert(failedRule) {
+		return
+	}
+	e.exporter.SendRuleAlert(failedRule)
+}
+
+func (e *RouteExporter) SendMalwareAlert(malwareResult malwaremanager.MalwareResult) {
+	if !e.matcher.matchMalwareAlert(malwareResult) {
+		return
+	}
+	e.exporter.SendMalwareAlert(malwareResult)
+}
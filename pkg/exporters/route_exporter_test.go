@@ -0,0 +1,145 @@
+package exporters
+
+import (
+	mmtypes "node-agent/pkg/malwaremanager/v1/types"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"testing"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func routedRuleFailure(name string, ruleID string, severity int, namespace string, tags ...string) ruleengine.RuleFailure {
+	return &ruleenginev1.GenericRuleFailure{
+		BaseRuntimeAlert:       apitypes.BaseRuntimeAlert{AlertName: name, Severity: severity},
+		RuleAlert:              apitypes.RuleAlert{RuleID: ruleID},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{Namespace: namespace},
+		RuleTags:               tags,
+	}
+}
+
+func TestRouteExporter(t *testing.T) {
+	tests := []struct {
+		name  string
+		route ExporterRoute
+		want  []string
+	}{
+		{
+			name:  "empty route",
+			route: ExporterRoute{},
+			want:  []string{"crypto", "file", "other-ns", "malware:clamav"},
+		},
+		{
+			name:  "rule IDs",
+			route: ExporterRoute{RuleIDs: []string{"R1007"}},
+			want:  []string{"crypto", "malware:clamav"},
+		},
+		{
+			name:  "rule IDs of rule alerts only",
+			route: ExporterRoute{RuleIDs: []string{"R1007"}, AlertTypes: []string{RuleAlertType}},
+			want:  []string{"crypto"},
+		},
+		{
+			name:  "tags",
+			route: ExporterRoute{Tags: []string{"crypto"}},
+			want:  []string{"crypto", "malware:clamav"},
+		},
+		{
+			name:  "minimum severity",
+			route: ExporterRoute{MinSeverity: "high"},
+			want:  []string{"crypto", "malware:clamav"},
+		},
+		{
+			name:  "namespaces",
+			route: ExporterRoute{Namespaces: []string{"default"}},
+			want:  []string{"crypto", "file"},
+		},
+		{
+			name:  "malware alerts",
+			route: ExporterRoute{AlertTypes: []string{MalwareAlertType}},
+			want:  []string{"malware:clamav"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := newAlertMatcher(tt.route)
+			require.NoError(t, err)
+			mock := &deliveryExporterMock{}
+			exporter := &RouteExporter{exporter: mock, matcher: matcher}
+
+			exporter.SendRuleAlert(routedRuleFailure("crypto", "R1007", ruleenginev1.RulePriorityCritical, "default", "crypto", "miners"))
+			exporter.SendRuleAlert(routedRuleFailure("file", "R0002", ruleenginev1.RulePriorityLow, "default", "files"))
+			exporter.SendRuleAlert(routedRuleFailure("other-ns", "R0002", ruleenginev1.RulePriorityMed, "kube-system", "files"))
+			exporter.SendMalwareAlert(&mmtypes.GenericMalwareResult{
+				BasicRuntimeAlert:      apitypes.BaseRuntimeAlert{AlertName: "clamav", Severity: ruleenginev1.RulePriorityCritical},
+				RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{Namespace: "malware-ns"},
+			})
+
+			assert.Equal(t, tt.want, mock.getDelivered())
+		})
+	}
+}
+
+func TestExportersConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ExportersConfig
+		wantErr bool
+	}{
+		{
+			name:   "no routes",
+			config: ExportersConfig{},
+		},
+		{
+			name: "valid routes",
+			config: ExportersConfig{
+				Routes: map[string]ExporterRoute{
+					"alertmanager": {Tags: []string{"crypto"}, MinSeverity: "high"},
+					"csv":          {AlertTypes: []string{RuleAlertType, MalwareAlertType}},
+				},
+				MinSeverity: map[string]string{"syslog": "5"},
+			},
+		},
+		{
+			name:    "unknown exporter",
+			config:  ExportersConfig{Routes: map[string]ExporterRoute{"pager": {}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown alert type",
+			config:  ExportersConfig{Routes: map[string]ExporterRoute{"csv": {AlertTypes: []string{"audit"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid severity",
+			config:  ExportersConfig{Routes: map[string]ExporterRoute{"csv": {MinSeverity: "urgent"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid minimum severity shorthand",
+			config:  ExportersConfig{MinSeverity: map[string]string{"otlp": "urgent"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRoutesMinSeverity(t *testing.T) {
+	routes, err := parseRoutes(
+		map[string]ExporterRoute{"alertmanager": {MinSeverity: "critical"}},
+		map[string]string{"alertmanager": "low", "csv": "medium"},
+	)
+	require.NoError(t, err)
+	// the route minimum severity takes precedence over the shorthand
+	assert.Equal(t, ruleenginev1.RulePriorityCritical, routes["alertmanager"].minSeverity)
+	assert.Equal(t, ruleenginev1.RulePriorityMed, routes["csv"].minSeverity)
+}
@@ -0,0 +1,380 @@
+package exporters
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"node-agent/pkg/malwaremanager"
+	mmtypes "node-agent/pkg/malwaremanager/v1/types"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	defaultSpoolMaxAlerts      = 10000
+	defaultSpoolInitialBackoff = time.Second
+	defaultSpoolMaxBackoff     = time.Minute
+)
+
+var errAlertLimitReached = errors.New("alert limit reached")
+
+// permanentErr marks a delivery error that will not succeed on retry
+type permanentErr struct {
+	err error
+}
+
+func (e *permanentErr) Error() string { return e.err.Error() }
+func (e *permanentErr) Unwrap() error { return e.err }
+
+func permanentError(err error) error {
+	return &permanentErr{err: err}
+}
+
+func isPermanentError(err error) bool {
+	var p *permanentErr
+	return errors.As(err, &p)
+}
+
+// deliveryExporter is implemented by the exporters that send alerts to a remote endpoint and report delivery failures
+type deliveryExporter interface {
+	Exporter
+	trySendRuleAlert(failedRule ruleengine.RuleFailure) error
+	trySendMalwareAlert(malwareResult malwaremanager.MalwareResult) error
+}
+
+var _ deliveryExporter = (*HTTPExporter)(nil)
+var _ deliveryExporter = (*AlertManagerExporter)(nil)
+var _ deliveryExporter = (*SyslogExporter)(nil)
+var _ deliveryExporter = (*OTLPExporter)(nil)
+
+type SpoolConfig struct {
+	// Path is the path of the spool file, the spool is disabled when empty
+	Path string `mapstructure:"path"`
+	// MaxAlerts is the maximum number of pending alerts per destination, the oldest alerts are dropped when the queue is full
+	MaxAlerts int `mapstructure:"maxAlerts"`
+	// InitialBackoff is the delay before the first retry, it is doubled on each failure up to MaxBackoff
+	InitialBackoff time.Duration `mapstructure:"initialBackoff"`
+	MaxBackoff     time.Duration `mapstructure:"maxBackoff"`
+}
+
+func (config *SpoolConfig) Validate() error {
+	if config.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+	if config.MaxAlerts <= 0 {
+		config.MaxAlerts = defaultSpoolMaxAlerts
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaultSpoolInitialBackoff
+	}
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = defaultSpoolMaxBackoff
+		if config.MaxBackoff < config.InitialBackoff {
+			config.MaxBackoff = config.InitialBackoff
+		}
+	}
+	return nil
+}
+
+// Spool is a bounded on-disk queue of pending alerts, with a bucket per destination
+type Spool struct {
+	config  SpoolConfig
+	db      *bolt.DB
+	metrics metricsmanager.MetricsManager
+	wg      sync.WaitGroup
+	stop    chan struct{}
+}
+
+// CreateSpool opens (or creates) the spool file
+func CreateSpool(config SpoolConfig, metrics metricsmanager.MetricsManager) (*Spool, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(config.Path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool file %s: %w", config.Path, err)
+	}
+	return &Spool{
+		config:  config,
+		db:      db,
+		metrics: metrics,
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+// Close stops the delivery of the pending alerts and closes the spool file, pending alerts are delivered on the next start
+func (s *Spool) Close() error {
+	close(s.stop)
+	s.wg.Wait()
+	return s.db.Close()
+}
+
+// spooledAlert is the persisted form of a rule or malware alert
+type spooledAlert struct {
+	AlertType              apitypes.AlertType              `json:"alertType"`
+	BaseRuntimeAlert       apitypes.BaseRuntimeAlert       `json:"baseRuntimeAlert"`
+	RuntimeProcessDetails  apitypes.ProcessTree            `json:"runtimeProcessDetails"`
+	TriggerEvent           igtypes.Event                   `json:"triggerEvent"`
+	RuleAlert              apitypes.RuleAlert              `json:"ruleAlert,omitempty"`
+	MalwareAlert           apitypes.MalwareAlert           `json:"malwareAlert,omitempty"`
+	RuntimeAlertK8sDetails apitypes.RuntimeAlertK8sDetails `json:"runtimeAlertK8sDetails"`
+	RuleTags               []string                        `json:"ruleTags,omitempty"`
+}
+
+var _ Exporter = (*SpoolExporter)(nil)
+
+// SpoolExporter persists the alerts before delivering them to the wrapped exporter.
+// Alerts are delivered one at a time in the order they were received and failed deliveries are retried with backoff.
+type SpoolExporter struct {
+	spool    *Spool
+	name     string
+	exporter deliveryExporter
+	depth    atomic.Int64
+	wakeup   chan struct{}
+}
+
+// Wrap returns an exporter that spools the alerts of the given exporter under the destination name.
+// Exporters that do not report delivery failures are returned as is.
+func (s *Spool) Wrap(name string, exporter Exporter) Exporter {
+	de, ok := exporter.(deliveryExporter)
+	if !ok {
+		return exporter
+	}
+	se := &SpoolExporter{
+		spool:    s,
+		name:     name,
+		exporter: de,
+		wakeup:   make(chan struct{}, 1),
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(name))
+		if err != nil {
+			return err
+		}
+		se.depth.Store(int64(b.Stats().KeyN))
+		return nil
+	})
+	if err != nil {
+		logger.L().Error("SpoolExporter - failed to create spool bucket, alerts will not be spooled", helpers.String("exporter", name), helpers.Error(err))
+		return exporter
+	}
+	s.metrics.ReportExporterQueueDepth(name, int(se.depth.Load()))
+	if se.depth.Load() > 0 {
+		logger.L().Info("SpoolExporter - delivering pending alerts", helpers.String("exporter", name), helpers.Int("alerts", int(se.depth.Load())))
+	}
+
+	s.wg.Add(1)
+	go se.deliver()
+	return se
+}
+
+func (se *SpoolExporter) SendRuleAlert(failedRule ruleengine.RuleFailure) {
+	se.enqueue(spooledAlert{
+		AlertType:              apitypes.AlertTypeRule,
+		BaseRuntimeAlert:       failedRule.GetBaseRuntimeAlert(),
+		RuntimeProcessDetails:  failedRule.GetRuntimeProcessDetails(),
+		TriggerEvent:           failedRule.GetTriggerEvent(),
+		RuleAlert:              failedRule.GetRuleAlert(),
+		RuntimeAlertK8sDetails: failedRule.GetRuntimeAlertK8sDetails(),
+		RuleTags:               failedRule.GetRuleTags(),
+	})
+}
+
+func (se *SpoolExporter) SendMalwareAlert(malwareResult malwaremanager.MalwareResult) {
+	se.enqueue(spooledAlert{
+		AlertType:              apitypes.AlertTypeMalware,
+		BaseRuntimeAlert:       malwareResult.GetBasicRuntimeAlert(),
+		RuntimeProcessDetails:  malwareResult.GetRuntimeProcessDetails(),
+		TriggerEvent:           malwareResult.GetTriggerEvent(),
+		MalwareAlert:           malwareResult.GetMalwareRuntimeAlert(),
+		RuntimeAlertK8sDetails: malwareResult.GetRuntimeAlertK8sDetails(),
+	})
+}
+
+// enqueue persists the alert, dropping the oldest alerts when the queue is full
+func (se *SpoolExporter) enqueue(alert spooledAlert) {
+	value, err := json.Marshal(alert)
+	if err != nil {
+		logger.L().Error("SpoolExporter - failed to marshal alert", helpers.String("exporter", se.name), helpers.Error(err))
+		se.spool.metrics.ReportExporterAlertDropped(se.name)
+		return
+	}
+
+	dropped := 0
+	err = se.spool.db.Update(func(tx *bolt.Tx) error {
+		// the depth is only updated in write transactions, which are serialized
+		depth := se.depth.Load()
+		b := tx.Bucket([]byte(se.name))
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := b.Put(spoolKey(seq), value); err != nil {
+			return err
+		}
+		depth++
+		// drop the oldest alerts over the limit
+		c := b.Cursor()
+		for ; depth > int64(se.spool.config.MaxAlerts); depth-- {
+			if k, _ := c.First(); k == nil {
+				break
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			dropped++
+		}
+		se.depth.Store(depth)
+		return nil
+	})
+	if err != nil {
+		logger.L().Error("SpoolExporter - failed to persist alert", helpers.String("exporter", se.name), helpers.Error(err))
+		se.spool.metrics.ReportExporterAlertDropped(se.name)
+		return
+	}
+	if dropped > 0 {
+		logger.L().Warning("SpoolExporter - queue is full, dropped oldest alerts", helpers.String("exporter", se.name), helpers.Int("dropped", dropped))
+		for i := 0; i < dropped; i++ {
+			se.spool.metrics.ReportExporterAlertDropped(se.name)
+		}
+	}
+	se.spool.metrics.ReportExporterQueueDepth(se.name, int(se.depth.Load()))
+
+	select {
+	case se.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends the pending alerts in order, the head of the queue is retried until it is delivered
+func (se *SpoolExporter) deliver() {
+	defer se.spool.wg.Done()
+	backoff := se.spool.config.InitialBackoff
+	for {
+		key, value, err := se.peek()
+		if err != nil {
+			logger.L().Error("SpoolExporter - failed to read spool", helpers.String("exporter", se.name), helpers.Error(err))
+		}
+		if key == nil {
+			// queue is empty, wait for new alerts
+			select {
+			case <-se.spool.stop:
+				return
+			case <-se.wakeup:
+				continue
+			}
+		}
+
+		err = se.send(value)
+		switch {
+		case err == nil:
+			backoff = se.spool.config.InitialBackoff
+		case isPermanentError(err):
+			logger.L().Error("SpoolExporter - dropping alert that cannot be delivered", helpers.String("exporter", se.name), helpers.Error(err))
+			se.spool.metrics.ReportExporterAlertDropped(se.name)
+		default:
+			logger.L().Debug("SpoolExporter - failed to deliver alert, retrying", helpers.String("exporter", se.name), helpers.String("backoff", backoff.String()), helpers.Error(err))
+			select {
+			case <-se.spool.stop:
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > se.spool.config.MaxBackoff {
+				backoff = se.spool.config.MaxBackoff
+			}
+			continue
+		}
+
+		if err := se.remove(key); err != nil {
+			logger.L().Error("SpoolExporter - failed to remove delivered alert", helpers.String("exporter", se.name), helpers.Error(err))
+		}
+
+		select {
+		case <-se.spool.stop:
+			return
+		default:
+		}
+	}
+}
+
+func (se *SpoolExporter) send(value []byte) error {
+	var alert spooledAlert
+	if err := json.Unmarshal(value, &alert); err != nil {
+		return permanentError(fmt.Errorf("failed to unmarshal alert: %w", err))
+	}
+	switch alert.AlertType {
+	case apitypes.AlertTypeMalware:
+		return se.exporter.trySendMalwareAlert(&mmtypes.GenericMalwareResult{
+			BasicRuntimeAlert:      alert.BaseRuntimeAlert,
+			RuntimeProcessDetails:  alert.RuntimeProcessDetails,
+			TriggerEvent:           alert.TriggerEvent,
+			MalwareRuntimeAlert:    alert.MalwareAlert,
+			RuntimeAlertK8sDetails: alert.RuntimeAlertK8sDetails,
+		})
+	default:
+		return se.exporter.trySendRuleAlert(&ruleenginev1.GenericRuleFailure{
+			BaseRuntimeAlert:       alert.BaseRuntimeAlert,
+			RuntimeProcessDetails:  alert.RuntimeProcessDetails,
+			TriggerEvent:           alert.TriggerEvent,
+			RuleAlert:              alert.RuleAlert,
+			RuntimeAlertK8sDetails: alert.RuntimeAlertK8sDetails,
+			RuleTags:               alert.RuleTags,
+		})
+	}
+}
+
+func (se *SpoolExporter) peek() ([]byte, []byte, error) {
+	var key, value []byte
+	err := se.spool.db.View(func(tx *bolt.Tx) error {
+		k, v := tx.Bucket([]byte(se.name)).Cursor().First()
+		if k != nil {
+			// the slices are only valid during the transaction
+			key = append([]byte{}, k...)
+			value = append([]byte{}, v...)
+		}
+		return nil
+	})
+	return key, value, err
+}
+
+func (se *SpoolExporter) remove(key []byte) error {
+	err := se.spool.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(se.name))
+		// the alert may have been dropped while it was being delivered
+		if b.Get(key) == nil {
+			return nil
+		}
+		if err := b.Delete(key); err != nil {
+			return err
+		}
+		se.depth.Add(-1)
+		return nil
+	})
+	se.spool.metrics.ReportExporterQueueDepth(se.name, int(se.depth.Load()))
+	return err
+}
+
+// Depth returns the number of pending alerts
+func (se *SpoolExporter) Depth() int {
+	return int(se.depth.Load())
+}
+
+// spoolKey encodes the sequence as big endian so the keys are iterated in insertion order
+func spoolKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
@@ -0,0 +1,192 @@
+package exporters
+
+import (
+	"errors"
+	"fmt"
+	"node-agent/pkg/malwaremanager"
+	mmtypes "node-agent/pkg/malwaremanager/v1/types"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deliveryExporterMock records the delivered alerts and fails while failing is set
+type deliveryExporterMock struct {
+	mu        sync.Mutex
+	failing   error
+	delivered []string
+	attempts  int
+}
+
+func (m *deliveryExporterMock) SendRuleAlert(failedRule ruleengine.RuleFailure) {
+	_ = m.trySendRuleAlert(failedRule)
+}
+
+func (m *deliveryExporterMock) SendMalwareAlert(malwareResult malwaremanager.MalwareResult) {
+	_ = m.trySendMalwareAlert(malwareResult)
+}
+
+func (m *deliveryExporterMock) trySendRuleAlert(failedRule ruleengine.RuleFailure) error {
+	return m.try(failedRule.GetBaseRuntimeAlert().AlertName)
+}
+
+func (m *deliveryExporterMock) trySendMalwareAlert(malwareResult malwaremanager.MalwareResult) error {
+	return m.try("malware:" + malwareResult.GetBasicRuntimeAlert().AlertName)
+}
+
+func (m *deliveryExporterMock) try(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts++
+	if m.failing != nil {
+		return m.failing
+	}
+	m.delivered = append(m.delivered, name)
+	return nil
+}
+
+func (m *deliveryExporterMock) setFailing(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failing = err
+}
+
+func (m *deliveryExporterMock) getDelivered() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string{}, m.delivered...)
+}
+
+func (m *deliveryExporterMock) getAttempts() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.attempts
+}
+
+func ruleFailure(name string) ruleengine.RuleFailure {
+	return &ruleenginev1.GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{AlertName: name},
+		RuleAlert:        apitypes.RuleAlert{RuleID: "R0001"},
+	}
+}
+
+func testSpoolConfig(t *testing.T) SpoolConfig {
+	return SpoolConfig{
+		Path:           filepath.Join(t.TempDir(), "spool.db"),
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+	}
+}
+
+func TestSpoolConfigValidate(t *testing.T) {
+	config := SpoolConfig{}
+	assert.Error(t, config.Validate())
+
+	config = SpoolConfig{Path: "/tmp/spool.db"}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, defaultSpoolMaxAlerts, config.MaxAlerts)
+	assert.Equal(t, defaultSpoolInitialBackoff, config.InitialBackoff)
+	assert.Equal(t, defaultSpoolMaxBackoff, config.MaxBackoff)
+}
+
+func TestSpoolExporterRetryInOrder(t *testing.T) {
+	metrics := metricsmanager.NewMetricsMock()
+	spool, err := CreateSpool(testSpoolConfig(t), metrics)
+	require.NoError(t, err)
+	defer spool.Close()
+
+	mock := &deliveryExporterMock{failing: errors.New("connection refused")}
+	exporter := spool.Wrap("test", mock)
+
+	exporter.SendRuleAlert(ruleFailure("alert1"))
+	exporter.SendMalwareAlert(&mmtypes.GenericMalwareResult{BasicRuntimeAlert: apitypes.BaseRuntimeAlert{AlertName: "alert2"}})
+	exporter.SendRuleAlert(ruleFailure("alert3"))
+
+	// the head of the queue is retried while the endpoint is down
+	assert.Eventually(t, func() bool { return mock.getAttempts() >= 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, mock.getDelivered())
+	assert.Equal(t, 3, metrics.ExporterQueueDepth.Get("test"))
+
+	mock.setFailing(nil)
+	assert.Eventually(t, func() bool { return len(mock.getDelivered()) == 3 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"alert1", "malware:alert2", "alert3"}, mock.getDelivered())
+	assert.Eventually(t, func() bool { return metrics.ExporterQueueDepth.Get("test") == 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, metrics.ExporterDropCounter.Get("test"))
+}
+
+func TestSpoolExporterPermanentError(t *testing.T) {
+	metrics := metricsmanager.NewMetricsMock()
+	spool, err := CreateSpool(testSpoolConfig(t), metrics)
+	require.NoError(t, err)
+	defer spool.Close()
+
+	mock := &deliveryExporterMock{failing: permanentError(errors.New("bad request"))}
+	exporter := spool.Wrap("test", mock)
+	exporter.SendRuleAlert(ruleFailure("alert1"))
+
+	assert.Eventually(t, func() bool { return metrics.ExporterDropCounter.Get("test") == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, mock.getAttempts())
+	assert.Equal(t, 0, exporter.(*SpoolExporter).Depth())
+}
+
+func TestSpoolExporterDropOldest(t *testing.T) {
+	metrics := metricsmanager.NewMetricsMock()
+	config := testSpoolConfig(t)
+	config.MaxAlerts = 2
+	config.InitialBackoff = time.Hour
+	config.MaxBackoff = time.Hour
+	spool, err := CreateSpool(config, metrics)
+	require.NoError(t, err)
+	defer spool.Close()
+
+	mock := &deliveryExporterMock{failing: errors.New("connection refused")}
+	exporter := spool.Wrap("test", mock)
+	for i := 0; i < 5; i++ {
+		exporter.SendRuleAlert(ruleFailure(fmt.Sprintf("alert%d", i)))
+	}
+
+	assert.Equal(t, 2, exporter.(*SpoolExporter).Depth())
+	assert.Equal(t, 2, metrics.ExporterQueueDepth.Get("test"))
+	assert.Equal(t, 3, metrics.ExporterDropCounter.Get("test"))
+}
+
+func TestSpoolExporterPersistence(t *testing.T) {
+	config := testSpoolConfig(t)
+	config.InitialBackoff = time.Hour
+	config.MaxBackoff = time.Hour
+
+	spool, err := CreateSpool(config, metricsmanager.NewMetricsMock())
+	require.NoError(t, err)
+	mock := &deliveryExporterMock{failing: errors.New("connection refused")}
+	exporter := spool.Wrap("test", mock)
+	exporter.SendRuleAlert(ruleFailure("alert1"))
+	exporter.SendRuleAlert(ruleFailure("alert2"))
+	require.NoError(t, spool.Close())
+
+	// pending alerts are delivered after a restart
+	spool, err = CreateSpool(config, metricsmanager.NewMetricsMock())
+	require.NoError(t, err)
+	defer spool.Close()
+
+	mock = &deliveryExporterMock{}
+	spool.Wrap("test", mock)
+	assert.Eventually(t, func() bool { return len(mock.getDelivered()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"alert1", "alert2"}, mock.getDelivered())
+}
+
+func TestSpoolWrapLocalExporter(t *testing.T) {
+	spool, err := CreateSpool(testSpoolConfig(t), metricsmanager.NewMetricsMock())
+	require.NoError(t, err)
+	defer spool.Close()
+
+	stdout := InitStdoutExporter(nil)
+	assert.Equal(t, Exporter(stdout), spool.Wrap("stdout", stdout))
+}
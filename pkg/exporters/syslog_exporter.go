@@ -46,8 +46,14 @@ func InitSyslogExporter(syslogHost string) *SyslogExporter {
 
 // SendRuleAlert sends an alert to syslog (RFC 5424) - https://tools.ietf.org/html/rfc5424
 func (se *SyslogExporter) SendRuleAlert(failedRule ruleengine.RuleFailure) {
+	if err := se.trySendRuleAlert(failedRule); err != nil {
+		logger.L().Error("failed to send alert to syslog", helpers.Error(err))
+	}
+}
+
+func (se *SyslogExporter) trySendRuleAlert(failedRule ruleengine.RuleFailure) error {
 	message := rfc5424.Message{
-		Priority:  rfc5424.Error,
+		Priority:  PriorityToSyslogPriority(failedRule.GetBaseRuntimeAlert().Severity),
 		Timestamp: failedRule.GetBaseRuntimeAlert().Timestamp,
 		Hostname:  failedRule.GetRuntimeAlertK8sDetails().PodName,
 		AppName:   failedRule.GetRuntimeAlertK8sDetails().ContainerName,
@@ -107,15 +113,19 @@ func (se *SyslogExporter) SendRuleAlert(failedRule ruleengine.RuleFailure) {
 	}
 
 	_, err := message.WriteTo(se.writer)
-	if err != nil {
-		logger.L().Error("failed to send alert to syslog", helpers.Error(err))
-	}
+	return err
 }
 
 // SendMalwareAlert sends an alert to syslog (RFC 5424) - https://tools.ietf.org/html/rfc5424
 func (se *SyslogExporter) SendMalwareAlert(malwareResult malwaremanager.MalwareResult) {
+	if err := se.trySendMalwareAlert(malwareResult); err != nil {
+		logger.L().Error("failed to send alert to syslog", helpers.Error(err))
+	}
+}
+
+func (se *SyslogExporter) trySendMalwareAlert(malwareResult malwaremanager.MalwareResult) error {
 	message := rfc5424.Message{
-		Priority:  rfc5424.Error,
+		Priority:  PriorityToSyslogPriority(malwareResult.GetBasicRuntimeAlert().Severity),
 		Timestamp: time.Now(),
 		Hostname:  malwareResult.GetTriggerEvent().GetBaseEvent().GetPod(),
 		AppName:   malwareResult.GetTriggerEvent().GetBaseEvent().GetContainer(),
@@ -179,7 +189,25 @@ func (se *SyslogExporter) SendMalwareAlert(malwareResult malwaremanager.MalwareR
 	}
 
 	_, err := message.WriteTo(se.writer)
-	if err != nil {
-		logger.L().Error("failed to send alert to syslog", helpers.Error(err))
+	return err
+}
+
+// PriorityToSyslogPriority maps a rule priority to the syslog severity
+func PriorityToSyslogPriority(priority int) rfc5424.Priority {
+	switch PriorityToStatus(priority) {
+	case "none":
+		return rfc5424.Info
+	case "low":
+		return rfc5424.Notice
+	case "medium":
+		return rfc5424.Warning
+	case "high":
+		return rfc5424.Error
+	case "critical":
+		return rfc5424.Crit
+	case "system_issue":
+		return rfc5424.Alert
+	default:
+		return rfc5424.Error
 	}
 }
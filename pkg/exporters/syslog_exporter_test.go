@@ -11,6 +11,7 @@ import (
 	"gopkg.in/mcuadros/go-syslog.v2"
 
 	apitypes "github.com/armosec/armoapi-go/armotypes"
+	"github.com/crewjam/rfc5424"
 	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -129,3 +130,23 @@ func TestSyslogExporter(t *testing.T) {
 	// Allow some time for the message to reach the mock syslog server
 	time.Sleep(200 * time.Millisecond)
 }
+
+func TestPriorityToSyslogPriority(t *testing.T) {
+	tests := []struct {
+		priority int
+		want     rfc5424.Priority
+	}{
+		{priority: ruleenginev1.RulePriorityNone, want: rfc5424.Info},
+		{priority: ruleenginev1.RulePriorityLow, want: rfc5424.Notice},
+		{priority: ruleenginev1.RulePriorityMed, want: rfc5424.Warning},
+		{priority: ruleenginev1.RulePriorityHigh, want: rfc5424.Error},
+		{priority: ruleenginev1.RulePriorityCritical, want: rfc5424.Crit},
+		{priority: ruleenginev1.RulePrioritySystemIssue, want: rfc5424.Alert},
+		{priority: 6, want: rfc5424.Warning},
+	}
+	for _, tt := range tests {
+		if got := PriorityToSyslogPriority(tt.priority); got != tt.want {
+			t.Errorf("PriorityToSyslogPriority(%d) = %v, want %v", tt.priority, got, tt.want)
+		}
+	}
+}
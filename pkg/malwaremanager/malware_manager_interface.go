@@ -14,6 +14,8 @@ type MalwareManagerClient interface {
 	ReportFileExec(k8sContainerID string, event tracerexectype.Event)
 	ReportFileOpen(k8sContainerID string, event traceropentype.Event)
 	ContainerCallback(notif containercollection.PubSubEvent)
+	// Stop stops the background work of the scanners
+	Stop()
 }
 
 type MalwareResult interface {
@@ -42,7 +44,28 @@ type MalwareResult interface {
 	SetRuntimeAlertK8sDetails(runtimeAlertK8sDetails apitypes.RuntimeAlertK8sDetails)
 }
 
+// FileHashes are the hashes of the file of an event, computed once for all the scanners
+type FileHashes struct {
+	MD5    string
+	SHA1   string
+	SHA256 string
+	Size   int64
+}
+
 type MalwareScanner interface {
-	// Scan scans the event for malware.
-	Scan(eventType utils.EventType, event interface{}, containerPid uint32) MalwareResult
+	// Scan scans the event for malware, hashes are the hashes of the file of the event.
+	Scan(eventType utils.EventType, event interface{}, containerPid uint32, hashes *FileHashes) MalwareResult
+}
+
+// HashMalwareScanner is a scanner using the hashes of the files, the files are only hashed when a scanner uses them
+type HashMalwareScanner interface {
+	MalwareScanner
+	// MaxHashedFileSize is the size of the largest file the scanner uses the hashes of, in bytes
+	MaxHashedFileSize() int64
+}
+
+// StoppableMalwareScanner is a scanner with background work, like reloading its feeds, that is stopped on shutdown
+type StoppableMalwareScanner interface {
+	MalwareScanner
+	Stop()
 }
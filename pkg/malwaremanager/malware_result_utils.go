@@ -0,0 +1,59 @@
+package malwaremanager
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	FixSuggestions = "Please remove the file from the system. If the file is required, please contact your security team for further investigation."
+)
+
+// ErrFileTooLarge is returned when a file is larger than the hashed size
+var ErrFileTooLarge = errors.New("file is too large to hash")
+
+// HashFile computes the hashes of a file in a single read, the files larger than maxSize bytes are not hashed
+func HashFile(path string, maxSize int64) (*FileHashes, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	md5Hash, sha1Hash, sha256Hash := md5.New(), sha1.New(), sha256.New()
+	// a file growing while it is read is not read past the limit
+	size, err := io.Copy(io.MultiWriter(md5Hash, sha1Hash, sha256Hash), io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > maxSize {
+		return nil, ErrFileTooLarge
+	}
+	return &FileHashes{
+		MD5:    hex.EncodeToString(md5Hash.Sum(nil)),
+		SHA1:   hex.EncodeToString(sha1Hash.Sum(nil)),
+		SHA256: hex.EncodeToString(sha256Hash.Sum(nil)),
+		Size:   size,
+	}, nil
+}
+
+// GetRuntimeAlertK8sDetails returns the k8s details of the malware alert raised by the event
+func GetRuntimeAlertK8sDetails(triggerEvent igtypes.Event) apitypes.RuntimeAlertK8sDetails {
+	return apitypes.RuntimeAlertK8sDetails{
+		ContainerID:   triggerEvent.Runtime.ContainerID,
+		ContainerName: triggerEvent.K8s.ContainerName,
+		Namespace:     triggerEvent.GetNamespace(),
+		PodName:       triggerEvent.GetPod(),
+		PodNamespace:  triggerEvent.GetNamespace(),
+		HostNetwork:   &triggerEvent.K8s.HostNetwork,
+		Image:         triggerEvent.Runtime.ContainerImageName,
+		ImageDigest:   triggerEvent.Runtime.ContainerImageDigest,
+	}
+}
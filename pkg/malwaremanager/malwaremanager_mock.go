@@ -26,3 +26,7 @@ func (r MalwareManagerMock) ReportFileOpen(_ string, _ traceropentype.Event) {
 func (r MalwareManagerMock) ContainerCallback(notif containercollection.PubSubEvent) {
 	// noop
 }
+
+func (r MalwareManagerMock) Stop() {
+	// noop
+}
@@ -56,7 +56,7 @@ func CreateClamAVClient(cfg *ClamAVConfig) (*ClamAVClient, error) {
 	return &clamavClient, nil
 }
 
-func (c *ClamAVClient) Scan(eventType nautils.EventType, event interface{}, containerPid uint32) malwaremanager.MalwareResult {
+func (c *ClamAVClient) Scan(eventType nautils.EventType, event interface{}, containerPid uint32, _ *malwaremanager.FileHashes) malwaremanager.MalwareResult {
 	// Check if the event is of type tracerexectype.Event or traceropentype.Event.
 	if eventType != nautils.ExecveEventType && eventType != nautils.OpenEventType {
 		return nil
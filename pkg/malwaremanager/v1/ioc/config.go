@@ -0,0 +1,33 @@
+package malwaremanager
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultMaxFileSize    = 100 * 1024 * 1024
+	defaultReloadInterval = time.Minute
+)
+
+type IOCConfig struct {
+	// FeedsPath is the directory of the IOC feeds
+	FeedsPath string `json:"feedsPath"`
+	// MaxFileSize is the size of the largest hashed file in bytes, larger files are skipped
+	MaxFileSize int64 `json:"maxFileSize"`
+	// ReloadInterval is the interval in which the feeds are checked for changes
+	ReloadInterval time.Duration `json:"reloadInterval"`
+}
+
+func (c *IOCConfig) Validate() error {
+	if c.FeedsPath == "" {
+		return fmt.Errorf("feeds path is required")
+	}
+	if c.MaxFileSize <= 0 {
+		c.MaxFileSize = defaultMaxFileSize
+	}
+	if c.ReloadInterval <= 0 {
+		c.ReloadInterval = defaultReloadInterval
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package malwaremanager
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	hashTypeMD5    = "md5"
+	hashTypeSHA1   = "sha1"
+	hashTypeSHA256 = "sha256"
+)
+
+// iocEntry is a known malicious hash
+type iocEntry struct {
+	// feed is the name of the feed file without the extension
+	feed string
+	// name is the malware name given by the feed, it may be empty
+	name string
+}
+
+// iocDatabase maps the lowercase hex hashes of all the feeds to their entries
+type iocDatabase struct {
+	hashes map[string]iocEntry
+	// feeds is the number of loaded feeds
+	feeds int
+}
+
+func (db *iocDatabase) lookup(hash string) (iocEntry, bool) {
+	entry, ok := db.hashes[hash]
+	return entry, ok
+}
+
+// hashType returns the type of a hex hash by its length, or an empty string if it is not a hash
+func hashType(hash string) string {
+	var t string
+	switch len(hash) {
+	case 32:
+		t = hashTypeMD5
+	case 40:
+		t = hashTypeSHA1
+	case 64:
+		t = hashTypeSHA256
+	default:
+		return ""
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return ""
+	}
+	return t
+}
+
+// feedFiles returns the feed files of the directory, sorted by name
+func feedFiles(path string) ([]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IOC feeds directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		// hidden files include the ..data links of mounted config maps
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(path, entry.Name()))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, entry.Name())
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// feedsSignature identifies the content of the feeds directory, it changes when a feed is added, removed or modified
+func feedsSignature(path string) (string, error) {
+	files, err := feedFiles(path)
+	if err != nil {
+		return "", err
+	}
+	var signature strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(filepath.Join(path, file))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&signature, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return signature.String(), nil
+}
+
+// loadIOCDatabase loads all the feeds of the directory, the format of a feed is detected by its extension:
+// .csv with a hash column, .json with an array of hashes or objects and any other extension with one hash per line
+func loadIOCDatabase(path string) (*iocDatabase, error) {
+	files, err := feedFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	db := &iocDatabase{hashes: make(map[string]iocEntry)}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(path, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read IOC feed %s: %w", file, err)
+		}
+		feed := strings.TrimSuffix(file, filepath.Ext(file))
+		add := func(hash string, name string) {
+			hash = strings.ToLower(strings.TrimSpace(hash))
+			if hashType(hash) == "" {
+				return
+			}
+			db.hashes[hash] = iocEntry{feed: feed, name: strings.TrimSpace(name)}
+		}
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".csv":
+			err = parseCSVFeed(data, add)
+		case ".json":
+			err = parseJSONFeed(data, add)
+		default:
+			err = parseListFeed(data, add)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse IOC feed %s: %w", file, err)
+		}
+		db.feeds++
+	}
+	return db, nil
+}
+
+// parseCSVFeed reads the hash columns (sha256, sha1, md5 or hash) and the name column (name, malware, signature or description) named by the header,
+// without a known header the first hash of each record is used with the next field as its name
+func parseCSVFeed(data []byte, add func(hash string, name string)) error {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	var hashColumns []int
+	nameColumn := -1
+	for i, column := range records[0] {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "sha256", "sha256_hash", "sha1", "sha1_hash", "md5", "md5_hash", "hash":
+			hashColumns = append(hashColumns, i)
+		case "name", "malware", "signature", "description":
+			if nameColumn < 0 {
+				nameColumn = i
+			}
+		}
+	}
+	if len(hashColumns) > 0 {
+		for _, record := range records[1:] {
+			name := ""
+			if nameColumn >= 0 && nameColumn < len(record) {
+				name = record[nameColumn]
+			}
+			for _, column := range hashColumns {
+				if column < len(record) {
+					add(record[column], name)
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, record := range records {
+		for i, field := range record {
+			if hashType(strings.ToLower(strings.TrimSpace(field))) == "" {
+				continue
+			}
+			name := ""
+			if i+1 < len(record) {
+				name = record[i+1]
+			}
+			add(field, name)
+			break
+		}
+	}
+	return nil
+}
+
+// parseJSONFeed reads an array of hashes or of objects with sha256, sha1, md5 or hash fields and an optional name
+func parseJSONFeed(data []byte, add func(hash string, name string)) error {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	for _, raw := range entries {
+		var hash string
+		if err := json.Unmarshal(raw, &hash); err == nil {
+			add(hash, "")
+			continue
+		}
+		var entry struct {
+			SHA256  string `json:"sha256"`
+			SHA1    string `json:"sha1"`
+			MD5     string `json:"md5"`
+			Hash    string `json:"hash"`
+			Name    string `json:"name"`
+			Malware string `json:"malware"`
+		}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return err
+		}
+		name := entry.Name
+		if name == "" {
+			name = entry.Malware
+		}
+		for _, hash := range []string{entry.SHA256, entry.SHA1, entry.MD5, entry.Hash} {
+			if hash != "" {
+				add(hash, name)
+			}
+		}
+	}
+	return nil
+}
+
+// parseListFeed reads one hash per line, optionally followed by a name, lines starting with # are comments
+func parseListFeed(data []byte, add func(hash string, name string)) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		add(fields[0], strings.Join(fields[1:], " "))
+	}
+	return scanner.Err()
+}
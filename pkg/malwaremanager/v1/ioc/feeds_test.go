@@ -0,0 +1,57 @@
+package malwaremanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testMD5    = "44d88612fea8a8f36de82e1278abb02f"
+	testSHA1   = "3395856ce81f2b7382dee72602f798b642f14140"
+	testSHA256 = "275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f"
+)
+
+func TestLoadIOCDatabase(t *testing.T) {
+	path := t.TempDir()
+	feeds := map[string]string{
+		"abuse.csv":   "# comment\nsha256_hash,signature,md5_hash\n" + testSHA256 + ",EICAR,\n",
+		"plain.csv":   "2023-01-01," + testSHA1 + ",Mirai\n",
+		"vendor.json": `["` + testMD5 + `", {"sha256": "` + "AAAA" + `", "name": "too short"}]`,
+		"list.txt":    "# known miners\n\n" + "0000000000000000000000000000000000000000000000000000000000000001 XMRig miner\nnot-a-hash\n",
+		".hidden":     testSHA256,
+	}
+	for name, content := range feeds {
+		require.NoError(t, os.WriteFile(filepath.Join(path, name), []byte(content), 0644))
+	}
+
+	db, err := loadIOCDatabase(path)
+	require.NoError(t, err)
+	assert.Equal(t, 4, db.feeds)
+	assert.Equal(t, 4, len(db.hashes))
+	assert.Equal(t, iocEntry{feed: "abuse", name: "EICAR"}, db.hashes[testSHA256])
+	assert.Equal(t, iocEntry{feed: "plain", name: "Mirai"}, db.hashes[testSHA1])
+	assert.Equal(t, iocEntry{feed: "vendor"}, db.hashes[testMD5])
+	assert.Equal(t, iocEntry{feed: "list", name: "XMRig miner"}, db.hashes["0000000000000000000000000000000000000000000000000000000000000001"])
+}
+
+func TestLoadIOCDatabaseErrors(t *testing.T) {
+	_, err := loadIOCDatabase(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+
+	path := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(path, "broken.json"), []byte(`{"sha256":`), 0644))
+	_, err = loadIOCDatabase(path)
+	assert.Error(t, err)
+}
+
+func TestHashType(t *testing.T) {
+	assert.Equal(t, hashTypeMD5, hashType(testMD5))
+	assert.Equal(t, hashTypeSHA1, hashType(testSHA1))
+	assert.Equal(t, hashTypeSHA256, hashType(testSHA256))
+	assert.Equal(t, "", hashType("z"+testMD5[1:]))
+	assert.Equal(t, "", hashType("abc"))
+}
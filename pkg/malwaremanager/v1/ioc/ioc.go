@@ -0,0 +1,173 @@
+package malwaremanager
+
+import (
+	"fmt"
+	"node-agent/pkg/malwaremanager"
+	mmtypes "node-agent/pkg/malwaremanager/v1/types"
+	"node-agent/pkg/ruleengine"
+	nautils "node-agent/pkg/utils"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	humanize "github.com/dustin/go-humanize"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	logger "github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+)
+
+// IOCScanner detects files whose hash is listed in the local IOC feeds, the feeds are reloaded when they change
+type IOCScanner struct {
+	cfg       *IOCConfig
+	db        atomic.Pointer[iocDatabase]
+	signature string
+	stop      chan struct{}
+}
+
+var _ malwaremanager.StoppableMalwareScanner = (*IOCScanner)(nil)
+var _ malwaremanager.HashMalwareScanner = (*IOCScanner)(nil)
+
+func CreateIOCScanner(cfg *IOCConfig) (*IOCScanner, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	s := &IOCScanner{
+		cfg:  cfg,
+		stop: make(chan struct{}),
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	go s.watch()
+	return s, nil
+}
+
+// MaxHashedFileSize is the size of the largest file looked up in the feeds
+func (s *IOCScanner) MaxHashedFileSize() int64 {
+	return s.cfg.MaxFileSize
+}
+
+// Stop stops reloading the feeds
+func (s *IOCScanner) Stop() {
+	close(s.stop)
+}
+
+func (s *IOCScanner) watch() {
+	ticker := time.NewTicker(s.cfg.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.reload(); err != nil {
+				logger.L().Error("failed to reload IOC feeds, keeping the previous feeds", helpers.Error(err))
+			}
+		}
+	}
+}
+
+// reload loads the feeds when they changed since the last load
+func (s *IOCScanner) reload() error {
+	signature, err := feedsSignature(s.cfg.FeedsPath)
+	if err != nil {
+		return err
+	}
+	if s.db.Load() != nil && signature == s.signature {
+		return nil
+	}
+	db, err := loadIOCDatabase(s.cfg.FeedsPath)
+	if err != nil {
+		return err
+	}
+	s.db.Store(db)
+	s.signature = signature
+	logger.L().Info("loaded IOC feeds", helpers.String("path", s.cfg.FeedsPath), helpers.Int("feeds", db.feeds), helpers.Int("hashes", len(db.hashes)))
+	return nil
+}
+
+func (s *IOCScanner) Scan(eventType nautils.EventType, event interface{}, _ uint32, hashes *malwaremanager.FileHashes) malwaremanager.MalwareResult {
+	if eventType != nautils.ExecveEventType && eventType != nautils.OpenEventType {
+		return nil
+	}
+	db := s.db.Load()
+	// the files are hashed up to the largest size of all the scanners
+	if len(db.hashes) == 0 || hashes == nil || hashes.Size > s.cfg.MaxFileSize {
+		return nil
+	}
+
+	for _, hash := range []struct{ value, hashType string }{{hashes.SHA256, hashTypeSHA256}, {hashes.SHA1, hashTypeSHA1}, {hashes.MD5, hashTypeMD5}} {
+		entry, ok := db.lookup(hash.value)
+		if !ok {
+			continue
+		}
+		switch e := event.(type) {
+		case *tracerexectype.Event:
+			commandLine := fmt.Sprintf("%s %s", nautils.GetExecPathFromEvent(e), strings.Join(nautils.GetExecArgsFromEvent(e), " "))
+			return createMalwareResult(entry, hash.value, hash.hashType, hashes, e.Event, apitypes.Process{
+				Comm:       e.Comm,
+				Path:       nautils.GetExecPathFromEvent(e),
+				Gid:        &e.Gid,
+				PID:        e.Pid,
+				Uid:        &e.Uid,
+				UpperLayer: e.UpperLayer,
+				PPID:       e.Ppid,
+				Pcomm:      e.Pcomm,
+				Cwd:        e.Cwd,
+				Hardlink:   e.ExePath,
+				Cmdline:    commandLine,
+			})
+		case *traceropentype.Event:
+			return createMalwareResult(entry, hash.value, hash.hashType, hashes, e.Event, apitypes.Process{
+				Comm: e.Comm,
+				Path: e.FullPath,
+				Gid:  &e.Gid,
+				PID:  e.Pid,
+				Uid:  &e.Uid,
+			})
+		}
+	}
+	return nil
+}
+
+func createMalwareResult(entry iocEntry, hash string, hashType string, hashes *malwaremanager.FileHashes, triggerEvent igtypes.Event, process apitypes.Process) malwaremanager.MalwareResult {
+	name := entry.name
+	if name == "" {
+		name = "Known malicious file"
+	}
+	description := fmt.Sprintf("The %s hash %s of %s matched the IOC feed %s", hashType, hash, process.Path, entry.feed)
+	if entry.name != "" {
+		description += ": " + entry.name
+	}
+
+	return &mmtypes.GenericMalwareResult{
+		BasicRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName: name,
+			Arguments: map[string]interface{}{
+				"iocFeed":     entry.feed,
+				"iocHash":     hash,
+				"iocHashType": hashType,
+			},
+			InfectedPID:    process.PID,
+			FixSuggestions: malwaremanager.FixSuggestions,
+			SHA1Hash:       hashes.SHA1,
+			SHA256Hash:     hashes.SHA256,
+			MD5Hash:        hashes.MD5,
+			Severity:       ruleengine.RulePriorityCritical,
+			Size:           humanize.IBytes(uint64(hashes.Size)),
+			Timestamp:      time.Unix(0, int64(triggerEvent.Timestamp)),
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: process,
+			ContainerID: triggerEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: triggerEvent,
+		MalwareRuntimeAlert: apitypes.MalwareAlert{
+			MalwareDescription: description,
+		},
+		RuntimeAlertK8sDetails: malwaremanager.GetRuntimeAlertK8sDetails(triggerEvent),
+	}
+}
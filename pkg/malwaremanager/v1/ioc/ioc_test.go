@@ -0,0 +1,99 @@
+package malwaremanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/utils"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestIOCScannerScan(t *testing.T) {
+	feedsPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(feedsPath, "miners.txt"), []byte(sha256Hex("miner")+" XMRig\n"), 0644))
+	scanner, err := CreateIOCScanner(&IOCConfig{FeedsPath: feedsPath, ReloadInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer scanner.Stop()
+
+	filesPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(filesPath, "miner"), []byte("miner"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(filesPath, "dropper"), []byte("dropper"), 0755))
+	scan := func(name string) malwaremanager.MalwareResult {
+		path := filepath.Join(filesPath, name)
+		hashes, err := malwaremanager.HashFile(path, scanner.MaxHashedFileSize())
+		require.NoError(t, err)
+		return scanner.Scan(utils.OpenEventType, &traceropentype.Event{Comm: "sh", Pid: 42, FullPath: path}, 0, hashes)
+	}
+
+	result := scan("miner")
+	require.NotNil(t, result)
+	assert.Equal(t, "XMRig", result.GetBasicRuntimeAlert().AlertName)
+	assert.Equal(t, sha256Hex("miner"), result.GetBasicRuntimeAlert().SHA256Hash)
+	assert.Equal(t, "miners", result.GetBasicRuntimeAlert().Arguments["iocFeed"])
+	assert.Equal(t, hashTypeSHA256, result.GetBasicRuntimeAlert().Arguments["iocHashType"])
+	assert.Contains(t, result.GetMalwareRuntimeAlert().MalwareDescription, "matched the IOC feed miners")
+	assert.Nil(t, scan("dropper"))
+
+	// a new feed is loaded without a restart
+	require.NoError(t, os.WriteFile(filepath.Join(feedsPath, "droppers.txt"), []byte(sha256Hex("dropper")+"\n"), 0644))
+	assert.Eventually(t, func() bool {
+		return scan("dropper") != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// an invalid feed keeps the previous feeds
+	require.NoError(t, os.WriteFile(filepath.Join(feedsPath, "broken.json"), []byte("{"), 0644))
+	time.Sleep(50 * time.Millisecond)
+	assert.NotNil(t, scan("miner"))
+}
+
+func TestIOCScannerWithoutHashes(t *testing.T) {
+	feedsPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(feedsPath, "feed.txt"), []byte(sha256Hex("malicious content")+"\n"), 0644))
+	scanner, err := CreateIOCScanner(&IOCConfig{FeedsPath: feedsPath})
+	require.NoError(t, err)
+	defer scanner.Stop()
+
+	// the scanner does not hash the files itself
+	filePath := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filePath, []byte("malicious content"), 0644))
+	assert.Nil(t, scanner.Scan(utils.OpenEventType, &traceropentype.Event{FullPath: filePath}, 0, nil))
+}
+
+func TestIOCScannerSkipsLargeFiles(t *testing.T) {
+	feedsPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(feedsPath, "feed.txt"), []byte(sha256Hex("malicious content")+"\n"), 0644))
+	scanner, err := CreateIOCScanner(&IOCConfig{FeedsPath: feedsPath, MaxFileSize: 4})
+	require.NoError(t, err)
+	defer scanner.Stop()
+	assert.Equal(t, int64(4), scanner.MaxHashedFileSize())
+
+	filePath := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filePath, []byte("malicious content"), 0644))
+	_, err = malwaremanager.HashFile(filePath, scanner.MaxHashedFileSize())
+	assert.ErrorIs(t, err, malwaremanager.ErrFileTooLarge)
+
+	// the files hashed for another scanner with a larger size are skipped too
+	hashes, err := malwaremanager.HashFile(filePath, 1024)
+	require.NoError(t, err)
+	assert.Nil(t, scanner.Scan(utils.OpenEventType, &traceropentype.Event{FullPath: filePath}, 0, hashes))
+}
+
+func TestIOCConfigValidate(t *testing.T) {
+	cfg := IOCConfig{FeedsPath: "/etc/ioc-feeds"}
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, int64(defaultMaxFileSize), cfg.MaxFileSize)
+	assert.Equal(t, defaultReloadInterval, cfg.ReloadInterval)
+	assert.Error(t, (&IOCConfig{}).Validate())
+}
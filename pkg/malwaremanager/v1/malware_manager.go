@@ -7,14 +7,16 @@ import (
 	"node-agent/pkg/k8sclient"
 	"node-agent/pkg/malwaremanager"
 	clamavv1 "node-agent/pkg/malwaremanager/v1/clamav"
+	iocv1 "node-agent/pkg/malwaremanager/v1/ioc"
+	yarav1 "node-agent/pkg/malwaremanager/v1/yara"
 	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/responsemanager"
 	"node-agent/pkg/utils"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/armosec/utils-k8s-go/wlid"
-	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/dustin/go-humanize"
 	"github.com/goradd/maps"
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
@@ -25,10 +27,8 @@ import (
 	"github.com/kubescape/k8s-interface/workloadinterface"
 )
 
-const ScannedFilesMaxBufferLength = 10000
-
 type MalwareManager struct {
-	scannedFiles         maps.SafeMap[string, mapset.Set[string]]
+	verdicts             *verdictCache
 	containerIdToPid     maps.SafeMap[string, uint32]
 	podToWlid            maps.SafeMap[string, string]
 	exporter             exporters.Exporter
@@ -39,11 +39,12 @@ type MalwareManager struct {
 	malwareScanners      []malwaremanager.MalwareScanner
 	cfg                  config.Config
 	containerIdToShimPid maps.SafeMap[string, uint32]
+	responseManager      responsemanager.ResponseManagerClient
 }
 
 var _ malwaremanager.MalwareManagerClient = (*MalwareManager)(nil)
 
-func CreateMalwareManager(cfg config.Config, k8sClient k8sclient.K8sClientInterface, nodeName string, clusterName string, exporter exporters.Exporter, prometheusExporter metricsmanager.MetricsManager) (*MalwareManager, error) {
+func CreateMalwareManager(cfg config.Config, k8sClient k8sclient.K8sClientInterface, nodeName string, clusterName string, exporter exporters.Exporter, prometheusExporter metricsmanager.MetricsManager, responseManager responsemanager.ResponseManagerClient) (*MalwareManager, error) {
 
 	// Create malware scanners
 	malwareScanners := []malwaremanager.MalwareScanner{}
@@ -60,6 +61,32 @@ func CreateMalwareManager(cfg config.Config, k8sClient k8sclient.K8sClientInterf
 		}
 		malwareScanners = append(malwareScanners, clamavScanner)
 	}
+
+	// Create YARA scanner
+	// Check if YARA is enabled (YARA_RULES_PATH env var is set to the directory of the rule files)
+	if yaraRulesPath, present := os.LookupEnv("YARA_RULES_PATH"); present {
+		yaraConfig := yarav1.YaraConfig{
+			RulesPath: yaraRulesPath,
+		}
+		yaraScanner, err := yarav1.CreateYaraScanner(&yaraConfig)
+		if err != nil {
+			return nil, err
+		}
+		malwareScanners = append(malwareScanners, yaraScanner)
+	}
+
+	// Create IOC hash scanner
+	// Check if IOC feeds are enabled (IOC_FEEDS_PATH env var is set to the directory of the feed files)
+	if iocFeedsPath, present := os.LookupEnv("IOC_FEEDS_PATH"); present {
+		iocConfig := iocv1.IOCConfig{
+			FeedsPath: iocFeedsPath,
+		}
+		iocScanner, err := iocv1.CreateIOCScanner(&iocConfig)
+		if err != nil {
+			return nil, err
+		}
+		malwareScanners = append(malwareScanners, iocScanner)
+	}
 	return &MalwareManager{
 		cfg:             cfg,
 		malwareScanners: malwareScanners,
@@ -68,12 +95,12 @@ func CreateMalwareManager(cfg config.Config, k8sClient k8sclient.K8sClientInterf
 		nodeName:        nodeName,
 		clusterName:     clusterName,
 		metrics:         prometheusExporter,
+		responseManager: responseManager,
+		verdicts:        newVerdictCache(cfg.MalwareScanCacheSize, cfg.MalwareScanCacheTTL, maxHashedFileSize(malwareScanners), prometheusExporter),
 	}, nil
 }
 
 func (mm *MalwareManager) ContainerCallback(notif containercollection.PubSubEvent) {
-	t := time.NewTicker(mm.cfg.InitialDelay)
-
 	switch notif.Type {
 	case containercollection.EventTypeAddContainer:
 		mm.containerIdToPid.Set(notif.Container.Runtime.ContainerID, notif.Container.Pid)
@@ -93,21 +120,19 @@ func (mm *MalwareManager) ContainerCallback(notif containercollection.PubSubEven
 		}
 	case containercollection.EventTypeRemoveContainer:
 		mm.containerIdToPid.Delete(notif.Container.Runtime.ContainerID)
-		t.Stop()
-		mm.scannedFiles.Delete(notif.Container.Runtime.ContainerID)
+		mm.verdicts.removeContainer(notif.Container.Runtime.ContainerID)
 		mm.podToWlid.Delete(notif.Container.K8s.PodName)
 		mm.containerIdToShimPid.Delete(notif.Container.Runtime.ContainerID)
 	}
+}
 
-	go func() {
-		for {
-			select {
-			case <-t.C:
-				mm.scannedFiles.Set(notif.Container.Runtime.ContainerID, mapset.NewSet[string]())
-				return
-			}
+// Stop stops the scanners that work in the background
+func (mm *MalwareManager) Stop() {
+	for _, scanner := range mm.malwareScanners {
+		if stoppable, ok := scanner.(malwaremanager.StoppableMalwareScanner); ok {
+			stoppable.Stop()
 		}
-	}()
+	}
 }
 
 func (mm *MalwareManager) getWorkloadIdentifier(podNamespace, podName string) (string, error) {
@@ -137,21 +162,26 @@ func (mm *MalwareManager) getWorkloadIdentifier(podNamespace, podName string) (s
 }
 
 func (mm *MalwareManager) ReportFileExec(_ string, event tracerexectype.Event) {
+	containerPid := mm.containerIdToPid.Get(event.Runtime.ContainerID)
+	hostFilePath, err := utils.GetHostFilePathFromEvent(&event, containerPid)
+	if err != nil {
+		return
+	}
+	file := mm.lookupVerdict(event.Runtime.ContainerID, hostFilePath)
+	if file == nil || file.verdict != verdictUnknown {
+		return
+	}
+
+	detected := false
 	for _, scanner := range mm.malwareScanners {
-		if result := scanner.Scan(utils.ExecveEventType, &event, mm.containerIdToPid.Get(event.Runtime.ContainerID)); result != nil {
+		if result := scanner.Scan(utils.ExecveEventType, &event, containerPid, file.hashes); result != nil {
+			detected = true
 			result.SetWorkloadDetails(mm.podToWlid.Get(event.GetPod()))
+			mm.responseManager.RespondToMalwareAlert(result, containerPid)
 			mm.exporter.SendMalwareAlert(result)
 		}
 	}
-
-	if mm.scannedFiles.Has(event.Runtime.ContainerID) && mm.scannedFiles.Get(event.Runtime.ContainerID).Cardinality() <= ScannedFilesMaxBufferLength {
-		hostFilePath, err := utils.GetHostFilePathFromEvent(&event, mm.containerIdToPid.Get(event.Runtime.ContainerID))
-		if err != nil {
-			return
-		}
-
-		mm.scannedFiles.Get(event.Runtime.ContainerID).Add(hostFilePath)
-	}
+	mm.setVerdict(file, detected)
 }
 
 func (mm *MalwareManager) ReportFileOpen(_ string, event traceropentype.Event) {
@@ -162,32 +192,48 @@ func (mm *MalwareManager) ReportFileOpen(_ string, event traceropentype.Event) {
 		return
 	}
 
-	hostFilePath, err := utils.GetHostFilePathFromEvent(&event, mm.containerIdToPid.Get(event.Runtime.ContainerID))
+	containerPid := mm.containerIdToPid.Get(event.Runtime.ContainerID)
+	hostFilePath, err := utils.GetHostFilePathFromEvent(&event, containerPid)
 	if err != nil {
 		return
 	}
-
-	if mm.scannedFiles.Has(event.Runtime.ContainerID) {
-		// If the file has already been scanned, return.
-		scannedFiles := mm.scannedFiles.Get(event.Runtime.ContainerID)
-		if scannedFiles.Contains(hostFilePath) {
-			return
-		}
-
-		// Add the scanned file to the cache.
-		if scannedFiles.Cardinality() <= ScannedFilesMaxBufferLength {
-			scannedFiles.Add(hostFilePath)
-		}
+	file := mm.lookupVerdict(event.Runtime.ContainerID, hostFilePath)
+	if file == nil || file.verdict != verdictUnknown {
+		return
 	}
 
+	detected := false
 	for _, scanner := range mm.malwareScanners {
-		if result := scanner.Scan(utils.OpenEventType, &event, mm.containerIdToPid.Get(event.Runtime.ContainerID)); result != nil {
+		if result := scanner.Scan(utils.OpenEventType, &event, containerPid, file.hashes); result != nil {
+			detected = true
 			result.SetWorkloadDetails(mm.podToWlid.Get(event.GetPod()))
+			// the response is taken before the enrichment replaces the offending process with the process tree
+			mm.responseManager.RespondToMalwareAlert(result, containerPid)
 			result = mm.enrichMalwareResult(result)
 			mm.exporter.SendMalwareAlert(result)
 			mm.metrics.ReportRuleAlert(result.GetBasicRuntimeAlert().AlertName)
 		}
 	}
+	mm.setVerdict(file, detected)
+}
+
+// lookupVerdict returns the hashed file with its cached verdict, or nil when the file cannot be hashed and is not scanned
+func (mm *MalwareManager) lookupVerdict(containerID string, hostFilePath string) *scannedFile {
+	file, err := mm.verdicts.lookup(containerID, hostFilePath)
+	if err != nil {
+		logger.L().Debug("MalwareManager - failed to look up the scan verdict", helpers.String("path", hostFilePath), helpers.Error(err))
+		return nil
+	}
+	return file
+}
+
+// setVerdict caches the verdict of the scanners on the file
+func (mm *MalwareManager) setVerdict(file *scannedFile, detected bool) {
+	if detected {
+		mm.verdicts.setDetected(file)
+	} else {
+		mm.verdicts.setClean(file)
+	}
 }
 
 func (mm *MalwareManager) enrichMalwareResult(malwareResult malwaremanager.MalwareResult) malwaremanager.MalwareResult {
@@ -0,0 +1,170 @@
+package malwaremanager
+
+import (
+	"errors"
+	"fmt"
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/metricsmanager"
+	"os"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	defaultVerdictCacheSize = 50000
+	defaultVerdictCacheTTL  = 24 * time.Hour
+)
+
+// fileIdentity identifies a version of a file without reading it, the files of an image layer have the same identity
+// in all the containers using the layer
+type fileIdentity struct {
+	dev   uint64
+	ino   uint64
+	mtime int64
+	size  int64
+}
+
+// detection is a detected file in a container
+type detection struct {
+	containerID string
+	identity    fileIdentity
+}
+
+// fileVerdict is the cached verdict of the scanners on a file
+type fileVerdict int
+
+const (
+	// verdictUnknown is the verdict of the files that were not scanned
+	verdictUnknown fileVerdict = iota
+	// verdictClean is the verdict of the files no scanner detected
+	verdictClean
+	// verdictDetected is the verdict of the files a scanner detected, they were already alerted on
+	verdictDetected
+)
+
+// scannedFile is a file of a container looked up in the verdict cache, hashes is nil when no scanner uses them or
+// the file is larger than the hashed size
+type scannedFile struct {
+	containerID string
+	identity    fileIdentity
+	hashes      *malwaremanager.FileHashes
+	verdict     fileVerdict
+}
+
+// cleanKey is the key of the clean verdict of the file, its SHA256 hash when it was hashed so the copies of the file
+// share it
+func (f *scannedFile) cleanKey() any {
+	if f.hashes != nil {
+		return f.hashes.SHA256
+	}
+	return f.identity
+}
+
+// verdictCache remembers the verdicts of the scanners, so files are neither scanned nor alerted on again.
+// The identity of a file is mapped to its hashes and the clean verdicts are kept by SHA256 hash, so the files of an
+// image layer shared by several containers are hashed and scanned once. The detections are kept by container and
+// identity, so a detected file is alerted on once in each container.
+// The files are only hashed up to maxHashSize, the largest size the scanners use the hashes of, and are not hashed
+// when no scanner uses them.
+type verdictCache struct {
+	ttl         time.Duration
+	maxHashSize int64
+	// hashes maps the file identities to their hashes
+	hashes *cache.LRUExpireCache
+	// clean holds the SHA256 hashes, or the identities when they are not hashed, of the clean files
+	clean *cache.LRUExpireCache
+	// detected holds the detections of the files in the containers
+	detected *cache.LRUExpireCache
+	metrics  metricsmanager.MetricsManager
+}
+
+func newVerdictCache(size int, ttl time.Duration, maxHashSize int64, metrics metricsmanager.MetricsManager) *verdictCache {
+	if size <= 0 {
+		size = defaultVerdictCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultVerdictCacheTTL
+	}
+	return &verdictCache{
+		ttl:         ttl,
+		maxHashSize: maxHashSize,
+		hashes:      cache.NewLRUExpireCache(size),
+		clean:       cache.NewLRUExpireCache(size),
+		detected:    cache.NewLRUExpireCache(size),
+		metrics:     metrics,
+	}
+}
+
+// maxHashedFileSize returns the largest size the scanners use the hashes of, zero when none uses them
+func maxHashedFileSize(scanners []malwaremanager.MalwareScanner) int64 {
+	var maxSize int64
+	for _, scanner := range scanners {
+		if hashScanner, ok := scanner.(malwaremanager.HashMalwareScanner); ok && hashScanner.MaxHashedFileSize() > maxSize {
+			maxSize = hashScanner.MaxHashedFileSize()
+		}
+	}
+	return maxSize
+}
+
+// lookup hashes the file, once per identity, and returns it with its cached verdict
+func (c *verdictCache) lookup(containerID string, hostFilePath string) (*scannedFile, error) {
+	info, err := os.Stat(hostFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", hostFilePath)
+	}
+	file := &scannedFile{
+		containerID: containerID,
+		identity: fileIdentity{
+			mtime: info.ModTime().UnixNano(),
+			size:  info.Size(),
+		},
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		file.identity.dev = uint64(stat.Dev)
+		file.identity.ino = stat.Ino
+	}
+
+	if cached, ok := c.hashes.Get(file.identity); ok {
+		file.hashes = cached.(*malwaremanager.FileHashes)
+	} else if info.Size() <= c.maxHashSize {
+		file.hashes, err = malwaremanager.HashFile(hostFilePath, c.maxHashSize)
+		// a file that grew past the size since the stat is scanned without hashes
+		if err != nil && !errors.Is(err, malwaremanager.ErrFileTooLarge) {
+			return nil, err
+		}
+		if file.hashes != nil {
+			c.hashes.Add(file.identity, file.hashes, c.ttl)
+		}
+	}
+
+	if _, detected := c.detected.Get(detection{containerID: file.containerID, identity: file.identity}); detected {
+		file.verdict = verdictDetected
+	} else if _, clean := c.clean.Get(file.cleanKey()); clean {
+		file.verdict = verdictClean
+	}
+	c.metrics.ReportMalwareScanCache(file.verdict != verdictUnknown)
+	return file, nil
+}
+
+// setClean records that no scanner detected the file, until the TTL expires
+func (c *verdictCache) setClean(file *scannedFile) {
+	c.clean.Add(file.cleanKey(), struct{}{}, c.ttl)
+}
+
+// setDetected records that a scanner detected the file in its container, until the TTL expires
+func (c *verdictCache) setDetected(file *scannedFile) {
+	c.detected.Add(detection{containerID: file.containerID, identity: file.identity}, struct{}{}, c.ttl)
+}
+
+// removeContainer forgets the detections of a removed container, the hashes and the clean verdicts stay shared until
+// they expire
+func (c *verdictCache) removeContainer(containerID string) {
+	c.detected.RemoveAll(func(key any) bool {
+		return key.(detection).containerID == containerID
+	})
+}
@@ -0,0 +1,162 @@
+package malwaremanager
+
+import (
+	"node-agent/pkg/exporters"
+	"node-agent/pkg/malwaremanager"
+	mmtypes "node-agent/pkg/malwaremanager/v1/types"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/responsemanager"
+	"node-agent/pkg/utils"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scannerMock detects the files named malware and counts the scans
+type scannerMock struct {
+	scans int
+}
+
+func (s *scannerMock) Scan(_ utils.EventType, event interface{}, _ uint32, _ *malwaremanager.FileHashes) malwaremanager.MalwareResult {
+	s.scans++
+	if filepath.Base(event.(*traceropentype.Event).FullPath) != "malware" {
+		return nil
+	}
+	return &mmtypes.GenericMalwareResult{BasicRuntimeAlert: apitypes.BaseRuntimeAlert{AlertName: "malware"}}
+}
+
+func TestVerdictCache(t *testing.T) {
+	metrics := metricsmanager.NewMetricsMock()
+	cache := newVerdictCache(10, time.Hour, 1024, metrics)
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(filePath, []byte("content"), 0644))
+
+	file, err := cache.lookup("container1", filePath)
+	require.NoError(t, err)
+	assert.Equal(t, verdictUnknown, file.verdict)
+	assert.Equal(t, "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73", file.hashes.SHA256)
+	assert.Equal(t, "9a0364b9e99bb480dd25e1f0284c8555", file.hashes.MD5)
+	assert.Equal(t, int64(7), file.hashes.Size)
+	cache.setClean(file)
+
+	file, err = cache.lookup("container1", filePath)
+	require.NoError(t, err)
+	assert.Equal(t, verdictClean, file.verdict)
+
+	// the same content in another container shares the verdict
+	otherPath := filepath.Join(dir, "other")
+	require.NoError(t, os.WriteFile(otherPath, []byte("content"), 0644))
+	file, err = cache.lookup("container2", otherPath)
+	require.NoError(t, err)
+	assert.Equal(t, verdictClean, file.verdict)
+
+	// a modified file is not clean anymore
+	require.NoError(t, os.WriteFile(filePath, []byte("modified"), 0644))
+	require.NoError(t, os.Chtimes(filePath, time.Now(), time.Now().Add(time.Minute)))
+	file, err = cache.lookup("container1", filePath)
+	require.NoError(t, err)
+	assert.Equal(t, verdictUnknown, file.verdict)
+
+	// detections are kept by container
+	cache.setDetected(file)
+	file, err = cache.lookup("container1", filePath)
+	require.NoError(t, err)
+	assert.Equal(t, verdictDetected, file.verdict)
+	file, err = cache.lookup("container2", filePath)
+	require.NoError(t, err)
+	assert.Equal(t, verdictUnknown, file.verdict)
+
+	assert.Equal(t, int32(3), metrics.MalwareCacheHits.Load())
+	assert.Equal(t, int32(3), metrics.MalwareCacheMisses.Load())
+
+	// the same file is hashed once for all the containers
+	assert.Len(t, cache.hashes.Keys(), 3)
+	cache.removeContainer("container1")
+	assert.Len(t, cache.hashes.Keys(), 3)
+	assert.Empty(t, cache.detected.Keys())
+
+	_, err = cache.lookup("container1", dir)
+	assert.Error(t, err, "directories are not cached")
+}
+
+func TestVerdictCacheMaxHashSize(t *testing.T) {
+	cache := newVerdictCache(10, time.Hour, 4, metricsmanager.NewMetricsMock())
+	filePath := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filePath, []byte("content"), 0644))
+
+	// the files larger than the hashed size are not hashed, their verdict is kept by identity
+	file, err := cache.lookup("container1", filePath)
+	require.NoError(t, err)
+	assert.Nil(t, file.hashes)
+	cache.setClean(file)
+	file, err = cache.lookup("container1", filePath)
+	require.NoError(t, err)
+	assert.Equal(t, verdictClean, file.verdict)
+	assert.Empty(t, cache.hashes.Keys())
+
+	// the files are not hashed when no scanner uses the hashes
+	assert.Equal(t, int64(0), maxHashedFileSize([]malwaremanager.MalwareScanner{&scannerMock{}}))
+}
+
+func TestVerdictCacheTTL(t *testing.T) {
+	cache := newVerdictCache(10, time.Millisecond, 1024, metricsmanager.NewMetricsMock())
+	filePath := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filePath, []byte("content"), 0644))
+
+	file, err := cache.lookup("container1", filePath)
+	require.NoError(t, err)
+	cache.setClean(file)
+	time.Sleep(10 * time.Millisecond)
+	file, err = cache.lookup("container1", filePath)
+	require.NoError(t, err)
+	assert.Equal(t, verdictUnknown, file.verdict)
+}
+
+func TestReportFileOpenVerdictCache(t *testing.T) {
+	scanner := &scannerMock{}
+	mm := &MalwareManager{
+		verdicts:        newVerdictCache(10, time.Hour, maxHashedFileSize([]malwaremanager.MalwareScanner{scanner}), metricsmanager.NewMetricsMock()),
+		exporter:        &exporters.ExporterMock{},
+		metrics:         metricsmanager.NewMetricsMock(),
+		malwareScanners: []malwaremanager.MalwareScanner{scanner},
+		responseManager: responsemanager.CreateResponseManagerMock(),
+	}
+	// the files are read through /proc/<pid>/root, our own root is the container root in the test
+	mm.containerIdToPid.Set("container1", uint32(os.Getpid()))
+	dir := t.TempDir()
+	cleanPath := filepath.Join(dir, "clean")
+	require.NoError(t, os.WriteFile(cleanPath, []byte("clean"), 0644))
+	malwarePath := filepath.Join(dir, "malware")
+	require.NoError(t, os.WriteFile(malwarePath, []byte("malware"), 0644))
+	open := func(path string) {
+		mm.ReportFileOpen("", traceropentype.Event{
+			Event:    igtypes.Event{CommonData: igtypes.CommonData{Runtime: igtypes.BasicRuntimeMetadata{ContainerID: "container1"}}},
+			FullPath: path,
+		})
+	}
+
+	open(cleanPath)
+	open(cleanPath)
+	assert.Equal(t, 1, scanner.scans, "a clean file is scanned once")
+
+	open(malwarePath)
+	open(malwarePath)
+	assert.Equal(t, 2, scanner.scans, "a detected file is scanned and alerted on once")
+	assert.Empty(t, mm.verdicts.hashes.Keys(), "the files are not hashed when no scanner uses the hashes")
+
+	mm.ContainerCallback(containercollection.PubSubEvent{
+		Type:      containercollection.EventTypeRemoveContainer,
+		Container: &containercollection.Container{Runtime: containercollection.RuntimeMetadata{BasicRuntimeMetadata: igtypes.BasicRuntimeMetadata{ContainerID: "container1"}}},
+	})
+	assert.Empty(t, mm.verdicts.detected.Keys())
+	assert.False(t, mm.containerIdToPid.Has("container1"))
+}
@@ -0,0 +1,33 @@
+package malwaremanager
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultMaxFileSize = 50 * 1024 * 1024
+	defaultScanTimeout = 10 * time.Second
+)
+
+type YaraConfig struct {
+	// RulesPath is the directory of the .yar/.yara rule files
+	RulesPath string `json:"rulesPath"`
+	// MaxFileSize is the size of the largest scanned file in bytes, larger files are skipped
+	MaxFileSize int64 `json:"maxFileSize"`
+	// ScanTimeout is the longest scan of a file, the files whose scan times out are not reported
+	ScanTimeout time.Duration `json:"scanTimeout"`
+}
+
+func (c *YaraConfig) Validate() error {
+	if c.RulesPath == "" {
+		return fmt.Errorf("rules path is required")
+	}
+	if c.MaxFileSize <= 0 {
+		c.MaxFileSize = defaultMaxFileSize
+	}
+	if c.ScanTimeout <= 0 {
+		c.ScanTimeout = defaultScanTimeout
+	}
+	return nil
+}
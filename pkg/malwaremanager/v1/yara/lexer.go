@@ -0,0 +1,236 @@
+package malwaremanager
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ruleDeclaration finds the start of a rule declaration, to resume parsing after a rule failed to parse
+var ruleDeclaration = regexp.MustCompile(`(?:\b(?:private|global)\s+)?\brule\s+\w+\s*[:{]`)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokStringID
+	tokCount
+	tokPunct
+	tokHex
+	tokRegex
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokEOF:
+		return "end of file"
+	case tokIdent:
+		return "identifier"
+	case tokString:
+		return "string"
+	case tokNumber:
+		return "number"
+	case tokStringID:
+		return "string identifier"
+	case tokCount:
+		return "string count"
+	case tokPunct:
+		return "punctuation"
+	case tokHex:
+		return "hex string"
+	case tokRegex:
+		return "regular expression"
+	}
+	return "unknown"
+}
+
+type yaraToken struct {
+	kind tokenKind
+	// text is the unquoted value of strings and the identifier without the $ or # prefix
+	text string
+	line int
+}
+
+// yaraLexer tokenizes the rules on demand, since hex strings and regular expressions are only valid as string values
+type yaraLexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (l *yaraLexer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", l.line+1, fmt.Sprintf(format, args...))
+}
+
+func (l *yaraLexer) token(kind tokenKind, text string) yaraToken {
+	return yaraToken{kind: kind, text: text, line: l.line + 1}
+}
+
+// peek returns the next token without consuming it
+func (l *yaraLexer) peek() (yaraToken, error) {
+	pos, line := l.pos, l.line
+	tok, err := l.next()
+	l.pos, l.line = pos, line
+	return tok, err
+}
+
+func (l *yaraLexer) skipSpaceAndComments() error {
+	for l.pos < len(l.src) {
+		switch {
+		case l.src[l.pos] == '\n':
+			l.line++
+			l.pos++
+		case l.src[l.pos] == ' ' || l.src[l.pos] == '\t' || l.src[l.pos] == '\r':
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "//"):
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			if end < 0 {
+				l.pos = len(l.src)
+			} else {
+				l.pos += end
+			}
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				return l.errorf("unterminated comment")
+			}
+			l.line += strings.Count(l.src[l.pos:l.pos+2+end], "\n")
+			l.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// skipToNextRule moves to the next rule declaration, or to the end of the source when there is none
+func (l *yaraLexer) skipToNextRule() {
+	end := len(l.src)
+	if loc := ruleDeclaration.FindStringIndex(l.src[l.pos:]); loc != nil {
+		end = l.pos + loc[0]
+	}
+	l.line += strings.Count(l.src[l.pos:end], "\n")
+	l.pos = end
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (l *yaraLexer) readIdent() string {
+	start := l.pos
+	for l.pos < len(l.src) && isIdentChar(l.src[l.pos]) {
+		l.pos++
+	}
+	return l.src[start:l.pos]
+}
+
+func (l *yaraLexer) next() (yaraToken, error) {
+	if err := l.skipSpaceAndComments(); err != nil {
+		return yaraToken{}, err
+	}
+	if l.pos >= len(l.src) {
+		return l.token(tokEOF, "end of file"), nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case c == '"':
+		return l.readString()
+	case c == '$' || c == '#':
+		l.pos++
+		id := l.readIdent()
+		kind := tokCount
+		if c == '$' {
+			kind = tokStringID
+			if l.pos < len(l.src) && l.src[l.pos] == '*' {
+				l.pos++
+				id += "*"
+			}
+		}
+		return l.token(kind, id), nil
+	case c >= '0' && c <= '9':
+		return l.token(tokNumber, l.readIdent()), nil
+	case isIdentChar(c):
+		return l.token(tokIdent, l.readIdent()), nil
+	}
+	for _, punct := range []string{"==", "!=", "<=", ">=", "{", "}", "(", ")", ":", "=", ",", "<", ">"} {
+		if strings.HasPrefix(l.src[l.pos:], punct) {
+			l.pos += len(punct)
+			return l.token(tokPunct, punct), nil
+		}
+	}
+	return yaraToken{}, l.errorf("unexpected character %q", c)
+}
+
+func (l *yaraLexer) readString() (yaraToken, error) {
+	// skip the opening quote, the escapes are the same as in Go
+	end := l.pos + 1
+	for ; end < len(l.src); end++ {
+		if l.src[end] == '\\' {
+			end++
+			continue
+		}
+		if l.src[end] == '"' || l.src[end] == '\n' {
+			break
+		}
+	}
+	if end >= len(l.src) || l.src[end] != '"' {
+		return yaraToken{}, l.errorf("unterminated string")
+	}
+	text, err := strconv.Unquote(l.src[l.pos : end+1])
+	if err != nil {
+		return yaraToken{}, l.errorf("invalid string %s", l.src[l.pos:end+1])
+	}
+	l.pos = end + 1
+	return l.token(tokString, text), nil
+}
+
+// nextStringValue reads the value of a string definition, a text string, a hex string or a regular expression
+func (l *yaraLexer) nextStringValue() (yaraToken, error) {
+	if err := l.skipSpaceAndComments(); err != nil {
+		return yaraToken{}, err
+	}
+	if l.pos >= len(l.src) {
+		return l.token(tokEOF, "end of file"), nil
+	}
+	switch l.src[l.pos] {
+	case '{':
+		end := strings.IndexByte(l.src[l.pos:], '}')
+		if end < 0 {
+			return yaraToken{}, l.errorf("unterminated hex string")
+		}
+		tok := l.token(tokHex, l.src[l.pos+1:l.pos+end])
+		l.line += strings.Count(tok.text, "\n")
+		l.pos += end + 1
+		return tok, nil
+	case '/':
+		end := l.pos + 1
+		for ; end < len(l.src) && l.src[end] != '/'; end++ {
+			if l.src[end] == '\\' {
+				end++
+			}
+			if end < len(l.src) && l.src[end] == '\n' {
+				return yaraToken{}, l.errorf("unterminated regular expression")
+			}
+		}
+		if end >= len(l.src) {
+			return yaraToken{}, l.errorf("unterminated regular expression")
+		}
+		expression := l.src[l.pos+1 : end]
+		l.pos = end + 1
+		flags := ""
+		for l.pos < len(l.src) && (l.src[l.pos] == 'i' || l.src[l.pos] == 's') {
+			flags += string(l.src[l.pos])
+			l.pos++
+		}
+		if flags != "" {
+			expression = "(?" + flags + ")" + expression
+		}
+		return l.token(tokRegex, expression), nil
+	}
+	return l.next()
+}
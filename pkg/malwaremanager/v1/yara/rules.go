@@ -0,0 +1,914 @@
+package malwaremanager
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The scanner supports the subset of the YARA language that does not need modules:
+//   - text strings with the nocase, wide, ascii, fullword and private modifiers
+//   - hex strings with wildcards (??, ?A, A?) and jumps ([n], [n-m], [n-]), a jump spans at most maxHexJumpSpan bytes
+//   - regular expressions (RE2 syntax) with the i and s flags
+//   - conditions with and, or, not, comparisons, $a, #a, $a at N, filesize,
+//     uint8/16/32(be), N|any|all|none of them/($a, $b*) and references to previous rules
+//
+// Imports, includes, global rules, alternatives in hex strings and the xor/base64 modifiers are rejected when the rules are loaded.
+
+const (
+	// maxHexJumpSpan is the largest number of bytes between the shortest and the longest match of a hex jump
+	maxHexJumpSpan = 64 * 1024
+	// deadlineCheckInterval is the number of scan steps between the reads of the clock
+	deadlineCheckInterval = 1024
+)
+
+var errScanTimeout = errors.New("scan deadline exceeded")
+
+type yaraRule struct {
+	name      string
+	tags      []string
+	meta      map[string]string
+	private   bool
+	strings   []*yaraString
+	condition yaraExpr
+}
+
+type yaraString struct {
+	id       string
+	private  bool
+	patterns []func(data *scanContext) []int
+}
+
+// scanContext holds the data of a single scan and the results computed so far
+type scanContext struct {
+	data    []byte
+	lower   []byte
+	matches map[*yaraString][]int
+	results map[*yaraRule]bool
+	// the scan stops with err once the deadline passes, a zero deadline never expires
+	deadline time.Time
+	steps    int
+	err      error
+}
+
+func newScanContext(data []byte, deadline time.Time) *scanContext {
+	return &scanContext{
+		data:     data,
+		matches:  make(map[*yaraString][]int),
+		results:  make(map[*yaraRule]bool),
+		deadline: deadline,
+	}
+}
+
+func (ctx *scanContext) lowerData() []byte {
+	if ctx.lower == nil {
+		ctx.lower = asciiLower(ctx.data)
+	}
+	return ctx.lower
+}
+
+// expired reports whether the scan passed its deadline, the clock is only read every deadlineCheckInterval steps
+func (ctx *scanContext) expired() bool {
+	if ctx.err != nil {
+		return true
+	}
+	ctx.steps++
+	if ctx.deadline.IsZero() || ctx.steps%deadlineCheckInterval != 0 {
+		return false
+	}
+	if time.Now().After(ctx.deadline) {
+		ctx.err = errScanTimeout
+	}
+	return ctx.err != nil
+}
+
+// asciiLower lowercases the ASCII letters only, unlike bytes.ToLower it keeps the length of invalid UTF-8 so the
+// offsets in the lowercase data are the offsets in the data
+func asciiLower(data []byte) []byte {
+	lower := make([]byte, len(data))
+	for i, c := range data {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	return lower
+}
+
+// offsets returns the offsets of the string matches, sorted and unique
+func (ctx *scanContext) offsets(s *yaraString) []int {
+	if offsets, ok := ctx.matches[s]; ok {
+		return offsets
+	}
+	var offsets []int
+	for _, pattern := range s.patterns {
+		offsets = mergeOffsets(offsets, pattern(ctx))
+	}
+	ctx.matches[s] = offsets
+	return offsets
+}
+
+func mergeOffsets(a, b []int) []int {
+	if len(a) == 0 {
+		return b
+	}
+	merged := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i] < b[j]):
+			merged = append(merged, a[i])
+			i++
+		case i == len(a) || b[j] < a[i]:
+			merged = append(merged, b[j])
+			j++
+		default:
+			merged = append(merged, a[i])
+			i++
+			j++
+		}
+	}
+	return merged
+}
+
+// scanRules evaluates the rules on the data and returns the matching public rules, it fails when the scan does not
+// complete before the deadline
+func scanRules(rules []*yaraRule, data []byte, deadline time.Time) ([]*yaraRule, error) {
+	ctx := newScanContext(data, deadline)
+	var matched []*yaraRule
+	for _, rule := range rules {
+		result := rule.condition.eval(ctx) != 0
+		if ctx.err != nil {
+			return nil, ctx.err
+		}
+		ctx.results[rule] = result
+		if result && !rule.private {
+			matched = append(matched, rule)
+		}
+	}
+	return matched, nil
+}
+
+// yaraExpr is a condition expression, booleans are evaluated as 1 and 0
+type yaraExpr interface {
+	eval(ctx *scanContext) int64
+}
+
+type exprFunc func(ctx *scanContext) int64
+
+func (f exprFunc) eval(ctx *scanContext) int64 {
+	return f(ctx)
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// parseYaraRules parses the rules of a single source, rules can reference the known rules of previously parsed sources.
+// The rules that fail to parse are skipped, the parsed rules are returned along with the errors of the skipped rules.
+func parseYaraRules(source string, known map[string]*yaraRule) ([]*yaraRule, error) {
+	p := &yaraParser{lexer: &yaraLexer{src: source}, known: known}
+	var rules []*yaraRule
+	var errs []error
+	for {
+		rule, err := p.parseNextRule()
+		if err != nil {
+			errs = append(errs, err)
+			p.lexer.skipToNextRule()
+			continue
+		}
+		if rule == nil {
+			return rules, errors.Join(errs...)
+		}
+		if _, ok := known[rule.name]; ok {
+			errs = append(errs, fmt.Errorf("duplicate rule %q", rule.name))
+			continue
+		}
+		known[rule.name] = rule
+		rules = append(rules, rule)
+	}
+}
+
+// parseNextRule parses the next rule of the source, it returns nil at the end of the source
+func (p *yaraParser) parseNextRule() (*yaraRule, error) {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok.kind == tokEOF {
+		return nil, nil
+	}
+	if tok.kind != tokIdent {
+		return nil, p.errorf(tok, "expected rule, got %q", tok.text)
+	}
+	rule := &yaraRule{meta: make(map[string]string)}
+	global := false
+	switch tok.text {
+	case "import", "include":
+		// the path is consumed so parsing resumes at the next rule
+		_, _ = p.lexer.next()
+		return nil, p.errorf(tok, "%s is not supported", tok.text)
+	case "global", "private":
+		global = tok.text == "global"
+		rule.private = tok.text == "private"
+		if tok, err = p.lexer.next(); err != nil {
+			return nil, err
+		}
+	}
+	if tok.kind != tokIdent || tok.text != "rule" {
+		return nil, p.errorf(tok, "expected rule, got %q", tok.text)
+	}
+	if err := p.parseRule(rule); err != nil {
+		return nil, err
+	}
+	// global rules are parsed before they are rejected, so parsing resumes after them
+	if global {
+		return nil, p.errorf(tok, "global rules are not supported")
+	}
+	return rule, nil
+}
+
+type yaraParser struct {
+	lexer *yaraLexer
+	known map[string]*yaraRule
+	rule  *yaraRule
+}
+
+func (p *yaraParser) errorf(tok yaraToken, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", tok.line, fmt.Sprintf(format, args...))
+}
+
+func (p *yaraParser) expect(kind tokenKind, text string) (yaraToken, error) {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return tok, err
+	}
+	if tok.kind != kind || (text != "" && tok.text != text) {
+		if text == "" {
+			text = kind.String()
+		}
+		return tok, p.errorf(tok, "expected %s, got %q", text, tok.text)
+	}
+	return tok, nil
+}
+
+func (p *yaraParser) parseRule(rule *yaraRule) error {
+	p.rule = rule
+	name, err := p.expect(tokIdent, "")
+	if err != nil {
+		return err
+	}
+	rule.name = name.text
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	if tok.text == ":" {
+		for {
+			if tok, err = p.lexer.next(); err != nil {
+				return err
+			}
+			if tok.kind != tokIdent {
+				break
+			}
+			rule.tags = append(rule.tags, tok.text)
+		}
+	}
+	if tok.text != "{" {
+		return p.errorf(tok, "expected {, got %q", tok.text)
+	}
+
+	section, err := p.expect(tokIdent, "")
+	if err != nil {
+		return err
+	}
+	if section.text == "meta" {
+		if _, err := p.expect(tokPunct, ":"); err != nil {
+			return err
+		}
+		if section, err = p.parseMeta(rule); err != nil {
+			return err
+		}
+	}
+	if section.text == "strings" {
+		if _, err := p.expect(tokPunct, ":"); err != nil {
+			return err
+		}
+		if section, err = p.parseStrings(rule); err != nil {
+			return err
+		}
+	}
+	if section.text != "condition" {
+		return p.errorf(section, "expected condition, got %q", section.text)
+	}
+	if _, err := p.expect(tokPunct, ":"); err != nil {
+		return err
+	}
+	if rule.condition, err = p.parseOr(); err != nil {
+		return err
+	}
+	_, err = p.expect(tokPunct, "}")
+	return err
+}
+
+// parseMeta parses the meta section and returns the next section
+func (p *yaraParser) parseMeta(rule *yaraRule) (yaraToken, error) {
+	for {
+		key, err := p.expect(tokIdent, "")
+		if err != nil {
+			return key, err
+		}
+		if next, _ := p.lexer.peek(); next.text != "=" {
+			return key, nil
+		}
+		_, _ = p.lexer.next()
+		value, err := p.lexer.next()
+		if err != nil {
+			return value, err
+		}
+		if value.kind != tokString && value.kind != tokNumber && value.kind != tokIdent {
+			return value, p.errorf(value, "invalid meta value %q", value.text)
+		}
+		rule.meta[key.text] = value.text
+	}
+}
+
+// parseStrings parses the strings section and returns the next section
+func (p *yaraParser) parseStrings(rule *yaraRule) (yaraToken, error) {
+	for {
+		tok, err := p.lexer.next()
+		if err != nil {
+			return tok, err
+		}
+		if tok.kind != tokStringID {
+			return tok, nil
+		}
+		s := &yaraString{id: tok.text}
+		if s.id == "" || strings.HasSuffix(s.id, "*") {
+			return tok, p.errorf(tok, "invalid string identifier $%s", s.id)
+		}
+		for _, other := range rule.strings {
+			if other.id == s.id {
+				return tok, p.errorf(tok, "duplicate string $%s", s.id)
+			}
+		}
+		if _, err := p.expect(tokPunct, "="); err != nil {
+			return tok, err
+		}
+		value, err := p.lexer.nextStringValue()
+		if err != nil {
+			return value, err
+		}
+		if err := p.parseStringValue(s, value); err != nil {
+			return value, err
+		}
+		rule.strings = append(rule.strings, s)
+	}
+}
+
+func (p *yaraParser) parseStringValue(s *yaraString, value yaraToken) error {
+	modifiers := map[string]bool{}
+loop:
+	for {
+		next, err := p.lexer.peek()
+		if err != nil {
+			return err
+		}
+		if next.kind != tokIdent {
+			break
+		}
+		switch next.text {
+		case "nocase", "wide", "ascii", "fullword", "private":
+			modifiers[next.text] = true
+		case "xor", "base64", "base64wide":
+			return p.errorf(next, "the %s modifier is not supported", next.text)
+		default:
+			// the next section
+			break loop
+		}
+		_, _ = p.lexer.next()
+	}
+	s.private = modifiers["private"]
+
+	switch value.kind {
+	case tokString:
+		text := []byte(value.text)
+		if modifiers["nocase"] {
+			text = asciiLower(text)
+		}
+		if !modifiers["wide"] || modifiers["ascii"] {
+			s.patterns = append(s.patterns, literalPattern(text, modifiers["nocase"], modifiers["fullword"], 1))
+		}
+		if modifiers["wide"] {
+			wide := make([]byte, 0, 2*len(text))
+			for _, b := range text {
+				wide = append(wide, b, 0)
+			}
+			s.patterns = append(s.patterns, literalPattern(wide, modifiers["nocase"], modifiers["fullword"], 2))
+		}
+	case tokHex:
+		if len(modifiers) > 0 && !(len(modifiers) == 1 && modifiers["private"]) {
+			return p.errorf(value, "hex strings only support the private modifier")
+		}
+		pattern, err := parseHexPattern(value.text)
+		if err != nil {
+			return p.errorf(value, "%v", err)
+		}
+		s.patterns = append(s.patterns, pattern.offsets)
+	case tokRegex:
+		if modifiers["wide"] || modifiers["fullword"] {
+			return p.errorf(value, "regular expressions do not support the wide and fullword modifiers")
+		}
+		expression := value.text
+		if modifiers["nocase"] {
+			expression = "(?i)" + expression
+		}
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			return p.errorf(value, "invalid regular expression: %v", err)
+		}
+		s.patterns = append(s.patterns, func(ctx *scanContext) []int {
+			var offsets []int
+			for _, match := range re.FindAllIndex(ctx.data, -1) {
+				offsets = append(offsets, match[0])
+			}
+			return offsets
+		})
+	default:
+		return p.errorf(value, "invalid string value %q", value.text)
+	}
+	return nil
+}
+
+// literalPattern finds the occurrences of text, charSize is the size of a character used to check the fullword boundaries
+func literalPattern(text []byte, nocase bool, fullword bool, charSize int) func(ctx *scanContext) []int {
+	return func(ctx *scanContext) []int {
+		data := ctx.data
+		if nocase {
+			data = ctx.lowerData()
+		}
+		var offsets []int
+		for start := 0; start <= len(data)-len(text); {
+			i := bytes.Index(data[start:], text)
+			if i < 0 {
+				break
+			}
+			offset := start + i
+			if ctx.expired() {
+				return nil
+			}
+			if !fullword || isWordBoundary(data, offset-charSize) && isWordBoundary(data, offset+len(text)) {
+				offsets = append(offsets, offset)
+			}
+			start = offset + 1
+		}
+		return offsets
+	}
+}
+
+func isWordBoundary(data []byte, i int) bool {
+	if i < 0 || i >= len(data) {
+		return true
+	}
+	c := rune(data[i])
+	return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+}
+
+type hexElement struct {
+	value, mask byte
+	// jump elements match between min and max bytes
+	jump     bool
+	min, max int
+}
+
+type hexPattern []hexElement
+
+func parseHexPattern(text string) (hexPattern, error) {
+	var pattern hexPattern
+	fields := strings.Fields(strings.NewReplacer("[", " [", "]", "] ").Replace(text))
+	for _, field := range fields {
+		if strings.HasPrefix(field, "[") {
+			jump, err := parseHexJump(strings.Trim(field, "[]"))
+			if err != nil {
+				return nil, err
+			}
+			if len(pattern) == 0 {
+				return nil, fmt.Errorf("hex strings can not start with a jump")
+			}
+			pattern = append(pattern, jump)
+			continue
+		}
+		if strings.ContainsAny(field, "()|") {
+			return nil, fmt.Errorf("alternatives in hex strings are not supported")
+		}
+		if len(field)%2 != 0 {
+			return nil, fmt.Errorf("invalid hex string %q", text)
+		}
+		for i := 0; i < len(field); i += 2 {
+			element, err := parseHexByte(field[i : i+2])
+			if err != nil {
+				return nil, err
+			}
+			pattern = append(pattern, element)
+		}
+	}
+	if len(pattern) == 0 {
+		return nil, fmt.Errorf("empty hex string")
+	}
+	if pattern[len(pattern)-1].jump {
+		return nil, fmt.Errorf("hex strings can not end with a jump")
+	}
+	return pattern, nil
+}
+
+func parseHexByte(s string) (hexElement, error) {
+	var element hexElement
+	for i, c := range strings.ToUpper(s) {
+		shift := uint(4 * (1 - i))
+		if c == '?' {
+			continue
+		}
+		nibble, err := strconv.ParseUint(string(c), 16, 8)
+		if err != nil {
+			return element, fmt.Errorf("invalid hex byte %q", s)
+		}
+		element.value |= byte(nibble) << shift
+		element.mask |= 0xF << shift
+	}
+	return element, nil
+}
+
+func parseHexJump(s string) (hexElement, error) {
+	element := hexElement{jump: true}
+	low, high, isRange := strings.Cut(s, "-")
+	var err error
+	if element.min, err = strconv.Atoi(strings.TrimSpace(low)); err != nil && strings.TrimSpace(low) != "" {
+		return element, fmt.Errorf("invalid jump [%s]", s)
+	}
+	element.max = element.min
+	if isRange {
+		element.max = element.min + maxHexJumpSpan
+		if high = strings.TrimSpace(high); high != "" {
+			if element.max, err = strconv.Atoi(high); err != nil || element.max < element.min {
+				return element, fmt.Errorf("invalid jump [%s]", s)
+			}
+		}
+	}
+	element.max = min(element.max, element.min+maxHexJumpSpan)
+	return element, nil
+}
+
+func (pattern hexPattern) offsets(ctx *scanContext) []int {
+	var offsets []int
+	first := pattern[0]
+	for offset := 0; offset < len(ctx.data); offset++ {
+		if first.mask == 0xFF {
+			i := bytes.IndexByte(ctx.data[offset:], first.value)
+			if i < 0 {
+				break
+			}
+			offset += i
+		}
+		if pattern.matchAt(ctx, offset) {
+			offsets = append(offsets, offset)
+		}
+		if ctx.err != nil {
+			return nil
+		}
+	}
+	return offsets
+}
+
+// hexSpan is a range of candidate positions, both ends included
+type hexSpan struct {
+	low, high int
+}
+
+// appendHexSpan appends a span to the sorted spans, merging it with the last span when they overlap or touch
+func appendHexSpan(spans []hexSpan, low, high int) []hexSpan {
+	if low > high {
+		return spans
+	}
+	if n := len(spans); n > 0 && low <= spans[n-1].high+1 {
+		spans[n-1].high = max(spans[n-1].high, high)
+		return spans
+	}
+	return append(spans, hexSpan{low: low, high: high})
+}
+
+// matchAt reports whether the pattern matches at pos. The candidate positions of each element are tracked as merged
+// spans, so every element is matched at most once per position whatever the number of jumps.
+func (pattern hexPattern) matchAt(ctx *scanContext, pos int) bool {
+	data := ctx.data
+	spans := []hexSpan{{low: pos, high: pos}}
+	for _, element := range pattern {
+		if ctx.expired() {
+			return false
+		}
+		var next []hexSpan
+		for _, span := range spans {
+			if element.jump {
+				next = appendHexSpan(next, span.low+element.min, min(span.high+element.max, len(data)))
+				continue
+			}
+			for i := span.low; i <= span.high && i < len(data); i++ {
+				if data[i]&element.mask == element.value {
+					next = appendHexSpan(next, i+1, i+1)
+				}
+			}
+		}
+		if len(next) == 0 {
+			return false
+		}
+		spans = next
+	}
+	return true
+}
+
+func (p *yaraParser) parseOr() (yaraExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if next, _ := p.lexer.peek(); next.kind != tokIdent || next.text != "or" {
+			return left, nil
+		}
+		_, _ = p.lexer.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = exprFunc(func(ctx *scanContext) int64 { return boolToInt(l.eval(ctx) != 0 || right.eval(ctx) != 0) })
+	}
+}
+
+func (p *yaraParser) parseAnd() (yaraExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if next, _ := p.lexer.peek(); next.kind != tokIdent || next.text != "and" {
+			return left, nil
+		}
+		_, _ = p.lexer.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = exprFunc(func(ctx *scanContext) int64 { return boolToInt(l.eval(ctx) != 0 && right.eval(ctx) != 0) })
+	}
+}
+
+func (p *yaraParser) parseNot() (yaraExpr, error) {
+	if next, _ := p.lexer.peek(); next.kind == tokIdent && next.text == "not" {
+		_, _ = p.lexer.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return exprFunc(func(ctx *scanContext) int64 { return boolToInt(operand.eval(ctx) == 0) }), nil
+	}
+	return p.parseComparison()
+}
+
+func (p *yaraParser) parseComparison() (yaraExpr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	next, _ := p.lexer.peek()
+	var compare func(a, b int64) bool
+	switch next.text {
+	case "==":
+		compare = func(a, b int64) bool { return a == b }
+	case "!=":
+		compare = func(a, b int64) bool { return a != b }
+	case "<":
+		compare = func(a, b int64) bool { return a < b }
+	case "<=":
+		compare = func(a, b int64) bool { return a <= b }
+	case ">":
+		compare = func(a, b int64) bool { return a > b }
+	case ">=":
+		compare = func(a, b int64) bool { return a >= b }
+	default:
+		return left, nil
+	}
+	if next.kind != tokPunct {
+		return left, nil
+	}
+	_, _ = p.lexer.next()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return exprFunc(func(ctx *scanContext) int64 { return boolToInt(compare(left.eval(ctx), right.eval(ctx))) }), nil
+}
+
+func (p *yaraParser) parsePrimary() (yaraExpr, error) {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.kind {
+	case tokPunct:
+		if tok.text != "(" {
+			break
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokPunct, ")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case tokNumber:
+		value, err := parseYaraNumber(tok.text)
+		if err != nil {
+			return nil, p.errorf(tok, "%v", err)
+		}
+		if next, _ := p.lexer.peek(); next.kind == tokIdent && next.text == "of" {
+			return p.parseOf(func(matched, total int) bool { return int64(matched) >= value })
+		}
+		return exprFunc(func(*scanContext) int64 { return value }), nil
+	case tokStringID:
+		s, err := p.lookupString(tok)
+		if err != nil {
+			return nil, err
+		}
+		if next, _ := p.lexer.peek(); next.kind == tokIdent && next.text == "at" {
+			_, _ = p.lexer.next()
+			offset, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return exprFunc(func(ctx *scanContext) int64 {
+				at := offset.eval(ctx)
+				for _, o := range ctx.offsets(s) {
+					if int64(o) == at {
+						return 1
+					}
+				}
+				return 0
+			}), nil
+		}
+		return exprFunc(func(ctx *scanContext) int64 { return boolToInt(len(ctx.offsets(s)) > 0) }), nil
+	case tokCount:
+		s, err := p.lookupString(tok)
+		if err != nil {
+			return nil, err
+		}
+		return exprFunc(func(ctx *scanContext) int64 { return int64(len(ctx.offsets(s))) }), nil
+	case tokIdent:
+		switch tok.text {
+		case "true", "false":
+			value := boolToInt(tok.text == "true")
+			return exprFunc(func(*scanContext) int64 { return value }), nil
+		case "filesize":
+			return exprFunc(func(ctx *scanContext) int64 { return int64(len(ctx.data)) }), nil
+		case "any":
+			return p.parseOf(func(matched, total int) bool { return matched > 0 })
+		case "all":
+			return p.parseOf(func(matched, total int) bool { return matched == total })
+		case "none":
+			return p.parseOf(func(matched, total int) bool { return matched == 0 })
+		case "uint8", "uint16", "uint32", "uint8be", "uint16be", "uint32be":
+			return p.parseIntFunction(tok.text)
+		}
+		if rule, ok := p.known[tok.text]; ok {
+			return exprFunc(func(ctx *scanContext) int64 { return boolToInt(ctx.results[rule]) }), nil
+		}
+		return nil, p.errorf(tok, "undefined identifier %q", tok.text)
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+func (p *yaraParser) lookupString(tok yaraToken) (*yaraString, error) {
+	for _, s := range p.rule.strings {
+		if s.id == tok.text {
+			return s, nil
+		}
+	}
+	return nil, p.errorf(tok, "undefined string $%s", tok.text)
+}
+
+// parseOf parses the string set of an of expression
+func (p *yaraParser) parseOf(quantifier func(matched, total int) bool) (yaraExpr, error) {
+	if _, err := p.expect(tokIdent, "of"); err != nil {
+		return nil, err
+	}
+	var set []*yaraString
+	tok, err := p.lexer.next()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case tok.kind == tokIdent && tok.text == "them":
+		set = p.rule.strings
+	case tok.text == "(":
+		for {
+			id, err := p.expect(tokStringID, "")
+			if err != nil {
+				return nil, err
+			}
+			prefix, wildcard := strings.CutSuffix(id.text, "*")
+			found := false
+			for _, s := range p.rule.strings {
+				if s.id == id.text || wildcard && strings.HasPrefix(s.id, prefix) {
+					set = append(set, s)
+					found = true
+				}
+			}
+			if !found {
+				return nil, p.errorf(id, "undefined string $%s", id.text)
+			}
+			sep, err := p.lexer.next()
+			if err != nil {
+				return nil, err
+			}
+			if sep.text == ")" {
+				break
+			}
+			if sep.text != "," {
+				return nil, p.errorf(sep, "expected , or ), got %q", sep.text)
+			}
+		}
+	default:
+		return nil, p.errorf(tok, "expected them or a string set, got %q", tok.text)
+	}
+	if len(set) == 0 {
+		return nil, p.errorf(tok, "empty string set")
+	}
+	return exprFunc(func(ctx *scanContext) int64 {
+		matched := 0
+		for _, s := range set {
+			if len(ctx.offsets(s)) > 0 {
+				matched++
+			}
+		}
+		return boolToInt(quantifier(matched, len(set)))
+	}), nil
+}
+
+func (p *yaraParser) parseIntFunction(name string) (yaraExpr, error) {
+	if _, err := p.expect(tokPunct, "("); err != nil {
+		return nil, err
+	}
+	offset, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokPunct, ")"); err != nil {
+		return nil, err
+	}
+	size, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "uint"), "be"))
+	size /= 8
+	var order binary.ByteOrder = binary.LittleEndian
+	if strings.HasSuffix(name, "be") {
+		order = binary.BigEndian
+	}
+	return exprFunc(func(ctx *scanContext) int64 {
+		at := offset.eval(ctx)
+		if at < 0 || at+int64(size) > int64(len(ctx.data)) {
+			return -1
+		}
+		b := ctx.data[at : at+int64(size)]
+		switch size {
+		case 1:
+			return int64(b[0])
+		case 2:
+			return int64(order.Uint16(b))
+		default:
+			return int64(order.Uint32(b))
+		}
+	}), nil
+}
+
+func parseYaraNumber(text string) (int64, error) {
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(text, "KB"):
+		multiplier, text = 1024, strings.TrimSuffix(text, "KB")
+	case strings.HasSuffix(text, "MB"):
+		multiplier, text = 1024*1024, strings.TrimSuffix(text, "MB")
+	}
+	value, err := strconv.ParseInt(text, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", text)
+	}
+	return value * multiplier, nil
+}
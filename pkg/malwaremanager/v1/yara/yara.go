@@ -0,0 +1,206 @@
+package malwaremanager
+
+import (
+	"fmt"
+	"io"
+	"node-agent/pkg/malwaremanager"
+	mmtypes "node-agent/pkg/malwaremanager/v1/types"
+	"node-agent/pkg/ruleengine"
+	nautils "node-agent/pkg/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	humanize "github.com/dustin/go-humanize"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	logger "github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+)
+
+// YaraScanner scans the executed and opened files with YARA rules, in process
+type YaraScanner struct {
+	cfg   *YaraConfig
+	rules []*yaraRule
+}
+
+var _ malwaremanager.HashMalwareScanner = (*YaraScanner)(nil)
+
+func CreateYaraScanner(cfg *YaraConfig) (*YaraScanner, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	rules, err := loadYaraRules(cfg.RulesPath)
+	if err != nil {
+		return nil, err
+	}
+	logger.L().Info("loaded YARA rules", helpers.String("path", cfg.RulesPath), helpers.Int("rules", len(rules)))
+	return &YaraScanner{
+		cfg:   cfg,
+		rules: rules,
+	}, nil
+}
+
+// loadYaraRules parses the .yar and .yara files of the directory, in lexical order. The rules must be in the subset
+// of the YARA language supported by the scanner, see rules.go
+func loadYaraRules(path string) ([]*yaraRule, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YARA rules directory: %w", err)
+	}
+	known := make(map[string]*yaraRule)
+	var rules []*yaraRule
+	for _, entry := range entries {
+		if entry.IsDir() || (filepath.Ext(entry.Name()) != ".yar" && filepath.Ext(entry.Name()) != ".yara") {
+			continue
+		}
+		source, err := os.ReadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read YARA rules file %s: %w", entry.Name(), err)
+		}
+		// a file outside of the supported subset fails the scanner, rather than silently not scanning with its rules
+		fileRules, err := parseYaraRules(string(source), known)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YARA rules file %s: %w", entry.Name(), err)
+		}
+		rules = append(rules, fileRules...)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no YARA rules found in %s", path)
+	}
+	return rules, nil
+}
+
+// MaxHashedFileSize is the size of the largest scanned file, the alerts hold the hashes of the matched files
+func (s *YaraScanner) MaxHashedFileSize() int64 {
+	return s.cfg.MaxFileSize
+}
+
+func (s *YaraScanner) Scan(eventType nautils.EventType, event interface{}, containerPid uint32, hashes *malwaremanager.FileHashes) malwaremanager.MalwareResult {
+	if eventType != nautils.ExecveEventType && eventType != nautils.OpenEventType {
+		return nil
+	}
+	hostFilePath, err := nautils.GetHostFilePathFromEvent(event, containerPid)
+	if err != nil {
+		logger.L().Debug("Error getting host file path", helpers.Error(err))
+		return nil
+	}
+	matched, err := s.scanFile(hostFilePath)
+	if err != nil {
+		logger.L().Debug("Error scanning file", helpers.String("path", hostFilePath), helpers.Error(err))
+		return nil
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	switch e := event.(type) {
+	case *tracerexectype.Event:
+		commandLine := fmt.Sprintf("%s %s", nautils.GetExecPathFromEvent(e), strings.Join(nautils.GetExecArgsFromEvent(e), " "))
+		return createMalwareResult(matched, hashes, e.Event, apitypes.Process{
+			Comm:       e.Comm,
+			Path:       nautils.GetExecPathFromEvent(e),
+			Gid:        &e.Gid,
+			PID:        e.Pid,
+			Uid:        &e.Uid,
+			UpperLayer: e.UpperLayer,
+			PPID:       e.Ppid,
+			Pcomm:      e.Pcomm,
+			Cwd:        e.Cwd,
+			Hardlink:   e.ExePath,
+			Cmdline:    commandLine,
+		})
+	case *traceropentype.Event:
+		return createMalwareResult(matched, hashes, e.Event, apitypes.Process{
+			Comm: e.Comm,
+			Path: e.FullPath,
+			Gid:  &e.Gid,
+			PID:  e.Pid,
+			Uid:  &e.Uid,
+		})
+	}
+	return nil
+}
+
+// scanFile returns the rules matching the file
+func (s *YaraScanner) scanFile(path string) ([]*yaraRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() || info.Size() > s.cfg.MaxFileSize {
+		return nil, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(file, s.cfg.MaxFileSize))
+	if err != nil {
+		return nil, err
+	}
+	return scanRules(s.rules, data, time.Now().Add(s.cfg.ScanTimeout))
+}
+
+// createMalwareResult creates the alert of the matched rules, the hashes are left to the enrichment when they are not known
+func createMalwareResult(matched []*yaraRule, hashes *malwaremanager.FileHashes, triggerEvent igtypes.Event, process apitypes.Process) malwaremanager.MalwareResult {
+	if hashes == nil {
+		hashes = &malwaremanager.FileHashes{}
+	}
+	names := make([]string, 0, len(matched))
+	descriptions := make([]string, 0, len(matched))
+	meta := make(map[string]map[string]string, len(matched))
+	var tags []string
+	severity := -1
+	for _, rule := range matched {
+		names = append(names, rule.name)
+		description := rule.name
+		if d, ok := rule.meta["description"]; ok {
+			description = fmt.Sprintf("%s (%s)", rule.name, d)
+		}
+		descriptions = append(descriptions, description)
+		meta[rule.name] = rule.meta
+		tags = append(tags, rule.tags...)
+		if ruleSeverity, err := ruleengine.ParseSeverity(rule.meta["severity"]); err == nil && ruleSeverity > severity {
+			severity = ruleSeverity
+		}
+	}
+	if severity < 0 {
+		severity = ruleengine.RulePriorityCritical
+	}
+	sort.Strings(tags)
+	description := fmt.Sprintf("Matched YARA rules: %s", strings.Join(descriptions, ", "))
+
+	return &mmtypes.GenericMalwareResult{
+		BasicRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName: names[0],
+			Arguments: map[string]interface{}{
+				"yaraRules": names,
+				"yaraTags":  tags,
+				"yaraMeta":  meta,
+			},
+			InfectedPID:    process.PID,
+			FixSuggestions: malwaremanager.FixSuggestions,
+			SHA1Hash:       hashes.SHA1,
+			SHA256Hash:     hashes.SHA256,
+			MD5Hash:        hashes.MD5,
+			Severity:       severity,
+			Size:           humanize.IBytes(uint64(hashes.Size)),
+			Timestamp:      time.Unix(0, int64(triggerEvent.Timestamp)),
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: process,
+			ContainerID: triggerEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: triggerEvent,
+		MalwareRuntimeAlert: apitypes.MalwareAlert{
+			MalwareDescription: description,
+		},
+		RuntimeAlertK8sDetails: malwaremanager.GetRuntimeAlertK8sDetails(triggerEvent),
+	}
+}
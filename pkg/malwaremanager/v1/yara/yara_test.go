@@ -0,0 +1,100 @@
+package malwaremanager
+
+import (
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+	"os"
+	"path/filepath"
+	"testing"
+
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testYaraRules = `
+rule xmrig_miner : miner {
+	meta:
+		description = "XMRig cryptocurrency miner"
+		severity = "high"
+	strings:
+		$a = "stratum+tcp://" nocase
+		$b = "xmrig"
+	condition:
+		all of them
+}
+`
+
+func createTestYaraScanner(t *testing.T) *YaraScanner {
+	rulesPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(rulesPath, "miners.yar"), []byte(testYaraRules), 0644))
+	// other files are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(rulesPath, "README.md"), []byte("not a rule"), 0644))
+	scanner, err := CreateYaraScanner(&YaraConfig{RulesPath: rulesPath})
+	require.NoError(t, err)
+	return scanner
+}
+
+func TestCreateYaraScannerErrors(t *testing.T) {
+	_, err := CreateYaraScanner(&YaraConfig{})
+	assert.Error(t, err)
+
+	rulesPath := t.TempDir()
+	_, err = CreateYaraScanner(&YaraConfig{RulesPath: rulesPath})
+	assert.Error(t, err, "expected an error when there are no rules")
+
+	require.NoError(t, os.WriteFile(filepath.Join(rulesPath, "invalid.yara"), []byte(`rule r {`), 0644))
+	_, err = CreateYaraScanner(&YaraConfig{RulesPath: rulesPath})
+	assert.Error(t, err)
+
+	// a file that fails to parse fails the scanner, even next to valid rules
+	require.NoError(t, os.WriteFile(filepath.Join(rulesPath, "miners.yar"), []byte(testYaraRules), 0644))
+	_, err = CreateYaraScanner(&YaraConfig{RulesPath: rulesPath})
+	assert.ErrorContains(t, err, "invalid.yara")
+
+	// so does a file outside of the supported subset
+	require.NoError(t, os.Remove(filepath.Join(rulesPath, "invalid.yara")))
+	require.NoError(t, os.WriteFile(filepath.Join(rulesPath, "pe.yar"), []byte("import \"pe\"\nrule r { condition: pe.is_pe }"), 0644))
+	_, err = CreateYaraScanner(&YaraConfig{RulesPath: rulesPath})
+	assert.ErrorContains(t, err, "pe.yar")
+
+	require.NoError(t, os.Remove(filepath.Join(rulesPath, "pe.yar")))
+	scanner, err := CreateYaraScanner(&YaraConfig{RulesPath: rulesPath})
+	require.NoError(t, err)
+	assert.Len(t, scanner.rules, 1)
+}
+
+func TestYaraScannerScan(t *testing.T) {
+	scanner := createTestYaraScanner(t)
+	filePath := filepath.Join(t.TempDir(), "miner")
+	require.NoError(t, os.WriteFile(filePath, []byte("xmrig -o STRATUM+TCP://pool:3333"), 0755))
+	hashes, err := malwaremanager.HashFile(filePath, scanner.MaxHashedFileSize())
+	require.NoError(t, err)
+
+	event := &traceropentype.Event{Comm: "sh", Pid: 42, FullPath: filePath}
+	// the files are read through /proc/<pid>/root, our own root is the container root in the test
+	result := scanner.Scan(utils.OpenEventType, event, uint32(os.Getpid()), hashes)
+	require.NotNil(t, result)
+	assert.Equal(t, "xmrig_miner", result.GetBasicRuntimeAlert().AlertName)
+	assert.Equal(t, ruleengine.RulePriorityHigh, result.GetBasicRuntimeAlert().Severity)
+	assert.Equal(t, []string{"xmrig_miner"}, result.GetBasicRuntimeAlert().Arguments["yaraRules"])
+	assert.Equal(t, []string{"miner"}, result.GetBasicRuntimeAlert().Arguments["yaraTags"])
+	assert.Equal(t, "Matched YARA rules: xmrig_miner (XMRig cryptocurrency miner)", result.GetMalwareRuntimeAlert().MalwareDescription)
+	assert.Equal(t, hashes.SHA256, result.GetBasicRuntimeAlert().SHA256Hash)
+	assert.Equal(t, hashes.MD5, result.GetBasicRuntimeAlert().MD5Hash)
+	assert.Equal(t, uint32(42), result.GetRuntimeProcessDetails().ProcessTree.PID)
+
+	require.NoError(t, os.WriteFile(filePath, []byte("clean"), 0755))
+	assert.Nil(t, scanner.Scan(utils.OpenEventType, event, uint32(os.Getpid()), nil))
+}
+
+func TestYaraScannerSkipsLargeFiles(t *testing.T) {
+	scanner := createTestYaraScanner(t)
+	scanner.cfg.MaxFileSize = 8
+	filePath := filepath.Join(t.TempDir(), "miner")
+	require.NoError(t, os.WriteFile(filePath, []byte("xmrig -o stratum+tcp://pool:3333"), 0755))
+
+	assert.Nil(t, scanner.Scan(utils.OpenEventType, &traceropentype.Event{FullPath: filePath}, uint32(os.Getpid()), nil))
+	assert.Nil(t, scanner.Scan(utils.DnsEventType, &traceropentype.Event{FullPath: filePath}, uint32(os.Getpid()), nil))
+}
@@ -2,6 +2,24 @@ package metricsmanager
 
 import "node-agent/pkg/utils"
 
+// reasons reported with the dropped events
+const (
+	// DroppedByTracer is reported when a tracer lost events, like when its perf buffer was full
+	DroppedByTracer = "tracer"
+	// DroppedByQueue is reported when an event is dropped because the queue of its worker pool is full
+	DroppedByQueue = "queue"
+)
+
+// reasons reported with the filtered events
+const (
+	// FilteredByPath is reported when the path of an event has an ignored prefix
+	FilteredByPath = "path"
+	// FilteredAsDuplicate is reported when an identical event was seen during the dedup window
+	FilteredAsDuplicate = "duplicate"
+	// FilteredBySampling is reported when an event is not sampled
+	FilteredBySampling = "sampling"
+)
+
 // MetricsManager is an interface for reporting metrics
 type MetricsManager interface {
 	Start()
@@ -10,4 +28,10 @@ type MetricsManager interface {
 	ReportFailedEvent()
 	ReportRuleProcessed(ruleID string)
 	ReportRuleAlert(ruleID string)
+	ReportExporterQueueDepth(exporter string, depth int)
+	ReportExporterAlertDropped(exporter string)
+	ReportMalwareScanCache(hit bool)
+	ReportDroppedEvent(eventType utils.EventType, reason string)
+	ReportWorkerPool(eventType utils.EventType, workers int, queueDepth int)
+	ReportFilteredEvent(eventType utils.EventType, reason string)
 }
@@ -14,6 +14,14 @@ type MetricsMock struct {
 	RuleProcessedCounter maps.SafeMap[string, int]
 	RuleAlertCounter     maps.SafeMap[string, int]
 	EventCounter         maps.SafeMap[utils.EventType, int]
+	ExporterQueueDepth   maps.SafeMap[string, int]
+	ExporterDropCounter  maps.SafeMap[string, int]
+	MalwareCacheHits     atomic.Int32
+	MalwareCacheMisses   atomic.Int32
+	DroppedEventCounter  maps.SafeMap[string, int]
+	WorkerPoolSize       maps.SafeMap[utils.EventType, int]
+	WorkerPoolQueueDepth maps.SafeMap[utils.EventType, int]
+	FilteredEventCounter maps.SafeMap[string, int]
 }
 
 func NewMetricsMock() *MetricsMock {
@@ -30,6 +38,14 @@ func (m *MetricsMock) Destroy() {
 	m.RuleProcessedCounter.Clear()
 	m.RuleAlertCounter.Clear()
 	m.EventCounter.Clear()
+	m.ExporterQueueDepth.Clear()
+	m.ExporterDropCounter.Clear()
+	m.MalwareCacheHits.Store(0)
+	m.MalwareCacheMisses.Store(0)
+	m.DroppedEventCounter.Clear()
+	m.WorkerPoolSize.Clear()
+	m.WorkerPoolQueueDepth.Clear()
+	m.FilteredEventCounter.Clear()
 }
 
 func (m *MetricsMock) ReportFailedEvent() {
@@ -47,3 +63,36 @@ func (m *MetricsMock) ReportRuleProcessed(ruleID string) {
 func (m *MetricsMock) ReportRuleAlert(ruleID string) {
 	m.RuleAlertCounter.Set(ruleID, m.RuleAlertCounter.Get(ruleID)+1)
 }
+
+func (m *MetricsMock) ReportExporterQueueDepth(exporter string, depth int) {
+	m.ExporterQueueDepth.Set(exporter, depth)
+}
+
+func (m *MetricsMock) ReportExporterAlertDropped(exporter string) {
+	m.ExporterDropCounter.Set(exporter, m.ExporterDropCounter.Get(exporter)+1)
+}
+
+func (m *MetricsMock) ReportMalwareScanCache(hit bool) {
+	if hit {
+		m.MalwareCacheHits.Add(1)
+	} else {
+		m.MalwareCacheMisses.Add(1)
+	}
+}
+
+// ReportDroppedEvent counts the dropped events by "<event type>/<reason>"
+func (m *MetricsMock) ReportDroppedEvent(eventType utils.EventType, reason string) {
+	key := eventType.String() + "/" + reason
+	m.DroppedEventCounter.Set(key, m.DroppedEventCounter.Get(key)+1)
+}
+
+func (m *MetricsMock) ReportWorkerPool(eventType utils.EventType, workers int, queueDepth int) {
+	m.WorkerPoolSize.Set(eventType, workers)
+	m.WorkerPoolQueueDepth.Set(eventType, queueDepth)
+}
+
+// ReportFilteredEvent counts the filtered events by "<event type>/<reason>"
+func (m *MetricsMock) ReportFilteredEvent(eventType utils.EventType, reason string) {
+	key := eventType.String() + "/" + reason
+	m.FilteredEventCounter.Set(key, m.FilteredEventCounter.Get(key)+1)
+}
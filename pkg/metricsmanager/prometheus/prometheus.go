@@ -13,7 +13,11 @@ import (
 )
 
 const (
-	prometheusRuleIdLabel = "rule_id"
+	prometheusRuleIdLabel   = "rule_id"
+	prometheusExporterLabel = "exporter"
+	prometheusResultLabel   = "result"
+	prometheusEventLabel    = "event_type"
+	prometheusReasonLabel   = "reason"
 )
 
 var _ metricsmanager.MetricsManager = (*prometheusMetric)(nil)
@@ -26,9 +30,17 @@ type prometheusMetric struct {
 	ebpfSyscallCounter    prometheus.Counter
 	ebpfCapabilityCounter prometheus.Counter
 	ebpfRandomXCounter    prometheus.Counter
+	ebpfGadgetCounter     *prometheus.CounterVec
 	ebpfFailedCounter     prometheus.Counter
 	ruleCounter           *prometheus.CounterVec
 	alertCounter          *prometheus.CounterVec
+	exporterQueueDepth    *prometheus.GaugeVec
+	exporterDropCounter   *prometheus.CounterVec
+	malwareCacheCounter   *prometheus.CounterVec
+	droppedEventCounter   *prometheus.CounterVec
+	workerPoolSize        *prometheus.GaugeVec
+	workerPoolQueueDepth  *prometheus.GaugeVec
+	filteredEventCounter  *prometheus.CounterVec
 }
 
 func NewPrometheusMetric() *prometheusMetric {
@@ -61,6 +73,10 @@ func NewPrometheusMetric() *prometheusMetric {
 			Name: "node_agent_randomx_counter",
 			Help: "The total number of randomx events received from the eBPF probe",
 		}),
+		ebpfGadgetCounter: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "node_agent_gadget_counter",
+			Help: "The total number of events received from the eBPF probes of the gadgets tracing syscalls, by event type",
+		}, []string{prometheusEventLabel}),
 		ebpfFailedCounter: promauto.NewCounter(prometheus.CounterOpts{
 			Name: "node_agent_ebpf_event_failure_counter",
 			Help: "The total number of failed events received from the eBPF probe",
@@ -73,6 +89,34 @@ func NewPrometheusMetric() *prometheusMetric {
 			Name: "node_agent_alert_counter",
 			Help: "The total number of alerts sent by the engine",
 		}, []string{prometheusRuleIdLabel}),
+		exporterQueueDepth: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "node_agent_exporter_queue_depth",
+			Help: "The number of alerts pending delivery in the exporter spool",
+		}, []string{prometheusExporterLabel}),
+		exporterDropCounter: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "node_agent_exporter_dropped_alerts_counter",
+			Help: "The total number of alerts dropped by the exporter spool",
+		}, []string{prometheusExporterLabel}),
+		malwareCacheCounter: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "node_agent_malware_scan_cache_counter",
+			Help: "The total number of malware scan verdict cache lookups, by result (hit or miss)",
+		}, []string{prometheusResultLabel}),
+		droppedEventCounter: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "node_agent_dropped_events_counter",
+			Help: "The total number of events dropped by the tracers or by the full queues of the worker pools, by event type and reason",
+		}, []string{prometheusEventLabel, prometheusReasonLabel}),
+		workerPoolSize: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "node_agent_worker_pool_size",
+			Help: "The number of workers of the worker pool of each event type",
+		}, []string{prometheusEventLabel}),
+		workerPoolQueueDepth: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "node_agent_worker_pool_queue_depth",
+			Help: "The number of events waiting for a worker of the worker pool of each event type",
+		}, []string{prometheusEventLabel}),
+		filteredEventCounter: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "node_agent_filtered_events_counter",
+			Help: "The total number of events filtered before processing, by event type and reason",
+		}, []string{prometheusEventLabel, prometheusReasonLabel}),
 	}
 }
 func (p *prometheusMetric) Start() {
@@ -92,9 +136,17 @@ func (p *prometheusMetric) Destroy() {
 	prometheus.Unregister(p.ebpfSyscallCounter)
 	prometheus.Unregister(p.ebpfCapabilityCounter)
 	prometheus.Unregister(p.ebpfRandomXCounter)
+	prometheus.Unregister(p.ebpfGadgetCounter)
 	prometheus.Unregister(p.ebpfFailedCounter)
 	prometheus.Unregister(p.ruleCounter)
 	prometheus.Unregister(p.alertCounter)
+	prometheus.Unregister(p.exporterQueueDepth)
+	prometheus.Unregister(p.exporterDropCounter)
+	prometheus.Unregister(p.malwareCacheCounter)
+	prometheus.Unregister(p.droppedEventCounter)
+	prometheus.Unregister(p.workerPoolSize)
+	prometheus.Unregister(p.workerPoolQueueDepth)
+	prometheus.Unregister(p.filteredEventCounter)
 }
 
 func (p *prometheusMetric) ReportEvent(eventType utils.EventType) {
@@ -113,6 +165,10 @@ func (p *prometheusMetric) ReportEvent(eventType utils.EventType) {
 		p.ebpfCapabilityCounter.Inc()
 	case utils.RandomXEventType:
 		p.ebpfRandomXCounter.Inc()
+	default:
+		if _, ok := utils.EventSources[eventType]; ok {
+			p.ebpfGadgetCounter.With(prometheus.Labels{prometheusEventLabel: eventType.String()}).Inc()
+		}
 	}
 }
 
@@ -127,3 +183,32 @@ func (p *prometheusMetric) ReportRuleProcessed(ruleID string) {
 func (p *prometheusMetric) ReportRuleAlert(ruleID string) {
 	p.alertCounter.With(prometheus.Labels{prometheusRuleIdLabel: ruleID}).Inc()
 }
+
+func (p *prometheusMetric) ReportExporterQueueDepth(exporter string, depth int) {
+	p.exporterQueueDepth.With(prometheus.Labels{prometheusExporterLabel: exporter}).Set(float64(depth))
+}
+
+func (p *prometheusMetric) ReportExporterAlertDropped(exporter string) {
+	p.exporterDropCounter.With(prometheus.Labels{prometheusExporterLabel: exporter}).Inc()
+}
+
+func (p *prometheusMetric) ReportMalwareScanCache(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	p.malwareCacheCounter.With(prometheus.Labels{prometheusResultLabel: result}).Inc()
+}
+
+func (p *prometheusMetric) ReportDroppedEvent(eventType utils.EventType, reason string) {
+	p.droppedEventCounter.With(prometheus.Labels{prometheusEventLabel: eventType.String(), prometheusReasonLabel: reason}).Inc()
+}
+
+func (p *prometheusMetric) ReportWorkerPool(eventType utils.EventType, workers int, queueDepth int) {
+	p.workerPoolSize.With(prometheus.Labels{prometheusEventLabel: eventType.String()}).Set(float64(workers))
+	p.workerPoolQueueDepth.With(prometheus.Labels{prometheusEventLabel: eventType.String()}).Set(float64(queueDepth))
+}
+
+func (p *prometheusMetric) ReportFilteredEvent(eventType utils.EventType, reason string) {
+	p.filteredEventCounter.With(prometheus.Labels{prometheusEventLabel: eventType.String(), prometheusReasonLabel: reason}).Inc()
+}
@@ -43,6 +43,7 @@ import (
 type NetworkManager struct {
 	cfg                      config.Config
 	clusterName              string
+	nodeName                 string // recorded on the saved network neighborhoods, the drift is reported from the node that last updated them
 	ctx                      context.Context
 	containerMutexes         storageUtils.MapMutex[string]                                 // key is k8sContainerID
 	trackedContainers        mapset.Set[string]                                            // key is k8sContainerID
@@ -60,10 +61,11 @@ type NetworkManager struct {
 
 var _ networkmanager.NetworkManagerClient = (*NetworkManager)(nil)
 
-func CreateNetworkManager(ctx context.Context, cfg config.Config, clusterName string, k8sClient k8sclient.K8sClientInterface, storageClient storage.StorageClient, dnsResolverClient dnsmanager.DNSResolver, preRunningContainerIDs mapset.Set[string], k8sObjectCache objectcache.K8sObjectCache) *NetworkManager {
+func CreateNetworkManager(ctx context.Context, cfg config.Config, clusterName, nodeName string, k8sClient k8sclient.K8sClientInterface, storageClient storage.StorageClient, dnsResolverClient dnsmanager.DNSResolver, preRunningContainerIDs mapset.Set[string], k8sObjectCache objectcache.K8sObjectCache) *NetworkManager {
 	return &NetworkManager{
 		cfg:                    cfg,
 		clusterName:            clusterName,
+		nodeName:               nodeName,
 		ctx:                    ctx,
 		dnsResolverClient:      dnsResolverClient,
 		k8sClient:              k8sClient,
@@ -287,6 +289,7 @@ func (nm *NetworkManager) saveNetworkEvents(ctx context.Context, watchedContaine
 		// 0. calculate patch
 		operations := utils.CreateNetworkPatchOperations(ingress, egress, watchedContainer.ContainerType.String(), watchedContainer.ContainerIndex)
 		operations = utils.AppendStatusAnnotationPatchOperations(operations, watchedContainer)
+		operations = utils.AppendLastUpdatedNodePatchOperations(operations, nm.nodeName)
 
 		patch, err := json.Marshal(operations)
 		if err != nil {
@@ -319,6 +322,7 @@ func (nm *NetworkManager) saveNetworkEvents(ctx context.Context, watchedContaine
 						},
 					},
 				}
+				utils.SetLastUpdatedNodeAnnotation(newObject.Annotations, nm.nodeName)
 				addContainers := func(containers []v1beta1.NetworkNeighborhoodContainer, containerNames []string) []v1beta1.NetworkNeighborhoodContainer {
 					for _, name := range containerNames {
 						containers = append(containers, v1beta1.NetworkNeighborhoodContainer{Name: name})
@@ -413,7 +417,16 @@ func (nm *NetworkManager) saveNetworkEvents(ctx context.Context, watchedContaine
 						})
 					}
 
+					// record the status and the node, creating the annotations if needed
+					if existingObject.Annotations == nil {
+						replaceOperations = append(replaceOperations, utils.PatchOperation{
+							Op:    "add",
+							Path:  "/metadata/annotations",
+							Value: map[string]string{},
+						})
+					}
 					replaceOperations = utils.AppendStatusAnnotationPatchOperations(replaceOperations, watchedContainer)
+					replaceOperations = utils.AppendLastUpdatedNodePatchOperations(replaceOperations, nm.nodeName)
 
 					patch, err := json.Marshal(replaceOperations)
 					if err != nil {
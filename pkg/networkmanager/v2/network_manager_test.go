@@ -17,6 +17,7 @@ import (
 	"node-agent/pkg/networkmanager"
 	"node-agent/pkg/objectcache"
 	"node-agent/pkg/storage"
+	"node-agent/pkg/utils"
 	"testing"
 	"time"
 )
@@ -32,7 +33,7 @@ func TestCreateNetworkManager(t *testing.T) {
 	storageClient := &storage.StorageHttpClientMock{}
 	dnsManager := &dnsmanager.DNSManagerMock{}
 	k8sObjectCacheMock := &objectcache.K8sObjectCacheMock{}
-	am := CreateNetworkManager(ctx, cfg, "cluster", k8sClient, storageClient, dnsManager, mapset.NewSet[string](), k8sObjectCacheMock)
+	am := CreateNetworkManager(ctx, cfg, "cluster", "node", k8sClient, storageClient, dnsManager, mapset.NewSet[string](), k8sObjectCacheMock)
 	// prepare container
 	container := &containercollection.Container{
 		K8s: containercollection.K8sMetadata{
@@ -103,6 +104,7 @@ func TestCreateNetworkManager(t *testing.T) {
 		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kubescape"}},
 	}, storageClient.NetworkNeighborhoods[0].Spec.Containers[1].Ingress[0])
 	assert.Equal(t, 0, len(storageClient.NetworkNeighborhoods[0].Spec.Containers[1].Egress))
+	assert.Equal(t, "node", storageClient.NetworkNeighborhoods[0].Annotations[utils.LastUpdatedNodeMetadataKey])
 	// check the second neighborhood - this is a patch for execs and opens
 	assert.Equal(t, v1beta1.NetworkNeighbor{
 		Identifier:        "c86024d63c2bfddde96a258c3005e963e06fb9d8ee941a6de3003d6eae5dd7cc",
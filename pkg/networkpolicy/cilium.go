@@ -0,0 +1,215 @@
+package networkpolicy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ciliumNamespaceLabel is the label of the namespace of the Cilium endpoints
+	ciliumNamespaceLabel = "k8s:io.kubernetes.pod.namespace"
+	// ciliumNamespaceLabelsPrefix prefixes the labels of the namespace of the Cilium endpoints
+	ciliumNamespaceLabelsPrefix = "k8s:io.cilium.k8s.namespace.labels."
+)
+
+// CiliumNetworkPolicy is the subset of the cilium.io/v2 CiliumNetworkPolicy used by the generated policies
+type CiliumNetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec CiliumRule `json:"spec"`
+}
+
+type CiliumRule struct {
+	EndpointSelector metav1.LabelSelector `json:"endpointSelector"`
+	Ingress          []CiliumIngressRule  `json:"ingress"`
+	Egress           []CiliumEgressRule   `json:"egress"`
+}
+
+type CiliumIngressRule struct {
+	FromEndpoints []metav1.LabelSelector `json:"fromEndpoints,omitempty"`
+	FromCIDR      []string               `json:"fromCIDR,omitempty"`
+	ToPorts       []CiliumPortRule       `json:"toPorts,omitempty"`
+}
+
+type CiliumEgressRule struct {
+	ToEndpoints []metav1.LabelSelector `json:"toEndpoints,omitempty"`
+	ToCIDR      []string               `json:"toCIDR,omitempty"`
+	ToFQDNs     []CiliumFQDNSelector   `json:"toFQDNs,omitempty"`
+	ToPorts     []CiliumPortRule       `json:"toPorts,omitempty"`
+}
+
+type CiliumPortRule struct {
+	Ports []CiliumPortProtocol `json:"ports"`
+	Rules *CiliumL7Rules       `json:"rules,omitempty"`
+}
+
+type CiliumPortProtocol struct {
+	Port     string `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+type CiliumL7Rules struct {
+	DNS []CiliumFQDNSelector `json:"dns,omitempty"`
+}
+
+type CiliumFQDNSelector struct {
+	MatchName    string `json:"matchName,omitempty"`
+	MatchPattern string `json:"matchPattern,omitempty"`
+}
+
+// generateCilium converts the merged peers into a CiliumNetworkPolicy, the egress peers with DNS names are allowed by FQDN
+// instead of IP address, and the DNS requests to kube-dns are allowed and inspected so Cilium learns the IPs of the names
+func generateCilium(nn *v1beta1.NetworkNeighborhood, ingress, egress []*mergedPeer) (*CiliumNetworkPolicy, []Warning) {
+	var warnings []Warning
+	cnp := &CiliumNetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CiliumNetworkPolicy",
+			APIVersion: "cilium.io/v2",
+		},
+		ObjectMeta: objectMeta(nn),
+		Spec: CiliumRule{
+			EndpointSelector: *nn.Spec.LabelSelector.DeepCopy(),
+		},
+	}
+
+	for _, p := range ingress {
+		ports, portWarnings := ciliumPorts(p)
+		warnings = append(warnings, portWarnings...)
+		if len(ports) == 0 {
+			continue
+		}
+		if p.podSelector != nil || p.namespaceSelector != nil {
+			cnp.Spec.Ingress = append(cnp.Spec.Ingress, CiliumIngressRule{
+				FromEndpoints: []metav1.LabelSelector{ciliumEndpointSelector(p.podSelector, p.namespaceSelector)},
+				ToPorts:       ports,
+			})
+		}
+		if p.ipAddress != "" {
+			// the selectors and the CIDRs of Cilium cannot be combined in a rule
+			cidr, err := ipBlock(p.ipAddress)
+			if err != nil {
+				warnings = append(warnings, p.warning(err.Error()))
+				continue
+			}
+			cnp.Spec.Ingress = append(cnp.Spec.Ingress, CiliumIngressRule{FromCIDR: []string{cidr}, ToPorts: ports})
+		} else if p.podSelector == nil && p.namespaceSelector == nil {
+			warnings = append(warnings, p.warning(fmt.Sprintf("CiliumNetworkPolicy cannot select the ingress DNS names %s", strings.Join(p.dnsNames, ", "))))
+		}
+	}
+
+	var fqdns bool
+	for _, p := range egress {
+		ports, portWarnings := ciliumPorts(p)
+		warnings = append(warnings, portWarnings...)
+		if len(ports) == 0 {
+			continue
+		}
+		if p.podSelector != nil || p.namespaceSelector != nil {
+			cnp.Spec.Egress = append(cnp.Spec.Egress, CiliumEgressRule{
+				ToEndpoints: []metav1.LabelSelector{ciliumEndpointSelector(p.podSelector, p.namespaceSelector)},
+				ToPorts:     ports,
+			})
+		}
+		switch {
+		case len(p.dnsNames) > 0:
+			// the IP addresses of the names are learned from the DNS responses
+			rule := CiliumEgressRule{ToPorts: ports}
+			for _, name := range p.dnsNames {
+				rule.ToFQDNs = append(rule.ToFQDNs, CiliumFQDNSelector{MatchName: name})
+			}
+			cnp.Spec.Egress = append(cnp.Spec.Egress, rule)
+			fqdns = true
+		case p.ipAddress != "":
+			cidr, err := ipBlock(p.ipAddress)
+			if err != nil {
+				warnings = append(warnings, p.warning(err.Error()))
+				continue
+			}
+			cnp.Spec.Egress = append(cnp.Spec.Egress, CiliumEgressRule{ToCIDR: []string{cidr}, ToPorts: ports})
+		}
+	}
+	if fqdns {
+		cnp.Spec.Egress = append(cnp.Spec.Egress, CiliumEgressRule{
+			ToEndpoints: []metav1.LabelSelector{{
+				MatchLabels: map[string]string{
+					ciliumNamespaceLabel: "kube-system",
+					"k8s:k8s-app":        "kube-dns",
+				},
+			}},
+			ToPorts: []CiliumPortRule{{
+				Ports: []CiliumPortProtocol{{Port: "53", Protocol: "ANY"}},
+				Rules: &CiliumL7Rules{DNS: []CiliumFQDNSelector{{MatchPattern: "*"}}},
+			}},
+		})
+	}
+
+	// an empty rule enables the default deny of its direction without allowing anything
+	if len(cnp.Spec.Ingress) == 0 {
+		cnp.Spec.Ingress = []CiliumIngressRule{{}}
+	}
+	if len(cnp.Spec.Egress) == 0 {
+		cnp.Spec.Egress = []CiliumEgressRule{{}}
+	}
+	return cnp, warnings
+}
+
+// ciliumPorts returns the port rules of a peer, nil when it has no supported port
+func ciliumPorts(p *mergedPeer) ([]CiliumPortRule, []Warning) {
+	var warnings []Warning
+	var ports []CiliumPortProtocol
+	for _, port := range p.sortedPorts() {
+		if !validProtocol(port.Protocol) {
+			warnings = append(warnings, p.warning(fmt.Sprintf("unsupported protocol %q", port.Protocol)))
+			continue
+		}
+		ports = append(ports, CiliumPortProtocol{Port: portString(port), Protocol: string(port.Protocol)})
+	}
+	if len(ports) == 0 {
+		warnings = append(warnings, p.warning("the neighbor has no supported port"))
+		return nil, warnings
+	}
+	return []CiliumPortRule{{Ports: ports}}, warnings
+}
+
+// ciliumEndpointSelector merges the pod and namespace selectors of a peer into a selector of Cilium endpoints, whose
+// namespace is a label. Without a namespace selector, the endpoints are in the namespace of the policy.
+func ciliumEndpointSelector(podSelector, namespaceSelector *metav1.LabelSelector) metav1.LabelSelector {
+	selector := metav1.LabelSelector{}
+	if podSelector != nil {
+		selector = *podSelector.DeepCopy()
+	}
+	if namespaceSelector == nil {
+		return selector
+	}
+	if len(namespaceSelector.MatchLabels) == 0 && len(namespaceSelector.MatchExpressions) == 0 {
+		// all the namespaces
+		selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      ciliumNamespaceLabel,
+			Operator: metav1.LabelSelectorOpExists,
+		})
+		return selector
+	}
+	for key, value := range namespaceSelector.MatchLabels {
+		if selector.MatchLabels == nil {
+			selector.MatchLabels = make(map[string]string)
+		}
+		selector.MatchLabels[ciliumNamespaceLabelKey(key)] = value
+	}
+	for _, requirement := range namespaceSelector.MatchExpressions {
+		requirement := *requirement.DeepCopy()
+		requirement.Key = ciliumNamespaceLabelKey(requirement.Key)
+		selector.MatchExpressions = append(selector.MatchExpressions, requirement)
+	}
+	return selector
+}
+
+func ciliumNamespaceLabelKey(key string) string {
+	if key == namespaceNameLabel {
+		return ciliumNamespaceLabel
+	}
+	return ciliumNamespaceLabelsPrefix + key
+}
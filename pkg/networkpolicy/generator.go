@@ -0,0 +1,353 @@
+package networkpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	helpersv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1/helpers"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	IngressDirection = "ingress"
+	EgressDirection  = "egress"
+
+	// namespaceNameLabel is set on all the namespaces from kubernetes 1.22
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+)
+
+// Options of the generation
+type Options struct {
+	// Cilium also generates a CiliumNetworkPolicy, with FQDN rules for the DNS names of the egress neighbors
+	Cilium bool
+	// AllowIncomplete generates the policies of a network neighborhood that is still learning
+	AllowIncomplete bool
+}
+
+// Warning flags a neighbor, or one of its ports, that a policy cannot express
+type Warning struct {
+	Container  string `json:"container"`
+	Direction  string `json:"direction"`
+	Identifier string `json:"identifier"`
+	Reason     string `json:"reason"`
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("container %s, %s neighbor %s: %s", w.Container, w.Direction, w.Identifier, w.Reason)
+}
+
+// Policies are generated from a network neighborhood
+type Policies struct {
+	NetworkPolicy *networkingv1.NetworkPolicy
+	// CiliumNetworkPolicy is nil unless Options.Cilium is set
+	CiliumNetworkPolicy *CiliumNetworkPolicy
+	Warnings            []Warning
+}
+
+// peer is a neighbor merged across the containers of the workload, with the union of their ports
+type peer struct {
+	podSelector       *metav1.LabelSelector
+	namespaceSelector *metav1.LabelSelector
+	ipAddress         string
+	dnsNames          []string
+	ports             map[string]v1beta1.NetworkPort // key is the port name
+}
+
+// Generate converts a completed network neighborhood into a least-privilege NetworkPolicy, the neighbors of all
+// the containers of the workload are merged and the traffic they did not learn is denied in both directions
+func Generate(nn *v1beta1.NetworkNeighborhood, opts Options) (*Policies, error) {
+	if status := nn.Annotations[helpersv1.StatusMetadataKey]; status != helpersv1.Completed && !opts.AllowIncomplete {
+		return nil, fmt.Errorf("network neighborhood %s/%s is not completed, status %q", nn.Namespace, nn.Name, status)
+	}
+
+	policies := &Policies{}
+	ingress, ingressWarnings := mergeNeighbors(nn, IngressDirection, func(c v1beta1.NetworkNeighborhoodContainer) []v1beta1.NetworkNeighbor { return c.Ingress })
+	egress, egressWarnings := mergeNeighbors(nn, EgressDirection, func(c v1beta1.NetworkNeighborhoodContainer) []v1beta1.NetworkNeighbor { return c.Egress })
+	policies.Warnings = append(ingressWarnings, egressWarnings...)
+
+	np := &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: "networking.k8s.io/v1",
+		},
+		ObjectMeta: objectMeta(nn),
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: *nn.Spec.LabelSelector.DeepCopy(),
+			// both types are set so a direction without rules denies all its traffic
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}
+	for _, p := range ingress {
+		peers, ports, warnings := networkPolicyRule(p)
+		policies.Warnings = append(policies.Warnings, warnings...)
+		if len(peers) > 0 {
+			np.Spec.Ingress = append(np.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{From: peers, Ports: ports})
+		}
+	}
+	for _, p := range egress {
+		peers, ports, warnings := networkPolicyRule(p)
+		policies.Warnings = append(policies.Warnings, warnings...)
+		if len(peers) > 0 {
+			np.Spec.Egress = append(np.Spec.Egress, networkingv1.NetworkPolicyEgressRule{To: peers, Ports: ports})
+		}
+	}
+	policies.NetworkPolicy = np
+
+	if opts.Cilium {
+		cnp, warnings := generateCilium(nn, ingress, egress)
+		policies.CiliumNetworkPolicy = cnp
+		policies.Warnings = append(policies.Warnings, warnings...)
+	}
+	policies.Warnings = uniqueWarnings(policies.Warnings)
+	return policies, nil
+}
+
+// uniqueWarnings removes the warnings flagged by both policies, keeping their order
+func uniqueWarnings(warnings []Warning) []Warning {
+	seen := make(map[Warning]bool, len(warnings))
+	unique := warnings[:0]
+	for _, w := range warnings {
+		if !seen[w] {
+			seen[w] = true
+			unique = append(unique, w)
+		}
+	}
+	return unique
+}
+
+func objectMeta(nn *v1beta1.NetworkNeighborhood) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{
+		Name:      nn.Name,
+		Namespace: nn.Namespace,
+	}
+	if wlid, ok := nn.Annotations[helpersv1.WlidMetadataKey]; ok {
+		meta.Annotations = map[string]string{helpersv1.WlidMetadataKey: wlid}
+	}
+	return meta
+}
+
+// mergedPeer is a peer with the direction and the first container and neighbor it was seen in, to flag it
+type mergedPeer struct {
+	peer
+	direction  string
+	container  string
+	identifier string
+}
+
+// mergeNeighbors merges the neighbors of a direction of all the containers by peer, sorted by peer
+func mergeNeighbors(nn *v1beta1.NetworkNeighborhood, direction string, neighbors func(v1beta1.NetworkNeighborhoodContainer) []v1beta1.NetworkNeighbor) ([]*mergedPeer, []Warning) {
+	var warnings []Warning
+	merged := make(map[string]*mergedPeer)
+	for _, containers := range [][]v1beta1.NetworkNeighborhoodContainer{nn.Spec.Containers, nn.Spec.InitContainers, nn.Spec.EphemeralContainers} {
+		for _, container := range containers {
+			for _, neighbor := range neighbors(container) {
+				if neighbor.PodSelector == nil && neighbor.NamespaceSelector == nil && neighbor.IPAddress == "" && len(dnsNames(neighbor)) == 0 {
+					warnings = append(warnings, Warning{Container: container.Name, Direction: direction, Identifier: neighbor.Identifier, Reason: "the neighbor has no selector, IP address or DNS name"})
+					continue
+				}
+				key, err := peerKey(neighbor)
+				if err != nil {
+					warnings = append(warnings, Warning{Container: container.Name, Direction: direction, Identifier: neighbor.Identifier, Reason: err.Error()})
+					continue
+				}
+				p, ok := merged[key]
+				if !ok {
+					p = &mergedPeer{
+						peer: peer{
+							podSelector:       neighbor.PodSelector,
+							namespaceSelector: neighbor.NamespaceSelector,
+							ipAddress:         neighbor.IPAddress,
+							ports:             make(map[string]v1beta1.NetworkPort),
+						},
+						direction:  direction,
+						container:  container.Name,
+						identifier: neighbor.Identifier,
+					}
+					merged[key] = p
+				}
+				for _, name := range dnsNames(neighbor) {
+					if !contains(p.dnsNames, name) {
+						p.dnsNames = append(p.dnsNames, name)
+					}
+				}
+				for _, port := range neighbor.Ports {
+					p.ports[portKey(port)] = port
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	peers := make([]*mergedPeer, 0, len(keys))
+	for _, key := range keys {
+		sort.Strings(merged[key].dnsNames)
+		peers = append(peers, merged[key])
+	}
+	return peers, warnings
+}
+
+// peerKey identifies a peer by its selectors and IP address, the DNS names of an IP address are merged
+func peerKey(neighbor v1beta1.NetworkNeighbor) (string, error) {
+	key := struct {
+		PodSelector       *metav1.LabelSelector `json:"p,omitempty"`
+		NamespaceSelector *metav1.LabelSelector `json:"n,omitempty"`
+		IPAddress         string                `json:"i,omitempty"`
+		DNSNames          []string              `json:"d,omitempty"`
+	}{
+		PodSelector:       neighbor.PodSelector,
+		NamespaceSelector: neighbor.NamespaceSelector,
+		IPAddress:         neighbor.IPAddress,
+	}
+	if neighbor.IPAddress == "" && neighbor.PodSelector == nil && neighbor.NamespaceSelector == nil {
+		// a DNS only neighbor
+		key.DNSNames = dnsNames(neighbor)
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("marshaling the neighbor: %w", err)
+	}
+	return string(data), nil
+}
+
+func portKey(port v1beta1.NetworkPort) string {
+	if port.Port == nil {
+		return string(port.Protocol)
+	}
+	return fmt.Sprintf("%s-%d", port.Protocol, *port.Port)
+}
+
+// dnsNames returns the DNS names of a neighbor, including the deprecated DNS field, without their trailing dot
+func dnsNames(neighbor v1beta1.NetworkNeighbor) []string {
+	var names []string
+	for _, name := range append([]string{neighbor.DNS}, neighbor.DNSNames...) {
+		name = strings.TrimSuffix(name, ".")
+		if name != "" && !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedPorts returns the ports of a peer sorted by protocol and port
+func (p *peer) sortedPorts() []v1beta1.NetworkPort {
+	ports := make([]v1beta1.NetworkPort, 0, len(p.ports))
+	for _, port := range p.ports {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Protocol != ports[j].Protocol {
+			return ports[i].Protocol < ports[j].Protocol
+		}
+		if ports[i].Port == nil || ports[j].Port == nil {
+			return ports[i].Port == nil && ports[j].Port != nil
+		}
+		return *ports[i].Port < *ports[j].Port
+	})
+	return ports
+}
+
+func (p *mergedPeer) warning(reason string) Warning {
+	return Warning{Container: p.container, Direction: p.direction, Identifier: p.identifier, Reason: reason}
+}
+
+// ipBlock returns the CIDR of a single IP address
+func ipBlock(ipAddress string) (string, error) {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return "", fmt.Errorf("invalid IP address %q", ipAddress)
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
+func validProtocol(protocol v1beta1.Protocol) bool {
+	switch protocol {
+	case v1beta1.ProtocolTCP, v1beta1.ProtocolUDP, v1beta1.ProtocolSCTP:
+		return true
+	}
+	return false
+}
+
+// networkPolicyRule returns the peers and ports of the rule of a peer, the rule is skipped when it has no peers
+func networkPolicyRule(p *mergedPeer) ([]networkingv1.NetworkPolicyPeer, []networkingv1.NetworkPolicyPort, []Warning) {
+	var warnings []Warning
+	var peers []networkingv1.NetworkPolicyPeer
+	if p.podSelector != nil || p.namespaceSelector != nil {
+		npPeer := networkingv1.NetworkPolicyPeer{
+			PodSelector:       p.podSelector.DeepCopy(),
+			NamespaceSelector: p.namespaceSelector.DeepCopy(),
+		}
+		if npPeer.NamespaceSelector != nil && npPeer.PodSelector == nil {
+			// select all the pods of the namespaces
+			npPeer.PodSelector = &metav1.LabelSelector{}
+		}
+		peers = append(peers, npPeer)
+	}
+	if p.ipAddress != "" {
+		cidr, err := ipBlock(p.ipAddress)
+		if err != nil {
+			warnings = append(warnings, p.warning(err.Error()))
+		} else {
+			if len(peers) > 0 {
+				warnings = append(warnings, p.warning(fmt.Sprintf("the neighbor has both selectors and the IP address %s, both are allowed", p.ipAddress)))
+			}
+			peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+		}
+	}
+	if len(peers) == 0 {
+		warnings = append(warnings, p.warning(fmt.Sprintf("NetworkPolicy cannot select the DNS names %s", strings.Join(p.dnsNames, ", "))))
+		return nil, nil, warnings
+	}
+
+	var ports []networkingv1.NetworkPolicyPort
+	for _, port := range p.sortedPorts() {
+		if !validProtocol(port.Protocol) {
+			warnings = append(warnings, p.warning(fmt.Sprintf("unsupported protocol %q", port.Protocol)))
+			continue
+		}
+		protocol := corev1.Protocol(port.Protocol)
+		npPort := networkingv1.NetworkPolicyPort{Protocol: &protocol}
+		if port.Port != nil {
+			npPort.Port = &intstr.IntOrString{Type: intstr.Int, IntVal: *port.Port}
+		}
+		ports = append(ports, npPort)
+	}
+	if len(ports) == 0 {
+		// a rule without ports allows all of them
+		warnings = append(warnings, p.warning("the neighbor has no supported port"))
+		return nil, nil, warnings
+	}
+	return peers, ports, warnings
+}
+
+// portString is the port of a Cilium rule, 0 matches all the ports of the protocol
+func portString(port v1beta1.NetworkPort) string {
+	if port.Port == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*port.Port))
+}
@@ -0,0 +1,214 @@
+package networkpolicy
+
+import (
+	"fmt"
+	"testing"
+
+	helpersv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1/helpers"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+func port(protocol v1beta1.Protocol, p int32) v1beta1.NetworkPort {
+	return v1beta1.NetworkPort{Name: fmt.Sprintf("%s-%d", protocol, p), Protocol: protocol, Port: ptr.To(p)}
+}
+
+func npPort(protocol corev1.Protocol, p int32) networkingv1.NetworkPolicyPort {
+	return networkingv1.NetworkPolicyPort{Protocol: ptr.To(protocol), Port: ptr.To(intstr.FromInt32(p))}
+}
+
+func neighborhood(status string) *v1beta1.NetworkNeighborhood {
+	redis := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}}
+	dns := &metav1.LabelSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}}
+	kubeSystem := &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"}}
+	return &v1beta1.NetworkNeighborhood{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "deployment-nginx",
+			Namespace: "default",
+			Annotations: map[string]string{
+				helpersv1.StatusMetadataKey: status,
+				helpersv1.WlidMetadataKey:   "wlid://cluster-test/namespace-default/deployment-nginx",
+			},
+		},
+		Spec: v1beta1.NetworkNeighborhoodSpec{
+			LabelSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}},
+			Containers: []v1beta1.NetworkNeighborhoodContainer{
+				{
+					Name: "nginx",
+					Ingress: []v1beta1.NetworkNeighbor{
+						{Identifier: "from-anywhere", Type: "external", IPAddress: "10.0.0.7", Ports: []v1beta1.NetworkPort{port(v1beta1.ProtocolTCP, 80)}},
+					},
+					Egress: []v1beta1.NetworkNeighbor{
+						{Identifier: "redis", Type: "internal", PodSelector: redis, Ports: []v1beta1.NetworkPort{port(v1beta1.ProtocolTCP, 6379)}},
+						{Identifier: "dns", Type: "internal", PodSelector: dns, NamespaceSelector: kubeSystem, Ports: []v1beta1.NetworkPort{port(v1beta1.ProtocolUDP, 53)}},
+						{Identifier: "example", Type: "external", IPAddress: "93.184.216.34", DNSNames: []string{"example.com."}, Ports: []v1beta1.NetworkPort{port(v1beta1.ProtocolTCP, 443)}},
+					},
+				},
+				{
+					Name: "sidecar",
+					Egress: []v1beta1.NetworkNeighbor{
+						// merged with the redis neighbor of nginx
+						{Identifier: "redis-sidecar", Type: "internal", PodSelector: redis, Ports: []v1beta1.NetworkPort{port(v1beta1.ProtocolTCP, 6380)}},
+						{Identifier: "icmp", Type: "external", IPAddress: "10.0.0.8", Ports: []v1beta1.NetworkPort{{Name: "ICMP", Protocol: "ICMP"}}},
+						{Identifier: "unknown", Type: "external"},
+					},
+				},
+			},
+			InitContainers: []v1beta1.NetworkNeighborhoodContainer{
+				{
+					Name: "init",
+					Egress: []v1beta1.NetworkNeighbor{
+						{Identifier: "dns-only", Type: "external", DNSNames: []string{"registry.example.com"}, Ports: []v1beta1.NetworkPort{port(v1beta1.ProtocolTCP, 443)}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	policies, err := Generate(neighborhood(helpersv1.Completed), Options{})
+	require.NoError(t, err)
+	assert.Nil(t, policies.CiliumNetworkPolicy)
+
+	np := policies.NetworkPolicy
+	assert.Equal(t, "deployment-nginx", np.Name)
+	assert.Equal(t, "default", np.Namespace)
+	assert.Equal(t, map[string]string{"app": "nginx"}, np.Spec.PodSelector.MatchLabels)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}, np.Spec.PolicyTypes)
+	assert.Equal(t, []networkingv1.NetworkPolicyIngressRule{{
+		From:  []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.7/32"}}},
+		Ports: []networkingv1.NetworkPolicyPort{npPort(corev1.ProtocolTCP, 80)},
+	}}, np.Spec.Ingress)
+	assert.ElementsMatch(t, []networkingv1.NetworkPolicyEgressRule{
+		{
+			To:    []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}}}},
+			Ports: []networkingv1.NetworkPolicyPort{npPort(corev1.ProtocolTCP, 6379), npPort(corev1.ProtocolTCP, 6380)},
+		},
+		{
+			To: []networkingv1.NetworkPolicyPeer{{
+				PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"}},
+			}},
+			Ports: []networkingv1.NetworkPolicyPort{npPort(corev1.ProtocolUDP, 53)},
+		},
+		{
+			To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "93.184.216.34/32"}}},
+			Ports: []networkingv1.NetworkPolicyPort{npPort(corev1.ProtocolTCP, 443)},
+		},
+	}, np.Spec.Egress)
+
+	var flagged []string
+	for _, w := range policies.Warnings {
+		flagged = append(flagged, w.Identifier)
+	}
+	assert.ElementsMatch(t, []string{"unknown", "icmp", "icmp", "dns-only"}, flagged)
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	first, err := Generate(neighborhood(helpersv1.Completed), Options{Cilium: true})
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		policies, err := Generate(neighborhood(helpersv1.Completed), Options{Cilium: true})
+		require.NoError(t, err)
+		assert.Equal(t, first, policies)
+	}
+}
+
+func TestGenerateIncomplete(t *testing.T) {
+	_, err := Generate(neighborhood(helpersv1.Ready), Options{})
+	assert.Error(t, err)
+	_, err = Generate(neighborhood(helpersv1.Ready), Options{AllowIncomplete: true})
+	assert.NoError(t, err)
+}
+
+func TestGenerateNoNeighbors(t *testing.T) {
+	nn := neighborhood(helpersv1.Completed)
+	nn.Spec.Containers = nil
+	nn.Spec.InitContainers = nil
+	policies, err := Generate(nn, Options{Cilium: true})
+	require.NoError(t, err)
+	// all the traffic is denied
+	assert.Empty(t, policies.NetworkPolicy.Spec.Ingress)
+	assert.Empty(t, policies.NetworkPolicy.Spec.Egress)
+	assert.Len(t, policies.NetworkPolicy.Spec.PolicyTypes, 2)
+	assert.Equal(t, []CiliumIngressRule{{}}, policies.CiliumNetworkPolicy.Spec.Ingress)
+	assert.Equal(t, []CiliumEgressRule{{}}, policies.CiliumNetworkPolicy.Spec.Egress)
+}
+
+func TestGenerateCilium(t *testing.T) {
+	policies, err := Generate(neighborhood(helpersv1.Completed), Options{Cilium: true})
+	require.NoError(t, err)
+	cnp := policies.CiliumNetworkPolicy
+	require.NotNil(t, cnp)
+	assert.Equal(t, "cilium.io/v2", cnp.APIVersion)
+	assert.Equal(t, map[string]string{"app": "nginx"}, cnp.Spec.EndpointSelector.MatchLabels)
+	assert.Equal(t, []CiliumIngressRule{{
+		FromCIDR: []string{"10.0.0.7/32"},
+		ToPorts:  []CiliumPortRule{{Ports: []CiliumPortProtocol{{Port: "80", Protocol: "TCP"}}}},
+	}}, cnp.Spec.Ingress)
+
+	assert.Contains(t, cnp.Spec.Egress, CiliumEgressRule{
+		ToEndpoints: []metav1.LabelSelector{{MatchLabels: map[string]string{"k8s-app": "kube-dns", "k8s:io.kubernetes.pod.namespace": "kube-system"}}},
+		ToPorts:     []CiliumPortRule{{Ports: []CiliumPortProtocol{{Port: "53", Protocol: "UDP"}}}},
+	})
+	assert.Contains(t, cnp.Spec.Egress, CiliumEgressRule{
+		ToFQDNs: []CiliumFQDNSelector{{MatchName: "example.com"}},
+		ToPorts: []CiliumPortRule{{Ports: []CiliumPortProtocol{{Port: "443", Protocol: "TCP"}}}},
+	})
+	assert.Contains(t, cnp.Spec.Egress, CiliumEgressRule{
+		ToFQDNs: []CiliumFQDNSelector{{MatchName: "registry.example.com"}},
+		ToPorts: []CiliumPortRule{{Ports: []CiliumPortProtocol{{Port: "443", Protocol: "TCP"}}}},
+	})
+	// the DNS requests are inspected for the FQDN rules
+	last := cnp.Spec.Egress[len(cnp.Spec.Egress)-1]
+	require.Len(t, last.ToPorts, 1)
+	assert.Equal(t, &CiliumL7Rules{DNS: []CiliumFQDNSelector{{MatchPattern: "*"}}}, last.ToPorts[0].Rules)
+
+	// the DNS only neighbor is expressed by the Cilium policy
+	var flagged []string
+	for _, w := range policies.Warnings {
+		flagged = append(flagged, w.Identifier)
+	}
+	assert.Contains(t, flagged, "dns-only")
+}
+
+func TestCiliumEndpointSelector(t *testing.T) {
+	tests := []struct {
+		name              string
+		podSelector       *metav1.LabelSelector
+		namespaceSelector *metav1.LabelSelector
+		expected          metav1.LabelSelector
+	}{
+		{
+			name:        "same namespace",
+			podSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}},
+			expected:    metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}},
+		},
+		{
+			name:              "namespace labels",
+			namespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+			expected:          metav1.LabelSelector{MatchLabels: map[string]string{"k8s:io.cilium.k8s.namespace.labels.team": "a"}},
+		},
+		{
+			name:              "all namespaces",
+			podSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}},
+			namespaceSelector: &metav1.LabelSelector{},
+			expected: metav1.LabelSelector{
+				MatchLabels:      map[string]string{"app": "redis"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "k8s:io.kubernetes.pod.namespace", Operator: metav1.LabelSelectorOpExists}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ciliumEndpointSelector(tt.podSelector, tt.namespaceSelector))
+		})
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"node-agent/pkg/k8sclient"
 	"node-agent/pkg/objectcache"
+	"node-agent/pkg/utils"
 	"node-agent/pkg/watcher"
+	"strings"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 
@@ -33,47 +37,106 @@ var _ objectcache.ApplicationProfileCache = (*ApplicationProfileCacheImpl)(nil)
 var _ watcher.Adaptor = (*ApplicationProfileCacheImpl)(nil)
 
 type applicationProfileState struct {
-	status string
-	mode   string
+	status      string
+	mode        string
+	profileMode utils.ProfileMode
 }
 
 func newApplicationProfileState(ap *v1beta1.ApplicationProfile) applicationProfileState {
 	mode := ap.Annotations[helpersv1.CompletionMetadataKey]
 	status := ap.Annotations[helpersv1.StatusMetadataKey]
 	return applicationProfileState{
-		status: status,
-		mode:   mode,
+		status:      status,
+		mode:        mode,
+		profileMode: utils.ProfileMode(ap.Annotations[utils.ProfileModeMetadataKey]),
 	}
 }
 
+// learned reports whether the profile finished learning once, the profiles created before the modes are learned when
+// they are completed
+func (s applicationProfileState) learned() bool {
+	switch s.profileMode {
+	case utils.ProfileModeReady, utils.ProfileModeLocked, utils.ProfileModeRelearning:
+		return true
+	case utils.ProfileModeLearning:
+		return false
+	}
+	return s.status == helpersv1.Completed
+}
+
+// containerRevision is the revision of the workload and the image of a container, the profiles learned on other
+// revisions or images are not used for the container
+type containerRevision struct {
+	revision       string
+	containerType  string
+	containerIndex int
+	imageID        string
+}
+
 type ApplicationProfileCacheImpl struct {
 	containerToSlug  maps.SafeMap[string, string]                      // cache the containerID to slug mapping, this will enable a quick lookup of the application profile
 	slugToAppProfile maps.SafeMap[string, *v1beta1.ApplicationProfile] // cache the application profile
 	slugToContainers maps.SafeMap[string, mapset.Set[string]]          // cache the containerIDs that belong to the application profile, this will enable removing from cache AP without pods
 	slugToState      maps.SafeMap[string, applicationProfileState]     // cache the containerID to slug mapping, this will enable a quick lookup of the application profile
+	containerToRev   maps.SafeMap[string, containerRevision]           // cache the revision and image of the containers, to withhold the profiles learned on others
 	k8sClient        k8sclient.K8sClientInterface
 	allProfiles      mapset.Set[string] // cache all the application profiles that are ready. this will enable removing from cache AP without pods that are running on the same node
 	nodeName         string
+	// relearnGracePeriod is how long a relearning profile is withheld from the rules
+	relearnGracePeriod time.Duration
 }
 
-func NewApplicationProfileCache(nodeName string, k8sClient k8sclient.K8sClientInterface) *ApplicationProfileCacheImpl {
+func NewApplicationProfileCache(nodeName string, k8sClient k8sclient.K8sClientInterface, relearnGracePeriod time.Duration) *ApplicationProfileCacheImpl {
 	return &ApplicationProfileCacheImpl{
-		nodeName:         nodeName,
-		k8sClient:        k8sClient,
-		containerToSlug:  maps.SafeMap[string, string]{},
-		slugToContainers: maps.SafeMap[string, mapset.Set[string]]{},
-		allProfiles:      mapset.NewSet[string](),
+		nodeName:           nodeName,
+		k8sClient:          k8sClient,
+		relearnGracePeriod: relearnGracePeriod,
+		containerToSlug:    maps.SafeMap[string, string]{},
+		slugToContainers:   maps.SafeMap[string, mapset.Set[string]]{},
+		allProfiles:        mapset.NewSet[string](),
 	}
 
 }
 
 // ------------------ objectcache.ApplicationProfileCache methods -----------------------
 
+// GetApplicationProfile returns the profile of a container, unless the profile is learning, within the grace period of
+// its relearning, or was learned on another revision or image than the container's and is not locked
 func (ap *ApplicationProfileCacheImpl) GetApplicationProfile(containerID string) *v1beta1.ApplicationProfile {
-	if s := ap.containerToSlug.Get(containerID); s != "" {
-		return ap.slugToAppProfile.Get(s)
+	s := ap.containerToSlug.Get(containerID)
+	if s == "" {
+		return nil
+	}
+	profile := ap.slugToAppProfile.Get(s)
+	if profile == nil {
+		return nil
 	}
-	return nil
+	// the cached profiles without mode were completed
+	mode := utils.ProfileModeReady
+	if profile.Annotations[utils.ProfileModeMetadataKey] != "" {
+		mode = utils.GetProfileMode(profile, time.Now(), ap.relearnGracePeriod)
+	}
+	switch mode {
+	case utils.ProfileModeLocked:
+		return profile
+	case utils.ProfileModeLearning, utils.ProfileModeRelearning:
+		return nil
+	}
+	if rev, ok := ap.containerToRev.Load(containerID); ok && utils.ProfileDrift(profile, rev.revision, rev.containerType, rev.containerIndex, rev.imageID) != "" {
+		return nil
+	}
+	return profile
+}
+
+// ListApplicationProfiles returns the cached application profiles of the workloads of the node, the cache replaces
+// them on updates so they can be kept and compared with their next versions
+func (ap *ApplicationProfileCacheImpl) ListApplicationProfiles() []*v1beta1.ApplicationProfile {
+	var profiles []*v1beta1.ApplicationProfile
+	ap.slugToAppProfile.Range(func(_ string, profile *v1beta1.ApplicationProfile) bool {
+		profiles = append(profiles, profile)
+		return true
+	})
+	return profiles
 }
 
 // ------------------ watcher.Adaptor methods -----------------------
@@ -152,6 +215,10 @@ func (ap *ApplicationProfileCacheImpl) addPod(podU *unstructured.Unstructured) {
 		ap.removeContainer(container)
 	}
 
+	for containerID, rev := range listContainerRevisions(pod) {
+		ap.containerToRev.Set(containerID, rev)
+	}
+
 	containers := objectcache.ListContainersIDs(pod)
 	for _, container := range containers {
 
@@ -205,6 +272,7 @@ func (ap *ApplicationProfileCacheImpl) removeContainer(containerID string) {
 
 	uniqueSlug := ap.containerToSlug.Get(containerID)
 	ap.containerToSlug.Delete(containerID)
+	ap.containerToRev.Delete(containerID)
 
 	// remove pod form the application profile mapping
 	if ap.slugToContainers.Has(uniqueSlug) {
@@ -231,10 +299,10 @@ func (ap *ApplicationProfileCacheImpl) addApplicationProfile(_ context.Context,
 	apState := newApplicationProfileState(appProfile)
 	ap.slugToState.Set(apName, apState)
 
-	// the cache holds only completed application profiles.
-	// check if the application profile is completed
-	// if status was completed and now is not (e.g. mode changed from complete to partial), remove from cache
-	if apState.status != helpersv1.Completed {
+	// the cache holds only the application profiles that finished learning once, the relearning ones are withheld by
+	// GetApplicationProfile until their grace period is over
+	// if the profile was learned and now is not (e.g. a new profile replaced it), remove from cache
+	if !apState.learned() {
 		if ap.slugToAppProfile.Has(apName) {
 			ap.slugToAppProfile.Delete(apName)
 			ap.allProfiles.Remove(apName)
@@ -262,6 +330,47 @@ func (ap *ApplicationProfileCacheImpl) addApplicationProfile(_ context.Context,
 	}
 }
 
+// listContainerRevisions returns the revision and image of the running containers of a pod, by container ID
+func listContainerRevisions(pod *corev1.Pod) map[string]containerRevision {
+	revisions := make(map[string]containerRevision)
+	revision := utils.GetRevision(pod.GetLabels())
+	addStatuses := func(containerType utils.ContainerType, names []string, statuses []corev1.ContainerStatus) {
+		for i := range statuses {
+			// remove the runtime prefix of the container ID
+			_, containerID, ok := strings.Cut(statuses[i].ContainerID, "//")
+			if !ok || containerID == "" {
+				continue
+			}
+			for index, name := range names {
+				if name == statuses[i].Name {
+					revisions[containerID] = containerRevision{
+						revision:       revision,
+						containerType:  containerType.String(),
+						containerIndex: index,
+						imageID:        utils.ImageDigest(statuses[i].ImageID),
+					}
+				}
+			}
+		}
+	}
+	var names []string
+	for _, c := range pod.Spec.Containers {
+		names = append(names, c.Name)
+	}
+	addStatuses(utils.Container, names, pod.Status.ContainerStatuses)
+	names = nil
+	for _, c := range pod.Spec.InitContainers {
+		names = append(names, c.Name)
+	}
+	addStatuses(utils.InitContainer, names, pod.Status.InitContainerStatuses)
+	names = nil
+	for _, c := range pod.Spec.EphemeralContainers {
+		names = append(names, c.Name)
+	}
+	addStatuses(utils.EphemeralContainer, names, pod.Status.EphemeralContainerStatuses)
+	return revisions
+}
+
 func (ap *ApplicationProfileCacheImpl) deleteApplicationProfile(obj *unstructured.Unstructured) {
 	apName := objectcache.UnstructuredUniqueName(obj)
 	ap.slugToAppProfile.Delete(apName)
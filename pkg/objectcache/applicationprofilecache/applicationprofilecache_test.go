@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"node-agent/mocks"
 	"node-agent/pkg/objectcache"
+	"node-agent/pkg/utils"
 	"node-agent/pkg/watcher"
 	"slices"
 	"testing"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
+	helpersv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1/helpers"
 	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -84,7 +87,7 @@ func Test_AddHandlers(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.obj.SetNamespace("default")
 			k8sClient := k8sinterface.NewKubernetesApiMock()
-			ap := NewApplicationProfileCache("", k8sClient)
+			ap := NewApplicationProfileCache("", k8sClient, 0)
 			ap.slugToContainers.Set(tt.slug, mapset.NewSet[string]())
 
 			tt.f(ap, context.Background(), tt.obj)
@@ -186,7 +189,7 @@ func Test_addApplicationProfile(t *testing.T) {
 
 			k8sClient.DynamicClient = dynamicfake.NewSimpleDynamicClient(scheme.Scheme, runtimeObjs...)
 
-			ap := NewApplicationProfileCache("", k8sClient)
+			ap := NewApplicationProfileCache("", k8sClient, 0)
 
 			for i := range tt.preCreatedPods {
 				ap.addPod(tt.preCreatedPods[i])
@@ -260,7 +263,7 @@ func Test_deleteApplicationProfile(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ap := NewApplicationProfileCache("", nil)
+			ap := NewApplicationProfileCache("", nil, 0)
 
 			ap.allProfiles.Append(tt.slugs...)
 			for _, i := range tt.slugs {
@@ -323,7 +326,7 @@ func Test_deletePod(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ap := NewApplicationProfileCache("", nil)
+			ap := NewApplicationProfileCache("", nil, 0)
 			for _, i := range tt.otherSlugs {
 				ap.slugToContainers.Set(i, mapset.NewSet[string]())
 				ap.slugToAppProfile.Set(i, &v1beta1.ApplicationProfile{})
@@ -431,7 +434,7 @@ func Test_GetApplicationProfile(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ap := NewApplicationProfileCache("", k8sinterface.NewKubernetesApiMock())
+			ap := NewApplicationProfileCache("", k8sinterface.NewKubernetesApiMock(), 0)
 
 			for _, c := range tt.pods {
 				ap.containerToSlug.Set(c.containerID, c.slug)
@@ -511,7 +514,7 @@ func Test_addApplicationProfile_existing(t *testing.T) {
 
 			k8sClient.DynamicClient = dynamicfake.NewSimpleDynamicClient(scheme.Scheme, runtimeObjs...)
 
-			ap := NewApplicationProfileCache("", k8sClient)
+			ap := NewApplicationProfileCache("", k8sClient, 0)
 
 			// add pods
 			for i := range tt.pods {
@@ -615,7 +618,7 @@ func Test_getApplicationProfile(t *testing.T) {
 }
 
 func Test_WatchResources(t *testing.T) {
-	ap := NewApplicationProfileCache("test-node", nil)
+	ap := NewApplicationProfileCache("test-node", nil, 0)
 
 	expectedPodWatchResource := watcher.NewWatchResource(schema.GroupVersionResource{
 		Group:    "",
@@ -757,7 +760,7 @@ func Test_addPod(t *testing.T) {
 
 			k8sClient.DynamicClient = dynamicfake.NewSimpleDynamicClient(scheme.Scheme, runtimeObjs...)
 
-			ap := NewApplicationProfileCache("", k8sClient)
+			ap := NewApplicationProfileCache("", k8sClient, 0)
 
 			ap.addApplicationProfile(context.Background(), tt.preCreatedAP)
 
@@ -785,3 +788,64 @@ func Test_addPod(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetApplicationProfileModes(t *testing.T) {
+	learnedAnnotations := map[string]string{
+		utils.ProfileRevisionMetadataKey:                 "77b4fdf86c",
+		utils.ProfileImageIDMetadataKey("containers", 0): "sha256:abc",
+	}
+	tests := []struct {
+		name       string
+		mode       utils.ProfileMode
+		relearnAge time.Duration
+		revision   string
+		imageID    string
+		expected   bool
+	}{
+		{name: "ready", mode: utils.ProfileModeReady, revision: "77b4fdf86c", imageID: "docker.io/library/nginx@sha256:abc", expected: true},
+		{name: "learning", mode: utils.ProfileModeLearning, revision: "77b4fdf86c", imageID: "sha256:abc", expected: false},
+		{name: "relearning within the grace period", mode: utils.ProfileModeRelearning, relearnAge: time.Minute, revision: "77b4fdf86c", imageID: "sha256:abc", expected: false},
+		{name: "relearning after the grace period", mode: utils.ProfileModeRelearning, relearnAge: time.Hour, revision: "77b4fdf86c", imageID: "sha256:abc", expected: true},
+		{name: "new revision", mode: utils.ProfileModeReady, revision: "5f8c6d9b7", imageID: "sha256:abc", expected: false},
+		{name: "new image", mode: utils.ProfileModeReady, revision: "77b4fdf86c", imageID: "sha256:def", expected: false},
+		{name: "locked on a new revision", mode: utils.ProfileModeLocked, revision: "5f8c6d9b7", imageID: "sha256:def", expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := NewApplicationProfileCache("", nil, 30*time.Minute)
+			annotations := map[string]string{utils.ProfileModeMetadataKey: string(tt.mode)}
+			for k, v := range learnedAnnotations {
+				annotations[k] = v
+			}
+			if tt.relearnAge > 0 {
+				annotations[utils.ProfileRelearnStartMetadataKey] = time.Now().Add(-tt.relearnAge).UTC().Format(time.RFC3339)
+			}
+			ap.containerToSlug.Set("1234", "default/replicaset-nginx-77b4fdf86c")
+			ap.slugToAppProfile.Set("default/replicaset-nginx-77b4fdf86c", &v1beta1.ApplicationProfile{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}})
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"pod-template-hash": tt.revision}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx"}}},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "nginx", ContainerID: "containerd://1234", ImageID: tt.imageID},
+				}},
+			}
+			for containerID, rev := range listContainerRevisions(pod) {
+				ap.containerToRev.Set(containerID, rev)
+			}
+
+			if tt.expected {
+				assert.NotNil(t, ap.GetApplicationProfile("1234"))
+			} else {
+				assert.Nil(t, ap.GetApplicationProfile("1234"))
+			}
+		})
+	}
+}
+
+func Test_applicationProfileStateLearned(t *testing.T) {
+	assert.True(t, applicationProfileState{status: helpersv1.Initializing, profileMode: utils.ProfileModeRelearning}.learned())
+	assert.True(t, applicationProfileState{status: helpersv1.Completed}.learned())
+	assert.False(t, applicationProfileState{status: helpersv1.Completed, profileMode: utils.ProfileModeLearning}.learned())
+	assert.False(t, applicationProfileState{status: helpersv1.Ready}.learned())
+}
@@ -76,6 +76,17 @@ func (nn *NetworkNeighborhoodCacheImpl) GetNetworkNeighborhood(containerID strin
 	return nil
 }
 
+// ListNetworkNeighborhoods returns the cached network neighborhoods of the workloads of the node, the cache replaces
+// them on updates so they can be kept and compared with their next versions
+func (nn *NetworkNeighborhoodCacheImpl) ListNetworkNeighborhoods() []*v1beta1.NetworkNeighborhood {
+	var neighborhoods []*v1beta1.NetworkNeighborhood
+	nn.slugToNetworkNeighborhood.Range(func(_ string, neighborhood *v1beta1.NetworkNeighborhood) bool {
+		neighborhoods = append(neighborhoods, neighborhood)
+		return true
+	})
+	return neighborhoods
+}
+
 // ------------------ watcher.Adaptor methods -----------------------
 
 // ------------------ watcher.WatchResources methods -----------------------
@@ -0,0 +1,306 @@
+package objectcache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"node-agent/pkg/utils"
+
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+)
+
+// StringsDiff are the values added to and removed from a list
+type StringsDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// ExecsDiff are the executions added and removed, an execution is identified by its path and arguments
+type ExecsDiff struct {
+	Added   []v1beta1.ExecCalls `json:"added,omitempty"`
+	Removed []v1beta1.ExecCalls `json:"removed,omitempty"`
+}
+
+// OpensDiff are the files opened with new flags and the files no longer opened with some flags, each open only holds
+// the added or removed flags of its path
+type OpensDiff struct {
+	Added   []v1beta1.OpenCalls `json:"added,omitempty"`
+	Removed []v1beta1.OpenCalls `json:"removed,omitempty"`
+}
+
+// NeighborsDiff are the neighbors reached on new ports and the neighbors no longer reached on some ports, neighbors are
+// identified by their identifier and each neighbor only holds the added or removed ports
+type NeighborsDiff struct {
+	Added   []v1beta1.NetworkNeighbor `json:"added,omitempty"`
+	Removed []v1beta1.NetworkNeighbor `json:"removed,omitempty"`
+}
+
+// ContainerDiff is the difference between two versions of the profile of a container
+type ContainerDiff struct {
+	Name         string      `json:"name"`
+	Execs        ExecsDiff   `json:"execs,omitempty"`
+	Opens        OpensDiff   `json:"opens,omitempty"`
+	Capabilities StringsDiff `json:"capabilities,omitempty"`
+	Syscalls     StringsDiff `json:"syscalls,omitempty"`
+	// BPFUsages are the BPF usages recorded in the activities of the container
+	BPFUsages StringsDiff   `json:"bpfUsages,omitempty"`
+	Egress    NeighborsDiff `json:"egress,omitempty"`
+}
+
+// Empty reports whether the container profile did not change
+func (d ContainerDiff) Empty() bool {
+	return len(d.Execs.Added) == 0 && len(d.Execs.Removed) == 0 &&
+		len(d.Opens.Added) == 0 && len(d.Opens.Removed) == 0 &&
+		len(d.Capabilities.Added) == 0 && len(d.Capabilities.Removed) == 0 &&
+		len(d.Syscalls.Added) == 0 && len(d.Syscalls.Removed) == 0 &&
+		len(d.BPFUsages.Added) == 0 && len(d.BPFUsages.Removed) == 0 &&
+		len(d.Egress.Added) == 0 && len(d.Egress.Removed) == 0
+}
+
+// ProfileDiff is the difference between two versions of an ApplicationProfile or a NetworkNeighborhood
+type ProfileDiff struct {
+	// Containers are the containers whose profile changed, sorted by name
+	Containers []ContainerDiff `json:"containers,omitempty"`
+}
+
+// Empty reports whether the profile did not change
+func (d ProfileDiff) Empty() bool {
+	return len(d.Containers) == 0
+}
+
+// Summary counts the changes of each container, e.g. "nginx: 2 added execs, 1 removed syscalls"
+func (d ProfileDiff) Summary() string {
+	var containers []string
+	for _, c := range d.Containers {
+		var changes []string
+		for _, count := range []struct {
+			n    int
+			kind string
+		}{
+			{len(c.Execs.Added), "added execs"},
+			{len(c.Execs.Removed), "removed execs"},
+			{len(c.Opens.Added), "added opens"},
+			{len(c.Opens.Removed), "removed opens"},
+			{len(c.Capabilities.Added), "added capabilities"},
+			{len(c.Capabilities.Removed), "removed capabilities"},
+			{len(c.Syscalls.Added), "added syscalls"},
+			{len(c.Syscalls.Removed), "removed syscalls"},
+			{len(c.BPFUsages.Added), "added BPF usages"},
+			{len(c.BPFUsages.Removed), "removed BPF usages"},
+			{len(c.Egress.Added), "added egress neighbors"},
+			{len(c.Egress.Removed), "removed egress neighbors"},
+		} {
+			if count.n > 0 {
+				changes = append(changes, fmt.Sprintf("%d %s", count.n, count.kind))
+			}
+		}
+		containers = append(containers, fmt.Sprintf("%s: %s", c.Name, strings.Join(changes, ", ")))
+	}
+	return strings.Join(containers, "; ")
+}
+
+// DiffApplicationProfiles returns the execs, opens, capabilities, syscalls and BPF usages added and removed between two
+// versions of an application profile, a nil profile has no containers
+func DiffApplicationProfiles(previous, current *v1beta1.ApplicationProfile) ProfileDiff {
+	previousContainers := applicationProfileContainers(previous)
+	currentContainers := applicationProfileContainers(current)
+	var diff ProfileDiff
+	for _, name := range containerNames(previousContainers, currentContainers) {
+		p, c := previousContainers[name], currentContainers[name]
+		containerDiff := ContainerDiff{
+			Name:         name,
+			Execs:        diffExecs(p.Execs, c.Execs),
+			Opens:        diffOpens(p.Opens, c.Opens),
+			Capabilities: diffStrings(p.Capabilities, c.Capabilities),
+			Syscalls:     diffStrings(p.Syscalls, c.Syscalls),
+			BPFUsages:    diffStrings(utils.GetActivities(previous, name, utils.BPFActivity), utils.GetActivities(current, name, utils.BPFActivity)),
+		}
+		if !containerDiff.Empty() {
+			diff.Containers = append(diff.Containers, containerDiff)
+		}
+	}
+	return diff
+}
+
+// DiffNetworkNeighborhoods returns the egress neighbors added and removed between two versions of a network
+// neighborhood, a nil neighborhood has no containers
+func DiffNetworkNeighborhoods(previous, current *v1beta1.NetworkNeighborhood) ProfileDiff {
+	previousContainers := networkNeighborhoodContainers(previous)
+	currentContainers := networkNeighborhoodContainers(current)
+	var diff ProfileDiff
+	for _, name := range containerNames(previousContainers, currentContainers) {
+		containerDiff := ContainerDiff{
+			Name:   name,
+			Egress: diffNeighbors(previousContainers[name].Egress, currentContainers[name].Egress),
+		}
+		if !containerDiff.Empty() {
+			diff.Containers = append(diff.Containers, containerDiff)
+		}
+	}
+	return diff
+}
+
+func applicationProfileContainers(ap *v1beta1.ApplicationProfile) map[string]v1beta1.ApplicationProfileContainer {
+	containers := make(map[string]v1beta1.ApplicationProfileContainer)
+	if ap == nil {
+		return containers
+	}
+	for _, list := range [][]v1beta1.ApplicationProfileContainer{ap.Spec.Containers, ap.Spec.InitContainers, ap.Spec.EphemeralContainers} {
+		for _, c := range list {
+			containers[c.Name] = c
+		}
+	}
+	return containers
+}
+
+func networkNeighborhoodContainers(nn *v1beta1.NetworkNeighborhood) map[string]v1beta1.NetworkNeighborhoodContainer {
+	containers := make(map[string]v1beta1.NetworkNeighborhoodContainer)
+	if nn == nil {
+		return containers
+	}
+	for _, list := range [][]v1beta1.NetworkNeighborhoodContainer{nn.Spec.Containers, nn.Spec.InitContainers, nn.Spec.EphemeralContainers} {
+		for _, c := range list {
+			containers[c.Name] = c
+		}
+	}
+	return containers
+}
+
+// containerNames returns the sorted names of the containers of both versions
+func containerNames[T any](previous, current map[string]T) []string {
+	var names []string
+	for name := range previous {
+		names = append(names, name)
+	}
+	for name := range current {
+		if _, ok := previous[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func diffStrings(previous, current []string) StringsDiff {
+	return StringsDiff{
+		Added:   missing(current, previous),
+		Removed: missing(previous, current),
+	}
+}
+
+// missing returns the sorted unique values of values that are not in others
+func missing(values, others []string) []string {
+	known := make(map[string]bool, len(others))
+	for _, v := range others {
+		known[v] = true
+	}
+	var result []string
+	for _, v := range values {
+		if !known[v] {
+			result = append(result, v)
+			known[v] = true
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func execKey(exec v1beta1.ExecCalls) string {
+	return strings.Join(append([]string{exec.Path}, exec.Args...), "\x00")
+}
+
+func diffExecs(previous, current []v1beta1.ExecCalls) ExecsDiff {
+	missingExecs := func(values, others []v1beta1.ExecCalls) []v1beta1.ExecCalls {
+		known := make(map[string]bool, len(others))
+		for _, exec := range others {
+			known[execKey(exec)] = true
+		}
+		var result []v1beta1.ExecCalls
+		for _, exec := range values {
+			if key := execKey(exec); !known[key] {
+				result = append(result, v1beta1.ExecCalls{Path: exec.Path, Args: exec.Args})
+				known[key] = true
+			}
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return execKey(result[i]) < execKey(result[j])
+		})
+		return result
+	}
+	return ExecsDiff{
+		Added:   missingExecs(current, previous),
+		Removed: missingExecs(previous, current),
+	}
+}
+
+func diffOpens(previous, current []v1beta1.OpenCalls) OpensDiff {
+	missingFlags := func(values, others []v1beta1.OpenCalls) []v1beta1.OpenCalls {
+		otherFlags := make(map[string][]string, len(others))
+		for _, open := range others {
+			otherFlags[open.Path] = append(otherFlags[open.Path], open.Flags...)
+		}
+		flags := make(map[string][]string, len(values))
+		var paths []string
+		for _, open := range values {
+			if _, ok := flags[open.Path]; !ok {
+				paths = append(paths, open.Path)
+			}
+			flags[open.Path] = append(flags[open.Path], open.Flags...)
+		}
+		sort.Strings(paths)
+		var result []v1beta1.OpenCalls
+		for _, path := range paths {
+			_, known := otherFlags[path]
+			newFlags := missing(flags[path], otherFlags[path])
+			// a path opened in both versions without new flags did not change
+			if known && len(newFlags) == 0 {
+				continue
+			}
+			result = append(result, v1beta1.OpenCalls{Path: path, Flags: newFlags})
+		}
+		return result
+	}
+	return OpensDiff{
+		Added:   missingFlags(current, previous),
+		Removed: missingFlags(previous, current),
+	}
+}
+
+func diffNeighbors(previous, current []v1beta1.NetworkNeighbor) NeighborsDiff {
+	missingPorts := func(values, others []v1beta1.NetworkNeighbor) []v1beta1.NetworkNeighbor {
+		otherPorts := make(map[string]map[string]bool, len(others))
+		for _, neighbor := range others {
+			if otherPorts[neighbor.Identifier] == nil {
+				otherPorts[neighbor.Identifier] = make(map[string]bool)
+			}
+			for _, port := range neighbor.Ports {
+				otherPorts[neighbor.Identifier][port.Name] = true
+			}
+		}
+		var result []v1beta1.NetworkNeighbor
+		for _, neighbor := range values {
+			ports, known := otherPorts[neighbor.Identifier]
+			var newPorts []v1beta1.NetworkPort
+			for _, port := range neighbor.Ports {
+				if !ports[port.Name] {
+					newPorts = append(newPorts, port)
+				}
+			}
+			// a neighbor of both versions without new ports did not change
+			if known && len(newPorts) == 0 {
+				continue
+			}
+			neighbor.Ports = newPorts
+			result = append(result, neighbor)
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Identifier < result[j].Identifier
+		})
+		return result
+	}
+	return NeighborsDiff{
+		Added:   missingPorts(current, previous),
+		Removed: missingPorts(previous, current),
+	}
+}
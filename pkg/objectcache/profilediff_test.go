@@ -0,0 +1,124 @@
+package objectcache
+
+import (
+	"testing"
+
+	"node-agent/pkg/utils"
+
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestDiffApplicationProfiles(t *testing.T) {
+	previous := &v1beta1.ApplicationProfile{
+		Spec: v1beta1.ApplicationProfileSpec{
+			Containers: []v1beta1.ApplicationProfileContainer{
+				{
+					Name:         "nginx",
+					Execs:        []v1beta1.ExecCalls{{Path: "/bin/sh", Args: []string{"-c", "ls"}}, {Path: "/bin/ls"}},
+					Opens:        []v1beta1.OpenCalls{{Path: "/etc/passwd", Flags: []string{"O_RDONLY"}}, {Path: "/tmp/cache", Flags: []string{"O_RDWR"}}},
+					Capabilities: []string{"NET_BIND_SERVICE"},
+					Syscalls:     []string{"read", "write"},
+				},
+				{
+					Name:     "sidecar",
+					Syscalls: []string{"read"},
+				},
+			},
+		},
+	}
+	current := &v1beta1.ApplicationProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				utils.ActivityMetadataKey(utils.BPFActivity, "bpf:MAP_CREATE:Hash", "containers", 0): "bpf:MAP_CREATE:Hash",
+			},
+		},
+		Spec: v1beta1.ApplicationProfileSpec{
+			Containers: []v1beta1.ApplicationProfileContainer{
+				{
+					Name:         "nginx",
+					Execs:        []v1beta1.ExecCalls{{Path: "/bin/sh", Args: []string{"-c", "ls"}}, {Path: "/bin/sh", Args: []string{"-c", "curl"}}},
+					Opens:        []v1beta1.OpenCalls{{Path: "/etc/passwd", Flags: []string{"O_RDONLY", "O_WRONLY"}}, {Path: "/etc/shadow", Flags: []string{"O_RDONLY"}}},
+					Capabilities: []string{"NET_BIND_SERVICE", "SYS_ADMIN"},
+					Syscalls:     []string{"read", "write", "ptrace"},
+				},
+				{
+					Name:     "sidecar",
+					Syscalls: []string{"read"},
+				},
+			},
+			InitContainers: []v1beta1.ApplicationProfileContainer{
+				{Name: "init", Capabilities: []string{"CHOWN"}},
+			},
+		},
+	}
+	diff := DiffApplicationProfiles(previous, current)
+	assert.Equal(t, ProfileDiff{Containers: []ContainerDiff{
+		{
+			Name:         "init",
+			Capabilities: StringsDiff{Added: []string{"CHOWN"}},
+		},
+		{
+			Name: "nginx",
+			Execs: ExecsDiff{
+				Added:   []v1beta1.ExecCalls{{Path: "/bin/sh", Args: []string{"-c", "curl"}}},
+				Removed: []v1beta1.ExecCalls{{Path: "/bin/ls"}},
+			},
+			Opens: OpensDiff{
+				Added:   []v1beta1.OpenCalls{{Path: "/etc/passwd", Flags: []string{"O_WRONLY"}}, {Path: "/etc/shadow", Flags: []string{"O_RDONLY"}}},
+				Removed: []v1beta1.OpenCalls{{Path: "/tmp/cache", Flags: []string{"O_RDWR"}}},
+			},
+			Capabilities: StringsDiff{Added: []string{"SYS_ADMIN"}},
+			Syscalls:     StringsDiff{Added: []string{"ptrace"}},
+			BPFUsages:    StringsDiff{Added: []string{"bpf:MAP_CREATE:Hash"}},
+		},
+	}}, diff)
+	assert.Equal(t, "init: 1 added capabilities; nginx: 1 added execs, 1 removed execs, 2 added opens, 1 removed opens, 1 added capabilities, 1 added syscalls, 1 added BPF usages", diff.Summary())
+
+	assert.True(t, DiffApplicationProfiles(current, current).Empty())
+	assert.Len(t, DiffApplicationProfiles(nil, current).Containers, 3)
+}
+
+func TestDiffNetworkNeighborhoods(t *testing.T) {
+	port := func(name string, p int32) v1beta1.NetworkPort {
+		return v1beta1.NetworkPort{Name: name, Protocol: v1beta1.ProtocolTCP, Port: ptr.To(p)}
+	}
+	previous := &v1beta1.NetworkNeighborhood{
+		Spec: v1beta1.NetworkNeighborhoodSpec{
+			Containers: []v1beta1.NetworkNeighborhoodContainer{{
+				Name: "nginx",
+				Egress: []v1beta1.NetworkNeighbor{
+					{Identifier: "redis", Ports: []v1beta1.NetworkPort{port("TCP-6379", 6379)}},
+					{Identifier: "example", DNSNames: []string{"example.com."}, Ports: []v1beta1.NetworkPort{port("TCP-443", 443)}},
+				},
+				Ingress: []v1beta1.NetworkNeighbor{{Identifier: "lb"}},
+			}},
+		},
+	}
+	current := &v1beta1.NetworkNeighborhood{
+		Spec: v1beta1.NetworkNeighborhoodSpec{
+			Containers: []v1beta1.NetworkNeighborhoodContainer{{
+				Name: "nginx",
+				Egress: []v1beta1.NetworkNeighbor{
+					{Identifier: "redis", Ports: []v1beta1.NetworkPort{port("TCP-6379", 6379), port("TCP-6380", 6380)}},
+					{Identifier: "miner", IPAddress: "203.0.113.7", Ports: []v1beta1.NetworkPort{port("TCP-3333", 3333)}},
+				},
+			}},
+		},
+	}
+	assert.Equal(t, ProfileDiff{Containers: []ContainerDiff{{
+		Name: "nginx",
+		Egress: NeighborsDiff{
+			Added: []v1beta1.NetworkNeighbor{
+				{Identifier: "miner", IPAddress: "203.0.113.7", Ports: []v1beta1.NetworkPort{port("TCP-3333", 3333)}},
+				{Identifier: "redis", Ports: []v1beta1.NetworkPort{port("TCP-6380", 6380)}},
+			},
+			Removed: []v1beta1.NetworkNeighbor{
+				{Identifier: "example", DNSNames: []string{"example.com."}, Ports: []v1beta1.NetworkPort{port("TCP-443", 443)}},
+			},
+		},
+	}}}, DiffNetworkNeighborhoods(previous, current))
+	assert.True(t, DiffNetworkNeighborhoods(current, current).Empty())
+}
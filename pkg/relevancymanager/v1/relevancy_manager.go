@@ -180,29 +180,15 @@ func (rm *RelevancyManager) handleRelevancy(ctx context.Context, watchedContaine
 }
 
 func findImageID(pod workloadinterface.IWorkload, containerName string, containerType utils.ContainerType) (string, error) {
-	var containerStatuses []v1.ContainerStatus
 	// find imageID
 	podStatus, err := pod.GetPodStatus() // Careful this is not available on container creation
 	if err != nil {
 		return "", err
 	}
-	switch containerType {
-	case utils.Container:
-		containerStatuses = podStatus.ContainerStatuses
-	case utils.InitContainer:
-		containerStatuses = podStatus.InitContainerStatuses
-	case utils.EphemeralContainer:
-		containerStatuses = podStatus.EphemeralContainerStatuses
-	}
-
-	for i := range containerStatuses {
-		if containerStatuses[i].Name == containerName {
-			return containerStatuses[i].ImageID, nil
-		}
-	}
-	return "", nil
-
+	imageID, _ := utils.GetContainerImageID(podStatus, containerType, containerName)
+	return imageID, nil
 }
+
 func findImageTag(pod workloadinterface.IWorkload, containerName string, containerType utils.ContainerType) (string, error) {
 	var containers []v1.Container
 	var ephemeralContainers []v1.EphemeralContainer
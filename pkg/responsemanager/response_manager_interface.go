@@ -0,0 +1,13 @@
+package responsemanager
+
+import (
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/ruleengine"
+)
+
+type ResponseManagerClient interface {
+	// RespondToRuleAlert runs the response actions of the policies matching the rule alert
+	RespondToRuleAlert(ruleFailure ruleengine.RuleFailure, containerPid uint32)
+	// RespondToMalwareAlert runs the response actions of the policies matching the malware alert
+	RespondToMalwareAlert(malwareResult malwaremanager.MalwareResult, containerPid uint32)
+}
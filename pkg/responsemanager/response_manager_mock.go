@@ -0,0 +1,23 @@
+package responsemanager
+
+import (
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/ruleengine"
+)
+
+type ResponseManagerMock struct {
+}
+
+var _ ResponseManagerClient = (*ResponseManagerMock)(nil)
+
+func CreateResponseManagerMock() *ResponseManagerMock {
+	return &ResponseManagerMock{}
+}
+
+func (r *ResponseManagerMock) RespondToRuleAlert(_ ruleengine.RuleFailure, _ uint32) {
+	// noop
+}
+
+func (r *ResponseManagerMock) RespondToMalwareAlert(_ malwaremanager.MalwareResult, _ uint32) {
+	// noop
+}
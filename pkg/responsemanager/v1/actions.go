@@ -0,0 +1,211 @@
+package responsemanager
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// quarantineMetadata is written next to each quarantined file
+type quarantineMetadata struct {
+	OriginalPath  string    `json:"originalPath"`
+	SHA256        string    `json:"sha256"`
+	Size          int64     `json:"size"`
+	Mode          string    `json:"mode"`
+	AlertName     string    `json:"alertName"`
+	RuleID        string    `json:"ruleID,omitempty"`
+	ContainerID   string    `json:"containerID"`
+	ContainerName string    `json:"containerName,omitempty"`
+	PodName       string    `json:"podName,omitempty"`
+	Namespace     string    `json:"namespace,omitempty"`
+	Image         string    `json:"image,omitempty"`
+	QuarantinedAt time.Time `json:"quarantinedAt"`
+}
+
+// belongsToContainer checks that the process is in the cgroup of the container, so a reused PID is never acted upon
+func (rm *ResponseManager) belongsToContainer(pid uint32, containerID string) error {
+	if containerID == "" {
+		return fmt.Errorf("unknown container of process %d", pid)
+	}
+	cgroups, err := os.ReadFile(filepath.Join(rm.procDir, strconv.FormatUint(uint64(pid), 10), "cgroup"))
+	if err != nil {
+		return fmt.Errorf("failed to read the cgroups of process %d: %w", pid, err)
+	}
+	if !bytes.Contains(cgroups, []byte(containerID)) {
+		return fmt.Errorf("process %d does not belong to container %s", pid, containerID)
+	}
+	return nil
+}
+
+// kill sends SIGKILL to the offending process
+func (rm *ResponseManager) kill(target *responseTarget) error {
+	if target.pid == 0 {
+		return fmt.Errorf("unknown process")
+	}
+	if err := rm.belongsToContainer(target.pid, target.containerID); err != nil {
+		return err
+	}
+	return syscall.Kill(int(target.pid), syscall.SIGKILL)
+}
+
+// quarantine moves the offending file out of the container into the quarantine directory, named by its SHA256 hash,
+// and writes its metadata next to it. It returns the path of the quarantined file.
+func (rm *ResponseManager) quarantine(target *responseTarget) (string, error) {
+	if target.path == "" {
+		return "", fmt.Errorf("unknown file path")
+	}
+	if target.containerPid == 0 {
+		return "", fmt.Errorf("unknown container process")
+	}
+	if err := rm.belongsToContainer(target.containerPid, target.containerID); err != nil {
+		return "", err
+	}
+	root, err := os.Open(filepath.Join(rm.procDir, strconv.FormatUint(uint64(target.containerPid), 10), "root"))
+	if err != nil {
+		return "", err
+	}
+	defer root.Close()
+	// the symbolic links of the container are resolved in its root, so a link can not point the action at a host file
+	dirFd, err := unix.Openat2(int(root.Fd()), filepath.Dir(target.path), &unix.OpenHow{
+		Flags:   unix.O_PATH | unix.O_DIRECTORY | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_IN_ROOT,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open the directory of %s: %w", target.path, err)
+	}
+	defer unix.Close(dirFd)
+	name := filepath.Base(target.path)
+	fd, err := unix.Openat(dirFd, name, unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", target.path, err)
+	}
+	source := os.NewFile(uintptr(fd), target.path)
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", target.path)
+	}
+
+	if err := os.MkdirAll(rm.cfg.QuarantinePath, 0700); err != nil {
+		return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(rm.cfg.QuarantinePath, ".quarantine-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), source)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to copy %s: %w", target.path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0400); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	quarantinedPath := filepath.Join(rm.cfg.QuarantinePath, sum)
+	if err := os.Rename(tmp.Name(), quarantinedPath); err != nil {
+		return "", err
+	}
+
+	metadata, err := json.MarshalIndent(quarantineMetadata{
+		OriginalPath:  target.path,
+		SHA256:        sum,
+		Size:          size,
+		Mode:          info.Mode().String(),
+		AlertName:     target.alertName,
+		RuleID:        target.ruleID,
+		ContainerID:   target.containerID,
+		ContainerName: target.k8sDetails.ContainerName,
+		PodName:       target.k8sDetails.PodName,
+		Namespace:     target.namespace,
+		Image:         target.k8sDetails.Image,
+		QuarantinedAt: time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(quarantinedPath+".json", metadata, 0400); err != nil {
+		return "", fmt.Errorf("failed to write quarantine metadata: %w", err)
+	}
+
+	// the file is only removed when the name still refers to the copied file
+	var current unix.Stat_t
+	if err := unix.Fstatat(dirFd, name, &current, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return "", fmt.Errorf("copied %s to quarantine but failed to remove it: %w", target.path, err)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || stat.Dev != current.Dev || stat.Ino != current.Ino {
+		return "", fmt.Errorf("copied %s to quarantine but it was replaced before its removal", target.path)
+	}
+	if err := unix.Unlinkat(dirFd, name, 0); err != nil {
+		return "", fmt.Errorf("copied %s to quarantine but failed to remove it: %w", target.path, err)
+	}
+	return quarantinedPath, nil
+}
+
+// freeze freezes the cgroup of the container, with cgroup.freeze on cgroup v2 and the freezer controller on cgroup v1
+func (rm *ResponseManager) freeze(target *responseTarget) error {
+	if target.containerPid == 0 {
+		return fmt.Errorf("unknown container process")
+	}
+	if err := rm.belongsToContainer(target.containerPid, target.containerID); err != nil {
+		return err
+	}
+	cgroups, err := os.ReadFile(filepath.Join(rm.procDir, strconv.FormatUint(uint64(target.containerPid), 10), "cgroup"))
+	if err != nil {
+		return err
+	}
+	freezerFile, state, err := freezerPath(rm.cfg.CgroupRoot, cgroups)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(freezerFile, []byte(state), 0)
+}
+
+// freezerPath returns the file freezing the cgroup described by /proc/<pid>/cgroup and the value freezing it,
+// the freezer controller of cgroup v1 is preferred over the unified hierarchy of cgroup v2
+func freezerPath(cgroupRoot string, cgroups []byte) (string, string, error) {
+	var unified string
+	scanner := bufio.NewScanner(bytes.NewReader(cgroups))
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			unified = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller == "freezer" {
+				return filepath.Join(cgroupRoot, "freezer", fields[2], "freezer.state"), "FROZEN", nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+	if unified == "" {
+		return "", "", fmt.Errorf("no freezer cgroup found")
+	}
+	return filepath.Join(cgroupRoot, unified, "cgroup.freeze"), "1", nil
+}
@@ -0,0 +1,145 @@
+package responsemanager
+
+import (
+	"fmt"
+	"node-agent/pkg/ruleengine"
+	"slices"
+)
+
+const (
+	ActionKill       = "kill"
+	ActionQuarantine = "quarantine"
+	ActionFreeze     = "freeze"
+
+	RuleAlertType    = "rule"
+	MalwareAlertType = "malware"
+
+	defaultCgroupRoot = "/sys/fs/cgroup"
+	defaultQueueSize  = 100
+)
+
+// actionsOrder is the order in which the actions of an alert run, the file is quarantined before its process is killed
+var actionsOrder = []string{ActionQuarantine, ActionKill, ActionFreeze}
+
+type ResponseConfig struct {
+	// Enabled turns on the response actions, alerts are only reported when disabled
+	Enabled bool `mapstructure:"enabled"`
+	// DryRun audits the actions that would run without running them
+	DryRun bool `mapstructure:"dryRun"`
+	// QuarantinePath is the directory of the quarantined files, required by the quarantine action
+	QuarantinePath string `mapstructure:"quarantinePath"`
+	// CgroupRoot is the mount point of the host cgroup filesystem, used by the freeze action
+	CgroupRoot string `mapstructure:"cgroupRoot"`
+	// QueueSize is the number of alerts waiting for a response, alerts are dropped when the queue is full
+	QueueSize int `mapstructure:"queueSize"`
+	// Policies select the alerts to respond to, the actions of all the matching policies run
+	Policies []ResponsePolicy `mapstructure:"policies"`
+}
+
+// ResponsePolicy selects alerts and the actions to run on them, an alert is selected when it matches all the set fields
+type ResponsePolicy struct {
+	// Name identifies the policy in the audit alerts
+	Name string `mapstructure:"name"`
+	// AlertTypes are the types of the alerts, rule and/or malware
+	AlertTypes []string `mapstructure:"alertTypes"`
+	// RuleIDs are the IDs of the rules, they only apply to rule alerts
+	RuleIDs []string `mapstructure:"ruleIDs"`
+	// MinSeverity is the minimum severity, a severity name or a numeric priority
+	MinSeverity string `mapstructure:"minSeverity"`
+	// Namespaces are the namespaces of the alerting pods
+	Namespaces []string `mapstructure:"namespaces"`
+	// Actions are kill, quarantine and/or freeze
+	Actions []string `mapstructure:"actions"`
+}
+
+// Validate checks the policies and sets the defaults
+func (c *ResponseConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.CgroupRoot == "" {
+		c.CgroupRoot = defaultCgroupRoot
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultQueueSize
+	}
+	_, err := c.parsePolicies()
+	return err
+}
+
+// responsePolicy is a validated policy
+type responsePolicy struct {
+	name        string
+	alertTypes  []string
+	ruleIDs     []string
+	minSeverity int
+	namespaces  []string
+	actions     []string
+}
+
+func (c *ResponseConfig) parsePolicies() ([]*responsePolicy, error) {
+	policies := make([]*responsePolicy, 0, len(c.Policies))
+	for i, policy := range c.Policies {
+		name := policy.Name
+		if name == "" {
+			name = fmt.Sprintf("policy-%d", i)
+		}
+		parsed, err := parsePolicy(name, policy)
+		if err != nil {
+			return nil, fmt.Errorf("response policy %q: %w", name, err)
+		}
+		if slices.Contains(parsed.actions, ActionQuarantine) && c.QuarantinePath == "" {
+			return nil, fmt.Errorf("response policy %q: the quarantine action requires a quarantine path", name)
+		}
+		policies = append(policies, parsed)
+	}
+	return policies, nil
+}
+
+func parsePolicy(name string, policy ResponsePolicy) (*responsePolicy, error) {
+	for _, alertType := range policy.AlertTypes {
+		if alertType != RuleAlertType && alertType != MalwareAlertType {
+			return nil, fmt.Errorf("unknown alert type %q, expected %s or %s", alertType, RuleAlertType, MalwareAlertType)
+		}
+	}
+	if len(policy.Actions) == 0 {
+		return nil, fmt.Errorf("no actions")
+	}
+	for _, action := range policy.Actions {
+		if !slices.Contains(actionsOrder, action) {
+			return nil, fmt.Errorf("unknown action %q, expected one of %v", action, actionsOrder)
+		}
+	}
+	parsed := &responsePolicy{
+		name:       name,
+		alertTypes: policy.AlertTypes,
+		ruleIDs:    policy.RuleIDs,
+		namespaces: policy.Namespaces,
+		actions:    policy.Actions,
+	}
+	if policy.MinSeverity != "" {
+		minSeverity, err := ruleengine.ParseSeverity(policy.MinSeverity)
+		if err != nil {
+			return nil, err
+		}
+		parsed.minSeverity = minSeverity
+	}
+	return parsed, nil
+}
+
+// match reports whether the policy selects the alert
+func (p *responsePolicy) match(target *responseTarget) bool {
+	if len(p.alertTypes) > 0 && !slices.Contains(p.alertTypes, target.alertType) {
+		return false
+	}
+	if len(p.ruleIDs) > 0 && (target.alertType != RuleAlertType || !slices.Contains(p.ruleIDs, target.ruleID)) {
+		return false
+	}
+	if target.severity < p.minSeverity {
+		return false
+	}
+	if len(p.namespaces) > 0 && !slices.Contains(p.namespaces, target.namespace) {
+		return false
+	}
+	return true
+}
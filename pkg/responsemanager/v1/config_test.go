@@ -0,0 +1,87 @@
+package responsemanager
+
+import (
+	"node-agent/pkg/ruleengine"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ResponseConfig
+		wantErr bool
+	}{
+		{
+			name: "disabled policies are not validated",
+			cfg:  ResponseConfig{Policies: []ResponsePolicy{{Actions: []string{"reboot"}}}},
+		},
+		{
+			name: "valid",
+			cfg: ResponseConfig{Enabled: true, QuarantinePath: "/quarantine", Policies: []ResponsePolicy{
+				{AlertTypes: []string{"malware"}, MinSeverity: "high", Actions: []string{"quarantine", "kill"}},
+			}},
+		},
+		{
+			name:    "unknown action",
+			cfg:     ResponseConfig{Enabled: true, Policies: []ResponsePolicy{{Actions: []string{"reboot"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "no actions",
+			cfg:     ResponseConfig{Enabled: true, Policies: []ResponsePolicy{{AlertTypes: []string{"rule"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown alert type",
+			cfg:     ResponseConfig{Enabled: true, Policies: []ResponsePolicy{{AlertTypes: []string{"audit"}, Actions: []string{"kill"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid severity",
+			cfg:     ResponseConfig{Enabled: true, Policies: []ResponsePolicy{{MinSeverity: "urgent", Actions: []string{"kill"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "quarantine without path",
+			cfg:     ResponseConfig{Enabled: true, Policies: []ResponsePolicy{{Actions: []string{"quarantine"}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestResponsePolicyMatch(t *testing.T) {
+	cfg := ResponseConfig{Enabled: true, Policies: []ResponsePolicy{
+		{Name: "rules", AlertTypes: []string{"rule"}, RuleIDs: []string{"R1000"}, MinSeverity: "high", Namespaces: []string{"prod"}, Actions: []string{"kill"}},
+	}}
+	policies, err := cfg.parsePolicies()
+	require.NoError(t, err)
+	policy := policies[0]
+	assert.Equal(t, "rules", policy.name)
+
+	target := &responseTarget{alertType: RuleAlertType, ruleID: "R1000", severity: ruleengine.RulePriorityHigh, namespace: "prod"}
+	assert.True(t, policy.match(target))
+
+	for name, modify := range map[string]func(target *responseTarget){
+		"alert type": func(target *responseTarget) { target.alertType = MalwareAlertType },
+		"rule ID":    func(target *responseTarget) { target.ruleID = "R0001" },
+		"severity":   func(target *responseTarget) { target.severity = ruleengine.RulePriorityMed },
+		"namespace":  func(target *responseTarget) { target.namespace = "dev" },
+	} {
+		mismatch := *target
+		modify(&mismatch)
+		assert.False(t, policy.match(&mismatch), name)
+	}
+}
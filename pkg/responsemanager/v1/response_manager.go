@@ -0,0 +1,287 @@
+package responsemanager
+
+import (
+	"context"
+	"fmt"
+	"node-agent/pkg/exporters"
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/responsemanager"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"slices"
+	"strings"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+)
+
+const (
+	// AuditRuleID is the rule ID of the alerts auditing the response actions
+	AuditRuleID    = "R-RESPONSE"
+	AuditAlertName = "Response action"
+)
+
+// responseTarget holds what the actions need from an alert, it is copied before the alert is enriched and exported
+type responseTarget struct {
+	alertType    string
+	alertName    string
+	ruleID       string
+	severity     int
+	namespace    string
+	containerID  string
+	containerPid uint32
+	// pid is the host PID of the offending process
+	pid  uint32
+	comm string
+	// path is the path of the offending file in the container
+	path         string
+	sha256       string
+	k8sDetails   apitypes.RuntimeAlertK8sDetails
+	triggerEvent igtypes.Event
+}
+
+type responseJob struct {
+	target   *responseTarget
+	actions  []string
+	policies []string
+}
+
+type ResponseManager struct {
+	cfg      ResponseConfig
+	policies []*responsePolicy
+	exporter exporters.Exporter
+	queue    chan *responseJob
+	// procDir is the host /proc, the files and processes of the containers are reached through it
+	procDir string
+}
+
+var _ responsemanager.ResponseManagerClient = (*ResponseManager)(nil)
+
+func CreateResponseManager(ctx context.Context, cfg ResponseConfig, exporter exporters.Exporter) (*ResponseManager, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	policies, err := cfg.parsePolicies()
+	if err != nil {
+		return nil, err
+	}
+	rm := &ResponseManager{
+		cfg:      cfg,
+		policies: policies,
+		exporter: exporter,
+		queue:    make(chan *responseJob, cfg.QueueSize),
+		procDir:  "/proc",
+	}
+	if cfg.DryRun {
+		logger.L().Info("ResponseManager - dry run, response actions are only audited")
+	}
+	go rm.run(ctx)
+	return rm, nil
+}
+
+func (rm *ResponseManager) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-rm.queue:
+			rm.respond(job)
+		}
+	}
+}
+
+func (rm *ResponseManager) RespondToRuleAlert(ruleFailure ruleengine.RuleFailure, containerPid uint32) {
+	processDetails := ruleFailure.GetRuntimeProcessDetails()
+	pid := ruleFailure.GetBaseRuntimeAlert().InfectedPID
+	if pid == 0 {
+		pid = processDetails.ProcessTree.PID
+	}
+	rm.enqueue(&responseTarget{
+		alertType:    RuleAlertType,
+		alertName:    ruleFailure.GetBaseRuntimeAlert().AlertName,
+		ruleID:       ruleFailure.GetRuleAlert().RuleID,
+		severity:     ruleFailure.GetBaseRuntimeAlert().Severity,
+		namespace:    alertNamespace(ruleFailure.GetRuntimeAlertK8sDetails(), ruleFailure.GetTriggerEvent()),
+		containerID:  containerID(processDetails.ContainerID, ruleFailure.GetTriggerEvent()),
+		containerPid: containerPid,
+		pid:          pid,
+		comm:         processDetails.ProcessTree.Comm,
+		path:         processDetails.ProcessTree.Path,
+		sha256:       ruleFailure.GetBaseRuntimeAlert().SHA256Hash,
+		k8sDetails:   ruleFailure.GetRuntimeAlertK8sDetails(),
+		triggerEvent: ruleFailure.GetTriggerEvent(),
+	})
+}
+
+func (rm *ResponseManager) RespondToMalwareAlert(malwareResult malwaremanager.MalwareResult, containerPid uint32) {
+	processDetails := malwareResult.GetRuntimeProcessDetails()
+	pid := malwareResult.GetBasicRuntimeAlert().InfectedPID
+	if pid == 0 {
+		pid = processDetails.ProcessTree.PID
+	}
+	rm.enqueue(&responseTarget{
+		alertType:    MalwareAlertType,
+		alertName:    malwareResult.GetBasicRuntimeAlert().AlertName,
+		severity:     malwareResult.GetBasicRuntimeAlert().Severity,
+		namespace:    alertNamespace(malwareResult.GetRuntimeAlertK8sDetails(), malwareResult.GetTriggerEvent()),
+		containerID:  containerID(processDetails.ContainerID, malwareResult.GetTriggerEvent()),
+		containerPid: containerPid,
+		pid:          pid,
+		comm:         processDetails.ProcessTree.Comm,
+		path:         processDetails.ProcessTree.Path,
+		sha256:       malwareResult.GetBasicRuntimeAlert().SHA256Hash,
+		k8sDetails:   malwareResult.GetRuntimeAlertK8sDetails(),
+		triggerEvent: malwareResult.GetTriggerEvent(),
+	})
+}
+
+func alertNamespace(k8sDetails apitypes.RuntimeAlertK8sDetails, triggerEvent igtypes.Event) string {
+	if k8sDetails.Namespace != "" {
+		return k8sDetails.Namespace
+	}
+	return triggerEvent.K8s.Namespace
+}
+
+func containerID(id string, triggerEvent igtypes.Event) string {
+	if id != "" {
+		return id
+	}
+	return triggerEvent.Runtime.ContainerID
+}
+
+// enqueue queues the actions of the policies matching the target, the actions run in the background so the alerts are not delayed
+func (rm *ResponseManager) enqueue(target *responseTarget) {
+	job := rm.createJob(target)
+	if job == nil {
+		return
+	}
+	select {
+	case rm.queue <- job:
+	default:
+		logger.L().Warning("ResponseManager - response queue is full, dropping response",
+			helpers.String("alert", target.alertName),
+			helpers.String("container ID", target.containerID))
+	}
+}
+
+// createJob returns the actions of all the policies matching the target, in order, or nil when no policy matches
+func (rm *ResponseManager) createJob(target *responseTarget) *responseJob {
+	job := &responseJob{target: target}
+	var actions []string
+	for _, policy := range rm.policies {
+		if !policy.match(target) {
+			continue
+		}
+		job.policies = append(job.policies, policy.name)
+		actions = append(actions, policy.actions...)
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+	for _, action := range actionsOrder {
+		if slices.Contains(actions, action) {
+			job.actions = append(job.actions, action)
+		}
+	}
+	return job
+}
+
+// respond runs the actions of the job and audits each of them
+func (rm *ResponseManager) respond(job *responseJob) {
+	for _, action := range job.actions {
+		var subject string
+		var err error
+		switch action {
+		case ActionKill:
+			subject = fmt.Sprintf("process %d (%s)", job.target.pid, job.target.comm)
+			if !rm.cfg.DryRun {
+				err = rm.kill(job.target)
+			}
+		case ActionQuarantine:
+			subject = fmt.Sprintf("file %s", job.target.path)
+			if !rm.cfg.DryRun {
+				var quarantinedPath string
+				quarantinedPath, err = rm.quarantine(job.target)
+				if err == nil {
+					subject = fmt.Sprintf("file %s to %s", job.target.path, quarantinedPath)
+				}
+			}
+		case ActionFreeze:
+			subject = fmt.Sprintf("container %s", job.target.containerID)
+			if !rm.cfg.DryRun {
+				err = rm.freeze(job.target)
+			}
+		}
+		if err != nil {
+			logger.L().Warning("ResponseManager - response action failed", helpers.String("action", action),
+				helpers.String("alert", job.target.alertName), helpers.Error(err))
+		} else if !rm.cfg.DryRun {
+			logger.L().Info("ResponseManager - response action succeeded", helpers.String("action", action),
+				helpers.String("alert", job.target.alertName), helpers.String("target", subject))
+		}
+		rm.exporter.SendRuleAlert(rm.createAuditAlert(job, action, subject, err))
+	}
+}
+
+// createAuditAlert creates the alert reporting a response action, it carries the workload details of the alert that triggered it
+func (rm *ResponseManager) createAuditAlert(job *responseJob, action string, subject string, actionErr error) ruleengine.RuleFailure {
+	target := job.target
+	var description string
+	switch {
+	case rm.cfg.DryRun:
+		description = fmt.Sprintf("Dry run: would %s %s in response to %s", action, subject, target.alertName)
+	case actionErr != nil:
+		description = fmt.Sprintf("Failed to %s %s in response to %s: %v", action, subject, target.alertName, actionErr)
+	default:
+		description = fmt.Sprintf("%s %s in response to %s", actionPastTense[action], subject, target.alertName)
+	}
+	arguments := map[string]interface{}{
+		"action":       action,
+		"dryRun":       rm.cfg.DryRun,
+		"success":      actionErr == nil,
+		"target":       subject,
+		"policies":     strings.Join(job.policies, ","),
+		"triggerAlert": target.alertName,
+		"triggerType":  target.alertType,
+	}
+	if target.ruleID != "" {
+		arguments["triggerRuleID"] = target.ruleID
+	}
+	if actionErr != nil {
+		arguments["error"] = actionErr.Error()
+	}
+
+	return &ruleenginev1.GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:   AuditAlertName,
+			Arguments:   arguments,
+			InfectedPID: target.pid,
+			SHA256Hash:  target.sha256,
+			Severity:    target.severity,
+			Timestamp:   time.Now(),
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{
+				Comm: target.comm,
+				PID:  target.pid,
+				Path: target.path,
+			},
+			ContainerID: target.containerID,
+		},
+		TriggerEvent: target.triggerEvent,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          AuditRuleID,
+			RuleDescription: description,
+		},
+		RuntimeAlertK8sDetails: target.k8sDetails,
+	}
+}
+
+var actionPastTense = map[string]string{
+	ActionKill:       "Killed",
+	ActionQuarantine: "Quarantined",
+	ActionFreeze:     "Froze",
+}
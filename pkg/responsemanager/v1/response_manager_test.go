@@ -0,0 +1,317 @@
+package responsemanager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"node-agent/pkg/malwaremanager"
+	mmtypes "node-agent/pkg/malwaremanager/v1/types"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testContainerID = "0123456789abcdef"
+
+type auditExporterMock struct {
+	mutex  sync.Mutex
+	alerts []ruleengine.RuleFailure
+}
+
+func (m *auditExporterMock) SendRuleAlert(failedRule ruleengine.RuleFailure) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.alerts = append(m.alerts, failedRule)
+}
+
+func (m *auditExporterMock) SendMalwareAlert(_ malwaremanager.MalwareResult) {
+}
+
+func (m *auditExporterMock) getAlerts() []ruleengine.RuleFailure {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]ruleengine.RuleFailure{}, m.alerts...)
+}
+
+// createTestResponseManager creates a response manager without its worker, reaching the processes through a fake /proc
+func createTestResponseManager(t *testing.T, cfg ResponseConfig) (*ResponseManager, *auditExporterMock) {
+	cfg.Enabled = true
+	require.NoError(t, cfg.Validate())
+	policies, err := cfg.parsePolicies()
+	require.NoError(t, err)
+	exporter := &auditExporterMock{}
+	return &ResponseManager{
+		cfg:      cfg,
+		policies: policies,
+		exporter: exporter,
+		queue:    make(chan *responseJob, cfg.QueueSize),
+		procDir:  t.TempDir(),
+	}, exporter
+}
+
+// addProcess adds a process of the container to the fake /proc
+func addProcess(t *testing.T, procDir string, pid int, cgroup string) string {
+	processDir := filepath.Join(procDir, strconv.Itoa(pid))
+	require.NoError(t, os.MkdirAll(filepath.Join(processDir, "root"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(processDir, "cgroup"), []byte(cgroup), 0644))
+	return processDir
+}
+
+func testTarget(pid uint32, containerPid uint32, path string) *responseTarget {
+	return &responseTarget{
+		alertType:    MalwareAlertType,
+		alertName:    "xmrig",
+		severity:     ruleengine.RulePriorityCritical,
+		namespace:    "default",
+		containerID:  testContainerID,
+		containerPid: containerPid,
+		pid:          pid,
+		comm:         "xmrig",
+		path:         path,
+		k8sDetails:   apitypes.RuntimeAlertK8sDetails{Namespace: "default", PodName: "miner", ContainerName: "app"},
+	}
+}
+
+func TestCreateJob(t *testing.T) {
+	rm, _ := createTestResponseManager(t, ResponseConfig{QuarantinePath: "/quarantine", Policies: []ResponsePolicy{
+		{Name: "freeze-all", Actions: []string{"freeze", "kill"}},
+		{Name: "malware", AlertTypes: []string{"malware"}, Actions: []string{"kill", "quarantine"}},
+		{Name: "dev", Namespaces: []string{"dev"}, Actions: []string{"kill"}},
+	}})
+
+	job := rm.createJob(testTarget(1, 1, "/bin/xmrig"))
+	require.NotNil(t, job)
+	assert.Equal(t, []string{"quarantine", "kill", "freeze"}, job.actions)
+	assert.Equal(t, []string{"freeze-all", "malware"}, job.policies)
+
+	rm.policies = rm.policies[2:]
+	assert.Nil(t, rm.createJob(testTarget(1, 1, "/bin/xmrig")))
+}
+
+func TestRespondDryRun(t *testing.T) {
+	rm, exporter := createTestResponseManager(t, ResponseConfig{DryRun: true, QuarantinePath: t.TempDir(), Policies: []ResponsePolicy{
+		{Actions: []string{"kill", "quarantine", "freeze"}},
+	}})
+	processDir := addProcess(t, rm.procDir, 1234, "0::/kubepods/"+testContainerID)
+	filePath := filepath.Join(processDir, "root", "xmrig")
+	require.NoError(t, os.WriteFile(filePath, []byte("miner"), 0755))
+
+	rm.respond(rm.createJob(testTarget(1234, 1234, "/xmrig")))
+
+	assert.FileExists(t, filePath)
+	alerts := exporter.getAlerts()
+	require.Len(t, alerts, 3)
+	for i, action := range []string{"quarantine", "kill", "freeze"} {
+		assert.Equal(t, AuditRuleID, alerts[i].GetRuleAlert().RuleID)
+		assert.Equal(t, action, alerts[i].GetBaseRuntimeAlert().Arguments["action"])
+		assert.Equal(t, true, alerts[i].GetBaseRuntimeAlert().Arguments["dryRun"])
+		assert.Equal(t, "miner", alerts[i].GetRuntimeAlertK8sDetails().PodName)
+	}
+	assert.Equal(t, "Dry run: would kill process 1234 (xmrig) in response to xmrig", alerts[1].GetRuleAlert().RuleDescription)
+}
+
+func TestRespondQuarantine(t *testing.T) {
+	quarantinePath := filepath.Join(t.TempDir(), "quarantine")
+	rm, exporter := createTestResponseManager(t, ResponseConfig{QuarantinePath: quarantinePath, Policies: []ResponsePolicy{
+		{Actions: []string{"quarantine"}},
+	}})
+	processDir := addProcess(t, rm.procDir, 1234, "0::/kubepods/"+testContainerID)
+	filePath := filepath.Join(processDir, "root", "xmrig")
+	require.NoError(t, os.WriteFile(filePath, []byte("miner"), 0755))
+
+	rm.respond(rm.createJob(testTarget(1234, 1234, "/xmrig")))
+
+	alerts := exporter.getAlerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, true, alerts[0].GetBaseRuntimeAlert().Arguments["success"])
+	assert.NoFileExists(t, filePath)
+
+	sum := sha256.Sum256([]byte("miner"))
+	quarantined := filepath.Join(quarantinePath, hex.EncodeToString(sum[:]))
+	entries, err := os.ReadDir(quarantinePath)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	content, err := os.ReadFile(quarantined)
+	require.NoError(t, err)
+	assert.Equal(t, "miner", string(content))
+	info, err := os.Stat(quarantined)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0400), info.Mode().Perm())
+
+	var metadata quarantineMetadata
+	data, err := os.ReadFile(quarantined + ".json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &metadata))
+	assert.Equal(t, "/xmrig", metadata.OriginalPath)
+	assert.Equal(t, hex.EncodeToString(sum[:]), metadata.SHA256)
+	assert.Equal(t, int64(5), metadata.Size)
+	assert.Equal(t, testContainerID, metadata.ContainerID)
+	assert.Equal(t, "miner", metadata.PodName)
+}
+
+func TestRespondQuarantineSymlinkEscape(t *testing.T) {
+	rm, exporter := createTestResponseManager(t, ResponseConfig{QuarantinePath: filepath.Join(t.TempDir(), "quarantine"), Policies: []ResponsePolicy{
+		{Actions: []string{"quarantine"}},
+	}})
+	processDir := addProcess(t, rm.procDir, 1234, "0::/kubepods/"+testContainerID)
+	hostDir := t.TempDir()
+	hostFile := filepath.Join(hostDir, "passwd")
+	require.NoError(t, os.WriteFile(hostFile, []byte("root"), 0644))
+	// links of the container pointing at host paths
+	require.NoError(t, os.Symlink(hostDir, filepath.Join(processDir, "root", "etc")))
+	require.NoError(t, os.Symlink(hostFile, filepath.Join(processDir, "root", "passwd")))
+
+	for _, path := range []string{"/etc/passwd", "/passwd"} {
+		rm.respond(rm.createJob(testTarget(1234, 1234, path)))
+	}
+
+	alerts := exporter.getAlerts()
+	require.Len(t, alerts, 2)
+	for _, alert := range alerts {
+		assert.Equal(t, false, alert.GetBaseRuntimeAlert().Arguments["success"])
+	}
+	assert.FileExists(t, hostFile)
+}
+
+func TestRespondKill(t *testing.T) {
+	rm, exporter := createTestResponseManager(t, ResponseConfig{Policies: []ResponsePolicy{{Actions: []string{"kill"}}}})
+	cmd := exec.Command("sleep", "60")
+	require.NoError(t, cmd.Start())
+	pid := cmd.Process.Pid
+	addProcess(t, rm.procDir, pid, "0::/kubepods/"+testContainerID)
+
+	rm.respond(rm.createJob(testTarget(uint32(pid), uint32(pid), "/bin/sleep")))
+
+	err := cmd.Wait()
+	require.Error(t, err)
+	status := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	assert.Equal(t, syscall.SIGKILL, status.Signal())
+	alerts := exporter.getAlerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, true, alerts[0].GetBaseRuntimeAlert().Arguments["success"])
+}
+
+func TestRespondKillOtherContainer(t *testing.T) {
+	rm, exporter := createTestResponseManager(t, ResponseConfig{Policies: []ResponsePolicy{{Actions: []string{"kill"}}}})
+	addProcess(t, rm.procDir, 1234, "0::/kubepods/another-container")
+
+	rm.respond(rm.createJob(testTarget(1234, 1234, "/bin/sleep")))
+
+	alerts := exporter.getAlerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, false, alerts[0].GetBaseRuntimeAlert().Arguments["success"])
+	assert.Contains(t, alerts[0].GetBaseRuntimeAlert().Arguments["error"], "does not belong to container")
+}
+
+func TestRespondFreeze(t *testing.T) {
+	cgroupRoot := t.TempDir()
+	rm, exporter := createTestResponseManager(t, ResponseConfig{CgroupRoot: cgroupRoot, Policies: []ResponsePolicy{{Actions: []string{"freeze"}}}})
+	cgroupPath := filepath.Join(cgroupRoot, "kubepods", testContainerID)
+	require.NoError(t, os.MkdirAll(cgroupPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cgroupPath, "cgroup.freeze"), []byte("0"), 0644))
+	addProcess(t, rm.procDir, 1234, "0::/kubepods/"+testContainerID+"\n")
+
+	rm.respond(rm.createJob(testTarget(1234, 1234, "")))
+
+	state, err := os.ReadFile(filepath.Join(cgroupPath, "cgroup.freeze"))
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(state))
+	alerts := exporter.getAlerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "Froze container "+testContainerID+" in response to xmrig", alerts[0].GetRuleAlert().RuleDescription)
+}
+
+func TestFreezerPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		cgroups   string
+		wantPath  string
+		wantState string
+		wantErr   bool
+	}{
+		{
+			name:      "cgroup v2",
+			cgroups:   "0::/kubepods/pod1/abc\n",
+			wantPath:  "/sys/fs/cgroup/kubepods/pod1/abc/cgroup.freeze",
+			wantState: "1",
+		},
+		{
+			name:      "cgroup v1",
+			cgroups:   "12:memory:/kubepods/pod1/abc\n7:freezer:/kubepods/pod1/abc\n",
+			wantPath:  "/sys/fs/cgroup/freezer/kubepods/pod1/abc/freezer.state",
+			wantState: "FROZEN",
+		},
+		{
+			name:      "hybrid prefers the freezer controller",
+			cgroups:   "0::/kubepods/pod1/abc\n3:cpu,freezer:/kubepods/pod1/abc\n",
+			wantPath:  "/sys/fs/cgroup/freezer/kubepods/pod1/abc/freezer.state",
+			wantState: "FROZEN",
+		},
+		{
+			name:    "no freezer",
+			cgroups: "12:memory:/kubepods/pod1/abc\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, state, err := freezerPath("/sys/fs/cgroup", []byte(tt.cgroups))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPath, path)
+			assert.Equal(t, tt.wantState, state)
+		})
+	}
+}
+
+func TestResponseManagerAlerts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exporter := &auditExporterMock{}
+	rm, err := CreateResponseManager(ctx, ResponseConfig{Enabled: true, DryRun: true, Policies: []ResponsePolicy{
+		{AlertTypes: []string{"rule"}, RuleIDs: []string{"R1000"}, Actions: []string{"kill"}},
+		{AlertTypes: []string{"malware"}, MinSeverity: "critical", Actions: []string{"freeze"}},
+	}}, exporter)
+	require.NoError(t, err)
+
+	rm.RespondToRuleAlert(&ruleenginev1.GenericRuleFailure{
+		BaseRuntimeAlert:      apitypes.BaseRuntimeAlert{AlertName: "Exec from malicious source", Severity: ruleengine.RulePriorityHigh},
+		RuntimeProcessDetails: apitypes.ProcessTree{ProcessTree: apitypes.Process{PID: 42, Comm: "sh"}, ContainerID: testContainerID},
+		RuleAlert:             apitypes.RuleAlert{RuleID: "R1000"},
+	}, 1)
+	// ignored, the rule does not match
+	rm.RespondToRuleAlert(&ruleenginev1.GenericRuleFailure{
+		RuleAlert: apitypes.RuleAlert{RuleID: "R0001"},
+	}, 1)
+	// ignored, the severity is too low
+	rm.RespondToMalwareAlert(&mmtypes.GenericMalwareResult{
+		BasicRuntimeAlert: apitypes.BaseRuntimeAlert{AlertName: "eicar", Severity: ruleengine.RulePriorityHigh},
+	}, 1)
+	rm.RespondToMalwareAlert(&mmtypes.GenericMalwareResult{
+		BasicRuntimeAlert:     apitypes.BaseRuntimeAlert{AlertName: "xmrig", Severity: ruleengine.RulePriorityCritical, InfectedPID: 43},
+		RuntimeProcessDetails: apitypes.ProcessTree{ContainerID: testContainerID},
+	}, 1)
+
+	require.Eventually(t, func() bool { return len(exporter.getAlerts()) == 2 }, time.Second, 10*time.Millisecond)
+	alerts := exporter.getAlerts()
+	assert.Equal(t, "kill", alerts[0].GetBaseRuntimeAlert().Arguments["action"])
+	assert.Equal(t, "R1000", alerts[0].GetBaseRuntimeAlert().Arguments["triggerRuleID"])
+	assert.Equal(t, uint32(42), alerts[0].GetBaseRuntimeAlert().InfectedPID)
+	assert.Equal(t, "freeze", alerts[1].GetBaseRuntimeAlert().Arguments["action"])
+	assert.Equal(t, uint32(43), alerts[1].GetBaseRuntimeAlert().InfectedPID)
+}
@@ -29,6 +29,13 @@ import (
 var _ rulebindingmanager.RuleBindingCache = (*RBCache)(nil)
 var _ watcher.Adaptor = (*RBCache)(nil)
 
+// invalidCustomRule keeps the compilation error of a custom rule so it can be reported when a binding references it
+type invalidCustomRule struct {
+	id   string
+	name string
+	err  error
+}
+
 type RBCache struct {
 	nodeName         string
 	k8sClient        k8sclient.K8sClientInterface
@@ -38,6 +45,8 @@ type RBCache struct {
 	rbNameToRules    maps.SafeMap[string, []ruleengine.RuleEvaluator]      // rule binding name -> []created rules
 	rbNameToPodNames maps.SafeMap[string, mapset.Set[string]]              // rule binding name -> []pod names
 	ruleCreator      ruleengine.RuleCreator
+	customRules      maps.SafeMap[string, ruleenginev1.RuleDescriptor] // custom rule name -> compiled rule descriptor
+	invalidRules     maps.SafeMap[string, invalidCustomRule]           // custom rule name -> compilation error
 	watchResources   []watcher.WatchResource
 	notifiers        []*chan rulebindingmanager.RuleBindingNotify
 }
@@ -104,6 +113,13 @@ func (c *RBCache) AddHandler(ctx context.Context, obj *unstructured.Unstructured
 			return
 		}
 		rbs = c.addRuleBinding(ruleBinding)
+	case types.RuntimeRuleKind:
+		rule, err := unstructuredToRuntimeRule(obj)
+		if err != nil {
+			logger.L().Error("failed to convert unstructured to runtime rule", helpers.Error(err))
+			return
+		}
+		c.addCustomRule(rule)
 	}
 	// notify
 	for n := range c.notifiers {
@@ -130,6 +146,13 @@ func (c *RBCache) ModifyHandler(ctx context.Context, obj *unstructured.Unstructu
 			return
 		}
 		rbs = c.modifiedRuleBinding(ruleBinding)
+	case types.RuntimeRuleKind:
+		rule, err := unstructuredToRuntimeRule(obj)
+		if err != nil {
+			logger.L().Error("failed to convert unstructured to runtime rule", helpers.Error(err))
+			return
+		}
+		c.addCustomRule(rule)
 	}
 	// notify
 	for n := range c.notifiers {
@@ -145,6 +168,8 @@ func (c *RBCache) DeleteHandler(_ context.Context, obj *unstructured.Unstructure
 		c.deletePod(unstructuredUniqueName(obj))
 	case types.RuntimeRuleBindingAlertKind:
 		rbs = c.deleteRuleBinding(unstructuredUniqueName(obj))
+	case types.RuntimeRuleKind:
+		c.deleteCustomRule(unstructuredUniqueName(obj))
 	}
 
 	// notify
@@ -183,7 +208,7 @@ func (c *RBCache) addRuleBinding(ruleBinding *typesv1.RuntimeAlertRuleBinding) [
 	// add the rule binding to the cache
 	c.rbNameToRB.Set(rbName, *ruleBinding)
 	c.rbNameToPodNames.Set(rbName, mapset.NewSet[string]())
-	c.rbNameToRules.Set(rbName, c.createRules(ruleBinding.Spec.Rules))
+	c.rbNameToRules.Set(rbName, c.createRules(rbName, ruleBinding.Spec.Rules))
 
 	var namespaces *corev1.NamespaceList
 	// if ruleBinding.GetNamespace() == "" {
@@ -352,17 +377,67 @@ func (c *RBCache) deletePod(uniqueName string) {
 	c.podToRBNames.Delete(uniqueName)
 }
 
-func (c *RBCache) createRules(rulesForPod []typesv1.RuntimeAlertRuleBindingRule) []ruleengine.RuleEvaluator {
+// ----------------- Custom rule methods -----------------
+
+// addCustomRule compiles a custom rule and refreshes the rules of the bindings so they pick up the change
+func (c *RBCache) addCustomRule(rule *typesv1.RuntimeRule) {
+	name := uniqueName(rule.GetNamespace(), rule.GetName())
+	logger.L().Info("RuntimeRule added/modified", helpers.String("name", name), helpers.String("ruleID", rule.Spec.ID))
+
+	descriptor, err := ruleenginev1.CreateCELRuleDescriptor(ruleenginev1.CELRuleSpecFromRuntimeRule(rule.Spec))
+	if err != nil {
+		logger.L().Error("failed to compile runtime rule", helpers.String("name", name), helpers.Error(err))
+		c.customRules.Delete(name)
+		c.invalidRules.Set(name, invalidCustomRule{id: rule.Spec.ID, name: rule.Spec.Name, err: err})
+	} else {
+		c.invalidRules.Delete(name)
+		c.customRules.Set(name, descriptor)
+	}
+	c.refreshRules()
+}
+
+func (c *RBCache) deleteCustomRule(uniqueName string) {
+	logger.L().Info("RuntimeRule deleted", helpers.String("name", uniqueName))
+	c.customRules.Delete(uniqueName)
+	c.invalidRules.Delete(uniqueName)
+	c.refreshRules()
+}
+
+// refreshRules re-creates the rules of all the rule bindings, the pods bound to each binding are not affected
+func (c *RBCache) refreshRules() {
+	for _, rbName := range c.rbNameToRB.Keys() {
+		rb := c.rbNameToRB.Get(rbName)
+		c.rbNameToRules.Set(rbName, c.createRules(rbName, rb.Spec.Rules))
+	}
+}
+
+func (c *RBCache) createRules(rbName string, rulesForPod []typesv1.RuntimeAlertRuleBindingRule) []ruleengine.RuleEvaluator {
 	rules := []ruleengine.RuleEvaluator{}
 	// Get the rules that are bound to the container
 	for _, ruleParams := range rulesForPod {
-		rules = append(rules, c.createRule(&ruleParams)...)
+		c.reportInvalidRules(rbName, &ruleParams)
+		rules = append(rules, setRulesSeverity(rbName, setRulesExceptions(rbName, c.createRule(&ruleParams), ruleParams.Exceptions), ruleParams.Severity)...)
 	}
 	return rules
 }
+
+// reportInvalidRules logs the compilation errors of the custom rules referenced by the binding
+func (c *RBCache) reportInvalidRules(rbName string, r *typesv1.RuntimeAlertRuleBindingRule) {
+	c.invalidRules.Range(func(name string, invalid invalidCustomRule) bool {
+		if (r.RuleID != "" && r.RuleID == invalid.id) || (r.RuleName != "" && r.RuleName == invalid.name) {
+			logger.L().Error("rule binding references a runtime rule that failed to compile", helpers.String("ruleBinding", rbName), helpers.String("runtimeRule", name), helpers.Error(invalid.err))
+		}
+		return true
+	})
+}
+
 func (c *RBCache) createRule(r *typesv1.RuntimeAlertRuleBindingRule) []ruleengine.RuleEvaluator {
 
 	if r.RuleID != "" {
+		// custom rules take precedence over the built-in rules
+		if rules := c.createCustomRules(func(desc *ruleenginev1.RuleDescriptor) bool { return desc.ID == r.RuleID }); len(rules) > 0 {
+			return setRulesParameters(rules, r.Parameters)
+		}
 		if ruleDesc := c.ruleCreator.CreateRuleByID(r.RuleID); ruleDesc != nil {
 			if r.Parameters != nil {
 				ruleDesc.SetParameters(r.Parameters)
@@ -371,6 +446,9 @@ func (c *RBCache) createRule(r *typesv1.RuntimeAlertRuleBindingRule) []ruleengin
 		}
 	}
 	if r.RuleName != "" {
+		if rules := c.createCustomRules(func(desc *ruleenginev1.RuleDescriptor) bool { return desc.Name == r.RuleName }); len(rules) > 0 {
+			return setRulesParameters(rules, r.Parameters)
+		}
 		if ruleDesc := c.ruleCreator.CreateRuleByName(r.RuleName); ruleDesc != nil {
 			if r.Parameters != nil {
 				ruleDesc.SetParameters(r.Parameters)
@@ -379,18 +457,72 @@ func (c *RBCache) createRule(r *typesv1.RuntimeAlertRuleBindingRule) []ruleengin
 		}
 	}
 	if len(r.RuleTags) > 0 {
-		if ruleTagsDescs := c.ruleCreator.CreateRulesByTags(r.RuleTags); ruleTagsDescs != nil {
-			for _, ruleDesc := range ruleTagsDescs {
-				if r.Parameters != nil {
-					ruleDesc.SetParameters(r.Parameters)
-				}
-			}
-			return ruleTagsDescs
+		ruleTagsDescs := c.ruleCreator.CreateRulesByTags(r.RuleTags)
+		ruleTagsDescs = append(ruleTagsDescs, c.createCustomRules(func(desc *ruleenginev1.RuleDescriptor) bool { return desc.HasTags(r.RuleTags) })...)
+		if len(ruleTagsDescs) > 0 {
+			return setRulesParameters(ruleTagsDescs, r.Parameters)
 		}
 	}
 	return []ruleengine.RuleEvaluator{}
 }
 
+// createCustomRules creates the custom rules whose descriptor matches
+func (c *RBCache) createCustomRules(match func(desc *ruleenginev1.RuleDescriptor) bool) []ruleengine.RuleEvaluator {
+	var rules []ruleengine.RuleEvaluator
+	for _, desc := range c.customRules.Values() {
+		if match(&desc) {
+			rules = append(rules, desc.CreateRule())
+		}
+	}
+	return rules
+}
+
+func setRulesParameters(rules []ruleengine.RuleEvaluator, parameters map[string]interface{}) []ruleengine.RuleEvaluator {
+	if parameters == nil {
+		return rules
+	}
+	for _, rule := range rules {
+		rule.SetParameters(parameters)
+	}
+	return rules
+}
+
+// setRulesExceptions sets the binding exceptions on the rules, invalid exceptions are logged and ignored
+func setRulesExceptions(rbName string, rules []ruleengine.RuleEvaluator, exceptions []typesv1.RuntimeAlertRuleBindingException) []ruleengine.RuleEvaluator {
+	if len(exceptions) == 0 {
+		return rules
+	}
+	ruleExceptions := make([]ruleengine.RuleException, 0, len(exceptions))
+	for i := range exceptions {
+		exception, err := toRuleException(&exceptions[i])
+		if err != nil {
+			logger.L().Error("invalid rule binding exception", helpers.String("ruleBinding", rbName), helpers.Int("index", i), helpers.Error(err))
+			continue
+		}
+		ruleExceptions = append(ruleExceptions, exception)
+	}
+	for _, rule := range rules {
+		rule.SetExceptions(ruleExceptions)
+	}
+	return rules
+}
+
+// setRulesSeverity overrides the severity of the rules with the binding severity, an invalid severity is logged and ignored
+func setRulesSeverity(rbName string, rules []ruleengine.RuleEvaluator, severity string) []ruleengine.RuleEvaluator {
+	if severity == "" {
+		return rules
+	}
+	priority, err := ruleengine.ParseSeverity(severity)
+	if err != nil {
+		logger.L().Error("invalid rule binding severity", helpers.String("ruleBinding", rbName), helpers.Error(err))
+		return rules
+	}
+	for _, rule := range rules {
+		rule.SetSeverity(priority)
+	}
+	return rules
+}
+
 func diff(a, b []rulebindingmanager.RuleBindingNotify) []rulebindingmanager.RuleBindingNotify {
 	m := make(map[string]rulebindingmanager.RuleBindingNotify)
 	diff := make([]rulebindingmanager.RuleBindingNotify, 0)
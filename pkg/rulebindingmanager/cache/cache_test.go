@@ -7,6 +7,7 @@ import (
 	"node-agent/pkg/rulebindingmanager"
 	typesv1 "node-agent/pkg/rulebindingmanager/types/v1"
 	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
 	"slices"
 	"testing"
 
@@ -286,6 +287,46 @@ func TestCreateRule(t *testing.T) {
 	}
 }
 
+func TestCreateRulesExceptions(t *testing.T) {
+	c := NewCacheMock("")
+	rules := c.createRules("default/rb", []typesv1.RuntimeAlertRuleBindingRule{
+		{
+			RuleID: "rule-1",
+			Exceptions: []typesv1.RuntimeAlertRuleBindingException{
+				{Comms: []string{"logrotate"}, FilePaths: []string{"/var/log/**"}},
+				// invalid exceptions are ignored
+				{},
+				{CIDRs: []string{"not-a-cidr"}},
+			},
+		},
+		{RuleID: "rule-2"},
+	})
+	assert.Equal(t, 2, len(rules))
+	assert.Equal(t, []ruleengine.RuleException{{Comms: []string{"logrotate"}, FilePaths: []string{"/var/log/**"}}}, rules[0].GetExceptions())
+	assert.Empty(t, rules[1].GetExceptions())
+}
+
+func TestCreateRulesSeverity(t *testing.T) {
+	c := NewCacheMock("")
+	rules := c.createRules("default/rb", []typesv1.RuntimeAlertRuleBindingRule{
+		{RuleID: "rule-1", Severity: "critical"},
+		{RuleID: "rule-2", Severity: "3"},
+		// an invalid severity is ignored
+		{RuleID: "rule-3", Severity: "urgent"},
+		{RuleID: "rule-4"},
+	})
+	assert.Equal(t, 4, len(rules))
+	for i, want := range []int{ruleengine.RulePriorityCritical, 3} {
+		severity, ok := rules[i].GetSeverity()
+		assert.True(t, ok)
+		assert.Equal(t, want, severity)
+	}
+	for _, rule := range rules[2:] {
+		_, ok := rule.GetSeverity()
+		assert.False(t, ok)
+	}
+}
+
 func TestDeleteHandler(t *testing.T) {
 	type expected struct {
 		pod  string
@@ -1113,3 +1154,72 @@ func TestDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomRules(t *testing.T) {
+	runtimeRule := func(id, expression string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "kubescape.io/v1",
+				"kind":       "RuntimeRule",
+				"metadata": map[string]interface{}{
+					"name":      "custom-rule",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{
+					"id":         id,
+					"name":       "Custom rule",
+					"tags":       []interface{}{"custom"},
+					"eventTypes": []interface{}{"exec"},
+					"expression": expression,
+				},
+			},
+		}
+	}
+
+	c := NewCacheMock("")
+	c.rbNameToRB.Set("default/rb-id", typesv1.RuntimeAlertRuleBinding{
+		Spec: typesv1.RuntimeAlertRuleBindingSpec{
+			Rules: []typesv1.RuntimeAlertRuleBindingRule{
+				{RuleID: "C0001", Parameters: map[string]interface{}{"param1": "value1"}},
+			},
+		},
+	})
+	c.rbNameToRB.Set("default/rb-tags", typesv1.RuntimeAlertRuleBinding{
+		Spec: typesv1.RuntimeAlertRuleBindingSpec{
+			Rules: []typesv1.RuntimeAlertRuleBindingRule{
+				{RuleTags: []string{"custom"}},
+			},
+		},
+	})
+
+	// valid rule - the bindings pick up the custom rule
+	c.AddHandler(context.Background(), runtimeRule("C0001", `event.comm == "nc"`))
+	assert.True(t, c.customRules.Has("default/custom-rule"))
+	rules := c.rbNameToRules.Get("default/rb-id")
+	assert.Equal(t, 1, len(rules))
+	_, isCustom := rules[0].(*ruleenginev1.CELRule)
+	assert.True(t, isCustom)
+	assert.Equal(t, map[string]interface{}{"param1": "value1"}, rules[0].GetParameters())
+	rules = c.rbNameToRules.Get("default/rb-tags")
+	assert.Equal(t, 2, len(rules))
+	assert.Equal(t, "C0001", rules[1].ID())
+
+	// the expression does not compile - the rule is removed and the error is kept
+	c.ModifyHandler(context.Background(), runtimeRule("C0001", `event.comm ==`))
+	assert.False(t, c.customRules.Has("default/custom-rule"))
+	assert.True(t, c.invalidRules.Has("default/custom-rule"))
+	assert.Error(t, c.invalidRules.Get("default/custom-rule").err)
+	rules = c.rbNameToRules.Get("default/rb-tags")
+	assert.Equal(t, 1, len(rules))
+
+	// deleted rule
+	c.ModifyHandler(context.Background(), runtimeRule("C0001", `event.comm == "nc"`))
+	assert.True(t, c.customRules.Has("default/custom-rule"))
+	c.DeleteHandler(context.Background(), runtimeRule("C0001", ""))
+	assert.False(t, c.customRules.Has("default/custom-rule"))
+	assert.False(t, c.invalidRules.Has("default/custom-rule"))
+	rules = c.rbNameToRules.Get("default/rb-id")
+	assert.Equal(t, 1, len(rules))
+	_, isCustom = rules[0].(*ruleenginev1.CELRule)
+	assert.False(t, isCustom)
+}
@@ -2,10 +2,14 @@ package cache
 
 import (
 	"fmt"
+	"net"
 	typesv1 "node-agent/pkg/rulebindingmanager/types/v1"
+	"node-agent/pkg/ruleengine"
 	"node-agent/pkg/watcher"
 	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
+
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 
 	corev1 "k8s.io/api/core/v1"
@@ -41,6 +45,13 @@ func unstructuredToRuleBinding(obj *unstructured.Unstructured) (*typesv1.Runtime
 	}
 	return rb, nil
 }
+func unstructuredToRuntimeRule(obj *unstructured.Unstructured) (*typesv1.RuntimeRule, error) {
+	rule := &typesv1.RuntimeRule{}
+	if err := k8sruntime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
 func unstructuredToPod(obj *unstructured.Unstructured) (*corev1.Pod, error) {
 	pod := &corev1.Pod{}
 	if err := k8sruntime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, pod); err != nil {
@@ -68,5 +79,46 @@ func resourcesToWatch(nodeName string) []watcher.WatchResource {
 	rb := watcher.NewWatchResource(typesv1.RuleBindingAlertGvr, metav1.ListOptions{})
 	w = append(w, rb)
 
+	// add custom rules
+	rr := watcher.NewWatchResource(typesv1.RuntimeRuleGvr, metav1.ListOptions{})
+	w = append(w, rr)
+
 	return w
 }
+
+// toRuleException validates the binding exception and converts it to a rule exception
+func toRuleException(e *typesv1.RuntimeAlertRuleBindingException) (ruleengine.RuleException, error) {
+	exception := ruleengine.RuleException{
+		ProcessPaths:   e.ProcessPaths,
+		Comms:          e.Comms,
+		FilePaths:      e.FilePaths,
+		Domains:        e.Domains,
+		ContainerNames: e.ContainerNames,
+	}
+	if len(e.ProcessPaths)+len(e.Comms)+len(e.FilePaths)+len(e.Domains)+len(e.CIDRs)+len(e.ContainerNames) == 0 {
+		return ruleengine.RuleException{}, fmt.Errorf("exception must match on at least one field")
+	}
+	for _, globs := range [][]string{e.ProcessPaths, e.FilePaths, e.Domains} {
+		for _, glob := range globs {
+			if !doublestar.ValidatePattern(glob) {
+				return ruleengine.RuleException{}, fmt.Errorf("invalid pattern %q", glob)
+			}
+		}
+	}
+	for _, cidr := range e.CIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return ruleengine.RuleException{}, fmt.Errorf("invalid CIDR %q", cidr)
+			}
+			// a single address
+			ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}
+		}
+		exception.CIDRs = append(exception.CIDRs, ipNet)
+	}
+	if e.ExpiresAt != nil {
+		exception.ExpiresAt = e.ExpiresAt.Time
+	}
+	return exception, nil
+}
@@ -1,8 +1,10 @@
 package cache
 
 import (
+	"net"
 	typesv1 "node-agent/pkg/rulebindingmanager/types/v1"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -29,7 +31,7 @@ func TestResourcesToWatch(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			result := resourcesToWatch(tt.nodeName)
 
-			assert.Equal(t, 2, len(result))
+			assert.Equal(t, 3, len(result))
 
 			podResource := result[0]
 			assert.Equal(t, "v1", podResource.GroupVersionResource().Version)
@@ -39,6 +41,10 @@ func TestResourcesToWatch(t *testing.T) {
 			rbResource := result[1]
 			assert.Equal(t, typesv1.RuleBindingAlertGvr, rbResource.GroupVersionResource())
 			assert.Equal(t, metav1.ListOptions{}, rbResource.ListOptions())
+
+			rrResource := result[2]
+			assert.Equal(t, typesv1.RuntimeRuleGvr, rrResource.GroupVersionResource())
+			assert.Equal(t, metav1.ListOptions{}, rrResource.ListOptions())
 		})
 	}
 }
@@ -364,3 +370,52 @@ func TestPodUniqueName(t *testing.T) {
 		})
 	}
 }
+
+func TestToRuleException(t *testing.T) {
+	expiresAt := metav1.NewTime(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name      string
+		exception typesv1.RuntimeAlertRuleBindingException
+		wantErr   bool
+	}{
+		{
+			name:      "empty exception",
+			exception: typesv1.RuntimeAlertRuleBindingException{},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid glob",
+			exception: typesv1.RuntimeAlertRuleBindingException{FilePaths: []string{"/var/log/["}},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid CIDR",
+			exception: typesv1.RuntimeAlertRuleBindingException{CIDRs: []string{"10.0.0.0/33"}},
+			wantErr:   true,
+		},
+		{
+			name: "valid exception",
+			exception: typesv1.RuntimeAlertRuleBindingException{
+				ProcessPaths: []string{"/usr/bin/*"},
+				Domains:      []string{"*.example.com"},
+				CIDRs:        []string{"10.0.0.0/8", "192.168.1.1"},
+				ExpiresAt:    &expiresAt,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exception, err := toRuleException(&tt.exception)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.exception.ProcessPaths, exception.ProcessPaths)
+			assert.Equal(t, 2, len(exception.CIDRs))
+			assert.True(t, exception.CIDRs[1].Contains(net.ParseIP("192.168.1.1")))
+			assert.False(t, exception.CIDRs[1].Contains(net.ParseIP("192.168.1.2")))
+			assert.Equal(t, expiresAt.Time, exception.ExpiresAt)
+		})
+	}
+}
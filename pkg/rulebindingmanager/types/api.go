@@ -4,4 +4,6 @@ const (
 	RuleBinderGroup               string = "kubescape.io"
 	RuntimeRuleBindingAlertKind   string = "RuntimeRuleAlertBinding"
 	RuntimeRuleBindingAlertPlural string = "runtimerulealertbindings"
+	RuntimeRuleKind               string = "RuntimeRule"
+	RuntimeRulePlural             string = "runtimerules"
 )
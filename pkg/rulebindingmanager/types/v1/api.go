@@ -21,3 +21,9 @@ var RuleBindingAlertGvr schema.GroupVersionResource = schema.GroupVersionResourc
 	Version:  RuleBinderVersion,
 	Resource: types.RuntimeRuleBindingAlertPlural,
 }
+
+var RuntimeRuleGvr schema.GroupVersionResource = schema.GroupVersionResource{
+	Group:    types.RuleBinderGroup,
+	Version:  RuleBinderVersion,
+	Resource: types.RuntimeRulePlural,
+}
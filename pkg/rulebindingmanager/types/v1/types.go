@@ -27,11 +27,23 @@ type RuntimeAlertRuleBindingSpec struct {
 }
 
 type RuntimeAlertRuleBindingRule struct {
-	Parameters map[string]interface{} `json:"parameters" yaml:"parameters"`
-	RuleName   string                 `json:"ruleName" yaml:"ruleName"`
-	RuleID     string                 `json:"ruleID" yaml:"ruleID"`
-	Severity   string                 `json:"severity" yaml:"severity"`
-	RuleTags   []string               `json:"ruleTags" yaml:"ruleTags"`
+	Parameters map[string]interface{}             `json:"parameters" yaml:"parameters"`
+	RuleName   string                             `json:"ruleName" yaml:"ruleName"`
+	RuleID     string                             `json:"ruleID" yaml:"ruleID"`
+	Severity   string                             `json:"severity" yaml:"severity"`
+	RuleTags   []string                           `json:"ruleTags" yaml:"ruleTags"`
+	Exceptions []RuntimeAlertRuleBindingException `json:"exceptions,omitempty" yaml:"exceptions,omitempty"`
+}
+
+// RuntimeAlertRuleBindingException silences the alerts of the rule that match all its non empty fields
+type RuntimeAlertRuleBindingException struct {
+	ProcessPaths   []string     `json:"processPaths,omitempty" yaml:"processPaths,omitempty"`
+	Comms          []string     `json:"comms,omitempty" yaml:"comms,omitempty"`
+	FilePaths      []string     `json:"filePaths,omitempty" yaml:"filePaths,omitempty"`
+	Domains        []string     `json:"domains,omitempty" yaml:"domains,omitempty"`
+	CIDRs          []string     `json:"cidrs,omitempty" yaml:"cidrs,omitempty"`
+	ContainerNames []string     `json:"containerNames,omitempty" yaml:"containerNames,omitempty"`
+	ExpiresAt      *metav1.Time `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
 }
 
 func (r *RuntimeAlertRuleBindingRule) Equal(other *RuntimeAlertRuleBindingRule) bool {
@@ -50,5 +62,44 @@ func (r *RuntimeAlertRuleBindingRule) Equal(other *RuntimeAlertRuleBindingRule)
 	if !reflect.DeepEqual(r.Parameters, other.Parameters) {
 		return false
 	}
+	if !reflect.DeepEqual(r.Exceptions, other.Exceptions) {
+		return false
+	}
 	return true
 }
+
+type RuntimeRuleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	// Items is the list of RuntimeRule
+	Items []RuntimeRule `json:"items"`
+}
+
+// RuntimeRule is a custom rule defined by a CEL expression
+type RuntimeRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Specification of the custom rule
+	Spec RuntimeRuleSpec `json:"spec,omitempty"`
+}
+
+type RuntimeRuleSpec struct {
+	ID             string                    `json:"id" yaml:"id"`
+	Name           string                    `json:"name" yaml:"name"`
+	Description    string                    `json:"description" yaml:"description"`
+	Priority       int                       `json:"priority" yaml:"priority"`
+	Tags           []string                  `json:"tags" yaml:"tags"`
+	EventTypes     []string                  `json:"eventTypes,omitempty" yaml:"eventTypes,omitempty"`
+	Expression     string                    `json:"expression,omitempty" yaml:"expression,omitempty"`
+	Sequence       []RuntimeRuleSequenceStep `json:"sequence,omitempty" yaml:"sequence,omitempty"`
+	Window         string                    `json:"window,omitempty" yaml:"window,omitempty"`
+	KeyBy          string                    `json:"keyBy,omitempty" yaml:"keyBy,omitempty"`
+	Message        string                    `json:"message,omitempty" yaml:"message,omitempty"`
+	FixSuggestions string                    `json:"fixSuggestions,omitempty" yaml:"fixSuggestions,omitempty"`
+}
+
+// RuntimeRuleSequenceStep is a step of a correlation rule, the steps must match in order within the rule window
+type RuntimeRuleSequenceStep struct {
+	EventTypes []string `json:"eventTypes" yaml:"eventTypes"`
+	Expression string   `json:"expression" yaml:"expression"`
+}
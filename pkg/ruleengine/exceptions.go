@@ -0,0 +1,30 @@
+package ruleengine
+
+import (
+	"net"
+	"time"
+)
+
+// RuleException describes alerts of a rule that must not be reported.
+// An alert matches the exception when it matches all the non empty fields, and a field matches when any of its values matches.
+type RuleException struct {
+	// ProcessPaths are globs matched against the path of the process
+	ProcessPaths []string
+	// Comms are the process names
+	Comms []string
+	// FilePaths are globs matched against the opened file path
+	FilePaths []string
+	// Domains are globs matched against the requested domain, e.g. *.example.com
+	Domains []string
+	// CIDRs are matched against the destination address
+	CIDRs []*net.IPNet
+	// ContainerNames are the names of the containers
+	ContainerNames []string
+	// ExpiresAt is the time after which the exception is ignored, the exception never expires when it is zero
+	ExpiresAt time.Time
+}
+
+// Expired returns true if the exception is expired at the given time
+func (e *RuleException) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
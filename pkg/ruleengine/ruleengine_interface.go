@@ -42,6 +42,24 @@ type RuleEvaluator interface {
 
 	// Get rule parameters
 	GetParameters() map[string]interface{}
+
+	// Set rule exceptions
+	SetExceptions(exceptions []RuleException)
+
+	// Get rule exceptions
+	GetExceptions() []RuleException
+
+	// Set the severity of the rule alerts, overriding the rule priority
+	SetSeverity(severity int)
+
+	// Get the severity override, ok is false when the rule priority is used
+	GetSeverity() (severity int, ok bool)
+
+	// Set the tags of the rule, from its descriptor
+	SetTags(tags []string)
+
+	// Get the tags of the rule
+	GetTags() []string
 }
 
 // RuleSpec is an interface for rule requirements
@@ -61,6 +79,8 @@ type RuleFailure interface {
 	GetRuleAlert() apitypes.RuleAlert
 	// Get K8s Runtime Details
 	GetRuntimeAlertK8sDetails() apitypes.RuntimeAlertK8sDetails
+	// Get the tags of the rule that raised the alert
+	GetRuleTags() []string
 
 	// Set Workload Details
 	SetWorkloadDetails(workloadDetails string)
@@ -74,4 +94,6 @@ type RuleFailure interface {
 	SetRuleAlert(ruleAlert apitypes.RuleAlert)
 	// Set K8s Runtime Details
 	SetRuntimeAlertK8sDetails(runtimeAlertK8sDetails apitypes.RuntimeAlertK8sDetails)
+	// Set the tags of the rule that raised the alert
+	SetRuleTags(tags []string)
 }
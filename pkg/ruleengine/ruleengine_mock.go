@@ -30,6 +30,9 @@ var _ RuleEvaluator = (*RuleMock)(nil)
 type RuleMock struct {
 	RuleRequirements RuleSpec
 	RuleParameters   map[string]interface{}
+	RuleExceptions   []RuleException
+	RuleSeverity     *int
+	RuleTags         []string
 	RuleName         string
 	RuleID           string
 }
@@ -58,6 +61,27 @@ func (rule *RuleMock) GetParameters() map[string]interface{} {
 func (rule *RuleMock) SetParameters(p map[string]interface{}) {
 	rule.RuleParameters = p
 }
+func (rule *RuleMock) GetExceptions() []RuleException {
+	return rule.RuleExceptions
+}
+func (rule *RuleMock) SetExceptions(e []RuleException) {
+	rule.RuleExceptions = e
+}
+func (rule *RuleMock) GetSeverity() (int, bool) {
+	if rule.RuleSeverity == nil {
+		return 0, false
+	}
+	return *rule.RuleSeverity, true
+}
+func (rule *RuleMock) SetSeverity(severity int) {
+	rule.RuleSeverity = &severity
+}
+func (rule *RuleMock) GetTags() []string {
+	return rule.RuleTags
+}
+func (rule *RuleMock) SetTags(tags []string) {
+	rule.RuleTags = tags
+}
 
 var _ RuleSpec = (*RuleSpecMock)(nil)
 
@@ -0,0 +1,28 @@
+package ruleengine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseSeverity parses a severity name (none, low, medium, high, critical) or a numeric priority
+func ParseSeverity(severity string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "none":
+		return RulePriorityNone, nil
+	case "low":
+		return RulePriorityLow, nil
+	case "medium", "med":
+		return RulePriorityMed, nil
+	case "high":
+		return RulePriorityHigh, nil
+	case "critical":
+		return RulePriorityCritical, nil
+	}
+	priority, err := strconv.Atoi(strings.TrimSpace(severity))
+	if err != nil || priority < RulePriorityNone {
+		return 0, fmt.Errorf("invalid severity %q", severity)
+	}
+	return priority, nil
+}
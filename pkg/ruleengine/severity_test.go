@@ -0,0 +1,32 @@
+package ruleengine
+
+import "testing"
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		severity string
+		want     int
+		wantErr  bool
+	}{
+		{severity: "none", want: RulePriorityNone},
+		{severity: "Low", want: RulePriorityLow},
+		{severity: "medium", want: RulePriorityMed},
+		{severity: " HIGH ", want: RulePriorityHigh},
+		{severity: "critical", want: RulePriorityCritical},
+		{severity: "7", want: 7},
+		{severity: "-1", wantErr: true},
+		{severity: "urgent", wantErr: true},
+		{severity: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.severity, func(t *testing.T) {
+			got, err := ParseSeverity(tt.severity)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSeverity(%q) error = %v, wantErr %v", tt.severity, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSeverity(%q) = %d, want %d", tt.severity, got, tt.want)
+			}
+		})
+	}
+}
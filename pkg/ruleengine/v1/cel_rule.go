@@ -0,0 +1,638 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	typesv1 "node-agent/pkg/rulebindingmanager/types/v1"
+	"node-agent/pkg/ruleengine"
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+	"node-agent/pkg/utils"
+	"slices"
+	"strings"
+	"time"
+
+	tracerrandomxtype "node-agent/pkg/ebpf/gadgets/randomx/types"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+	tracercapabilitiestype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	tracerdnstype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	tracernetworktype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+)
+
+// CELRuleSpec describes a declarative rule whose logic is a CEL expression.
+//
+// The expression must evaluate to a bool and has access to the following variables:
+//   - eventType: the name of the event type (exec, open, capabilities, dns, network, syscall, randomx)
+//   - event: the event fields (see celEventFields for the per event type fields)
+//   - profile: the application profile of the container ({"available": bool, "execs": [{"path", "args"}], "opens": [{"path", "flags"}], "capabilities": [], "syscalls": []})
+//   - nn: the network neighborhood of the container ({"available": bool, "egressDomains": [], "egressAddresses": [], "ingressAddresses": []})
+//   - k8s: pod related details ({"mountPaths": [], "apiServerIP": string})
+//   - params: the parameters set by the rule binding
+//
+// The paths of the opens of the profile may hold wildcard segments, matchesOpenPath(profilePath, path) matches them,
+// e.g. profile.opens.exists(o, matchesOpenPath(o.path, event.path)).
+//
+// The optional message expression must evaluate to a string and is used as the alert description.
+//
+// Instead of a single expression, a rule can declare a sequence of steps that must match in order within the window,
+// for the events of the same container or process (see CorrelationSequence). The alert is raised on the event that
+// matches the last step, and the message is evaluated on that event.
+type CELRuleSpec struct {
+	ID             string
+	Name           string
+	Description    string
+	Priority       int
+	Tags           []string
+	EventTypes     []string
+	Expression     string
+	Sequence       []CELSequenceStep
+	Window         string
+	KeyBy          string
+	Message        string
+	FixSuggestions string
+}
+
+// CELSequenceStep is a step of a sequence rule, the expression has access to the same variables as a rule expression
+type CELSequenceStep struct {
+	EventTypes []string
+	Expression string
+}
+
+// CELRuleSpecFromRuntimeRule returns the spec of the CEL rule of a RuntimeRule
+func CELRuleSpecFromRuntimeRule(spec typesv1.RuntimeRuleSpec) CELRuleSpec {
+	sequence := make([]CELSequenceStep, 0, len(spec.Sequence))
+	for _, step := range spec.Sequence {
+		sequence = append(sequence, CELSequenceStep{EventTypes: step.EventTypes, Expression: step.Expression})
+	}
+	return CELRuleSpec{
+		ID:             spec.ID,
+		Name:           spec.Name,
+		Description:    spec.Description,
+		Priority:       spec.Priority,
+		Tags:           spec.Tags,
+		EventTypes:     spec.EventTypes,
+		Expression:     spec.Expression,
+		Sequence:       sequence,
+		Window:         spec.Window,
+		KeyBy:          spec.KeyBy,
+		Message:        spec.Message,
+		FixSuggestions: spec.FixSuggestions,
+	}
+}
+
+var celEnvOptions = []cel.EnvOption{
+	cel.Variable("eventType", cel.StringType),
+	cel.Variable("event", cel.MapType(cel.StringType, cel.DynType)),
+	cel.Variable("profile", cel.MapType(cel.StringType, cel.DynType)),
+	cel.Variable("nn", cel.MapType(cel.StringType, cel.DynType)),
+	cel.Variable("k8s", cel.MapType(cel.StringType, cel.DynType)),
+	cel.Variable("params", cel.MapType(cel.StringType, cel.DynType)),
+	ext.Strings(),
+	cel.CrossTypeNumericComparisons(true),
+	cel.Function("matchesOpenPath",
+		cel.Overload("matchesOpenPath_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.BoolType,
+			cel.BinaryBinding(func(pattern, path ref.Val) ref.Val {
+				return types.Bool(utils.MatchOpenPath(string(pattern.(types.String)), string(path.(types.String))))
+			}),
+		),
+	),
+}
+
+// CreateCELRuleDescriptor compiles the rule expressions and returns a descriptor for creating the rule.
+// Compilation errors are returned here so they are reported when the rule is loaded and not per event.
+func CreateCELRuleDescriptor(spec CELRuleSpec) (RuleDescriptor, error) {
+	if spec.ID == "" {
+		return RuleDescriptor{}, fmt.Errorf("rule ID is required")
+	}
+	if spec.Expression == "" && len(spec.Sequence) == 0 {
+		return RuleDescriptor{}, fmt.Errorf("rule %s: expression or sequence is required", spec.ID)
+	}
+	if spec.Expression != "" && len(spec.Sequence) > 0 {
+		return RuleDescriptor{}, fmt.Errorf("rule %s: expression and sequence are mutually exclusive", spec.ID)
+	}
+	if spec.Name == "" {
+		spec.Name = spec.ID
+	}
+
+	env, err := cel.NewEnv(celEnvOptions...)
+	if err != nil {
+		return RuleDescriptor{}, fmt.Errorf("rule %s: creating CEL environment: %w", spec.ID, err)
+	}
+
+	var eventTypes []utils.EventType
+	var program cel.Program
+	var sequence []celSequenceStep
+	if len(spec.Sequence) == 0 {
+		if eventTypes, err = parseEventTypes(spec.EventTypes); err != nil {
+			return RuleDescriptor{}, fmt.Errorf("rule %s: %w", spec.ID, err)
+		}
+		if program, err = compileCELExpression(env, spec.Expression, cel.BoolType); err != nil {
+			return RuleDescriptor{}, fmt.Errorf("rule %s: compiling expression: %w", spec.ID, err)
+		}
+	} else {
+		for i, step := range spec.Sequence {
+			stepEventTypes, err := parseEventTypes(step.EventTypes)
+			if err != nil {
+				return RuleDescriptor{}, fmt.Errorf("rule %s: step %d: %w", spec.ID, i, err)
+			}
+			stepProgram, err := compileCELExpression(env, step.Expression, cel.BoolType)
+			if err != nil {
+				return RuleDescriptor{}, fmt.Errorf("rule %s: step %d: compiling expression: %w", spec.ID, i, err)
+			}
+			sequence = append(sequence, celSequenceStep{eventTypes: stepEventTypes, program: stepProgram})
+			for _, eventType := range stepEventTypes {
+				if !slices.Contains(eventTypes, eventType) {
+					eventTypes = append(eventTypes, eventType)
+				}
+			}
+		}
+	}
+
+	var window time.Duration
+	keyBy := CorrelateByContainer
+	if len(sequence) > 0 {
+		if window, err = time.ParseDuration(spec.Window); err != nil || window <= 0 {
+			return RuleDescriptor{}, fmt.Errorf("rule %s: invalid window %q", spec.ID, spec.Window)
+		}
+		if spec.KeyBy != "" {
+			keyBy = CorrelationKeyBy(spec.KeyBy)
+		}
+		if keyBy != CorrelateByContainer && keyBy != CorrelateByProcess {
+			return RuleDescriptor{}, fmt.Errorf("rule %s: unknown keyBy %q, must be %q or %q", spec.ID, spec.KeyBy, CorrelateByContainer, CorrelateByProcess)
+		}
+	}
+
+	var message cel.Program
+	if spec.Message != "" {
+		if message, err = compileCELExpression(env, spec.Message, cel.StringType); err != nil {
+			return RuleDescriptor{}, fmt.Errorf("rule %s: compiling message: %w", spec.ID, err)
+		}
+	}
+
+	descriptor := RuleDescriptor{
+		ID:          spec.ID,
+		Name:        spec.Name,
+		Description: spec.Description,
+		Priority:    spec.Priority,
+		Tags:        spec.Tags,
+		Requirements: &RuleRequirements{
+			EventTypes: eventTypes,
+		},
+	}
+	descriptor.RuleCreationFunc = func() ruleengine.RuleEvaluator {
+		rule := &CELRule{
+			spec:       spec,
+			eventTypes: eventTypes,
+			program:    program,
+			message:    message,
+		}
+		if len(sequence) > 0 {
+			// each rule instance has its own correlation state
+			steps := make([]CorrelationStep, 0, len(sequence))
+			for _, step := range sequence {
+				step := step
+				steps = append(steps, CorrelationStep{
+					EventTypes: step.eventTypes,
+					Match: func(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) bool {
+						activation, _, ok := rule.activation(eventType, event, objCache)
+						return ok && rule.eval(step.program, activation)
+					},
+				})
+			}
+			rule.sequence = NewCorrelationTracker(CorrelationSequence{
+				Steps:  steps,
+				Window: window,
+				KeyBy:  keyBy,
+			})
+		}
+		return rule
+	}
+	return descriptor, nil
+}
+
+func parseEventTypes(names []string) ([]utils.EventType, error) {
+	eventTypes := make([]utils.EventType, 0, len(names))
+	for _, name := range names {
+		eventType, err := utils.EventTypeFromString(name)
+		if err != nil {
+			return nil, err
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("at least one event type is required")
+	}
+	return eventTypes, nil
+}
+
+type celSequenceStep struct {
+	eventTypes []utils.EventType
+	program    cel.Program
+}
+
+func compileCELExpression(env *cel.Env, expression string, outputType *cel.Type) (cel.Program, error) {
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if !ast.OutputType().IsExactType(outputType) && !ast.OutputType().IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("expression must evaluate to %s, got %s", outputType, ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+var _ ruleengine.RuleEvaluator = (*CELRule)(nil)
+
+// CELRule is a rule evaluator backed by a compiled CEL expression.
+// The compiled programs are shared between all the instances created from the same descriptor.
+type CELRule struct {
+	BaseRule
+	spec       CELRuleSpec
+	eventTypes []utils.EventType
+	program    cel.Program
+	message    cel.Program
+	sequence   *CorrelationTracker // nil for single expression rules
+}
+
+func (rule *CELRule) Name() string {
+	return rule.spec.Name
+}
+
+func (rule *CELRule) ID() string {
+	return rule.spec.ID
+}
+
+func (rule *CELRule) Requirements() ruleengine.RuleSpec {
+	return &RuleRequirements{
+		EventTypes: rule.eventTypes,
+	}
+}
+
+func (rule *CELRule) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) ruleengine.RuleFailure {
+	if rule.sequence != nil {
+		if !rule.sequence.ProcessEvent(eventType, event, objCache) {
+			return nil
+		}
+	}
+
+	activation, details, ok := rule.activation(eventType, event, objCache)
+	if !ok {
+		return nil
+	}
+	if rule.sequence == nil && !rule.eval(rule.program, activation) {
+		return nil
+	}
+
+	description := rule.spec.Description
+	if rule.message != nil {
+		if msg, _, err := rule.message.Eval(activation); err == nil {
+			if s, ok := msg.Value().(string); ok {
+				description = s
+			}
+		} else {
+			logger.L().Debug("CELRule - failed to evaluate message", helpers.String("rule", rule.ID()), helpers.Error(err))
+		}
+	}
+
+	containerID := details.triggerEvent.Runtime.ContainerID
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:   rule.Name(),
+			InfectedPID: details.process.PID,
+			Arguments: map[string]interface{}{
+				"eventType": eventType.String(),
+			},
+			FixSuggestions: rule.spec.FixSuggestions,
+			Severity:       rule.spec.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: details.process,
+			ContainerID: containerID,
+		},
+		TriggerEvent: details.triggerEvent,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: description,
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: details.triggerEvent.GetPod(),
+		},
+	}
+
+	return &ruleFailure
+}
+
+// activation returns the variables of the expressions for the event
+func (rule *CELRule) activation(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) (map[string]any, celEventDetails, bool) {
+	fields, details, ok := celEventFields(eventType, event)
+	if !ok {
+		return nil, details, false
+	}
+
+	containerID := details.triggerEvent.Runtime.ContainerID
+	return map[string]any{
+		"eventType": eventType.String(),
+		"event":     fields,
+		"params":    rule.GetParameters(),
+		// the object cache lookups are lazy so expressions that do not use them do not pay for them
+		"profile": func() ref.Val {
+			return types.DefaultTypeAdapter.NativeToValue(celProfileFields(objCache, containerID, details.triggerEvent.GetContainer()))
+		},
+		"nn": func() ref.Val {
+			return types.DefaultTypeAdapter.NativeToValue(celNetworkNeighborhoodFields(objCache, containerID, details.triggerEvent.GetContainer()))
+		},
+		"k8s": func() ref.Val {
+			return types.DefaultTypeAdapter.NativeToValue(celK8sFields(objCache, &details.triggerEvent))
+		},
+	}, details, true
+}
+
+func (rule *CELRule) eval(program cel.Program, activation map[string]any) bool {
+	out, _, err := program.Eval(activation)
+	if err != nil {
+		logger.L().Debug("CELRule - failed to evaluate expression", helpers.String("rule", rule.ID()), helpers.Error(err))
+		return false
+	}
+	matched, ok := out.Value().(bool)
+	return ok && matched
+}
+
+type celEventDetails struct {
+	triggerEvent igtypes.Event
+	process      apitypes.Process
+}
+
+// celEventFields flattens an event to the map exposed to CEL expressions as "event".
+// Numeric fields are exposed as int so they can be compared with integer literals.
+func celEventFields(eventType utils.EventType, event interface{}) (map[string]any, celEventDetails, bool) {
+	var details celEventDetails
+	var fields map[string]any
+
+	switch eventType {
+	case utils.ExecveEventType:
+		e, ok := event.(*tracerexectype.Event)
+		if !ok {
+			return nil, details, false
+		}
+		fields = map[string]any{
+			"pid":        int64(e.Pid),
+			"ppid":       int64(e.Ppid),
+			"comm":       e.Comm,
+			"pcomm":      e.Pcomm,
+			"uid":        int64(e.Uid),
+			"gid":        int64(e.Gid),
+			"path":       getExecPathFromEvent(e),
+			"fullPath":   getExecFullPathFromEvent(e),
+			"args":       utils.GetExecArgsFromEvent(e),
+			"cwd":        e.Cwd,
+			"exePath":    e.ExePath,
+			"upperLayer": e.UpperLayer,
+		}
+		details.triggerEvent = e.Event
+		details.process = apitypes.Process{
+			Comm:       e.Comm,
+			PID:        e.Pid,
+			PPID:       e.Ppid,
+			Pcomm:      e.Pcomm,
+			Uid:        &e.Uid,
+			Gid:        &e.Gid,
+			UpperLayer: e.UpperLayer,
+			Cwd:        e.Cwd,
+			Hardlink:   e.ExePath,
+			Cmdline:    fmt.Sprintf("%s %s", getExecPathFromEvent(e), strings.Join(utils.GetExecArgsFromEvent(e), " ")),
+		}
+	case utils.OpenEventType:
+		e, ok := event.(*traceropentype.Event)
+		if !ok {
+			return nil, details, false
+		}
+		fields = map[string]any{
+			"pid":      int64(e.Pid),
+			"comm":     e.Comm,
+			"uid":      int64(e.Uid),
+			"gid":      int64(e.Gid),
+			"path":     e.FullPath,
+			"flags":    e.Flags,
+			"flagsRaw": int64(e.FlagsRaw),
+			"mode":     e.Mode,
+			"ret":      int64(e.Ret),
+		}
+		details.triggerEvent = e.Event
+		details.process = apitypes.Process{Comm: e.Comm, PID: e.Pid, Uid: &e.Uid, Gid: &e.Gid}
+	case utils.CapabilitiesEventType:
+		e, ok := event.(*tracercapabilitiestype.Event)
+		if !ok {
+			return nil, details, false
+		}
+		fields = map[string]any{
+			"pid":     int64(e.Pid),
+			"comm":    e.Comm,
+			"uid":     int64(e.Uid),
+			"gid":     int64(e.Gid),
+			"capName": e.CapName,
+			"syscall": e.Syscall,
+			"verdict": e.Verdict,
+		}
+		details.triggerEvent = e.Event
+		details.process = apitypes.Process{Comm: e.Comm, PID: e.Pid, Uid: &e.Uid, Gid: &e.Gid}
+	case utils.DnsEventType:
+		e, ok := event.(*tracerdnstype.Event)
+		if !ok {
+			return nil, details, false
+		}
+		fields = map[string]any{
+			"pid":       int64(e.Pid),
+			"comm":      e.Comm,
+			"uid":       int64(e.Uid),
+			"gid":       int64(e.Gid),
+			"dnsName":   e.DNSName,
+			"qtype":     e.QType,
+			"addresses": e.Addresses,
+			"dstIP":     e.DstIP,
+			"dstPort":   int64(e.DstPort),
+			"protocol":  e.Protocol,
+		}
+		details.triggerEvent = e.Event
+		details.process = apitypes.Process{Comm: e.Comm, PID: e.Pid, Uid: &e.Uid, Gid: &e.Gid}
+	case utils.NetworkEventType:
+		e, ok := event.(*tracernetworktype.Event)
+		if !ok {
+			return nil, details, false
+		}
+		fields = map[string]any{
+			"pid":     int64(e.Pid),
+			"comm":    e.Comm,
+			"uid":     int64(e.Uid),
+			"gid":     int64(e.Gid),
+			"pktType": e.PktType,
+			"proto":   e.Proto,
+			"port":    int64(e.Port),
+			"dstAddr": e.DstEndpoint.Addr,
+			"dstKind": string(e.DstEndpoint.Kind),
+			"dstName": e.DstEndpoint.Name,
+		}
+		details.triggerEvent = e.Event
+		details.process = apitypes.Process{Comm: e.Comm, PID: e.Pid, Uid: &e.Uid, Gid: &e.Gid}
+	case utils.SyscallEventType:
+		e, ok := event.(*ruleenginetypes.SyscallEvent)
+		if !ok {
+			return nil, details, false
+		}
+		fields = map[string]any{
+			"pid":     int64(e.Pid),
+			"comm":    e.Comm,
+			"uid":     int64(e.Uid),
+			"gid":     int64(e.Gid),
+			"syscall": e.SyscallName,
+		}
+		details.triggerEvent = e.Event
+		details.process = apitypes.Process{Comm: e.Comm, PID: e.Pid, Uid: &e.Uid, Gid: &e.Gid}
+	case utils.RandomXEventType:
+		e, ok := event.(*tracerrandomxtype.Event)
+		if !ok {
+			return nil, details, false
+		}
+		fields = map[string]any{
+			"pid":        int64(e.Pid),
+			"ppid":       int64(e.PPid),
+			"comm":       e.Comm,
+			"uid":        int64(e.Uid),
+			"gid":        int64(e.Gid),
+			"upperLayer": e.UpperLayer,
+		}
+		details.triggerEvent = e.Event
+		details.process = apitypes.Process{Comm: e.Comm, PID: e.Pid, PPID: e.PPid, Uid: &e.Uid, Gid: &e.Gid, UpperLayer: e.UpperLayer}
+	default:
+		source, ok := utils.DescribeSourceEvent(eventType, event)
+		if !ok {
+			return nil, details, false
+		}
+		fields = map[string]any{
+			"pid":     int64(source.Process.Pid),
+			"ppid":    int64(source.Process.Ppid),
+			"comm":    source.Process.Comm,
+			"uid":     int64(source.Process.Uid),
+			"gid":     int64(source.Process.Gid),
+			"syscall": source.Syscall,
+		}
+		for name, value := range source.Fields {
+			fields[name] = value
+		}
+		details.triggerEvent = *source.Event
+		details.process = apitypes.Process{Comm: source.Process.Comm, PID: source.Process.Pid, PPID: source.Process.Ppid, Uid: &source.Process.Uid, Gid: &source.Process.Gid}
+	}
+
+	fields["containerID"] = details.triggerEvent.Runtime.ContainerID
+	fields["containerName"] = details.triggerEvent.GetContainer()
+	fields["containerImage"] = details.triggerEvent.Runtime.ContainerImageName
+	fields["podName"] = details.triggerEvent.GetPod()
+	fields["namespace"] = details.triggerEvent.GetNamespace()
+	fields["timestamp"] = int64(details.triggerEvent.Timestamp)
+
+	return fields, details, true
+}
+
+func celProfileFields(objCache objectcache.ObjectCache, containerID, containerName string) map[string]any {
+	fields := map[string]any{
+		"available":    false,
+		"execs":        []any{},
+		"opens":        []any{},
+		"capabilities": []string{},
+		"syscalls":     []string{},
+	}
+	if objCache == nil {
+		return fields
+	}
+	ap := objCache.ApplicationProfileCache().GetApplicationProfile(containerID)
+	if ap == nil {
+		return fields
+	}
+	container, err := getContainerFromApplicationProfile(ap, containerName)
+	if err != nil {
+		return fields
+	}
+
+	execs := make([]any, 0, len(container.Execs))
+	for _, exec := range container.Execs {
+		execs = append(execs, map[string]any{"path": exec.Path, "args": exec.Args})
+	}
+	opens := make([]any, 0, len(container.Opens))
+	for _, open := range container.Opens {
+		opens = append(opens, map[string]any{"path": open.Path, "flags": open.Flags})
+	}
+
+	fields["available"] = true
+	fields["execs"] = execs
+	fields["opens"] = opens
+	fields["capabilities"] = container.Capabilities
+	fields["syscalls"] = container.Syscalls
+	return fields
+}
+
+func celNetworkNeighborhoodFields(objCache objectcache.ObjectCache, containerID, containerName string) map[string]any {
+	fields := map[string]any{
+		"available":        false,
+		"egressDomains":    []string{},
+		"egressAddresses":  []string{},
+		"ingressAddresses": []string{},
+	}
+	if objCache == nil {
+		return fields
+	}
+	nn := objCache.NetworkNeighborhoodCache().GetNetworkNeighborhood(containerID)
+	if nn == nil {
+		return fields
+	}
+	container, err := getContainerFromNetworkNeighborhood(nn, containerName)
+	if err != nil {
+		return fields
+	}
+
+	egressDomains := []string{}
+	egressAddresses := []string{}
+	for _, egress := range container.Egress {
+		if egress.DNS != "" {
+			egressDomains = append(egressDomains, egress.DNS)
+		}
+		egressDomains = append(egressDomains, egress.DNSNames...)
+		if egress.IPAddress != "" {
+			egressAddresses = append(egressAddresses, egress.IPAddress)
+		}
+	}
+	ingressAddresses := []string{}
+	for _, ingress := range container.Ingress {
+		if ingress.IPAddress != "" {
+			ingressAddresses = append(ingressAddresses, ingress.IPAddress)
+		}
+	}
+
+	fields["available"] = true
+	fields["egressDomains"] = egressDomains
+	fields["egressAddresses"] = egressAddresses
+	fields["ingressAddresses"] = ingressAddresses
+	return fields
+}
+
+func celK8sFields(objCache objectcache.ObjectCache, event *igtypes.Event) map[string]any {
+	fields := map[string]any{
+		"mountPaths":  []string{},
+		"apiServerIP": "",
+	}
+	if objCache == nil {
+		return fields
+	}
+	if mounts, err := getContainerMountPaths(event.GetNamespace(), event.GetPod(), event.GetContainer(), objCache.K8sObjectCache()); err == nil {
+		fields["mountPaths"] = mounts
+	}
+	fields["apiServerIP"] = objCache.K8sObjectCache().GetApiServerIpAddress()
+	return fields
+}
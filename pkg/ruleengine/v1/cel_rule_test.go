@@ -0,0 +1,312 @@
+package ruleengine
+
+import (
+	"reflect"
+	"testing"
+
+	"node-agent/pkg/utils"
+
+	typesv1 "node-agent/pkg/rulebindingmanager/types/v1"
+
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func TestCreateCELRuleDescriptorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec CELRuleSpec
+	}{
+		{
+			name: "missing id",
+			spec: CELRuleSpec{EventTypes: []string{"exec"}, Expression: "true"},
+		},
+		{
+			name: "unknown event type",
+			spec: CELRuleSpec{ID: "C0001", EventTypes: []string{"unknown"}, Expression: "true"},
+		},
+		{
+			name: "syntax error",
+			spec: CELRuleSpec{ID: "C0001", EventTypes: []string{"exec"}, Expression: "event.path =="},
+		},
+		{
+			name: "undeclared variable",
+			spec: CELRuleSpec{ID: "C0001", EventTypes: []string{"exec"}, Expression: "foo == 1"},
+		},
+		{
+			name: "non bool expression",
+			spec: CELRuleSpec{ID: "C0001", EventTypes: []string{"exec"}, Expression: "'abc'"},
+		},
+		{
+			name: "non string message",
+			spec: CELRuleSpec{ID: "C0001", EventTypes: []string{"exec"}, Expression: "true", Message: "1 + 1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateCELRuleDescriptor(tt.spec); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestCELRuleSpecFromRuntimeRule(t *testing.T) {
+	spec := CELRuleSpecFromRuntimeRule(typesv1.RuntimeRuleSpec{
+		ID:       "C0003",
+		Name:     "Token read then curl",
+		Priority: RulePriorityHigh,
+		Tags:     []string{"custom"},
+		Sequence: []typesv1.RuntimeRuleSequenceStep{
+			{EventTypes: []string{"open"}, Expression: `event.path == "/token"`},
+			{EventTypes: []string{"exec"}, Expression: `event.comm == "curl"`},
+		},
+		Window:         "10s",
+		KeyBy:          "container",
+		Message:        `"curl"`,
+		FixSuggestions: "remove curl",
+	})
+	expected := CELRuleSpec{
+		ID:       "C0003",
+		Name:     "Token read then curl",
+		Priority: RulePriorityHigh,
+		Tags:     []string{"custom"},
+		Sequence: []CELSequenceStep{
+			{EventTypes: []string{"open"}, Expression: `event.path == "/token"`},
+			{EventTypes: []string{"exec"}, Expression: `event.comm == "curl"`},
+		},
+		Window:         "10s",
+		KeyBy:          "container",
+		Message:        `"curl"`,
+		FixSuggestions: "remove curl",
+	}
+	if !reflect.DeepEqual(spec, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, spec)
+	}
+	if _, err := CreateCELRuleDescriptor(spec); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestCELRuleExecNotInProfile(t *testing.T) {
+	descriptor, err := CreateCELRuleDescriptor(CELRuleSpec{
+		ID:         "C0001",
+		Name:       "Exec not in profile",
+		Priority:   RulePriorityHigh,
+		Tags:       []string{"exec", "custom"},
+		EventTypes: []string{"exec"},
+		Expression: `profile.available && !profile.execs.exists(e, e.path == event.path) && !(event.comm in params.allowedComms)`,
+		Message:    `"unexpected exec " + event.path + " by uid " + string(event.uid)`,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !descriptor.HasTags([]string{"custom"}) {
+		t.Errorf("Expected descriptor to have the custom tag")
+	}
+
+	r := descriptor.RuleCreationFunc()
+	r.SetParameters(map[string]interface{}{"allowedComms": []interface{}{"sh"}})
+	if r.ID() != "C0001" || r.Name() != "Exec not in profile" {
+		t.Errorf("Unexpected rule ID/name %s/%s", r.ID(), r.Name())
+	}
+	if len(r.Requirements().RequiredEventTypes()) != 1 || r.Requirements().RequiredEventTypes()[0] != utils.ExecveEventType {
+		t.Errorf("Expected rule to require exec events")
+	}
+
+	e := &tracerexectype.Event{
+		Event: eventtypes.Event{
+			CommonData: eventtypes.CommonData{
+				K8s: eventtypes.K8sMetadata{
+					BasicK8sMetadata: eventtypes.BasicK8sMetadata{
+						ContainerName: "test",
+					},
+				},
+			},
+		},
+		Comm: "curl",
+		Args: []string{"/usr/bin/curl"},
+		Uid:  1000,
+	}
+
+	objCache := RuleObjectCacheMock{}
+	// No profile - the expression checks profile.available
+	if ruleResult := r.ProcessEvent(utils.ExecveEventType, e, &objCache); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since there is no profile")
+	}
+
+	profile := &v1beta1.ApplicationProfile{}
+	profile.Spec.Containers = append(profile.Spec.Containers, v1beta1.ApplicationProfileContainer{
+		Name: "test",
+		Execs: []v1beta1.ExecCalls{
+			{Path: "/bin/ls"},
+		},
+	})
+	objCache.SetApplicationProfile(profile)
+
+	ruleResult := r.ProcessEvent(utils.ExecveEventType, e, &objCache)
+	if ruleResult == nil {
+		t.Fatalf("Expected ruleResult to not be nil since exec is not in the profile")
+	}
+	if ruleResult.GetRuleAlert().RuleDescription != "unexpected exec /usr/bin/curl by uid 1000" {
+		t.Errorf("Unexpected rule description %q", ruleResult.GetRuleAlert().RuleDescription)
+	}
+	if ruleResult.GetBaseRuntimeAlert().Severity != RulePriorityHigh {
+		t.Errorf("Expected severity %d, got %d", RulePriorityHigh, ruleResult.GetBaseRuntimeAlert().Severity)
+	}
+
+	// Allowed by the binding parameters
+	e.Comm = "sh"
+	if ruleResult := r.ProcessEvent(utils.ExecveEventType, e, &objCache); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since comm is allowed by the parameters")
+	}
+
+	// Whitelisted in the profile
+	e.Comm = "ls"
+	e.Args = []string{"/bin/ls"}
+	if ruleResult := r.ProcessEvent(utils.ExecveEventType, e, &objCache); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since exec is in the profile")
+	}
+}
+
+func TestCELRuleOpen(t *testing.T) {
+	descriptor, err := CreateCELRuleDescriptor(CELRuleSpec{
+		ID:          "C0002",
+		Description: "Shadow file opened",
+		EventTypes:  []string{"open"},
+		Expression:  `eventType == "open" && event.path.startsWith("/etc/shadow") && "O_RDONLY" in event.flags`,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	r := descriptor.RuleCreationFunc()
+
+	e := &traceropentype.Event{
+		FullPath: "/etc/shadow",
+		Flags:    []string{"O_RDONLY"},
+	}
+	ruleResult := r.ProcessEvent(utils.OpenEventType, e, &RuleObjectCacheMock{})
+	if ruleResult == nil {
+		t.Fatalf("Expected ruleResult to not be nil")
+	}
+	if ruleResult.GetRuleAlert().RuleDescription != "Shadow file opened" {
+		t.Errorf("Expected the description to be used when there is no message, got %q", ruleResult.GetRuleAlert().RuleDescription)
+	}
+
+	// Event of a different type is ignored
+	if ruleResult := r.ProcessEvent(utils.ExecveEventType, e, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil for a mismatched event")
+	}
+
+	e.FullPath = "/etc/passwd"
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, e, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil")
+	}
+}
+
+func TestCELRuleOpenNotInProfile(t *testing.T) {
+	descriptor, err := CreateCELRuleDescriptor(CELRuleSpec{
+		ID:         "C0003",
+		EventTypes: []string{"open"},
+		Expression: `profile.available && !profile.opens.exists(o, matchesOpenPath(o.path, event.path))`,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	r := descriptor.RuleCreationFunc()
+
+	objCache := RuleObjectCacheMock{}
+	objCache.SetApplicationProfile(&v1beta1.ApplicationProfile{
+		Spec: v1beta1.ApplicationProfileSpec{
+			Containers: []v1beta1.ApplicationProfileContainer{{
+				Name:  "test",
+				Opens: []v1beta1.OpenCalls{{Path: "/proc/*/status", Flags: []string{"O_RDONLY"}}},
+			}},
+		},
+	})
+	e := &traceropentype.Event{
+		Event: eventtypes.Event{
+			CommonData: eventtypes.CommonData{
+				K8s: eventtypes.K8sMetadata{
+					BasicK8sMetadata: eventtypes.BasicK8sMetadata{ContainerName: "test"},
+				},
+			},
+		},
+		FullPath: "/proc/1234/status",
+		Flags:    []string{"O_RDONLY"},
+	}
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, e, &objCache); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the path matches the wildcard path of the profile")
+	}
+	e.FullPath = "/proc/1234/environ"
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, e, &objCache); ruleResult == nil {
+		t.Errorf("Expected ruleResult to not be nil since the path is not in the profile")
+	}
+}
+
+func TestCELRuleSequence(t *testing.T) {
+	for _, spec := range []CELRuleSpec{
+		{ID: "C0003", Expression: "true", Sequence: []CELSequenceStep{{EventTypes: []string{"exec"}, Expression: "true"}}, Window: "1m"},
+		{ID: "C0003", Sequence: []CELSequenceStep{{EventTypes: []string{"exec"}, Expression: "true"}}},
+		{ID: "C0003", Sequence: []CELSequenceStep{{EventTypes: []string{"exec"}, Expression: "true"}}, Window: "1m", KeyBy: "pod"},
+		{ID: "C0003", Sequence: []CELSequenceStep{{Expression: "true"}}, Window: "1m"},
+	} {
+		if _, err := CreateCELRuleDescriptor(spec); err == nil {
+			t.Errorf("Expected an error for %+v", spec)
+		}
+	}
+
+	descriptor, err := CreateCELRuleDescriptor(CELRuleSpec{
+		ID: "C0003",
+		Sequence: []CELSequenceStep{
+			{EventTypes: []string{"open"}, Expression: `event.path.startsWith("/run/secrets/kubernetes.io/serviceaccount")`},
+			{EventTypes: []string{"exec"}, Expression: `event.comm == "curl"`},
+		},
+		Window:  "10s",
+		Message: `"token read then " + event.comm`,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(descriptor.Requirements.RequiredEventTypes()) != 2 {
+		t.Errorf("Expected the rule to require the event types of all the steps")
+	}
+
+	r := descriptor.RuleCreationFunc()
+	exec := &tracerexectype.Event{
+		Event: eventtypes.Event{
+			Timestamp: 2,
+			CommonData: eventtypes.CommonData{
+				Runtime: eventtypes.BasicRuntimeMetadata{ContainerID: "test"},
+			},
+		},
+		Comm: "curl",
+	}
+	open := &traceropentype.Event{
+		Event:    exec.Event,
+		FullPath: "/run/secrets/kubernetes.io/serviceaccount/token",
+	}
+	open.Timestamp = 1
+
+	if ruleResult := r.ProcessEvent(utils.ExecveEventType, exec, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil before the token is read")
+	}
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, open, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil on the first step")
+	}
+	ruleResult := r.ProcessEvent(utils.ExecveEventType, exec, &RuleObjectCacheMock{})
+	if ruleResult == nil {
+		t.Fatalf("Expected ruleResult to not be nil when the sequence matches")
+	}
+	if ruleResult.GetRuleAlert().RuleDescription != "token read then curl" {
+		t.Errorf("Unexpected rule description %q", ruleResult.GetRuleAlert().RuleDescription)
+	}
+
+	// another instance of the rule has its own state
+	if ruleResult := descriptor.RuleCreationFunc().ProcessEvent(utils.ExecveEventType, exec, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil for a new rule instance")
+	}
+}
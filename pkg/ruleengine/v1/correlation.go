@@ -0,0 +1,254 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+	"node-agent/pkg/utils"
+	"slices"
+	"sync"
+	"time"
+
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+	tracerrandomxtype "node-agent/pkg/ebpf/gadgets/randomx/types"
+
+	tracercapabilitiestype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	tracerdnstype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	tracernetworktype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const defaultCorrelationMaxKeys = 4096
+
+// CorrelationKeyBy defines which events share a correlation state
+type CorrelationKeyBy string
+
+const (
+	// CorrelateByContainer correlates the events of all the processes of a container
+	CorrelateByContainer CorrelationKeyBy = "container"
+	// CorrelateByProcess correlates the events of a single process of a container
+	CorrelateByProcess CorrelationKeyBy = "process"
+	// CorrelateByPath correlates the events of a container on the same file, like a write and an execution of the file
+	CorrelateByPath CorrelationKeyBy = "path"
+)
+
+// CorrelationStep is a predicate on one event of a sequence
+type CorrelationStep struct {
+	EventTypes []utils.EventType
+	Match      func(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) bool
+}
+
+// CorrelationSequence is an ordered sequence of events that must all match within the window
+type CorrelationSequence struct {
+	Steps  []CorrelationStep
+	Window time.Duration
+	KeyBy  CorrelationKeyBy
+	// MaxKeys bounds the number of tracked containers/processes, the oldest state is evicted when it is reached
+	MaxKeys int
+}
+
+// RequiredEventTypes returns the event types of all the steps
+func (sequence CorrelationSequence) RequiredEventTypes() []utils.EventType {
+	var eventTypes []utils.EventType
+	for _, step := range sequence.Steps {
+		for _, eventType := range step.EventTypes {
+			if !slices.Contains(eventTypes, eventType) {
+				eventTypes = append(eventTypes, eventType)
+			}
+		}
+	}
+	return eventTypes
+}
+
+type correlationState struct {
+	// next is the index of the next step to match
+	next int
+	// started is the timestamp of the event that matched the first step
+	started int64
+}
+
+// CorrelationTracker runs the state machine of a sequence per container or process.
+// States expire after the sequence window and the number of states is bounded, so the tracker can be shared by all the containers a rule is bound to.
+type CorrelationTracker struct {
+	sequence CorrelationSequence
+	mutex    sync.Mutex
+	states   map[string]*correlationState
+}
+
+func NewCorrelationTracker(sequence CorrelationSequence) *CorrelationTracker {
+	if sequence.MaxKeys <= 0 {
+		sequence.MaxKeys = defaultCorrelationMaxKeys
+	}
+	return &CorrelationTracker{
+		sequence: sequence,
+		states:   make(map[string]*correlationState),
+	}
+}
+
+// ProcessEvent advances the state of the event container/process/path, it returns true when the event completes the sequence.
+func (t *CorrelationTracker) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) bool {
+	triggerEvent, pid, ok := correlationEventDetails(eventType, event)
+	if !ok {
+		return false
+	}
+	keys := []string{triggerEvent.Runtime.ContainerID}
+	switch t.sequence.KeyBy {
+	case CorrelateByProcess:
+		keys = []string{fmt.Sprintf("%s/%d", triggerEvent.Runtime.ContainerID, pid)}
+	case CorrelateByPath:
+		// an event can refer to several files, like the executed program and its interpreter
+		keys = nil
+		for _, path := range correlationEventPaths(eventType, event) {
+			keys = append(keys, fmt.Sprintf("%s/%s", triggerEvent.Runtime.ContainerID, path))
+		}
+	}
+	timestamp := int64(triggerEvent.Timestamp)
+	if timestamp == 0 {
+		timestamp = time.Now().UnixNano()
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	completed := false
+	for _, key := range keys {
+		if t.processKey(key, timestamp, eventType, event, objCache) {
+			completed = true
+		}
+	}
+	return completed
+}
+
+func (t *CorrelationTracker) processKey(key string, timestamp int64, eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) bool {
+	state, ok := t.states[key]
+	if ok && timestamp-state.started > int64(t.sequence.Window) {
+		delete(t.states, key)
+		state, ok = nil, false
+	}
+
+	if ok && t.sequence.matches(state.next, eventType, event, objCache) {
+		state.next++
+		if state.next == len(t.sequence.Steps) {
+			delete(t.states, key)
+			return true
+		}
+		return false
+	}
+
+	// the first step (re)starts the sequence
+	if t.sequence.matches(0, eventType, event, objCache) {
+		if len(t.sequence.Steps) == 1 {
+			delete(t.states, key)
+			return true
+		}
+		if !ok {
+			t.evict(timestamp)
+		}
+		t.states[key] = &correlationState{next: 1, started: timestamp}
+	}
+	return false
+}
+
+func (sequence CorrelationSequence) matches(step int, eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) bool {
+	s := sequence.Steps[step]
+	return slices.Contains(s.EventTypes, eventType) && s.Match(eventType, event, objCache)
+}
+
+// evict makes room for a new state, first by removing the expired states and then the oldest one
+func (t *CorrelationTracker) evict(now int64) {
+	if len(t.states) < t.sequence.MaxKeys {
+		return
+	}
+	oldestKey := ""
+	var oldest int64
+	for key, state := range t.states {
+		if now-state.started > int64(t.sequence.Window) {
+			delete(t.states, key)
+			continue
+		}
+		if oldestKey == "" || state.started < oldest {
+			oldestKey, oldest = key, state.started
+		}
+	}
+	if len(t.states) >= t.sequence.MaxKeys {
+		delete(t.states, oldestKey)
+	}
+}
+
+// Len returns the number of tracked states
+func (t *CorrelationTracker) Len() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.states)
+}
+
+func correlationEventDetails(eventType utils.EventType, event interface{}) (igtypes.Event, uint32, bool) {
+	switch eventType {
+	case utils.ExecveEventType:
+		if e, ok := event.(*tracerexectype.Event); ok {
+			return e.Event, e.Pid, true
+		}
+	case utils.OpenEventType:
+		if e, ok := event.(*traceropentype.Event); ok {
+			return e.Event, e.Pid, true
+		}
+	case utils.CapabilitiesEventType:
+		if e, ok := event.(*tracercapabilitiestype.Event); ok {
+			return e.Event, e.Pid, true
+		}
+	case utils.DnsEventType:
+		if e, ok := event.(*tracerdnstype.Event); ok {
+			return e.Event, e.Pid, true
+		}
+	case utils.NetworkEventType:
+		if e, ok := event.(*tracernetworktype.Event); ok {
+			return e.Event, e.Pid, true
+		}
+	case utils.SyscallEventType:
+		if e, ok := event.(*ruleenginetypes.SyscallEvent); ok {
+			return e.Event, e.Pid, true
+		}
+	case utils.RandomXEventType:
+		if e, ok := event.(*tracerrandomxtype.Event); ok {
+			return e.Event, e.Pid, true
+		}
+	default:
+		if source, ok := utils.DescribeSourceEvent(eventType, event); ok {
+			return *source.Event, source.Process.Pid, true
+		}
+	}
+	return igtypes.Event{}, 0, false
+}
+
+// correlationEventPaths returns the files an event refers to, without duplicates
+func correlationEventPaths(eventType utils.EventType, event interface{}) []string {
+	var paths []string
+	switch eventType {
+	case utils.ExecveEventType:
+		if e, ok := event.(*tracerexectype.Event); ok {
+			paths = []string{e.ExePath, getExecFullPathFromEvent(e)}
+		}
+	case utils.OpenEventType:
+		if e, ok := event.(*traceropentype.Event); ok {
+			path := e.FullPath
+			if path == "" {
+				path = e.Path
+			}
+			paths = []string{path}
+		}
+	case utils.FileModEventType:
+		// the old path of a rename does not exist anymore
+		if e, ok := event.(*tracerfilemodtype.Event); ok {
+			paths = []string{e.Path}
+		}
+	}
+	var unique []string
+	for _, path := range paths {
+		if path != "" && !slices.Contains(unique, path) {
+			unique = append(unique, path)
+		}
+	}
+	return unique
+}
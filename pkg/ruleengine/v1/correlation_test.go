@@ -0,0 +1,166 @@
+package ruleengine
+
+import (
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/utils"
+	"testing"
+	"time"
+
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func correlationExecEvent(containerID string, pid uint32, comm string, timestamp time.Duration) *tracerexectype.Event {
+	return &tracerexectype.Event{
+		Event: eventtypes.Event{
+			Timestamp: eventtypes.Time(timestamp),
+			CommonData: eventtypes.CommonData{
+				Runtime: eventtypes.BasicRuntimeMetadata{ContainerID: containerID},
+			},
+		},
+		Pid:  pid,
+		Comm: comm,
+	}
+}
+
+func execCommStep(comm string) CorrelationStep {
+	return CorrelationStep{
+		EventTypes: []utils.EventType{utils.ExecveEventType},
+		Match: func(_ utils.EventType, event interface{}, _ objectcache.ObjectCache) bool {
+			return event.(*tracerexectype.Event).Comm == comm
+		},
+	}
+}
+
+func TestCorrelationTrackerSequence(t *testing.T) {
+	tracker := NewCorrelationTracker(CorrelationSequence{
+		Steps:  []CorrelationStep{execCommStep("curl"), execCommStep("chmod"), execCommStep("malware")},
+		Window: 10 * time.Second,
+		KeyBy:  CorrelateByContainer,
+	})
+	process := func(containerID string, comm string, timestamp time.Duration) bool {
+		return tracker.ProcessEvent(utils.ExecveEventType, correlationExecEvent(containerID, 1, comm, timestamp), nil)
+	}
+
+	// out of order
+	if process("c1", "chmod", time.Second) || process("c1", "malware", 2*time.Second) {
+		t.Errorf("Expected the sequence to not match out of order")
+	}
+
+	// the steps of another container do not advance the sequence
+	if process("c1", "curl", 3*time.Second) || process("c2", "chmod", 4*time.Second) || process("c1", "malware", 5*time.Second) {
+		t.Errorf("Expected the sequence to not match across containers")
+	}
+
+	// unrelated events in between are ignored
+	if process("c1", "chmod", 6*time.Second) || process("c1", "ls", 7*time.Second) {
+		t.Errorf("Expected the sequence to not match yet")
+	}
+	if !process("c1", "malware", 8*time.Second) {
+		t.Errorf("Expected the sequence to match")
+	}
+	if tracker.Len() != 0 {
+		t.Errorf("Expected the state to be removed once the sequence matched, got %d states", tracker.Len())
+	}
+
+	// the state expires after the window
+	if process("c1", "curl", 10*time.Second) || process("c1", "chmod", 15*time.Second) || process("c1", "malware", 21*time.Second) {
+		t.Errorf("Expected the sequence to expire")
+	}
+}
+
+func TestCorrelationTrackerByProcess(t *testing.T) {
+	tracker := NewCorrelationTracker(CorrelationSequence{
+		Steps:  []CorrelationStep{execCommStep("curl"), execCommStep("sh")},
+		Window: time.Minute,
+		KeyBy:  CorrelateByProcess,
+	})
+
+	tracker.ProcessEvent(utils.ExecveEventType, correlationExecEvent("c1", 1, "curl", time.Second), nil)
+	if tracker.ProcessEvent(utils.ExecveEventType, correlationExecEvent("c1", 2, "sh", 2*time.Second), nil) {
+		t.Errorf("Expected the sequence to not match across processes")
+	}
+	if !tracker.ProcessEvent(utils.ExecveEventType, correlationExecEvent("c1", 1, "sh", 3*time.Second), nil) {
+		t.Errorf("Expected the sequence to match for the same process")
+	}
+}
+
+func TestCorrelationTrackerMaxKeys(t *testing.T) {
+	tracker := NewCorrelationTracker(CorrelationSequence{
+		Steps:   []CorrelationStep{execCommStep("curl"), execCommStep("sh")},
+		Window:  time.Minute,
+		KeyBy:   CorrelateByContainer,
+		MaxKeys: 2,
+	})
+
+	tracker.ProcessEvent(utils.ExecveEventType, correlationExecEvent("c1", 1, "curl", 1*time.Second), nil)
+	tracker.ProcessEvent(utils.ExecveEventType, correlationExecEvent("c2", 1, "curl", 2*time.Second), nil)
+	tracker.ProcessEvent(utils.ExecveEventType, correlationExecEvent("c3", 1, "curl", 3*time.Second), nil)
+	if tracker.Len() != 2 {
+		t.Errorf("Expected 2 states, got %d", tracker.Len())
+	}
+
+	// the oldest state was evicted
+	if tracker.ProcessEvent(utils.ExecveEventType, correlationExecEvent("c1", 1, "sh", 4*time.Second), nil) {
+		t.Errorf("Expected the state of c1 to be evicted")
+	}
+	if !tracker.ProcessEvent(utils.ExecveEventType, correlationExecEvent("c3", 1, "sh", 5*time.Second), nil) {
+		t.Errorf("Expected the sequence of c3 to match")
+	}
+}
+
+func TestCorrelationTrackerByPath(t *testing.T) {
+	tracker := NewCorrelationTracker(CorrelationSequence{
+		Steps: []CorrelationStep{
+			{
+				EventTypes: []utils.EventType{utils.FileModEventType},
+				Match: func(_ utils.EventType, _ interface{}, _ objectcache.ObjectCache) bool {
+					return true
+				},
+			},
+			execCommStep("sh"),
+		},
+		Window: time.Minute,
+		KeyBy:  CorrelateByPath,
+	})
+	rename := func(path string, timestamp time.Duration) *tracerfilemodtype.Event {
+		return &tracerfilemodtype.Event{
+			Event: eventtypes.Event{
+				Timestamp:  eventtypes.Time(timestamp),
+				CommonData: eventtypes.CommonData{Runtime: eventtypes.BasicRuntimeMetadata{ContainerID: "c1"}},
+			},
+			Operation: tracerfilemodtype.RenameOperation,
+			Source:    "/tmp/download",
+			Path:      path,
+		}
+	}
+	exec := func(args []string, exePath string, timestamp time.Duration) *tracerexectype.Event {
+		event := correlationExecEvent("c1", 2, "sh", timestamp)
+		event.Args = args
+		event.ExePath = exePath
+		return event
+	}
+
+	tracker.ProcessEvent(utils.FileModEventType, rename("/tmp/run.sh", time.Second), nil)
+	if tracker.ProcessEvent(utils.ExecveEventType, exec([]string{"/tmp/other.sh"}, "/bin/sh", 2*time.Second), nil) {
+		t.Errorf("Expected the sequence to not match for another path")
+	}
+	if tracker.ProcessEvent(utils.ExecveEventType, exec([]string{"/tmp/download"}, "/bin/sh", 2*time.Second), nil) {
+		t.Errorf("Expected the sequence to not match for the old path of the rename")
+	}
+	// the script is the argument of the interpreter
+	if !tracker.ProcessEvent(utils.ExecveEventType, exec([]string{"/tmp/run.sh"}, "/bin/sh", 3*time.Second), nil) {
+		t.Errorf("Expected the sequence to match for the script")
+	}
+
+	// the binary is found in the PATH
+	tracker.ProcessEvent(utils.FileModEventType, rename("/usr/local/bin/sh", 4*time.Second), nil)
+	if !tracker.ProcessEvent(utils.ExecveEventType, exec([]string{"sh"}, "/usr/local/bin/sh", 5*time.Second), nil) {
+		t.Errorf("Expected the sequence to match for the executable")
+	}
+	if tracker.Len() != 0 {
+		t.Errorf("Expected no state, got %d states", tracker.Len())
+	}
+}
@@ -18,6 +18,7 @@ func NewRuleCreator() *RuleCreatorImpl {
 			R0005UnexpectedDomainRequestRuleDescriptor,
 			R0006UnexpectedServiceAccountTokenAccessRuleDescriptor,
 			R0007KubernetesClientExecutedDescriptor,
+			R0008UnexpectedCredentialTransitionRuleDescriptor,
 			R1000ExecFromMaliciousSourceDescriptor,
 			R1001ExecBinaryNotInBaseImageRuleDescriptor,
 			R1002LoadKernelModuleRuleDescriptor,
@@ -28,6 +29,14 @@ func NewRuleCreator() *RuleCreatorImpl {
 			R1007XMRCryptoMiningRuleDescriptor,
 			R1008CryptoMiningDomainCommunicationRuleDescriptor,
 			R1009CryptoMiningRelatedPortRuleDescriptor,
+			R1010ProcessInjectionRuleDescriptor,
+			R1011HostPathMountRuleDescriptor,
+			R1012UsermodeHelperWriteRuleDescriptor,
+			R1013SetnsHostNamespaceRuleDescriptor,
+			R1014UnexpectedBPFUsageRuleDescriptor,
+			R1015BinaryWrittenThenExecutedRuleDescriptor,
+			R1016SensitiveFileModificationRuleDescriptor,
+			R1017LogFileTruncationRuleDescriptor,
 		},
 	}
 }
@@ -36,7 +45,7 @@ func (r *RuleCreatorImpl) CreateRulesByTags(tags []string) []ruleengine.RuleEval
 	var rules []ruleengine.RuleEvaluator
 	for _, rule := range r.ruleDescriptions {
 		if rule.HasTags(tags) {
-			rules = append(rules, rule.RuleCreationFunc())
+			rules = append(rules, rule.CreateRule())
 		}
 	}
 	return rules
@@ -45,7 +54,7 @@ func (r *RuleCreatorImpl) CreateRulesByTags(tags []string) []ruleengine.RuleEval
 func (r *RuleCreatorImpl) CreateRuleByID(id string) ruleengine.RuleEvaluator {
 	for _, rule := range r.ruleDescriptions {
 		if rule.ID == id {
-			return rule.RuleCreationFunc()
+			return rule.CreateRule()
 		}
 	}
 	return nil
@@ -54,7 +63,7 @@ func (r *RuleCreatorImpl) CreateRuleByID(id string) ruleengine.RuleEvaluator {
 func (r *RuleCreatorImpl) CreateRuleByName(name string) ruleengine.RuleEvaluator {
 	for _, rule := range r.ruleDescriptions {
 		if rule.Name == name {
-			return rule.RuleCreationFunc()
+			return rule.CreateRule()
 		}
 	}
 	return nil
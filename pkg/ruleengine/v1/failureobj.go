@@ -16,6 +16,7 @@ type GenericRuleFailure struct {
 	TriggerEvent           igtypes.Event
 	RuleAlert              apitypes.RuleAlert
 	RuntimeAlertK8sDetails apitypes.RuntimeAlertK8sDetails
+	RuleTags               []string
 }
 
 func (rule *GenericRuleFailure) GetBaseRuntimeAlert() apitypes.BaseRuntimeAlert {
@@ -38,6 +39,10 @@ func (rule *GenericRuleFailure) GetRuntimeAlertK8sDetails() apitypes.RuntimeAler
 	return rule.RuntimeAlertK8sDetails
 }
 
+func (rule *GenericRuleFailure) GetRuleTags() []string {
+	return rule.RuleTags
+}
+
 func (rule *GenericRuleFailure) SetBaseRuntimeAlert(baseRuntimeAlert apitypes.BaseRuntimeAlert) {
 	rule.BaseRuntimeAlert = baseRuntimeAlert
 }
@@ -58,6 +63,10 @@ func (rule *GenericRuleFailure) SetRuntimeAlertK8sDetails(runtimeAlertK8sDetails
 	rule.RuntimeAlertK8sDetails = runtimeAlertK8sDetails
 }
 
+func (rule *GenericRuleFailure) SetRuleTags(tags []string) {
+	rule.RuleTags = tags
+}
+
 func (rule *GenericRuleFailure) SetWorkloadDetails(workloadDetails string) {
 	if workloadDetails == "" {
 		return
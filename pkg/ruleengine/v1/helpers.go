@@ -5,8 +5,10 @@ import (
 	"node-agent/pkg/objectcache"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
 	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
 )
 
@@ -27,6 +29,11 @@ func getExecFullPathFromEvent(event *tracerexectype.Event) string {
 	return execPath
 }
 
+// isOpenForWrite returns whether the file of an open event is opened for writing
+func isOpenForWrite(event *traceropentype.Event) bool {
+	return event.FlagsRaw&(syscall.O_WRONLY|syscall.O_RDWR) != 0
+}
+
 func getContainerFromApplicationProfile(ap *v1beta1.ApplicationProfile, containerName string) (v1beta1.ApplicationProfileContainer, error) {
 	for i := range ap.Spec.Containers {
 		if ap.Spec.Containers[i].Name == containerName {
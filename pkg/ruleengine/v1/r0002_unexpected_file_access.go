@@ -9,6 +9,7 @@ import (
 	"node-agent/pkg/objectcache"
 
 	apitypes "github.com/armosec/armoapi-go/armotypes"
+	mapset "github.com/deckarep/golang-set/v2"
 	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
 
 	"github.com/kubescape/go-logger"
@@ -144,23 +145,18 @@ func (rule *R0002UnexpectedFileAccess) ProcessEvent(eventType utils.EventType, e
 		return nil
 	}
 
+	// the flags of the opens whose path, or wildcard path, matches the event
+	profileOpenFlags := mapset.NewThreadUnsafeSet[string]()
+	matched := false
 	for _, open := range appProfileOpenList.Opens {
-		if open.Path == openEvent.FullPath {
-			found := 0
-			for _, eventOpenFlag := range openEvent.Flags {
-				// Check that event open flag is in the open.Flags
-				for _, profileOpenFlag := range open.Flags {
-					if eventOpenFlag == profileOpenFlag {
-						found += 1
-					}
-				}
-			}
-			if found == len(openEvent.Flags) {
-				return nil
-			}
-			// TODO: optimize this list (so path will be only once in the list so we can break the loop)
+		if utils.MatchOpenPath(open.Path, openEvent.FullPath) {
+			matched = true
+			profileOpenFlags.Append(open.Flags...)
 		}
 	}
+	if matched && profileOpenFlags.Contains(openEvent.Flags...) {
+		return nil
+	}
 
 	ruleFailure := GenericRuleFailure{
 		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
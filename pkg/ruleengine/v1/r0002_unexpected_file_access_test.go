@@ -77,6 +77,24 @@ func TestR0002UnexpectedFileAccess(t *testing.T) {
 		t.Errorf("Expected ruleResult to not be nil since flag is not whitelisted")
 	}
 
+	// Test with a wildcard path of the profile
+	e.FullPath = "/proc/1234/status"
+	profile.Spec.Containers[0].Opens = append(profile.Spec.Containers[0].Opens, v1beta1.OpenCalls{Path: "/proc/*/status", Flags: []string{"O_RDONLY"}})
+	ruleResult = r.ProcessEvent(utils.OpenEventType, e, &objCache)
+	if ruleResult == nil {
+		t.Errorf("Expected ruleResult to not be nil since flag is not whitelisted for the wildcard path")
+	}
+	e.Flags = []string{"O_RDONLY"}
+	ruleResult = r.ProcessEvent(utils.OpenEventType, e, &objCache)
+	if ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since file matches the wildcard path")
+	}
+	e.FullPath = "/proc/1234/environ"
+	ruleResult = r.ProcessEvent(utils.OpenEventType, e, &objCache)
+	if ruleResult == nil {
+		t.Errorf("Expected ruleResult to not be nil since file does not match the wildcard path")
+	}
+
 	// Test with mounted file
 	e.Flags = []string{"O_RDONLY"}
 	e.FullPath = "/var/test1"
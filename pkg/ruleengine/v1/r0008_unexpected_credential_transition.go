@@ -0,0 +1,119 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+
+	tracercredstype "node-agent/pkg/ebpf/gadgets/creds/types"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+)
+
+const (
+	R0008ID   = "R0008"
+	R0008Name = "Unexpected credential transition"
+)
+
+var R0008UnexpectedCredentialTransitionRuleDescriptor = RuleDescriptor{
+	ID:          R0008ID,
+	Name:        R0008Name,
+	Description: "Detecting changes of user id, group id or capabilities and executions of SUID and SGID files that are not whitelisted by application profile.",
+	Tags:        []string{"credentials", "privilege escalation", "whitelisted"},
+	Priority:    RulePriorityHigh,
+	Requirements: &RuleRequirements{
+		EventTypes: []utils.EventType{utils.CredsEventType},
+	},
+	RuleCreationFunc: func() ruleengine.RuleEvaluator {
+		return CreateRuleR0008UnexpectedCredentialTransition()
+	},
+}
+var _ ruleengine.RuleEvaluator = (*R0008UnexpectedCredentialTransition)(nil)
+
+type R0008UnexpectedCredentialTransition struct {
+	BaseRule
+}
+
+func CreateRuleR0008UnexpectedCredentialTransition() *R0008UnexpectedCredentialTransition {
+	return &R0008UnexpectedCredentialTransition{}
+}
+func (rule *R0008UnexpectedCredentialTransition) Name() string {
+	return R0008Name
+}
+
+func (rule *R0008UnexpectedCredentialTransition) ID() string {
+	return R0008ID
+}
+
+func (rule *R0008UnexpectedCredentialTransition) DeleteRule() {
+}
+
+func (rule *R0008UnexpectedCredentialTransition) generatePatchCommand(event *tracercredstype.Event, ap *v1beta1.ApplicationProfile) string {
+	baseTemplate := "kubectl annotate applicationprofile %s --namespace %s '%s=%s'"
+	return fmt.Sprintf(baseTemplate, ap.GetName(), ap.GetNamespace(),
+		utils.GetActivityMetadataKey(ap, event.GetContainer(), utils.CredentialTransitionActivity, event.Transition), event.Transition)
+}
+
+func (rule *R0008UnexpectedCredentialTransition) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) ruleengine.RuleFailure {
+	if eventType != utils.CredsEventType {
+		return nil
+	}
+
+	credsEvent, ok := event.(*tracercredstype.Event)
+	if !ok {
+		return nil
+	}
+
+	ap := objCache.ApplicationProfileCache().GetApplicationProfile(credsEvent.Runtime.ContainerID)
+	if ap == nil {
+		return nil
+	}
+
+	if _, err := getContainerFromApplicationProfile(ap, credsEvent.GetContainer()); err != nil {
+		return nil
+	}
+
+	for _, transition := range utils.GetActivities(ap, credsEvent.GetContainer(), utils.CredentialTransitionActivity) {
+		if credsEvent.Transition == transition {
+			return nil
+		}
+	}
+
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:      rule.Name(),
+			InfectedPID:    credsEvent.Pid,
+			FixSuggestions: fmt.Sprintf("If this is a valid behavior, please add the credential transition \"%s\" to the whitelist in the application profile for the Pod \"%s\". You can use the following command: %s", credsEvent.Transition, credsEvent.GetPod(), rule.generatePatchCommand(credsEvent, ap)),
+			Severity:       R0008UnexpectedCredentialTransitionRuleDescriptor.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{
+				Comm: credsEvent.Comm,
+				Gid:  &credsEvent.Gid,
+				PID:  credsEvent.Pid,
+				PPID: credsEvent.PPid,
+				Uid:  &credsEvent.Uid,
+				Path: credsEvent.Path,
+			},
+			ContainerID: credsEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: credsEvent.Event,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: fmt.Sprintf("Unexpected credential transition (transition %s in syscall %s) in: %s", credsEvent.Transition, credsEvent.Syscall, credsEvent.GetContainer()),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: credsEvent.GetPod(),
+		},
+	}
+
+	return &ruleFailure
+}
+
+func (rule *R0008UnexpectedCredentialTransition) Requirements() ruleengine.RuleSpec {
+	return &RuleRequirements{
+		EventTypes: R0008UnexpectedCredentialTransitionRuleDescriptor.Requirements.RequiredEventTypes(),
+	}
+}
@@ -0,0 +1,70 @@
+package ruleengine
+
+import (
+	"node-agent/pkg/utils"
+	"testing"
+
+	tracercredstype "node-agent/pkg/ebpf/gadgets/creds/types"
+
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+)
+
+func TestR0008UnexpectedCredentialTransition(t *testing.T) {
+	// Create a new rule
+	r := CreateRuleR0008UnexpectedCredentialTransition()
+	// Assert r is not nil
+	if r == nil {
+		t.Errorf("Expected r to not be nil")
+	}
+
+	e := &tracercredstype.Event{
+		Event: eventtypes.Event{
+			CommonData: eventtypes.CommonData{
+				K8s: eventtypes.K8sMetadata{
+					BasicK8sMetadata: eventtypes.BasicK8sMetadata{
+						ContainerName: "test",
+					},
+				},
+			},
+		},
+		Syscall:    "setuid",
+		NewId:      1000,
+		Transition: "uid:0->1000",
+	}
+
+	// Test with nil appProfileAccess
+	ruleResult := r.ProcessEvent(utils.CredsEventType, e, &RuleObjectCacheMock{})
+	if ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since no appProfile is present")
+	}
+
+	objCache := RuleObjectCacheMock{}
+	profile := &v1beta1.ApplicationProfile{}
+	profile.Spec.Containers = append(profile.Spec.Containers, v1beta1.ApplicationProfileContainer{
+		Name: "test",
+	})
+	objCache.SetApplicationProfile(profile)
+
+	// Test with a transition not in the profile
+	ruleResult = r.ProcessEvent(utils.CredsEventType, e, &objCache)
+	if ruleResult == nil {
+		t.Errorf("Expected ruleResult to not be nil since transition is not in the profile")
+	}
+
+	// Test with the transition in the profile
+	profile.Annotations = map[string]string{
+		utils.GetActivityMetadataKey(profile, "test", utils.CredentialTransitionActivity, "uid:0->1000"): "uid:0->1000",
+	}
+	ruleResult = r.ProcessEvent(utils.CredsEventType, e, &objCache)
+	if ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since transition is in the profile")
+	}
+
+	// Test with another transition
+	e.Transition = "suid:/usr/bin/passwd"
+	ruleResult = r.ProcessEvent(utils.CredsEventType, e, &objCache)
+	if ruleResult == nil {
+		t.Errorf("Expected ruleResult to not be nil since transition is not in the profile")
+	}
+}
@@ -65,19 +65,38 @@ var _ ruleengine.RuleEvaluator = (*R1003MaliciousSSHConnection)(nil)
 
 type R1003MaliciousSSHConnection struct {
 	BaseRule
-	accessRelatedFiles        bool
-	sshInitiatorPid           uint32
-	configFileAccessTimeStamp int64
-	allowedPorts              []uint16
+	// sshConnection correlates the access to an SSH related file with an outgoing connection of the same process
+	sshConnection *CorrelationTracker
+	allowedPorts  []uint16
 }
 
 func CreateRuleR1003MaliciousSSHConnection() *R1003MaliciousSSHConnection {
-	return &R1003MaliciousSSHConnection{accessRelatedFiles: false,
-		sshInitiatorPid:           0,
-		configFileAccessTimeStamp: 0,
-		allowedPorts:              []uint16{22},
+	rule := &R1003MaliciousSSHConnection{
+		allowedPorts: []uint16{22},
 	}
+	rule.sshConnection = NewCorrelationTracker(CorrelationSequence{
+		Steps: []CorrelationStep{
+			{
+				EventTypes: []utils.EventType{utils.OpenEventType},
+				Match: func(_ utils.EventType, event interface{}, _ objectcache.ObjectCache) bool {
+					openEvent, ok := event.(*traceropentype.Event)
+					return ok && IsSSHConfigFile(openEvent.FullPath)
+				},
+			},
+			{
+				EventTypes: []utils.EventType{utils.NetworkEventType},
+				Match: func(_ utils.EventType, event interface{}, _ objectcache.ObjectCache) bool {
+					networkEvent, ok := event.(*tracernetworktype.Event)
+					return ok && networkEvent.PktType == "OUTGOING" && networkEvent.Proto == "TCP" && !slices.Contains(rule.allowedPorts, networkEvent.Port)
+				},
+			},
+		},
+		Window: MaxTimeDiffInSeconds * time.Second,
+		KeyBy:  CorrelateByProcess,
+	})
+	return rule
 }
+
 func (rule *R1003MaliciousSSHConnection) Name() string {
 	return R1003Name
 }
@@ -117,72 +136,42 @@ func (rule *R1003MaliciousSSHConnection) ProcessEvent(eventType utils.EventType,
 		return nil
 	}
 
-	if eventType == utils.OpenEventType && !rule.accessRelatedFiles {
-		openEvent, ok := event.(*traceropentype.Event)
-		if !ok {
-			return nil
-		} else {
-			if IsSSHConfigFile(openEvent.FullPath) {
-				rule.accessRelatedFiles = true
-				rule.sshInitiatorPid = openEvent.Pid
-				rule.configFileAccessTimeStamp = int64(openEvent.Timestamp)
-			}
-
-			return nil
-		}
-	} else if eventType == utils.NetworkEventType && rule.accessRelatedFiles {
-		networkEvent, ok := event.(*tracernetworktype.Event)
-		if !ok {
-			return nil
-		}
-
-		timestampDiffInSeconds := calculateTimestampDiffInSeconds(int64(networkEvent.Timestamp), rule.configFileAccessTimeStamp)
-		if timestampDiffInSeconds > MaxTimeDiffInSeconds {
-			rule.accessRelatedFiles = false
-			rule.sshInitiatorPid = 0
-			rule.configFileAccessTimeStamp = 0
-			return nil
-		}
-		if networkEvent.Pid == rule.sshInitiatorPid && networkEvent.PktType == "OUTGOING" && networkEvent.Proto == "TCP" && !slices.Contains(rule.allowedPorts, networkEvent.Port) {
-			rule.accessRelatedFiles = false
-			rule.sshInitiatorPid = 0
-			rule.configFileAccessTimeStamp = 0
-
-			ruleFailure := GenericRuleFailure{
-				BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
-					AlertName:      rule.Name(),
-					InfectedPID:    networkEvent.Pid,
-					FixSuggestions: "If this is a legitimate action, please add the port as a parameter to the binding of this rule",
-					Severity:       R1003MaliciousSSHConnectionRuleDescriptor.Priority,
-				},
-				RuntimeProcessDetails: apitypes.ProcessTree{
-					ProcessTree: apitypes.Process{
-						Comm: networkEvent.Comm,
-						Gid:  &networkEvent.Gid,
-						PID:  networkEvent.Pid,
-						Uid:  &networkEvent.Uid,
-					},
-					ContainerID: networkEvent.Runtime.ContainerID,
-				},
-				TriggerEvent: networkEvent.Event,
-				RuleAlert: apitypes.RuleAlert{
-					RuleID:          rule.ID(),
-					RuleDescription: fmt.Sprintf("SSH connection to disallowed port %d", networkEvent.Port),
-				},
-				RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
-					PodName: networkEvent.GetPod(),
-				},
-			}
+	if !rule.sshConnection.ProcessEvent(eventType, event, objCache) {
+		return nil
+	}
 
-			return &ruleFailure
-		}
+	networkEvent, ok := event.(*tracernetworktype.Event)
+	if !ok {
+		return nil
 	}
 
-	return nil
-}
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:      rule.Name(),
+			InfectedPID:    networkEvent.Pid,
+			FixSuggestions: "If this is a legitimate action, please add the port as a parameter to the binding of this rule",
+			Severity:       R1003MaliciousSSHConnectionRuleDescriptor.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{
+				Comm: networkEvent.Comm,
+				Gid:  &networkEvent.Gid,
+				PID:  networkEvent.Pid,
+				Uid:  &networkEvent.Uid,
+			},
+			ContainerID: networkEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: networkEvent.Event,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: fmt.Sprintf("SSH connection to disallowed port %d", networkEvent.Port),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: networkEvent.GetPod(),
+		},
+	}
 
-func calculateTimestampDiffInSeconds(timestamp1 int64, timestamp2 int64) int64 {
-	return (timestamp1 - timestamp2) / int64(time.Second)
+	return &ruleFailure
 }
 
 func IsSSHConfigFile(path string) bool {
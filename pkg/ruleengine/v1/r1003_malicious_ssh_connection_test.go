@@ -97,4 +97,14 @@ func TestR1003DisallowedSSHConnectionPort_ProcessEvent(t *testing.T) {
 	if failure != nil {
 		t.Errorf("Expected failure to be nil, but got %v", failure)
 	}
+
+	// Test case 6: SSH config accessed in another container with the same PID
+	openEvent.Runtime.ContainerID = "other"
+	openEvent.Timestamp = 6
+	rule.ProcessEvent(utils.OpenEventType, openEvent, &RuleObjectCacheMock{})
+	networkEvent.Timestamp = 7
+	failure = rule.ProcessEvent(utils.NetworkEventType, networkEvent, &RuleObjectCacheMock{})
+	if failure != nil {
+		t.Errorf("Expected failure to be nil, but got %v", failure)
+	}
 }
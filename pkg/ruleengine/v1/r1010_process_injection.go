@@ -0,0 +1,137 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+	"time"
+
+	tracerptracetype "node-agent/pkg/ebpf/gadgets/ptrace/types"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	R1010ID   = "R1010"
+	R1010Name = "Cross-Process Injection"
+	// r1010TraceesSize and r1010TraceesTTL bound the tracees remembered to alert once per attach
+	r1010TraceesSize = 10000
+	r1010TraceesTTL  = time.Hour
+)
+
+var R1010ProcessInjectionRuleDescriptor = RuleDescriptor{
+	ID:          R1010ID,
+	Name:        R1010Name,
+	Description: "Detecting injection into another process with ptrace, process_vm_writev or writes to /proc/<pid>/mem. Every attach is alerted once.",
+	Tags:        []string{"ptrace", "injection", "malicious"},
+	Priority:    RulePriorityHigh,
+	Requirements: &RuleRequirements{
+		EventTypes: []utils.EventType{
+			utils.PtraceEventType,
+		},
+	},
+	RuleCreationFunc: func() ruleengine.RuleEvaluator {
+		return CreateRuleR1010ProcessInjection()
+	},
+}
+
+var _ ruleengine.RuleEvaluator = (*R1010ProcessInjection)(nil)
+
+type R1010ProcessInjection struct {
+	BaseRule
+	// tracees are the processes already alerted on with their targets, the requests and writes following an attach
+	// are not alerted on again
+	tracees *cache.LRUExpireCache
+}
+
+func CreateRuleR1010ProcessInjection() *R1010ProcessInjection {
+	return &R1010ProcessInjection{
+		tracees: cache.NewLRUExpireCache(r1010TraceesSize),
+	}
+}
+
+func (rule *R1010ProcessInjection) Name() string {
+	return R1010Name
+}
+
+func (rule *R1010ProcessInjection) ID() string {
+	return R1010ID
+}
+
+func (rule *R1010ProcessInjection) DeleteRule() {
+}
+
+func (rule *R1010ProcessInjection) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) ruleengine.RuleFailure {
+	if eventType != utils.PtraceEventType {
+		return nil
+	}
+
+	ptraceEvent, ok := event.(*tracerptracetype.Event)
+	if !ok {
+		return nil
+	}
+
+	// a process writing to itself is not an injection, like a JIT patching its own code
+	if ptraceEvent.IsSelfInjection() {
+		return nil
+	}
+
+	// an attach is alerted on, then the requests and writes of the tracer to the same target are not
+	tracee := fmt.Sprintf("%s/%d/%d", ptraceEvent.Runtime.ContainerID, ptraceEvent.Pid, ptraceEvent.TargetPid)
+	attach := ptraceEvent.Request == "PTRACE_ATTACH" || ptraceEvent.Request == "PTRACE_SEIZE"
+	if _, alerted := rule.tracees.Get(tracee); alerted && !attach {
+		return nil
+	}
+	rule.tracees.Add(tracee, struct{}{}, r1010TraceesTTL)
+
+	method := ptraceEvent.Syscall
+	switch {
+	case ptraceEvent.Request != "":
+		method = fmt.Sprintf("ptrace(%s)", ptraceEvent.Request)
+	case ptraceEvent.Path != "":
+		method = fmt.Sprintf("write to %s", ptraceEvent.Path)
+	}
+
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:   rule.Name(),
+			InfectedPID: ptraceEvent.Pid,
+			Arguments: map[string]interface{}{
+				"syscall":   ptraceEvent.Syscall,
+				"request":   ptraceEvent.Request,
+				"targetPid": ptraceEvent.TargetPid,
+				"path":      ptraceEvent.Path,
+			},
+			FixSuggestions: "If this is a legitimate action, like a debugger or a profiler, please consider removing this workload from the binding of this rule.",
+			Severity:       R1010ProcessInjectionRuleDescriptor.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{
+				Comm: ptraceEvent.Comm,
+				Gid:  &ptraceEvent.Gid,
+				PID:  ptraceEvent.Pid,
+				Uid:  &ptraceEvent.Uid,
+				PPID: ptraceEvent.PPid,
+			},
+			ContainerID: ptraceEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: ptraceEvent.Event,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: fmt.Sprintf("Process (%s) injected into process %d with %s in: %s", ptraceEvent.Comm, ptraceEvent.TargetPid, method, ptraceEvent.GetContainer()),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: ptraceEvent.GetPod(),
+		},
+	}
+
+	return &ruleFailure
+}
+
+func (rule *R1010ProcessInjection) Requirements() ruleengine.RuleSpec {
+	return &RuleRequirements{
+		EventTypes: R1010ProcessInjectionRuleDescriptor.Requirements.RequiredEventTypes(),
+	}
+}
@@ -0,0 +1,77 @@
+package ruleengine
+
+import (
+	"node-agent/pkg/utils"
+	"testing"
+
+	tracerptracetype "node-agent/pkg/ebpf/gadgets/ptrace/types"
+)
+
+func TestR1010ProcessInjection(t *testing.T) {
+	// Create a new rule
+	r := CreateRuleR1010ProcessInjection()
+	// Assert r is not nil
+	if r == nil {
+		t.Errorf("Expected r to not be nil")
+	}
+
+	// Test other event types
+	if ruleResult := r.ProcessEvent(utils.RandomXEventType, &tracerptracetype.Event{}, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the event is not a ptrace event")
+	}
+
+	tests := []struct {
+		event       *tracerptracetype.Event
+		description string
+	}{
+		{
+			event:       &tracerptracetype.Event{Pid: 10, Comm: "gdb", Syscall: "ptrace", Request: "PTRACE_ATTACH", TargetPid: 2},
+			description: "Process (gdb) injected into process 2 with ptrace(PTRACE_ATTACH) in: ",
+		},
+		{
+			event:       &tracerptracetype.Event{Pid: 11, Comm: "inject", Syscall: "process_vm_writev", TargetPid: 2},
+			description: "Process (inject) injected into process 2 with process_vm_writev in: ",
+		},
+		{
+			event:       &tracerptracetype.Event{Pid: 12, Comm: "inject", Syscall: "openat", TargetPid: 2, Path: "/proc/2/mem"},
+			description: "Process (inject) injected into process 2 with write to /proc/2/mem in: ",
+		},
+	}
+	for _, tt := range tests {
+		ruleResult := r.ProcessEvent(utils.PtraceEventType, tt.event, &RuleObjectCacheMock{})
+		if ruleResult == nil {
+			t.Errorf("Expected ruleResult to be Failure because of %s", tt.event.Syscall)
+			continue
+		}
+		if ruleResult.GetRuleAlert().RuleDescription != tt.description {
+			t.Errorf("Expected description %q, got %q", tt.description, ruleResult.GetRuleAlert().RuleDescription)
+		}
+	}
+
+	// the requests following the attach are not alerted on
+	poke := &tracerptracetype.Event{Pid: 10, Comm: "gdb", Syscall: "ptrace", Request: "PTRACE_POKETEXT", TargetPid: 2}
+	if ruleResult := r.ProcessEvent(utils.PtraceEventType, poke, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the target is already attached")
+	}
+	// a new attach is alerted on
+	attach := &tracerptracetype.Event{Pid: 10, Comm: "gdb", Syscall: "ptrace", Request: "PTRACE_ATTACH", TargetPid: 2}
+	if ruleResult := r.ProcessEvent(utils.PtraceEventType, attach, &RuleObjectCacheMock{}); ruleResult == nil {
+		t.Errorf("Expected ruleResult to be Failure because of a new attach")
+	}
+	// the first request of a tracer attached before the agent started is alerted on
+	poke = &tracerptracetype.Event{Pid: 10, Comm: "gdb", Syscall: "ptrace", Request: "PTRACE_POKETEXT", TargetPid: 3}
+	if ruleResult := r.ProcessEvent(utils.PtraceEventType, poke, &RuleObjectCacheMock{}); ruleResult == nil {
+		t.Errorf("Expected ruleResult to be Failure because of a request to a new target")
+	}
+
+	// the writes of a process to itself or to another thread of its process are not injections
+	for _, event := range []*tracerptracetype.Event{
+		{Comm: "java", Syscall: "openat", NsPid: 5, NsTid: 6, TargetPid: 5, Path: "/proc/5/mem"},
+		{Comm: "java", Syscall: "process_vm_writev", NsPid: 5, NsTid: 6, TargetPid: 6},
+		{Comm: "java", Syscall: "process_vm_writev", NsPid: 5, NsTid: 6, TargetPid: 7, TargetTgid: 5},
+	} {
+		if ruleResult := r.ProcessEvent(utils.PtraceEventType, event, &RuleObjectCacheMock{}); ruleResult != nil {
+			t.Errorf("Expected ruleResult to be nil since the process writes to itself: %+v", event)
+		}
+	}
+}
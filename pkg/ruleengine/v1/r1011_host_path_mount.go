@@ -0,0 +1,126 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+	"strings"
+	"syscall"
+
+	tracerescapetype "node-agent/pkg/ebpf/gadgets/escape/types"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+)
+
+const (
+	R1011ID   = "R1011"
+	R1011Name = "Host Path Mount"
+)
+
+var R1011HostPathMountRuleDescriptor = RuleDescriptor{
+	ID:          R1011ID,
+	Name:        R1011Name,
+	Description: "Detecting mounts of host devices, of the host filesystem through /proc/1/root and of the cgroup filesystem, which can be used to escape container.",
+	Tags:        []string{"mount", "escape"},
+	Priority:    RulePriorityHigh,
+	Requirements: &RuleRequirements{
+		EventTypes: []utils.EventType{
+			utils.EscapeEventType,
+		},
+	},
+	RuleCreationFunc: func() ruleengine.RuleEvaluator {
+		return CreateRuleR1011HostPathMount()
+	},
+}
+
+var _ ruleengine.RuleEvaluator = (*R1011HostPathMount)(nil)
+
+type R1011HostPathMount struct {
+	BaseRule
+}
+
+func CreateRuleR1011HostPathMount() *R1011HostPathMount {
+	return &R1011HostPathMount{}
+}
+
+func (rule *R1011HostPathMount) Name() string {
+	return R1011Name
+}
+
+func (rule *R1011HostPathMount) ID() string {
+	return R1011ID
+}
+
+func (rule *R1011HostPathMount) DeleteRule() {
+}
+
+// hostMountReason returns why the mount exposes the host, or an empty string
+func hostMountReason(event *tracerescapetype.Event) string {
+	switch {
+	case event.FsType == "cgroup" || event.FsType == "cgroup2":
+		return "the cgroup filesystem is mounted"
+	case strings.HasPrefix(event.Source, "/proc/1/"):
+		return "the host filesystem is mounted through the init process"
+	case strings.HasPrefix(event.Source, "/dev/") && event.Flags&syscall.MS_BIND == 0:
+		return "a host device is mounted"
+	}
+	return ""
+}
+
+func (rule *R1011HostPathMount) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) ruleengine.RuleFailure {
+	if eventType != utils.EscapeEventType {
+		return nil
+	}
+
+	escapeEvent, ok := event.(*tracerescapetype.Event)
+	if !ok || escapeEvent.Syscall != "mount" {
+		return nil
+	}
+
+	reason := hostMountReason(escapeEvent)
+	if reason == "" {
+		return nil
+	}
+
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:   rule.Name(),
+			InfectedPID: escapeEvent.Pid,
+			Arguments: map[string]interface{}{
+				"source": escapeEvent.Source,
+				"target": escapeEvent.Target,
+				"fstype": escapeEvent.FsType,
+				"flags":  escapeEvent.Flags,
+			},
+			FixSuggestions: "If this is a legitimate action, please consider removing this workload from the binding of this rule.",
+			Severity:       R1011HostPathMountRuleDescriptor.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{
+				Comm: escapeEvent.Comm,
+				Gid:  &escapeEvent.Gid,
+				PID:  escapeEvent.Pid,
+				Uid:  &escapeEvent.Uid,
+				PPID: escapeEvent.PPid,
+			},
+			ContainerID: escapeEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: escapeEvent.Event,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: fmt.Sprintf("Mount of %s on %s, %s in: %s", escapeEvent.Source, escapeEvent.Target, reason, escapeEvent.GetContainer()),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: escapeEvent.GetPod(),
+		},
+	}
+
+	return &ruleFailure
+}
+
+func (rule *R1011HostPathMount) Requirements() ruleengine.RuleSpec {
+	return &RuleRequirements{
+		EventTypes: R1011HostPathMountRuleDescriptor.Requirements.RequiredEventTypes(),
+	}
+}
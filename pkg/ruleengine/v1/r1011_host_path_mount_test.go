@@ -0,0 +1,42 @@
+package ruleengine
+
+import (
+	"node-agent/pkg/utils"
+	"syscall"
+	"testing"
+
+	tracerescapetype "node-agent/pkg/ebpf/gadgets/escape/types"
+)
+
+func TestR1011HostPathMount(t *testing.T) {
+	// Create a new rule
+	r := CreateRuleR1011HostPathMount()
+	// Assert r is not nil
+	if r == nil {
+		t.Errorf("Expected r to not be nil")
+	}
+
+	tests := []struct {
+		name        string
+		event       *tracerescapetype.Event
+		expectAlert bool
+	}{
+		{name: "device mount", event: &tracerescapetype.Event{Syscall: "mount", Source: "/dev/sda1", Target: "/mnt", FsType: "ext4"}, expectAlert: true},
+		{name: "cgroup mount", event: &tracerescapetype.Event{Syscall: "mount", Source: "cgroup", Target: "/tmp/cgrp", FsType: "cgroup"}, expectAlert: true},
+		{name: "host root bind mount", event: &tracerescapetype.Event{Syscall: "mount", Source: "/proc/1/root", Target: "/mnt", Flags: syscall.MS_BIND}, expectAlert: true},
+		{name: "device bind mount", event: &tracerescapetype.Event{Syscall: "mount", Source: "/dev/null", Target: "/mnt/null", Flags: syscall.MS_BIND}},
+		{name: "tmpfs mount", event: &tracerescapetype.Event{Syscall: "mount", Source: "tmpfs", Target: "/tmp", FsType: "tmpfs"}},
+		{name: "pivot_root", event: &tracerescapetype.Event{Syscall: "pivot_root", Source: "/dev/root", Target: "/dev/root/old"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleResult := r.ProcessEvent(utils.EscapeEventType, tt.event, &RuleObjectCacheMock{})
+			if tt.expectAlert && ruleResult == nil {
+				t.Errorf("Expected ruleResult to be Failure because of %s", tt.name)
+			}
+			if !tt.expectAlert && ruleResult != nil {
+				t.Errorf("Expected ruleResult to be nil because of %s", tt.name)
+			}
+		})
+	}
+}
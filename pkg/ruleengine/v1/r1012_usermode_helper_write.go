@@ -0,0 +1,104 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+
+	tracerescapetype "node-agent/pkg/ebpf/gadgets/escape/types"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+)
+
+const (
+	R1012ID   = "R1012"
+	R1012Name = "Kernel Usermode Helper Write"
+)
+
+var R1012UsermodeHelperWriteRuleDescriptor = RuleDescriptor{
+	ID:          R1012ID,
+	Name:        R1012Name,
+	Description: "Detecting writes to the cgroup release_agent, core_pattern, uevent_helper and modprobe files, which make the kernel run a program on the host.",
+	Tags:        []string{"escape", "release_agent", "core_pattern"},
+	Priority:    RulePriorityCritical,
+	Requirements: &RuleRequirements{
+		EventTypes: []utils.EventType{
+			utils.EscapeEventType,
+		},
+	},
+	RuleCreationFunc: func() ruleengine.RuleEvaluator {
+		return CreateRuleR1012UsermodeHelperWrite()
+	},
+}
+
+var _ ruleengine.RuleEvaluator = (*R1012UsermodeHelperWrite)(nil)
+
+type R1012UsermodeHelperWrite struct {
+	BaseRule
+}
+
+func CreateRuleR1012UsermodeHelperWrite() *R1012UsermodeHelperWrite {
+	return &R1012UsermodeHelperWrite{}
+}
+
+func (rule *R1012UsermodeHelperWrite) Name() string {
+	return R1012Name
+}
+
+func (rule *R1012UsermodeHelperWrite) ID() string {
+	return R1012ID
+}
+
+func (rule *R1012UsermodeHelperWrite) DeleteRule() {
+}
+
+func (rule *R1012UsermodeHelperWrite) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) ruleengine.RuleFailure {
+	if eventType != utils.EscapeEventType {
+		return nil
+	}
+
+	// the tracer only reports the writes to the usermode helper files
+	escapeEvent, ok := event.(*tracerescapetype.Event)
+	if !ok || escapeEvent.Syscall != "openat" || escapeEvent.Path == "" {
+		return nil
+	}
+
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:   rule.Name(),
+			InfectedPID: escapeEvent.Pid,
+			Arguments: map[string]interface{}{
+				"path": escapeEvent.Path,
+			},
+			FixSuggestions: "If this is a legitimate action, please consider removing this workload from the binding of this rule.",
+			Severity:       R1012UsermodeHelperWriteRuleDescriptor.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{
+				Comm: escapeEvent.Comm,
+				Gid:  &escapeEvent.Gid,
+				PID:  escapeEvent.Pid,
+				Uid:  &escapeEvent.Uid,
+				PPID: escapeEvent.PPid,
+			},
+			ContainerID: escapeEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: escapeEvent.Event,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: fmt.Sprintf("Kernel usermode helper file %s opened for writing in: %s", escapeEvent.Path, escapeEvent.GetContainer()),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: escapeEvent.GetPod(),
+		},
+	}
+
+	return &ruleFailure
+}
+
+func (rule *R1012UsermodeHelperWrite) Requirements() ruleengine.RuleSpec {
+	return &RuleRequirements{
+		EventTypes: R1012UsermodeHelperWriteRuleDescriptor.Requirements.RequiredEventTypes(),
+	}
+}
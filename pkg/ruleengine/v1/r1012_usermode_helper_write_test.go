@@ -0,0 +1,34 @@
+package ruleengine
+
+import (
+	"node-agent/pkg/utils"
+	"testing"
+
+	tracerescapetype "node-agent/pkg/ebpf/gadgets/escape/types"
+)
+
+func TestR1012UsermodeHelperWrite(t *testing.T) {
+	// Create a new rule
+	r := CreateRuleR1012UsermodeHelperWrite()
+	// Assert r is not nil
+	if r == nil {
+		t.Errorf("Expected r to not be nil")
+	}
+
+	// Test a mount event
+	e := &tracerescapetype.Event{Syscall: "mount", Source: "cgroup", Target: "/tmp/cgrp", FsType: "cgroup"}
+	if ruleResult := r.ProcessEvent(utils.EscapeEventType, e, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the event is not a write")
+	}
+
+	// Test a release_agent write
+	e = &tracerescapetype.Event{Syscall: "openat", Path: "/tmp/cgrp/release_agent"}
+	ruleResult := r.ProcessEvent(utils.EscapeEventType, e, &RuleObjectCacheMock{})
+	if ruleResult == nil {
+		t.Errorf("Expected ruleResult to be Failure because of release_agent write")
+		return
+	}
+	if ruleResult.GetBaseRuntimeAlert().Arguments["path"] != "/tmp/cgrp/release_agent" {
+		t.Errorf("Expected the path argument to be the release_agent file")
+	}
+}
@@ -0,0 +1,103 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+
+	tracerescapetype "node-agent/pkg/ebpf/gadgets/escape/types"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+)
+
+const (
+	R1013ID   = "R1013"
+	R1013Name = "Setns Into Host Namespace"
+)
+
+var R1013SetnsHostNamespaceRuleDescriptor = RuleDescriptor{
+	ID:          R1013ID,
+	Name:        R1013Name,
+	Description: "Detecting setns system calls joining a namespace of the host, like nsenter into the host init process.",
+	Tags:        []string{"syscall", "escape", "setns"},
+	Priority:    RulePriorityCritical,
+	Requirements: &RuleRequirements{
+		EventTypes: []utils.EventType{
+			utils.EscapeEventType,
+		},
+	},
+	RuleCreationFunc: func() ruleengine.RuleEvaluator {
+		return CreateRuleR1013SetnsHostNamespace()
+	},
+}
+
+var _ ruleengine.RuleEvaluator = (*R1013SetnsHostNamespace)(nil)
+
+type R1013SetnsHostNamespace struct {
+	BaseRule
+}
+
+func CreateRuleR1013SetnsHostNamespace() *R1013SetnsHostNamespace {
+	return &R1013SetnsHostNamespace{}
+}
+
+func (rule *R1013SetnsHostNamespace) Name() string {
+	return R1013Name
+}
+
+func (rule *R1013SetnsHostNamespace) ID() string {
+	return R1013ID
+}
+
+func (rule *R1013SetnsHostNamespace) DeleteRule() {
+}
+
+func (rule *R1013SetnsHostNamespace) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) ruleengine.RuleFailure {
+	if eventType != utils.EscapeEventType {
+		return nil
+	}
+
+	escapeEvent, ok := event.(*tracerescapetype.Event)
+	if !ok || escapeEvent.Syscall != "setns" || !escapeEvent.HostNamespace {
+		return nil
+	}
+
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:   rule.Name(),
+			InfectedPID: escapeEvent.Pid,
+			Arguments: map[string]interface{}{
+				"namespace": escapeEvent.Namespace,
+			},
+			FixSuggestions: "If this is a legitimate action, please consider removing this workload from the binding of this rule.",
+			Severity:       R1013SetnsHostNamespaceRuleDescriptor.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{
+				Comm: escapeEvent.Comm,
+				Gid:  &escapeEvent.Gid,
+				PID:  escapeEvent.Pid,
+				Uid:  &escapeEvent.Uid,
+				PPID: escapeEvent.PPid,
+			},
+			ContainerID: escapeEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: escapeEvent.Event,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: fmt.Sprintf("Process (%s) joined the host namespace %s in: %s", escapeEvent.Comm, escapeEvent.Namespace, escapeEvent.GetContainer()),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: escapeEvent.GetPod(),
+		},
+	}
+
+	return &ruleFailure
+}
+
+func (rule *R1013SetnsHostNamespace) Requirements() ruleengine.RuleSpec {
+	return &RuleRequirements{
+		EventTypes: R1013SetnsHostNamespaceRuleDescriptor.Requirements.RequiredEventTypes(),
+	}
+}
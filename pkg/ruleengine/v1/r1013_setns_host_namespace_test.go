@@ -0,0 +1,29 @@
+package ruleengine
+
+import (
+	"node-agent/pkg/utils"
+	"testing"
+
+	tracerescapetype "node-agent/pkg/ebpf/gadgets/escape/types"
+)
+
+func TestR1013SetnsHostNamespace(t *testing.T) {
+	// Create a new rule
+	r := CreateRuleR1013SetnsHostNamespace()
+	// Assert r is not nil
+	if r == nil {
+		t.Errorf("Expected r to not be nil")
+	}
+
+	// Test setns into a container namespace
+	e := &tracerescapetype.Event{Syscall: "setns", Namespace: "mnt:[4026532000]"}
+	if ruleResult := r.ProcessEvent(utils.EscapeEventType, e, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the namespace is not a host namespace")
+	}
+
+	// Test setns into a host namespace
+	e = &tracerescapetype.Event{Syscall: "setns", Namespace: "mnt:[4026531840]", HostNamespace: true}
+	if ruleResult := r.ProcessEvent(utils.EscapeEventType, e, &RuleObjectCacheMock{}); ruleResult == nil {
+		t.Errorf("Expected ruleResult to be Failure because of setns into a host namespace")
+	}
+}
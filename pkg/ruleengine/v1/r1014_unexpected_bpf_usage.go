@@ -0,0 +1,141 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+
+	tracerbpftype "node-agent/pkg/ebpf/gadgets/bpf/types"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+)
+
+const (
+	R1014ID   = "R1014"
+	R1014Name = "Unexpected eBPF Usage"
+)
+
+var R1014UnexpectedBPFUsageRuleDescriptor = RuleDescriptor{
+	ID:          R1014ID,
+	Name:        R1014Name,
+	Description: "Detecting loads of eBPF programs, creations of eBPF maps and perf_event_open calls, with the program type and the attach point, that are not whitelisted by application profile.",
+	Tags:        []string{"bpf", "rootkit", "whitelisted"},
+	Priority:    RulePriorityCritical,
+	Requirements: &RuleRequirements{
+		EventTypes: []utils.EventType{utils.BPFEventType},
+	},
+	RuleCreationFunc: func() ruleengine.RuleEvaluator {
+		return CreateRuleR1014UnexpectedBPFUsage()
+	},
+}
+var _ ruleengine.RuleEvaluator = (*R1014UnexpectedBPFUsage)(nil)
+
+type R1014UnexpectedBPFUsage struct {
+	BaseRule
+}
+
+func CreateRuleR1014UnexpectedBPFUsage() *R1014UnexpectedBPFUsage {
+	return &R1014UnexpectedBPFUsage{}
+}
+func (rule *R1014UnexpectedBPFUsage) Name() string {
+	return R1014Name
+}
+
+func (rule *R1014UnexpectedBPFUsage) ID() string {
+	return R1014ID
+}
+
+func (rule *R1014UnexpectedBPFUsage) DeleteRule() {
+}
+
+func (rule *R1014UnexpectedBPFUsage) generatePatchCommand(event *tracerbpftype.Event, ap *v1beta1.ApplicationProfile) string {
+	baseTemplate := "kubectl annotate applicationprofile %s --namespace %s '%s=%s'"
+	return fmt.Sprintf(baseTemplate, ap.GetName(), ap.GetNamespace(),
+		utils.GetActivityMetadataKey(ap, event.GetContainer(), utils.BPFActivity, event.Usage), event.Usage)
+}
+
+func (rule *R1014UnexpectedBPFUsage) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) ruleengine.RuleFailure {
+	if eventType != utils.BPFEventType {
+		return nil
+	}
+
+	bpfEvent, ok := event.(*tracerbpftype.Event)
+	if !ok {
+		return nil
+	}
+
+	ap := objCache.ApplicationProfileCache().GetApplicationProfile(bpfEvent.Runtime.ContainerID)
+	if ap == nil {
+		return nil
+	}
+
+	if _, err := getContainerFromApplicationProfile(ap, bpfEvent.GetContainer()); err != nil {
+		return nil
+	}
+
+	for _, usage := range utils.GetActivities(ap, bpfEvent.GetContainer(), utils.BPFActivity) {
+		if bpfEvent.Usage == usage {
+			return nil
+		}
+	}
+
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:      rule.Name(),
+			InfectedPID:    bpfEvent.Pid,
+			FixSuggestions: fmt.Sprintf("If this is a valid behavior, please add the eBPF usage \"%s\" to the whitelist in the application profile for the Pod \"%s\". You can use the following command: %s", bpfEvent.Usage, bpfEvent.GetPod(), rule.generatePatchCommand(bpfEvent, ap)),
+			Severity:       R1014UnexpectedBPFUsageRuleDescriptor.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{
+				Comm: bpfEvent.Comm,
+				Gid:  &bpfEvent.Gid,
+				PID:  bpfEvent.Pid,
+				PPID: bpfEvent.PPid,
+				Uid:  &bpfEvent.Uid,
+			},
+			ContainerID: bpfEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: bpfEvent.Event,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: fmt.Sprintf("Unexpected eBPF usage (%s, program type %s, attach point %s) in: %s", bpfEvent.Usage, valueOrUnknown(bpfEvent.ProgramType), valueOrUnknown(bpfAttachPoint(bpfEvent)), bpfEvent.GetContainer()),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: bpfEvent.GetPod(),
+		},
+	}
+
+	return &ruleFailure
+}
+
+func (rule *R1014UnexpectedBPFUsage) Requirements() ruleengine.RuleSpec {
+	return &RuleRequirements{
+		EventTypes: R1014UnexpectedBPFUsageRuleDescriptor.Requirements.RequiredEventTypes(),
+	}
+}
+
+// bpfAttachPoint returns where the program or the event is attached, the attach type of bpf or the attach point of
+// perf_event_open and raw tracepoints
+func bpfAttachPoint(event *tracerbpftype.Event) string {
+	switch {
+	case event.PerfType != "" && event.AttachPoint != "":
+		return event.PerfType + ":" + event.AttachPoint
+	case event.PerfType != "":
+		return event.PerfType
+	case event.AttachPoint != "":
+		return event.AttachPoint
+	case event.AttachType != "None":
+		return event.AttachType
+	}
+	return ""
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
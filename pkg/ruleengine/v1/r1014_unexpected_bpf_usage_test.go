@@ -0,0 +1,85 @@
+package ruleengine
+
+import (
+	"node-agent/pkg/utils"
+	"strings"
+	"testing"
+
+	tracerbpftype "node-agent/pkg/ebpf/gadgets/bpf/types"
+
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+)
+
+func TestR1014UnexpectedBPFUsage(t *testing.T) {
+	// Create a new rule
+	r := CreateRuleR1014UnexpectedBPFUsage()
+	// Assert r is not nil
+	if r == nil {
+		t.Errorf("Expected r to not be nil")
+	}
+
+	e := &tracerbpftype.Event{
+		Event: eventtypes.Event{
+			CommonData: eventtypes.CommonData{
+				K8s: eventtypes.K8sMetadata{
+					BasicK8sMetadata: eventtypes.BasicK8sMetadata{
+						ContainerName: "test",
+					},
+				},
+			},
+		},
+		Syscall:     "bpf",
+		Command:     "PROG_LOAD",
+		ProgramType: "Kprobe",
+		AttachType:  "None",
+		Usage:       "bpf:PROG_LOAD:Kprobe",
+	}
+
+	// Test with nil appProfileAccess
+	ruleResult := r.ProcessEvent(utils.BPFEventType, e, &RuleObjectCacheMock{})
+	if ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since no appProfile is present")
+	}
+
+	objCache := RuleObjectCacheMock{}
+	profile := &v1beta1.ApplicationProfile{}
+	profile.Spec.Containers = append(profile.Spec.Containers, v1beta1.ApplicationProfileContainer{
+		Name: "test",
+	})
+	objCache.SetApplicationProfile(profile)
+
+	// Test with a usage not in the profile
+	ruleResult = r.ProcessEvent(utils.BPFEventType, e, &objCache)
+	if ruleResult == nil {
+		t.Fatalf("Expected ruleResult to not be nil since usage is not in the profile")
+	}
+	if !strings.Contains(ruleResult.GetRuleAlert().RuleDescription, "program type Kprobe") {
+		t.Errorf("Expected the program type in the rule description, got %s", ruleResult.GetRuleAlert().RuleDescription)
+	}
+
+	// Test with the usage in the profile
+	profile.Annotations = map[string]string{
+		utils.GetActivityMetadataKey(profile, "test", utils.BPFActivity, "bpf:PROG_LOAD:Kprobe"): "bpf:PROG_LOAD:Kprobe",
+	}
+	ruleResult = r.ProcessEvent(utils.BPFEventType, e, &objCache)
+	if ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since usage is in the profile")
+	}
+
+	// Test with a kprobe opened by perf_event_open
+	e = &tracerbpftype.Event{
+		Event:       e.Event,
+		Syscall:     "perf_event_open",
+		PerfType:    "kprobe",
+		AttachPoint: "do_sys_open",
+		Usage:       "perf_event_open:kprobe:do_sys_open",
+	}
+	ruleResult = r.ProcessEvent(utils.BPFEventType, e, &objCache)
+	if ruleResult == nil {
+		t.Fatalf("Expected ruleResult to not be nil since usage is not in the profile")
+	}
+	if !strings.Contains(ruleResult.GetRuleAlert().RuleDescription, "attach point kprobe:do_sys_open") {
+		t.Errorf("Expected the attach point in the rule description, got %s", ruleResult.GetRuleAlert().RuleDescription)
+	}
+}
@@ -0,0 +1,147 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+	"strings"
+	"time"
+
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+)
+
+const (
+	R1015ID   = "R1015"
+	R1015Name = "Binary Written Then Executed"
+	// R1015Window is the time after which the execution of a written file is not correlated with the write anymore
+	R1015Window = time.Hour
+)
+
+var R1015BinaryWrittenThenExecutedRuleDescriptor = RuleDescriptor{
+	ID:          R1015ID,
+	Name:        R1015Name,
+	Description: "Detecting executions of files written, renamed or linked into place by the container, like a downloaded payload.",
+	Tags:        []string{"exec", "malicious", "binary", "tamper"},
+	Priority:    RulePriorityCritical,
+	Requirements: &RuleRequirements{
+		EventTypes: []utils.EventType{utils.OpenEventType, utils.FileModEventType, utils.ExecveEventType},
+	},
+	RuleCreationFunc: func() ruleengine.RuleEvaluator {
+		return CreateRuleR1015BinaryWrittenThenExecuted()
+	},
+}
+
+var _ ruleengine.RuleEvaluator = (*R1015BinaryWrittenThenExecuted)(nil)
+
+type R1015BinaryWrittenThenExecuted struct {
+	BaseRule
+	// writtenThenExecuted correlates the write of a file with its execution
+	writtenThenExecuted *CorrelationTracker
+}
+
+func CreateRuleR1015BinaryWrittenThenExecuted() *R1015BinaryWrittenThenExecuted {
+	return &R1015BinaryWrittenThenExecuted{
+		writtenThenExecuted: NewCorrelationTracker(CorrelationSequence{
+			Steps: []CorrelationStep{
+				{
+					EventTypes: []utils.EventType{utils.OpenEventType, utils.FileModEventType},
+					Match: func(eventType utils.EventType, event interface{}, _ objectcache.ObjectCache) bool {
+						switch e := event.(type) {
+						case *traceropentype.Event:
+							return isOpenForWrite(e)
+						case *tracerfilemodtype.Event:
+							return e.Operation == tracerfilemodtype.RenameOperation || e.Operation == tracerfilemodtype.LinkOperation
+						}
+						return false
+					},
+				},
+				{
+					EventTypes: []utils.EventType{utils.ExecveEventType},
+					Match: func(_ utils.EventType, _ interface{}, _ objectcache.ObjectCache) bool {
+						return true
+					},
+				},
+			},
+			Window: R1015Window,
+			KeyBy:  CorrelateByPath,
+		}),
+	}
+}
+
+func (rule *R1015BinaryWrittenThenExecuted) Name() string {
+	return R1015Name
+}
+
+func (rule *R1015BinaryWrittenThenExecuted) ID() string {
+	return R1015ID
+}
+
+func (rule *R1015BinaryWrittenThenExecuted) DeleteRule() {
+}
+
+func (rule *R1015BinaryWrittenThenExecuted) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) ruleengine.RuleFailure {
+	if eventType != utils.OpenEventType && eventType != utils.FileModEventType && eventType != utils.ExecveEventType {
+		return nil
+	}
+
+	if !rule.writtenThenExecuted.ProcessEvent(eventType, event, objCache) {
+		return nil
+	}
+
+	// the sequence is completed by the execution
+	execEvent, ok := event.(*tracerexectype.Event)
+	if !ok {
+		return nil
+	}
+
+	fullPath := getExecFullPathFromEvent(execEvent)
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:   rule.Name(),
+			InfectedPID: execEvent.Pid,
+			Arguments: map[string]interface{}{
+				"path":     fullPath,
+				"hardlink": execEvent.ExePath,
+			},
+			FixSuggestions: "If this is a legitimate action, like a program installed at runtime, please consider removing this workload from the binding of this rule.",
+			Severity:       R1015BinaryWrittenThenExecutedRuleDescriptor.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{
+				Comm:       execEvent.Comm,
+				Gid:        &execEvent.Gid,
+				PID:        execEvent.Pid,
+				Uid:        &execEvent.Uid,
+				UpperLayer: execEvent.UpperLayer,
+				PPID:       execEvent.Ppid,
+				Pcomm:      execEvent.Pcomm,
+				Cwd:        execEvent.Cwd,
+				Hardlink:   execEvent.ExePath,
+				Path:       fullPath,
+				Cmdline:    fmt.Sprintf("%s %s", getExecPathFromEvent(execEvent), strings.Join(utils.GetExecArgsFromEvent(execEvent), " ")),
+			},
+			ContainerID: execEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: execEvent.Event,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: fmt.Sprintf("Process (%s) was executed after being written in: %s", fullPath, execEvent.GetContainer()),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: execEvent.GetPod(),
+		},
+	}
+
+	return &ruleFailure
+}
+
+func (rule *R1015BinaryWrittenThenExecuted) Requirements() ruleengine.RuleSpec {
+	return &RuleRequirements{
+		EventTypes: R1015BinaryWrittenThenExecutedRuleDescriptor.Requirements.RequiredEventTypes(),
+	}
+}
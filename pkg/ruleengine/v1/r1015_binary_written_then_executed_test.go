@@ -0,0 +1,68 @@
+package ruleengine
+
+import (
+	"node-agent/pkg/utils"
+	"syscall"
+	"testing"
+
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func TestR1015BinaryWrittenThenExecuted(t *testing.T) {
+	// Create a new rule
+	r := CreateRuleR1015BinaryWrittenThenExecuted()
+	// Assert r is not nil
+	if r == nil {
+		t.Errorf("Expected r to not be nil")
+	}
+
+	base := eventtypes.Event{
+		CommonData: eventtypes.CommonData{
+			Runtime: eventtypes.BasicRuntimeMetadata{ContainerID: "test"},
+		},
+	}
+
+	// Test the execution of a file that was only read
+	read := &traceropentype.Event{Event: base, FullPath: "/tmp/payload", FlagsRaw: syscall.O_RDONLY}
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, read, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the file is only read")
+	}
+	exec := &tracerexectype.Event{Event: base, Comm: "payload", Args: []string{"/tmp/payload"}}
+	if ruleResult := r.ProcessEvent(utils.ExecveEventType, exec, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the file was not written")
+	}
+
+	// Test the execution of a written file
+	write := &traceropentype.Event{Event: base, FullPath: "/tmp/payload", FlagsRaw: syscall.O_WRONLY | syscall.O_CREAT}
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, write, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the file is not executed yet")
+	}
+	ruleResult := r.ProcessEvent(utils.ExecveEventType, exec, &RuleObjectCacheMock{})
+	if ruleResult == nil {
+		t.Fatalf("Expected ruleResult to be Failure because the written file was executed")
+	}
+	if ruleResult.GetBaseRuntimeAlert().Arguments["path"] != "/tmp/payload" {
+		t.Errorf("Expected the path argument to be the executed file")
+	}
+
+	// Test the execution of a file renamed into place, found in the PATH
+	rename := &tracerfilemodtype.Event{Event: base, Operation: tracerfilemodtype.RenameOperation, Source: "/tmp/payload", Path: "/usr/bin/ls"}
+	if ruleResult := r.ProcessEvent(utils.FileModEventType, rename, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the file is not executed yet")
+	}
+	exec = &tracerexectype.Event{Event: base, Comm: "ls", Args: []string{"ls"}, ExePath: "/usr/bin/ls"}
+	if ruleResult := r.ProcessEvent(utils.ExecveEventType, exec, &RuleObjectCacheMock{}); ruleResult == nil {
+		t.Errorf("Expected ruleResult to be Failure because the renamed file was executed")
+	}
+
+	// Test the execution of a chmoded file
+	chmod := &tracerfilemodtype.Event{Event: base, Operation: tracerfilemodtype.ChmodOperation, Path: "/usr/bin/ls", Mode: 0755}
+	r.ProcessEvent(utils.FileModEventType, chmod, &RuleObjectCacheMock{})
+	if ruleResult := r.ProcessEvent(utils.ExecveEventType, exec, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the file was not written")
+	}
+}
@@ -0,0 +1,158 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+	"slices"
+	"strings"
+
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	R1016ID   = "R1016"
+	R1016Name = "Sensitive File Modification"
+)
+
+// SensitiveFiles are the files of the users, the privileges, the scheduled jobs and the preloaded libraries
+var SensitiveFiles = []string{
+	"/etc/passwd",
+	"/etc/shadow",
+	"/etc/group",
+	"/etc/gshadow",
+	"/etc/sudoers",
+	"/etc/crontab",
+	"/etc/ld.so.preload",
+}
+
+// SensitiveDirectories are the directories of the sudoers and the scheduled jobs, all their files are sensitive
+var SensitiveDirectories = []string{
+	"/etc/sudoers.d/",
+	"/etc/cron.d/",
+	"/etc/cron.hourly/",
+	"/etc/cron.daily/",
+	"/etc/cron.weekly/",
+	"/etc/cron.monthly/",
+	"/var/spool/cron/",
+}
+
+var R1016SensitiveFileModificationRuleDescriptor = RuleDescriptor{
+	ID:          R1016ID,
+	Name:        R1016Name,
+	Description: "Detecting writes, renames, unlinks, chmods and links of /etc/passwd, /etc/shadow, the sudoers, the crontabs and /etc/ld.so.preload.",
+	Tags:        []string{"files", "tamper", "persistence"},
+	Priority:    RulePriorityHigh,
+	Requirements: &RuleRequirements{
+		EventTypes: []utils.EventType{utils.OpenEventType, utils.FileModEventType},
+	},
+	RuleCreationFunc: func() ruleengine.RuleEvaluator {
+		return CreateRuleR1016SensitiveFileModification()
+	},
+}
+
+var _ ruleengine.RuleEvaluator = (*R1016SensitiveFileModification)(nil)
+
+type R1016SensitiveFileModification struct {
+	BaseRule
+}
+
+func CreateRuleR1016SensitiveFileModification() *R1016SensitiveFileModification {
+	return &R1016SensitiveFileModification{}
+}
+
+func (rule *R1016SensitiveFileModification) Name() string {
+	return R1016Name
+}
+
+func (rule *R1016SensitiveFileModification) ID() string {
+	return R1016ID
+}
+
+func (rule *R1016SensitiveFileModification) DeleteRule() {
+}
+
+// IsSensitiveFile returns whether a file is one of the sensitive files or is in one of the sensitive directories
+func IsSensitiveFile(path string) bool {
+	if slices.Contains(SensitiveFiles, path) {
+		return true
+	}
+	for _, directory := range SensitiveDirectories {
+		if strings.HasPrefix(path, directory) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule *R1016SensitiveFileModification) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) ruleengine.RuleFailure {
+	var (
+		triggerEvent igtypes.Event
+		process      apitypes.Process
+		path         string
+		operation    string
+	)
+
+	switch eventType {
+	case utils.OpenEventType:
+		openEvent, ok := event.(*traceropentype.Event)
+		if !ok || !isOpenForWrite(openEvent) || !IsSensitiveFile(openEvent.FullPath) {
+			return nil
+		}
+		triggerEvent, path, operation = openEvent.Event, openEvent.FullPath, "write"
+		process = apitypes.Process{Comm: openEvent.Comm, Gid: &openEvent.Gid, PID: openEvent.Pid, Uid: &openEvent.Uid}
+	case utils.FileModEventType:
+		filemodEvent, ok := event.(*tracerfilemodtype.Event)
+		if !ok {
+			return nil
+		}
+		// moving a sensitive file away is a modification too
+		paths := filemodEvent.ModifiedPaths()
+		idx := slices.IndexFunc(paths, IsSensitiveFile)
+		if idx < 0 {
+			return nil
+		}
+		triggerEvent, path, operation = filemodEvent.Event, paths[idx], filemodEvent.Operation
+		process = apitypes.Process{Comm: filemodEvent.Comm, Gid: &filemodEvent.Gid, PID: filemodEvent.Pid, Uid: &filemodEvent.Uid, PPID: filemodEvent.PPid}
+	default:
+		return nil
+	}
+
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:   rule.Name(),
+			InfectedPID: process.PID,
+			Arguments: map[string]interface{}{
+				"path":      path,
+				"operation": operation,
+			},
+			FixSuggestions: "If this is a legitimate action, like a user added by the entrypoint, please consider removing this workload from the binding of this rule.",
+			Severity:       R1016SensitiveFileModificationRuleDescriptor.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: process,
+			ContainerID: triggerEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: triggerEvent,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: fmt.Sprintf("Sensitive file %s was modified (%s) in: %s", path, operation, triggerEvent.GetContainer()),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: triggerEvent.GetPod(),
+		},
+	}
+
+	return &ruleFailure
+}
+
+func (rule *R1016SensitiveFileModification) Requirements() ruleengine.RuleSpec {
+	return &RuleRequirements{
+		EventTypes: R1016SensitiveFileModificationRuleDescriptor.Requirements.RequiredEventTypes(),
+	}
+}
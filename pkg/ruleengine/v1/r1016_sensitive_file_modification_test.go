@@ -0,0 +1,60 @@
+package ruleengine
+
+import (
+	"node-agent/pkg/utils"
+	"syscall"
+	"testing"
+
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+)
+
+func TestR1016SensitiveFileModification(t *testing.T) {
+	// Create a new rule
+	r := CreateRuleR1016SensitiveFileModification()
+	// Assert r is not nil
+	if r == nil {
+		t.Errorf("Expected r to not be nil")
+	}
+
+	// Test a read of /etc/shadow
+	e := &traceropentype.Event{FullPath: "/etc/shadow", FlagsRaw: syscall.O_RDONLY}
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, e, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the file is only read")
+	}
+
+	// Test a write of /etc/passwd
+	e = &traceropentype.Event{FullPath: "/etc/passwd", FlagsRaw: syscall.O_WRONLY | syscall.O_APPEND}
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, e, &RuleObjectCacheMock{}); ruleResult == nil {
+		t.Errorf("Expected ruleResult to be Failure because of the write of /etc/passwd")
+	}
+
+	// Test a write of another file
+	e = &traceropentype.Event{FullPath: "/etc/hosts", FlagsRaw: syscall.O_RDWR}
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, e, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the file is not sensitive")
+	}
+
+	// Test a crontab renamed into place
+	f := &tracerfilemodtype.Event{Operation: tracerfilemodtype.RenameOperation, Source: "/tmp/job", Path: "/var/spool/cron/crontabs/root"}
+	ruleResult := r.ProcessEvent(utils.FileModEventType, f, &RuleObjectCacheMock{})
+	if ruleResult == nil {
+		t.Fatalf("Expected ruleResult to be Failure because of the crontab rename")
+	}
+	if ruleResult.GetBaseRuntimeAlert().Arguments["path"] != "/var/spool/cron/crontabs/root" {
+		t.Errorf("Expected the path argument to be the crontab")
+	}
+
+	// Test /etc/ld.so.preload moved away
+	f = &tracerfilemodtype.Event{Operation: tracerfilemodtype.RenameOperation, Source: "/etc/ld.so.preload", Path: "/tmp/preload"}
+	if ruleResult := r.ProcessEvent(utils.FileModEventType, f, &RuleObjectCacheMock{}); ruleResult == nil {
+		t.Errorf("Expected ruleResult to be Failure because of the rename of /etc/ld.so.preload")
+	}
+
+	// Test a symlink to /etc/shadow
+	f = &tracerfilemodtype.Event{Operation: tracerfilemodtype.SymlinkOperation, Source: "/etc/shadow", Path: "/tmp/shadow"}
+	if ruleResult := r.ProcessEvent(utils.FileModEventType, f, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the link target is not modified")
+	}
+}
@@ -0,0 +1,120 @@
+package ruleengine
+
+import (
+	"fmt"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+	"strings"
+	"syscall"
+
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	igtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	R1017ID   = "R1017"
+	R1017Name = "Log File Truncation"
+	// LogDirectory is the directory of the system logs
+	LogDirectory = "/var/log/"
+)
+
+var R1017LogFileTruncationRuleDescriptor = RuleDescriptor{
+	ID:          R1017ID,
+	Name:        R1017Name,
+	Description: "Detecting truncations of the files of /var/log, which hide the traces of an attack.",
+	Tags:        []string{"logs", "tamper", "defense evasion"},
+	Priority:    RulePriorityHigh,
+	Requirements: &RuleRequirements{
+		EventTypes: []utils.EventType{utils.OpenEventType, utils.FileModEventType},
+	},
+	RuleCreationFunc: func() ruleengine.RuleEvaluator {
+		return CreateRuleR1017LogFileTruncation()
+	},
+}
+
+var _ ruleengine.RuleEvaluator = (*R1017LogFileTruncation)(nil)
+
+type R1017LogFileTruncation struct {
+	BaseRule
+}
+
+func CreateRuleR1017LogFileTruncation() *R1017LogFileTruncation {
+	return &R1017LogFileTruncation{}
+}
+
+func (rule *R1017LogFileTruncation) Name() string {
+	return R1017Name
+}
+
+func (rule *R1017LogFileTruncation) ID() string {
+	return R1017ID
+}
+
+func (rule *R1017LogFileTruncation) DeleteRule() {
+}
+
+func (rule *R1017LogFileTruncation) ProcessEvent(eventType utils.EventType, event interface{}, objCache objectcache.ObjectCache) ruleengine.RuleFailure {
+	var (
+		triggerEvent igtypes.Event
+		process      apitypes.Process
+		path         string
+		syscallName  string
+	)
+
+	switch eventType {
+	case utils.OpenEventType:
+		// a shell redirection truncates the file when it opens it
+		openEvent, ok := event.(*traceropentype.Event)
+		if !ok || !isOpenForWrite(openEvent) || openEvent.FlagsRaw&syscall.O_TRUNC == 0 || !strings.HasPrefix(openEvent.FullPath, LogDirectory) {
+			return nil
+		}
+		triggerEvent, path, syscallName = openEvent.Event, openEvent.FullPath, "open"
+		process = apitypes.Process{Comm: openEvent.Comm, Gid: &openEvent.Gid, PID: openEvent.Pid, Uid: &openEvent.Uid}
+	case utils.FileModEventType:
+		filemodEvent, ok := event.(*tracerfilemodtype.Event)
+		if !ok || filemodEvent.Operation != tracerfilemodtype.TruncateOperation || !strings.HasPrefix(filemodEvent.Path, LogDirectory) {
+			return nil
+		}
+		triggerEvent, path, syscallName = filemodEvent.Event, filemodEvent.Path, filemodEvent.Syscall
+		process = apitypes.Process{Comm: filemodEvent.Comm, Gid: &filemodEvent.Gid, PID: filemodEvent.Pid, Uid: &filemodEvent.Uid, PPID: filemodEvent.PPid}
+	default:
+		return nil
+	}
+
+	ruleFailure := GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{
+			AlertName:   rule.Name(),
+			InfectedPID: process.PID,
+			Arguments: map[string]interface{}{
+				"path":    path,
+				"syscall": syscallName,
+			},
+			FixSuggestions: "If this is a legitimate action, like a log rotation with copytruncate, please consider removing this workload from the binding of this rule.",
+			Severity:       R1017LogFileTruncationRuleDescriptor.Priority,
+		},
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: process,
+			ContainerID: triggerEvent.Runtime.ContainerID,
+		},
+		TriggerEvent: triggerEvent,
+		RuleAlert: apitypes.RuleAlert{
+			RuleID:          rule.ID(),
+			RuleDescription: fmt.Sprintf("Log file %s was truncated (%s) in: %s", path, syscallName, triggerEvent.GetContainer()),
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{
+			PodName: triggerEvent.GetPod(),
+		},
+	}
+
+	return &ruleFailure
+}
+
+func (rule *R1017LogFileTruncation) Requirements() ruleengine.RuleSpec {
+	return &RuleRequirements{
+		EventTypes: R1017LogFileTruncationRuleDescriptor.Requirements.RequiredEventTypes(),
+	}
+}
@@ -0,0 +1,54 @@
+package ruleengine
+
+import (
+	"node-agent/pkg/utils"
+	"syscall"
+	"testing"
+
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+)
+
+func TestR1017LogFileTruncation(t *testing.T) {
+	// Create a new rule
+	r := CreateRuleR1017LogFileTruncation()
+	// Assert r is not nil
+	if r == nil {
+		t.Errorf("Expected r to not be nil")
+	}
+
+	// Test an append to a log file
+	e := &traceropentype.Event{FullPath: "/var/log/auth.log", FlagsRaw: syscall.O_WRONLY | syscall.O_APPEND}
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, e, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the log file is not truncated")
+	}
+
+	// Test a shell redirection to a log file
+	e = &traceropentype.Event{FullPath: "/var/log/auth.log", FlagsRaw: syscall.O_WRONLY | syscall.O_CREAT | syscall.O_TRUNC}
+	if ruleResult := r.ProcessEvent(utils.OpenEventType, e, &RuleObjectCacheMock{}); ruleResult == nil {
+		t.Errorf("Expected ruleResult to be Failure because of the truncation of the log file")
+	}
+
+	// Test a truncation of another file
+	f := &tracerfilemodtype.Event{Syscall: "ftruncate", Operation: tracerfilemodtype.TruncateOperation, Path: "/data/db.sqlite"}
+	if ruleResult := r.ProcessEvent(utils.FileModEventType, f, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the file is not a log file")
+	}
+
+	// Test a truncate of a log file
+	f = &tracerfilemodtype.Event{Syscall: "truncate", Operation: tracerfilemodtype.TruncateOperation, Path: "/var/log/wtmp"}
+	ruleResult := r.ProcessEvent(utils.FileModEventType, f, &RuleObjectCacheMock{})
+	if ruleResult == nil {
+		t.Fatalf("Expected ruleResult to be Failure because of the truncation of the log file")
+	}
+	if ruleResult.GetBaseRuntimeAlert().Arguments["syscall"] != "truncate" {
+		t.Errorf("Expected the syscall argument to be truncate")
+	}
+
+	// Test an unlink of a log file
+	f = &tracerfilemodtype.Event{Syscall: "unlink", Operation: tracerfilemodtype.UnlinkOperation, Path: "/var/log/wtmp"}
+	if ruleResult := r.ProcessEvent(utils.FileModEventType, f, &RuleObjectCacheMock{}); ruleResult != nil {
+		t.Errorf("Expected ruleResult to be nil since the log file is not truncated")
+	}
+}
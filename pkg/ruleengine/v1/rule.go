@@ -3,6 +3,7 @@ package ruleengine
 import (
 	"node-agent/pkg/ruleengine"
 	"node-agent/pkg/utils"
+	"sync"
 
 	"github.com/goradd/maps"
 )
@@ -33,6 +34,13 @@ type RuleDescriptor struct {
 	RuleCreationFunc func() ruleengine.RuleEvaluator
 }
 
+// CreateRule creates a rule evaluator tagged with the descriptor tags
+func (r *RuleDescriptor) CreateRule() ruleengine.RuleEvaluator {
+	rule := r.RuleCreationFunc()
+	rule.SetTags(r.Tags)
+	return rule
+}
+
 func (r *RuleDescriptor) HasTags(tags []string) bool {
 	for _, tag := range tags {
 		for _, ruleTag := range r.Tags {
@@ -59,6 +67,11 @@ func (r *RuleRequirements) RequiredEventTypes() []utils.EventType {
 type BaseRule struct {
 	// Mutex for protecting rule parameters.
 	parameters maps.SafeMap[string, interface{}]
+	// Exceptions and severity set by the rule binding, applied by the rule manager
+	bindingMutex sync.RWMutex
+	exceptions   []ruleengine.RuleException
+	severity     *int
+	tags         []string
 }
 
 func (br *BaseRule) SetParameters(parameters map[string]interface{}) {
@@ -80,3 +93,42 @@ func (br *BaseRule) GetParameters() map[string]interface{} {
 	)
 	return parametersCopy
 }
+
+func (br *BaseRule) SetExceptions(exceptions []ruleengine.RuleException) {
+	br.bindingMutex.Lock()
+	defer br.bindingMutex.Unlock()
+	br.exceptions = exceptions
+}
+
+func (br *BaseRule) GetExceptions() []ruleengine.RuleException {
+	br.bindingMutex.RLock()
+	defer br.bindingMutex.RUnlock()
+	return br.exceptions
+}
+
+func (br *BaseRule) SetSeverity(severity int) {
+	br.bindingMutex.Lock()
+	defer br.bindingMutex.Unlock()
+	br.severity = &severity
+}
+
+func (br *BaseRule) GetSeverity() (int, bool) {
+	br.bindingMutex.RLock()
+	defer br.bindingMutex.RUnlock()
+	if br.severity == nil {
+		return 0, false
+	}
+	return *br.severity, true
+}
+
+func (br *BaseRule) SetTags(tags []string) {
+	br.bindingMutex.Lock()
+	defer br.bindingMutex.Unlock()
+	br.tags = tags
+}
+
+func (br *BaseRule) GetTags() []string {
+	br.bindingMutex.RLock()
+	defer br.bindingMutex.RUnlock()
+	return br.tags
+}
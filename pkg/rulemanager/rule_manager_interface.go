@@ -2,6 +2,8 @@ package rulemanager
 
 import (
 	tracerrandomxtype "node-agent/pkg/ebpf/gadgets/randomx/types"
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+	"node-agent/pkg/utils"
 
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	tracercapabilitiestype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
@@ -20,4 +22,7 @@ type RuleManagerClient interface {
 	ReportNetworkEvent(k8sContainerID string, event tracernetworktype.Event)
 	ReportDNSEvent(event tracerdnstype.Event)
 	ReportRandomxEvent(k8sContainerID string, event tracerrandomxtype.Event)
+	// ReportGadgetEvent reports a pointer to an event of a gadget tracing syscalls, see utils.EventSources
+	ReportGadgetEvent(eventType utils.EventType, k8sContainerID string, event interface{})
+	ReportSyscallEvent(k8sContainerID string, event ruleenginetypes.SyscallEvent)
 }
@@ -2,6 +2,8 @@ package rulemanager
 
 import (
 	tracerrandomxtype "node-agent/pkg/ebpf/gadgets/randomx/types"
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+	"node-agent/pkg/utils"
 
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	tracercapabilitiestype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
@@ -48,3 +50,9 @@ func (r *RuleManagerMock) ReportDNSEvent(event tracerdnstype.Event) {
 func (r *RuleManagerMock) ReportRandomxEvent(_ string, _ tracerrandomxtype.Event) {
 	// noop
 }
+func (r *RuleManagerMock) ReportGadgetEvent(_ utils.EventType, _ string, _ interface{}) {
+	// noop
+}
+func (r *RuleManagerMock) ReportSyscallEvent(_ string, _ ruleenginetypes.SyscallEvent) {
+	// noop
+}
@@ -0,0 +1,182 @@
+package rulemanager
+
+import (
+	"fmt"
+	"node-agent/pkg/exporters"
+	"node-agent/pkg/ruleengine"
+	ruleenginetypes "node-agent/pkg/ruleengine/types"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"node-agent/pkg/utils"
+	"strings"
+	"sync"
+	"time"
+
+	tracerrandomxtype "node-agent/pkg/ebpf/gadgets/randomx/types"
+
+	tracercapabilitiestype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	tracerdnstype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	tracernetworktype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+)
+
+// maxAggregatedAlerts bounds the number of open aggregation windows, alerts are sent as is when it is reached
+const maxAggregatedAlerts = 10000
+
+// aggregatedAlert is an open aggregation window
+type aggregatedAlert struct {
+	alert       ruleengine.RuleFailure // the first alert of the window, nil until it is enriched
+	occurrences int
+	firstSeen   time.Time
+	lastSeen    time.Time
+}
+
+// alertAggregator suppresses repeated alerts of the same rule in the same container within a window,
+// and sends a summary with the number of occurrences when the window closes.
+type alertAggregator struct {
+	window   time.Duration
+	exporter exporters.Exporter
+	mutex    sync.Mutex
+	alerts   map[string]*aggregatedAlert
+}
+
+func newAlertAggregator(window time.Duration, exporter exporters.Exporter) *alertAggregator {
+	return &alertAggregator{
+		window:   window,
+		exporter: exporter,
+		alerts:   make(map[string]*aggregatedAlert),
+	}
+}
+
+// aggregate records an occurrence of the alert, it returns true if the alert is a repeat and should not be sent.
+// The first alert of a window must be registered with setAlert once it is enriched.
+func (a *alertAggregator) aggregate(key string) bool {
+	now := time.Now()
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if entry, ok := a.alerts[key]; ok {
+		entry.occurrences++
+		entry.lastSeen = now
+		return true
+	}
+	if len(a.alerts) >= maxAggregatedAlerts {
+		logger.L().Debug("RuleManager - too many aggregated alerts, sending alert as is", helpers.String("key", key))
+		return false
+	}
+	a.alerts[key] = &aggregatedAlert{
+		occurrences: 1,
+		firstSeen:   now,
+		lastSeen:    now,
+	}
+	time.AfterFunc(a.window, func() {
+		a.flush(key)
+	})
+	return false
+}
+
+func (a *alertAggregator) setAlert(key string, alert ruleengine.RuleFailure) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if entry, ok := a.alerts[key]; ok && entry.alert == nil {
+		entry.alert = alert
+	}
+}
+
+// flush closes the window and sends the summary if there were repeated alerts
+func (a *alertAggregator) flush(key string) {
+	a.mutex.Lock()
+	entry, ok := a.alerts[key]
+	delete(a.alerts, key)
+	a.mutex.Unlock()
+
+	if !ok || entry.alert == nil || entry.occurrences < 2 {
+		return
+	}
+	a.exporter.SendRuleAlert(summarizeAlert(entry))
+}
+
+// summarizeAlert creates the summary alert of the window from its first alert
+func summarizeAlert(entry *aggregatedAlert) ruleengine.RuleFailure {
+	baseRuntimeAlert := entry.alert.GetBaseRuntimeAlert()
+	arguments := make(map[string]interface{}, len(baseRuntimeAlert.Arguments)+3)
+	for k, v := range baseRuntimeAlert.Arguments {
+		arguments[k] = v
+	}
+	arguments["occurrences"] = entry.occurrences
+	arguments["firstSeen"] = entry.firstSeen.UTC().Format(time.RFC3339)
+	arguments["lastSeen"] = entry.lastSeen.UTC().Format(time.RFC3339)
+	baseRuntimeAlert.Arguments = arguments
+	baseRuntimeAlert.Timestamp = entry.lastSeen
+
+	ruleAlert := entry.alert.GetRuleAlert()
+	ruleAlert.RuleDescription = fmt.Sprintf("%s (%d occurrences between %s and %s)", ruleAlert.RuleDescription, entry.occurrences,
+		entry.firstSeen.UTC().Format(time.RFC3339), entry.lastSeen.UTC().Format(time.RFC3339))
+
+	return &ruleenginev1.GenericRuleFailure{
+		BaseRuntimeAlert:       baseRuntimeAlert,
+		RuntimeProcessDetails:  entry.alert.GetRuntimeProcessDetails(),
+		TriggerEvent:           entry.alert.GetTriggerEvent(),
+		RuleAlert:              ruleAlert,
+		RuntimeAlertK8sDetails: entry.alert.GetRuntimeAlertK8sDetails(),
+		RuleTags:               entry.alert.GetRuleTags(),
+	}
+}
+
+// aggregationKey identifies repeated alerts: the rule, the container and the salient fields of the event
+func aggregationKey(ruleID string, ruleFailure ruleengine.RuleFailure, eventType utils.EventType, event interface{}) string {
+	triggerEvent := ruleFailure.GetTriggerEvent()
+	container := triggerEvent.Runtime.ContainerID
+	if container == "" {
+		container = utils.CreateK8sContainerID(triggerEvent.K8s.Namespace, triggerEvent.K8s.PodName, triggerEvent.K8s.ContainerName)
+	}
+	return strings.Join(append([]string{ruleID, container, eventType.String()}, salientFields(eventType, event)...), "/")
+}
+
+func salientFields(eventType utils.EventType, event interface{}) []string {
+	switch eventType {
+	case utils.ExecveEventType:
+		if e, ok := event.(*tracerexectype.Event); ok {
+			if len(e.Args) > 0 {
+				return []string{e.Args[0]}
+			}
+			return []string{e.Comm}
+		}
+	case utils.OpenEventType:
+		if e, ok := event.(*traceropentype.Event); ok {
+			if e.FullPath != "" {
+				return []string{e.Comm, e.FullPath}
+			}
+			return []string{e.Comm, e.Path}
+		}
+	case utils.DnsEventType:
+		if e, ok := event.(*tracerdnstype.Event); ok {
+			return []string{e.Comm, e.DNSName}
+		}
+	case utils.NetworkEventType:
+		if e, ok := event.(*tracernetworktype.Event); ok {
+			return []string{e.Comm, e.DstEndpoint.Addr, fmt.Sprintf("%d", e.Port), e.Proto}
+		}
+	case utils.CapabilitiesEventType:
+		if e, ok := event.(*tracercapabilitiestype.Event); ok {
+			return []string{e.Comm, e.CapName}
+		}
+	case utils.SyscallEventType:
+		if e, ok := event.(*ruleenginetypes.SyscallEvent); ok {
+			return []string{e.SyscallName}
+		}
+	case utils.RandomXEventType:
+		if e, ok := event.(*tracerrandomxtype.Event); ok {
+			return []string{e.Comm}
+		}
+	default:
+		if source, ok := utils.DescribeSourceEvent(eventType, event); ok {
+			return source.Identity
+		}
+	}
+	return nil
+}
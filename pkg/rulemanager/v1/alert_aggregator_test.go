@@ -0,0 +1,149 @@
+package rulemanager
+
+import (
+	"node-agent/pkg/malwaremanager"
+	"node-agent/pkg/metricsmanager"
+	"node-agent/pkg/objectcache"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"node-agent/pkg/utils"
+	"sync"
+	"testing"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exporterRecorder struct {
+	mutex  sync.Mutex
+	alerts []ruleengine.RuleFailure
+}
+
+func (e *exporterRecorder) SendRuleAlert(failedRule ruleengine.RuleFailure) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.alerts = append(e.alerts, failedRule)
+}
+
+func (e *exporterRecorder) SendMalwareAlert(malwareResult malwaremanager.MalwareResult) {
+}
+
+func (e *exporterRecorder) getAlerts() []ruleengine.RuleFailure {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]ruleengine.RuleFailure{}, e.alerts...)
+}
+
+func execEvent(containerID string, path string) *tracerexectype.Event {
+	return &tracerexectype.Event{
+		Event: eventtypes.Event{
+			CommonData: eventtypes.CommonData{
+				Runtime: eventtypes.BasicRuntimeMetadata{ContainerID: containerID},
+			},
+		},
+		Comm: "test",
+		Args: []string{path},
+	}
+}
+
+func execFailure(event *tracerexectype.Event) ruleengine.RuleFailure {
+	return &ruleenginev1.GenericRuleFailure{
+		BaseRuntimeAlert: apitypes.BaseRuntimeAlert{AlertName: "Unexpected process launched"},
+		TriggerEvent:     event.Event,
+		RuleAlert:        apitypes.RuleAlert{RuleID: "R0001", RuleDescription: "Unexpected process launched: " + event.Args[0]},
+	}
+}
+
+func TestAggregationKey(t *testing.T) {
+	e1 := execEvent("container1", "/bin/ls")
+	e2 := execEvent("container1", "/bin/cat")
+	e3 := execEvent("container2", "/bin/ls")
+
+	key := aggregationKey("R0001", execFailure(e1), utils.ExecveEventType, e1)
+	assert.Equal(t, key, aggregationKey("R0001", execFailure(e1), utils.ExecveEventType, execEvent("container1", "/bin/ls")))
+	assert.NotEqual(t, key, aggregationKey("R0002", execFailure(e1), utils.ExecveEventType, e1))
+	assert.NotEqual(t, key, aggregationKey("R0001", execFailure(e2), utils.ExecveEventType, e2))
+	assert.NotEqual(t, key, aggregationKey("R0001", execFailure(e3), utils.ExecveEventType, e3))
+}
+
+func TestAlertAggregator(t *testing.T) {
+	exporter := &exporterRecorder{}
+	aggregator := newAlertAggregator(100*time.Millisecond, exporter)
+
+	e := execEvent("container1", "/bin/ls")
+	key := aggregationKey("R0001", execFailure(e), utils.ExecveEventType, e)
+
+	// the first alert of the window is sent by the caller
+	require.False(t, aggregator.aggregate(key))
+	aggregator.setAlert(key, execFailure(e))
+	for i := 0; i < 4; i++ {
+		assert.True(t, aggregator.aggregate(key))
+	}
+
+	// a different key is not aggregated
+	other := execEvent("container1", "/bin/cat")
+	otherKey := aggregationKey("R0001", execFailure(other), utils.ExecveEventType, other)
+	require.False(t, aggregator.aggregate(otherKey))
+	aggregator.setAlert(otherKey, execFailure(other))
+
+	require.Eventually(t, func() bool { return len(exporter.getAlerts()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	summary := exporter.getAlerts()[0]
+	assert.Equal(t, "R0001", summary.GetRuleAlert().RuleID)
+	assert.Contains(t, summary.GetRuleAlert().RuleDescription, "Unexpected process launched: /bin/ls (5 occurrences between")
+	assert.Equal(t, 5, summary.GetBaseRuntimeAlert().Arguments["occurrences"])
+	assert.NotEmpty(t, summary.GetBaseRuntimeAlert().Arguments["firstSeen"])
+	assert.NotEmpty(t, summary.GetBaseRuntimeAlert().Arguments["lastSeen"])
+
+	// no summary for the key without repeats, and the window is reopened after it closes
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, len(exporter.getAlerts()))
+	assert.False(t, aggregator.aggregate(key))
+}
+
+// alertingRule raises an exec alert for every exec event
+type alertingRule struct {
+	ruleengine.RuleMock
+}
+
+func (rule *alertingRule) ProcessEvent(_ utils.EventType, event interface{}, _ objectcache.ObjectCache) ruleengine.RuleFailure {
+	return execFailure(event.(*tracerexectype.Event))
+}
+
+// responseRecorder counts the responses to the rule alerts
+type responseRecorder struct {
+	ruleAlerts int
+}
+
+func (r *responseRecorder) RespondToRuleAlert(_ ruleengine.RuleFailure, _ uint32) {
+	r.ruleAlerts++
+}
+
+func (r *responseRecorder) RespondToMalwareAlert(_ malwaremanager.MalwareResult, _ uint32) {
+}
+
+func TestProcessEventRespondsToAggregatedAlerts(t *testing.T) {
+	exporter := &exporterRecorder{}
+	responses := &responseRecorder{}
+	rm := &RuleManager{
+		exporter:               exporter,
+		metrics:                metricsmanager.NewMetricsMock(),
+		alertAggregator:        newAlertAggregator(time.Hour, exporter),
+		responseManager:        responses,
+		hostEnrichmentDisabled: true,
+	}
+	rule := &alertingRule{RuleMock: ruleengine.RuleMock{
+		RuleID:           "R0001",
+		RuleRequirements: &ruleenginev1.RuleRequirements{EventTypes: []utils.EventType{utils.ExecveEventType}},
+	}}
+
+	for i := 0; i < 3; i++ {
+		rm.processEvent(utils.ExecveEventType, execEvent("container1", "/bin/ls"), []ruleengine.RuleEvaluator{rule})
+	}
+	// the repeats are aggregated but every alert is responded to
+	assert.Len(t, exporter.getAlerts(), 1)
+	assert.Equal(t, 3, responses.ruleAlerts)
+}
@@ -0,0 +1,144 @@
+package rulemanager
+
+import (
+	"net"
+	"node-agent/pkg/ruleengine"
+	"node-agent/pkg/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	tracerdnstype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	tracerexectype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	tracernetworktype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+)
+
+// alertAttributes are the attributes of an alert that the exceptions are matched against
+type alertAttributes struct {
+	processPaths  []string
+	comm          string
+	filePath      string
+	domain        string
+	addresses     []net.IP
+	containerName string
+}
+
+func getAlertAttributes(ruleFailure ruleengine.RuleFailure, eventType utils.EventType, event interface{}) alertAttributes {
+	process := ruleFailure.GetRuntimeProcessDetails().ProcessTree
+	triggerEvent := ruleFailure.GetTriggerEvent()
+	attributes := alertAttributes{
+		comm:          process.Comm,
+		containerName: ruleFailure.GetRuntimeAlertK8sDetails().ContainerName,
+	}
+	if process.Path != "" {
+		attributes.processPaths = append(attributes.processPaths, process.Path)
+	}
+	if attributes.containerName == "" {
+		attributes.containerName = triggerEvent.K8s.ContainerName
+	}
+
+	switch eventType {
+	case utils.ExecveEventType:
+		if e, ok := event.(*tracerexectype.Event); ok {
+			if len(e.Args) > 0 {
+				attributes.processPaths = append(attributes.processPaths, e.Args[0])
+			}
+			if e.ExePath != "" {
+				attributes.processPaths = append(attributes.processPaths, e.ExePath)
+			}
+		}
+	case utils.OpenEventType:
+		if e, ok := event.(*traceropentype.Event); ok {
+			attributes.filePath = e.FullPath
+			if attributes.filePath == "" {
+				attributes.filePath = e.Path
+			}
+		}
+	case utils.DnsEventType:
+		if e, ok := event.(*tracerdnstype.Event); ok {
+			attributes.domain = e.DNSName
+			for _, address := range e.Addresses {
+				if ip := net.ParseIP(address); ip != nil {
+					attributes.addresses = append(attributes.addresses, ip)
+				}
+			}
+		}
+	case utils.NetworkEventType:
+		if e, ok := event.(*tracernetworktype.Event); ok {
+			attributes.domain = e.DstEndpoint.Name
+			if ip := net.ParseIP(e.DstEndpoint.Addr); ip != nil {
+				attributes.addresses = append(attributes.addresses, ip)
+			}
+		}
+	default:
+		if source, ok := utils.DescribeSourceEvent(eventType, event); ok {
+			attributes.filePath = source.Path
+		}
+	}
+	attributes.domain = strings.TrimSuffix(attributes.domain, ".")
+
+	// fall back to the path of the running process, only when needed since it reads /proc
+	if len(attributes.processPaths) == 0 && process.PID != 0 {
+		if path, err := utils.GetPathFromPid(process.PID); err == nil {
+			attributes.processPaths = append(attributes.processPaths, path)
+		}
+	}
+	return attributes
+}
+
+// isAlertExcepted returns true if the alert matches any of the exceptions that did not expire
+func isAlertExcepted(exceptions []ruleengine.RuleException, ruleFailure ruleengine.RuleFailure, eventType utils.EventType, event interface{}) bool {
+	if len(exceptions) == 0 {
+		return false
+	}
+	now := time.Now()
+	var attributes *alertAttributes
+	for i := range exceptions {
+		if exceptions[i].Expired(now) {
+			continue
+		}
+		if attributes == nil {
+			a := getAlertAttributes(ruleFailure, eventType, event)
+			attributes = &a
+		}
+		if exceptionMatches(&exceptions[i], attributes) {
+			return true
+		}
+	}
+	return false
+}
+
+func exceptionMatches(exception *ruleengine.RuleException, attributes *alertAttributes) bool {
+	if len(exception.ProcessPaths) > 0 && !slices.ContainsFunc(attributes.processPaths, func(path string) bool { return matchesGlobs(exception.ProcessPaths, path) }) {
+		return false
+	}
+	if len(exception.Comms) > 0 && !slices.Contains(exception.Comms, attributes.comm) {
+		return false
+	}
+	if len(exception.FilePaths) > 0 && (attributes.filePath == "" || !matchesGlobs(exception.FilePaths, attributes.filePath)) {
+		return false
+	}
+	if len(exception.Domains) > 0 && (attributes.domain == "" || !matchesGlobs(exception.Domains, attributes.domain)) {
+		return false
+	}
+	if len(exception.CIDRs) > 0 && !slices.ContainsFunc(attributes.addresses, func(ip net.IP) bool {
+		return slices.ContainsFunc(exception.CIDRs, func(cidr *net.IPNet) bool { return cidr.Contains(ip) })
+	}) {
+		return false
+	}
+	if len(exception.ContainerNames) > 0 && !slices.Contains(exception.ContainerNames, attributes.containerName) {
+		return false
+	}
+	return true
+}
+
+func matchesGlobs(globs []string, value string) bool {
+	for _, glob := range globs {
+		if matched, _ := doublestar.Match(glob, value); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package rulemanager
+
+import (
+	"net"
+	"node-agent/pkg/ruleengine"
+	ruleenginev1 "node-agent/pkg/ruleengine/v1"
+	"node-agent/pkg/utils"
+	"testing"
+	"time"
+
+	apitypes "github.com/armosec/armoapi-go/armotypes"
+	tracerdnstype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	tracernetworktype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	traceropentype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/open/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func exceptionsFailure(comm string, path string) ruleengine.RuleFailure {
+	return &ruleenginev1.GenericRuleFailure{
+		RuntimeProcessDetails: apitypes.ProcessTree{
+			ProcessTree: apitypes.Process{Comm: comm, Path: path},
+		},
+		RuntimeAlertK8sDetails: apitypes.RuntimeAlertK8sDetails{ContainerName: "nginx"},
+	}
+}
+
+func TestIsAlertExcepted(t *testing.T) {
+	_, cidr, _ := net.ParseCIDR("10.0.0.0/8")
+	tests := []struct {
+		name       string
+		exceptions []ruleengine.RuleException
+		eventType  utils.EventType
+		event      interface{}
+		want       bool
+	}{
+		{
+			name:      "no exceptions",
+			eventType: utils.OpenEventType,
+			event:     &traceropentype.Event{FullPath: "/etc/passwd"},
+			want:      false,
+		},
+		{
+			name:       "file path glob",
+			exceptions: []ruleengine.RuleException{{FilePaths: []string{"/var/log/**"}}},
+			eventType:  utils.OpenEventType,
+			event:      &traceropentype.Event{FullPath: "/var/log/nginx/access.log"},
+			want:       true,
+		},
+		{
+			name:       "file path glob mismatch",
+			exceptions: []ruleengine.RuleException{{FilePaths: []string{"/var/log/**"}}},
+			eventType:  utils.OpenEventType,
+			event:      &traceropentype.Event{FullPath: "/etc/passwd"},
+			want:       false,
+		},
+		{
+			name:       "all fields must match",
+			exceptions: []ruleengine.RuleException{{FilePaths: []string{"/var/log/**"}, Comms: []string{"logrotate"}}},
+			eventType:  utils.OpenEventType,
+			event:      &traceropentype.Event{FullPath: "/var/log/nginx/access.log"},
+			want:       false,
+		},
+		{
+			name:       "any exception matches",
+			exceptions: []ruleengine.RuleException{{Comms: []string{"logrotate"}}, {Comms: []string{"nginx"}, ContainerNames: []string{"nginx"}}},
+			eventType:  utils.OpenEventType,
+			event:      &traceropentype.Event{FullPath: "/etc/passwd"},
+			want:       true,
+		},
+		{
+			name:       "process path",
+			exceptions: []ruleengine.RuleException{{ProcessPaths: []string{"/usr/sbin/*"}}},
+			eventType:  utils.OpenEventType,
+			event:      &traceropentype.Event{FullPath: "/etc/passwd"},
+			want:       true,
+		},
+		{
+			name:       "domain glob",
+			exceptions: []ruleengine.RuleException{{Domains: []string{"*.example.com"}}},
+			eventType:  utils.DnsEventType,
+			event:      &tracerdnstype.Event{DNSName: "api.example.com."},
+			want:       true,
+		},
+		{
+			name:       "domain does not apply to open events",
+			exceptions: []ruleengine.RuleException{{Domains: []string{"*"}}},
+			eventType:  utils.OpenEventType,
+			event:      &traceropentype.Event{FullPath: "/etc/passwd"},
+			want:       false,
+		},
+		{
+			name:       "cidr",
+			exceptions: []ruleengine.RuleException{{CIDRs: []*net.IPNet{cidr}}},
+			eventType:  utils.NetworkEventType,
+			event:      &tracernetworktype.Event{DstEndpoint: eventtypes.L3Endpoint{Addr: "10.1.2.3"}},
+			want:       true,
+		},
+		{
+			name:       "cidr mismatch",
+			exceptions: []ruleengine.RuleException{{CIDRs: []*net.IPNet{cidr}}},
+			eventType:  utils.NetworkEventType,
+			event:      &tracernetworktype.Event{DstEndpoint: eventtypes.L3Endpoint{Addr: "192.168.1.1"}},
+			want:       false,
+		},
+		{
+			name:       "expired",
+			exceptions: []ruleengine.RuleException{{ContainerNames: []string{"nginx"}, ExpiresAt: time.Now().Add(-time.Minute)}},
+			eventType:  utils.OpenEventType,
+			event:      &traceropentype.Event{FullPath: "/etc/passwd"},
+			want:       false,
+		},
+		{
+			name:       "not expired yet",
+			exceptions: []ruleengine.RuleException{{ContainerNames: []string{"nginx"}, ExpiresAt: time.Now().Add(time.Minute)}},
+			eventType:  utils.OpenEventType,
+			event:      &traceropentype.Event{FullPath: "/etc/passwd"},
+			want:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isAlertExcepted(tt.exceptions, exceptionsFailure("nginx", "/usr/sbin/nginx"), tt.eventType, tt.event))
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"node-agent/pkg/config"
 	"node-agent/pkg/exporters"
 	"node-agent/pkg/k8sclient"
+	"node-agent/pkg/responsemanager"
 	"node-agent/pkg/ruleengine"
 	"node-agent/pkg/rulemanager"
 	"node-agent/pkg/utils"
@@ -65,11 +66,20 @@ type RuleManager struct {
 	nodeName                 string
 	clusterName              string
 	containerIdToShimPid     maps.SafeMap[string, uint32]
+	containerIdToPid         maps.SafeMap[string, uint32]
+	alertAggregator          *alertAggregator // nil when aggregation is disabled
+	responseManager          responsemanager.ResponseManagerClient
+	// hostEnrichmentDisabled stops the alerts from being enriched from the processes and files of the host
+	hostEnrichmentDisabled bool
 }
 
 var _ rulemanager.RuleManagerClient = (*RuleManager)(nil)
 
-func CreateRuleManager(ctx context.Context, cfg config.Config, k8sClient k8sclient.K8sClientInterface, ruleBindingCache bindingcache.RuleBindingCache, objectCache objectcache.ObjectCache, exporter exporters.Exporter, metrics metricsmanager.MetricsManager, preRunningContainersIDs mapset.Set[string], nodeName string, clusterName string) (*RuleManager, error) {
+func CreateRuleManager(ctx context.Context, cfg config.Config, k8sClient k8sclient.K8sClientInterface, ruleBindingCache bindingcache.RuleBindingCache, objectCache objectcache.ObjectCache, exporter exporters.Exporter, metrics metricsmanager.MetricsManager, preRunningContainersIDs mapset.Set[string], nodeName string, clusterName string, responseManager responsemanager.ResponseManagerClient) (*RuleManager, error) {
+	var aggregator *alertAggregator
+	if cfg.AlertAggregationWindow > 0 {
+		aggregator = newAlertAggregator(cfg.AlertAggregationWindow, exporter)
+	}
 	return &RuleManager{
 		cfg:                    cfg,
 		ctx:                    ctx,
@@ -85,6 +95,8 @@ func CreateRuleManager(ctx context.Context, cfg config.Config, k8sClient k8sclie
 		cachedPods:             mapset.NewSet[string](),
 		nodeName:               nodeName,
 		clusterName:            clusterName,
+		alertAggregator:        aggregator,
+		responseManager:        responseManager,
 	}, nil
 }
 
@@ -269,6 +281,7 @@ func (rm *RuleManager) ContainerCallback(notif containercollection.PubSubEvent)
 			}
 		}
 		rm.trackedContainers.Add(k8sContainerID)
+		rm.containerIdToPid.Set(notif.Container.Runtime.ContainerID, notif.Container.Pid)
 		shim, err := utils.GetProcessStat(int(notif.Container.Pid))
 		if err != nil {
 			logger.L().Warning("RuleManager - failed to get shim process", helpers.Error(err))
@@ -284,6 +297,7 @@ func (rm *RuleManager) ContainerCallback(notif containercollection.PubSubEvent)
 		rm.watchedContainerChannels.Delete(notif.Container.Runtime.ContainerID)
 		rm.podToWlid.Delete(notif.Container.K8s.PodName)
 		rm.containerIdToShimPid.Delete(notif.Container.Runtime.ContainerID)
+		rm.containerIdToPid.Delete(notif.Container.Runtime.ContainerID)
 	}
 }
 
@@ -393,6 +407,40 @@ func (rm *RuleManager) ReportRandomxEvent(k8sContainerID string, event tracerran
 	rm.processEvent(utils.RandomXEventType, &event, rules)
 }
 
+func (rm *RuleManager) ReportGadgetEvent(eventType utils.EventType, k8sContainerID string, event interface{}) {
+	source, ok := utils.DescribeSourceEvent(eventType, event)
+	if !ok {
+		logger.L().Error("RuleManager - unknown gadget event", helpers.String("eventType", eventType.String()))
+		return
+	}
+	if source.Event.GetNamespace() == "" || source.Event.GetPod() == "" {
+		logger.L().Error("RuleManager - failed to get namespace and pod name from gadget event", helpers.String("eventType", eventType.String()))
+		return
+	}
+
+	// list the rules of the pod
+	rules := rm.ruleBindingCache.ListRulesForPod(source.Event.GetNamespace(), source.Event.GetPod())
+
+	rm.processEvent(eventType, event, rules)
+}
+
+func (rm *RuleManager) ReportSyscallEvent(k8sContainerID string, event ruleenginetypes.SyscallEvent) {
+	if event.GetNamespace() == "" || event.GetPod() == "" {
+		logger.L().Error("RuleManager - failed to get namespace and pod name from syscall event")
+		return
+	}
+
+	// list syscall rules
+	rules := rm.ruleBindingCache.ListRulesForPod(event.GetNamespace(), event.GetPod())
+
+	rm.processEvent(utils.SyscallEventType, &event, rules)
+}
+
+// DisableHostEnrichment keeps the alerts to the details of their events, for the replayed events that do not belong to the processes of the host
+func (rm *RuleManager) DisableHostEnrichment() {
+	rm.hostEnrichmentDisabled = true
+}
+
 func (rm *RuleManager) processEvent(eventType utils.EventType, event interface{}, rules []ruleengine.RuleEvaluator) {
 	for _, rule := range rules {
 		if rule == nil {
@@ -405,16 +453,45 @@ func (rm *RuleManager) processEvent(eventType utils.EventType, event interface{}
 
 		res := rule.ProcessEvent(eventType, event, rm.objectCache)
 		if res != nil {
+			if isAlertExcepted(rule.GetExceptions(), res, eventType, event) {
+				logger.L().Debug("RuleManager - alert matches a rule binding exception", helpers.String("rule", rule.ID()))
+				rm.metrics.ReportRuleProcessed(rule.Name())
+				continue
+			}
+			rm.metrics.ReportRuleAlert(rule.Name())
+			if severity, ok := rule.GetSeverity(); ok {
+				baseRuntimeAlert := res.GetBaseRuntimeAlert()
+				baseRuntimeAlert.Severity = severity
+				res.SetBaseRuntimeAlert(baseRuntimeAlert)
+			}
+			res.SetRuleTags(rule.GetTags())
 			res.SetWorkloadDetails(rm.podToWlid.Get(res.GetRuntimeAlertK8sDetails().PodName))
+			// the response is taken for every alert, aggregated or not, and before the enrichment replaces the
+			// offending process with the process tree
+			rm.responseManager.RespondToRuleAlert(res, rm.containerIdToPid.Get(res.GetTriggerEvent().Runtime.ContainerID))
+			// repeated alerts are counted by the aggregator and sent as a summary when the window closes
+			var key string
+			if rm.alertAggregator != nil {
+				key = aggregationKey(rule.ID(), res, eventType, event)
+				if rm.alertAggregator.aggregate(key) {
+					rm.metrics.ReportRuleProcessed(rule.Name())
+					continue
+				}
+			}
 			res = rm.enrichRuleFailure(res)
+			if rm.alertAggregator != nil {
+				rm.alertAggregator.setAlert(key, res)
+			}
 			rm.exporter.SendRuleAlert(res)
-			rm.metrics.ReportRuleAlert(rule.Name())
 		}
 		rm.metrics.ReportRuleProcessed(rule.Name())
 	}
 }
 
 func (rm *RuleManager) enrichRuleFailure(ruleFailure ruleengine.RuleFailure) ruleengine.RuleFailure {
+	if rm.hostEnrichmentDisabled {
+		return enrichRuleFailureFromEvent(ruleFailure)
+	}
 	path, err := utils.GetPathFromPid(ruleFailure.GetRuntimeProcessDetails().ProcessTree.PID)
 	hostPath := ""
 	if err != nil {
@@ -426,8 +503,6 @@ func (rm *RuleManager) enrichRuleFailure(ruleFailure ruleengine.RuleFailure) rul
 	// Enrich BaseRuntimeAlert
 	baseRuntimeAlert := ruleFailure.GetBaseRuntimeAlert()
 
-	baseRuntimeAlert.Timestamp = time.Unix(int64(ruleFailure.GetTriggerEvent().Timestamp)/1e9, 0)
-
 	if baseRuntimeAlert.MD5Hash == "" && hostPath != "" {
 		md5hash, err := utils.CalculateMD5FileHash(hostPath)
 		if err != nil {
@@ -518,6 +593,15 @@ func (rm *RuleManager) enrichRuleFailure(ruleFailure ruleengine.RuleFailure) rul
 
 	ruleFailure.SetRuntimeProcessDetails(runtimeProcessDetails)
 
+	return enrichRuleFailureFromEvent(ruleFailure)
+}
+
+// enrichRuleFailureFromEvent sets the timestamp and the k8s details of the alert from its trigger event
+func enrichRuleFailureFromEvent(ruleFailure ruleengine.RuleFailure) ruleengine.RuleFailure {
+	baseRuntimeAlert := ruleFailure.GetBaseRuntimeAlert()
+	baseRuntimeAlert.Timestamp = time.Unix(int64(ruleFailure.GetTriggerEvent().Timestamp)/1e9, 0)
+	ruleFailure.SetBaseRuntimeAlert(baseRuntimeAlert)
+
 	// Enrich RuntimeAlertK8sDetails
 	runtimek8sdetails := ruleFailure.GetRuntimeAlertK8sDetails()
 	if runtimek8sdetails.Image == "" {
@@ -6,6 +6,7 @@ import (
 	"node-agent/pkg/exporters"
 	"node-agent/pkg/k8sclient"
 	"node-agent/pkg/objectcache"
+	"node-agent/pkg/responsemanager"
 	"node-agent/pkg/storage"
 
 	bindingcache "node-agent/pkg/rulebindingmanager/cache"
@@ -29,5 +30,6 @@ func CreateRuleManagerMock(clusterName string, storageClient storage.StorageClie
 		objectCache:       &objectcache.ObjectCacheMock{},
 		exporter:          &exporters.ExporterMock{},
 		metrics:           metricsmanager.NewMetricsMock(),
+		responseManager:   responsemanager.CreateResponseManagerMock(),
 	}
 }
@@ -0,0 +1,244 @@
+package seccompprofile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"node-agent/pkg/utils"
+
+	helpersv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1/helpers"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// runtimeSyscalls are called by the container runtime after it loads the seccomp filter, they are not seen by the tracer
+// when the container started before the node agent, and the process cannot start without them
+var runtimeSyscalls = []string{"execve", "exit", "exit_group", "rt_sigreturn"}
+
+// architectures are the seccomp architectures of the Go architectures, with the compatible ones of the 64-bit architectures
+var architectures = map[string][]specs.Arch{
+	"amd64":   {specs.ArchX86_64, specs.ArchX86, specs.ArchX32},
+	"arm64":   {specs.ArchAARCH64, specs.ArchARM},
+	"386":     {specs.ArchX86},
+	"arm":     {specs.ArchARM},
+	"ppc64le": {specs.ArchPPC64LE},
+	"s390x":   {specs.ArchS390X, specs.ArchS390},
+}
+
+// genericArchitectures only have the generic syscalls of the kernel, like openat, and none of the legacy ones, like open
+var genericArchitectures = map[string]bool{"arm64": true}
+
+// legacySyscalls are the syscalls missing from the generic architectures, with the generic syscall the C libraries and
+// the Go runtime call instead
+var legacySyscalls = map[string]string{
+	"access":       "faccessat",
+	"alarm":        "setitimer",
+	"chmod":        "fchmodat",
+	"chown":        "fchownat",
+	"creat":        "openat",
+	"dup2":         "dup3",
+	"epoll_create": "epoll_create1",
+	"epoll_wait":   "epoll_pwait",
+	"eventfd":      "eventfd2",
+	"fork":         "clone",
+	"futimesat":    "utimensat",
+	"getdents":     "getdents64",
+	"getpgrp":      "getpgid",
+	"inotify_init": "inotify_init1",
+	"lchown":       "fchownat",
+	"link":         "linkat",
+	"lstat":        "newfstatat",
+	"mkdir":        "mkdirat",
+	"mknod":        "mknodat",
+	"open":         "openat",
+	"pause":        "ppoll",
+	"pipe":         "pipe2",
+	"poll":         "ppoll",
+	"readlink":     "readlinkat",
+	"rename":       "renameat",
+	"rmdir":        "unlinkat",
+	"select":       "pselect6",
+	"signalfd":     "signalfd4",
+	"stat":         "newfstatat",
+	"symlink":      "symlinkat",
+	"time":         "clock_gettime",
+	"unlink":       "unlinkat",
+	"utime":        "utimensat",
+	"utimes":       "utimensat",
+	"vfork":        "clone",
+}
+
+// DefaultArchitecture is the architecture the syscalls of the application profiles that do not record it were
+// learned on
+const DefaultArchitecture = "amd64"
+
+// Options of the generation
+type Options struct {
+	// Architectures are the Go architectures of the nodes the profiles are used on, a profile is generated per
+	// architecture. The architectures the syscalls were learned on when empty.
+	Architectures []string
+	// AllowIncomplete generates the profiles of an application profile that is still learning
+	AllowIncomplete bool
+}
+
+// ContainerProfile is the seccomp profile of a container for an architecture, in the OCI format used by the Localhost
+// seccomp profiles of kubernetes
+type ContainerProfile struct {
+	Name string
+	// Arch is the Go architecture of the nodes the profile is used on
+	Arch string
+	// ProfileName is <application profile>-<container>, suffixed with -<arch> when several architectures are generated
+	ProfileName string
+	Seccomp     specs.LinuxSeccomp
+}
+
+// Profiles are generated from an application profile
+type Profiles struct {
+	// Containers are sorted by name then architecture, the containers without syscalls are skipped
+	Containers []ContainerProfile
+	Warnings   []string
+}
+
+// Generate converts the syscalls learned for the containers of an application profile into seccomp profiles
+// denying all the other syscalls with EPERM, a profile per container and architecture. The syscalls learned on another
+// architecture are translated between the legacy and the generic syscalls.
+func Generate(ap *v1beta1.ApplicationProfile, opts Options) (*Profiles, error) {
+	if status := ap.Annotations[helpersv1.StatusMetadataKey]; status != helpersv1.Completed && !opts.AllowIncomplete {
+		return nil, fmt.Errorf("application profile %s/%s is not completed, status %q", ap.Namespace, ap.Name, status)
+	}
+
+	profiles := &Profiles{}
+	if ap.Annotations[helpersv1.CompletionMetadataKey] == helpersv1.Partial {
+		profiles.Warnings = append(profiles.Warnings, "the application profile is partial, the syscalls of the containers before the node agent started are missing")
+	}
+	learned := utils.GetLearnedArchitectures(ap.Annotations)
+	if len(learned) == 0 {
+		profiles.Warnings = append(profiles.Warnings, fmt.Sprintf("the application profile does not record the architecture its syscalls were learned on, %s is assumed", DefaultArchitecture))
+		learned = []string{DefaultArchitecture}
+	}
+	goArchs := opts.Architectures
+	if len(goArchs) == 0 {
+		goArchs = learned
+	}
+	for _, goArch := range goArchs {
+		if _, ok := architectures[goArch]; !ok {
+			return nil, fmt.Errorf("unsupported architecture %q", goArch)
+		}
+		if !contains(learned, goArch) {
+			profiles.Warnings = append(profiles.Warnings, fmt.Sprintf("the syscalls were learned on %s, the %s profiles translate them and deny the syscalls the containers only call on %s", strings.Join(learned, ","), goArch, goArch))
+		}
+	}
+
+	for _, containers := range [][]v1beta1.ApplicationProfileContainer{ap.Spec.Containers, ap.Spec.InitContainers, ap.Spec.EphemeralContainers} {
+		for _, container := range containers {
+			if len(container.Syscalls) == 0 {
+				profiles.Warnings = append(profiles.Warnings, fmt.Sprintf("container %s has no syscalls, its profile would deny all of them", container.Name))
+				continue
+			}
+			for _, goArch := range goArchs {
+				syscalls := container.Syscalls
+				if !contains(learned, goArch) {
+					syscalls = translateSyscalls(syscalls, goArch)
+				}
+				profileName := fmt.Sprintf("%s-%s", ap.Name, container.Name)
+				if len(goArchs) > 1 {
+					profileName += "-" + goArch
+				}
+				profiles.Containers = append(profiles.Containers, ContainerProfile{
+					Name:        container.Name,
+					Arch:        goArch,
+					ProfileName: profileName,
+					Seccomp: specs.LinuxSeccomp{
+						DefaultAction: specs.ActErrno,
+						Architectures: architectures[goArch],
+						Syscalls: []specs.LinuxSyscall{{
+							Names:  allowedSyscalls(syscalls),
+							Action: specs.ActAllow,
+						}},
+					},
+				})
+			}
+		}
+	}
+	sort.SliceStable(profiles.Containers, func(i, j int) bool {
+		return profiles.Containers[i].Name < profiles.Containers[j].Name
+	})
+	return profiles, nil
+}
+
+// translateSyscalls returns the syscalls learned on other architectures for a generic architecture, with the generic
+// syscalls instead of the legacy ones, or for a legacy architecture, with the legacy syscalls of the generic ones
+func translateSyscalls(learned []string, goArch string) []string {
+	var syscalls []string
+	for _, syscall := range learned {
+		if genericArchitectures[goArch] {
+			if generic, ok := legacySyscalls[syscall]; ok {
+				syscall = generic
+			}
+			syscalls = append(syscalls, syscall)
+			continue
+		}
+		syscalls = append(syscalls, syscall)
+		for legacy, generic := range legacySyscalls {
+			if generic == syscall {
+				syscalls = append(syscalls, legacy)
+			}
+		}
+	}
+	return syscalls
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedSyscalls returns the sorted syscalls and the syscalls of the runtime
+func allowedSyscalls(learned []string) []string {
+	unique := make(map[string]bool, len(learned)+len(runtimeSyscalls))
+	for _, syscall := range append(append([]string{}, learned...), runtimeSyscalls...) {
+		if syscall != "" {
+			unique[syscall] = true
+		}
+	}
+	syscalls := make([]string, 0, len(unique))
+	for syscall := range unique {
+		syscalls = append(syscalls, syscall)
+	}
+	sort.Strings(syscalls)
+	return syscalls
+}
+
+// SeccompProfile is the security-profiles-operator.x-k8s.io/v1beta1 SeccompProfile of a container
+type SeccompProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec specs.LinuxSeccomp `json:"spec"`
+}
+
+// SeccompProfileCR returns the profile of a container as a custom resource of the security-profiles-operator, named
+// after its profile name. The pods use it with the localhost profile operator/<namespace>/<name>.json.
+func SeccompProfileCR(ap *v1beta1.ApplicationProfile, container ContainerProfile) *SeccompProfile {
+	cr := &SeccompProfile{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "SeccompProfile",
+			APIVersion: "security-profiles-operator.x-k8s.io/v1beta1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      container.ProfileName,
+			Namespace: ap.Namespace,
+		},
+		Spec: container.Seccomp,
+	}
+	if wlid, ok := ap.Annotations[helpersv1.WlidMetadataKey]; ok {
+		cr.Annotations = map[string]string{helpersv1.WlidMetadataKey: wlid}
+	}
+	return cr
+}
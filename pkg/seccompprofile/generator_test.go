@@ -0,0 +1,130 @@
+package seccompprofile
+
+import (
+	"testing"
+
+	helpersv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1/helpers"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func applicationProfile(status, completion string) *v1beta1.ApplicationProfile {
+	return &v1beta1.ApplicationProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "deployment-nginx",
+			Namespace: "default",
+			Annotations: map[string]string{
+				helpersv1.StatusMetadataKey:               status,
+				helpersv1.CompletionMetadataKey:           completion,
+				"kubescape.io/learned-architecture.amd64": "amd64",
+			},
+		},
+		Spec: v1beta1.ApplicationProfileSpec{
+			Containers: []v1beta1.ApplicationProfileContainer{
+				{Name: "sidecar"},
+				{Name: "nginx", Syscalls: []string{"write", "read", "accept4", "read"}},
+			},
+			InitContainers: []v1beta1.ApplicationProfileContainer{
+				{Name: "init", Syscalls: []string{"openat"}},
+			},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	profiles, err := Generate(applicationProfile(helpersv1.Completed, helpersv1.Complete), Options{Architectures: []string{"amd64"}})
+	require.NoError(t, err)
+	assert.Equal(t, []ContainerProfile{
+		{
+			Name:        "init",
+			Arch:        "amd64",
+			ProfileName: "deployment-nginx-init",
+			Seccomp: specs.LinuxSeccomp{
+				DefaultAction: specs.ActErrno,
+				Architectures: []specs.Arch{specs.ArchX86_64, specs.ArchX86, specs.ArchX32},
+				Syscalls:      []specs.LinuxSyscall{{Names: []string{"execve", "exit", "exit_group", "openat", "rt_sigreturn"}, Action: specs.ActAllow}},
+			},
+		},
+		{
+			Name:        "nginx",
+			Arch:        "amd64",
+			ProfileName: "deployment-nginx-nginx",
+			Seccomp: specs.LinuxSeccomp{
+				DefaultAction: specs.ActErrno,
+				Architectures: []specs.Arch{specs.ArchX86_64, specs.ArchX86, specs.ArchX32},
+				Syscalls:      []specs.LinuxSyscall{{Names: []string{"accept4", "execve", "exit", "exit_group", "read", "rt_sigreturn", "write"}, Action: specs.ActAllow}},
+			},
+		},
+	}, profiles.Containers)
+	// the sidecar without syscalls is skipped
+	assert.Len(t, profiles.Warnings, 1)
+}
+
+func TestGenerateArchitectures(t *testing.T) {
+	ap := applicationProfile(helpersv1.Completed, helpersv1.Complete)
+	ap.Spec.Containers[1].Syscalls = []string{"open", "stat", "read"}
+
+	// only the architecture the syscalls were learned on by default
+	profiles, err := Generate(ap, Options{})
+	require.NoError(t, err)
+	require.Len(t, profiles.Containers, 2)
+	assert.Equal(t, "amd64", profiles.Containers[1].Arch)
+	assert.Equal(t, []specs.Arch{specs.ArchX86_64, specs.ArchX86, specs.ArchX32}, profiles.Containers[1].Seccomp.Architectures)
+	assert.Len(t, profiles.Warnings, 1)
+
+	// a profile per architecture, the legacy syscalls are translated for arm64
+	profiles, err = Generate(ap, Options{Architectures: []string{"amd64", "arm64"}})
+	require.NoError(t, err)
+	require.Len(t, profiles.Containers, 4)
+	assert.Equal(t, "deployment-nginx-nginx-amd64", profiles.Containers[2].ProfileName)
+	assert.Equal(t, []string{"execve", "exit", "exit_group", "open", "read", "rt_sigreturn", "stat"}, profiles.Containers[2].Seccomp.Syscalls[0].Names)
+	assert.Equal(t, "deployment-nginx-nginx-arm64", profiles.Containers[3].ProfileName)
+	assert.Equal(t, []specs.Arch{specs.ArchAARCH64, specs.ArchARM}, profiles.Containers[3].Seccomp.Architectures)
+	assert.Equal(t, []string{"execve", "exit", "exit_group", "newfstatat", "openat", "read", "rt_sigreturn"}, profiles.Containers[3].Seccomp.Syscalls[0].Names)
+	// the architectures differ
+	assert.Len(t, profiles.Warnings, 2)
+
+	// the legacy syscalls are added to the syscalls learned on arm64 for amd64
+	delete(ap.Annotations, "kubescape.io/learned-architecture.amd64")
+	ap.Annotations["kubescape.io/learned-architecture.arm64"] = "arm64"
+	ap.Spec.Containers[1].Syscalls = []string{"openat", "read"}
+	profiles, err = Generate(ap, Options{Architectures: []string{"amd64"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"creat", "execve", "exit", "exit_group", "open", "openat", "read", "rt_sigreturn"}, profiles.Containers[1].Seccomp.Syscalls[0].Names)
+
+	// the architecture is unknown
+	delete(ap.Annotations, "kubescape.io/learned-architecture.arm64")
+	profiles, err = Generate(ap, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultArchitecture, profiles.Containers[0].Arch)
+	assert.Len(t, profiles.Warnings, 2)
+
+	_, err = Generate(ap, Options{Architectures: []string{"mips"}})
+	assert.Error(t, err)
+}
+
+func TestGenerateIncomplete(t *testing.T) {
+	_, err := Generate(applicationProfile(helpersv1.Ready, helpersv1.Complete), Options{})
+	assert.Error(t, err)
+	_, err = Generate(applicationProfile(helpersv1.Ready, helpersv1.Complete), Options{AllowIncomplete: true})
+	assert.NoError(t, err)
+
+	profiles, err := Generate(applicationProfile(helpersv1.Completed, helpersv1.Partial), Options{})
+	require.NoError(t, err)
+	assert.Len(t, profiles.Warnings, 2)
+}
+
+func TestSeccompProfileCR(t *testing.T) {
+	ap := applicationProfile(helpersv1.Completed, helpersv1.Complete)
+	profiles, err := Generate(ap, Options{})
+	require.NoError(t, err)
+	cr := SeccompProfileCR(ap, profiles.Containers[1])
+	assert.Equal(t, "security-profiles-operator.x-k8s.io/v1beta1", cr.APIVersion)
+	assert.Equal(t, "SeccompProfile", cr.Kind)
+	assert.Equal(t, "deployment-nginx-nginx", cr.Name)
+	assert.Equal(t, "default", cr.Namespace)
+	assert.Equal(t, profiles.Containers[1].Seccomp, cr.Spec)
+}
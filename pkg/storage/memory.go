@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var _ StorageClient = (*MemoryStorageClient)(nil)
+
+// MemoryStorageClient keeps the objects in memory, without a cluster. The application profiles and the network
+// neighborhoods are patched and the application profiles are deflated like the storage does, the SBOMs are never
+// found.
+type MemoryStorageClient struct {
+	mutex                 sync.Mutex
+	applicationActivities map[string]*v1beta1.ApplicationActivity // key is namespace/name
+	applicationProfiles   map[string]*v1beta1.ApplicationProfile  // key is namespace/name
+	networkNeighborses    map[string]*v1beta1.NetworkNeighbors    // key is namespace/name
+	networkNeighborhoods  map[string]*v1beta1.NetworkNeighborhood // key is namespace/name
+	filteredSBOMs         map[string]*v1beta1.SBOMSyftFiltered    // key is name
+	imageCounters         map[string]int                          // key is imageID
+}
+
+func NewMemoryStorageClient() *MemoryStorageClient {
+	return &MemoryStorageClient{
+		applicationActivities: map[string]*v1beta1.ApplicationActivity{},
+		applicationProfiles:   map[string]*v1beta1.ApplicationProfile{},
+		networkNeighborses:    map[string]*v1beta1.NetworkNeighbors{},
+		networkNeighborhoods:  map[string]*v1beta1.NetworkNeighborhood{},
+		filteredSBOMs:         map[string]*v1beta1.SBOMSyftFiltered{},
+		imageCounters:         map[string]int{},
+	}
+}
+
+func memoryKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// ApplicationProfiles returns the application profiles sorted by namespace and name
+func (sc *MemoryStorageClient) ApplicationProfiles() []*v1beta1.ApplicationProfile {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	keys := make([]string, 0, len(sc.applicationProfiles))
+	for key := range sc.applicationProfiles {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	profiles := make([]*v1beta1.ApplicationProfile, 0, len(keys))
+	for _, key := range keys {
+		profiles = append(profiles, sc.applicationProfiles[key].DeepCopy())
+	}
+	return profiles
+}
+
+func (sc *MemoryStorageClient) CreateApplicationActivity(activity *v1beta1.ApplicationActivity, namespace string) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	activity = activity.DeepCopy()
+	activity.Namespace = namespace
+	sc.applicationActivities[memoryKey(namespace, activity.Name)] = activity
+	return nil
+}
+
+func (sc *MemoryStorageClient) GetApplicationActivity(namespace, name string) (*v1beta1.ApplicationActivity, error) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	activity, ok := sc.applicationActivities[memoryKey(namespace, name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(v1beta1.Resource("applicationactivity"), name)
+	}
+	return activity.DeepCopy(), nil
+}
+
+func (sc *MemoryStorageClient) CreateApplicationProfile(profile *v1beta1.ApplicationProfile, namespace string) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	profile = profile.DeepCopy()
+	profile.Namespace = namespace
+	deflateApplicationProfile(profile)
+	sc.applicationProfiles[memoryKey(namespace, profile.Name)] = profile
+	return nil
+}
+
+func (sc *MemoryStorageClient) PatchApplicationProfile(name, namespace string, patch []byte, _ chan error) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	profile, ok := sc.applicationProfiles[memoryKey(namespace, name)]
+	if !ok {
+		return apierrors.NewNotFound(v1beta1.Resource("applicationprofile"), name)
+	}
+	patched := &v1beta1.ApplicationProfile{}
+	if err := applyJSONPatch(profile, patch, patched); err != nil {
+		return err
+	}
+	deflateApplicationProfile(patched)
+	sc.applicationProfiles[memoryKey(namespace, name)] = patched
+	return nil
+}
+
+func (sc *MemoryStorageClient) GetApplicationProfile(namespace, name string) (*v1beta1.ApplicationProfile, error) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	profile, ok := sc.applicationProfiles[memoryKey(namespace, name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(v1beta1.Resource("applicationprofile"), name)
+	}
+	return profile.DeepCopy(), nil
+}
+
+func (sc *MemoryStorageClient) CreateFilteredSBOM(SBOM *v1beta1.SBOMSyftFiltered) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	sc.filteredSBOMs[SBOM.Name] = SBOM.DeepCopy()
+	return nil
+}
+
+func (sc *MemoryStorageClient) GetFilteredSBOM(name string) (*v1beta1.SBOMSyftFiltered, error) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	SBOM, ok := sc.filteredSBOMs[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(v1beta1.Resource("sbomsyftfiltered"), name)
+	}
+	return SBOM.DeepCopy(), nil
+}
+
+func (sc *MemoryStorageClient) GetSBOM(name string) (*v1beta1.SBOMSyft, error) {
+	return nil, apierrors.NewNotFound(v1beta1.Resource("sbomsyft"), name)
+}
+
+func (sc *MemoryStorageClient) IncrementImageUse(imageID string) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	sc.imageCounters[imageID]++
+}
+
+func (sc *MemoryStorageClient) DecrementImageUse(imageID string) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	sc.imageCounters[imageID]--
+}
+
+func (sc *MemoryStorageClient) GetNetworkNeighbors(namespace, name string) (*v1beta1.NetworkNeighbors, error) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	networkNeighbors, ok := sc.networkNeighborses[memoryKey(namespace, name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(v1beta1.Resource("networkneighbors"), name)
+	}
+	return networkNeighbors.DeepCopy(), nil
+}
+
+func (sc *MemoryStorageClient) CreateNetworkNeighbors(networkNeighbors *v1beta1.NetworkNeighbors, namespace string) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	networkNeighbors = networkNeighbors.DeepCopy()
+	networkNeighbors.Namespace = namespace
+	sc.networkNeighborses[memoryKey(namespace, networkNeighbors.Name)] = networkNeighbors
+	return nil
+}
+
+func (sc *MemoryStorageClient) PatchNetworkNeighborsMatchLabels(name, namespace string, networkNeighbors *v1beta1.NetworkNeighbors) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	existing, ok := sc.networkNeighborses[memoryKey(namespace, name)]
+	if !ok {
+		return apierrors.NewNotFound(v1beta1.Resource("networkneighbors"), name)
+	}
+	existing.Spec.LabelSelector = networkNeighbors.Spec.LabelSelector
+	return nil
+}
+
+func (sc *MemoryStorageClient) PatchNetworkNeighborsIngressAndEgress(name, namespace string, networkNeighbors *v1beta1.NetworkNeighbors) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	existing, ok := sc.networkNeighborses[memoryKey(namespace, name)]
+	if !ok {
+		return apierrors.NewNotFound(v1beta1.Resource("networkneighbors"), name)
+	}
+	existing.Spec.Ingress = append(existing.Spec.Ingress, networkNeighbors.Spec.Ingress...)
+	existing.Spec.Egress = append(existing.Spec.Egress, networkNeighbors.Spec.Egress...)
+	return nil
+}
+
+func (sc *MemoryStorageClient) GetNetworkNeighborhood(namespace, name string) (*v1beta1.NetworkNeighborhood, error) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	neighborhood, ok := sc.networkNeighborhoods[memoryKey(namespace, name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(v1beta1.Resource("networkneighborhood"), name)
+	}
+	return neighborhood.DeepCopy(), nil
+}
+
+func (sc *MemoryStorageClient) CreateNetworkNeighborhood(neighborhood *v1beta1.NetworkNeighborhood, namespace string) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	neighborhood = neighborhood.DeepCopy()
+	neighborhood.Namespace = namespace
+	sc.networkNeighborhoods[memoryKey(namespace, neighborhood.Name)] = neighborhood
+	return nil
+}
+
+func (sc *MemoryStorageClient) PatchNetworkNeighborhood(name, namespace string, patch []byte, _ chan error) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	neighborhood, ok := sc.networkNeighborhoods[memoryKey(namespace, name)]
+	if !ok {
+		return apierrors.NewNotFound(v1beta1.Resource("networkneighborhood"), name)
+	}
+	patched := &v1beta1.NetworkNeighborhood{}
+	if err := applyJSONPatch(neighborhood, patch, patched); err != nil {
+		return err
+	}
+	sc.networkNeighborhoods[memoryKey(namespace, name)] = patched
+	return nil
+}
+
+// applyJSONPatch applies a JSON patch to an object and decodes the patched object, the invalid patches are rejected
+// with an invalid error like the storage does
+func applyJSONPatch(object interface{}, patchJSON []byte, patched interface{}) error {
+	original, err := json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("marshal object: %w", err)
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("decode patch: %v", err))
+	}
+	patchedJSON, err := patch.Apply(original)
+	if err != nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("apply patch: %v", err))
+	}
+	if err := json.Unmarshal(patchedJSON, patched); err != nil {
+		return fmt.Errorf("unmarshal patched object: %w", err)
+	}
+	return nil
+}
+
+// deflateApplicationProfile removes the duplicated entries of the containers of a profile, the managers patch the
+// syscalls they observed so far and rely on the storage to keep them once
+func deflateApplicationProfile(profile *v1beta1.ApplicationProfile) {
+	for _, containers := range [][]v1beta1.ApplicationProfileContainer{profile.Spec.Containers, profile.Spec.InitContainers, profile.Spec.EphemeralContainers} {
+		for i := range containers {
+			containers[i].Capabilities = deflateStrings(containers[i].Capabilities)
+			containers[i].Syscalls = deflateStrings(containers[i].Syscalls)
+			containers[i].Execs = deflateCalls(containers[i].Execs, func(exec v1beta1.ExecCalls) string {
+				return strings.Join(append([]string{exec.Path}, exec.Args...), "\x00")
+			})
+			containers[i].Opens = deflateCalls(containers[i].Opens, func(open v1beta1.OpenCalls) string {
+				return strings.Join(append([]string{open.Path}, open.Flags...), "\x00")
+			})
+		}
+	}
+}
+
+// deflateStrings returns the sorted unique strings
+func deflateStrings(in []string) []string {
+	if in == nil {
+		return nil
+	}
+	out := make([]string, 0, len(in))
+	seen := map[string]bool{}
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// deflateCalls returns the calls with a unique key, in their order
+func deflateCalls[T any](in []T, key func(T) string) []T {
+	if in == nil {
+		return nil
+	}
+	out := make([]T, 0, len(in))
+	seen := map[string]bool{}
+	for _, call := range in {
+		if k := key(call); !seen[k] {
+			seen[k] = true
+			out = append(out, call)
+		}
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMemoryStorageClientApplicationProfile(t *testing.T) {
+	sc := NewMemoryStorageClient()
+
+	_, err := sc.GetApplicationProfile("default", "pod-nginx")
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.True(t, apierrors.IsNotFound(sc.PatchApplicationProfile("pod-nginx", "default", []byte(`[]`), nil)))
+
+	require.NoError(t, sc.CreateApplicationProfile(&v1beta1.ApplicationProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-nginx"},
+		Spec: v1beta1.ApplicationProfileSpec{
+			Containers: []v1beta1.ApplicationProfileContainer{{Name: "nginx", Execs: []v1beta1.ExecCalls{}, Syscalls: []string{"read"}}},
+		},
+	}, "default"))
+
+	// the patched entries are deflated
+	patch := `[{"op":"add","path":"/spec/containers/0/syscalls/-","value":"read"},
+		{"op":"add","path":"/spec/containers/0/syscalls/-","value":"accept4"},
+		{"op":"add","path":"/spec/containers/0/execs/-","value":{"path":"/usr/sbin/nginx","args":["-g"]}},
+		{"op":"add","path":"/spec/containers/0/execs/-","value":{"path":"/usr/sbin/nginx","args":["-g"]}}]`
+	require.NoError(t, sc.PatchApplicationProfile("pod-nginx", "default", []byte(patch), nil))
+	profile, err := sc.GetApplicationProfile("default", "pod-nginx")
+	require.NoError(t, err)
+	assert.Equal(t, "default", profile.Namespace)
+	assert.Equal(t, []string{"accept4", "read"}, profile.Spec.Containers[0].Syscalls)
+	assert.Equal(t, []v1beta1.ExecCalls{{Path: "/usr/sbin/nginx", Args: []string{"-g"}}}, profile.Spec.Containers[0].Execs)
+
+	// the invalid patches are rejected and the profile is unchanged
+	err = sc.PatchApplicationProfile("pod-nginx", "default", []byte(`[{"op":"replace","path":"/spec/containers/1","value":{}}]`), nil)
+	assert.True(t, apierrors.IsBadRequest(err))
+	assert.Len(t, sc.ApplicationProfiles(), 1)
+	assert.Len(t, sc.ApplicationProfiles()[0].Spec.Containers, 1)
+}
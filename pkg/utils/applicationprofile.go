@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"sort"
+	"strings"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
@@ -123,3 +126,142 @@ func GetApplicationProfileContainer(object *v1beta1.ApplicationProfile, containe
 	}
 	return nil
 }
+
+// The activities of the containers the container profile has no field for, like the credential transitions, are
+// recorded in annotations of the application profile. Each activity is an annotation so the activities are added to
+// the profile by patches like the other container activities.
+const (
+	CredentialTransitionActivity = "credential-transition"
+	BPFActivity                  = "bpf"
+	FileModificationActivity     = "file-modification"
+)
+
+// MaxActivitiesAnnotationsBytes caps the size of the annotations of the activities of a container, Kubernetes limits
+// the annotations of an object to 256KiB in total
+const MaxActivitiesAnnotationsBytes = 32 * 1024
+
+// ActivitiesAnnotationsBytes returns the size of the annotations of activities of a container
+func ActivitiesAnnotationsBytes(kind string, activities []string, containerType string, containerIndex int) int {
+	size := 0
+	for _, activity := range activities {
+		size += len(ActivityMetadataKey(kind, activity, containerType, containerIndex)) + len(activity)
+	}
+	return size
+}
+
+// LimitActivities returns the activities whose annotations fit in the bytes left to a container with the bytes left
+// after them, the other activities are dropped
+func LimitActivities(kind string, activities []string, left int, containerType string, containerIndex int) ([]string, int) {
+	sort.Strings(activities)
+	var kept []string
+	for _, activity := range activities {
+		size := ActivitiesAnnotationsBytes(kind, []string{activity}, containerType, containerIndex)
+		if size > left {
+			continue
+		}
+		kept = append(kept, activity)
+		left -= size
+	}
+	return kept, left
+}
+
+func activitiesMetadataPrefix(kind, containerType string, containerIndex int) string {
+	return fmt.Sprintf("kubescape.io/%s.%s.%d.", kind, containerType, containerIndex)
+}
+
+// ActivityMetadataKey returns the annotation of an activity of a container, the activity is hashed to keep the
+// annotation name valid
+func ActivityMetadataKey(kind, activity, containerType string, containerIndex int) string {
+	hash := sha256.Sum256([]byte(activity))
+	return activitiesMetadataPrefix(kind, containerType, containerIndex) + hex.EncodeToString(hash[:8])
+}
+
+func CreateActivitiesPatchOperations(kind string, activities []string, containerType string, containerIndex int) []PatchOperation {
+	var profileOperations []PatchOperation
+	sort.Strings(activities)
+	for _, activity := range activities {
+		profileOperations = append(profileOperations, PatchOperation{
+			Op:    "add",
+			Path:  "/metadata/annotations/" + EscapeJSONPointerElement(ActivityMetadataKey(kind, activity, containerType, containerIndex)),
+			Value: activity,
+		})
+	}
+	return profileOperations
+}
+
+// GetActivities returns the activities of a kind recorded in the application profile for a container
+func GetActivities(object *v1beta1.ApplicationProfile, containerName, kind string) []string {
+	containerType, containerIndex, ok := findApplicationProfileContainer(object, containerName)
+	if !ok {
+		return nil
+	}
+	prefix := activitiesMetadataPrefix(kind, containerType.String(), containerIndex)
+	var activities []string
+	for key, activity := range object.Annotations {
+		if strings.HasPrefix(key, prefix) {
+			activities = append(activities, activity)
+		}
+	}
+	sort.Strings(activities)
+	return activities
+}
+
+// GetActivityMetadataKey returns the annotation of an activity of a container of the application profile, or an
+// empty string if the profile has no such container
+func GetActivityMetadataKey(object *v1beta1.ApplicationProfile, containerName, kind, activity string) string {
+	containerType, containerIndex, ok := findApplicationProfileContainer(object, containerName)
+	if !ok {
+		return ""
+	}
+	return ActivityMetadataKey(kind, activity, containerType.String(), containerIndex)
+}
+
+func findApplicationProfileContainer(object *v1beta1.ApplicationProfile, containerName string) (ContainerType, int, bool) {
+	if object == nil {
+		return Unknown, 0, false
+	}
+	for containerType, containers := range map[ContainerType][]v1beta1.ApplicationProfileContainer{
+		Container:          object.Spec.Containers,
+		InitContainer:      object.Spec.InitContainers,
+		EphemeralContainer: object.Spec.EphemeralContainers,
+	} {
+		for i := range containers {
+			if containers[i].Name == containerName {
+				return containerType, i, true
+			}
+		}
+	}
+	return Unknown, 0, false
+}
+
+// learnedArchitectureMetadataPrefix prefixes the annotations of the architectures of the nodes the syscalls of an
+// application profile were learned on, there is an annotation per architecture so the nodes of a multi-architecture
+// cluster record theirs by patches
+const learnedArchitectureMetadataPrefix = "kubescape.io/learned-architecture."
+
+// AppendLearnedArchitecturePatchOperations records the Go architecture of the node updating an application profile.
+// The annotations must exist.
+func AppendLearnedArchitecturePatchOperations(existingPatch []PatchOperation, arch string) []PatchOperation {
+	return append(existingPatch, PatchOperation{
+		Op:    "add",
+		Path:  "/metadata/annotations/" + EscapeJSONPointerElement(learnedArchitectureMetadataPrefix+arch),
+		Value: arch,
+	})
+}
+
+// SetLearnedArchitectureAnnotation records the Go architecture of the node creating an application profile
+func SetLearnedArchitectureAnnotation(annotations map[string]string, arch string) {
+	annotations[learnedArchitectureMetadataPrefix+arch] = arch
+}
+
+// GetLearnedArchitectures returns the sorted Go architectures the syscalls of an application profile were learned on
+func GetLearnedArchitectures(annotations map[string]string) []string {
+	var archs []string
+	for key, arch := range annotations {
+		if strings.HasPrefix(key, learnedArchitectureMetadataPrefix) {
+			archs = append(archs, arch)
+		}
+	}
+	sort.Strings(archs)
+	return archs
+}
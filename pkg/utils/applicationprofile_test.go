@@ -7,6 +7,7 @@ import (
 	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func Test_EnrichApplicationProfileContainer(t *testing.T) {
@@ -71,3 +72,69 @@ func Test_EnrichApplicationProfileContainer(t *testing.T) {
 	assert.Equal(t, 7, len(existingContainer.Syscalls))
 	assert.Equal(t, 1, len(existingContainer.Opens))
 }
+
+func Test_Activities(t *testing.T) {
+	operations := CreateActivitiesPatchOperations(CredentialTransitionActivity, []string{"uid:0->1000", "caps:+NET_RAW"}, "initContainers", 1)
+	assert.Len(t, operations, 2)
+	assert.Equal(t, "add", operations[0].Op)
+	assert.Equal(t, "caps:+NET_RAW", operations[0].Value)
+	key := ActivityMetadataKey(CredentialTransitionActivity, "caps:+NET_RAW", "initContainers", 1)
+	assert.Regexp(t, `^kubescape\.io/credential-transition\.initContainers\.1\.[0-9a-f]{16}$`, key)
+	assert.Equal(t, "/metadata/annotations/"+EscapeJSONPointerElement(key), operations[0].Path)
+
+	applicationProfile := &v1beta1.ApplicationProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				ActivityMetadataKey(CredentialTransitionActivity, "uid:0->1000", "initContainers", 1):   "uid:0->1000",
+				ActivityMetadataKey(CredentialTransitionActivity, "caps:+NET_RAW", "initContainers", 1): "caps:+NET_RAW",
+				ActivityMetadataKey(CredentialTransitionActivity, "gid:0->101", "initContainers", 0):    "gid:0->101",
+				ActivityMetadataKey(CredentialTransitionActivity, "gid:0->102", "containers", 1):        "gid:0->102",
+				ActivityMetadataKey(BPFActivity, "bpf:PROG_LOAD:Kprobe", "initContainers", 1):           "bpf:PROG_LOAD:Kprobe",
+			},
+		},
+		Spec: v1beta1.ApplicationProfileSpec{
+			Containers:     []v1beta1.ApplicationProfileContainer{{Name: "server"}, {Name: "sidecar"}},
+			InitContainers: []v1beta1.ApplicationProfileContainer{{Name: "init"}, {Name: "setup"}},
+		},
+	}
+	assert.Equal(t, []string{"caps:+NET_RAW", "uid:0->1000"}, GetActivities(applicationProfile, "setup", CredentialTransitionActivity))
+	assert.Equal(t, []string{"gid:0->102"}, GetActivities(applicationProfile, "sidecar", CredentialTransitionActivity))
+	assert.Empty(t, GetActivities(applicationProfile, "server", CredentialTransitionActivity))
+	assert.Empty(t, GetActivities(applicationProfile, "missing", CredentialTransitionActivity))
+	assert.Empty(t, GetActivities(nil, "setup", CredentialTransitionActivity))
+	assert.Equal(t, []string{"bpf:PROG_LOAD:Kprobe"}, GetActivities(applicationProfile, "setup", BPFActivity))
+	assert.Equal(t, ActivityMetadataKey(CredentialTransitionActivity, "gid:0->1", "initContainers", 1), GetActivityMetadataKey(applicationProfile, "setup", CredentialTransitionActivity, "gid:0->1"))
+	assert.Empty(t, GetActivityMetadataKey(applicationProfile, "missing", CredentialTransitionActivity, "gid:0->1"))
+	// the annotation names are valid names of the kubernetes API
+	for key := range applicationProfile.Annotations {
+		assert.Empty(t, validation.IsQualifiedName(key))
+	}
+}
+
+func Test_LimitActivities(t *testing.T) {
+	size := ActivitiesAnnotationsBytes(CredentialTransitionActivity, []string{"uid:0->1000"}, "containers", 0)
+	assert.Equal(t, len(ActivityMetadataKey(CredentialTransitionActivity, "uid:0->1000", "containers", 0))+len("uid:0->1000"), size)
+
+	kept, left := LimitActivities(CredentialTransitionActivity, []string{"uid:0->1000", "uid:0->1001"}, 2*size, "containers", 0)
+	assert.Equal(t, []string{"uid:0->1000", "uid:0->1001"}, kept)
+	assert.Equal(t, 0, left)
+
+	// the activities over the bytes left are dropped, the shorter ones may still fit
+	kept, left = LimitActivities(CredentialTransitionActivity, []string{"uid:0->1000", "suid:/usr/bin/passwd", "uid:0->1001"}, size+5, "containers", 0)
+	assert.Equal(t, []string{"uid:0->1000"}, kept)
+	assert.Equal(t, 5, left)
+
+	kept, left = LimitActivities(CredentialTransitionActivity, []string{"uid:0->1000"}, 0, "containers", 0)
+	assert.Empty(t, kept)
+	assert.Equal(t, 0, left)
+}
+
+func Test_LearnedArchitectures(t *testing.T) {
+	annotations := map[string]string{"kubescape.io/status": "completed"}
+	assert.Empty(t, GetLearnedArchitectures(annotations))
+	SetLearnedArchitectureAnnotation(annotations, "arm64")
+	operations := AppendLearnedArchitecturePatchOperations(nil, "amd64")
+	assert.Equal(t, []PatchOperation{{Op: "add", Path: "/metadata/annotations/kubescape.io~1learned-architecture.amd64", Value: "amd64"}}, operations)
+	annotations["kubescape.io/learned-architecture.amd64"] = "amd64"
+	assert.Equal(t, []string{"amd64", "arm64"}, GetLearnedArchitectures(annotations))
+}
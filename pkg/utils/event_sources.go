@@ -0,0 +1,157 @@
+package utils
+
+import (
+	tracerbpftype "node-agent/pkg/ebpf/gadgets/bpf/types"
+	tracercredstype "node-agent/pkg/ebpf/gadgets/creds/types"
+	tracerescapetype "node-agent/pkg/ebpf/gadgets/escape/types"
+	tracerfilemodtype "node-agent/pkg/ebpf/gadgets/filemod/types"
+	tracerptracetype "node-agent/pkg/ebpf/gadgets/ptrace/types"
+
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// EventSource is a gadget of the agent tracing syscalls, like ptrace or escape. The events of the sources share their
+// plumbing from the tracers to the rules: worker pool, queue, counter, recording, report to the rule manager, CEL
+// fields, exceptions and correlations. Only the rules handle the events of a source by their type.
+type EventSource struct {
+	EventType EventType
+	// NewEvent returns a pointer to an empty event of the source
+	NewEvent func() interface{}
+	// Describe returns the details of a pointer to an event of the source, false for the other events
+	Describe func(event interface{}) (*SourceEventDetails, bool)
+}
+
+// SourceEventDetails are the details shared by the events of the sources
+type SourceEventDetails struct {
+	Event   *eventtypes.Event
+	Process ProcessDetails
+	Syscall string
+	// Fields are the fields specific to the source, by their names in the CEL rules
+	Fields map[string]any
+	// Path is the file the event refers to, if any
+	Path string
+	// Identity are the values identifying the repeats of the event, like its syscall and its arguments
+	Identity []string
+}
+
+func newEventSource[T any](eventType EventType, describe func(event *T) *SourceEventDetails) EventSource {
+	return EventSource{
+		EventType: eventType,
+		NewEvent: func() interface{} {
+			return new(T)
+		},
+		Describe: func(event interface{}) (*SourceEventDetails, bool) {
+			e, ok := event.(*T)
+			if !ok {
+				return nil, false
+			}
+			return describe(e), true
+		},
+	}
+}
+
+// EventSources are the gadgets of the agent tracing syscalls, by their event type
+var EventSources = map[EventType]EventSource{
+	PtraceEventType: newEventSource(PtraceEventType, func(e *tracerptracetype.Event) *SourceEventDetails {
+		return &SourceEventDetails{
+			Event:   &e.Event,
+			Process: ProcessDetails{Pid: e.Pid, Ppid: e.PPid, Comm: e.Comm, Uid: e.Uid, Gid: e.Gid},
+			Syscall: e.Syscall,
+			Fields: map[string]any{
+				"request":   e.Request,
+				"targetPid": int64(e.TargetPid),
+				"path":      e.Path,
+			},
+			Path:     e.Path,
+			Identity: []string{e.Comm, e.Syscall, e.Request},
+		}
+	}),
+	EscapeEventType: newEventSource(EscapeEventType, func(e *tracerescapetype.Event) *SourceEventDetails {
+		path := e.Path
+		if path == "" {
+			path = e.Target
+		}
+		return &SourceEventDetails{
+			Event:   &e.Event,
+			Process: ProcessDetails{Pid: e.Pid, Ppid: e.PPid, Comm: e.Comm, Uid: e.Uid, Gid: e.Gid},
+			Syscall: e.Syscall,
+			Fields: map[string]any{
+				"source":          e.Source,
+				"target":          e.Target,
+				"fsType":          e.FsType,
+				"flags":           int64(e.Flags),
+				"joinedNamespace": e.Namespace,
+				"hostNamespace":   e.HostNamespace,
+				"path":            e.Path,
+			},
+			Path:     path,
+			Identity: []string{e.Comm, e.Syscall, e.Source, e.Target, e.Namespace, e.Path},
+		}
+	}),
+	CredsEventType: newEventSource(CredsEventType, func(e *tracercredstype.Event) *SourceEventDetails {
+		return &SourceEventDetails{
+			Event:   &e.Event,
+			Process: ProcessDetails{Pid: e.Pid, Ppid: e.PPid, Comm: e.Comm, Uid: e.Uid, Gid: e.Gid},
+			Syscall: e.Syscall,
+			Fields: map[string]any{
+				"oldId":        int64(e.OldId),
+				"newId":        int64(e.NewId),
+				"capabilities": e.Capabilities,
+				"path":         e.Path,
+				"transition":   e.Transition,
+			},
+			Path:     e.Path,
+			Identity: []string{e.Comm, e.Transition},
+		}
+	}),
+	BPFEventType: newEventSource(BPFEventType, func(e *tracerbpftype.Event) *SourceEventDetails {
+		// the attach point of an uprobe is a file
+		var path string
+		if e.PerfType == "uprobe" {
+			path = e.AttachPoint
+		}
+		return &SourceEventDetails{
+			Event:   &e.Event,
+			Process: ProcessDetails{Pid: e.Pid, Ppid: e.PPid, Comm: e.Comm, Uid: e.Uid, Gid: e.Gid},
+			Syscall: e.Syscall,
+			Fields: map[string]any{
+				"command":     e.Command,
+				"programType": e.ProgramType,
+				"programName": e.ProgramName,
+				"mapType":     e.MapType,
+				"mapName":     e.MapName,
+				"attachType":  e.AttachType,
+				"perfType":    e.PerfType,
+				"attachPoint": e.AttachPoint,
+				"usage":       e.Usage,
+			},
+			Path:     path,
+			Identity: []string{e.Comm, e.Usage, e.ProgramName, e.MapName},
+		}
+	}),
+	FileModEventType: newEventSource(FileModEventType, func(e *tracerfilemodtype.Event) *SourceEventDetails {
+		return &SourceEventDetails{
+			Event:   &e.Event,
+			Process: ProcessDetails{Pid: e.Pid, Ppid: e.PPid, Comm: e.Comm, Uid: e.Uid, Gid: e.Gid},
+			Syscall: e.Syscall,
+			Fields: map[string]any{
+				"operation": e.Operation,
+				"path":      e.Path,
+				"source":    e.Source,
+				"mode":      int64(e.Mode),
+				"length":    int64(e.Length),
+			},
+			Path:     e.Path,
+			Identity: []string{e.Comm, e.Operation, e.Path, e.Source},
+		}
+	}),
+}
+
+// DescribeSourceEvent returns the details of an event of a source, false for the events of the other types
+func DescribeSourceEvent(eventType EventType, event interface{}) (*SourceEventDetails, bool) {
+	source, ok := EventSources[eventType]
+	if !ok {
+		return nil, false
+	}
+	return source.Describe(event)
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	tracerrandomxtype "node-agent/pkg/ebpf/gadgets/randomx/types"
 
 	tracercapabilitiestype "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
@@ -28,9 +29,48 @@ const (
 	NetworkEventType
 	SyscallEventType
 	RandomXEventType
+	PtraceEventType
+	EscapeEventType
+	CredsEventType
+	BPFEventType
+	FileModEventType
 	AllEventType
 )
 
+var eventTypeNames = map[EventType]string{
+	ExecveEventType:       "exec",
+	OpenEventType:         "open",
+	CapabilitiesEventType: "capabilities",
+	DnsEventType:          "dns",
+	NetworkEventType:      "network",
+	SyscallEventType:      "syscall",
+	RandomXEventType:      "randomx",
+	PtraceEventType:       "ptrace",
+	EscapeEventType:       "escape",
+	CredsEventType:        "creds",
+	BPFEventType:          "bpf",
+	FileModEventType:      "filemod",
+	AllEventType:          "all",
+}
+
+// String returns the name used to refer to the event type in rule definitions
+func (e EventType) String() string {
+	if name, ok := eventTypeNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(e))
+}
+
+// EventTypeFromString converts an event type name (e.g. "exec") to its EventType
+func EventTypeFromString(name string) (EventType, error) {
+	for eventType, eventTypeName := range eventTypeNames {
+		if eventTypeName == name {
+			return eventType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown event type %q", name)
+}
+
 type ProcessDetails struct {
 	Pid  uint32
 	Ppid uint32
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// OpenPathWildcard replaces a whole segment of the path of an open in the profiles, it matches any segment
+const OpenPathWildcard = "*"
+
+var dynamicSegmentPatterns = []*regexp.Regexp{
+	// PIDs and timestamps
+	regexp.MustCompile(`^[0-9]+$`),
+	regexp.MustCompile(`[0-9]{6,}`),
+	// UUIDs
+	regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`),
+	// hashes and container IDs
+	regexp.MustCompile(`[0-9a-fA-F]{16,}`),
+}
+
+// isDynamicSegment reports whether a path segment looks generated, like a PID, UUID, hash or timestamp
+func isDynamicSegment(segment string) bool {
+	for _, pattern := range dynamicSegmentPatterns {
+		if pattern.MatchString(segment) {
+			return true
+		}
+	}
+	return false
+}
+
+// PathNormalizer collapses the high cardinality segments of the paths opened by a container, so the profile does not
+// hold a path per PID or temporary file. Once a directory has more than threshold distinct dynamic segments (see
+// isDynamicSegment), its dynamic segments are replaced by OpenPathWildcard, e.g. /proc/1234/status becomes
+// /proc/*/status.
+type PathNormalizer struct {
+	threshold       int
+	mutex           sync.Mutex
+	dynamicSegments map[string]mapset.Set[string] // key is the normalized directory
+	collapsed       mapset.Set[string]            // normalized directories whose dynamic segments are wildcards
+}
+
+// NewPathNormalizer creates a normalizer collapsing the directories with more than threshold dynamic segments, the
+// paths are not changed when threshold is not positive
+func NewPathNormalizer(threshold int) *PathNormalizer {
+	return &PathNormalizer{
+		threshold:       threshold,
+		dynamicSegments: make(map[string]mapset.Set[string]),
+		collapsed:       mapset.NewThreadUnsafeSet[string](),
+	}
+}
+
+// Normalize records the dynamic segments of path and returns it with the dynamic segments of the collapsed
+// directories replaced by wildcards
+func (n *PathNormalizer) Normalize(path string) string {
+	return n.normalize(path, true)
+}
+
+// Collapse returns path with the dynamic segments of the collapsed directories replaced by wildcards, without
+// recording it. It collapses the paths normalized before their directory was collapsed.
+func (n *PathNormalizer) Collapse(path string) string {
+	return n.normalize(path, false)
+}
+
+func (n *PathNormalizer) normalize(path string, record bool) string {
+	if n == nil || n.threshold <= 0 || !strings.HasPrefix(path, "/") {
+		return path
+	}
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		if !isDynamicSegment(segments[i]) {
+			continue
+		}
+		// the directory is normalized, so the directories of all the PIDs share their collapsed subdirectories
+		dir := strings.Join(segments[:i], "/")
+		if n.collapsed.Contains(dir) {
+			segments[i] = OpenPathWildcard
+			continue
+		}
+		if !record {
+			continue
+		}
+		seen, ok := n.dynamicSegments[dir]
+		if !ok {
+			seen = mapset.NewThreadUnsafeSet[string]()
+			n.dynamicSegments[dir] = seen
+		}
+		seen.Add(segments[i])
+		if seen.Cardinality() > n.threshold {
+			n.collapsed.Add(dir)
+			delete(n.dynamicSegments, dir)
+			segments[i] = OpenPathWildcard
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// MatchOpenPath reports whether path matches the path of an open of a profile, whose segments may be wildcards
+func MatchOpenPath(pattern, path string) bool {
+	if !strings.Contains(pattern, OpenPathWildcard) {
+		return pattern == path
+	}
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(path, "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != OpenPathWildcard && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
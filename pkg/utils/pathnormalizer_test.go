@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDynamicSegment(t *testing.T) {
+	for _, segment := range []string{"1234", "1700000000", "session-1700000000.log", "3f2b8c1e-9d4a-4b7e-8f1a-2c3d4e5f6a7b", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", "..2024_01_15_10_22_33.123456789"} {
+		assert.True(t, isDynamicSegment(segment), segment)
+	}
+	for _, segment := range []string{"proc", "status", "libc.so.6", "nginx.conf", "python3.11", "fd"} {
+		assert.False(t, isDynamicSegment(segment), segment)
+	}
+}
+
+func TestPathNormalizer(t *testing.T) {
+	n := NewPathNormalizer(2)
+	assert.Equal(t, "/proc/1/status", n.Normalize("/proc/1/status"))
+	assert.Equal(t, "/proc/2/status", n.Normalize("/proc/2/status"))
+	assert.Equal(t, "/proc/2/fd/3", n.Normalize("/proc/2/fd/3"))
+	// the third PID collapses /proc
+	assert.Equal(t, "/proc/*/status", n.Normalize("/proc/3/status"))
+	assert.Equal(t, "/proc/*/cmdline", n.Normalize("/proc/4/cmdline"))
+	// only the dynamic segments are collapsed
+	assert.Equal(t, "/proc/self/status", n.Normalize("/proc/self/status"))
+	// the paths normalized before the collapse are collapsed
+	assert.Equal(t, "/proc/*/status", n.Collapse("/proc/1/status"))
+	assert.Equal(t, "/proc/*/fd/3", n.Collapse("/proc/2/fd/3"))
+	// collapsing does not record the paths
+	assert.Equal(t, "/tmp/1/a", n.Collapse("/tmp/1/a"))
+	assert.Equal(t, "/tmp/2/a", n.Normalize("/tmp/2/a"))
+	assert.Equal(t, "/tmp/3/a", n.Normalize("/tmp/3/a"))
+
+	for i := 0; i < 3; i++ {
+		n.Normalize(fmt.Sprintf("/proc/%d/fd/%d", i, i))
+	}
+	assert.Equal(t, "/proc/*/fd/*", n.Normalize("/proc/10/fd/10"))
+	assert.Equal(t, "etc/hosts", n.Normalize("etc/hosts"))
+}
+
+func TestPathNormalizerDisabled(t *testing.T) {
+	n := NewPathNormalizer(0)
+	for i := 0; i < 10; i++ {
+		path := fmt.Sprintf("/proc/%d/status", i)
+		assert.Equal(t, path, n.Normalize(path))
+	}
+	var nilNormalizer *PathNormalizer
+	assert.Equal(t, "/proc/1/status", nilNormalizer.Normalize("/proc/1/status"))
+}
+
+func TestMatchOpenPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "/etc/passwd", path: "/etc/passwd", want: true},
+		{pattern: "/etc/passwd", path: "/etc/shadow", want: false},
+		{pattern: "/proc/*/status", path: "/proc/1234/status", want: true},
+		{pattern: "/proc/*/status", path: "/proc/1234/task/1/status", want: false},
+		{pattern: "/proc/*/status", path: "/proc/1234/cmdline", want: false},
+		{pattern: "/proc/*/fd/*", path: "/proc/1/fd/3", want: true},
+		{pattern: "/tmp/a*", path: "/tmp/ab", want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchOpenPath(tt.pattern, tt.path), "%s %s", tt.pattern, tt.path)
+	}
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	helpersv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1/helpers"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ProfileMode is the lifecycle phase of an application profile, recorded in the ProfileModeMetadataKey annotation.
+// The anomaly rules only use the profiles that are ready or locked.
+type ProfileMode string
+
+const (
+	// ProfileModeLearning is the mode of a new profile, until it is completed
+	ProfileModeLearning ProfileMode = "learning"
+	// ProfileModeReady is the mode of a completed profile, it is relearned when its workload rolls out
+	ProfileModeReady ProfileMode = "ready"
+	// ProfileModeLocked is set by users on a profile that must not change, it is only relearned on request
+	ProfileModeLocked ProfileMode = "locked"
+	// ProfileModeRelearning is the mode of a profile learning the activities of a new revision or image of its
+	// workload, the anomaly rules do not use it until the relearn grace period is over
+	ProfileModeRelearning ProfileMode = "relearning"
+)
+
+const (
+	ProfileModeMetadataKey = "kubescape.io/profile-mode"
+	// ProfileRevisionMetadataKey is the revision of the workload the profile was learned on
+	ProfileRevisionMetadataKey = "kubescape.io/profile-revision"
+	// ProfileRelearnStartMetadataKey is the RFC 3339 time the profile started relearning
+	ProfileRelearnStartMetadataKey = "kubescape.io/profile-relearn-start"
+	// ProfileRelearnRequestMetadataKey is the last relearn request of the workload the profile was relearned on
+	ProfileRelearnRequestMetadataKey = "kubescape.io/profile-relearn-request"
+	// RelearnMetadataKey is set by users on a workload to relearn its profile, each new value is a new request
+	RelearnMetadataKey = "kubescape.io/relearn"
+)
+
+// The labels of the pods holding the revision of their workload
+const (
+	podTemplateHashLabel        = "pod-template-hash"
+	controllerRevisionHashLabel = "controller-revision-hash"
+)
+
+// GetRevision returns the revision of the workload of a pod from its labels, the pod template hash of the pods of
+// ReplicaSets or the controller revision hash of the pods of StatefulSets and DaemonSets
+func GetRevision(podLabels map[string]string) string {
+	if revision := podLabels[podTemplateHashLabel]; revision != "" {
+		return revision
+	}
+	return podLabels[controllerRevisionHashLabel]
+}
+
+// ProfileImageIDMetadataKey returns the annotation of the image ID a container of the profile was learned on
+func ProfileImageIDMetadataKey(containerType string, containerIndex int) string {
+	return fmt.Sprintf("kubescape.io/profile-image-id.%s.%d", containerType, containerIndex)
+}
+
+// ImageDigest returns the digest of an image ID, the image IDs of the pod statuses are prefixed with the repository the
+// image was pulled from, which may differ between the pods of the same image
+func ImageDigest(imageID string) string {
+	return imageID[strings.LastIndex(imageID, "@")+1:]
+}
+
+// GetContainerImageID returns the image ID of a container from the status of its pod, the image IDs of the profiles
+// are recorded and compared from the pod statuses only. ok is false when the pod has no status for the container yet.
+func GetContainerImageID(status *corev1.PodStatus, containerType ContainerType, containerName string) (imageID string, ok bool) {
+	var statuses []corev1.ContainerStatus
+	switch containerType {
+	case Container:
+		statuses = status.ContainerStatuses
+	case InitContainer:
+		statuses = status.InitContainerStatuses
+	case EphemeralContainer:
+		statuses = status.EphemeralContainerStatuses
+	}
+	for i := range statuses {
+		if statuses[i].Name == containerName {
+			return statuses[i].ImageID, true
+		}
+	}
+	return "", false
+}
+
+// GetProfileMode returns the mode of a profile, the profiles created before the modes are ready when they are completed.
+// A relearning profile is ready once the grace period since the start of its relearning is over.
+func GetProfileMode(object *v1beta1.ApplicationProfile, now time.Time, gracePeriod time.Duration) ProfileMode {
+	mode := ProfileMode(object.Annotations[ProfileModeMetadataKey])
+	switch mode {
+	case ProfileModeLearning, ProfileModeReady, ProfileModeLocked:
+		return mode
+	case ProfileModeRelearning:
+		start, err := time.Parse(time.RFC3339, object.Annotations[ProfileRelearnStartMetadataKey])
+		if err == nil && now.Sub(start) >= gracePeriod {
+			return ProfileModeReady
+		}
+		return ProfileModeRelearning
+	}
+	if object.Annotations[helpersv1.StatusMetadataKey] == helpersv1.Completed {
+		return ProfileModeReady
+	}
+	return ProfileModeLearning
+}
+
+// ProfileDrift returns why a container of a new revision or image of the workload differs from the profile, or an
+// empty string when the profile was learned on them. The profiles without revision or image ID never drift.
+func ProfileDrift(object *v1beta1.ApplicationProfile, revision string, containerType string, containerIndex int, imageID string) string {
+	if profileRevision := object.Annotations[ProfileRevisionMetadataKey]; profileRevision != "" && revision != "" && profileRevision != revision {
+		return fmt.Sprintf("revision %s was learned, the container runs revision %s", profileRevision, revision)
+	}
+	if profileImageID := object.Annotations[ProfileImageIDMetadataKey(containerType, containerIndex)]; profileImageID != "" && imageID != "" && profileImageID != imageID {
+		return fmt.Sprintf("image %s was learned, the container runs image %s", profileImageID, imageID)
+	}
+	return ""
+}
+
+// CreateProfileModePatchOperations sets the mode of a profile, the annotations must exist
+func CreateProfileModePatchOperations(mode ProfileMode) []PatchOperation {
+	return []PatchOperation{{
+		Op:    "add",
+		Path:  "/metadata/annotations/" + EscapeJSONPointerElement(ProfileModeMetadataKey),
+		Value: string(mode),
+	}}
+}
+
+// CreateRelearnPatchOperations starts relearning a profile on the revision and image of a container, the execs, opens
+// and syscalls the profile holds for the container are reset so it only keeps the ones of the new revision. The
+// annotations must exist.
+func CreateRelearnPatchOperations(object *v1beta1.ApplicationProfile, now time.Time, revision, relearnRequest string, containerType ContainerType, containerIndex int, imageID string) []PatchOperation {
+	operations := append(CreateProfileModePatchOperations(ProfileModeRelearning), PatchOperation{
+		Op:    "add",
+		Path:  "/metadata/annotations/" + EscapeJSONPointerElement(ProfileRelearnStartMetadataKey),
+		Value: now.UTC().Format(time.RFC3339),
+	})
+	operations = append(operations, CreateProfileRevisionPatchOperations(nil, revision, relearnRequest, containerType.String(), containerIndex, imageID)...)
+	if GetApplicationProfileContainer(object, containerType, containerIndex) == nil {
+		// the container is added to the profile when it is saved
+		return operations
+	}
+	for _, field := range []string{"execs", "opens", "syscalls"} {
+		operations = append(operations, PatchOperation{
+			Op:    "add",
+			Path:  fmt.Sprintf("/spec/%s/%d/%s", containerType, containerIndex, field),
+			Value: []string{},
+		})
+	}
+	return operations
+}
+
+// CreateProfileRevisionPatchOperations records the revision, relearn request and image a container learns the
+// profile on, the empty values and the values already in the annotations are not recorded and the annotations must exist
+func CreateProfileRevisionPatchOperations(annotations map[string]string, revision, relearnRequest, containerType string, containerIndex int, imageID string) []PatchOperation {
+	var operations []PatchOperation
+	for _, annotation := range profileRevisionAnnotations(revision, relearnRequest, containerType, containerIndex, imageID) {
+		if annotations[annotation[0]] != annotation[1] {
+			operations = append(operations, PatchOperation{
+				Op:    "add",
+				Path:  "/metadata/annotations/" + EscapeJSONPointerElement(annotation[0]),
+				Value: annotation[1],
+			})
+		}
+	}
+	return operations
+}
+
+// SetProfileRevisionAnnotations records the revision, relearn request and image a container learns a new profile on,
+// the empty values are not recorded
+func SetProfileRevisionAnnotations(annotations map[string]string, revision, relearnRequest, containerType string, containerIndex int, imageID string) {
+	for _, annotation := range profileRevisionAnnotations(revision, relearnRequest, containerType, containerIndex, imageID) {
+		annotations[annotation[0]] = annotation[1]
+	}
+}
+
+// profileRevisionAnnotations returns the non-empty revision annotations as key and value pairs, in a stable order
+func profileRevisionAnnotations(revision, relearnRequest, containerType string, containerIndex int, imageID string) [][2]string {
+	var annotations [][2]string
+	for _, annotation := range [][2]string{
+		{ProfileRevisionMetadataKey, revision},
+		{ProfileRelearnRequestMetadataKey, relearnRequest},
+		{ProfileImageIDMetadataKey(containerType, containerIndex), imageID},
+	} {
+		if annotation[1] != "" {
+			annotations = append(annotations, annotation)
+		}
+	}
+	return annotations
+}
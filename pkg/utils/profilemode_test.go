@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	helpersv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1/helpers"
+	"github.com/kubescape/storage/pkg/apis/softwarecomposition/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func profileWithAnnotations(annotations map[string]string) *v1beta1.ApplicationProfile {
+	return &v1beta1.ApplicationProfile{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+}
+
+func TestGetRevision(t *testing.T) {
+	assert.Equal(t, "77b4fdf86c", GetRevision(map[string]string{"pod-template-hash": "77b4fdf86c", "controller-revision-hash": "6d5b9c"}))
+	assert.Equal(t, "6d5b9c", GetRevision(map[string]string{"controller-revision-hash": "6d5b9c"}))
+	assert.Equal(t, "", GetRevision(nil))
+}
+
+func TestImageDigest(t *testing.T) {
+	assert.Equal(t, "sha256:abc", ImageDigest("docker.io/library/nginx@sha256:abc"))
+	assert.Equal(t, "sha256:abc", ImageDigest("sha256:abc"))
+}
+
+func TestGetProfileMode(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        ProfileMode
+	}{
+		{
+			name:        "learning",
+			annotations: map[string]string{ProfileModeMetadataKey: "learning", helpersv1.StatusMetadataKey: helpersv1.Completed},
+			want:        ProfileModeLearning,
+		},
+		{
+			name:        "locked",
+			annotations: map[string]string{ProfileModeMetadataKey: "locked"},
+			want:        ProfileModeLocked,
+		},
+		{
+			name:        "relearning within the grace period",
+			annotations: map[string]string{ProfileModeMetadataKey: "relearning", ProfileRelearnStartMetadataKey: "2024-05-01T11:50:00Z"},
+			want:        ProfileModeRelearning,
+		},
+		{
+			name:        "relearning after the grace period",
+			annotations: map[string]string{ProfileModeMetadataKey: "relearning", ProfileRelearnStartMetadataKey: "2024-05-01T11:30:00Z"},
+			want:        ProfileModeReady,
+		},
+		{
+			name:        "relearning without start",
+			annotations: map[string]string{ProfileModeMetadataKey: "relearning"},
+			want:        ProfileModeRelearning,
+		},
+		{
+			name:        "completed without mode",
+			annotations: map[string]string{helpersv1.StatusMetadataKey: helpersv1.Completed},
+			want:        ProfileModeReady,
+		},
+		{
+			name:        "initializing without mode",
+			annotations: map[string]string{helpersv1.StatusMetadataKey: helpersv1.Initializing},
+			want:        ProfileModeLearning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, GetProfileMode(profileWithAnnotations(tt.annotations), now, 15*time.Minute))
+		})
+	}
+}
+
+func TestProfileDrift(t *testing.T) {
+	profile := profileWithAnnotations(map[string]string{
+		ProfileRevisionMetadataKey:                 "77b4fdf86c",
+		ProfileImageIDMetadataKey("containers", 0): "sha256:abc",
+	})
+	assert.Empty(t, ProfileDrift(profile, "77b4fdf86c", "containers", 0, "sha256:abc"))
+	assert.NotEmpty(t, ProfileDrift(profile, "5f8c6d9b7", "containers", 0, "sha256:abc"))
+	assert.NotEmpty(t, ProfileDrift(profile, "77b4fdf86c", "containers", 0, "sha256:def"))
+	// the revision and image of the pods without them, and of the containers not recorded, never drift
+	assert.Empty(t, ProfileDrift(profile, "", "containers", 0, ""))
+	assert.Empty(t, ProfileDrift(profile, "77b4fdf86c", "containers", 1, "sha256:def"))
+	assert.Empty(t, ProfileDrift(profileWithAnnotations(nil), "5f8c6d9b7", "containers", 0, "sha256:def"))
+}
+
+func TestCreateRelearnPatchOperations(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	profile := profileWithAnnotations(nil)
+	operations := []PatchOperation{
+		{Op: "add", Path: "/metadata/annotations/kubescape.io~1profile-mode", Value: "relearning"},
+		{Op: "add", Path: "/metadata/annotations/kubescape.io~1profile-relearn-start", Value: "2024-05-01T12:00:00Z"},
+		{Op: "add", Path: "/metadata/annotations/kubescape.io~1profile-revision", Value: "5f8c6d9b7"},
+		{Op: "add", Path: "/metadata/annotations/kubescape.io~1profile-image-id.containers.1", Value: "sha256:def"},
+	}
+	// the container is not in the profile yet
+	assert.Equal(t, operations, CreateRelearnPatchOperations(profile, now, "5f8c6d9b7", "", Container, 1, "sha256:def"))
+
+	// the activities the container learned on the previous revision are reset
+	profile.Spec.Containers = []v1beta1.ApplicationProfileContainer{{Name: "server"}, {Name: "sidecar", Syscalls: []string{"read"}}}
+	assert.Equal(t, append(operations,
+		PatchOperation{Op: "add", Path: "/spec/containers/1/execs", Value: []string{}},
+		PatchOperation{Op: "add", Path: "/spec/containers/1/opens", Value: []string{}},
+		PatchOperation{Op: "add", Path: "/spec/containers/1/syscalls", Value: []string{}},
+	), CreateRelearnPatchOperations(profile, now, "5f8c6d9b7", "", Container, 1, "sha256:def"))
+}
+
+func TestGetContainerImageID(t *testing.T) {
+	status := &corev1.PodStatus{
+		ContainerStatuses:     []corev1.ContainerStatus{{Name: "server", ImageID: "docker.io/library/nginx@sha256:abc"}},
+		InitContainerStatuses: []corev1.ContainerStatus{{Name: "init", ImageID: "docker.io/library/busybox@sha256:def"}},
+	}
+	imageID, ok := GetContainerImageID(status, Container, "server")
+	assert.True(t, ok)
+	assert.Equal(t, "docker.io/library/nginx@sha256:abc", imageID)
+	imageID, ok = GetContainerImageID(status, InitContainer, "init")
+	assert.True(t, ok)
+	assert.Equal(t, "sha256:def", ImageDigest(imageID))
+	// the status of a container is only found with its type
+	_, ok = GetContainerImageID(status, Container, "init")
+	assert.False(t, ok)
+	_, ok = GetContainerImageID(status, EphemeralContainer, "debug")
+	assert.False(t, ok)
+}
+
+func TestCreateProfileRevisionPatchOperations(t *testing.T) {
+	annotations := map[string]string{ProfileRevisionMetadataKey: "77b4fdf86c"}
+	assert.Equal(t, []PatchOperation{
+		{Op: "add", Path: "/metadata/annotations/kubescape.io~1profile-relearn-request", Value: "1"},
+		{Op: "add", Path: "/metadata/annotations/kubescape.io~1profile-image-id.initContainers.0", Value: "sha256:abc"},
+	}, CreateProfileRevisionPatchOperations(annotations, "77b4fdf86c", "1", "initContainers", 0, "sha256:abc"))
+
+	SetProfileRevisionAnnotations(annotations, "5f8c6d9b7", "", "initContainers", 0, "sha256:abc")
+	assert.Equal(t, map[string]string{
+		ProfileRevisionMetadataKey:                     "5f8c6d9b7",
+		ProfileImageIDMetadataKey("initContainers", 0): "sha256:abc",
+	}, annotations)
+}
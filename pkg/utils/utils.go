@@ -100,6 +100,8 @@ type WatchedContainerData struct {
 	ImageID                                    string
 	Wlid                                       string
 	TemplateHash                               string
+	Revision                                   string
+	RelearnRequest                             string
 	K8sContainerID                             string
 	SBOMResourceVersion                        int
 	ContainerType                              ContainerType
@@ -314,6 +316,29 @@ func AppendStatusAnnotationPatchOperations(existingPatch []PatchOperation, watch
 	return existingPatch
 }
 
+// LastUpdatedNodeMetadataKey is the node that last updated a profile, the drift of the profile is only reported from it
+const LastUpdatedNodeMetadataKey = "kubescape.io/last-updated-node"
+
+// AppendLastUpdatedNodePatchOperations records the node updating a profile, nothing is recorded without a node name.
+// The annotations must exist.
+func AppendLastUpdatedNodePatchOperations(existingPatch []PatchOperation, nodeName string) []PatchOperation {
+	if nodeName == "" {
+		return existingPatch
+	}
+	return append(existingPatch, PatchOperation{
+		Op:    "add",
+		Path:  "/metadata/annotations/" + EscapeJSONPointerElement(LastUpdatedNodeMetadataKey),
+		Value: nodeName,
+	})
+}
+
+// SetLastUpdatedNodeAnnotation records the node creating a profile, nothing is recorded without a node name
+func SetLastUpdatedNodeAnnotation(annotations map[string]string, nodeName string) {
+	if nodeName != "" {
+		annotations[LastUpdatedNodeMetadataKey] = nodeName
+	}
+}
+
 func SetInMap(newExecMap *maps.SafeMap[string, mapset.Set[string]]) func(k string, v mapset.Set[string]) bool {
 	return func(k string, v mapset.Set[string]) bool {
 		if newExecMap.Has(k) {